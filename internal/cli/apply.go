@@ -0,0 +1,271 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/unixsocket"
+	"github.com/jaepetto/cron-exporter/pkg/util"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// envAdminAPIKey holds the admin API key used by "job apply"
+const envAdminAPIKey = "CRONMETRICS_ADMIN_API_KEY"
+
+var (
+	applyFile     string
+	applyDryRun   bool
+	applyServer   string
+	applyAdminKey string
+)
+
+func init() {
+	jobApplyCmd.Flags().StringVarP(&applyFile, "file", "f", "", `job spec in YAML or JSON, "-" reads standard input (required)`)
+	jobApplyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "show the changes without applying them")
+	jobApplyCmd.Flags().StringVar(&applyServer, "server", "", `server URL or "unix:/path" socket (defaults to the configured listen address)`)
+	jobApplyCmd.Flags().StringVar(&applyAdminKey, "admin-key", os.Getenv(envAdminAPIKey), "admin API key (default $"+envAdminAPIKey+", else the first configured admin key)")
+	addOutputFlags(jobApplyCmd)
+
+	if err := jobApplyCmd.MarkFlagRequired("file"); err != nil {
+		panic(fmt.Sprintf("Failed to mark file flag as required: %v", err))
+	}
+}
+
+// jobApplyCmd creates or patches a job from a spec file
+var jobApplyCmd = &cobra.Command{
+	Use:   "apply -f <file>",
+	Short: "Create or update a job from a spec file",
+	Long: `Send a job spec to the server, which creates the job identified by
+job_name and host, or patches the existing one: fields present in the spec
+replace the stored ones, omitted fields are kept. The server computes the
+changes, so --dry-run shows exactly what applying would do.
+
+The spec uses the field names of the REST API and of "job show -o yaml".
+automatic_failure_threshold accepts seconds or a duration such as 2h.`,
+	Example: `  cronmetrics job show 1 -o yaml > backup.yaml
+  cronmetrics job apply -f backup.yaml --dry-run
+  cronmetrics job apply -f backup.yaml`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runJobApply(); err != nil {
+			logrus.WithError(err).Fatal("failed to apply job")
+		}
+	},
+}
+
+// applyResponse is the server's answer to an apply, dry run or not
+type applyResponse struct {
+	Action string                 `json:"action"`
+	Diff   map[string]fieldChange `json:"diff"`
+	Job    *model.Job             `json:"job"`
+}
+
+// fieldChange is the old and new value of a job field
+type fieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+func runJobApply() error {
+	format, err := outputMode()
+	if err != nil {
+		return err
+	}
+
+	spec, err := readJobSpec(applyFile)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	serverURL := applyServer
+	if serverURL == "" {
+		serverURL = configuredServerURL(cfg) + cfg.Server.BasePath
+	}
+	adminKey := applyAdminKey
+	if adminKey == "" && len(cfg.Security.AdminAPIKeys) > 0 {
+		adminKey = cfg.Security.AdminAPIKeys[0]
+	}
+
+	client, baseURL := unixsocket.Client(serverURL, &http.Client{Timeout: 30 * time.Second})
+	endpoint := baseURL + "/api/job"
+	if applyDryRun {
+		endpoint += "?dry_run=true"
+	}
+	req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewReader(spec))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if adminKey != "" {
+		req.Header.Set("Authorization", "Bearer "+adminKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("server answered %s: %s", resp.Status, apiErr.Error)
+		}
+		return fmt.Errorf("server answered %s", resp.Status)
+	}
+
+	result := &applyResponse{}
+	if applyDryRun {
+		err = json.Unmarshal(body, result)
+	} else {
+		result.Action = resp.Header.Get("X-Reconcile-Action")
+		err = json.Unmarshal(body, &result.Job)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid server response: %w", err)
+	}
+
+	switch {
+	case outputQuiet:
+		printIDs([]int{result.Job.ID})
+		return nil
+	case format == formatJSON || format == formatYAML:
+		if applyDryRun {
+			return printStructured(format, result)
+		}
+		return printStructured(format, result.Job)
+	}
+
+	job := result.Job
+	if !applyDryRun {
+		fmt.Printf("Job ID %d ('%s@%s') %s\n", job.ID, job.Name, job.Host, result.Action)
+		return nil
+	}
+	if result.Action == "unchanged" {
+		fmt.Printf("Job '%s@%s' is up to date\n", job.Name, job.Host)
+		return nil
+	}
+	fmt.Printf("Job '%s@%s' would be %s:\n", job.Name, job.Host, result.Action)
+	printFieldChanges(result.Diff)
+	return nil
+}
+
+// readJobSpec reads a YAML or JSON job spec and returns it as the JSON the
+// API expects
+func readJobSpec(path string) ([]byte, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path) // #nosec G304 -- reading the given spec is the point
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job spec: %w", err)
+	}
+
+	// JSON is valid YAML, so one decoder reads both
+	var spec map[string]any
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid job spec: %w", err)
+	}
+	if spec == nil {
+		return nil, fmt.Errorf("job spec %s is empty", path)
+	}
+
+	// Label values are strings, even those YAML reads as numbers or booleans
+	if labels, ok := spec["labels"].(map[string]any); ok {
+		for key, value := range labels {
+			labels[key] = fmt.Sprint(value)
+		}
+	}
+
+	if threshold, ok := spec["automatic_failure_threshold"].(string); ok {
+		seconds, err := util.ParseSeconds(threshold)
+		if err != nil {
+			return nil, fmt.Errorf("invalid automatic_failure_threshold: %w", err)
+		}
+		spec["automatic_failure_threshold"] = seconds
+	}
+
+	// Fields the server manages are ignored, so "job show" output applies as is
+	for _, field := range []string{"id", "last_reported_at", "snoozed_until", "created_at", "updated_at"} {
+		delete(spec, field)
+	}
+
+	return json.Marshal(spec)
+}
+
+// printFieldChanges prints a diff one field per line, in name order
+func printFieldChanges(diff map[string]fieldChange) {
+	fields := make([]string, 0, len(diff))
+	for field := range diff {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		change := diff[field]
+		if field == "api_key" {
+			change = fieldChange{Old: maskChangeValue(change.Old), New: maskChangeValue(change.New)}
+		}
+		fmt.Printf("  %s: %s -> %s\n", field, formatChangeValue(change.Old), formatChangeValue(change.New))
+	}
+}
+
+// maskChangeValue masks an API key in a diff
+func maskChangeValue(value any) any {
+	if key, ok := value.(string); ok && key != "" {
+		return maskApiKey(key)
+	}
+	return value
+}
+
+// formatChangeValue renders a diff value compactly, "-" when unset
+func formatChangeValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "-"
+	case string:
+		if v == "" {
+			return "-"
+		}
+		return fmt.Sprintf("%q", v)
+	case map[string]any:
+		labels := make(map[string]string, len(v))
+		for key, value := range v {
+			labels[key] = fmt.Sprint(value)
+		}
+		return formatLabels(labels)
+	case []any:
+		if len(v) == 0 {
+			return "-"
+		}
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/archive"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// archiveCmd represents the archive command
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Job result archival",
+	Long: `Export aged job results to object storage and read them back.
+
+Results older than archive.retention_days are written to archive.destination
+as gzip-compressed JSON lines, partitioned by day, then deleted from the
+database. The server does this every archive.interval seconds when
+archive.enabled is set; these commands run it on demand and query or restore
+archived history.`,
+}
+
+var (
+	archiveDestination string
+	archiveJobName     string
+	archiveHost        string
+	archiveSince       string
+	archiveUntil       string
+)
+
+func init() {
+	archiveCmd.PersistentFlags().StringVar(&archiveDestination, "destination", "", "archive destination (defaults to archive.destination)")
+
+	for _, cmd := range []*cobra.Command{archiveQueryCmd, archiveRestoreCmd} {
+		cmd.Flags().StringVarP(&archiveJobName, "name", "n", "", "only results of this job name")
+		cmd.Flags().StringVar(&archiveHost, "host", "", "only results from this host")
+		cmd.Flags().StringVar(&archiveSince, "since", "", "only results recorded at or after this date (YYYY-MM-DD or RFC3339)")
+		cmd.Flags().StringVar(&archiveUntil, "until", "", "only results recorded before this date (YYYY-MM-DD or RFC3339)")
+	}
+	addOutputFlags(archiveQueryCmd)
+
+	archiveCmd.AddCommand(archiveRunCmd)
+	archiveCmd.AddCommand(archiveListCmd)
+	archiveCmd.AddCommand(archiveQueryCmd)
+	archiveCmd.AddCommand(archiveRestoreCmd)
+}
+
+// archiveRunCmd archives aged results now
+var archiveRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Archive results older than the retention period now",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runArchive(func(ctx context.Context, archiver *archive.Archiver, retention time.Duration) error {
+			stats, err := archiver.Archive(ctx, time.Now().Add(-retention))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Archived %d results in %d objects\n", stats.Results, stats.Objects)
+			return nil
+		}); err != nil {
+			logrus.WithError(err).Fatal("failed to archive job results")
+		}
+	},
+}
+
+// archiveListCmd lists archived objects
+var archiveListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List archived objects",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runArchive(func(ctx context.Context, archiver *archive.Archiver, _ time.Duration) error {
+			keys, err := archiver.Objects(ctx)
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				fmt.Println(key)
+			}
+			return nil
+		}); err != nil {
+			logrus.WithError(err).Fatal("failed to list archive")
+		}
+	},
+}
+
+// archiveQueryCmd prints archived results
+var archiveQueryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Print archived results",
+	Long: `Print archived results. With --output json, each result is printed as a
+JSON object on its own line, so large histories can be streamed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runArchive(func(ctx context.Context, archiver *archive.Archiver, _ time.Duration) error {
+			format, err := outputMode()
+			if err != nil {
+				return err
+			}
+			query, err := archiveQuery()
+			if err != nil {
+				return err
+			}
+
+			results, err := archiver.Query(ctx, query)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case outputQuiet:
+				ids := make([]int, len(results))
+				for i, result := range results {
+					ids[i] = result.ID
+				}
+				printIDs(ids)
+				return nil
+			case format == formatJSON:
+				encoder := json.NewEncoder(os.Stdout)
+				for _, result := range results {
+					if err := encoder.Encode(result); err != nil {
+						return err
+					}
+				}
+				return nil
+			case format == formatYAML:
+				if results == nil {
+					results = []*model.JobResult{}
+				}
+				return printStructured(format, results)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			if format == formatWide {
+				fmt.Fprintln(w, "ID\tTIMESTAMP\tJOB\tHOST\tSTATUS\tDURATION\tLABELS\tOUTPUT")
+			} else {
+				fmt.Fprintln(w, "TIMESTAMP\tJOB\tHOST\tSTATUS\tDURATION")
+			}
+			for _, result := range results {
+				timestamp := result.Timestamp.UTC().Format(time.RFC3339)
+				if format == formatWide {
+					firstLine, _, _ := strings.Cut(strings.TrimSpace(result.Output), "\n")
+					fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%ds\t%s\t%s\n",
+						result.ID, timestamp, result.JobName, result.Host, result.Status, result.Duration,
+						formatLabels(result.Labels), firstLine)
+					continue
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%ds\n",
+					timestamp, result.JobName, result.Host, result.Status, result.Duration)
+			}
+			return w.Flush()
+		}); err != nil {
+			logrus.WithError(err).Fatal("failed to query archive")
+		}
+	},
+}
+
+// archiveRestoreCmd copies archived results back into the database
+var archiveRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Copy archived results back into the database",
+	Long: `Copy archived results back into the database. Results that are still in
+the database are skipped. Restored results older than the retention period
+are archived again by the next archival run.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runArchive(func(ctx context.Context, archiver *archive.Archiver, _ time.Duration) error {
+			query, err := archiveQuery()
+			if err != nil {
+				return err
+			}
+
+			restored, err := archiver.Restore(ctx, query)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Restored %d results\n", restored)
+			return nil
+		}); err != nil {
+			logrus.WithError(err).Fatal("failed to restore archive")
+		}
+	},
+}
+
+// runArchive opens the database and archive store and calls fn with the
+// archiver and the configured retention
+func runArchive(fn func(ctx context.Context, archiver *archive.Archiver, retention time.Duration) error) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if archiveDestination != "" {
+		cfg.Archive.Destination = archiveDestination
+	}
+	if cfg.Archive.Destination == "" {
+		return fmt.Errorf("no archive destination configured (set archive.destination or --destination)")
+	}
+
+	store, err := archive.NewStore(&cfg.Archive)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	archiver := archive.NewArchiver(model.NewJobResultStore(db.GetDB()), store)
+	retention := time.Duration(cfg.Archive.RetentionDays) * 24 * time.Hour
+
+	return fn(context.Background(), archiver, retention)
+}
+
+// archiveQuery builds the archive query from the command flags
+func archiveQuery() (archive.Query, error) {
+	query := archive.Query{JobName: archiveJobName, Host: archiveHost}
+
+	var err error
+	if query.Since, err = parseArchiveTime(archiveSince); err != nil {
+		return query, fmt.Errorf("invalid --since: %w", err)
+	}
+	if query.Until, err = parseArchiveTime(archiveUntil); err != nil {
+		return query, fmt.Errorf("invalid --until: %w", err)
+	}
+
+	return query, nil
+}
+
+// parseArchiveTime parses a date or RFC3339 timestamp; empty means unset
+func parseArchiveTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
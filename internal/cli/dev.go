@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/demo"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/util"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// devCmd groups development and demo helpers
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Development and demo helpers",
+}
+
+var (
+	seedJobs    int
+	seedHistory string
+	seedRandom  int64
+)
+
+func init() {
+	seedCmd.Flags().IntVar(&seedJobs, "jobs", 50, "number of jobs to generate")
+	seedCmd.Flags().StringVar(&seedHistory, "history", "7d", `span of result history, e.g. "7d" or "36h"`)
+	seedCmd.Flags().Int64Var(&seedRandom, "seed", 0, "random seed (defaults to the current time)")
+
+	devCmd.AddCommand(seedCmd)
+}
+
+// seedCmd fills the database with demo data
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Generate demo jobs and results",
+	Long: `Generate realistic jobs and result history, with healthy, flapping,
+failing and silent jobs, so the dashboard and metrics can be explored
+without wiring real cron jobs. Jobs that already exist are left alone.
+
+Do not run this against a production database.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSeed(); err != nil {
+			logrus.WithError(err).Fatal("failed to seed demo data")
+		}
+	},
+}
+
+func runSeed() error {
+	if seedJobs < 1 {
+		return fmt.Errorf("--jobs must be at least 1")
+	}
+	history, err := parseHistory(seedHistory)
+	if err != nil {
+		return err
+	}
+	if seedRandom == 0 {
+		seedRandom = time.Now().UnixNano()
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	jobStore := model.NewJobStore(db.GetDB())
+	resultStore := model.NewJobResultStore(db.GetDB())
+
+	jobs, results := demo.Generate(demo.Options{Jobs: seedJobs, History: history, Seed: seedRandom})
+
+	// Per-job results are only kept for the jobs created here
+	created := make(map[string]bool, len(jobs))
+	skipped := 0
+	for _, job := range jobs {
+		if _, err := jobStore.GetJob(job.Name, job.Host); err == nil {
+			skipped++
+			continue
+		}
+
+		if job.ApiKey, err = util.GenerateAPIKey(); err != nil {
+			return fmt.Errorf("failed to generate API key: %w", err)
+		}
+		if err := jobStore.CreateJob(job); err != nil {
+			return fmt.Errorf("failed to create job %s@%s: %w", job.Name, job.Host, err)
+		}
+		created[job.Name+"@"+job.Host] = true
+	}
+
+	kept := results[:0]
+	for _, result := range results {
+		if created[result.JobName+"@"+result.Host] {
+			kept = append(kept, result)
+		}
+	}
+	if err := resultStore.CreateJobResults(kept); err != nil {
+		return err
+	}
+
+	fmt.Printf("Created %d jobs with %d results over %s (seed %d)\n", len(created), len(kept), seedHistory, seedRandom)
+	if skipped > 0 {
+		fmt.Printf("Skipped %d jobs that already exist\n", skipped)
+	}
+	return nil
+}
+
+// parseHistory parses a history span such as "7d" or "36h"
+func parseHistory(value string) (time.Duration, error) {
+	span, err := util.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --history: %w", err)
+	}
+
+	if span <= 0 {
+		return 0, fmt.Errorf("--history must be positive")
+	}
+	return span, nil
+}
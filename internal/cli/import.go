@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jaepetto/cron-exporter/pkg/importer"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/util"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// importCmd groups the importers of hosted monitoring services
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import jobs and results from hosted monitoring services",
+	Long: `Import the monitors and ping history of a hosted cron monitoring service
+as jobs and results, to migrate from it. Monitors become jobs on the host
+set with --host, or the host of their "host:<name>" tag; their other tags
+become labels. Jobs that already exist are left alone, with their results.
+
+Imported jobs get new API keys: retrieve them with "cronmetrics job show"
+to point the cron jobs at this server.`,
+}
+
+var (
+	importHost   string
+	importDryRun bool
+)
+
+func init() {
+	importCmd.PersistentFlags().StringVar(&importHost, "host", "imported", `host of the imported jobs, unless a monitor has a "host:<name>" tag`)
+	importCmd.PersistentFlags().BoolVar(&importDryRun, "dry-run", false, "show what would be imported without changing the database")
+
+	importCmd.AddCommand(importHealthchecksCmd)
+	importCmd.AddCommand(importCronitorCmd)
+}
+
+// importHealthchecksCmd imports a Healthchecks.io export
+var importHealthchecksCmd = &cobra.Command{
+	Use:   "healthchecks <file>",
+	Short: "Import checks from a Healthchecks.io export",
+	Long: `Import the checks listed by the Healthchecks.io API (/api/v3/checks/),
+read from a file or "-" for stdin. The pings of each check, as listed by
+/api/v3/checks/<uuid>/pings/, are imported from its "pings" list.
+
+Simple checks fail after their period and grace time; cron checks follow
+the configured default thresholds. Success and failure pings become
+results; start, log and ignored pings are skipped. Paused checks are
+imported paused.`,
+	Example: `  cronmetrics import healthchecks backup.json --host db1
+  curl -H "X-Api-Key: $HC_KEY" https://healthchecks.io/api/v3/checks/ | cronmetrics import healthchecks -`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runImport("Healthchecks.io", importer.Healthchecks, args[0]); err != nil {
+			logrus.WithError(err).Fatal("failed to import checks")
+		}
+	},
+}
+
+// importCronitorCmd imports a Cronitor export
+var importCronitorCmd = &cobra.Command{
+	Use:   "cronitor <file>",
+	Short: "Import monitors from a Cronitor export",
+	Long: `Import the job and heartbeat monitors listed by the Cronitor API
+(/api/monitors), read from a file or "-" for stdin. The telemetry events
+of each monitor are imported from its "pings" list.
+
+Monitors on an interval schedule, e.g. "every 1 hour", fail after the
+interval and grace time; cron schedules follow the configured default
+thresholds. Complete and fail events become results, with the "count"
+metric as the items processed; run events are skipped.`,
+	Example: `  cronmetrics import cronitor monitors.json --host app1`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runImport("Cronitor", importer.Cronitor, args[0]); err != nil {
+			logrus.WithError(err).Fatal("failed to import monitors")
+		}
+	},
+}
+
+// runImport maps the export at path with parse, and creates the jobs that
+// do not exist yet with their results
+func runImport(service string, parse func(io.Reader, importer.Options) (*importer.Import, error), path string) error {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	imported, err := parse(in, importer.Options{Host: importHost})
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	jobStore := model.NewJobStore(db.GetDB())
+	resultStore := model.NewJobResultStore(db.GetDB())
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB\tHOST\tTHRESHOLD\tRESULTS\tACTION")
+	var results []*model.JobResult
+	created, skipped := 0, 0
+	for _, job := range imported.Jobs {
+		jobResults := imported.ResultsOf(job)
+		job.ApplyDefaultThreshold(cfg.Defaults.FailureThreshold)
+		threshold := fmt.Sprintf("%ds", job.AutomaticFailureThreshold)
+		if job.ThresholdInherited {
+			threshold += " (default)"
+		}
+
+		if _, err := jobStore.GetJob(job.Name, job.Host); err == nil {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", job.Name, job.Host, threshold, len(jobResults), "skipped, exists")
+			skipped++
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", job.Name, job.Host, threshold, len(jobResults), "create")
+		created++
+		results = append(results, jobResults...)
+		if importDryRun {
+			continue
+		}
+
+		if job.ApiKey, err = util.GenerateAPIKey(); err != nil {
+			return fmt.Errorf("failed to generate API key: %w", err)
+		}
+		if err := jobStore.CreateJob(job); err != nil {
+			return fmt.Errorf("failed to create job %s@%s: %w", job.Name, job.Host, err)
+		}
+	}
+	w.Flush()
+
+	if importDryRun {
+		fmt.Printf("\nWould import %d jobs with %d results from %s\n", created, len(results), service)
+	} else {
+		if err := resultStore.CreateJobResults(results); err != nil {
+			return err
+		}
+		fmt.Printf("\nImported %d jobs with %d results from %s\n", created, len(results), service)
+	}
+	if skipped > 0 {
+		fmt.Printf("Skipped %d jobs that already exist\n", skipped)
+	}
+	return nil
+}
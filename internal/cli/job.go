@@ -1,14 +1,18 @@
 package cli
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/jaepetto/cron-exporter/pkg/metrics"
 	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/scaffold"
 	"github.com/jaepetto/cron-exporter/pkg/util"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -30,6 +34,11 @@ func init() {
 	jobCmd.AddCommand(jobUpdateCmd)
 	jobCmd.AddCommand(jobDeleteCmd)
 	jobCmd.AddCommand(jobShowCmd)
+	jobCmd.AddCommand(jobSnoozeCmd)
+	jobCmd.AddCommand(jobStatusCmd)
+	jobCmd.AddCommand(jobTestCmd)
+	jobCmd.AddCommand(jobScaffoldCmd)
+	jobCmd.AddCommand(jobApplyCmd)
 }
 
 // jobAddCmd adds a new job
@@ -49,18 +58,30 @@ var (
 	jobName      string
 	jobHost      string
 	jobApiKey    string
-	jobThreshold int
+	jobThreshold string
 	jobLabels    []string
 	jobStatus    string
+	jobCIDRs     []string
+	jobDesc      string
+	jobType      string
+	jobFloor     int64
+	jobSchedule  string
+	jobShards    string
 )
 
 func init() {
 	jobAddCmd.Flags().StringVarP(&jobName, "name", "n", "", "job name (required)")
 	jobAddCmd.Flags().StringVar(&jobHost, "host", "", "host name (required)")
 	jobAddCmd.Flags().StringVar(&jobApiKey, "api-key", "", "API key for the job (auto-generated if not provided)")
-	jobAddCmd.Flags().IntVarP(&jobThreshold, "threshold", "t", 3600, "automatic failure threshold in seconds")
+	jobAddCmd.Flags().StringVarP(&jobThreshold, "threshold", "t", "default", `automatic failure threshold, in seconds or e.g. 90m, 2h, 1d ("default" follows the configured defaults)`)
 	jobAddCmd.Flags().StringSliceVarP(&jobLabels, "label", "l", []string{}, "labels in key=value format")
 	jobAddCmd.Flags().StringVarP(&jobStatus, "status", "s", "active", "job status (active, maintenance, paused)")
+	jobAddCmd.Flags().StringSliceVar(&jobCIDRs, "allowed-cidr", nil, "source range results are accepted from (repeatable, default any)")
+	jobAddCmd.Flags().StringVar(&jobDesc, "description", "", "markdown notes on what the job does and how to rerun it")
+	jobAddCmd.Flags().StringVar(&jobType, "type", model.JobTypeStandard, "job type (standard, counter)")
+	jobAddCmd.Flags().Int64Var(&jobFloor, "throughput-floor", 0, "fewest items a successful run of a counter job may process (0 disables)")
+	jobAddCmd.Flags().StringVar(&jobSchedule, "schedule", "", `crontab schedule the job runs on, in UTC, e.g. "0 3 * * 1" or "@monthly"; the threshold then counts from each expected run`)
+	jobAddCmd.Flags().StringVar(&jobShards, "shard-policy", "", "how the shards of a fan-out run make its status (all, any); empty for jobs without shards")
 
 	if err := jobAddCmd.MarkFlagRequired("name"); err != nil {
 		panic(fmt.Sprintf("Failed to mark name flag as required: %v", err))
@@ -68,12 +89,21 @@ func init() {
 	if err := jobAddCmd.MarkFlagRequired("host"); err != nil {
 		panic(fmt.Sprintf("Failed to mark host flag as required: %v", err))
 	}
+	addOutputFlags(jobAddCmd)
 }
 
 func runJobAdd(cmd *cobra.Command) error {
 	if jobName == "" || jobHost == "" {
 		return fmt.Errorf("job name and host are required")
 	}
+	if err := model.CheckJobIdentity(jobName, jobHost); err != nil {
+		return err
+	}
+
+	format, err := outputMode()
+	if err != nil {
+		return err
+	}
 
 	// Parse labels
 	labels, err := parseLabels(jobLabels)
@@ -81,6 +111,32 @@ func runJobAdd(cmd *cobra.Command) error {
 		return fmt.Errorf("invalid labels: %w", err)
 	}
 
+	allowedCIDRs, err := model.NormalizeCIDRs(jobCIDRs)
+	if err != nil {
+		return fmt.Errorf("invalid allowed CIDRs: %w", err)
+	}
+
+	if len(jobDesc) > model.MaxDescriptionLength {
+		return fmt.Errorf("description must be at most %d characters", model.MaxDescriptionLength)
+	}
+
+	if err := model.CheckJobType(jobType, jobFloor); err != nil {
+		return err
+	}
+
+	if err := model.CheckSchedule(jobSchedule); err != nil {
+		return err
+	}
+
+	if err := model.CheckShardPolicy(jobShards); err != nil {
+		return err
+	}
+
+	threshold, err := parseThreshold(jobThreshold)
+	if err != nil {
+		return err
+	}
+
 	// Generate API key if not provided
 	apiKey := jobApiKey
 	if apiKey == "" {
@@ -97,7 +153,7 @@ func runJobAdd(cmd *cobra.Command) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	db, err := model.NewDatabase(cfg.Database.Path)
+	db, err := openDatabase(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -110,16 +166,31 @@ func runJobAdd(cmd *cobra.Command) error {
 		Name:                      jobName,
 		Host:                      jobHost,
 		ApiKey:                    apiKey,
-		AutomaticFailureThreshold: jobThreshold,
+		AutomaticFailureThreshold: threshold,
 		Labels:                    labels,
+		AllowedCIDRs:              allowedCIDRs,
+		Description:               jobDesc,
+		Type:                      jobType,
+		ThroughputFloor:           jobFloor,
+		Schedule:                  jobSchedule,
+		ShardPolicy:               jobShards,
 		Status:                    jobStatus,
 		LastReportedAt:            time.Now().UTC(),
 	}
+	job.ApplyDefaultThreshold(cfg.Defaults.FailureThreshold)
 
 	if err := jobStore.CreateJob(job); err != nil {
 		return fmt.Errorf("failed to create job: %w", err)
 	}
 
+	switch {
+	case outputQuiet:
+		printIDs([]int{job.ID})
+		return nil
+	case format == formatJSON || format == formatYAML:
+		return printStructured(format, job)
+	}
+
 	fmt.Printf("Job ID %d ('%s@%s') created successfully\n", job.ID, jobName, jobHost)
 	fmt.Printf("API Key: %s\n", apiKey)
 
@@ -145,17 +216,21 @@ var jobListCmd = &cobra.Command{
 
 var (
 	listLabels  []string
-	outputJSON  bool
 	showApiKeys bool
 )
 
 func init() {
 	jobListCmd.Flags().StringSliceVarP(&listLabels, "label", "l", []string{}, "filter by labels in key=value format")
-	jobListCmd.Flags().BoolVarP(&outputJSON, "json", "j", false, "output as JSON")
 	jobListCmd.Flags().BoolVar(&showApiKeys, "show-api-keys", false, "show API keys (masked for security)")
+	addOutputFlags(jobListCmd)
 }
 
 func runJobList(cmd *cobra.Command) error {
+	format, err := outputMode()
+	if err != nil {
+		return err
+	}
+
 	// Parse label filters
 	labelFilters, err := parseLabels(listLabels)
 	if err != nil {
@@ -168,7 +243,7 @@ func runJobList(cmd *cobra.Command) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	db, err := model.NewDatabase(cfg.Database.Path)
+	db, err := openDatabase(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -182,14 +257,20 @@ func runJobList(cmd *cobra.Command) error {
 		return fmt.Errorf("failed to list jobs: %w", err)
 	}
 
-	if outputJSON {
-		output, err := json.MarshalIndent(jobs, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal JSON: %w", err)
+	switch {
+	case outputQuiet:
+		ids := make([]int, len(jobs))
+		for i, job := range jobs {
+			ids[i] = job.ID
 		}
-		fmt.Println(string(output))
-	} else {
-		printJobsTable(jobs)
+		printIDs(ids)
+	case format == formatJSON || format == formatYAML:
+		if jobs == nil {
+			jobs = []*model.Job{}
+		}
+		return printStructured(format, jobs)
+	default:
+		printJobsTable(jobs, format == formatWide)
 	}
 
 	return nil
@@ -209,7 +290,7 @@ var jobUpdateCmd = &cobra.Command{
 }
 
 var (
-	updateThreshold *int
+	updateThreshold string
 	updateLabels    []string
 	updateStatus    string
 	maintenance     bool
@@ -219,10 +300,17 @@ func init() {
 	jobUpdateCmd.Flags().StringVarP(&jobName, "name", "n", "", "update job name")
 	jobUpdateCmd.Flags().StringVar(&jobHost, "host", "", "update host name")
 	jobUpdateCmd.Flags().StringVar(&jobApiKey, "api-key", "", "update API key for the job")
-	jobUpdateCmd.Flags().IntVar(&jobThreshold, "threshold", 0, "automatic failure threshold in seconds")
+	jobUpdateCmd.Flags().StringVar(&updateThreshold, "threshold", "", `automatic failure threshold, in seconds or e.g. 90m, 2h, 1d ("default" follows the configured defaults)`)
 	jobUpdateCmd.Flags().StringSliceVarP(&updateLabels, "label", "l", []string{}, "labels in key=value format")
 	jobUpdateCmd.Flags().StringVarP(&updateStatus, "status", "s", "", "job status (active, maintenance, paused)")
 	jobUpdateCmd.Flags().BoolVarP(&maintenance, "maintenance", "m", false, "set job to maintenance mode")
+	jobUpdateCmd.Flags().StringSliceVar(&jobCIDRs, "allowed-cidr", nil, `source range results are accepted from (repeatable, "" allows any)`)
+	jobUpdateCmd.Flags().StringVar(&jobDesc, "description", "", `markdown notes on what the job does ("" clears them)`)
+	jobUpdateCmd.Flags().StringVar(&jobType, "type", "", "job type (standard, counter)")
+	jobUpdateCmd.Flags().Int64Var(&jobFloor, "throughput-floor", 0, "fewest items a successful run of a counter job may process (0 disables)")
+	jobUpdateCmd.Flags().StringVar(&jobSchedule, "schedule", "", `crontab schedule the job runs on, in UTC ("" removes it)`)
+	jobUpdateCmd.Flags().StringVar(&jobShards, "shard-policy", "", `how the shards of a fan-out run make its status (all, any; "" for none)`)
+	addOutputFlags(jobUpdateCmd)
 }
 
 func runJobUpdate(cmd *cobra.Command, args []string) error {
@@ -232,13 +320,18 @@ func runJobUpdate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid job ID: %w", err)
 	}
 
+	format, err := outputMode()
+	if err != nil {
+		return err
+	}
+
 	// Load configuration and initialize database
 	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	db, err := model.NewDatabase(cfg.Database.Path)
+	db, err := openDatabase(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -260,13 +353,21 @@ func runJobUpdate(cmd *cobra.Command, args []string) error {
 	if cmd.Flags().Changed("host") {
 		job.Host = jobHost
 	}
+	if err := model.CheckJobIdentity(job.Name, job.Host); err != nil {
+		return err
+	}
 
 	if cmd.Flags().Changed("api-key") {
 		job.ApiKey = jobApiKey
 	}
 
 	if cmd.Flags().Changed("threshold") {
-		job.AutomaticFailureThreshold = jobThreshold
+		threshold, err := parseThreshold(updateThreshold)
+		if err != nil {
+			return err
+		}
+		job.AutomaticFailureThreshold = threshold
+		job.ThresholdInherited = threshold == 0
 	}
 
 	if len(updateLabels) > 0 {
@@ -277,6 +378,43 @@ func runJobUpdate(cmd *cobra.Command, args []string) error {
 		job.Labels = labels
 	}
 
+	if cmd.Flags().Changed("allowed-cidr") {
+		allowedCIDRs, err := model.NormalizeCIDRs(jobCIDRs)
+		if err != nil {
+			return fmt.Errorf("invalid allowed CIDRs: %w", err)
+		}
+		job.AllowedCIDRs = allowedCIDRs
+	}
+
+	if cmd.Flags().Changed("description") {
+		if len(jobDesc) > model.MaxDescriptionLength {
+			return fmt.Errorf("description must be at most %d characters", model.MaxDescriptionLength)
+		}
+		job.Description = jobDesc
+	}
+
+	if err := model.CheckJobType(jobType, jobFloor); err != nil {
+		return err
+	}
+	if jobType != "" {
+		job.Type = jobType
+	}
+	if cmd.Flags().Changed("throughput-floor") {
+		job.ThroughputFloor = jobFloor
+	}
+	if cmd.Flags().Changed("schedule") {
+		if err := model.CheckSchedule(jobSchedule); err != nil {
+			return err
+		}
+		job.Schedule = jobSchedule
+	}
+	if cmd.Flags().Changed("shard-policy") {
+		if err := model.CheckShardPolicy(jobShards); err != nil {
+			return err
+		}
+		job.ShardPolicy = jobShards
+	}
+
 	if updateStatus != "" {
 		job.Status = updateStatus
 	}
@@ -284,12 +422,21 @@ func runJobUpdate(cmd *cobra.Command, args []string) error {
 	if maintenance {
 		job.Status = "maintenance"
 	}
+	job.ApplyDefaultThreshold(cfg.Defaults.FailureThreshold)
 
 	// Update job
 	if err := jobStore.UpdateJobByID(job); err != nil {
 		return fmt.Errorf("failed to update job: %w", err)
 	}
 
+	switch {
+	case outputQuiet:
+		printIDs([]int{job.ID})
+		return nil
+	case format == formatJSON || format == formatYAML:
+		return printStructured(format, job)
+	}
+
 	fmt.Printf("Job ID %d ('%s@%s') updated successfully\n", job.ID, job.Name, job.Host)
 	return nil
 }
@@ -320,7 +467,7 @@ func runJobDelete(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	db, err := model.NewDatabase(cfg.Database.Path)
+	db, err := openDatabase(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -357,7 +504,7 @@ var jobShowCmd = &cobra.Command{
 }
 
 func init() {
-	jobShowCmd.Flags().BoolVarP(&outputJSON, "json", "j", false, "output as JSON")
+	addOutputFlags(jobShowCmd)
 }
 
 func runJobShow(cmd *cobra.Command, args []string) error {
@@ -367,13 +514,18 @@ func runJobShow(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid job ID: %w", err)
 	}
 
+	format, err := outputMode()
+	if err != nil {
+		return err
+	}
+
 	// Load configuration and initialize database
 	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	db, err := model.NewDatabase(cfg.Database.Path)
+	db, err := openDatabase(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -387,19 +539,311 @@ func runJobShow(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get job: %w", err)
 	}
 
-	if outputJSON {
-		output, err := json.MarshalIndent(job, "", "  ")
+	switch {
+	case outputQuiet:
+		printIDs([]int{job.ID})
+	case format == formatJSON || format == formatYAML:
+		return printStructured(format, job)
+	default:
+		printJobDetails(job)
+	}
+
+	return nil
+}
+
+// jobSnoozeCmd suppresses a job's failures for a while
+var jobSnoozeCmd = &cobra.Command{
+	Use:   "snooze <id>",
+	Short: "Snooze a job's failures",
+	Long: `Report no failure for a job during the given window. Results are still
+recorded; unlike maintenance, the job keeps reporting success normally.`,
+	Example: `  cronmetrics job snooze 1 --for 2h
+  cronmetrics job snooze 1 --clear`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runJobSnooze(cmd, args); err != nil {
+			logrus.WithError(err).Fatal("failed to snooze job")
+		}
+	},
+}
+
+var (
+	snoozeFor   string
+	snoozeClear bool
+)
+
+func init() {
+	jobSnoozeCmd.Flags().StringVar(&snoozeFor, "for", "", "snooze duration, e.g. 2h or 1d")
+	jobSnoozeCmd.Flags().BoolVar(&snoozeClear, "clear", false, "end the job's snooze")
+	jobSnoozeCmd.MarkFlagsOneRequired("for", "clear")
+	jobSnoozeCmd.MarkFlagsMutuallyExclusive("for", "clear")
+}
+
+func runJobSnooze(cmd *cobra.Command, args []string) error {
+	// Parse job ID from argument
+	jobID, err := parseJobID(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid job ID: %w", err)
+	}
+
+	var until *time.Time
+	if !snoozeClear {
+		duration, err := util.ParseDuration(snoozeFor)
 		if err != nil {
-			return fmt.Errorf("failed to marshal JSON: %w", err)
+			return fmt.Errorf("invalid --for: %w", err)
 		}
-		fmt.Println(string(output))
+		if duration <= 0 {
+			return fmt.Errorf("--for must be positive")
+		}
+		end := time.Now().UTC().Add(duration)
+		until = &end
+	}
+
+	// Load configuration and initialize database
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	jobStore := model.NewJobStore(db.GetDB())
+
+	job, err := jobStore.SnoozeJobByID(jobID, until)
+	if err != nil {
+		return fmt.Errorf("failed to snooze job: %w", err)
+	}
+
+	if until == nil {
+		fmt.Printf("Job ID %d ('%s@%s') is no longer snoozed\n", job.ID, job.Name, job.Host)
 	} else {
-		printJobDetails(job)
+		fmt.Printf("Job ID %d ('%s@%s') snoozed until %s\n", job.ID, job.Name, job.Host, until.Format("2006-01-02 15:04:05 MST"))
 	}
+	return nil
+}
 
+// Exit codes of "job status"
+const (
+	statusExitHealthy    = 0
+	statusExitFailing    = 1
+	statusExitSuppressed = 2 // Maintenance, paused or snoozed
+	statusExitMissed     = 3
+	statusExitUnknown    = 4 // The status could not be determined
+)
+
+// jobStatusCmd reports a job's status through its exit code
+var jobStatusCmd = &cobra.Command{
+	Use:   "status <id>",
+	Short: "Check a job's status",
+	Long: `Evaluate a job's status as the metrics report it and exit with a code
+scripts and external monitors can branch on:
+
+  0  healthy: the last result succeeded, within the failure threshold
+  1  failing: the last result failed
+  2  maintenance, paused, or a snoozed failure or missed deadline
+  3  missed deadline: no report within the failure threshold
+  4  the status could not be determined, e.g. the job does not exist`,
+	Example: `  cronmetrics job status 1 --quiet || echo "backup needs attention"`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		code, err := runJobStatus(args)
+		if err != nil {
+			logrus.WithError(err).Error("failed to check job status")
+		}
+		os.Exit(code)
+	},
+}
+
+var statusQuiet bool
+
+func init() {
+	jobStatusCmd.Flags().BoolVarP(&statusQuiet, "quiet", "q", false, "print nothing, only exit with the status code")
+}
+
+// runJobStatus prints the job's status and returns the matching exit code
+func runJobStatus(args []string) (int, error) {
+	jobID, err := parseJobID(args[0])
+	if err != nil {
+		return statusExitUnknown, fmt.Errorf("invalid job ID: %w", err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return statusExitUnknown, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return statusExitUnknown, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	jobStore := model.NewJobStore(db.GetDB())
+	job, err := jobStore.GetJobByID(jobID)
+	if err != nil {
+		return statusExitUnknown, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	check, err := metrics.NewCollector(jobStore, model.NewJobResultStore(db.GetDB())).CheckJob(context.Background(), job)
+	if err != nil {
+		return statusExitUnknown, fmt.Errorf("failed to check job: %w", err)
+	}
+
+	code := statusExitHealthy
+	switch check.Reason {
+	case "failure", "lost", "low_throughput", "overlapping":
+		code = statusExitFailing
+	case "maintenance", "paused", "snoozed":
+		code = statusExitSuppressed
+	case "missed_deadline":
+		code = statusExitMissed
+	}
+
+	if !statusQuiet {
+		fmt.Printf("%s@%s: %s (last reported %s ago, threshold %s)\n", job.Name, job.Host, check.Reason,
+			util.FormatDuration(max(check.CheckedAt.Sub(job.LastReportedAt), 0).Truncate(time.Second)),
+			util.FormatDuration(time.Duration(job.AutomaticFailureThreshold)*time.Second))
+	}
+	return code, nil
+}
+
+// jobScaffoldCmd prints the crontab line or systemd units running a command
+// that reports to a job
+var jobScaffoldCmd = &cobra.Command{
+	Use:   "scaffold <id> -- <program> [args...]",
+	Short: "Generate the crontab line or systemd units of a job",
+	Long: `Print a ready-to-install crontab line, or systemd service and timer, running
+the command under "cronmetrics wrap" with the job's name, host, API key and
+server URL, so every run is reported to the job.
+
+The server URL defaults to server.external_url, else the configured listen
+address; set --server when the job runs on another host. The schedule
+defaults to the job's own.`,
+	Example: `  cronmetrics job scaffold 1 --schedule "0 3 * * *" -- /usr/local/bin/backup.sh --full
+  cronmetrics job scaffold 1 --type systemd --schedule "*/15 * * * *" \
+    --server https://cron.example.com -- /usr/local/bin/sync.sh`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runJobScaffold(cmd, args); err != nil {
+			logrus.WithError(err).Fatal("failed to scaffold job")
+		}
+	},
+}
+
+var (
+	scaffoldType     string
+	scaffoldSchedule string
+	scaffoldServer   string
+	scaffoldBin      string
+	scaffoldSign     bool
+)
+
+func init() {
+	jobScaffoldCmd.Flags().StringVar(&scaffoldType, "type", "cron", "what to generate: cron or systemd")
+	jobScaffoldCmd.Flags().StringVar(&scaffoldSchedule, "schedule", "", `crontab schedule, e.g. "30 2 * * 1-5" or "@daily" (defaults to the job's schedule)`)
+	jobScaffoldCmd.Flags().StringVar(&scaffoldServer, "server", "", "server URL the job reports to (defaults to the configured one)")
+	jobScaffoldCmd.Flags().StringVar(&scaffoldBin, "bin", "/usr/local/bin/cronmetrics", "path of cronmetrics on the job's host")
+	jobScaffoldCmd.Flags().BoolVar(&scaffoldSign, "sign", false, "sign results with the job's API key instead of sending the key")
+}
+
+func runJobScaffold(cmd *cobra.Command, args []string) error {
+	// Parse job ID from argument
+	jobID, err := parseJobID(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid job ID: %w", err)
+	}
+	if scaffoldType != "cron" && scaffoldType != "systemd" {
+		return fmt.Errorf("invalid type %q: must be cron or systemd", scaffoldType)
+	}
+
+	// Load configuration and initialize database
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	jobStore := model.NewJobStore(db.GetDB())
+
+	job, err := jobStore.GetJobByID(jobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+	if job.ApiKey == "" {
+		return fmt.Errorf("job '%s@%s' has no API key", job.Name, job.Host)
+	}
+	schedule := scaffoldSchedule
+	if schedule == "" {
+		schedule = job.Schedule
+	}
+	if schedule == "" {
+		return fmt.Errorf("job '%s@%s' has no schedule: set one with --schedule", job.Name, job.Host)
+	}
+
+	serverURL := scaffoldServer
+	if serverURL == "" {
+		serverURL = strings.TrimRight(cfg.Server.ExternalURL, "/")
+		if serverURL == "" {
+			serverURL = configuredServerURL(cfg)
+		}
+		serverURL += cfg.Server.BasePath
+	}
+
+	target := &scaffold.Job{
+		Name:       job.Name,
+		Host:       job.Host,
+		APIKey:     job.ApiKey,
+		ServerURL:  serverURL,
+		Schedule:   schedule,
+		Command:    args[1:],
+		Executable: scaffoldBin,
+		Sign:       scaffoldSign,
+	}
+
+	if scaffoldType == "cron" {
+		line, err := scaffold.Crontab(target)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("# %s@%s, reported to cronmetrics\n%s", job.Name, job.Host, line)
+		return nil
+	}
+
+	service, timer, err := scaffold.SystemdUnits(target)
+	if err != nil {
+		return err
+	}
+	unit := scaffold.UnitName(target)
+	fmt.Printf("# /etc/systemd/system/%s.service\n%s\n# /etc/systemd/system/%s.timer\n%s", unit, service, unit, timer)
+	fmt.Printf("\n# Enable with: systemctl daemon-reload && systemctl enable --now %s.timer\n", unit)
 	return nil
 }
 
+// parseThreshold parses a --threshold value into seconds, or 0 for
+// "default"
+func parseThreshold(value string) (int, error) {
+	if value == "default" {
+		return 0, nil
+	}
+	seconds, err := util.ParseSeconds(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --threshold: %w", err)
+	}
+	if seconds <= 0 {
+		return 0, fmt.Errorf("--threshold must be positive")
+	}
+	return seconds, nil
+}
+
 // parseLabels parses key=value label strings into a map
 func parseLabels(labelStings []string) (map[string]string, error) {
 	labels := make(map[string]string)
@@ -414,7 +858,7 @@ func parseLabels(labelStings []string) (map[string]string, error) {
 }
 
 // printJobsTable prints jobs in table format
-func printJobsTable(jobs []*model.Job) {
+func printJobsTable(jobs []*model.Job, wide bool) {
 	if len(jobs) == 0 {
 		fmt.Println("No jobs found")
 		return
@@ -422,26 +866,34 @@ func printJobsTable(jobs []*model.Job) {
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 
+	header := []string{"ID", "NAME", "HOST"}
 	if showApiKeys {
-		fmt.Fprintln(w, "ID\tNAME\tHOST\tAPI_KEY\tSTATUS\tTHRESHOLD\tLAST_REPORTED\tLABELS")
-	} else {
-		fmt.Fprintln(w, "ID\tNAME\tHOST\tSTATUS\tTHRESHOLD\tLAST_REPORTED\tLABELS")
+		header = append(header, "API_KEY")
+	}
+	header = append(header, "STATUS", "THRESHOLD", "LAST_REPORTED")
+	if wide {
+		header = append(header, "OWNER", "SNOOZED_UNTIL", "ALLOWED_CIDRS")
 	}
+	fmt.Fprintln(w, strings.Join(append(header, "LABELS"), "\t"))
 
 	for _, job := range jobs {
-		labelsStr := formatLabels(job.Labels)
-		lastReported := job.LastReportedAt.Format("2006-01-02 15:04:05")
-
+		row := []string{strconv.Itoa(job.ID), job.Name, job.Host}
 		if showApiKeys {
-			maskedApiKey := maskApiKey(job.ApiKey)
-			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%ds\t%s\t%s\n",
-				job.ID, job.Name, job.Host, maskedApiKey, job.Status, job.AutomaticFailureThreshold,
-				lastReported, labelsStr)
-		} else {
-			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%ds\t%s\t%s\n",
-				job.ID, job.Name, job.Host, job.Status, job.AutomaticFailureThreshold,
-				lastReported, labelsStr)
+			row = append(row, maskApiKey(job.ApiKey))
+		}
+		row = append(row, job.Status, fmt.Sprintf("%ds", job.AutomaticFailureThreshold),
+			job.LastReportedAt.Format("2006-01-02 15:04:05"))
+		if wide {
+			snoozedUntil, allowedCIDRs := "-", "any"
+			if job.IsSnoozed(time.Now()) {
+				snoozedUntil = job.SnoozedUntil.Format("2006-01-02 15:04:05")
+			}
+			if len(job.AllowedCIDRs) > 0 {
+				allowedCIDRs = strings.Join(job.AllowedCIDRs, ",")
+			}
+			row = append(row, jobOwner(job), snoozedUntil, allowedCIDRs)
 		}
+		fmt.Fprintln(w, strings.Join(append(row, formatLabels(job.Labels)), "\t"))
 	}
 
 	if err := w.Flush(); err != nil {
@@ -449,6 +901,14 @@ func printJobsTable(jobs []*model.Job) {
 	}
 }
 
+// jobOwner returns the owner of a job, or "-"
+func jobOwner(job *model.Job) string {
+	if owner := job.Owner(); owner != "" {
+		return owner
+	}
+	return "-"
+}
+
 // printJobDetails prints detailed job information
 func printJobDetails(job *model.Job) {
 	fmt.Printf("Job Details:\n")
@@ -457,8 +917,22 @@ func printJobDetails(job *model.Job) {
 	fmt.Printf("  Host: %s\n", job.Host)
 	fmt.Printf("  API Key: %s\n", job.ApiKey)
 	fmt.Printf("  Status: %s\n", job.Status)
-	fmt.Printf("  Threshold: %d seconds\n", job.AutomaticFailureThreshold)
+	if job.Type == model.JobTypeCounter {
+		fmt.Printf("  Type: counter (throughput floor %d items)\n", job.ThroughputFloor)
+	}
+	inherited := ""
+	if job.ThresholdInherited {
+		inherited = ", from defaults"
+	}
+	fmt.Printf("  Threshold: %d seconds (%s%s)\n", job.AutomaticFailureThreshold, util.FormatDuration(time.Duration(job.AutomaticFailureThreshold)*time.Second), inherited)
+	if job.Schedule != "" {
+		fmt.Printf("  Schedule: %s (UTC)\n", job.Schedule)
+	}
 	fmt.Printf("  Last Reported: %s\n", job.LastReportedAt.Format("2006-01-02 15:04:05 MST"))
+	fmt.Printf("  Deadline: %s\n", job.Deadline().Format("2006-01-02 15:04:05 MST"))
+	if job.IsSnoozed(time.Now()) {
+		fmt.Printf("  Snoozed Until: %s\n", job.SnoozedUntil.Format("2006-01-02 15:04:05 MST"))
+	}
 	fmt.Printf("  Created: %s\n", job.CreatedAt.Format("2006-01-02 15:04:05 MST"))
 	fmt.Printf("  Updated: %s\n", job.UpdatedAt.Format("2006-01-02 15:04:05 MST"))
 
@@ -470,6 +944,19 @@ func printJobDetails(job *model.Job) {
 	} else {
 		fmt.Printf("  Labels: none\n")
 	}
+
+	if len(job.AllowedCIDRs) > 0 {
+		fmt.Printf("  Allowed CIDRs: %s\n", strings.Join(job.AllowedCIDRs, ", "))
+	} else {
+		fmt.Printf("  Allowed CIDRs: any\n")
+	}
+
+	if job.Description != "" {
+		fmt.Printf("  Description:\n")
+		for _, line := range strings.Split(job.Description, "\n") {
+			fmt.Printf("    %s\n", line)
+		}
+	}
 }
 
 // formatLabels formats labels map for display
@@ -482,6 +969,7 @@ func formatLabels(labels map[string]string) string {
 	for key, value := range labels {
 		parts = append(parts, fmt.Sprintf("%s=%s", key, value))
 	}
+	sort.Strings(parts)
 	return strings.Join(parts, ",")
 }
 
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/unixsocket"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	testStatus   string
+	testMessage  string
+	testKeep     bool
+	testItems    int64
+	testServer   string
+	testAdminKey string
+)
+
+func init() {
+	jobTestCmd.Flags().StringVar(&testStatus, "status", "success", "status of the test result: success or failure")
+	jobTestCmd.Flags().StringVar(&testMessage, "message", "", "output of the test result")
+	jobTestCmd.Flags().Int64Var(&testItems, "items", 0, "items processed by the test run (default the throughput floor of counter jobs)")
+	jobTestCmd.Flags().BoolVar(&testKeep, "keep", false, "keep the test result in the job's history")
+	jobTestCmd.Flags().StringVar(&testServer, "server", "", `server URL or "unix:/path" socket (defaults to the configured listen address)`)
+	jobTestCmd.Flags().StringVar(&testAdminKey, "admin-key", os.Getenv(envAdminAPIKey), "admin API key (default $"+envAdminAPIKey+", else the first configured admin key)")
+	addOutputFlags(jobTestCmd)
+}
+
+// jobTestCmd sends a test result through a job's pipeline
+var jobTestCmd = &cobra.Command{
+	Use:   "test <id>",
+	Short: "Send a test result for a job",
+	Long: `Send a synthetic result flagged as a test through the server's pipeline
+for a job, and report each stage: authentication with the job's API key,
+storage, metrics, and a notification to the tickets test team when one is
+configured. Exits with 1 when a stage failed.
+
+Test results do not update the job's last report nor open issues. They are
+removed once checked, unless --keep leaves them in the job's history.`,
+	Example: `  cronmetrics job test 1
+  cronmetrics job test 1 --status failure --keep`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		passed, err := runJobTest(cmd, args)
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to test job")
+		}
+		if !passed {
+			os.Exit(1)
+		}
+	},
+}
+
+// testSubmissionResponse is the server's report of a test submission
+type testSubmissionResponse struct {
+	Passed bool `json:"passed"`
+	Stages []struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+		Detail string `json:"detail"`
+	} `json:"stages"`
+}
+
+// runJobTest sends the test submission and reports whether it passed
+func runJobTest(cmd *cobra.Command, args []string) (bool, error) {
+	format, err := outputMode()
+	if err != nil {
+		return false, err
+	}
+	jobID, err := parseJobID(args[0])
+	if err != nil {
+		return false, fmt.Errorf("invalid job ID: %w", err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return false, fmt.Errorf("failed to load config: %w", err)
+	}
+	serverURL := testServer
+	if serverURL == "" {
+		serverURL = configuredServerURL(cfg) + cfg.Server.BasePath
+	}
+	adminKey := testAdminKey
+	if adminKey == "" && len(cfg.Security.AdminAPIKeys) > 0 {
+		adminKey = cfg.Security.AdminAPIKeys[0]
+	}
+
+	submission := map[string]any{"status": testStatus, "output": testMessage, "keep": testKeep}
+	if cmd.Flags().Changed("items") {
+		submission["items_processed"] = testItems
+	}
+	payload, err := json.Marshal(submission)
+	if err != nil {
+		return false, err
+	}
+
+	client, baseURL := unixsocket.Client(serverURL, &http.Client{Timeout: 60 * time.Second})
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/api/job/%d/test", baseURL, jobID), bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if adminKey != "" {
+		req.Header.Set("Authorization", "Bearer "+adminKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+			return false, fmt.Errorf("server answered %s: %s", resp.Status, apiErr.Error)
+		}
+		return false, fmt.Errorf("server answered %s", resp.Status)
+	}
+
+	var result testSubmissionResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return false, fmt.Errorf("invalid server response: %w", err)
+	}
+
+	switch {
+	case outputQuiet:
+		return result.Passed, nil
+	case format == formatJSON || format == formatYAML:
+		// Print the full report, with the result, metrics and notification
+		var report any
+		if err := json.Unmarshal(body, &report); err != nil {
+			return false, fmt.Errorf("invalid server response: %w", err)
+		}
+		return result.Passed, printStructured(format, report)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, stage := range result.Stages {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", stage.Name, stage.Status, stage.Detail)
+	}
+	w.Flush()
+
+	if result.Passed {
+		fmt.Println("Test passed")
+	} else {
+		fmt.Println("Test failed")
+	}
+	return result.Passed, nil
+}
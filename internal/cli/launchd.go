@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/launchd"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// reporterLabel is the launchd label of the reporter agent
+const reporterLabel = "io.cronmetrics.reporter"
+
+// launchdCmd represents the launchd command
+var launchdCmd = &cobra.Command{
+	Use:   "launchd",
+	Short: "Monitor macOS launchd jobs",
+	Long: `Monitor periodic jobs run by launchd on macOS.
+
+"launchd plist" generates the property list of a job, recording the job's
+name, host, API key and the server URL in its environment variables.
+"launchd report", run periodically by the agent from "launchd
+reporter-plist", reads the exit status of each such job from launchctl and
+submits a result for every job that ran since the previous report.`,
+}
+
+var (
+	launchdName       string
+	launchdHost       string
+	launchdAPIKey     string
+	launchdServer     string
+	launchdInterval   int
+	launchdReportRate int
+	launchdCalendar   string
+	launchdStdoutPath string
+	launchdStderrPath string
+	launchdDir        string
+	launchdDomain     string
+	launchdStatePath  string
+	launchdLaunchctl  string
+	launchdSign       bool
+	launchdRetries    int
+)
+
+func init() {
+	home, _ := os.UserHomeDir()
+	hostname, _ := os.Hostname()
+	hostname, _, _ = strings.Cut(hostname, ".")
+	agentsDir := filepath.Join(home, "Library", "LaunchAgents")
+
+	launchdPlistCmd.Flags().StringVarP(&launchdName, "name", "n", "", "job name (required)")
+	launchdPlistCmd.Flags().StringVar(&launchdHost, "host", hostname, "job host")
+	launchdPlistCmd.Flags().StringVar(&launchdAPIKey, "api-key", "", "the job's API key (required)")
+	launchdPlistCmd.Flags().StringVar(&launchdServer, "server", "", "cronmetrics server URL, e.g. https://cronmetrics.example.com or unix:/var/run/cronmetrics.sock (required)")
+	launchdPlistCmd.Flags().IntVar(&launchdInterval, "interval", 0, "run every this many seconds")
+	launchdPlistCmd.Flags().StringVar(&launchdCalendar, "calendar", "", `run on a crontab schedule, e.g. "30 2 * * 1,3,5"`)
+	launchdPlistCmd.Flags().StringVar(&launchdStdoutPath, "stdout", "", "file receiving the job's standard output")
+	launchdPlistCmd.Flags().StringVar(&launchdStderrPath, "stderr", "", "file receiving the job's standard error")
+	_ = launchdPlistCmd.MarkFlagRequired("name")
+	_ = launchdPlistCmd.MarkFlagRequired("api-key")
+	_ = launchdPlistCmd.MarkFlagRequired("server")
+
+	for _, cmd := range []*cobra.Command{launchdReportCmd, launchdReporterPlistCmd} {
+		cmd.Flags().StringVar(&launchdDir, "dir", agentsDir, "directory of the job plists")
+		cmd.Flags().StringVar(&launchdDomain, "domain", fmt.Sprintf("gui/%d", os.Getuid()), "launchd domain of the jobs (gui/<uid> for agents, system for daemons)")
+		cmd.Flags().StringVar(&launchdStatePath, "state", filepath.Join(home, "Library", "Application Support", "cronmetrics", "launchd-state.json"), "file keeping the run counts between reports")
+	}
+	launchdReportCmd.Flags().StringVar(&launchdLaunchctl, "launchctl", "launchctl", "path of launchctl")
+	launchdReportCmd.Flags().IntVar(&launchdRetries, "retries", 2, "retries of a failed submission, with exponential backoff")
+	for _, cmd := range []*cobra.Command{launchdReportCmd, launchdReporterPlistCmd} {
+		cmd.Flags().BoolVar(&launchdSign, "sign", false, "sign results with the job's API key instead of sending the key")
+	}
+	launchdReporterPlistCmd.Flags().IntVar(&launchdReportRate, "interval", 60, "report every this many seconds")
+
+	launchdCmd.AddCommand(launchdPlistCmd)
+	launchdCmd.AddCommand(launchdReportCmd)
+	launchdCmd.AddCommand(launchdReporterPlistCmd)
+}
+
+// launchdPlistCmd prints the plist of a monitored job
+var launchdPlistCmd = &cobra.Command{
+	Use:   "plist <label> -- <program> [args...]",
+	Short: "Generate the launchd plist of a monitored job",
+	Example: `  cronmetrics launchd plist com.example.backup --name backup --api-key cm_abc123... \
+    --server https://cronmetrics.example.com --calendar "0 3 * * *" \
+    -- /usr/local/bin/backup.sh --full > ~/Library/LaunchAgents/com.example.backup.plist
+  launchctl bootstrap gui/$(id -u) ~/Library/LaunchAgents/com.example.backup.plist`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if (launchdInterval > 0) == (launchdCalendar != "") {
+			logrus.Fatal("exactly one of --interval and --calendar is required")
+		}
+
+		job := &launchd.Job{
+			Label:      args[0],
+			Program:    args[1:],
+			Name:       launchdName,
+			Host:       launchdHost,
+			APIKey:     launchdAPIKey,
+			ServerURL:  launchdServer,
+			Interval:   launchdInterval,
+			StdoutPath: launchdStdoutPath,
+			StderrPath: launchdStderrPath,
+		}
+		if launchdCalendar != "" {
+			calendar, err := launchd.ParseCalendar(launchdCalendar)
+			if err != nil {
+				logrus.WithError(err).Fatal("invalid calendar")
+			}
+			job.Calendar = calendar
+		}
+
+		os.Stdout.Write(launchd.Plist(job))
+	},
+}
+
+// launchdReportCmd submits the results of launchd job runs
+var launchdReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Submit the results of launchd jobs that ran since the last report",
+	Long: `Submit the results of launchd jobs that ran since the last report.
+
+Jobs are the plists in --dir generated by "launchd plist". A job's result is
+a success when its last exit code is 0. The first report only records how
+many times each job already ran.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		jobs, err := launchd.LoadJobs(launchdDir)
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to read launchd jobs")
+		}
+
+		reporter := &launchd.Reporter{
+			Launchctl: &launchd.Launchctl{Path: launchdLaunchctl, Domain: launchdDomain},
+			StatePath: launchdStatePath,
+			Client:    &http.Client{Timeout: 30 * time.Second},
+			Retries:   launchdRetries,
+			Sign:      launchdSign,
+		}
+		submitted, err := reporter.Report(jobs)
+		fmt.Printf("Reported %d results for %d launchd jobs\n", submitted, len(jobs))
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to report some launchd jobs")
+		}
+	},
+}
+
+// launchdReporterPlistCmd prints the plist of the reporter agent
+var launchdReporterPlistCmd = &cobra.Command{
+	Use:   "reporter-plist",
+	Short: "Generate the launchd plist running the reporter periodically",
+	Example: `  cronmetrics launchd reporter-plist > ~/Library/LaunchAgents/` + reporterLabel + `.plist
+  launchctl bootstrap gui/$(id -u) ~/Library/LaunchAgents/` + reporterLabel + `.plist`,
+	Run: func(cmd *cobra.Command, args []string) {
+		executable, err := os.Executable()
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to locate the cronmetrics executable")
+		}
+
+		reportArgs := []string{
+			executable, "launchd", "report",
+			"--dir", launchdDir,
+			"--domain", launchdDomain,
+			"--state", launchdStatePath,
+		}
+		if launchdSign {
+			reportArgs = append(reportArgs, "--sign")
+		}
+		os.Stdout.Write(launchd.ReporterPlist(reporterLabel, launchdReportRate, reportArgs))
+	},
+}
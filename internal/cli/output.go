@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of the --output flag
+const (
+	formatTable = "table"
+	formatWide  = "wide"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+var outputFormats = []string{formatTable, formatWide, formatJSON, formatYAML}
+
+var (
+	outputFormat string
+	outputQuiet  bool
+	outputJSON   bool // Deprecated --json flag, same as --output json
+)
+
+// addOutputFlags registers the output flags shared by commands printing
+// jobs or results
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", formatTable, "output format: "+strings.Join(outputFormats, ", "))
+	cmd.Flags().BoolVarP(&outputQuiet, "quiet", "q", false, "print only IDs, one per line")
+	cmd.Flags().BoolVarP(&outputJSON, "json", "j", false, "output as JSON")
+	if err := cmd.Flags().MarkDeprecated("json", "use --output json instead"); err != nil {
+		panic(fmt.Sprintf("Failed to deprecate json flag: %v", err))
+	}
+}
+
+// outputMode returns the validated output format
+func outputMode() (string, error) {
+	if outputJSON {
+		return formatJSON, nil
+	}
+	if !slices.Contains(outputFormats, outputFormat) {
+		return "", fmt.Errorf("invalid output format %q: must be one of %s", outputFormat, strings.Join(outputFormats, ", "))
+	}
+	return outputFormat, nil
+}
+
+// printStructured prints v as indented JSON or as YAML. Both use the JSON
+// field names, so scripts can switch formats without renaming fields.
+func printStructured(format string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if format == formatJSON {
+		fmt.Println(string(data))
+		return nil
+	}
+
+	// JSON is valid YAML: decoding it into a node keeps the field order
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return fmt.Errorf("failed to convert to YAML: %w", err)
+	}
+	blockStyle(&node)
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	return encoder.Close()
+}
+
+// blockStyle drops the JSON flow style and quoting of node and its children;
+// the encoder quotes the scalars that need it
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// printIDs prints one ID per line
+func printIDs(ids []int) {
+	for _, id := range ids {
+		fmt.Println(id)
+	}
+}
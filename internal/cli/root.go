@@ -3,8 +3,13 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/logging"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/scaffold"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -40,13 +45,19 @@ func Execute() error {
 
 func init() {
 	// Global flags
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is /etc/cronmetrics/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file or directory of config fragments (default is /etc/cronmetrics/config.yaml)")
 	rootCmd.PersistentFlags().BoolVar(&dev, "dev", false, "run in development mode with debug logging and in-memory database")
 
 	// Add subcommands
 	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(jobCmd)
 	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(launchdCmd)
+	rootCmd.AddCommand(devCmd)
+	rootCmd.AddCommand(pingCmd)
+	rootCmd.AddCommand(wrapCmd)
+	rootCmd.AddCommand(importCmd)
 }
 
 // initLogging initializes the logging system
@@ -67,28 +78,9 @@ func initLogging() {
 		return
 	}
 
-	// Set log level
-	level, err := logrus.ParseLevel(cfg.Logging.Level)
-	if err != nil {
-		level = logrus.InfoLevel
-	}
-	logrus.SetLevel(level)
-
-	// Set log format
-	if cfg.Logging.Format == "text" {
-		logrus.SetFormatter(&logrus.TextFormatter{
-			FullTimestamp: true,
-		})
-	} else {
-		logrus.SetFormatter(&logrus.JSONFormatter{})
-	}
-
-	// Set log output
-	if cfg.Logging.Output != "stdout" && cfg.Logging.Output != "stderr" {
-		file, err := os.OpenFile(cfg.Logging.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600) // More restrictive permissions
-		if err == nil {
-			logrus.SetOutput(file)
-		}
+	// Set level, format, output and additional sinks
+	if err := logging.Configure(logrus.StandardLogger(), &cfg.Logging); err != nil {
+		logrus.WithError(err).Warn("failed to set up logging")
 	}
 }
 
@@ -106,17 +98,43 @@ func loadConfig() (*config.Config, error) {
 	return config.Load(configPath)
 }
 
+// openDatabase opens the configured database
+func openDatabase(cfg *config.Config) (*model.Database, error) {
+	source := cfg.Database.Path
+	switch cfg.Database.Driver {
+	case model.DriverPostgres, model.DriverMySQL:
+		source = cfg.Database.DSN
+	}
+	return model.OpenDatabase(cfg.Database.Driver, source, model.DatabaseOptions{
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: time.Duration(cfg.Database.ConnMaxLifetime) * time.Second,
+		BusyTimeout:     time.Duration(cfg.Database.BusyTimeout) * time.Second,
+		JournalMode:     cfg.Database.JournalMode,
+	})
+}
+
 // configCmd represents the config command
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Configuration management",
-	Long:  `Generate example configuration and manage config settings`,
+	Long:  `Generate example configuration and render the effective configuration`,
 }
 
 func init() {
+	configRenderCmd.Flags().StringArrayVar(&configOverrides, "set", nil, "override a setting, e.g. --set server.port=9090 (repeatable)")
+	configScrapeCmd.Flags().StringVar(&scrapeServer, "server", "", "server URL Prometheus scrapes (defaults to the configured one)")
+
 	configCmd.AddCommand(configExampleCmd)
+	configCmd.AddCommand(configRenderCmd)
+	configCmd.AddCommand(configScrapeCmd)
 }
 
+var (
+	configOverrides []string
+	scrapeServer    string
+)
+
 // configExampleCmd generates example configuration
 var configExampleCmd = &cobra.Command{
 	Use:   "example",
@@ -126,3 +144,78 @@ var configExampleCmd = &cobra.Command{
 		fmt.Print(config.GetConfigExample())
 	},
 }
+
+// configRenderCmd prints the effective configuration
+var configRenderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Print the effective configuration as YAML",
+	Long: `Print the effective configuration as YAML, after merging the defaults, the
+config file (or the fragments of a config directory), CRONMETRICS_*
+environment variables and --set overrides, in that order of precedence.
+
+--config may point to a conf.d-style directory: its .yaml, .yml and .json
+files are merged in file name order, later files overriding earlier ones.`,
+	Example: `  cronmetrics config render --config /etc/cronmetrics/conf.d --set server.port=9090 \
+    --set 'security.admin_api_keys=[key-one, key-two]'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath := cfgFile
+		if configPath == "" && !dev {
+			configPath = "/etc/cronmetrics/config.yaml"
+		}
+
+		rendered, err := config.Render(configPath, configOverrides)
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to render config")
+		}
+		os.Stdout.Write(rendered)
+	},
+}
+
+// configScrapeCmd prints the Prometheus scrape config of the server
+var configScrapeCmd = &cobra.Command{
+	Use:   "scrape",
+	Short: "Print the Prometheus scrape config of the server",
+	Long: `Print the scrape_configs entry Prometheus needs to scrape the metrics of
+the server, with the scrape credentials of metrics.auth: basic_auth for
+basic, or an authorization header for bearer.
+
+The server URL defaults to server.external_url, else the configured listen
+address. The output contains the scrape credentials; keep the Prometheus
+configuration readable by its owner only.`,
+	Example: `  cronmetrics config scrape --server https://cron.example.com >> /etc/prometheus/prometheus.yml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runConfigScrape(); err != nil {
+			logrus.WithError(err).Fatal("failed to generate scrape config")
+		}
+	},
+}
+
+func runConfigScrape() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	serverURL := scrapeServer
+	if serverURL == "" {
+		serverURL = strings.TrimRight(cfg.Server.ExternalURL, "/")
+		if serverURL == "" {
+			serverURL = configuredServerURL(cfg)
+		}
+		serverURL += cfg.Server.BasePath
+	}
+
+	snippet, err := scaffold.PrometheusScrapeConfig(&scaffold.Scrape{
+		ServerURL:   serverURL,
+		MetricsPath: cfg.Metrics.Path,
+		Auth:        cfg.Metrics.Auth,
+		Username:    cfg.Metrics.Username,
+		Password:    cfg.Metrics.Password,
+		BearerToken: cfg.Metrics.BearerToken,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Print(snippet)
+	return nil
+}
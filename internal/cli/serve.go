@@ -3,6 +3,7 @@ package cli
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,8 +11,15 @@ import (
 	"time"
 
 	"github.com/jaepetto/cron-exporter/pkg/api"
+	"github.com/jaepetto/cron-exporter/pkg/archive"
+	"github.com/jaepetto/cron-exporter/pkg/events"
+	"github.com/jaepetto/cron-exporter/pkg/ingest"
 	"github.com/jaepetto/cron-exporter/pkg/metrics"
 	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/notifier"
+	"github.com/jaepetto/cron-exporter/pkg/slo"
+	"github.com/jaepetto/cron-exporter/pkg/ticket"
+	"github.com/jaepetto/cron-exporter/pkg/unixsocket"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -25,7 +33,7 @@ and serve Prometheus metrics.
 
 The server provides:
 - REST API for job CRUD operations
-- Job result submission endpoint
+- Job result submission endpoint (and optional message queue ingestion)
 - Prometheus metrics endpoint
 - Health check endpoints`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -49,31 +57,155 @@ func runServer() error {
 	}).Info("starting server")
 
 	// Initialize database
-	db, err := model.NewDatabase(cfg.Database.Path)
+	db, err := openDatabase(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
 	defer db.Close()
 
-	// Configure database connection pool
-	sqlxDB := db.GetDB()
-	sqlxDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
-	sqlxDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
-	sqlxDB.SetConnMaxLifetime(time.Duration(cfg.Database.ConnMaxLifetime) * time.Second)
-
 	// Create stores
+	sqlxDB := db.GetDB()
 	jobStore := model.NewJobStore(sqlxDB)
 	jobResultStore := model.NewJobResultStore(sqlxDB)
 
+	// Export job and result events to a message broker
+	var eventHook model.ChangeHook
+	if cfg.Events.Enabled {
+		publisher, err := events.NewPublisher(&cfg.Events)
+		if err != nil {
+			return fmt.Errorf("failed to initialize event export: %w", err)
+		}
+
+		exporter := events.NewExporter(publisher, cfg.Events.BufferSize)
+		defer exporter.Close()
+		jobStore.SetChangeHook(exporter.Handle)
+		jobResultStore.SetChangeHook(exporter.Handle)
+		eventHook = exporter.Handle
+
+		logrus.WithField("driver", cfg.Events.Driver).Info("event export enabled")
+	}
+
+	// Open issues for jobs that keep failing
+	resultHook := eventHook
+	if cfg.Tickets.Enabled {
+		filer, err := ticket.NewFiler(cfg, jobStore, jobResultStore, model.NewAcknowledgementStore(sqlxDB))
+		if err != nil {
+			return fmt.Errorf("failed to initialize ticket creation: %w", err)
+		}
+		defer filer.Close()
+		resultHook = model.ChainHooks(resultHook, filer.Handle)
+		jobResultStore.SetChangeHook(resultHook)
+
+		logrus.WithField("teams", len(cfg.Tickets.Teams)).Info("ticket creation enabled")
+	}
+
+	// Give the jobs inheriting their threshold the configured defaults
+	if cfg.Defaults.Propagate {
+		updated, err := jobStore.PropagateDefaultThresholds(cfg.Defaults.FailureThreshold)
+		if err != nil {
+			return fmt.Errorf("failed to propagate default thresholds: %w", err)
+		}
+		logrus.WithField("jobs", len(updated)).Info("default thresholds propagated")
+	}
+
 	// Initialize metrics collector
 	metricsCollector := metrics.NewCollector(jobStore, jobResultStore)
-	if err := metricsCollector.Register(); err != nil {
-		return fmt.Errorf("failed to register metrics collector: %w", err)
+	metricsCollector.SetLabelFilter(metrics.NewLabelFilter(cfg.Metrics.LabelAllowlist, cfg.Metrics.LabelDenylist))
+	metricsCollector.SetHealthScorer(metrics.NewHealthScorer(cfg.Metrics.HealthGroupLabel, cfg.Metrics.CriticalityLabel, cfg.Metrics.CriticalityWeights))
+	sloTracker := slo.NewTracker(&cfg.SLO, jobStore, jobResultStore)
+	metricsCollector.SetSLOTracker(sloTracker)
+	statusMapping, err := metrics.NewStatusMapping(cfg.Metrics.MaintenanceStatus, cfg.Metrics.PausedStatus)
+	if err != nil {
+		return fmt.Errorf("failed to configure metrics: %w", err)
+	}
+	metricsCollector.SetStatusMapping(statusMapping)
+	if cfg.Tickets.Enabled || cfg.Notifier.Enabled {
+		metricsCollector.AddSource(metrics.NewNotificationSource(model.NewNotificationStore(sqlxDB)))
+	}
+	metricsCollector.AddSource(metrics.NewClockSkewSource(jobResultStore))
+	metricsCollector.AddSource(metrics.NewThroughputSource(jobResultStore))
+	metricsCollector.AddSource(metrics.NewDurationSource(jobResultStore, cfg.Metrics.DurationBuckets))
+	metricsCollector.AddSource(metrics.NewOverlapSource(jobResultStore))
+	metricsCollector.AddSource(metrics.NewRecoverySource(jobResultStore, cfg.Metrics.HealthGroupLabel, cfg.Metrics.MTTRWindowDays))
+
+	// Notify channels when jobs start failing, miss their deadline or recover
+	if cfg.Notifier.Enabled {
+		jobNotifier, err := notifier.NewNotifier(cfg, jobStore, metricsCollector)
+		if err != nil {
+			return fmt.Errorf("failed to initialize notifications: %w", err)
+		}
+		defer jobNotifier.Close()
+		jobResultStore.SetChangeHook(model.ChainHooks(resultHook, jobNotifier.Handle))
+
+		logrus.WithFields(logrus.Fields{
+			"channels": len(cfg.Notifier.Channels),
+			"routes":   len(cfg.Notifier.Routes),
+		}).Info("notifications enabled")
 	}
 
 	// Create API server
 	apiServer := api.NewServer(cfg, jobStore, jobResultStore, metricsCollector)
 
+	// Accept job results from a message queue as well as over HTTP
+	if cfg.Ingest.Enabled {
+		consumer, err := ingest.NewConsumer(&cfg.Ingest, apiServer)
+		if err != nil {
+			return fmt.Errorf("failed to initialize result ingestion: %w", err)
+		}
+		defer consumer.Close()
+
+		logrus.WithField("driver", cfg.Ingest.Driver).Info("job result ingestion enabled")
+	}
+
+	// Record job results from the cron daemon's log entries
+	if cfg.Syslog.Enabled {
+		tailer, err := ingest.NewSyslogTailer(&cfg.Syslog, jobStore, apiServer.ObservedResultRecorder())
+		if err != nil {
+			return fmt.Errorf("failed to initialize syslog ingestion: %w", err)
+		}
+		defer tailer.Close()
+
+		logrus.WithField("source", cfg.Syslog.Source).Info("syslog ingestion enabled")
+	}
+
+	// Background tasks run until shutdown
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Archive aged job results in the background
+	if cfg.Archive.Enabled {
+		store, err := archive.NewStore(&cfg.Archive)
+		if err != nil {
+			return fmt.Errorf("failed to initialize archive: %w", err)
+		}
+
+		archiver := archive.NewArchiver(jobResultStore, store)
+		go archiver.Run(backgroundCtx,
+			time.Duration(cfg.Archive.RetentionDays)*24*time.Hour,
+			time.Duration(cfg.Archive.Interval)*time.Second)
+
+		logrus.WithFields(logrus.Fields{
+			"destination":    cfg.Archive.Destination,
+			"retention_days": cfg.Archive.RetentionDays,
+		}).Info("job result archival enabled")
+	}
+
+	// Record started runs whose result never arrived
+	go ingest.NewLostRunDetector(&cfg.LostRuns, jobResultStore).Run(backgroundCtx)
+
+	// Return jobs to active when their maintenance window ends
+	go apiServer.RunMaintenanceExpiry(backgroundCtx, time.Minute)
+
+	// Forget the changes pollers no longer need
+	go apiServer.RunChangePruning(backgroundCtx, time.Hour)
+
+	// Warn about objectives burning their error budget
+	if sloTracker != nil {
+		go sloTracker.Run(backgroundCtx, eventHook)
+
+		logrus.WithField("objectives", len(cfg.SLO.Objectives)).Info("SLO tracking enabled")
+	}
+
 	// Create HTTP server
 	server := &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
@@ -83,15 +215,57 @@ func runServer() error {
 		IdleTimeout:  time.Duration(cfg.Server.IdleTimeout) * time.Second,
 	}
 
+	// Obtain certificates automatically instead of reading cert/key files
+	var challengeServer *http.Server
+	if cfg.Security.RequireHTTPS && cfg.Security.ACME.Enabled {
+		manager := api.NewACMEManager(&cfg.Security.ACME)
+		server.TLSConfig = manager.TLSConfig()
+
+		if cfg.Security.ACME.HTTPPort != 0 {
+			challengeServer = &http.Server{
+				Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Security.ACME.HTTPPort),
+				Handler:      manager.HTTPHandler(nil),
+				ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
+				WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+			}
+			go func() {
+				logrus.WithField("addr", challengeServer.Addr).Info("ACME challenge listener started")
+				if err := challengeServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					logrus.WithError(err).Fatal("ACME challenge listener failed to start")
+				}
+			}()
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"domains":   cfg.Security.ACME.Domains,
+			"cache_dir": cfg.Security.ACME.CacheDir,
+		}).Info("automatic certificate management enabled")
+	}
+
+	// Listen on a Unix domain socket, e.g. behind a local reverse proxy, or on host:port
+	var listener net.Listener
+	if path, ok := unixsocket.Path(cfg.Server.Listen); ok {
+		mode, _ := unixsocket.ParseMode(cfg.Server.SocketMode) // Checked by config validation
+		listener, err = unixsocket.Listen(path, mode)
+		server.Addr = cfg.Server.Listen
+	} else {
+		listener, err = net.Listen("tcp", server.Addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", server.Addr, err)
+	}
+
 	// Start server in goroutine
 	go func() {
 		logrus.WithField("addr", server.Addr).Info("server listening")
 
 		var err error
-		if cfg.Security.RequireHTTPS {
-			err = server.ListenAndServeTLS(cfg.Security.TLSCertFile, cfg.Security.TLSKeyFile)
+		if cfg.Security.RequireHTTPS && cfg.Security.ACME.Enabled {
+			err = server.ServeTLS(listener, "", "")
+		} else if cfg.Security.RequireHTTPS {
+			err = server.ServeTLS(listener, cfg.Security.TLSCertFile, cfg.Security.TLSKeyFile)
 		} else {
-			err = server.ListenAndServe()
+			err = server.Serve(listener)
 		}
 
 		if err != nil && err != http.ErrServerClosed {
@@ -105,11 +279,18 @@ func runServer() error {
 	<-quit
 
 	logrus.Info("shutting down server...")
+	stopBackground()
 
 	// Give outstanding requests 30 seconds to complete
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	if challengeServer != nil {
+		if err := challengeServer.Shutdown(ctx); err != nil {
+			logrus.WithError(err).Warn("ACME challenge listener forced to shutdown")
+		}
+	}
+
 	if err := server.Shutdown(ctx); err != nil {
 		return fmt.Errorf("server forced to shutdown: %w", err)
 	}
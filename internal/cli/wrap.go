@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/report"
+	"github.com/jaepetto/cron-exporter/pkg/util"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// wrapOutputLimit is how much of the end of a command's output is reported
+const wrapOutputLimit = 4096
+
+var (
+	wrapName    string
+	wrapHost    string
+	wrapAPIKey  string
+	wrapServer  string
+	wrapSign    bool
+	wrapTimeout time.Duration
+	wrapRetries int
+	wrapStart   bool
+	wrapRunID   string
+	wrapShard   int
+	wrapShards  int
+
+	wrapBreakerThreshold int
+	wrapBreakerCooldown  time.Duration
+	wrapBreakerState     string
+)
+
+func init() {
+	hostname, _ := os.Hostname()
+	hostname, _, _ = strings.Cut(hostname, ".")
+	if host := os.Getenv(report.EnvJobHost); host != "" {
+		hostname = host
+	}
+
+	wrapCmd.Flags().StringVarP(&wrapName, "name", "n", os.Getenv(report.EnvJobName), "job name (default $"+report.EnvJobName+")")
+	wrapCmd.Flags().StringVar(&wrapHost, "host", hostname, "job host (default $"+report.EnvJobHost+", else the short hostname)")
+	wrapCmd.Flags().StringVar(&wrapAPIKey, "api-key", os.Getenv(report.EnvAPIKey), "the job's API key (default $"+report.EnvAPIKey+")")
+	wrapCmd.Flags().StringVar(&wrapServer, "server", os.Getenv(report.EnvServerURL), "cronmetrics server URL or unix:/path socket (default $"+report.EnvServerURL+")")
+	wrapCmd.Flags().BoolVar(&wrapSign, "sign", false, "sign the result with the job's API key instead of sending the key")
+	wrapCmd.Flags().DurationVar(&wrapTimeout, "timeout", 10*time.Second, "time allowed for each submission attempt")
+	wrapCmd.Flags().IntVar(&wrapRetries, "retries", report.DefaultRetries, "retries of a failed submission, with exponential backoff")
+	wrapCmd.Flags().BoolVar(&wrapStart, "ping-start", false, "report that the run started before running the command, so a run that never reports its result is recorded as lost")
+	wrapCmd.Flags().StringVar(&wrapRunID, "run-id", os.Getenv(report.EnvRunID), "ID grouping the start and results of the run, e.g. shared by retries (default $"+report.EnvRunID+", else random)")
+	wrapCmd.Flags().IntVar(&wrapShard, "shard", -1, "index from 0 of the shard this command is, among the child tasks of a fan-out run sharing --run-id (-1: not a shard)")
+	wrapCmd.Flags().IntVar(&wrapShards, "shards", 0, "number of child tasks of the fan-out run, so missing shards are known (0: unknown)")
+
+	statePath, err := os.UserCacheDir()
+	if err == nil {
+		statePath = filepath.Join(statePath, "cronmetrics", "breaker.json")
+	}
+	wrapCmd.Flags().IntVar(&wrapBreakerThreshold, "breaker-threshold", 5, "consecutive failed submissions after which submissions are skipped for --breaker-cooldown (0 disables)")
+	wrapCmd.Flags().DurationVar(&wrapBreakerCooldown, "breaker-cooldown", 5*time.Minute, "how long submissions are skipped once the breaker opens")
+	wrapCmd.Flags().StringVar(&wrapBreakerState, "breaker-state", statePath, "file keeping the breaker state between runs")
+}
+
+// wrapCmd runs a command and reports its result
+var wrapCmd = &cobra.Command{
+	Use:     "wrap -- <program> [args...]",
+	Aliases: []string{"report"},
+	Short:   "Run a command and report its result to its job",
+	Long: `Run a command and submit its result: a success when it exits 0, a failure
+otherwise, with its run time and the end of its output. The command's
+output is passed through and wrap exits with the command's exit code, also
+when the result cannot be submitted.
+
+Failed submissions are retried with exponential backoff. After
+--breaker-threshold consecutive runs failed to submit, the following runs
+skip submitting for --breaker-cooldown rather than waiting on an
+unreachable server.
+
+With --ping-start, the run is also reported when it starts. If the host
+goes down or wrap is killed before the result is submitted, the server then
+records the run as lost rather than as never started.
+
+The start and result are submitted with a run ID. Retries of a run that
+share its --run-id are grouped with it, and their result takes the
+duration of the whole run.
+
+The child tasks of a fan-out run each report their --shard under the run's
+shared --run-id. Their job's shard policy aggregates them into its status.
+
+The job and server default to the variables set by "job scaffold":
+` + report.EnvServerURL + `, ` + report.EnvJobName + `, ` + report.EnvJobHost + ` and ` + report.EnvAPIKey + `.`,
+	Example: `  cronmetrics wrap --name backup --api-key cm_abc123... \
+    --server https://cronmetrics.example.com -- /usr/local/bin/backup.sh --full`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(runWrap(args))
+	},
+}
+
+// runWrap runs the command in args and returns its exit code
+func runWrap(args []string) int {
+	runID := wrapRunID
+	if runID == "" {
+		if wrapShard >= 0 {
+			logrus.Warn("--shard without --run-id: the shard is reported as a run of its own")
+		}
+		runID, _ = util.GenerateNonce()
+	}
+
+	submitter := wrapSubmitter()
+	if wrapStart && submitter != nil {
+		started := &model.JobResult{
+			JobName:   wrapName,
+			Host:      wrapHost,
+			Status:    model.ResultStarted,
+			Timestamp: time.Now().UTC(),
+			RunID:     runID,
+		}
+		if err := submitter.Submit(wrapServer, wrapAPIKey, wrapSign, started); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"job_name": wrapName,
+				"host":     wrapHost,
+			}).Error("failed to report the job start")
+		}
+	}
+
+	output := &tailBuffer{limit: wrapOutputLimit}
+	command := exec.Command(args[0], args[1:]...) // #nosec G204 -- running the given command is the point
+	command.Stdin = os.Stdin
+	command.Stdout = io.MultiWriter(os.Stdout, output)
+	command.Stderr = io.MultiWriter(os.Stderr, output)
+
+	start := time.Now()
+	err := command.Run()
+	duration := time.Since(start)
+
+	exitCode := 0
+	result := &model.JobResult{
+		JobName:   wrapName,
+		Host:      wrapHost,
+		Status:    "success",
+		Duration:  int(duration.Round(time.Second) / time.Second),
+		Output:    output.String(),
+		Timestamp: time.Now().UTC(),
+		RunID:     runID,
+	}
+	if wrapShard >= 0 {
+		result.Shard = &wrapShard
+		result.Shards = wrapShards
+	}
+	if err != nil {
+		exitCode = 127
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+			if exitCode < 0 {
+				exitCode = 128 // Killed by a signal
+			}
+		}
+		result.Status = "failure"
+		if result.Output != "" && !strings.HasSuffix(result.Output, "\n") {
+			result.Output += "\n"
+		}
+		result.Output += wrapFailure(args[0], err)
+	}
+
+	result.ExitCode = &exitCode
+
+	if submitter == nil {
+		logrus.Error("cannot submit the job result: the job name, API key and server are required")
+		return exitCode
+	}
+	if err := submitter.Submit(wrapServer, wrapAPIKey, wrapSign, result); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"job_name": wrapName,
+			"host":     wrapHost,
+		}).Error("failed to submit the job result")
+	}
+	return exitCode
+}
+
+// wrapSubmitter returns the submitter of the flags, or nil when the job or
+// server is not given
+func wrapSubmitter() *report.Submitter {
+	if wrapName == "" || wrapAPIKey == "" || wrapServer == "" {
+		return nil
+	}
+	submitter := &report.Submitter{
+		Client:  &http.Client{Timeout: wrapTimeout},
+		Retries: wrapRetries,
+	}
+	if wrapBreakerThreshold > 0 {
+		submitter.Breaker = &report.Breaker{
+			Threshold: wrapBreakerThreshold,
+			Cooldown:  wrapBreakerCooldown,
+			StatePath: wrapBreakerState,
+		}
+	}
+	return submitter
+}
+
+// wrapFailure describes why the command failed
+func wrapFailure(program string, err error) string {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Sprintf("cronmetrics wrap: %s: %s", program, exitErr.ProcessState)
+	}
+	return fmt.Sprintf("cronmetrics wrap: %v", err)
+}
+
+// tailBuffer keeps the last limit bytes written to it
+type tailBuffer struct {
+	limit     int
+	data      []byte
+	truncated bool
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	if len(b.data) > b.limit {
+		b.data = append(b.data[:0], b.data[len(b.data)-b.limit:]...)
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+// String returns the kept output, marked when earlier output was dropped
+func (b *tailBuffer) String() string {
+	text := strings.ToValidUTF8(string(b.data), "")
+	if b.truncated {
+		return "...\n" + text
+	}
+	return text
+}
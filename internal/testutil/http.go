@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -15,6 +18,7 @@ import (
 type HTTPClient struct {
 	BaseURL string
 	Headers map[string]string
+	client  *http.Client
 	t       *testing.T
 }
 
@@ -23,10 +27,19 @@ func NewHTTPClient(t *testing.T, baseURL string) *HTTPClient {
 	return &HTTPClient{
 		BaseURL: baseURL,
 		Headers: make(map[string]string),
+		client:  &http.Client{},
 		t:       t,
 	}
 }
 
+// WithCookies keeps cookies between requests, like a browser session
+func (c *HTTPClient) WithCookies() *HTTPClient {
+	jar, err := cookiejar.New(nil)
+	require.NoError(c.t, err, "Failed to create cookie jar")
+	c.client = &http.Client{Jar: jar}
+	return c
+}
+
 // WithHeaders adds headers to the HTTP client
 func (c *HTTPClient) WithHeaders(headers map[string]string) *HTTPClient {
 	for k, v := range headers {
@@ -55,6 +68,25 @@ func (c *HTTPClient) DELETE(path string) *HTTPResponse {
 	return c.Request("DELETE", path, nil)
 }
 
+// PostForm makes a POST request to the specified path with a form-encoded body
+func (c *HTTPClient) PostForm(path string, form url.Values) *HTTPResponse {
+	req, err := http.NewRequest("POST", c.BaseURL+path, strings.NewReader(form.Encode()))
+	require.NoError(c.t, err, fmt.Sprintf("Failed to create POST request to %s", path))
+
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	require.NoError(c.t, err, fmt.Sprintf("Failed to execute POST request to %s", path))
+
+	return &HTTPResponse{
+		Response: resp,
+		t:        c.t,
+	}
+}
+
 // Request makes an HTTP request with the specified method, path, and body
 func (c *HTTPClient) Request(method, path string, body interface{}) *HTTPResponse {
 	url := c.BaseURL + path
@@ -79,8 +111,7 @@ func (c *HTTPClient) Request(method, path string, body interface{}) *HTTPRespons
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.client.Do(req)
 	require.NoError(c.t, err, fmt.Sprintf("Failed to execute %s request to %s", method, url))
 
 	return &HTTPResponse{
@@ -1,6 +1,7 @@
 package testutil
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net/http/httptest"
 	"testing"
@@ -8,6 +9,10 @@ import (
 	"github.com/jaepetto/cron-exporter/pkg/api"
 	"github.com/jaepetto/cron-exporter/pkg/config"
 	"github.com/jaepetto/cron-exporter/pkg/metrics"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/notifier"
+	"github.com/jaepetto/cron-exporter/pkg/slo"
+	"github.com/jaepetto/cron-exporter/pkg/ticket"
 	"github.com/stretchr/testify/require"
 )
 
@@ -16,22 +21,54 @@ type TestServer struct {
 	Server   *httptest.Server
 	Config   *config.Config
 	Database *TestDatabase
+	Metrics  *metrics.Collector
+	Notifier *notifier.Notifier // nil unless notifications are enabled
+	filer    *ticket.Filer      // nil unless tickets are enabled
 	t        *testing.T
 }
 
 // NewTestServer creates a new test HTTP server with a test database
 func NewTestServer(t *testing.T) *TestServer {
-	// Create test database
-	testDB := NewInMemoryTestDatabase(t)
+	return startTestServer(t, NewInMemoryTestDatabase(t), newTestConfig())
+}
+
+// NewTestServerWithConfig creates a test server after letting configure
+// adjust the default test configuration
+func NewTestServerWithConfig(t *testing.T, configure func(cfg *config.Config)) *TestServer {
+	cfg := newTestConfig()
+	configure(cfg)
+
+	return startTestServer(t, NewInMemoryTestDatabase(t), cfg)
+}
+
+// NewTestServerWithDashboard creates a test server with the dashboard mounted
+// at /dashboard behind admin key authentication
+func NewTestServerWithDashboard(t *testing.T) *TestServer {
+	return NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Dashboard = config.DashboardConfig{
+			Enabled:         true,
+			Path:            "/dashboard",
+			Title:           "Test Dashboard",
+			RefreshInterval: 5,
+			AuthRequired:    true,
+			PageSize:        25,
+			SSEHeartbeat:    30,
+			KioskRotation:   15,
+			KioskPageSize:   24,
+		}
+	})
+}
 
-	// Create test configuration
-	cfg := &config.Config{
+// newTestConfig returns the configuration shared by all test servers
+func newTestConfig() *config.Config {
+	return &config.Config{
 		Server: config.ServerConfig{
 			Host:         "localhost",
 			Port:         0, // Will be set by httptest.Server
 			ReadTimeout:  30,
 			WriteTimeout: 30,
 			IdleTimeout:  120,
+			StrictJSON:   true,
 		},
 		Database: config.DatabaseConfig{
 			Path:            ":memory:",
@@ -40,7 +77,10 @@ func NewTestServer(t *testing.T) *TestServer {
 			ConnMaxLifetime: 300,
 		},
 		Metrics: config.MetricsConfig{
-			Path: "/metrics",
+			Path:              "/metrics",
+			MaintenanceStatus: "-1",
+			PausedStatus:      "-1",
+			MTTRWindowDays:    30,
 		},
 		Logging: config.LoggingConfig{
 			Level:  "info",
@@ -53,17 +93,74 @@ func NewTestServer(t *testing.T) *TestServer {
 			AdminAPIKeys: []string{"admin-api-key"},
 			TLSCertFile:  "",
 			TLSKeyFile:   "",
+
+			ActionLinkSecret: "test-action-link-secret-0123456789abcdef",
+			ActionLinkTTL:    3600,
+		},
+		Defaults: config.DefaultsConfig{
+			AutomaticFailureThreshold: 3600,
+		},
+		LostRuns: config.LostRunsConfig{
+			Interval:        60,
+			DefaultDuration: 3600,
+		},
+		ClockSkew: config.ClockSkewConfig{
+			Threshold: 300,
+		},
+		ChangeFeed: config.ChangeFeedConfig{
+			RetentionDays: 7,
+		},
+		RateLimit: config.RateLimitConfig{
+			Action: "reject",
+		},
+		Scripts: config.ScriptsConfig{
+			MaxSteps:  100000,
+			MaxMemory: 16,
+			Timeout:   1,
 		},
 	}
+}
 
+// startTestServer wires the API server for cfg on top of testDB
+func startTestServer(t *testing.T, testDB *TestDatabase, cfg *config.Config) *TestServer {
 	// Create stores
 	jobStore := testDB.GetJobStore()
 	jobResultStore := testDB.GetJobResultStore()
 
 	// Create metrics collector
 	metricsCollector := metrics.NewCollector(jobStore, jobResultStore)
-	err := metricsCollector.Register()
-	require.NoError(t, err, "Failed to register metrics collector")
+	metricsCollector.SetLabelFilter(metrics.NewLabelFilter(cfg.Metrics.LabelAllowlist, cfg.Metrics.LabelDenylist))
+	metricsCollector.SetHealthScorer(metrics.NewHealthScorer(cfg.Metrics.HealthGroupLabel, cfg.Metrics.CriticalityLabel, cfg.Metrics.CriticalityWeights))
+	metricsCollector.SetSLOTracker(slo.NewTracker(&cfg.SLO, jobStore, jobResultStore))
+	statusMapping, err := metrics.NewStatusMapping(cfg.Metrics.MaintenanceStatus, cfg.Metrics.PausedStatus)
+	require.NoError(t, err, "Failed to create status mapping")
+	metricsCollector.SetStatusMapping(statusMapping)
+	if cfg.Tickets.Enabled || cfg.Notifier.Enabled {
+		metricsCollector.AddSource(metrics.NewNotificationSource(model.NewNotificationStore(jobStore.DB())))
+	}
+	metricsCollector.AddSource(metrics.NewClockSkewSource(jobResultStore))
+	metricsCollector.AddSource(metrics.NewThroughputSource(jobResultStore))
+	metricsCollector.AddSource(metrics.NewDurationSource(jobResultStore, cfg.Metrics.DurationBuckets))
+	metricsCollector.AddSource(metrics.NewOverlapSource(jobResultStore))
+	metricsCollector.AddSource(metrics.NewRecoverySource(jobResultStore, cfg.Metrics.HealthGroupLabel, cfg.Metrics.MTTRWindowDays))
+
+	// Open issues for jobs that keep failing
+	var filer *ticket.Filer
+	var resultHook model.ChangeHook
+	if cfg.Tickets.Enabled {
+		filer, err = ticket.NewFiler(cfg, jobStore, jobResultStore, model.NewAcknowledgementStore(jobStore.DB()))
+		require.NoError(t, err, "Failed to create ticket filer")
+		resultHook = filer.Handle
+		jobResultStore.SetChangeHook(resultHook)
+	}
+
+	// Notify channels when jobs change state
+	var jobNotifier *notifier.Notifier
+	if cfg.Notifier.Enabled {
+		jobNotifier, err = notifier.NewNotifier(cfg, jobStore, metricsCollector)
+		require.NoError(t, err, "Failed to create notifier")
+		jobResultStore.SetChangeHook(model.ChainHooks(resultHook, jobNotifier.Handle))
+	}
 
 	// Create API server
 	apiServer := api.NewServer(cfg, jobStore, jobResultStore, metricsCollector)
@@ -75,6 +172,9 @@ func NewTestServer(t *testing.T) *TestServer {
 		Server:   server,
 		Config:   cfg,
 		Database: testDB,
+		Metrics:  metricsCollector,
+		Notifier: jobNotifier,
+		filer:    filer,
 		t:        t,
 	}
 }
@@ -98,6 +198,12 @@ func (ts *TestServer) Close() {
 	if ts.Server != nil {
 		ts.Server.Close()
 	}
+	if ts.filer != nil {
+		ts.filer.Close()
+	}
+	if ts.Notifier != nil {
+		ts.Notifier.Close()
+	}
 	if ts.Database != nil {
 		ts.Database.Close()
 	}
@@ -121,6 +227,14 @@ func (ts *TestServer) AdminHeaders() map[string]string {
 	}
 }
 
+// DashboardHeaders returns HTTP headers with basic auth credentials for the dashboard
+func (ts *TestServer) DashboardHeaders() map[string]string {
+	credentials := base64.StdEncoding.EncodeToString([]byte("admin:" + ts.Config.Security.AdminAPIKeys[0]))
+	return map[string]string{
+		"Authorization": "Basic " + credentials,
+	}
+}
+
 // JobHeaders returns HTTP headers with job API key for job result submissions
 func (ts *TestServer) JobHeaders() map[string]string {
 	if len(ts.Config.Security.APIKeys) > 0 {
@@ -0,0 +1,92 @@
+package actionlink
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Supported actions
+const (
+	ActionMaintenance = "maintenance" // Put the job in maintenance
+	ActionActivate    = "activate"    // End maintenance
+	ActionAcknowledge = "acknowledge" // Record that someone is handling a failure
+)
+
+// Actions lists the supported actions
+var Actions = []string{ActionMaintenance, ActionActivate, ActionAcknowledge}
+
+var (
+	// ErrInvalid is returned for unknown actions and bad or missing signatures
+	ErrInvalid = errors.New("invalid action link")
+	// ErrExpired is returned for correctly signed links past their expiry
+	ErrExpired = errors.New("action link has expired")
+)
+
+// IsValidAction reports whether action is a supported action
+func IsValidAction(action string) bool {
+	for _, a := range Actions {
+		if action == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Link is a one-click action link: it authorizes exactly one action on one
+// job until it expires. The signature is an HMAC-SHA256 over the job ID,
+// action and expiry keyed with the server's action link secret, so links
+// cannot be forged or altered without it.
+type Link struct {
+	JobID   int
+	Action  string
+	Expires time.Time
+}
+
+// signature computes the link signature
+func (l Link) signature(secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d\n%s\n%d", l.JobID, l.Action, l.Expires.Unix())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Query returns the signed query parameters for the link
+func (l Link) Query(secret string) url.Values {
+	return url.Values{
+		"job":     {strconv.Itoa(l.JobID)},
+		"expires": {strconv.FormatInt(l.Expires.Unix(), 10)},
+		"sig":     {l.signature(secret)},
+	}
+}
+
+// Parse verifies the signed query parameters of a link for action and
+// returns the link they authorize
+func Parse(secret, action string, query url.Values) (*Link, error) {
+	if secret == "" || !IsValidAction(action) {
+		return nil, ErrInvalid
+	}
+
+	jobID, err := strconv.Atoi(query.Get("job"))
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+
+	link := &Link{JobID: jobID, Action: action, Expires: time.Unix(expires, 0)}
+	if !hmac.Equal([]byte(query.Get("sig")), []byte(link.signature(secret))) {
+		return nil, ErrInvalid
+	}
+	if time.Now().After(link.Expires) {
+		return nil, ErrExpired
+	}
+
+	return link, nil
+}
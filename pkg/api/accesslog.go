@@ -0,0 +1,148 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// redactedValue replaces the values of redacted query parameters, and the
+// API keys of dry run responses
+const redactedValue = "REDACTED"
+
+// accessLog decides whether and at which level requests are logged, and
+// with which fields
+type accessLog struct {
+	basePath    string
+	level       accessLogLevel
+	paths       []accessLogPath
+	sample      uint64
+	count       atomic.Uint64 // Successful requests seen, for sampling
+	fields      map[string]bool
+	redactQuery bool
+	ips         *util.IPHasher // nil to keep client addresses
+}
+
+// accessLogLevel is a log level, or off
+type accessLogLevel struct {
+	level logrus.Level
+	off   bool
+}
+
+// accessLogPath overrides the level for a path, or everything below it
+// when the path ends in "/"
+type accessLogPath struct {
+	path  string
+	level accessLogLevel
+}
+
+// newAccessLog returns the access log settings of cfg
+func newAccessLog(cfg *config.Config) *accessLog {
+	access := &accessLog{
+		basePath: cfg.Server.BasePath,
+		level:    parseAccessLogLevel(cfg.Logging.Access.Level),
+		sample:   uint64(max(cfg.Logging.Access.Sample, 1)),
+		fields:   make(map[string]bool),
+
+		redactQuery: cfg.Logging.Access.RedactQuery,
+		ips:         cfg.Logging.IPHasher(),
+	}
+	for _, path := range cfg.Logging.Access.Paths {
+		access.paths = append(access.paths, accessLogPath{path: path.Path, level: parseAccessLogLevel(path.Level)})
+	}
+	fields := cfg.Logging.Access.Fields
+	if len(fields) == 0 {
+		fields = slices.DeleteFunc(slices.Clone(config.AccessLogFields), func(field string) bool { return field == "query" })
+	}
+	for _, field := range fields {
+		access.fields[field] = true
+	}
+	return access
+}
+
+// parseAccessLogLevel parses a level or "off"; unset levels are info
+func parseAccessLogLevel(value string) accessLogLevel {
+	if strings.EqualFold(value, "off") {
+		return accessLogLevel{off: true}
+	}
+	level, err := logrus.ParseLevel(value)
+	if err != nil {
+		level = logrus.InfoLevel
+	}
+	return accessLogLevel{level: level}
+}
+
+// levelFor returns the level to log a request for path at, and false when
+// it is not logged. The most specific path override applies. Only one in
+// every sample successful requests is logged; failed ones always are.
+func (a *accessLog) levelFor(path string, status int) (logrus.Level, bool) {
+	path = strings.TrimPrefix(path, a.basePath)
+
+	level, matched := a.level, ""
+	for _, override := range a.paths {
+		matches := path == override.path ||
+			(strings.HasSuffix(override.path, "/") && strings.HasPrefix(path, override.path))
+		if matches && len(override.path) > len(matched) {
+			level, matched = override.level, override.path
+		}
+	}
+	if level.off {
+		return 0, false
+	}
+
+	if status < 400 && a.sample > 1 && (a.count.Add(1)-1)%a.sample != 0 {
+		return 0, false
+	}
+	return level.level, true
+}
+
+// entryFields returns the configured fields of the entry of a request
+func (a *accessLog) entryFields(r *http.Request, status int, durationMs int64) logrus.Fields {
+	all := map[string]func() interface{}{
+		"method":         func() interface{} { return r.Method },
+		"path":           func() interface{} { return r.URL.Path },
+		"query":          func() interface{} { return a.query(r.URL.RawQuery) },
+		"status":         func() interface{} { return status },
+		"duration_ms":    func() interface{} { return durationMs },
+		"remote_addr":    func() interface{} { return a.remoteAddr(r) },
+		"user_agent":     func() interface{} { return r.UserAgent() },
+		"content_length": func() interface{} { return r.ContentLength },
+	}
+
+	fields := make(logrus.Fields, len(a.fields))
+	for name := range a.fields {
+		if value, ok := all[name]; ok {
+			fields[name] = value()
+		}
+	}
+	return fields
+}
+
+// remoteAddr returns the client address to log. Hashes are of the host
+// alone, so that they do not change with the client's port.
+func (a *accessLog) remoteAddr(r *http.Request) string {
+	if a.ips == nil {
+		return r.RemoteAddr
+	}
+	return a.ips.Hash(remoteHost(r))
+}
+
+// query returns a raw query to log, keeping only the names of parameters
+// when queries are redacted, e.g. "key=REDACTED&status=REDACTED"
+func (a *accessLog) query(raw string) string {
+	if !a.redactQuery || raw == "" {
+		return raw
+	}
+	params := strings.Split(raw, "&")
+	for i, param := range params {
+		if name, _, ok := strings.Cut(param, "="); ok {
+			params[i] = name + "=" + redactedValue
+		}
+	}
+	return strings.Join(params, "&")
+}
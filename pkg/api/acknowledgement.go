@@ -0,0 +1,81 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// AcknowledgementRequest is the body of POST /api/job/{id}/acknowledgements
+type AcknowledgementRequest struct {
+	Author    string `json:"author,omitempty"` // Defaults to "api"
+	TicketID  string `json:"ticket_id,omitempty"`
+	TicketURL string `json:"ticket_url,omitempty"`
+}
+
+// handleJobAcknowledgements lists (GET) or records (POST) who is handling a
+// job's failures, and the tickets tracking the work
+func (s *Server) handleJobAcknowledgements(w http.ResponseWriter, r *http.Request, jobID int) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	job, err := s.jobStore.GetJobByID(jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.writeErrorResponse(w, http.StatusNotFound, "job not found")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get job: %v", err))
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		acks, err := s.ackStore.ListAcknowledgements(job.Name, job.Host)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list acknowledgements: %v", err))
+			return
+		}
+		s.writeJSONResponse(w, http.StatusOK, acks)
+		return
+	}
+
+	var req AcknowledgementRequest
+	if err := s.decodeBody(r, &req); err != nil && err != io.EOF {
+		s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+
+	ack := &model.Acknowledgement{
+		JobName:   job.Name,
+		Host:      job.Host,
+		Author:    req.Author,
+		TicketID:  strings.TrimSpace(req.TicketID),
+		TicketURL: strings.TrimSpace(req.TicketURL),
+	}
+	if ack.Author == "" {
+		ack.Author = "api"
+	}
+	if err := ack.Validate(); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.ackStore.CreateAcknowledgement(ack); err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to create acknowledgement: %v", err))
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"job_name":  job.Name,
+		"host":      job.Host,
+		"ticket_id": ack.TicketID,
+	}).Info("job acknowledged")
+
+	s.writeJSONResponse(w, http.StatusCreated, ack)
+}
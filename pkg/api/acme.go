@@ -0,0 +1,24 @@
+package api
+
+import (
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// NewACMEManager returns a certificate manager that obtains and renews
+// certificates for the configured domains, caching them in cfg.CacheDir.
+// Its TLSConfig serves TLS-ALPN-01 challenges and its HTTPHandler answers
+// HTTP-01 challenges and redirects everything else to HTTPS.
+func NewACMEManager(cfg *config.ACMEConfig) *autocert.Manager {
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		Email:      cfg.Email,
+	}
+	if cfg.DirectoryURL != "" {
+		manager.Client = &acme.Client{DirectoryURL: cfg.DirectoryURL}
+	}
+	return manager
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/actionlink"
+	"github.com/sirupsen/logrus"
+)
+
+// maxActionLinkTTL caps the lifetime of an action link
+const maxActionLinkTTL = 30 * 24 * 60 * 60
+
+// ActionLinkRequest selects the action and lifetime of a new action link
+type ActionLinkRequest struct {
+	Action string `json:"action"`
+	TTL    int    `json:"ttl,omitempty"` // Lifetime in seconds, defaults to security.action_link_ttl
+}
+
+// ActionLinkResponse is a signed one-click link for a job action
+type ActionLinkResponse struct {
+	JobID     int       `json:"job_id"`
+	Action    string    `json:"action"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// handleCreateActionLink generates a signed, expiring link that performs one
+// action on a job without logging in, e.g. for use in notifications
+func (s *Server) handleCreateActionLink(w http.ResponseWriter, r *http.Request, jobID int) {
+	if r.Method != http.MethodPost {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Only admin can hand out action links
+	if !isAdminRequest(r) {
+		s.writeErrorResponse(w, http.StatusForbidden, "admin access required")
+		return
+	}
+
+	// Links are served by the dashboard and signed with the configured secret
+	if s.config.Security.ActionLinkSecret == "" || !s.config.Dashboard.Enabled {
+		s.writeErrorResponse(w, http.StatusServiceUnavailable, "action links are disabled (set security.action_link_secret and enable the dashboard)")
+		return
+	}
+
+	var req ActionLinkRequest
+	if err := s.decodeBody(r, &req); err != nil && err != io.EOF {
+		s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+
+	if !actionlink.IsValidAction(req.Action) {
+		s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("action must be one of: %s", strings.Join(actionlink.Actions, ", ")))
+		return
+	}
+
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = s.config.Security.ActionLinkTTL
+	}
+	if ttl < 1 || ttl > maxActionLinkTTL {
+		s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("ttl must be between 1 and %d seconds", maxActionLinkTTL))
+		return
+	}
+
+	job, err := s.jobStore.GetJobByID(jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.writeErrorResponse(w, http.StatusNotFound, "job not found")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get job: %v", err))
+		return
+	}
+
+	link := actionlink.Link{
+		JobID:   job.ID,
+		Action:  req.Action,
+		Expires: time.Now().Add(time.Duration(ttl) * time.Second).Truncate(time.Second),
+	}
+
+	linkURL := strings.TrimRight(s.config.Server.ExternalURL, "/") + s.config.Server.BasePath + s.config.Dashboard.Path +
+		"/actions/" + link.Action + "?" + link.Query(s.config.Security.ActionLinkSecret).Encode()
+
+	logrus.WithFields(logrus.Fields{
+		"job_id":     job.ID,
+		"action":     link.Action,
+		"expires_at": link.Expires,
+	}).Info("action link created")
+
+	s.writeJSONResponse(w, http.StatusCreated, ActionLinkResponse{
+		JobID:     job.ID,
+		Action:    link.Action,
+		URL:       linkURL,
+		ExpiresAt: link.Expires.UTC(),
+	})
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// AnnotationRequest is the body of POST /api/job/{id}/annotations
+type AnnotationRequest struct {
+	Comment  string `json:"comment"`
+	ResultID int    `json:"result_id,omitempty"` // Omitted to annotate the job's failures as a whole
+	Author   string `json:"author,omitempty"`    // Defaults to "api"
+}
+
+// handleJobAnnotations lists (GET) or adds (POST) a job's annotations, or
+// deletes one (DELETE /api/job/{id}/annotations/{annotationID})
+func (s *Server) handleJobAnnotations(w http.ResponseWriter, r *http.Request, jobID int, annotationID string) {
+	if annotationID == "" && r.Method != http.MethodGet && r.Method != http.MethodPost ||
+		annotationID != "" && r.Method != http.MethodDelete {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	job, err := s.jobStore.GetJobByID(jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.writeErrorResponse(w, http.StatusNotFound, "job not found")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get job: %v", err))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		annotations, err := s.annotationStore.ListAnnotations(job.Name, job.Host)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list annotations: %v", err))
+			return
+		}
+		s.writeJSONResponse(w, http.StatusOK, annotations)
+
+	case http.MethodPost:
+		var req AnnotationRequest
+		if err := s.decodeBody(r, &req); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+			return
+		}
+
+		annotation := &model.ResultAnnotation{
+			JobName:  job.Name,
+			Host:     job.Host,
+			ResultID: req.ResultID,
+			Comment:  strings.TrimSpace(req.Comment),
+			Author:   req.Author,
+		}
+		if annotation.Comment == "" || len(annotation.Comment) > model.MaxAnnotationLength {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("comment is required and at most %d characters", model.MaxAnnotationLength))
+			return
+		}
+		if annotation.Author == "" {
+			annotation.Author = "api"
+		}
+
+		if err := s.annotationStore.CreateAnnotation(annotation); err != nil {
+			if errors.Is(err, model.ErrResultNotFound) {
+				s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("result %d is not a result of this job", req.ResultID))
+				return
+			}
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to create annotation: %v", err))
+			return
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"job_name":  job.Name,
+			"host":      job.Host,
+			"result_id": annotation.ResultID,
+		}).Info("job annotated")
+
+		s.writeJSONResponse(w, http.StatusCreated, annotation)
+
+	case http.MethodDelete:
+		id, err := strconv.Atoi(annotationID)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, "invalid annotation ID format (must be a number)")
+			return
+		}
+
+		if err := s.annotationStore.DeleteAnnotation(id, job.Name, job.Host); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				s.writeErrorResponse(w, http.StatusNotFound, "annotation not found")
+				return
+			}
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to delete annotation: %v", err))
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// sinceNow is the since value that starts following the change feed from
+// the latest change
+const sinceNow = "now"
+
+// handleChanges serves the change feed: the job and result changes after
+// the since cursor, oldest first, for integrations that poll instead of
+// holding a connection. Each page's next_cursor is the since of the next
+// poll; since=now returns no changes and the cursor to start from.
+func (s *Server) handleChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	_, limit, _, err := parsePageParams(r)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	since := r.URL.Query().Get("since")
+	if since == sinceNow {
+		cursor, err := s.changeStore.LatestCursor()
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list changes: %v", err))
+			return
+		}
+		s.writeJSONResponse(w, http.StatusOK, &model.ChangePage{Changes: []*model.Change{}, NextCursor: cursor})
+		return
+	}
+
+	page, err := s.changeStore.ListChanges(since, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrInvalidCursor):
+			s.writeErrorResponse(w, http.StatusBadRequest, "invalid cursor")
+		case errors.Is(err, model.ErrCursorExpired):
+			s.writeErrorResponse(w, http.StatusGone, "cursor expired: changes after it were pruned; get a new cursor with since=now, then list jobs in full")
+		default:
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list changes: %v", err))
+		}
+		return
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, page)
+}
+
+// RunChangePruning prunes the changes older than the change feed's
+// retention every interval until ctx is cancelled
+func (s *Server) RunChangePruning(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	changeStore := s.changeStore.WithContext(ctx)
+	retention := time.Duration(s.config.ChangeFeed.RetentionDays) * 24 * time.Hour
+	for {
+		pruned, err := changeStore.PruneChanges(time.Now().UTC().Add(-retention))
+		if err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Error("failed to prune the change feed")
+		} else if pruned > 0 {
+			logrus.WithField("changes", pruned).Debug("change feed pruned")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
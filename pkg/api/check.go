@@ -0,0 +1,42 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// handleCheckJob evaluates a job's status and metrics immediately, e.g.
+// after fixing the job, instead of waiting for the next scrape
+func (s *Server) handleCheckJob(w http.ResponseWriter, r *http.Request, jobID int) {
+	if r.Method != http.MethodPost {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	job, err := s.jobStore.GetJobByID(jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.writeErrorResponse(w, http.StatusNotFound, "job not found")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get job: %v", err))
+		return
+	}
+
+	check, err := s.metrics.CheckJob(r.Context(), job)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to check job: %v", err))
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"job_name": job.Name,
+		"host":     job.Host,
+		"reason":   check.Reason,
+	}).Info("job checked on demand")
+
+	s.writeJSONResponse(w, http.StatusOK, check)
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// CloneJobRequest names the job created by a clone. Omitted fields keep the
+// source job's value, but the resulting name+host must be new.
+type CloneJobRequest struct {
+	Name string `json:"job_name,omitempty"`
+	Host string `json:"host,omitempty"`
+}
+
+// cloneJob returns a copy of source under a new identity with a fresh API key.
+// Threshold, description and labels (including ownership labels) are carried
+// over, an inherited threshold staying inherited; the clone starts out active
+// regardless of the source's status.
+func cloneJob(source *model.Job, name, host string, defaults *config.DefaultsConfig) (*model.Job, error) {
+	apiKey, err := util.GenerateAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	labels := make(map[string]string, len(source.Labels))
+	for key, value := range source.Labels {
+		labels[key] = value
+	}
+
+	clone := &model.Job{
+		Name:                      name,
+		Host:                      host,
+		ApiKey:                    apiKey,
+		AutomaticFailureThreshold: source.AutomaticFailureThreshold,
+		ThresholdInherited:        source.ThresholdInherited,
+		Labels:                    labels,
+		Description:               source.Description,
+		Type:                      source.Type,
+		ThroughputFloor:           source.ThroughputFloor,
+		Schedule:                  source.Schedule,
+		ShardPolicy:               source.ShardPolicy,
+		MinInterval:               source.MinInterval,
+	}
+	applyJobDefaults(clone, defaults)
+
+	return clone, nil
+}
+
+// handleCloneJob creates a new job from an existing one
+func (s *Server) handleCloneJob(w http.ResponseWriter, r *http.Request, jobID int) {
+	if r.Method != http.MethodPost {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Only admin can create jobs
+	if !isAdminRequest(r) {
+		s.writeErrorResponse(w, http.StatusForbidden, "admin access required")
+		return
+	}
+
+	var req CloneJobRequest
+	if err := s.decodeBody(r, &req); err != nil && err != io.EOF {
+		s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+
+	source, err := s.jobStore.GetJobByID(jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.writeErrorResponse(w, http.StatusNotFound, "job not found")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get job: %v", err))
+		return
+	}
+
+	name, host := req.Name, req.Host
+	if name == "" {
+		name = source.Name
+	}
+	if host == "" {
+		host = source.Host
+	}
+	if name == source.Name && host == source.Host {
+		s.writeErrorResponse(w, http.StatusBadRequest, "clone requires a new job name or host")
+		return
+	}
+	if err := model.CheckJobIdentity(name, host); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	clone, err := cloneJob(source, name, host, &s.config.Defaults)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if isDryRun(r) {
+		if _, err := s.jobStore.GetJob(clone.Name, clone.Host); err == nil {
+			s.writeErrorResponse(w, http.StatusConflict, "job already exists")
+			return
+		}
+		s.writeDryRunResponse(w, reconcileCreated, nil, clone)
+		return
+	}
+
+	if err := s.jobStore.CreateJob(clone); err != nil {
+		if model.IsUniqueViolation(err) {
+			s.writeErrorResponse(w, http.StatusConflict, "job already exists")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to create job: %v", err))
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"source_job_id": source.ID,
+		"job_id":        clone.ID,
+		"job_name":      clone.Name,
+		"host":          clone.Host,
+	}).Info("job cloned")
+
+	s.writeJSONResponse(w, http.StatusCreated, clone)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// serverHandler is a handler method of Server, e.g. (*Server).handleJob
+type serverHandler func(s *Server, w http.ResponseWriter, r *http.Request)
+
+// requestTimeout is how long a request may take: most of the server's write
+// timeout, leaving time to answer before the connection is closed. Zero
+// means no deadline.
+func (s *Server) requestTimeout() time.Duration {
+	return time.Duration(s.config.Server.WriteTimeout) * time.Second * 9 / 10
+}
+
+// withDeadline runs handler with a copy of the server whose stores query
+// the database with the request's context, given a deadline by
+// requestTimeout, so a slow query cannot hold a request indefinitely.
+// Queries past the deadline fail, and their 500 answers become 503.
+func (s *Server) withDeadline(handler serverHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if timeout := s.requestTimeout(); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		handler(s.withContext(ctx), &deadlineWriter{ResponseWriter: w, ctx: ctx}, r.WithContext(ctx))
+	}
+}
+
+// adminRoute serves handler to admins, with the deadline of withDeadline
+func (s *Server) adminRoute(handler serverHandler) http.HandlerFunc {
+	return s.withDeadline(func(s *Server, w http.ResponseWriter, r *http.Request) {
+		s.withAuth(func(w http.ResponseWriter, r *http.Request) { handler(s, w, r) })(w, r)
+	})
+}
+
+// jobRoute serves handler to jobs, with the deadline of withDeadline
+func (s *Server) jobRoute(handler serverHandler) http.HandlerFunc {
+	return s.withDeadline(func(s *Server, w http.ResponseWriter, r *http.Request) {
+		s.withJobAuth(func(w http.ResponseWriter, r *http.Request) { handler(s, w, r) })(w, r)
+	})
+}
+
+// metricsRoute serves handler to scrapers from the allowed sources, with
+// the deadline of withDeadline
+func (s *Server) metricsRoute(handler serverHandler) http.HandlerFunc {
+	return s.withDeadline(func(s *Server, w http.ResponseWriter, r *http.Request) {
+		s.withScrapeSources(s.withMetricsAuth(func(w http.ResponseWriter, r *http.Request) { handler(s, w, r) }))(w, r)
+	})
+}
+
+// withContext returns a copy of the server whose stores query with ctx
+func (s *Server) withContext(ctx context.Context) *Server {
+	scoped := *s
+	scoped.jobStore = s.jobStore.WithContext(ctx)
+	scoped.jobResultStore = s.jobResultStore.WithContext(ctx)
+	scoped.adminKeyStore = s.adminKeyStore.WithContext(ctx)
+	scoped.rejectionStore = s.rejectionStore.WithContext(ctx)
+	scoped.nonceStore = s.nonceStore.WithContext(ctx)
+	scoped.annotationStore = s.annotationStore.WithContext(ctx)
+	scoped.ackStore = s.ackStore.WithContext(ctx)
+	scoped.notificationStore = s.notificationStore.WithContext(ctx)
+	scoped.changeStore = s.changeStore.WithContext(ctx)
+	return &scoped
+}
+
+// deadlineWriter answers 503 instead of 500 once the request's context is
+// done: the database was too slow, not broken, and the request may be
+// retried
+type deadlineWriter struct {
+	http.ResponseWriter
+	ctx context.Context
+}
+
+func (w *deadlineWriter) WriteHeader(code int) {
+	if code == http.StatusInternalServerError && w.ctx.Err() != nil {
+		code = http.StatusServiceUnavailable
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// unknownFieldsError lists the fields of a request body that its type does
+// not have
+type unknownFieldsError struct {
+	fields []string
+}
+
+func (e *unknownFieldsError) Error() string {
+	if len(e.fields) == 1 {
+		return "unknown field " + e.fields[0]
+	}
+	return "unknown fields " + strings.Join(e.fields, ", ")
+}
+
+// decodeBody decodes the JSON body of a request into v, like decodeJSON. An
+// empty body returns io.EOF.
+func (s *Server) decodeBody(r *http.Request, v interface{}) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	return s.decodeJSON(data, v)
+}
+
+// decodeJSON decodes a JSON request body into v. Unless server.strict_json
+// is off, fields v has no place for are refused, all of them listed in the
+// error, so a misspelled field such as "treshold" is not silently ignored.
+func (s *Server) decodeJSON(data []byte, v interface{}) error {
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(v); err != nil {
+		return err
+	}
+	if !s.config.Server.StrictJSON {
+		return nil
+	}
+
+	if unknown := unknownFields(data, reflect.TypeOf(v), ""); len(unknown) > 0 {
+		return &unknownFieldsError{fields: unknown}
+	}
+	return nil
+}
+
+// unknownFields returns the paths of the object keys in data that typ has
+// no field for, e.g. "treshold" or "jobs[2].treshold". Keys match fields
+// case-insensitively, as encoding/json decodes them.
+func unknownFields(data []byte, typ reflect.Type, path string) []string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if reflect.PointerTo(typ).Implements(jsonUnmarshalerType) || reflect.PointerTo(typ).Implements(textUnmarshalerType) {
+		return nil
+	}
+
+	var unknown []string
+	switch typ.Kind() {
+	case reflect.Struct:
+		var object map[string]json.RawMessage
+		if json.Unmarshal(data, &object) != nil {
+			return nil
+		}
+		fields := jsonFields(typ)
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			field, ok := fields[key]
+			if !ok {
+				for name, candidate := range fields {
+					if strings.EqualFold(name, key) {
+						field, ok = candidate, true
+						break
+					}
+				}
+			}
+			if !ok {
+				unknown = append(unknown, fmt.Sprintf("%q", path+key))
+				continue
+			}
+			unknown = append(unknown, unknownFields(object[key], field, path+key+".")...)
+		}
+
+	case reflect.Map:
+		var object map[string]json.RawMessage
+		if json.Unmarshal(data, &object) != nil {
+			return nil
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			unknown = append(unknown, unknownFields(object[key], typ.Elem(), path+key+".")...)
+		}
+
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if json.Unmarshal(data, &items) != nil {
+			return nil
+		}
+		prefix := strings.TrimSuffix(path, ".")
+		for i, item := range items {
+			unknown = append(unknown, unknownFields(item, typ.Elem(), fmt.Sprintf("%s[%d].", prefix, i))...)
+		}
+	}
+	return unknown
+}
+
+// jsonFields returns the types of a struct's fields by JSON name, with the
+// fields of embedded structs
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for embeddedName, embeddedType := range jsonFields(embedded) {
+					if _, ok := fields[embeddedName]; !ok {
+						fields[embeddedName] = embeddedType
+					}
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// FieldChange describes the old and new value of a single job field
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// DryRunResponse describes what a mutating request would have done
+type DryRunResponse struct {
+	DryRun  bool                   `json:"dry_run"`
+	Action  string                 `json:"action"`
+	Changed bool                   `json:"changed"`
+	Diff    map[string]FieldChange `json:"diff"`
+	Job     *model.Job             `json:"job,omitempty"`
+}
+
+// isDryRun reports whether the request asked for validation without applying changes
+func isDryRun(r *http.Request) bool {
+	dryRun, err := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	return err == nil && dryRun
+}
+
+// jobDiff returns the user-visible fields that differ between before and after.
+// A nil before describes a creation, a nil after describes a deletion.
+func jobDiff(before, after *model.Job) map[string]FieldChange {
+	diff := make(map[string]FieldChange)
+
+	fields := func(job *model.Job) map[string]interface{} {
+		if job == nil {
+			return map[string]interface{}{}
+		}
+		return map[string]interface{}{
+			"job_name":                    job.Name,
+			"host":                        job.Host,
+			"api_key":                     job.ApiKey,
+			"automatic_failure_threshold": job.AutomaticFailureThreshold,
+			"threshold_inherited":         job.ThresholdInherited,
+			"labels":                      job.Labels,
+			"allowed_cidrs":               job.AllowedCIDRs,
+			"description":                 job.Description,
+			"type":                        job.Type,
+			"throughput_floor":            job.ThroughputFloor,
+			"schedule":                    job.Schedule,
+			"shard_policy":                job.ShardPolicy,
+			"min_interval":                job.MinInterval,
+			"status":                      job.Status,
+		}
+	}
+
+	old, updated := fields(before), fields(after)
+	for _, key := range []string{"job_name", "host", "api_key", "automatic_failure_threshold", "threshold_inherited", "labels", "allowed_cidrs", "description", "type", "throughput_floor", "schedule", "shard_policy", "min_interval", "status"} {
+		oldValue, newValue := old[key], updated[key]
+
+		if key == "labels" {
+			oldLabels, _ := oldValue.(map[string]string)
+			newLabels, _ := newValue.(map[string]string)
+			if (before != nil && after != nil) && labelsEqual(oldLabels, newLabels) {
+				continue
+			}
+		} else if key == "api_key" {
+			// Keys are secrets, and those generated by a dry run are never
+			// stored: the diff only tells that the key is set or changes
+			if oldValue == newValue {
+				continue
+			}
+			oldValue, newValue = redactAPIKey(oldValue), redactAPIKey(newValue)
+		} else if key == "allowed_cidrs" {
+			oldCIDRs, _ := oldValue.([]string)
+			newCIDRs, _ := newValue.([]string)
+			if slices.Equal(oldCIDRs, newCIDRs) {
+				continue
+			}
+		} else if oldValue == newValue {
+			continue
+		}
+
+		diff[key] = FieldChange{Old: oldValue, New: newValue}
+	}
+
+	return diff
+}
+
+// redactAPIKey returns the redaction marker for a set API key value
+func redactAPIKey(value interface{}) interface{} {
+	if key, ok := value.(string); ok && key != "" {
+		return redactedValue
+	}
+	return value
+}
+
+// redactedJob returns a copy of job without its API key, for dry run responses
+func redactedJob(job *model.Job) *model.Job {
+	if job == nil || job.ApiKey == "" {
+		return job
+	}
+	redacted := *job
+	redacted.ApiKey = redactedValue
+	return &redacted
+}
+
+// writeDryRunResponse reports the would-be effect of a mutating request
+func (s *Server) writeDryRunResponse(w http.ResponseWriter, action string, before, after *model.Job) {
+	diff := jobDiff(before, after)
+
+	job := after
+	if job == nil {
+		job = before
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, DryRunResponse{
+		DryRun:  true,
+		Action:  action,
+		Changed: action != reconcileUnchanged,
+		Diff:    diff,
+		Job:     redactedJob(job),
+	})
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// JobExistsResponse reports whether a job with the given name and host exists
+type JobExistsResponse struct {
+	Exists bool `json:"exists"`
+	ID     int  `json:"id,omitempty"` // ID of the existing job
+}
+
+// handleJobExists checks a name+host pair for collisions before a create
+func (s *Server) handleJobExists(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Only admin can look up jobs
+	if !isAdminRequest(r) {
+		s.writeErrorResponse(w, http.StatusForbidden, "admin access required")
+		return
+	}
+
+	name, host := r.URL.Query().Get("name"), r.URL.Query().Get("host")
+	if name == "" || host == "" {
+		s.writeErrorResponse(w, http.StatusBadRequest, "name and host are required")
+		return
+	}
+
+	job, err := s.jobStore.GetJob(name, host)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.writeJSONResponse(w, http.StatusOK, JobExistsResponse{Exists: false})
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get job: %v", err))
+		return
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, JobExistsResponse{Exists: true, ID: job.ID})
+}
@@ -0,0 +1,550 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/graphql"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// maxGraphQLResults is the most results a job's results field returns
+const maxGraphQLResults = 100
+
+// handleGraphQL runs a GraphQL query over jobs, their results, roll-ups,
+// hosts and SLOs, so tools fetch the nested data they need in one round
+// trip. Queries are POSTed as JSON or sent as GET parameters; a GET without
+// a query returns the schema in SDL.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphql.Request
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid variables: %v", err))
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := s.decodeBody(r, &req); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+			return
+		}
+	default:
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	schema, err := newGraphQLSchema(s)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to build the GraphQL schema: %v", err))
+		return
+	}
+
+	if r.Method == http.MethodGet && req.Query == "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(schema.SDL()))
+		return
+	}
+	if req.Query == "" {
+		s.writeErrorResponse(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	response := schema.Execute(r.Context(), &req)
+	statusCode := http.StatusOK
+	if response.Data == nil {
+		statusCode = http.StatusBadRequest
+	}
+	s.writeJSONResponse(w, statusCode, response)
+}
+
+// graphQLResolver resolves the fields of one GraphQL request. It caches
+// what several fields of the request need, such as the status of each job,
+// so nested queries do not repeat it.
+type graphQLResolver struct {
+	server     *Server
+	now        time.Time
+	jobs       []*model.Job                                // Every job, once listed
+	checks     map[int]*graphQLCheck                       // By job ID
+	counts     map[int]map[model.JobRef]model.ResultCounts // By window, in days
+	recoveries map[int]map[model.JobRef][]*model.Recovery  // By window, in days
+	statuses   []*model.SLOStatus                          // Once evaluated
+}
+
+// graphQLCheck is the cronjob_status of a job
+type graphQLCheck struct {
+	Value    float64
+	Reason   string
+	Excluded bool // Left out of roll-up scores: in maintenance, paused or snoozed
+}
+
+// graphQLSLA is a job's share of successful results over a window, and the
+// time it took to recover from its failures
+type graphQLSLA struct {
+	WindowDays  int
+	Total       int
+	Failed      int
+	SuccessRate *float64 // nil without results
+	Recoveries  int
+	MTTR        *float64 // Seconds; nil without recoveries
+}
+
+// graphQLGroup is the roll-up of the jobs sharing a label value
+type graphQLGroup struct {
+	Value       string
+	Jobs        []*model.Job
+	Succeeding  int
+	Failing     int
+	Excluded    int
+	HealthScore *float64 // nil when every job is excluded
+}
+
+// graphQLHost is a host with its jobs
+type graphQLHost struct {
+	Host     string
+	JobCount int
+}
+
+// newGraphQLSchema creates the GraphQL schema with resolvers scoped to one
+// request of s
+func newGraphQLSchema(s *Server) (*graphql.Schema, error) {
+	r := &graphQLResolver{
+		server:     s,
+		now:        time.Now().UTC(),
+		checks:     make(map[int]*graphQLCheck),
+		counts:     make(map[int]map[model.JobRef]model.ResultCounts),
+		recoveries: make(map[int]map[model.JobRef][]*model.Recovery),
+	}
+
+	job := &graphql.Object{
+		Name:        "Job",
+		Description: "A monitored cron job",
+		Fields: []*graphql.FieldDef{
+			{Name: "id", Type: "Int!", Resolve: jobField(func(job *model.Job) interface{} { return job.ID })},
+			{Name: "name", Type: "String!", Resolve: jobField(func(job *model.Job) interface{} { return job.Name })},
+			{Name: "host", Type: "String!", Resolve: jobField(func(job *model.Job) interface{} { return job.Host })},
+			{Name: "status", Description: "active, maintenance or paused", Type: "String!", Resolve: jobField(func(job *model.Job) interface{} { return job.Status })},
+			{Name: "labels", Type: "JSON", Resolve: jobField(func(job *model.Job) interface{} { return job.Labels })},
+			{Name: "description", Type: "String", Resolve: jobField(func(job *model.Job) interface{} { return nonEmpty(job.Description) })},
+			{Name: "threshold", Description: "Seconds without a result before the job is failing", Type: "Int!", Resolve: jobField(func(job *model.Job) interface{} { return job.AutomaticFailureThreshold })},
+			{Name: "schedule", Description: "Crontab schedule the job's deadlines follow, in UTC", Type: "String", Resolve: jobField(func(job *model.Job) interface{} { return nonEmpty(job.Schedule) })},
+			{Name: "shardPolicy", Description: "How the shards of a fan-out run make its status: all or any", Type: "String", Resolve: jobField(func(job *model.Job) interface{} { return nonEmpty(job.ShardPolicy) })},
+			{Name: "lastReportedAt", Type: "Time!", Resolve: jobField(func(job *model.Job) interface{} { return job.LastReportedAt })},
+			{Name: "snoozedUntil", Type: "Time", Resolve: jobField(func(job *model.Job) interface{} { return job.SnoozedUntil })},
+			{Name: "check", Description: "The job's cronjob_status as of now", Type: "Check!", Resolve: func(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+				return r.check(ctx, source.(*model.Job)), nil
+			}},
+			{
+				Name:        "results",
+				Description: "The job's latest results, newest first",
+				Type:        "[Result!]!",
+				Args:        []*graphql.Arg{{Name: "limit", Type: "Int", Default: 10}},
+				Resolve:     r.results,
+			},
+			{
+				Name:        "sla",
+				Description: "The job's share of successful results and mean time to recovery over the last windowDays",
+				Type:        "SLA!",
+				Args:        []*graphql.Arg{{Name: "windowDays", Type: "Int", Default: 30}},
+				Resolve:     r.sla,
+			},
+		},
+	}
+
+	check := &graphql.Object{
+		Name: "Check",
+		Fields: []*graphql.FieldDef{
+			{Name: "value", Description: "The cronjob_status value", Type: "Float!", Resolve: checkField(func(check *graphQLCheck) interface{} { return check.Value })},
+			{Name: "reason", Description: "e.g. success, failure, missed_deadline", Type: "String!", Resolve: checkField(func(check *graphQLCheck) interface{} { return check.Reason })},
+			{Name: "excluded", Description: "In maintenance, paused or snoozed", Type: "Boolean!", Resolve: checkField(func(check *graphQLCheck) interface{} { return check.Excluded })},
+		},
+	}
+
+	result := &graphql.Object{
+		Name:        "Result",
+		Description: "A job result",
+		Fields: []*graphql.FieldDef{
+			{Name: "status", Description: "success, failure or lost", Type: "String!", Resolve: resultField(func(result *model.JobResult) interface{} { return result.Status })},
+			{Name: "timestamp", Type: "Time!", Resolve: resultField(func(result *model.JobResult) interface{} { return result.Timestamp })},
+			{Name: "duration", Description: "Seconds", Type: "Int!", Resolve: resultField(func(result *model.JobResult) interface{} { return result.Duration })},
+			{Name: "output", Type: "String", Resolve: resultField(func(result *model.JobResult) interface{} { return nonEmpty(result.Output) })},
+			{Name: "labels", Type: "JSON", Resolve: resultField(func(result *model.JobResult) interface{} { return result.Labels })},
+			{Name: "receivedAt", Type: "Time", Resolve: resultField(func(result *model.JobResult) interface{} { return result.ReceivedAt })},
+			{Name: "sourceIp", Type: "String", Resolve: resultField(func(result *model.JobResult) interface{} { return nonEmpty(result.SourceIP) })},
+			{Name: "clockSkewed", Type: "Boolean!", Resolve: resultField(func(result *model.JobResult) interface{} { return result.ClockSkewed })},
+			{Name: "source", Description: "How the result was submitted, e.g. ping; null for the JSON API", Type: "String", Resolve: resultField(func(result *model.JobResult) interface{} { return nonEmpty(result.Source) })},
+		},
+	}
+
+	sla := &graphql.Object{
+		Name: "SLA",
+		Fields: []*graphql.FieldDef{
+			{Name: "windowDays", Type: "Int!", Resolve: slaField(func(sla *graphQLSLA) interface{} { return sla.WindowDays })},
+			{Name: "total", Description: "Results in the window", Type: "Int!", Resolve: slaField(func(sla *graphQLSLA) interface{} { return sla.Total })},
+			{Name: "failed", Description: "Failed and lost results in the window", Type: "Int!", Resolve: slaField(func(sla *graphQLSLA) interface{} { return sla.Failed })},
+			{Name: "successRate", Description: "Share of the results that succeeded, 0-1; null without results", Type: "Float", Resolve: slaField(func(sla *graphQLSLA) interface{} { return sla.SuccessRate })},
+			{Name: "recoveries", Description: "Incidents the job recovered from in the window, from a first failure to the next success", Type: "Int!", Resolve: slaField(func(sla *graphQLSLA) interface{} { return sla.Recoveries })},
+			{Name: "mttr", Description: "Mean time to recovery of the incidents in seconds; null without recoveries", Type: "Float", Resolve: slaField(func(sla *graphQLSLA) interface{} { return sla.MTTR })},
+		},
+	}
+
+	host := &graphql.Object{
+		Name: "Host",
+		Fields: []*graphql.FieldDef{
+			{Name: "host", Type: "String!", Resolve: hostField(func(host *graphQLHost) interface{} { return host.Host })},
+			{Name: "jobCount", Type: "Int!", Resolve: hostField(func(host *graphQLHost) interface{} { return host.JobCount })},
+			{Name: "jobs", Type: "[Job!]!", Resolve: func(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+				return r.filterJobs(nil, source.(*graphQLHost).Host, "")
+			}},
+		},
+	}
+
+	group := &graphql.Object{
+		Name:        "Group",
+		Description: "The roll-up of the jobs sharing a label value",
+		Fields: []*graphql.FieldDef{
+			{Name: "value", Description: "The label value; empty for the jobs without the label", Type: "String!", Resolve: groupField(func(group *graphQLGroup) interface{} { return group.Value })},
+			{Name: "total", Type: "Int!", Resolve: groupField(func(group *graphQLGroup) interface{} { return len(group.Jobs) })},
+			{Name: "succeeding", Type: "Int!", Resolve: groupField(func(group *graphQLGroup) interface{} { return group.Succeeding })},
+			{Name: "failing", Description: "Failed, lost or past their deadline", Type: "Int!", Resolve: groupField(func(group *graphQLGroup) interface{} { return group.Failing })},
+			{Name: "excluded", Description: "In maintenance, paused or snoozed", Type: "Int!", Resolve: groupField(func(group *graphQLGroup) interface{} { return group.Excluded })},
+			{Name: "healthScore", Description: "Share of the jobs not excluded that are succeeding, 0-100", Type: "Float", Resolve: groupField(func(group *graphQLGroup) interface{} { return group.HealthScore })},
+			{Name: "jobs", Type: "[Job!]!", Resolve: groupField(func(group *graphQLGroup) interface{} { return group.Jobs })},
+		},
+	}
+
+	objective := &graphql.Object{
+		Name:        "SLO",
+		Description: "The error budget of a service level objective",
+		Fields: []*graphql.FieldDef{
+			{Name: "name", Type: "String!", Resolve: sloField(func(status *model.SLOStatus) interface{} { return status.Name })},
+			{Name: "target", Type: "Float!", Resolve: sloField(func(status *model.SLOStatus) interface{} { return status.Target })},
+			{Name: "windowDays", Type: "Int!", Resolve: sloField(func(status *model.SLOStatus) interface{} { return status.WindowDays })},
+			{Name: "jobs", Description: "Jobs the objective covers", Type: "Int!", Resolve: sloField(func(status *model.SLOStatus) interface{} { return status.Jobs })},
+			{Name: "total", Type: "Int!", Resolve: sloField(func(status *model.SLOStatus) interface{} { return status.Total })},
+			{Name: "failed", Type: "Int!", Resolve: sloField(func(status *model.SLOStatus) interface{} { return status.Failed })},
+			{Name: "errorBudgetRemaining", Type: "Float!", Resolve: sloField(func(status *model.SLOStatus) interface{} { return status.BudgetRemaining })},
+			{Name: "burnRate", Type: "Float!", Resolve: sloField(func(status *model.SLOStatus) interface{} { return status.BurnRate })},
+			{Name: "burning", Type: "Boolean!", Resolve: sloField(func(status *model.SLOStatus) interface{} { return status.Burning })},
+			{Name: "recoveries", Description: "Incidents the covered jobs recovered from in the window", Type: "Int!", Resolve: sloField(func(status *model.SLOStatus) interface{} { return status.Recoveries })},
+			{Name: "mttr", Description: "Mean time to recovery of the incidents in seconds; null without recoveries", Type: "Float", Resolve: sloField(func(status *model.SLOStatus) interface{} { return status.MTTR })},
+		},
+	}
+
+	query := &graphql.Object{
+		Name: "Query",
+		Fields: []*graphql.FieldDef{
+			{
+				Name:        "job",
+				Description: "A job by id, or by name and host; null when there is none",
+				Type:        "Job",
+				Args:        []*graphql.Arg{{Name: "id", Type: "Int"}, {Name: "name", Type: "String"}, {Name: "host", Type: "String"}},
+				Resolve:     r.job,
+			},
+			{
+				Name:        "jobs",
+				Description: "The jobs carrying all the given labels, optionally on a host or with a status",
+				Type:        "[Job!]!",
+				Args:        []*graphql.Arg{{Name: "labels", Type: "JSON"}, {Name: "host", Type: "String"}, {Name: "status", Type: "String"}},
+				Resolve: func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+					labels, err := labelsArg(args["labels"])
+					if err != nil {
+						return nil, err
+					}
+					host, _ := args["host"].(string)
+					status, _ := args["status"].(string)
+					return r.filterJobs(labels, host, status)
+				},
+			},
+			{Name: "hosts", Description: "The hosts running jobs", Type: "[Host!]!", Resolve: r.hosts},
+			{
+				Name:        "rollup",
+				Description: "The jobs grouped by the value of a label",
+				Type:        "[Group!]!",
+				Args:        []*graphql.Arg{{Name: "label", Type: "String!"}},
+				Resolve:     r.rollup,
+			},
+			{Name: "slos", Description: "The configured service level objectives", Type: "[SLO!]!", Resolve: r.slos},
+		},
+	}
+
+	return graphql.NewSchema(query,
+		[]*graphql.Object{job, check, result, sla, host, group, objective},
+		[]*graphql.Scalar{
+			{Name: "JSON", Description: "Any JSON value, e.g. a map of labels"},
+			{Name: "Time", Description: "An RFC 3339 timestamp"},
+		},
+	)
+}
+
+func jobField(get func(*model.Job) interface{}) graphql.ResolveFunc {
+	return func(_ context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+		return get(source.(*model.Job)), nil
+	}
+}
+
+func checkField(get func(*graphQLCheck) interface{}) graphql.ResolveFunc {
+	return func(_ context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+		return get(source.(*graphQLCheck)), nil
+	}
+}
+
+func resultField(get func(*model.JobResult) interface{}) graphql.ResolveFunc {
+	return func(_ context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+		return get(source.(*model.JobResult)), nil
+	}
+}
+
+func slaField(get func(*graphQLSLA) interface{}) graphql.ResolveFunc {
+	return func(_ context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+		return get(source.(*graphQLSLA)), nil
+	}
+}
+
+func hostField(get func(*graphQLHost) interface{}) graphql.ResolveFunc {
+	return func(_ context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+		return get(source.(*graphQLHost)), nil
+	}
+}
+
+func groupField(get func(*graphQLGroup) interface{}) graphql.ResolveFunc {
+	return func(_ context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+		return get(source.(*graphQLGroup)), nil
+	}
+}
+
+func sloField(get func(*model.SLOStatus) interface{}) graphql.ResolveFunc {
+	return func(_ context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+		return get(source.(*model.SLOStatus)), nil
+	}
+}
+
+// nonEmpty returns s, or nil when it is empty so the field is null
+func nonEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// labelsArg converts a labels argument to a label filter
+func labelsArg(value interface{}) (map[string]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("labels must be an object of label values")
+	}
+	labels := make(map[string]string, len(object))
+	for key, v := range object {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("label %q must be a string", key)
+		}
+		labels[key] = s
+	}
+	return labels, nil
+}
+
+func (r *graphQLResolver) job(_ context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+	var job *model.Job
+	var err error
+	if id, ok := args["id"].(int); ok {
+		job, err = r.server.jobStore.GetJobByID(id)
+	} else {
+		name, _ := args["name"].(string)
+		host, _ := args["host"].(string)
+		if name == "" || host == "" {
+			return nil, errors.New("job requires an id, or a name and a host")
+		}
+		job, err = r.server.jobStore.GetJob(name, host)
+	}
+
+	if err != nil && strings.Contains(err.Error(), "not found") {
+		return nil, nil
+	}
+	return job, err
+}
+
+// allJobs lists every job once per request
+func (r *graphQLResolver) allJobs() ([]*model.Job, error) {
+	if r.jobs == nil {
+		jobs, err := r.server.jobStore.ListJobs(nil)
+		if err != nil {
+			return nil, err
+		}
+		r.jobs = jobs
+	}
+	return r.jobs, nil
+}
+
+// filterJobs returns the jobs carrying all labels, on host and with status;
+// empty values match any
+func (r *graphQLResolver) filterJobs(labels map[string]string, host, status string) ([]*model.Job, error) {
+	jobs, err := r.allJobs()
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []*model.Job{}
+	for _, job := range jobs {
+		if (host != "" && job.Host != host) || (status != "" && job.Status != status) {
+			continue
+		}
+		matches := true
+		for key, value := range labels {
+			matches = matches && job.Labels[key] == value
+		}
+		if matches {
+			matching = append(matching, job)
+		}
+	}
+	return matching, nil
+}
+
+// check evaluates the job's status once per request
+func (r *graphQLResolver) check(ctx context.Context, job *model.Job) *graphQLCheck {
+	if check, ok := r.checks[job.ID]; ok {
+		return check
+	}
+	value, reason := r.server.metrics.JobStatus(ctx, job)
+	check := &graphQLCheck{
+		Value:    value,
+		Reason:   reason,
+		Excluded: reason == "maintenance" || reason == "paused" || reason == "snoozed",
+	}
+	r.checks[job.ID] = check
+	return check
+}
+
+func (r *graphQLResolver) results(_ context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+	limit := args["limit"].(int)
+	if limit < 1 || limit > maxGraphQLResults {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxGraphQLResults)
+	}
+	job := source.(*model.Job)
+	return r.server.jobResultStore.GetJobResults(job.Name, job.Host, limit)
+}
+
+// sla counts the job's results and its recoveries over the window. Those
+// of every job are computed at once, so listing jobs with their SLA takes
+// two queries.
+func (r *graphQLResolver) sla(_ context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+	windowDays := args["windowDays"].(int)
+	if windowDays < 1 {
+		return nil, errors.New("windowDays must be at least 1")
+	}
+	since := r.now.Add(-time.Duration(windowDays) * 24 * time.Hour)
+
+	counts, ok := r.counts[windowDays]
+	if !ok {
+		var err error
+		counts, err = r.server.jobResultStore.CountJobResultsSince(since)
+		if err != nil {
+			return nil, err
+		}
+		r.counts[windowDays] = counts
+	}
+	recoveries, ok := r.recoveries[windowDays]
+	if !ok {
+		var err error
+		recoveries, err = r.server.jobResultStore.RecoveriesSince(since)
+		if err != nil {
+			return nil, err
+		}
+		r.recoveries[windowDays] = recoveries
+	}
+
+	job := source.(*model.Job)
+	ref := model.JobRef{Name: job.Name, Host: job.Host}
+	count := counts[ref]
+	sla := &graphQLSLA{WindowDays: windowDays, Total: count.Total, Failed: count.Failed, Recoveries: len(recoveries[ref])}
+	if count.Total > 0 {
+		rate := float64(count.Total-count.Failed) / float64(count.Total)
+		sla.SuccessRate = &rate
+	}
+	if sla.Recoveries > 0 {
+		mttr := model.MeanRecovery(recoveries[ref]).Seconds()
+		sla.MTTR = &mttr
+	}
+	return sla, nil
+}
+
+func (r *graphQLResolver) hosts(_ context.Context, _ interface{}, _ map[string]interface{}) (interface{}, error) {
+	usage, err := r.server.jobStore.ListHosts()
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]*graphQLHost, 0, len(usage))
+	for _, host := range usage {
+		hosts = append(hosts, &graphQLHost{Host: host.Host, JobCount: host.JobCount})
+	}
+	return hosts, nil
+}
+
+// rollup groups the jobs by the value of a label, ordered by value, and
+// scores each group like the group health score, without weights
+func (r *graphQLResolver) rollup(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+	label := args["label"].(string)
+	jobs, err := r.allJobs()
+	if err != nil {
+		return nil, err
+	}
+
+	byValue := make(map[string]*graphQLGroup)
+	for _, job := range jobs {
+		value := job.Labels[label]
+		group, ok := byValue[value]
+		if !ok {
+			group = &graphQLGroup{Value: value}
+			byValue[value] = group
+		}
+		group.Jobs = append(group.Jobs, job)
+
+		switch check := r.check(ctx, job); {
+		case check.Excluded:
+			group.Excluded++
+		case check.Value == 1:
+			group.Succeeding++
+		default:
+			group.Failing++
+		}
+	}
+
+	groups := make([]*graphQLGroup, 0, len(byValue))
+	for _, group := range byValue {
+		if scored := group.Succeeding + group.Failing; scored > 0 {
+			score := 100 * float64(group.Succeeding) / float64(scored)
+			group.HealthScore = &score
+		}
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Value < groups[j].Value })
+	return groups, nil
+}
+
+func (r *graphQLResolver) slos(ctx context.Context, _ interface{}, _ map[string]interface{}) (interface{}, error) {
+	if r.server.sloTracker == nil {
+		return []*model.SLOStatus{}, nil
+	}
+	if r.statuses == nil {
+		statuses, err := r.server.sloTracker.Evaluate(ctx, r.now)
+		if err != nil {
+			return nil, err
+		}
+		r.statuses = statuses
+	}
+	return r.statuses, nil
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// LabelsResponse lists label keys with their distinct values and usage counts
+type LabelsResponse struct {
+	Labels         []*model.LabelUsage `json:"labels"`
+	MaxLabelValues int                 `json:"max_label_values,omitempty"` // Configured per-key limit, 0 when unlimited
+}
+
+// HostsResponse lists the distinct hosts running jobs
+type HostsResponse struct {
+	Hosts []*model.HostUsage `json:"hosts"`
+}
+
+// labelLimitError reports label values refused by the cardinality guard
+type labelLimitError struct {
+	keys  []string
+	limit int
+}
+
+func (e *labelLimitError) Error() string {
+	return fmt.Sprintf("label value limit reached for %s (max %d distinct values per key)", strings.Join(e.keys, ", "), e.limit)
+}
+
+// checkLabelCardinality warns when labels introduce new values for keys that
+// already reached the configured limit, and refuses them when configured to
+func (s *Server) checkLabelCardinality(labels map[string]string) error {
+	limit := s.config.Metrics.MaxLabelValues
+
+	exceeded, err := s.jobStore.CheckLabelCardinality(labels, limit)
+	if err != nil {
+		return err
+	}
+	if len(exceeded) == 0 {
+		return nil
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"label_keys": exceeded,
+		"limit":      limit,
+		"rejected":   s.config.Metrics.RejectNewLabelValues,
+	}).Warn("label value limit reached")
+
+	if !s.config.Metrics.RejectNewLabelValues {
+		return nil
+	}
+	return &labelLimitError{keys: exceeded, limit: limit}
+}
+
+// writeLabelCheckError maps a checkLabelCardinality error to a response
+func (s *Server) writeLabelCheckError(w http.ResponseWriter, err error) {
+	var limitErr *labelLimitError
+	if errors.As(err, &limitErr) {
+		s.writeErrorResponse(w, http.StatusBadRequest, limitErr.Error())
+		return
+	}
+	s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to check labels: %v", err))
+}
+
+// handleLabels lists label keys and values in use, for cleanup and filtering
+func (s *Server) handleLabels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	usage, err := s.jobStore.ListLabelUsage()
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list labels: %v", err))
+		return
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, LabelsResponse{
+		Labels:         usage,
+		MaxLabelValues: s.config.Metrics.MaxLabelValues,
+	})
+}
+
+// handleHosts lists the distinct hosts running jobs
+func (s *Server) handleHosts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	hosts, err := s.jobStore.ListHosts()
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list hosts: %v", err))
+		return
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, HostsResponse{Hosts: hosts})
+}
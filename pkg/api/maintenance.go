@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// MaintenanceRequest is the body of POST /api/maintenance
+type MaintenanceRequest struct {
+	Selector map[string]string `json:"selector"`        // Labels the jobs must all carry, e.g. {"env": "staging"}
+	Until    *time.Time        `json:"until,omitempty"` // End of the window; without it, it lasts until ended
+}
+
+// handleMaintenance lists the maintenance windows that have not ended (GET)
+// or starts one for the jobs matching a label selector (POST)
+func (s *Server) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		windows, err := s.jobStore.ListMaintenanceWindows()
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list maintenance windows: %v", err))
+			return
+		}
+		s.writeJSONResponse(w, http.StatusOK, windows)
+
+	case http.MethodPost:
+		var req MaintenanceRequest
+		if err := s.decodeBody(r, &req); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+			return
+		}
+		if len(req.Selector) == 0 {
+			s.writeErrorResponse(w, http.StatusBadRequest, "selector must name at least one label")
+			return
+		}
+		if req.Until != nil && !req.Until.After(time.Now()) {
+			s.writeErrorResponse(w, http.StatusBadRequest, "until must be in the future")
+			return
+		}
+
+		// A selector matching nothing is most likely a typo
+		matching, err := s.jobStore.ListJobs(req.Selector)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list jobs: %v", err))
+			return
+		}
+		if len(matching) == 0 {
+			s.writeErrorResponse(w, http.StatusNotFound, "no job matches the selector")
+			return
+		}
+
+		window, err := s.jobStore.StartMaintenance(req.Selector, req.Until)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to start maintenance: %v", err))
+			return
+		}
+		s.writeJSONResponse(w, http.StatusCreated, window)
+
+	default:
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleMaintenanceByID shows a maintenance window (GET) or ends it ahead
+// of time (DELETE), making the jobs it put in maintenance active again
+func (s *Server) handleMaintenanceByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/maintenance/"), "/"))
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "invalid maintenance window ID format (must be a number)")
+		return
+	}
+
+	var window *model.MaintenanceWindow
+	switch r.Method {
+	case http.MethodGet:
+		window, err = s.jobStore.GetMaintenanceWindow(id)
+	case http.MethodDelete:
+		window, err = s.jobStore.EndMaintenance(id)
+	default:
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if errors.Is(err, model.ErrMaintenanceWindowNotFound) {
+		s.writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to update maintenance window: %v", err))
+		return
+	}
+	s.writeJSONResponse(w, http.StatusOK, window)
+}
+
+// RunMaintenanceExpiry ends the maintenance windows past their end time
+// every interval until ctx is cancelled
+func (s *Server) RunMaintenanceExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	jobStore := s.jobStore.WithContext(ctx)
+	for {
+		if _, err := jobStore.EndExpiredMaintenance(time.Now().UTC()); err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Error("failed to end expired maintenance windows")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/unixsocket"
+)
+
+// withScrapeSources refuses requests from outside metrics.allowed_cidrs,
+// when set. Clients of a Unix socket are local and always allowed.
+func (s *Server) withScrapeSources(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(s.config.Metrics.AllowedCIDRs) > 0 {
+			_, socket := unixsocket.Path(s.config.Server.Listen)
+			if !socket && !model.SourceInCIDRs(remoteHost(r), s.config.Metrics.AllowedCIDRs) {
+				s.writeErrorResponse(w, http.StatusForbidden, "source address not allowed")
+				return
+			}
+		}
+		handler(w, r)
+	}
+}
+
+// withMetricsAuth requires the scrape credentials of metrics.auth, when
+// set, to serve the metrics endpoints. Admin and job API keys are not
+// accepted, so scrapers hold no other access.
+func (s *Server) withMetricsAuth(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch s.config.Metrics.Auth {
+		case "basic":
+			username, password, ok := r.BasicAuth()
+			if !ok || !secretEqual(username, s.config.Metrics.Username) || !secretEqual(password, s.config.Metrics.Password) {
+				w.Header().Set("WWW-Authenticate", `Basic realm="cronmetrics metrics"`)
+				s.writeErrorResponse(w, http.StatusUnauthorized, "metrics credentials required")
+				return
+			}
+		case "bearer":
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || !secretEqual(token, s.config.Metrics.BearerToken) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="cronmetrics metrics"`)
+				s.writeErrorResponse(w, http.StatusUnauthorized, "metrics bearer token required")
+				return
+			}
+		}
+		handler(w, r)
+	}
+}
+
+// secretEqual compares a presented credential with the expected one in
+// constant time
+func secretEqual(presented, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(presented), []byte(expected)) == 1
+}
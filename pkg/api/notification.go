@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/notifier"
+	"github.com/jaepetto/cron-exporter/pkg/ticket"
+)
+
+// errRedeliveryDisabled is returned when what sent a notification is
+// disabled
+var errRedeliveryDisabled = errors.New("redelivery disabled")
+
+// handleNotifications lists notification deliveries, most recent first,
+// optionally filtered by job_name, host and status
+func (s *Server) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	cursor, limit, _, err := parsePageParams(r)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	filter := model.DeliveryFilter{
+		JobName: query.Get("job_name"),
+		Host:    query.Get("host"),
+		Status:  query.Get("status"),
+	}
+	if filter.Status != "" && filter.Status != model.DeliveryDelivered && filter.Status != model.DeliveryFailed {
+		s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("status must be %q or %q", model.DeliveryDelivered, model.DeliveryFailed))
+		return
+	}
+
+	page, err := s.notificationStore.ListDeliveriesPage(filter, cursor, limit)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidCursor) {
+			s.writeErrorResponse(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list notification deliveries: %v", err))
+		return
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, page)
+}
+
+// handleNotificationByID serves /api/notifications/{id}/redeliver, which
+// sends a recorded notification again. The new delivery is returned with
+// 201 when it went out, or 502 when the channel failed.
+func (s *Server) handleNotificationByID(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/notifications/"), "/"), "/")
+	if len(segments) != 2 || segments[1] != "redeliver" {
+		s.writeErrorResponse(w, http.StatusNotFound, "not found")
+		return
+	}
+	id, err := strconv.Atoi(segments[0])
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "invalid notification delivery ID format (must be a number)")
+		return
+	}
+	if r.Method != http.MethodPost {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	delivery, err := s.redeliver(r.Context(), id)
+	switch {
+	case errors.Is(err, model.ErrDeliveryNotFound):
+		s.writeErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, errRedeliveryDisabled):
+		s.writeErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, ticket.ErrNoTracker), errors.Is(err, notifier.ErrNoChannel):
+		s.writeErrorResponse(w, http.StatusConflict, err.Error())
+	case delivery != nil && delivery.Status == model.DeliveryFailed:
+		s.writeJSONResponse(w, http.StatusBadGateway, delivery)
+	case err != nil && delivery == nil:
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to redeliver notification: %v", err))
+	default:
+		// Delivered; failing to acknowledge the job afterwards is not the
+		// channel's fault and the ticket exists
+		s.writeJSONResponse(w, http.StatusCreated, delivery)
+	}
+}
+
+// redeliver sends a recorded notification again through what sent it: the
+// notifier for its channels, else the ticket trackers
+func (s *Server) redeliver(ctx context.Context, id int) (*model.NotificationDelivery, error) {
+	previous, err := s.notificationStore.WithContext(ctx).GetDelivery(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(config.NotifierChannelTypes, previous.Channel) {
+		if s.notifierDeliverer == nil {
+			return nil, fmt.Errorf("%w: the notifier is disabled (set notifier.enabled)", errRedeliveryDisabled)
+		}
+		return s.notifierDeliverer.Redeliver(ctx, id)
+	}
+	if s.deliverer == nil {
+		return nil, fmt.Errorf("%w: ticket creation is disabled (set tickets.enabled)", errRedeliveryDisabled)
+	}
+	return s.deliverer.Redeliver(ctx, id)
+}
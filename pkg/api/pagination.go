@@ -0,0 +1,283 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/util"
+)
+
+const (
+	// defaultPageLimit is the page size used when a paginated request omits limit
+	defaultPageLimit = 100
+	// maxPageLimit caps the page size a client may request
+	maxPageLimit = 1000
+)
+
+// parsePageParams reads the cursor and limit query parameters. paginated is
+// false when neither is present, so list endpoints can keep returning their
+// unpaginated response to existing clients.
+func parsePageParams(r *http.Request) (cursor string, limit int, paginated bool, err error) {
+	query := r.URL.Query()
+	cursor = query.Get("cursor")
+	limit = defaultPageLimit
+	paginated = query.Has("cursor") || query.Has("limit")
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			return "", 0, false, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+	}
+
+	return cursor, limit, paginated, nil
+}
+
+// handleListJobsPage lists jobs one page at a time
+func (s *Server) handleListJobsPage(w http.ResponseWriter, labelFilters map[string]string, cursor string, limit int) {
+	page, err := s.jobStore.ListJobsPage(labelFilters, cursor, limit)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidCursor) {
+			s.writeErrorResponse(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list jobs: %v", err))
+		return
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, page)
+}
+
+// handleJobResults lists the recorded results of a job, newest first
+func (s *Server) handleJobResults(w http.ResponseWriter, r *http.Request, jobID int) {
+	if r.Method != http.MethodGet {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	cursor, limit, _, err := parsePageParams(r)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter, err := parseResultFilter(r, time.Now().UTC())
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	job, err := s.jobStore.GetJobByID(jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.writeErrorResponse(w, http.StatusNotFound, "job not found")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get job: %v", err))
+		return
+	}
+
+	filter.JobName, filter.Host = job.Name, job.Host
+	s.writeResultsPage(w, filter, cursor, limit)
+}
+
+// handleListJobResults lists the recorded results of all jobs, newest
+// first, optionally of one job or host
+func (s *Server) handleListJobResults(w http.ResponseWriter, r *http.Request) {
+	cursor, limit, _, err := parsePageParams(r)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter, err := parseResultFilter(r, time.Now().UTC())
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.JobName = r.URL.Query().Get("job_name")
+	filter.Host = r.URL.Query().Get("host")
+
+	s.writeResultsPage(w, filter, cursor, limit)
+}
+
+// writeResultsPage answers a page of the results matching filter, with
+// their annotations
+func (s *Server) writeResultsPage(w http.ResponseWriter, filter model.JobResultFilter, cursor string, limit int) {
+	page, err := s.jobResultStore.ListResultsPage(filter, cursor, limit)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidCursor) {
+			s.writeErrorResponse(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get job results: %v", err))
+		return
+	}
+
+	// Annotations are stored per job
+	seen := make(map[model.JobRef]bool)
+	for _, result := range page.Results {
+		ref := model.JobRef{Name: result.JobName, Host: result.Host}
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+
+		annotations, err := s.annotationStore.ListAnnotations(ref.Name, ref.Host)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get annotations: %v", err))
+			return
+		}
+		model.AttachAnnotations(jobResults(page.Results, ref), annotations)
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, page)
+}
+
+// jobResults returns the results of the job ref
+func jobResults(results []*model.JobResult, ref model.JobRef) []*model.JobResult {
+	var matching []*model.JobResult
+	for _, result := range results {
+		if result.JobName == ref.Name && result.Host == ref.Host {
+			matching = append(matching, result)
+		}
+	}
+	return matching
+}
+
+// resultStatuses are the statuses results are stored with
+var resultStatuses = []string{"success", "failure", model.ResultLost}
+
+// parseResultFilter reads the status, run_id, since and until query
+// parameters.
+// since and until are RFC 3339 timestamps, or durations before now such as
+// "24h" or "7d".
+func parseResultFilter(r *http.Request, now time.Time) (model.JobResultFilter, error) {
+	query := r.URL.Query()
+	filter := model.JobResultFilter{Status: query.Get("status"), RunID: query.Get("run_id")}
+
+	if filter.Status != "" && !slices.Contains(resultStatuses, filter.Status) {
+		return filter, fmt.Errorf("status must be one of %s", strings.Join(resultStatuses, ", "))
+	}
+
+	for _, param := range []struct {
+		name  string
+		value *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		if raw := query.Get(param.name); raw != "" {
+			timestamp, err := parseTimeParam(param.name, raw, now)
+			if err != nil {
+				return filter, err
+			}
+			*param.value = timestamp
+		}
+	}
+
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Since.Before(filter.Until) {
+		return filter, fmt.Errorf("since must be before until")
+	}
+	return filter, nil
+}
+
+// parseTimeParam parses the value of the time query parameter name, an RFC
+// 3339 timestamp or a duration before now
+func parseTimeParam(name, raw string, now time.Time) (time.Time, error) {
+	if timestamp, err := time.Parse(time.RFC3339, raw); err == nil {
+		return timestamp, nil
+	}
+	ago, err := util.ParseDuration(raw)
+	if err != nil || ago < 0 {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 timestamp or a duration such as \"24h\"", name)
+	}
+	return now.Add(-ago), nil
+}
+
+// jobStatuses are the statuses jobs are set to
+var jobStatuses = []string{"active", "maintenance", "paused"}
+
+// jobSearchParams are the GET /api/job query parameters answered with a
+// page of search results
+var jobSearchParams = []string{"q", "name", "host", "status", "before", "after", "sort_by", "sort_dir", "page", "page_size"}
+
+// parseJobSearch reads the search parameters of GET /api/job, the criteria
+// of the dashboard search. search is false when none is present, so the
+// job list keeps its unpaginated response. before and after are RFC 3339
+// timestamps, or durations before now such as "24h" or "7d".
+func parseJobSearch(r *http.Request, now time.Time) (criteria *model.JobSearchCriteria, search bool, err error) {
+	query := r.URL.Query()
+	for _, param := range jobSearchParams {
+		search = search || query.Has(param)
+	}
+	if !search {
+		return nil, false, nil
+	}
+
+	criteria = &model.JobSearchCriteria{
+		Query:   query.Get("q"),
+		Name:    query.Get("name"),
+		Host:    query.Get("host"),
+		SortBy:  query.Get("sort_by"),
+		SortDir: query.Get("sort_dir"),
+	}
+
+	// status may be repeated to match any of several statuses
+	for _, status := range query["status"] {
+		if !slices.Contains(jobStatuses, status) {
+			return nil, true, fmt.Errorf("status must be one of %s", strings.Join(jobStatuses, ", "))
+		}
+		criteria.Statuses = append(criteria.Statuses, status)
+	}
+
+	if criteria.SortBy != "" && !model.IsValidJobSortField(criteria.SortBy) {
+		return nil, true, fmt.Errorf("sort_by must be one of %s", strings.Join(model.JobSortFields, ", "))
+	}
+	if criteria.SortDir != "" && criteria.SortDir != "asc" && criteria.SortDir != "desc" {
+		return nil, true, fmt.Errorf("sort_dir must be 'asc' or 'desc'")
+	}
+
+	if pageStr := query.Get("page"); pageStr != "" {
+		criteria.Page, err = strconv.Atoi(pageStr)
+		if err != nil || criteria.Page <= 0 {
+			return nil, true, fmt.Errorf("page must be a positive integer")
+		}
+	}
+	if pageSizeStr := query.Get("page_size"); pageSizeStr != "" {
+		criteria.PageSize, err = strconv.Atoi(pageSizeStr)
+		if err != nil || criteria.PageSize <= 0 || criteria.PageSize > maxPageLimit {
+			return nil, true, fmt.Errorf("page_size must be between 1 and %d", maxPageLimit)
+		}
+	}
+
+	for _, param := range []struct {
+		name  string
+		value **time.Time
+	}{{"before", &criteria.LastReportedBefore}, {"after", &criteria.LastReportedAfter}} {
+		if raw := query.Get(param.name); raw != "" {
+			timestamp, err := parseTimeParam(param.name, raw, now)
+			if err != nil {
+				return nil, true, err
+			}
+			*param.value = &timestamp
+		}
+	}
+
+	return criteria, true, nil
+}
+
+// handleSearchJobs lists a page of the jobs matching criteria
+func (s *Server) handleSearchJobs(w http.ResponseWriter, criteria *model.JobSearchCriteria) {
+	result, err := s.jobStore.SearchJobs(criteria)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to search jobs: %v", err))
+		return
+	}
+	if result.Jobs == nil {
+		result.Jobs = []*model.Job{}
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, result)
+}
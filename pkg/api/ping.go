@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// resultSourcePing marks the results submitted through /api/ping
+const resultSourcePing = "ping"
+
+// handlePing records a job result from a bare GET request, for crontabs
+// where `curl -fsS <url>` is all a job can run. The job is identified by
+// its API key in the key parameter (or the usual headers); the status
+// defaults to success, and other parameters are read like form results:
+//
+//	0 3 * * * backup.sh && curl -fsS "https://cron.example.com/api/ping?key=cm_..."
+//	0 3 * * * backup.sh || curl -fsS "https://cron.example.com/api/ping?key=cm_...&status=failure"
+func (s *Server) handlePing(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	values := r.URL.Query()
+	apiKey := values.Get("key")
+	if apiKey == "" {
+		apiKey = s.extractAPIKey(r)
+	}
+	values.Del("key")
+	// Only ever fingerprinted, and without the key
+	payload := []byte(values.Encode())
+
+	job, err := s.authenticateJobKey(apiKey)
+	if err == nil && !job.AllowsSource(remoteHost(r)) {
+		err = &ResultError{http.StatusForbidden, "source address not allowed for this job", model.RejectionSourceDenied}
+	}
+	if err != nil {
+		s.recordRejection(err, nil, apiKey, payload, s.clientAddr(r))
+		s.writeResultError(w, err)
+		return
+	}
+
+	result := model.JobResult{JobName: job.Name, Host: job.Host, Status: "success"}
+	if err := s.decodeResultValues(values, &result); err != nil {
+		resultErr := &ResultError{http.StatusBadRequest, fmt.Sprintf("invalid parameters: %v", err), model.RejectionInvalidPayload}
+		s.recordRejection(resultErr, &result, "", payload, s.clientAddr(r))
+		s.writeResultError(w, resultErr)
+		return
+	}
+
+	setResultSource(&result, r)
+	result.Source = resultSourcePing
+
+	coalesced, err := s.recordJobResult(&result, job, true)
+	if err != nil {
+		s.recordRejection(err, &result, "", payload, s.clientAddr(r))
+		s.writeResultError(w, err)
+		return
+	}
+
+	status := "recorded"
+	if coalesced {
+		status = "coalesced"
+	}
+	s.writeJSONResponse(w, http.StatusOK, map[string]string{
+		"status": status,
+		"job":    fmt.Sprintf("%s@%s", result.JobName, result.Host),
+	})
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// Authentication levels of a request
+const (
+	AuthLevelAdmin = "admin" // Admin API key, or development mode
+	AuthLevelJob   = "job"   // Per-job API key or body signature
+)
+
+// Principal is the identity a request was authenticated as
+type Principal struct {
+	Level string
+	Job   *model.Job // The authenticated job, for job-level principals
+}
+
+// principalKey is the request context key of the Principal
+type principalKey struct{}
+
+// withPrincipal returns r carrying principal in its context
+func withPrincipal(r *http.Request, principal *Principal) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), principalKey{}, principal))
+}
+
+// PrincipalFromContext returns the principal the auth middleware stored in
+// ctx, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
+
+// isAdminRequest reports whether r was authenticated with admin access
+func isAdminRequest(r *http.Request) bool {
+	principal, ok := PrincipalFromContext(r.Context())
+	return ok && principal.Level == AuthLevelAdmin
+}
+
+// withoutAuthHeaders drops inbound X-Auth-* headers. Identity travels in the
+// request context only; the headers used to carry it and must not be
+// trusted if a client sends them.
+func withoutAuthHeaders(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name := range r.Header {
+			if strings.HasPrefix(name, "X-Auth-") {
+				r.Header.Del(name)
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jaepetto/cron-exporter/pkg/metrics"
+)
+
+// ProblemsResponse lists what needs attention, the most urgent first
+type ProblemsResponse struct {
+	Problems []*metrics.Problem `json:"problems"`
+}
+
+// handleProblems lists the active failures, missed deadlines, flapping jobs
+// and stale hosts in a single prioritized list
+func (s *Server) handleProblems(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	problems, err := s.metrics.Problems(r.Context())
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list problems: %v", err))
+		return
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, ProblemsResponse{Problems: problems})
+}
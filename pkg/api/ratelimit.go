@@ -0,0 +1,133 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// maxLimitedJobs is the number of jobs the limiter remembers before it
+// forgets those whose interval has passed
+const maxLimitedJobs = 10000
+
+// rateLimitedError refuses a result submitted before its job's minimum
+// interval passed
+type rateLimitedError struct {
+	ResultError
+	retryAfter time.Duration
+}
+
+func (e *rateLimitedError) Unwrap() error {
+	return &e.ResultError
+}
+
+// resultLimiter enforces the minimum interval between the recorded results
+// of each job: the job's own min_interval, else the configured one. It is
+// kept in memory, so each server instance limits the results it receives
+// on its own.
+type resultLimiter struct {
+	config *config.RateLimitConfig
+
+	mu   sync.Mutex
+	last map[string]limitedResult // By job name and host
+}
+
+// limitedResult is the latest result of a job let through the limiter
+type limitedResult struct {
+	at       time.Time
+	status   string
+	interval time.Duration
+}
+
+// reservation holds a job's interval for a result from the moment it passes
+// the limiter, so concurrent results of the job cannot all pass. It is
+// released when the result is not recorded after all.
+type reservation struct {
+	limiter  *resultLimiter
+	key      string
+	held     limitedResult
+	previous limitedResult
+	existed  bool
+}
+
+func newResultLimiter(cfg *config.RateLimitConfig) *resultLimiter {
+	return &resultLimiter{config: cfg, last: make(map[string]limitedResult)}
+}
+
+func limiterKey(result *model.JobResult) string {
+	return result.JobName + "@" + result.Host
+}
+
+// interval returns the minimum interval between the results of job, which
+// is nil when results are not authenticated
+func (l *resultLimiter) interval(job *model.Job) time.Duration {
+	if job != nil && job.MinInterval > 0 {
+		return time.Duration(job.MinInterval) * time.Second
+	}
+	return time.Duration(l.config.MinInterval) * time.Second
+}
+
+// check decides what becomes of a result of job arriving now. It returns
+// whether the result is coalesced into its job's previous one, which had
+// the same status, or an error refusing it. Other results are to be
+// recorded, and hold the job's interval from now until their reservation
+// is released.
+func (l *resultLimiter) check(result *model.JobResult, job *model.Job, now time.Time) (*reservation, bool, error) {
+	interval := l.interval(job)
+	if interval <= 0 {
+		return nil, false, nil
+	}
+
+	key := limiterKey(result)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	last, ok := l.last[key]
+	if wait := interval - now.Sub(last.at); ok && wait > 0 {
+		if l.config.Action != "coalesce" {
+			return nil, false, &rateLimitedError{
+				ResultError: ResultError{http.StatusTooManyRequests, fmt.Sprintf("job submitted a result less than %s ago, retry in %s", interval, wait.Round(time.Second)), model.RejectionRateLimited},
+				retryAfter:  wait,
+			}
+		}
+		// A change of status is never lost
+		if result.Status == last.status {
+			return nil, true, nil
+		}
+	}
+
+	if len(l.last) >= maxLimitedJobs {
+		for key, last := range l.last {
+			if now.Sub(last.at) >= last.interval {
+				delete(l.last, key)
+			}
+		}
+	}
+	held := limitedResult{at: now, status: result.Status, interval: interval}
+	l.last[key] = held
+	return &reservation{limiter: l, key: key, held: held, previous: last, existed: ok}, false, nil
+}
+
+// release gives the job's interval back to the result it held before, for
+// a result that was not recorded. A nil reservation holds nothing.
+func (r *reservation) release() {
+	if r == nil {
+		return
+	}
+
+	r.limiter.mu.Lock()
+	defer r.limiter.mu.Unlock()
+	// A later result may hold the interval already
+	if current, ok := r.limiter.last[r.key]; !ok || current != r.held {
+		return
+	}
+	if r.existed {
+		r.limiter.last[r.key] = r.previous
+	} else {
+		delete(r.limiter.last, r.key)
+	}
+}
@@ -0,0 +1,433 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// Reconcile actions reported for each job
+const (
+	reconcileCreated   = "created"
+	reconcileUpdated   = "updated"
+	reconcileUnchanged = "unchanged"
+	reconcileDeleted   = "deleted"
+)
+
+// ReconcileRequest describes the desired job inventory for a bulk reconcile
+type ReconcileRequest struct {
+	Jobs     []model.Job       `json:"jobs"`
+	Prune    bool              `json:"prune,omitempty"`    // Delete jobs missing from the desired set
+	Selector map[string]string `json:"selector,omitempty"` // Restrict pruning to jobs with these labels
+}
+
+// ReconcileItem reports the action taken for a single job
+type ReconcileItem struct {
+	Action string                 `json:"action"`
+	Job    *model.Job             `json:"job"`
+	Diff   map[string]FieldChange `json:"diff,omitempty"`
+}
+
+// ReconcileResponse summarizes a bulk reconcile run
+type ReconcileResponse struct {
+	DryRun    bool            `json:"dry_run,omitempty"`
+	Results   []ReconcileItem `json:"results"`
+	Created   int             `json:"created"`
+	Updated   int             `json:"updated"`
+	Unchanged int             `json:"unchanged"`
+	Deleted   int             `json:"deleted"`
+}
+
+// applyJobDefaults fills in default values for a job about to be created.
+// Without a threshold of its own, the job inherits the default for its
+// labels.
+func applyJobDefaults(job *model.Job, defaults *config.DefaultsConfig) {
+	job.ApplyDefaultThreshold(defaults.FailureThreshold)
+	if job.Status == "" {
+		job.Status = "active"
+	}
+	if job.Labels == nil {
+		job.Labels = make(map[string]string)
+	}
+	job.LastReportedAt = time.Now().UTC()
+}
+
+// normalizeAllowedCIDRs validates the source ranges of a job and rewrites
+// them in canonical form
+func normalizeAllowedCIDRs(job *model.Job) error {
+	cidrs, err := model.NormalizeCIDRs(job.AllowedCIDRs)
+	if err != nil {
+		return fmt.Errorf("invalid allowed_cidrs: %w", err)
+	}
+	job.AllowedCIDRs = cidrs
+	return nil
+}
+
+// checkDescription rejects job descriptions over the length limit
+func checkDescription(job *model.Job) error {
+	if len(job.Description) > model.MaxDescriptionLength {
+		return fmt.Errorf("description must be at most %d characters", model.MaxDescriptionLength)
+	}
+	return nil
+}
+
+// mergeJobSpec applies desired onto existing and reports whether anything
+// changed. Name and host are the identity and are never modified here. The
+// description, schedule, shard policy, minimum interval and throughput
+// floor are replaced, omitted ones clearing the job's, so a spec dropping
+// them converges; other fields are applied when provided. An inherited
+// threshold follows the defaults for the merged labels.
+func mergeJobSpec(existing, desired *model.Job, defaults *config.DefaultsConfig) bool {
+	changed := false
+
+	if desired.ApiKey != "" && desired.ApiKey != existing.ApiKey {
+		existing.ApiKey = desired.ApiKey
+		changed = true
+	}
+	if desired.AutomaticFailureThreshold > 0 && (desired.AutomaticFailureThreshold != existing.AutomaticFailureThreshold || existing.ThresholdInherited) {
+		existing.AutomaticFailureThreshold = desired.AutomaticFailureThreshold
+		existing.ThresholdInherited = false
+		changed = true
+	} else if desired.AutomaticFailureThreshold == 0 && desired.ThresholdInherited && !existing.ThresholdInherited {
+		existing.ThresholdInherited = true
+		changed = true
+	}
+	if desired.Labels != nil && !labelsEqual(desired.Labels, existing.Labels) {
+		existing.Labels = desired.Labels
+		changed = true
+	}
+	if desired.Description != existing.Description {
+		existing.Description = desired.Description
+		changed = true
+	}
+	if desired.Type != "" && desired.Type != existing.Type {
+		existing.Type = desired.Type
+		changed = true
+	}
+	if desired.ThroughputFloor != existing.ThroughputFloor {
+		existing.ThroughputFloor = desired.ThroughputFloor
+		changed = true
+	}
+	if desired.Schedule != existing.Schedule {
+		existing.Schedule = desired.Schedule
+		changed = true
+	}
+	if desired.ShardPolicy != existing.ShardPolicy {
+		existing.ShardPolicy = desired.ShardPolicy
+		changed = true
+	}
+	if desired.MinInterval != existing.MinInterval {
+		existing.MinInterval = desired.MinInterval
+		changed = true
+	}
+	if desired.AllowedCIDRs != nil && !slices.Equal(desired.AllowedCIDRs, existing.AllowedCIDRs) {
+		existing.AllowedCIDRs = desired.AllowedCIDRs
+		changed = true
+	}
+	if desired.Status != "" && desired.Status != existing.Status {
+		existing.Status = desired.Status
+		changed = true
+	}
+	if existing.ApplyDefaultThreshold(defaults.FailureThreshold) {
+		changed = true
+	}
+
+	return changed
+}
+
+// labelsEqual compares two label maps treating nil and empty as equal
+func labelsEqual(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// upsertJob creates the job identified by name+host or updates it in place.
+// It returns the job before and after the upsert, nil before a creation, and
+// the action taken. With dryRun set the store is left untouched.
+func (s *Server) upsertJob(desired *model.Job, dryRun bool) (*model.Job, *model.Job, string, error) {
+	before, job, action, err := s.planUpsert(desired)
+	if err != nil || dryRun {
+		return before, job, action, err
+	}
+
+	switch action {
+	case reconcileCreated:
+		err = s.jobStore.CreateJob(job)
+	case reconcileUpdated:
+		err = s.jobStore.UpdateJobByID(job)
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return before, job, action, nil
+}
+
+// planUpsert returns the job identified by name+host before and after
+// desired would be applied, nil before a creation, and whether it would be
+// created, updated or left unchanged, without touching the store
+func (s *Server) planUpsert(desired *model.Job) (*model.Job, *model.Job, string, error) {
+	if desired.Name == "" || desired.Host == "" {
+		return nil, nil, "", fmt.Errorf("job name and host are required")
+	}
+	if err := model.CheckJobIdentity(desired.Name, desired.Host); err != nil {
+		return nil, nil, "", err
+	}
+
+	existing, err := s.jobStore.GetJob(desired.Name, desired.Host)
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			return nil, nil, "", err
+		}
+
+		job := *desired
+		job.ID = 0
+		if job.ApiKey == "" {
+			apiKey, err := util.GenerateAPIKey()
+			if err != nil {
+				return nil, nil, "", fmt.Errorf("failed to generate API key: %w", err)
+			}
+			job.ApiKey = apiKey
+		}
+		applyJobDefaults(&job, &s.config.Defaults)
+
+		if err := s.checkLabelCardinality(job.Labels); err != nil {
+			return nil, nil, "", err
+		}
+
+		return nil, &job, reconcileCreated, nil
+	}
+
+	before := *existing
+	if !mergeJobSpec(existing, desired, &s.config.Defaults) {
+		return &before, existing, reconcileUnchanged, nil
+	}
+
+	if !labelsEqual(before.Labels, existing.Labels) {
+		if err := s.checkLabelCardinality(existing.Labels); err != nil {
+			return nil, nil, "", err
+		}
+	}
+
+	return &before, existing, reconcileUpdated, nil
+}
+
+// handleUpsertJob creates or updates a job keyed by name and host
+func (s *Server) handleUpsertJob(w http.ResponseWriter, r *http.Request) {
+	// Only admin can manage jobs
+	if !isAdminRequest(r) {
+		s.writeErrorResponse(w, http.StatusForbidden, "admin access required")
+		return
+	}
+
+	var desired model.Job
+	if err := s.decodeBody(r, &desired); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+
+	if desired.Name == "" || desired.Host == "" {
+		s.writeErrorResponse(w, http.StatusBadRequest, "job name and host are required")
+		return
+	}
+	if err := model.CheckJobIdentity(desired.Name, desired.Host); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := normalizeAllowedCIDRs(&desired); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkDescription(&desired); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := model.CheckJobType(desired.Type, desired.ThroughputFloor); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := model.CheckSchedule(desired.Schedule); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := model.CheckShardPolicy(desired.ShardPolicy); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := model.CheckMinInterval(desired.MinInterval); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	before, job, action, err := s.upsertJob(&desired, isDryRun(r))
+	if err != nil {
+		var limitErr *labelLimitError
+		if errors.As(err, &limitErr) {
+			s.writeErrorResponse(w, http.StatusBadRequest, limitErr.Error())
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to upsert job: %v", err))
+		return
+	}
+
+	if isDryRun(r) {
+		s.writeDryRunResponse(w, action, before, job)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if action == reconcileCreated {
+		statusCode = http.StatusCreated
+	}
+
+	w.Header().Set("X-Reconcile-Action", action)
+	s.writeJSONResponse(w, statusCode, job)
+}
+
+// handleReconcile converges the job inventory towards the desired set
+func (s *Server) handleReconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Only admin can manage jobs
+	if !isAdminRequest(r) {
+		s.writeErrorResponse(w, http.StatusForbidden, "admin access required")
+		return
+	}
+
+	var req ReconcileRequest
+	if err := s.decodeBody(r, &req); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+
+	// Validate the whole desired set before touching anything
+	desiredKeys := make(map[string]bool, len(req.Jobs))
+	for i, job := range req.Jobs {
+		if job.Name == "" || job.Host == "" {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("jobs[%d]: job name and host are required", i))
+			return
+		}
+		if err := model.CheckJobIdentity(job.Name, job.Host); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("jobs[%d]: %v", i, err))
+			return
+		}
+		if err := normalizeAllowedCIDRs(&req.Jobs[i]); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("jobs[%d]: %v", i, err))
+			return
+		}
+		if err := checkDescription(&req.Jobs[i]); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("jobs[%d]: %v", i, err))
+			return
+		}
+		if err := model.CheckJobType(req.Jobs[i].Type, req.Jobs[i].ThroughputFloor); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("jobs[%d]: %v", i, err))
+			return
+		}
+		if err := model.CheckSchedule(req.Jobs[i].Schedule); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("jobs[%d]: %v", i, err))
+			return
+		}
+		if err := model.CheckShardPolicy(req.Jobs[i].ShardPolicy); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("jobs[%d]: %v", i, err))
+			return
+		}
+		if err := model.CheckMinInterval(req.Jobs[i].MinInterval); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("jobs[%d]: %v", i, err))
+			return
+		}
+		key := job.Name + "@" + job.Host
+		if desiredKeys[key] {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("jobs[%d]: duplicate job %s", i, key))
+			return
+		}
+		desiredKeys[key] = true
+	}
+
+	dryRun := isDryRun(r)
+	resp := ReconcileResponse{DryRun: dryRun, Results: []ReconcileItem{}}
+
+	// Plan every change, then apply them in one transaction, so a failure
+	// leaves the inventory as it was
+	var changes model.JobChanges
+	for i := range req.Jobs {
+		before, job, action, err := s.planUpsert(&req.Jobs[i])
+		if err != nil {
+			statusCode := http.StatusInternalServerError
+			var limitErr *labelLimitError
+			if errors.As(err, &limitErr) {
+				statusCode = http.StatusBadRequest
+			}
+			s.writeErrorResponse(w, statusCode, fmt.Sprintf("failed to reconcile %s@%s: %v", req.Jobs[i].Name, req.Jobs[i].Host, err))
+			return
+		}
+		item := ReconcileItem{Action: action, Job: job}
+		if action != reconcileUnchanged {
+			item.Diff = jobDiff(before, job)
+		}
+		if dryRun {
+			item.Job = redactedJob(job)
+		}
+		resp.Results = append(resp.Results, item)
+		switch action {
+		case reconcileCreated:
+			changes.Create = append(changes.Create, job)
+			resp.Created++
+		case reconcileUpdated:
+			changes.Update = append(changes.Update, job)
+			resp.Updated++
+		default:
+			resp.Unchanged++
+		}
+	}
+
+	if req.Prune {
+		existing, err := s.jobStore.ListJobs(req.Selector)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list jobs: %v", err))
+			return
+		}
+
+		for _, job := range existing {
+			if desiredKeys[job.Name+"@"+job.Host] {
+				continue
+			}
+			changes.Delete = append(changes.Delete, job)
+			item := ReconcileItem{Action: reconcileDeleted, Job: job, Diff: jobDiff(job, nil)}
+			if dryRun {
+				item.Job = redactedJob(job)
+			}
+			resp.Results = append(resp.Results, item)
+			resp.Deleted++
+		}
+	}
+
+	if !dryRun {
+		if err := s.jobStore.ApplyJobChanges(&changes); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to reconcile: %v", err))
+			return
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"dry_run":   dryRun,
+		"created":   resp.Created,
+		"updated":   resp.Updated,
+		"unchanged": resp.Unchanged,
+		"deleted":   resp.Deleted,
+	}).Info("job inventory reconciled")
+
+	s.writeJSONResponse(w, http.StatusOK, resp)
+}
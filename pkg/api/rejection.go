@@ -0,0 +1,93 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// maxRejectedPayloadSize caps how much of a request refused before its body
+// is read is fingerprinted
+const maxRejectedPayloadSize = 1 << 20
+
+// keyPrefixLength is the number of characters of a rejected API key kept
+// to help identify the misconfigured client, as for admin keys
+const keyPrefixLength = 7
+
+// recordRejection audits a rejected submission and counts it in the
+// rejected results metric. Errors without a rejection reason, i.e. server
+// failures, are not recorded. Failing to store the rejection is logged but
+// does not change the response.
+func (s *Server) recordRejection(err error, result *model.JobResult, apiKey string, payload []byte, source string) {
+	var resultErr *ResultError
+	if !errors.As(err, &resultErr) || resultErr.Reason == "" {
+		return
+	}
+
+	rejection := &model.ResultRejection{
+		Reason:      resultErr.Reason,
+		Message:     resultErr.Message,
+		Source:      source,
+		Fingerprint: model.PayloadFingerprint(payload),
+	}
+	if result != nil {
+		rejection.JobName, rejection.Host = result.JobName, result.Host
+	}
+	if resultErr.Reason == model.RejectionInvalidKey {
+		rejection.KeyPrefix = apiKey[:min(len(apiKey), keyPrefixLength)]
+	}
+
+	if s.metrics != nil {
+		s.metrics.RecordRejection(rejection.Reason)
+	}
+	if err := s.rejectionStore.RecordRejection(rejection); err != nil {
+		logrus.WithError(err).Warn("failed to record job result rejection")
+	}
+}
+
+// clientAddr returns the address of the client that sent r as recorded in
+// rejections, hashed when client addresses are
+func (s *Server) clientAddr(r *http.Request) string {
+	return s.ips.Hash(remoteHost(r))
+}
+
+// remoteHost returns the address of the client that sent r, without the
+// port
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// handleResultRejections lists rejected job result submissions, most recent
+// first
+func (s *Server) handleResultRejections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	cursor, limit, _, err := parsePageParams(r)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := s.rejectionStore.ListRejectionsPage(cursor, limit)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidCursor) {
+			s.writeErrorResponse(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list rejections: %v", err))
+		return
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, page)
+}
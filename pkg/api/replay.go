@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// maxNonceLength caps the nonces stored for replay protection
+const maxNonceLength = 128
+
+// checkReplay refuses a job result whose timestamp is outside the allowed
+// clock skew or whose nonce was already used for its job, when replay
+// protection is enabled. A replay of an accepted result repeats its
+// timestamp, so nonces only need to be remembered until that timestamp
+// leaves the skew window.
+func (s *Server) checkReplay(result *model.JobResult) error {
+	replay := s.config.Security.ReplayProtection
+	if !replay.Enabled {
+		return nil
+	}
+
+	if result.Timestamp.IsZero() || result.Nonce == "" {
+		return &ResultError{http.StatusBadRequest, "timestamp and nonce are required", model.RejectionInvalidPayload}
+	}
+	if len(result.Nonce) > maxNonceLength {
+		return &ResultError{http.StatusBadRequest, fmt.Sprintf("nonce must be at most %d characters", maxNonceLength), model.RejectionInvalidPayload}
+	}
+
+	maxSkew := time.Duration(replay.MaxSkew) * time.Second
+	if skew := time.Since(result.Timestamp); skew > maxSkew || skew < -maxSkew {
+		return &ResultError{http.StatusBadRequest, fmt.Sprintf("timestamp must be within %s of the server time", maxSkew), model.RejectionStaleTimestamp}
+	}
+
+	fresh, err := s.nonceStore.UseNonce(result.JobName, result.Host, result.Nonce, result.Timestamp.Add(maxSkew))
+	if err != nil {
+		return &ResultError{http.StatusInternalServerError, fmt.Sprintf("failed to check nonce: %v", err), ""}
+	}
+	if !fresh {
+		return &ResultError{http.StatusConflict, "nonce has already been used", model.RejectionReplay}
+	}
+	return nil
+}
+
+// releaseNonce forgets the nonce of a result that could not be stored
+func (s *Server) releaseNonce(result *model.JobResult) {
+	if !s.config.Security.ReplayProtection.Enabled {
+		return
+	}
+	if err := s.nonceStore.ReleaseNonce(result.JobName, result.Host, result.Nonce); err != nil {
+		logrus.WithError(err).Warn("failed to release nonce")
+	}
+}
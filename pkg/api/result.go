@@ -0,0 +1,416 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/script"
+	"github.com/jaepetto/cron-exporter/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// ResultError is a rejected job result submission. StatusCode is the HTTP
+// status the API answers with. Reason is the model.Rejection* reason the
+// submission is audited under, empty when the server is at fault.
+type ResultError struct {
+	StatusCode int
+	Message    string
+	Reason     string
+}
+
+func (e *ResultError) Error() string {
+	return e.Message
+}
+
+// Temporary reports whether submitting the same result again may succeed
+func (e *ResultError) Temporary() bool {
+	return e.StatusCode >= http.StatusInternalServerError
+}
+
+// RecordJobResult authenticates a job result with its job's API key and
+// records it, exactly like POST /api/job-result. It is used by the message
+// queue consumers.
+func (s *Server) RecordJobResult(apiKey string, result *model.JobResult) error {
+	return s.recordIngestedJobResult(apiKey, result, "ingest", true)
+}
+
+// ObservedResultRecorder records job results the server derived itself,
+// such as cron runs read from the system log. They are authenticated like
+// queued results but exempt from replay protection: they carry no nonce
+// and their timestamps come from the clocks of the hosts that logged them.
+type ObservedResultRecorder struct {
+	server *Server
+}
+
+// ObservedResultRecorder returns the recorder for job results the server
+// derived itself
+func (s *Server) ObservedResultRecorder() *ObservedResultRecorder {
+	return &ObservedResultRecorder{server: s}
+}
+
+// RecordJobResult authenticates and records an observed job result
+func (r *ObservedResultRecorder) RecordJobResult(apiKey string, result *model.JobResult) error {
+	return r.server.recordIngestedJobResult(apiKey, result, "syslog", false)
+}
+
+// recordIngestedJobResult authenticates and records a job result that did
+// not arrive over HTTP, auditing rejections under source
+func (s *Server) recordIngestedJobResult(apiKey string, result *model.JobResult, source string, checkReplay bool) error {
+	setResultSource(result, nil)
+	err := s.authenticateAndRecordJobResult(apiKey, result, checkReplay)
+	if err != nil {
+		// The payload was decoded already; fingerprint the result
+		payload, _ := json.Marshal(result)
+		s.recordRejection(err, result, apiKey, payload, source)
+	}
+	return err
+}
+
+// authenticateAndRecordJobResult records a job result authenticated by the
+// API key of its job
+func (s *Server) authenticateAndRecordJobResult(apiKey string, result *model.JobResult, checkReplay bool) error {
+	// Skip auth in development mode
+	if s.config.Database.Path == "/tmp/cronmetrics_dev.db" {
+		_, err := s.recordJobResult(result, nil, checkReplay)
+		return err
+	}
+
+	job, err := s.authenticateJobKey(apiKey)
+	if err != nil {
+		return err
+	}
+	_, err = s.recordJobResult(result, job, checkReplay)
+	return err
+}
+
+// maxResultBodySize caps the body of a job result submission
+const maxResultBodySize = 1 << 20
+
+// readResultBody reads the body of a job result submission, refusing
+// bodies larger than maxResultBodySize with 413 Request Entity Too Large
+func readResultBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxResultBodySize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, &ResultError{http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxResultBodySize), model.RejectionInvalidPayload}
+	}
+	if err != nil {
+		return nil, &ResultError{http.StatusBadRequest, fmt.Sprintf("failed to read request body: %v", err), model.RejectionInvalidPayload}
+	}
+	return payload, nil
+}
+
+// authenticateJobRequest returns the job a result submission is
+// authenticated as: the owner of its API key or, when the key is not sent,
+// the job named in the body if the body is signed with that job's key. The
+// body is left to be read again; a signed body is read up to
+// maxResultBodySize before it is authenticated.
+func (s *Server) authenticateJobRequest(w http.ResponseWriter, r *http.Request, apiKey string) (*model.Job, error) {
+	signature := r.Header.Get(util.SignatureHeader)
+	if apiKey != "" || signature == "" {
+		return s.authenticateJobKey(apiKey)
+	}
+
+	payload, err := readResultBody(w, r)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(payload))
+
+	// An unknown job gets the same answer as a wrong signature. So does an
+	// empty body, as the signature would not cover query parameters.
+	var result model.JobResult
+	_ = s.decodeResult(r, payload, &result)
+	job, err := s.jobStore.GetJob(result.JobName, result.Host)
+	if err != nil || len(payload) == 0 || !util.VerifySignature(job.ApiKey, payload, signature) {
+		return nil, &ResultError{http.StatusUnauthorized, "invalid signature", model.RejectionBadSignature}
+	}
+	return job, nil
+}
+
+// authenticateJobKey returns the job owning a per-job API key
+func (s *Server) authenticateJobKey(apiKey string) (*model.Job, error) {
+	if apiKey == "" {
+		return nil, &ResultError{http.StatusUnauthorized, "missing or invalid API key", model.RejectionMissingKey}
+	}
+
+	job, err := s.jobStore.GetJobByApiKey(apiKey)
+	if err != nil {
+		return nil, &ResultError{http.StatusUnauthorized, "invalid API key", model.RejectionInvalidKey}
+	}
+	return job, nil
+}
+
+// Longest user agent and client version kept with a result
+const (
+	maxUserAgentLength     = 256
+	maxClientVersionLength = 64
+)
+
+// maxRunIDLength caps the run IDs grouping a run's start and results
+const maxRunIDLength = 128
+
+// setResultSource records where a result came from and when it was
+// received, replacing anything the client sent in those fields. r is nil
+// for results that did not arrive over HTTP.
+func setResultSource(result *model.JobResult, r *http.Request) {
+	receivedAt := time.Now().UTC()
+	result.ReceivedAt = &receivedAt
+	result.SourceIP, result.UserAgent, result.ClientVersion = "", "", ""
+	result.ClockSkew, result.ClockSkewed = nil, false
+	result.Source = ""
+	result.ReportedStatus, result.StatusRuleID = "", 0
+	result.Test = false
+	if r != nil {
+		result.SourceIP = remoteHost(r)
+		result.UserAgent = truncateValid(r.UserAgent(), maxUserAgentLength)
+		result.ClientVersion = truncateValid(r.Header.Get(util.ClientVersionHeader), maxClientVersionLength)
+	}
+}
+
+// checkClockSkew records how far the result's timestamp is from the time
+// it was received. Results skewed beyond the configured threshold are
+// flagged and, with correction enabled, recorded at the time they were
+// received; the original timestamp remains ReceivedAt plus ClockSkew.
+func (s *Server) checkClockSkew(result *model.JobResult) {
+	if result.Timestamp.IsZero() || result.ReceivedAt == nil {
+		return
+	}
+
+	skew := result.Timestamp.Sub(*result.ReceivedAt).Round(time.Millisecond).Seconds()
+	result.ClockSkew = &skew
+
+	threshold := float64(s.config.ClockSkew.Threshold)
+	if threshold <= 0 || math.Abs(skew) <= threshold {
+		return
+	}
+	result.ClockSkewed = true
+
+	logrus.WithFields(logrus.Fields{
+		"job_name":   result.JobName,
+		"host":       result.Host,
+		"clock_skew": skew,
+	}).Warn("job result timestamp is skewed from the server clock")
+
+	if s.config.ClockSkew.Correct {
+		result.Timestamp = *result.ReceivedAt
+	}
+}
+
+// truncateValid cuts s to at most limit bytes of valid UTF-8
+func truncateValid(s string, limit int) string {
+	if len(s) > limit {
+		s = s[:limit]
+	}
+	return strings.ToValidUTF8(s, "")
+}
+
+// checkItemsProcessed refuses negative item counts, and results of counter
+// jobs that do not report how many items their run processed
+func (s *Server) checkItemsProcessed(result *model.JobResult, job *model.Job) error {
+	if result.ItemsProcessed != nil && *result.ItemsProcessed < 0 {
+		return &ResultError{http.StatusBadRequest, "items_processed cannot be negative", model.RejectionInvalidPayload}
+	}
+	if result.ItemsProcessed != nil || result.Status == model.ResultStarted {
+		return nil
+	}
+	if job == nil {
+		var err error
+		if job, err = s.jobStore.GetJob(result.JobName, result.Host); err != nil {
+			return nil
+		}
+	}
+	if job.Type == model.JobTypeCounter {
+		return &ResultError{http.StatusBadRequest, "items_processed is required for counter jobs", model.RejectionInvalidPayload}
+	}
+	return nil
+}
+
+// runScripts runs the scripts assigned to the job of result on it. A
+// failing script is logged, and the result recorded as the scripts before
+// it left it. A script stopped at a limit refuses the result instead, as
+// the script may have been meant to reclassify it; it can be submitted
+// again.
+func (s *Server) runScripts(result *model.JobResult, job *model.Job) error {
+	if job == nil {
+		var err error
+		if job, err = s.jobStore.GetJob(result.JobName, result.Host); err != nil {
+			return nil
+		}
+	}
+
+	status := result.Status
+	err := s.scripts.Process(job, result)
+	fields := logrus.Fields{
+		"job_name": result.JobName,
+		"host":     result.Host,
+	}
+	if errors.Is(err, script.ErrLimitExceeded) {
+		logrus.WithError(err).WithFields(fields).Warn("result script stopped at a limit, refusing the result")
+		return &ResultError{http.StatusServiceUnavailable, "a result script ran past its limits, retry later", model.RejectionScriptLimit}
+	}
+	if err != nil {
+		logrus.WithError(err).WithFields(fields).Warn("result script failed")
+	}
+	if result.Status != status {
+		fields["reported_status"] = status
+		fields["status"] = result.Status
+		logrus.WithFields(fields).Info("result status changed by script")
+	}
+	return nil
+}
+
+// recordJobResult validates and stores a job result. When authJob is set,
+// the result must belong to it. With checkReplay, the result must also pass
+// replay protection when it is enabled. A "started" result only records
+// that a run started, so it can be recorded as lost if its result never
+// arrives. It returns whether the result was coalesced into its job's
+// previous one by the rate limit rather than stored.
+func (s *Server) recordJobResult(result *model.JobResult, authJob *model.Job, checkReplay bool) (bool, error) {
+	// Validate required fields
+	if result.JobName == "" || result.Host == "" || result.Status == "" {
+		return false, &ResultError{http.StatusBadRequest, "job_name, host, and status are required", model.RejectionInvalidPayload}
+	}
+
+	// Validate status
+	if result.Status != "success" && result.Status != "failure" && result.Status != model.ResultStarted {
+		return false, &ResultError{http.StatusBadRequest, "status must be 'success', 'failure' or 'started'", model.RejectionInvalidPayload}
+	}
+
+	if len(result.RunID) > maxRunIDLength {
+		return false, &ResultError{http.StatusBadRequest, fmt.Sprintf("run_id must be at most %d characters", maxRunIDLength), model.RejectionInvalidPayload}
+	}
+	if err := model.CheckShard(result); err != nil {
+		return false, &ResultError{http.StatusBadRequest, err.Error(), model.RejectionInvalidPayload}
+	}
+
+	if authJob != nil && (result.JobName != authJob.Name || result.Host != authJob.Host) {
+		return false, &ResultError{http.StatusForbidden, "job result does not match authenticated job", model.RejectionJobMismatch}
+	}
+
+	if err := s.checkItemsProcessed(result, authJob); err != nil {
+		return false, err
+	}
+
+	// Let the status rules and scripts of the job reclassify the result
+	// before it is limited, as the limit looks at its status
+	if result.Status != model.ResultStarted {
+		s.applyStatusRules(result)
+		if s.scripts != nil {
+			if err := s.runScripts(result, authJob); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	// Refuse results of a job stuck in a loop before their nonce is used.
+	// Starts are not results and are never limited, nor are tests.
+	coalesced := false
+	var held *reservation
+	if result.Status != model.ResultStarted && !result.Test {
+		var err error
+		if held, coalesced, err = s.limiter.check(result, authJob, time.Now()); err != nil {
+			if s.metrics != nil {
+				s.metrics.RecordRateLimited(result.JobName, result.Host, "rejected")
+			}
+			return false, err
+		}
+	}
+
+	if checkReplay {
+		if err := s.checkReplay(result); err != nil {
+			held.release()
+			return false, err
+		}
+	}
+
+	s.checkClockSkew(result)
+
+	// Set timestamp if not provided
+	if result.Timestamp.IsZero() {
+		result.Timestamp = time.Now().UTC()
+	}
+
+	if result.Status == model.ResultStarted {
+		overlap, err := s.jobResultStore.RecordStart(result.JobName, result.Host, result.RunID, result.Timestamp)
+		if err != nil {
+			if checkReplay {
+				s.releaseNonce(result)
+			}
+			return false, &ResultError{http.StatusInternalServerError, fmt.Sprintf("failed to store job start: %v", err), ""}
+		}
+		if overlap != nil {
+			logrus.WithFields(logrus.Fields{
+				"job_name":            overlap.JobName,
+				"host":                overlap.Host,
+				"run_id":              overlap.RunID,
+				"previous_run_id":     overlap.PreviousRunID,
+				"previous_started_at": overlap.PreviousStartedAt,
+			}).Warn("job run started while its previous run was still open")
+		}
+		return false, nil
+	}
+
+	if coalesced {
+		// The job is alive, but its previous result stands for this one
+		if s.metrics != nil {
+			s.metrics.RecordRateLimited(result.JobName, result.Host, "coalesced")
+		}
+		if err := s.jobStore.UpdateJobLastReported(result.JobName, result.Host, result.Timestamp); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"job_name": result.JobName,
+				"host":     result.Host,
+			}).Warn("failed to update job last reported timestamp")
+		}
+		return true, nil
+	}
+
+	// Store the job result
+	if err := s.jobResultStore.CreateJobResult(result); err != nil {
+		// Let the client retry with the same nonce, and within the interval
+		if checkReplay {
+			s.releaseNonce(result)
+		}
+		held.release()
+		return false, &ResultError{http.StatusInternalServerError, fmt.Sprintf("failed to store job result: %v", err), ""}
+	}
+	// Update job's last reported timestamp; a test does not show the job
+	// is alive
+	if !result.Test {
+		if err := s.jobStore.UpdateJobLastReported(result.JobName, result.Host, result.Timestamp); err != nil {
+			// Log error but don't fail the submission
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"job_name": result.JobName,
+				"host":     result.Host,
+			}).Warn("failed to update job last reported timestamp")
+		}
+	}
+
+	// Broadcast job status change to dashboard clients if dashboard is enabled
+	if s.dashboard != nil && s.dashboard.IsEnabled() {
+		if broadcaster := s.dashboard.GetBroadcaster(); broadcaster != nil {
+			// Get the updated job to broadcast current status
+			if job, err := s.jobStore.GetJob(result.JobName, result.Host); err == nil {
+				// Determine if job is in failure state
+				isFailure := result.Status == "failure"
+
+				// Also check automatic failure threshold
+				if !isFailure && job.AutomaticFailureThreshold > 0 && job.MissedDeadline(time.Now()) {
+					isFailure = true
+				}
+
+				broadcaster.BroadcastJobStatusChange(job, isFailure)
+			}
+		}
+	}
+
+	return false, nil
+}
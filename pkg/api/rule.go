@@ -0,0 +1,135 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// StatusRuleRequest is the body of POST /api/job/{id}/rules
+type StatusRuleRequest struct {
+	Pattern     string `json:"pattern,omitempty"`   // Regular expression searched for in the output
+	ExitCode    *int   `json:"exit_code,omitempty"` // Exit code the result must have
+	Status      string `json:"status"`              // Status matching results are recorded with
+	Description string `json:"description,omitempty"`
+	Author      string `json:"author,omitempty"` // Defaults to "api"
+}
+
+// handleJobRules lists (GET) or adds (POST) a job's status rules, or
+// deletes one (DELETE /api/job/{id}/rules/{ruleID})
+func (s *Server) handleJobRules(w http.ResponseWriter, r *http.Request, jobID int, ruleID string) {
+	if ruleID == "" && r.Method != http.MethodGet && r.Method != http.MethodPost ||
+		ruleID != "" && r.Method != http.MethodDelete {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	job, err := s.jobStore.GetJobByID(jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.writeErrorResponse(w, http.StatusNotFound, "job not found")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get job: %v", err))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := s.ruleStore.ListRules(job.Name, job.Host)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list status rules: %v", err))
+			return
+		}
+		s.writeJSONResponse(w, http.StatusOK, rules)
+
+	case http.MethodPost:
+		var req StatusRuleRequest
+		if err := s.decodeBody(r, &req); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+			return
+		}
+
+		rule := &model.StatusRule{
+			JobName:     job.Name,
+			Host:        job.Host,
+			Pattern:     req.Pattern,
+			ExitCode:    req.ExitCode,
+			Status:      req.Status,
+			Description: strings.TrimSpace(req.Description),
+			Author:      req.Author,
+		}
+		if rule.Author == "" {
+			rule.Author = "api"
+		}
+		if err := rule.Validate(); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := s.ruleStore.CreateRule(rule); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to create status rule: %v", err))
+			return
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"job_name": job.Name,
+			"host":     job.Host,
+			"rule_id":  rule.ID,
+			"status":   rule.Status,
+		}).Info("status rule added")
+
+		s.writeJSONResponse(w, http.StatusCreated, rule)
+
+	case http.MethodDelete:
+		id, err := strconv.Atoi(ruleID)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, "invalid rule ID format (must be a number)")
+			return
+		}
+
+		if err := s.ruleStore.DeleteRule(id, job.Name, job.Host); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				s.writeErrorResponse(w, http.StatusNotFound, "status rule not found")
+				return
+			}
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to delete status rule: %v", err))
+			return
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"job_name": job.Name,
+			"host":     job.Host,
+			"rule_id":  id,
+		}).Info("status rule deleted")
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// applyStatusRules overrides the status of result by the first of its
+// job's rules it matches. Results whose rules cannot be read are recorded
+// as reported.
+func (s *Server) applyStatusRules(result *model.JobResult) {
+	fields := logrus.Fields{
+		"job_name": result.JobName,
+		"host":     result.Host,
+	}
+
+	rules, err := s.ruleStore.ListRules(result.JobName, result.Host)
+	if err != nil {
+		logrus.WithError(err).WithFields(fields).Warn("failed to read status rules")
+		return
+	}
+
+	if rule := model.ApplyStatusRules(rules, result); rule != nil {
+		fields["rule_id"] = rule.ID
+		fields["reported_status"] = result.ReportedStatus
+		fields["status"] = result.Status
+		logrus.WithFields(fields).Info("result status overridden by rule")
+	}
+}
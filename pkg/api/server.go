@@ -2,10 +2,14 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,34 +19,88 @@ import (
 	"github.com/jaepetto/cron-exporter/pkg/dashboard"
 	"github.com/jaepetto/cron-exporter/pkg/metrics"
 	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/notifier"
+	"github.com/jaepetto/cron-exporter/pkg/script"
+	"github.com/jaepetto/cron-exporter/pkg/slo"
+	"github.com/jaepetto/cron-exporter/pkg/ticket"
 	"github.com/jaepetto/cron-exporter/pkg/util"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 )
 
 // Server represents the HTTP API server
 type Server struct {
-	config         *config.Config
-	jobStore       *model.JobStore
-	jobResultStore *model.JobResultStore
-	metrics        *metrics.Collector
-	dashboard      *dashboard.Dashboard
+	config            *config.Config
+	jobStore          *model.JobStore
+	jobResultStore    *model.JobResultStore
+	adminKeyStore     *model.AdminKeyStore
+	rejectionStore    *model.RejectionStore
+	nonceStore        *model.NonceStore
+	annotationStore   *model.AnnotationStore
+	ruleStore         *model.RuleStore
+	ackStore          *model.AcknowledgementStore
+	notificationStore *model.NotificationStore
+	changeStore       *model.ChangeStore
+	deliverer         *ticket.Deliverer   // nil unless tickets are enabled
+	notifierDeliverer *notifier.Deliverer // nil unless the notifier is enabled
+	sloTracker        *slo.Tracker        // nil when no SLO is defined
+	limiter           *resultLimiter
+	ips               *util.IPHasher // nil to keep client addresses
+	scripts           *script.Runner // nil when no script is assigned
+	metrics           *metrics.Collector
+	dashboard         *dashboard.Dashboard
 }
 
 // NewServer creates a new API server instance
 func NewServer(cfg *config.Config, jobStore *model.JobStore, jobResultStore *model.JobResultStore, metricsCollector *metrics.Collector) *Server {
 	server := &Server{
-		config:         cfg,
-		jobStore:       jobStore,
-		jobResultStore: jobResultStore,
-		metrics:        metricsCollector,
+		config:            cfg,
+		jobStore:          jobStore,
+		jobResultStore:    jobResultStore,
+		adminKeyStore:     model.NewAdminKeyStore(jobStore.DB()),
+		rejectionStore:    model.NewRejectionStore(jobStore.DB()),
+		nonceStore:        model.NewNonceStore(jobStore.DB()),
+		annotationStore:   model.NewAnnotationStore(jobStore.DB()),
+		ruleStore:         model.NewRuleStore(jobStore.DB()),
+		ackStore:          model.NewAcknowledgementStore(jobStore.DB()),
+		notificationStore: model.NewNotificationStore(jobStore.DB()),
+		changeStore:       model.NewChangeStore(jobStore.DB()),
+		sloTracker:        slo.NewTracker(&cfg.SLO, jobStore, jobResultStore),
+		limiter:           newResultLimiter(&cfg.RateLimit),
+		ips:               cfg.Logging.IPHasher(),
+		metrics:           metricsCollector,
+	}
+
+	// Load the scripts run on incoming results
+	scripts, err := script.NewRunner(&cfg.Scripts)
+	if err != nil {
+		logrus.WithError(err).Error("result scripts disabled")
+	}
+	server.scripts = scripts
+
+	// Deliver recorded notifications again on request
+	if cfg.Tickets.Enabled {
+		deliverer, err := ticket.NewDeliverer(&cfg.Tickets, server.ackStore, server.notificationStore)
+		if err != nil {
+			logrus.WithError(err).Warn("notification redelivery disabled")
+		} else {
+			server.deliverer = deliverer
+		}
+	}
+	if cfg.Notifier.Enabled {
+		deliverer, err := notifier.NewDeliverer(&cfg.Notifier, model.NewChannelStore(jobStore.DB()), server.notificationStore)
+		if err != nil {
+			logrus.WithError(err).Warn("notifier redelivery disabled")
+		} else {
+			server.notifierDeliverer = deliverer
+		}
 	}
 
 	// Initialize dashboard if enabled
 	if cfg.Dashboard.Enabled {
 		server.dashboard = dashboard.New(
-			&cfg.Dashboard,
+			cfg,
 			jobStore,
-			cfg.Security.AdminAPIKeys,
 			logrus.StandardLogger(),
 		)
 	}
@@ -55,22 +113,42 @@ func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 
 	// API routes
-	mux.HandleFunc("/api/job", s.withAuth(s.handleJob))
-	mux.HandleFunc("/api/job/", s.withAuth(s.handleJobByID))
-	mux.HandleFunc("/api/job-result", s.withJobAuth(s.handleJobResult))
+	mux.HandleFunc("/api/job", s.adminRoute((*Server).handleJob))
+	mux.HandleFunc("/api/job/reconcile", s.adminRoute((*Server).handleReconcile))
+	mux.HandleFunc("/api/job/exists", s.adminRoute((*Server).handleJobExists))
+	mux.HandleFunc("/api/job/", s.adminRoute((*Server).handleJobByID))
+	mux.HandleFunc("GET /api/job-result", s.adminRoute((*Server).handleListJobResults))
+	mux.HandleFunc("/api/job-result", s.jobRoute((*Server).handleJobResult))
+	mux.HandleFunc("/api/ping", s.withDeadline((*Server).handlePing))
+	mux.HandleFunc("/api/result-rejections", s.adminRoute((*Server).handleResultRejections))
+	mux.HandleFunc("/api/notifications", s.adminRoute((*Server).handleNotifications))
+	mux.HandleFunc("/api/notifications/", s.adminRoute((*Server).handleNotificationByID))
+	mux.HandleFunc("/api/labels", s.adminRoute((*Server).handleLabels))
+	mux.HandleFunc("/api/hosts", s.adminRoute((*Server).handleHosts))
+	mux.HandleFunc("/api/problems", s.adminRoute((*Server).handleProblems))
+	mux.HandleFunc("/api/maintenance", s.adminRoute((*Server).handleMaintenance))
+	mux.HandleFunc("/api/maintenance/", s.adminRoute((*Server).handleMaintenanceByID))
+	mux.HandleFunc("/api/changes", s.adminRoute((*Server).handleChanges))
+	mux.HandleFunc("/api/graphql", s.adminRoute((*Server).handleGraphQL))
 
 	// Metrics endpoint
-	mux.HandleFunc(s.config.Metrics.Path, s.handleMetrics)
+	mux.HandleFunc(s.config.Metrics.Path, s.metricsRoute((*Server).handleMetrics))
+	mux.HandleFunc(s.config.Metrics.Path+"/job/", s.metricsRoute((*Server).handleJobMetrics))
 
 	// Health check
-	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/health", s.withScrapeSources(s.handleHealth))
 
 	// Swagger UI and OpenAPI spec
 	mux.Handle("/swagger/", httpSwagger.Handler(
-		httpSwagger.URL("/api/openapi.yaml"), // The URL pointing to the OpenAPI spec
+		httpSwagger.URL(s.config.Server.BasePath+"/api/openapi.yaml"), // The URL pointing to the OpenAPI spec
 		httpSwagger.DeepLinking(true),
 		httpSwagger.DocExpansion("list"),
 		httpSwagger.DomID("swagger-ui"),
+		httpSwagger.PersistAuthorization(true), // Keep the entered API keys across reloads
+		httpSwagger.UIConfig(map[string]string{
+			"tryItOutEnabled":        "true",
+			"displayRequestDuration": "true",
+		}),
 	))
 	mux.HandleFunc("/api/openapi.yaml", s.handleOpenAPISpec)
 
@@ -80,8 +158,16 @@ func (s *Server) Handler() http.Handler {
 		mux.Handle(s.config.Dashboard.Path+"/", http.StripPrefix(s.config.Dashboard.Path, s.dashboard.Router()))
 	}
 
+	// Serve everything under the base path, e.g. behind a reverse proxy
+	var handler http.Handler = mux
+	if s.config.Server.BasePath != "" {
+		prefixed := http.NewServeMux()
+		prefixed.Handle(s.config.Server.BasePath+"/", http.StripPrefix(s.config.Server.BasePath, mux))
+		handler = prefixed
+	}
+
 	// Add request logging middleware
-	return s.withLogging(mux)
+	return s.withLogging(withoutAuthHeaders(handler))
 }
 
 // withAuth provides authentication middleware for admin operations
@@ -89,7 +175,7 @@ func (s *Server) withAuth(handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Skip auth in development mode
 		if s.config.Database.Path == "/tmp/cronmetrics_dev.db" {
-			handler(w, r)
+			handler(w, withPrincipal(r, &Principal{Level: AuthLevelAdmin}))
 			return
 		}
 
@@ -106,9 +192,7 @@ func (s *Server) withAuth(handler http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		// Add auth info to request context
-		r.Header.Set("X-Auth-Level", "admin")
-		handler(w, r)
+		handler(w, withPrincipal(r, &Principal{Level: AuthLevelAdmin}))
 	}
 }
 
@@ -121,26 +205,25 @@ func (s *Server) withJobAuth(handler http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		// Get API key from header
+		// Validate API key or body signature by looking up the associated job
 		apiKey := s.extractAPIKey(r)
-		if apiKey == "" {
-			s.writeErrorResponse(w, http.StatusUnauthorized, "missing or invalid API key")
-			return
+		job, err := s.authenticateJobRequest(w, r, apiKey)
+		if err == nil && !job.AllowsSource(remoteHost(r)) {
+			// A valid key used from outside the job's ranges may have leaked
+			err = &ResultError{http.StatusForbidden, "source address not allowed for this job", model.RejectionSourceDenied}
 		}
-
-		// Validate API key by looking up the associated job
-		job, err := s.jobStore.GetJobByApiKey(apiKey)
 		if err != nil {
-			s.writeErrorResponse(w, http.StatusUnauthorized, "invalid API key")
+			// Read what the client sent, for the rejection audit
+			payload, _ := io.ReadAll(io.LimitReader(r.Body, maxRejectedPayloadSize))
+			var result model.JobResult
+			_ = s.decodeResult(r, payload, &result)
+			s.recordRejection(err, &result, apiKey, payload, s.clientAddr(r))
+
+			s.writeResultError(w, err)
 			return
 		}
 
-		// Add job info to request context for validation
-		r.Header.Set("X-Auth-Job-Name", job.Name)
-		r.Header.Set("X-Auth-Job-Host", job.Host)
-		r.Header.Set("X-Auth-Level", "job")
-
-		handler(w, r)
+		handler(w, withPrincipal(r, &Principal{Level: AuthLevelJob, Job: job}))
 	}
 }
 
@@ -168,6 +251,8 @@ func (s *Server) extractAPIKey(r *http.Request) string {
 
 // withLogging provides request logging middleware
 func (s *Server) withLogging(handler http.Handler) http.Handler {
+	access := newAccessLog(s.config)
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
@@ -178,15 +263,13 @@ func (s *Server) withLogging(handler http.Handler) http.Handler {
 
 		duration := time.Since(start)
 
-		logrus.WithFields(logrus.Fields{
-			"method":         r.Method,
-			"path":           r.URL.Path,
-			"status":         wrapped.statusCode,
-			"duration_ms":    duration.Milliseconds(),
-			"remote_addr":    r.RemoteAddr,
-			"user_agent":     r.UserAgent(),
-			"content_length": r.ContentLength,
-		}).Info("http request")
+		level, ok := access.levelFor(r.URL.Path, wrapped.statusCode)
+		if !ok {
+			return
+		}
+
+		logrus.WithFields(access.entryFields(r, wrapped.statusCode, duration.Milliseconds())).
+			Log(level, "http request")
 	})
 }
 
@@ -201,6 +284,14 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Flush sends buffered data, for streamed responses such as the dashboard's
+// server-sent events
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 // handleJob handles job CRUD operations
 func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -208,6 +299,8 @@ func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
 		s.handleCreateJob(w, r)
 	case http.MethodGet:
 		s.handleListJobs(w, r)
+	case http.MethodPut:
+		s.handleUpsertJob(w, r)
 	default:
 		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
 	}
@@ -223,13 +316,46 @@ func (s *Server) handleJobByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse job ID
-	jobID := 0
-	if _, err := fmt.Sscanf(path, "%d", &jobID); err != nil {
+	// Parse job ID and optional sub-resource (/api/job/{id}/{action})
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	jobID, err := strconv.Atoi(segments[0])
+	if err != nil {
 		s.writeErrorResponse(w, http.StatusBadRequest, "invalid job ID format (must be a number)")
 		return
 	}
 
+	if len(segments) > 1 {
+		switch {
+		case len(segments) == 2 && segments[1] == "clone":
+			s.handleCloneJob(w, r, jobID)
+		case len(segments) == 2 && segments[1] == "results":
+			s.handleJobResults(w, r, jobID)
+		case len(segments) == 2 && segments[1] == "action-link":
+			s.handleCreateActionLink(w, r, jobID)
+		case len(segments) == 2 && segments[1] == "snooze":
+			s.handleSnoozeJob(w, r, jobID)
+		case len(segments) == 2 && segments[1] == "check":
+			s.handleCheckJob(w, r, jobID)
+		case len(segments) == 2 && segments[1] == "test":
+			s.handleTestJob(w, r, jobID)
+		case len(segments) == 2 && segments[1] == "annotations":
+			s.handleJobAnnotations(w, r, jobID, "")
+		case len(segments) == 3 && segments[1] == "annotations":
+			s.handleJobAnnotations(w, r, jobID, segments[2])
+		case len(segments) == 2 && segments[1] == "rules":
+			s.handleJobRules(w, r, jobID, "")
+		case len(segments) == 3 && segments[1] == "rules":
+			s.handleJobRules(w, r, jobID, segments[2])
+		case len(segments) == 2 && segments[1] == "acknowledgements":
+			s.handleJobAcknowledgements(w, r, jobID)
+		case len(segments) <= 4 && segments[1] == "versions":
+			s.handleJobVersions(w, r, jobID, segments[2:])
+		default:
+			s.writeErrorResponse(w, http.StatusNotFound, "not found")
+		}
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		s.handleGetJobByID(w, r, jobID)
@@ -245,13 +371,13 @@ func (s *Server) handleJobByID(w http.ResponseWriter, r *http.Request) {
 // handleCreateJob creates a new job
 func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
 	// Only admin can create jobs
-	if r.Header.Get("X-Auth-Level") != "admin" {
+	if !isAdminRequest(r) {
 		s.writeErrorResponse(w, http.StatusForbidden, "admin access required")
 		return
 	}
 
 	var job model.Job
-	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+	if err := s.decodeBody(r, &job); err != nil {
 		s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
 		return
 	}
@@ -261,6 +387,10 @@ func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
 		s.writeErrorResponse(w, http.StatusBadRequest, "job name and host are required")
 		return
 	}
+	if err := model.CheckJobIdentity(job.Name, job.Host); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	// Generate API key if not provided
 	if job.ApiKey == "" {
@@ -272,20 +402,49 @@ func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
 		job.ApiKey = apiKey
 	}
 
-	// Set defaults
-	if job.AutomaticFailureThreshold == 0 {
-		job.AutomaticFailureThreshold = 3600
+	applyJobDefaults(&job, &s.config.Defaults)
+
+	if err := normalizeAllowedCIDRs(&job); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkDescription(&job); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
 	}
-	if job.Status == "" {
-		job.Status = "active"
+	if err := model.CheckJobType(job.Type, job.ThroughputFloor); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
 	}
-	if job.Labels == nil {
-		job.Labels = make(map[string]string)
+	if err := model.CheckSchedule(job.Schedule); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := model.CheckShardPolicy(job.ShardPolicy); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := model.CheckMinInterval(job.MinInterval); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.checkLabelCardinality(job.Labels); err != nil {
+		s.writeLabelCheckError(w, err)
+		return
+	}
+
+	if isDryRun(r) {
+		if _, err := s.jobStore.GetJob(job.Name, job.Host); err == nil {
+			s.writeErrorResponse(w, http.StatusConflict, "job already exists")
+			return
+		}
+		s.writeDryRunResponse(w, reconcileCreated, nil, &job)
+		return
 	}
-	job.LastReportedAt = time.Now().UTC()
 
 	if err := s.jobStore.CreateJob(&job); err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		if model.IsUniqueViolation(err) {
 			s.writeErrorResponse(w, http.StatusConflict, "job already exists")
 			return
 		}
@@ -309,6 +468,35 @@ func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Clients asking for a cursor or limit get a page and a next_cursor
+	cursor, limit, paginated, err := parsePageParams(r)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Search parameters get a numbered page of the matching jobs
+	criteria, search, err := parseJobSearch(r, time.Now().UTC())
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if search && paginated {
+		s.writeErrorResponse(w, http.StatusBadRequest, "cursor and limit cannot be combined with search parameters, use page and page_size")
+		return
+	}
+	if search {
+		if len(labelFilters) > 0 {
+			criteria.Labels = labelFilters
+		}
+		s.handleSearchJobs(w, criteria)
+		return
+	}
+	if paginated {
+		s.handleListJobsPage(w, labelFilters, cursor, limit)
+		return
+	}
+
 	jobs, err := s.jobStore.ListJobs(labelFilters)
 	if err != nil {
 		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list jobs: %v", err))
@@ -351,7 +539,7 @@ func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request, jobName, j
 // handleUpdateJobByID updates a job by ID
 func (s *Server) handleUpdateJobByID(w http.ResponseWriter, r *http.Request, jobID int) {
 	// Only admin can update jobs
-	if r.Header.Get("X-Auth-Level") != "admin" {
+	if !isAdminRequest(r) {
 		s.writeErrorResponse(w, http.StatusForbidden, "admin access required")
 		return
 	}
@@ -367,12 +555,14 @@ func (s *Server) handleUpdateJobByID(w http.ResponseWriter, r *http.Request, job
 		return
 	}
 
-	var updateData model.Job
-	if err := json.NewDecoder(r.Body).Decode(&updateData); err != nil {
+	var updateData jobUpdate
+	if err := s.decodeBody(r, &updateData); err != nil {
 		s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
 		return
 	}
 
+	before := *existingJob
+
 	// Update only provided fields
 	if updateData.Name != "" {
 		existingJob.Name = updateData.Name
@@ -380,18 +570,79 @@ func (s *Server) handleUpdateJobByID(w http.ResponseWriter, r *http.Request, job
 	if updateData.Host != "" {
 		existingJob.Host = updateData.Host
 	}
+	if err := model.CheckJobIdentity(existingJob.Name, existingJob.Host); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if updateData.ApiKey != "" {
 		existingJob.ApiKey = updateData.ApiKey
 	}
-	if updateData.AutomaticFailureThreshold > 0 {
-		existingJob.AutomaticFailureThreshold = updateData.AutomaticFailureThreshold
+	setThreshold(existingJob, &updateData.Job)
+	if updateData.Description != "" {
+		existingJob.Description = updateData.Description
+		if err := checkDescription(existingJob); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 	}
 	if updateData.Labels != nil {
 		existingJob.Labels = updateData.Labels
+		if err := s.checkLabelCardinality(existingJob.Labels); err != nil {
+			s.writeLabelCheckError(w, err)
+			return
+		}
+	}
+	if updateData.AllowedCIDRs != nil {
+		existingJob.AllowedCIDRs = updateData.AllowedCIDRs
+		if err := normalizeAllowedCIDRs(existingJob); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 	}
 	if updateData.Status != "" {
 		existingJob.Status = updateData.Status
 	}
+	if err := model.CheckJobType(updateData.Type, updateData.ThroughputFloor); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if updateData.Type != "" {
+		existingJob.Type = updateData.Type
+	}
+	if updateData.ThroughputFloor > 0 {
+		existingJob.ThroughputFloor = updateData.ThroughputFloor
+	}
+	if updateData.Schedule.Set {
+		if err := model.CheckSchedule(updateData.Schedule.Value); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		existingJob.Schedule = updateData.Schedule.Value
+	}
+	if updateData.ShardPolicy != "" {
+		if err := model.CheckShardPolicy(updateData.ShardPolicy); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		existingJob.ShardPolicy = updateData.ShardPolicy
+	}
+	if updateData.MinInterval != 0 {
+		if err := model.CheckMinInterval(updateData.MinInterval); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		existingJob.MinInterval = updateData.MinInterval
+	}
+	existingJob.ApplyDefaultThreshold(s.config.Defaults.FailureThreshold)
+
+	if isDryRun(r) {
+		action := reconcileUpdated
+		if len(jobDiff(&before, existingJob)) == 0 {
+			action = reconcileUnchanged
+		}
+		s.writeDryRunResponse(w, action, &before, existingJob)
+		return
+	}
 
 	if err := s.jobStore.UpdateJobByID(existingJob); err != nil {
 		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to update job: %v", err))
@@ -404,7 +655,7 @@ func (s *Server) handleUpdateJobByID(w http.ResponseWriter, r *http.Request, job
 // handleUpdateJob updates a job (kept for backward compatibility)
 func (s *Server) handleUpdateJob(w http.ResponseWriter, r *http.Request, jobName, jobHost string) {
 	// Only admin can update jobs
-	if r.Header.Get("X-Auth-Level") != "admin" {
+	if !isAdminRequest(r) {
 		s.writeErrorResponse(w, http.StatusForbidden, "admin access required")
 		return
 	}
@@ -420,8 +671,8 @@ func (s *Server) handleUpdateJob(w http.ResponseWriter, r *http.Request, jobName
 		return
 	}
 
-	var updateData model.Job
-	if err := json.NewDecoder(r.Body).Decode(&updateData); err != nil {
+	var updateData jobUpdate
+	if err := s.decodeBody(r, &updateData); err != nil {
 		s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
 		return
 	}
@@ -430,15 +681,59 @@ func (s *Server) handleUpdateJob(w http.ResponseWriter, r *http.Request, jobName
 	if updateData.ApiKey != "" {
 		existingJob.ApiKey = updateData.ApiKey
 	}
-	if updateData.AutomaticFailureThreshold > 0 {
-		existingJob.AutomaticFailureThreshold = updateData.AutomaticFailureThreshold
-	}
+	setThreshold(existingJob, &updateData.Job)
 	if updateData.Labels != nil {
 		existingJob.Labels = updateData.Labels
 	}
+	if updateData.Description != "" {
+		existingJob.Description = updateData.Description
+		if err := checkDescription(existingJob); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if updateData.AllowedCIDRs != nil {
+		existingJob.AllowedCIDRs = updateData.AllowedCIDRs
+		if err := normalizeAllowedCIDRs(existingJob); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
 	if updateData.Status != "" {
 		existingJob.Status = updateData.Status
 	}
+	if err := model.CheckJobType(updateData.Type, updateData.ThroughputFloor); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if updateData.Type != "" {
+		existingJob.Type = updateData.Type
+	}
+	if updateData.ThroughputFloor > 0 {
+		existingJob.ThroughputFloor = updateData.ThroughputFloor
+	}
+	if updateData.Schedule.Set {
+		if err := model.CheckSchedule(updateData.Schedule.Value); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		existingJob.Schedule = updateData.Schedule.Value
+	}
+	if updateData.ShardPolicy != "" {
+		if err := model.CheckShardPolicy(updateData.ShardPolicy); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		existingJob.ShardPolicy = updateData.ShardPolicy
+	}
+	if updateData.MinInterval != 0 {
+		if err := model.CheckMinInterval(updateData.MinInterval); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		existingJob.MinInterval = updateData.MinInterval
+	}
+	existingJob.ApplyDefaultThreshold(s.config.Defaults.FailureThreshold)
 
 	if err := s.jobStore.UpdateJob(existingJob); err != nil {
 		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to update job: %v", err))
@@ -448,14 +743,63 @@ func (s *Server) handleUpdateJob(w http.ResponseWriter, r *http.Request, jobName
 	s.writeJSONResponse(w, http.StatusOK, existingJob)
 }
 
+// jobUpdate is the body of a job update. Empty fields keep the job's
+// values, except the schedule: an empty or null one clears it, and only an
+// omitted one keeps it.
+type jobUpdate struct {
+	model.Job
+	Schedule optionalString `json:"schedule"`
+}
+
+// optionalString is a string field that tells an omitted value from an
+// empty one, null decoding as empty
+type optionalString struct {
+	Set   bool
+	Value string
+}
+
+func (o *optionalString) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = ""
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// setThreshold applies the threshold of an update: its own threshold, or
+// threshold_inherited to follow the configured defaults again
+func setThreshold(job, update *model.Job) {
+	if update.AutomaticFailureThreshold > 0 {
+		job.AutomaticFailureThreshold = update.AutomaticFailureThreshold
+		job.ThresholdInherited = false
+	} else if update.ThresholdInherited {
+		job.ThresholdInherited = true
+	}
+}
+
 // handleDeleteJobByID deletes a job by ID
 func (s *Server) handleDeleteJobByID(w http.ResponseWriter, r *http.Request, jobID int) {
 	// Only admin can delete jobs
-	if r.Header.Get("X-Auth-Level") != "admin" {
+	if !isAdminRequest(r) {
 		s.writeErrorResponse(w, http.StatusForbidden, "admin access required")
 		return
 	}
 
+	if isDryRun(r) {
+		job, err := s.jobStore.GetJobByID(jobID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				s.writeErrorResponse(w, http.StatusNotFound, "job not found")
+				return
+			}
+			s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get job: %v", err))
+			return
+		}
+		s.writeDryRunResponse(w, reconcileDeleted, job, nil)
+		return
+	}
+
 	if err := s.jobStore.DeleteJobByID(jobID); err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			s.writeErrorResponse(w, http.StatusNotFound, "job not found")
@@ -471,7 +815,7 @@ func (s *Server) handleDeleteJobByID(w http.ResponseWriter, r *http.Request, job
 // handleDeleteJob deletes a job (kept for backward compatibility)
 func (s *Server) handleDeleteJob(w http.ResponseWriter, r *http.Request, jobName, jobHost string) {
 	// Only admin can delete jobs
-	if r.Header.Get("X-Auth-Level") != "admin" {
+	if !isAdminRequest(r) {
 		s.writeErrorResponse(w, http.StatusForbidden, "admin access required")
 		return
 	}
@@ -495,78 +839,47 @@ func (s *Server) handleJobResult(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var result model.JobResult
-	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
-		s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+	payload, err := readResultBody(w, r)
+	if err != nil {
+		s.writeResultError(w, err)
 		return
 	}
 
-	// Validate required fields
-	if result.JobName == "" || result.Host == "" || result.Status == "" {
-		s.writeErrorResponse(w, http.StatusBadRequest, "job_name, host, and status are required")
+	var result model.JobResult
+	if resultErr := s.decodeResult(r, payload, &result); resultErr != nil {
+		if resultErr.StatusCode == http.StatusUnsupportedMediaType {
+			w.Header().Set("Accept-Post", s.acceptedResultTypes())
+		}
+		s.recordRejection(resultErr, nil, "", payload, s.clientAddr(r))
+		s.writeResultError(w, resultErr)
 		return
 	}
 
-	// Validate status
-	if result.Status != "success" && result.Status != "failure" {
-		s.writeErrorResponse(w, http.StatusBadRequest, "status must be 'success' or 'failure'")
-		return
-	}
+	setResultSource(&result, r)
 
-	// In non-dev mode, validate that the job result matches the authenticated job
+	// In non-dev mode, the job result must match the authenticated job;
+	// without one, no job matches
+	var authJob *model.Job
 	if s.config.Database.Path != "/tmp/cronmetrics_dev.db" {
-		authJobName := r.Header.Get("X-Auth-Job-Name")
-		authJobHost := r.Header.Get("X-Auth-Job-Host")
-
-		if result.JobName != authJobName || result.Host != authJobHost {
-			s.writeErrorResponse(w, http.StatusForbidden, "job result does not match authenticated job")
-			return
+		authJob = &model.Job{}
+		if principal, ok := PrincipalFromContext(r.Context()); ok && principal.Job != nil {
+			authJob = principal.Job
 		}
 	}
 
-	// Set timestamp if not provided
-	if result.Timestamp.IsZero() {
-		result.Timestamp = time.Now().UTC()
-	}
-
-	// Store the job result
-	if err := s.jobResultStore.CreateJobResult(&result); err != nil {
-		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to store job result: %v", err))
+	coalesced, err := s.recordJobResult(&result, authJob, true)
+	if err != nil {
+		s.recordRejection(err, &result, "", payload, s.clientAddr(r))
+		s.writeResultError(w, err)
 		return
 	}
 
-	// Update job's last reported timestamp
-	if err := s.jobStore.UpdateJobLastReported(result.JobName, result.Host, result.Timestamp); err != nil {
-		// Log error but don't fail the request
-		logrus.WithError(err).WithFields(logrus.Fields{
-			"job_name": result.JobName,
-			"host":     result.Host,
-		}).Warn("failed to update job last reported timestamp")
-	}
-
-	// Broadcast job status change to dashboard clients if dashboard is enabled
-	if s.dashboard != nil && s.dashboard.IsEnabled() {
-		if broadcaster := s.dashboard.GetBroadcaster(); broadcaster != nil {
-			// Get the updated job to broadcast current status
-			if job, err := s.jobStore.GetJob(result.JobName, result.Host); err == nil {
-				// Determine if job is in failure state
-				isFailure := result.Status == "failure"
-
-				// Also check automatic failure threshold
-				if !isFailure && job.AutomaticFailureThreshold > 0 {
-					timeSinceLastReport := time.Since(job.LastReportedAt)
-					if timeSinceLastReport > time.Duration(job.AutomaticFailureThreshold)*time.Second {
-						isFailure = true
-					}
-				}
-
-				broadcaster.BroadcastJobStatusChange(job, isFailure)
-			}
-		}
+	statusCode, status := http.StatusCreated, "recorded"
+	if coalesced {
+		statusCode, status = http.StatusAccepted, "coalesced"
 	}
-
-	s.writeJSONResponse(w, http.StatusCreated, map[string]string{
-		"status": "recorded",
+	s.writeJSONResponse(w, statusCode, map[string]string{
+		"status": status,
 		"job":    fmt.Sprintf("%s@%s", result.JobName, result.Host),
 	})
 }
@@ -578,7 +891,13 @@ func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	metrics, err := s.metrics.Gather()
+	selector, err := metrics.ParseJobSelector(r.URL.Query())
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	body, err := s.metrics.GatherSelected(r.Context(), selector)
 	if err != nil {
 		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to gather metrics: %v", err))
 		return
@@ -586,7 +905,44 @@ func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write([]byte(metrics)); err != nil {
+	if _, err := w.Write([]byte(body)); err != nil {
+		logrus.WithError(err).Error("Failed to write metrics response")
+	}
+}
+
+// handleJobMetrics serves the Prometheus metrics of a single job
+func (s *Server) handleJobMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, s.config.Metrics.Path+"/job/")
+	jobID, err := strconv.Atoi(idStr)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "invalid job ID")
+		return
+	}
+
+	job, err := s.jobStore.GetJobByID(jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.writeErrorResponse(w, http.StatusNotFound, "job not found")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get job: %v", err))
+		return
+	}
+
+	body, err := s.metrics.GatherJob(r.Context(), job)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(body)); err != nil {
 		logrus.WithError(err).Error("Failed to write metrics response")
 	}
 }
@@ -660,14 +1016,75 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Try-it-out requests go to this server, through the base path
+	if content, err = withServerURL(content, s.specServerURL(r)); err != nil {
+		logrus.WithError(err).Error("Failed to set the OpenAPI server URL")
+		s.writeErrorResponse(w, http.StatusInternalServerError, "invalid OpenAPI specification")
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/yaml")
 	w.Header().Set("Cache-Control", "public, max-age=3600") // Cache for 1 hour
+	w.Header().Set("Vary", "Host, X-Forwarded-Proto")       // The server URL is derived from them
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write(content); err != nil {
 		logrus.WithError(err).Error("Failed to write OpenAPI spec response")
 	}
 }
 
+// specServerURL returns the URL the served OpenAPI spec points clients
+// at: the configured external URL, or else the scheme and host the spec was
+// requested with, followed by the base path
+func (s *Server) specServerURL(r *http.Request) string {
+	base := strings.TrimRight(s.config.Server.ExternalURL, "/")
+	if base == "" && r.Host != "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		// Proxies terminating TLS tell the original scheme
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		base = scheme + "://" + r.Host
+	}
+
+	if base == "" && s.config.Server.BasePath == "" {
+		return "/" // Relative to the spec, e.g. over a Unix socket
+	}
+	return base + s.config.Server.BasePath
+}
+
+// withServerURL replaces the servers of an OpenAPI spec with url
+func withServerURL(spec []byte, url string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("OpenAPI spec is not a mapping")
+	}
+
+	servers := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: "url"},
+			{Kind: yaml.ScalarNode, Value: url},
+		},
+	}}}
+	root := doc.Content[0]
+	replaced := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "servers" {
+			root.Content[i+1], replaced = servers, true
+		}
+	}
+	if !replaced {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "servers"}, servers)
+	}
+	return yaml.Marshal(&doc)
+}
+
 // isValidAdminAPIKey checks if the provided token is a valid admin API key
 func (s *Server) isValidAdminAPIKey(token string) bool {
 	for _, key := range s.config.Security.AdminAPIKeys {
@@ -675,7 +1092,7 @@ func (s *Server) isValidAdminAPIKey(token string) bool {
 			return true
 		}
 	}
-	return false
+	return s.adminKeyStore.IsValidAdminKey(token)
 }
 
 // writeJSONResponse writes a JSON response
@@ -688,6 +1105,21 @@ func (s *Server) writeJSONResponse(w http.ResponseWriter, statusCode int, data i
 	}
 }
 
+// writeResultError writes a job result submission error
+func (s *Server) writeResultError(w http.ResponseWriter, err error) {
+	statusCode := http.StatusInternalServerError
+	var resultErr *ResultError
+	if errors.As(err, &resultErr) {
+		statusCode = resultErr.StatusCode
+	}
+	// Tell a rate limited job when to submit again
+	var limited *rateLimitedError
+	if errors.As(err, &limited) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.retryAfter.Seconds()))))
+	}
+	s.writeErrorResponse(w, statusCode, err.Error())
+}
+
 // writeErrorResponse writes an error response
 func (s *Server) writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
 	errorResponse := map[string]string{
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/util"
+)
+
+// maxSnoozeDuration bounds snoozes so a forgotten one cannot hide failures
+// indefinitely; maintenance is meant for longer absences
+const maxSnoozeDuration = 30 * 24 * time.Hour
+
+// SnoozeRequest is the body of POST /api/job/{id}/snooze
+type SnoozeRequest struct {
+	For string `json:"for"` // Duration, e.g. "2h", "90m" or "1d"
+}
+
+// handleSnoozeJob snoozes a job's failures (POST) or ends its snooze (DELETE).
+// Results are still recorded while a job is snoozed.
+func (s *Server) handleSnoozeJob(w http.ResponseWriter, r *http.Request, jobID int) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Only admin can snooze jobs
+	if !isAdminRequest(r) {
+		s.writeErrorResponse(w, http.StatusForbidden, "admin access required")
+		return
+	}
+
+	var until *time.Time
+	if r.Method == http.MethodPost {
+		var req SnoozeRequest
+		if err := s.decodeBody(r, &req); err != nil && err != io.EOF {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+			return
+		}
+
+		duration, err := util.ParseDuration(req.For)
+		if err != nil || duration <= 0 || duration > maxSnoozeDuration {
+			s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("for must be a duration between 1s and %s, e.g. \"2h\"", maxSnoozeDuration))
+			return
+		}
+		end := time.Now().UTC().Add(duration)
+		until = &end
+	}
+
+	job, err := s.jobStore.SnoozeJobByID(jobID, until)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.writeErrorResponse(w, http.StatusNotFound, "job not found")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to snooze job: %v", err))
+		return
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, job)
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+const formMediaType = "application/x-www-form-urlencoded"
+
+// decodeResult decodes a job result submission by its Content-Type. JSON
+// is always accepted, as are bodies sent without a Content-Type. With
+// server.form_results, results may also be sent as form fields or, with an
+// empty body, as query parameters, for clients such as busybox wget that
+// cannot easily build JSON. Other bodies are refused with 415.
+func (s *Server) decodeResult(r *http.Request, payload []byte, result *model.JobResult) *ResultError {
+	mediaType := ""
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			mediaType = contentType
+		}
+	}
+	empty := len(bytes.TrimSpace(payload)) == 0
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"),
+		mediaType == "" && (!empty || !s.config.Server.FormResults):
+		if err := s.decodeJSON(payload, result); err != nil {
+			return &ResultError{http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err), model.RejectionInvalidPayload}
+		}
+
+	case s.config.Server.FormResults && (mediaType == formMediaType || mediaType == "" && empty):
+		values := r.URL.Query()
+		form, err := url.ParseQuery(string(payload))
+		if err != nil {
+			return &ResultError{http.StatusBadRequest, fmt.Sprintf("invalid form: %v", err), model.RejectionInvalidPayload}
+		}
+		// Fields of the body take precedence over query parameters
+		for key, value := range form {
+			values[key] = value
+		}
+		if err := s.decodeResultValues(values, result); err != nil {
+			return &ResultError{http.StatusBadRequest, fmt.Sprintf("invalid form: %v", err), model.RejectionInvalidPayload}
+		}
+
+	default:
+		accepted := strings.ReplaceAll(s.acceptedResultTypes(), ", ", " or ")
+		return &ResultError{http.StatusUnsupportedMediaType, fmt.Sprintf("unsupported content type %q, send %s", mediaType, accepted), model.RejectionInvalidPayload}
+	}
+	return nil
+}
+
+// acceptedResultTypes lists the media types results may be submitted as
+func (s *Server) acceptedResultTypes() string {
+	if s.config.Server.FormResults {
+		return "application/json, " + formMediaType
+	}
+	return "application/json"
+}
+
+// decodeResultValues decodes a job result from form fields or query
+// parameters. They are named like the JSON fields, labels are given as
+// label.<name>=<value> and the timestamp may be Unix seconds, which
+// `date +%s` prints on any system.
+func (s *Server) decodeResultValues(values url.Values, result *model.JobResult) error {
+	var unknown []string
+	for key := range values {
+		value := values.Get(key)
+		switch key {
+		case "job_name":
+			result.JobName = value
+		case "host":
+			result.Host = value
+		case "status":
+			result.Status = value
+		case "output":
+			result.Output = value
+		case "nonce":
+			result.Nonce = value
+		case "run_id":
+			result.RunID = value
+		case "duration":
+			duration, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("duration must be a number of seconds, got %q", value)
+			}
+			result.Duration = duration
+		case "shard":
+			shard, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("shard must be a number, got %q", value)
+			}
+			result.Shard = &shard
+		case "shards":
+			shards, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("shards must be a number, got %q", value)
+			}
+			result.Shards = shards
+		case "exit_code":
+			exitCode, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("exit_code must be a number, got %q", value)
+			}
+			result.ExitCode = &exitCode
+		case "items_processed":
+			items, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("items_processed must be a number, got %q", value)
+			}
+			result.ItemsProcessed = &items
+		case "timestamp":
+			timestamp, err := parseResultTimestamp(value)
+			if err != nil {
+				return err
+			}
+			result.Timestamp = timestamp
+		default:
+			name, ok := strings.CutPrefix(key, "label.")
+			if !ok || name == "" {
+				unknown = append(unknown, fmt.Sprintf("%q", key))
+				continue
+			}
+			if result.Labels == nil {
+				result.Labels = make(map[string]string)
+			}
+			result.Labels[name] = value
+		}
+	}
+
+	if len(unknown) > 0 && s.config.Server.StrictJSON {
+		sort.Strings(unknown)
+		return &unknownFieldsError{fields: unknown}
+	}
+	return nil
+}
+
+// parseResultTimestamp parses an RFC 3339 timestamp or Unix seconds
+func parseResultTimestamp(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("timestamp must be RFC 3339 or Unix seconds, got %q", value)
+	}
+	return timestamp, nil
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/metrics"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultTestOutput is the output of test results sent without one
+const defaultTestOutput = "cronmetrics test submission"
+
+// Outcomes of the stages of a test submission
+const (
+	testStagePassed  = "passed"
+	testStageFailed  = "failed"
+	testStageSkipped = "skipped"
+)
+
+// TestSubmissionRequest is the optional body of POST /api/job/{id}/test
+type TestSubmissionRequest struct {
+	Status string `json:"status,omitempty"` // "success" (default) or "failure"
+	Output string `json:"output,omitempty"`
+	Keep   bool   `json:"keep,omitempty"` // Keep the result in the job's history
+
+	// Items the test run processed, by default the throughput floor of
+	// counter jobs
+	ItemsProcessed *int64 `json:"items_processed,omitempty"`
+}
+
+// TestStage is the outcome of one stage of a test submission
+type TestStage struct {
+	Name   string `json:"name"`   // "auth", "storage", "metrics", "notification" or "cleanup"
+	Status string `json:"status"` // "passed", "failed" or "skipped"
+	Detail string `json:"detail,omitempty"`
+}
+
+// TestSubmissionResponse reports how a test result went through the
+// pipeline. Passed is false when any stage failed.
+type TestSubmissionResponse struct {
+	Passed       bool                        `json:"passed"`
+	Kept         bool                        `json:"kept"`
+	Stages       []TestStage                 `json:"stages"`
+	Result       *model.JobResult            `json:"result,omitempty"`
+	Metrics      *metrics.JobCheck           `json:"metrics,omitempty"`
+	Notification *model.NotificationDelivery `json:"notification,omitempty"`
+}
+
+// stage records the outcome of a stage
+func (t *TestSubmissionResponse) stage(name, status, detail string) {
+	t.Stages = append(t.Stages, TestStage{Name: name, Status: status, Detail: detail})
+	if status == testStageFailed {
+		t.Passed = false
+	}
+}
+
+// handleTestJob sends a synthetic result flagged as a test through a job's
+// pipeline: authentication with the job's API key, status rules and
+// scripts, storage, metrics and a notification to the tickets test team.
+// Test results never update the job's last report, finish a running run or
+// open issues, and unless kept they are removed once checked.
+func (s *Server) handleTestJob(w http.ResponseWriter, r *http.Request, jobID int) {
+	if r.Method != http.MethodPost {
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	job, err := s.jobStore.GetJobByID(jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.writeErrorResponse(w, http.StatusNotFound, "job not found")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get job: %v", err))
+		return
+	}
+
+	var req TestSubmissionRequest
+	if err := s.decodeBody(r, &req); err != nil && err != io.EOF {
+		s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+	if req.Status == "" {
+		req.Status = "success"
+	}
+	if req.Status != "success" && req.Status != "failure" {
+		s.writeErrorResponse(w, http.StatusBadRequest, "status must be 'success' or 'failure'")
+		return
+	}
+	if req.Output == "" {
+		req.Output = defaultTestOutput
+	}
+	if req.ItemsProcessed == nil && job.Type == model.JobTypeCounter {
+		req.ItemsProcessed = &job.ThroughputFloor
+	}
+
+	response := &TestSubmissionResponse{Passed: true, Kept: req.Keep}
+
+	// The job's key must authenticate it, as its results will be
+	authJob, err := s.authenticateJobKey(job.ApiKey)
+	if err == nil && authJob.ID != job.ID {
+		err = fmt.Errorf("the job's API key authenticates %s@%s", authJob.Name, authJob.Host)
+	}
+	if err != nil {
+		response.stage("auth", testStageFailed, err.Error())
+		response.stage("storage", testStageSkipped, "authentication failed")
+	} else {
+		response.stage("auth", testStagePassed, "the job's API key authenticates it")
+
+		result := &model.JobResult{JobName: job.Name, Host: job.Host, Status: req.Status, Output: req.Output, ItemsProcessed: req.ItemsProcessed}
+		setResultSource(result, r)
+		result.Test = true
+		if _, err := s.recordJobResult(result, authJob, false); err != nil {
+			response.stage("storage", testStageFailed, err.Error())
+		} else {
+			response.Result = result
+			response.stage("storage", testStagePassed, fmt.Sprintf("recorded as result %d with status %s", result.ID, result.Status))
+		}
+	}
+
+	// Evaluate the job while its test result is its latest
+	if s.metrics == nil {
+		response.stage("metrics", testStageSkipped, "metrics are disabled")
+	} else if check, err := s.metrics.CheckJob(r.Context(), job); err != nil {
+		response.stage("metrics", testStageFailed, err.Error())
+	} else {
+		response.Metrics = check
+		response.stage("metrics", testStagePassed, fmt.Sprintf("cronjob_status %g (%s)", check.Value, check.Reason))
+	}
+
+	switch {
+	case s.deliverer == nil:
+		response.stage("notification", testStageSkipped, "tickets are disabled")
+	case s.config.Tickets.TestTeam == "":
+		response.stage("notification", testStageSkipped, "no tickets test team is configured")
+	default:
+		delivery, err := s.deliverer.DeliverTest(r.Context(), job, s.config.Tickets.TestTeam)
+		response.Notification = delivery
+		if err != nil {
+			response.stage("notification", testStageFailed, err.Error())
+		} else {
+			response.stage("notification", testStagePassed, fmt.Sprintf("opened %s in %s", delivery.Reference, delivery.Target))
+		}
+	}
+
+	if response.Result != nil && !req.Keep {
+		if _, err := s.jobResultStore.DeleteJobResults([]int{response.Result.ID}); err != nil {
+			response.stage("cleanup", testStageFailed, err.Error())
+		} else {
+			response.stage("cleanup", testStagePassed, fmt.Sprintf("removed result %d", response.Result.ID))
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"job_name": job.Name,
+		"host":     job.Host,
+		"passed":   response.Passed,
+		"kept":     req.Keep,
+	}).Info("job test submission")
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// handleJobVersions lists the versions of a job's configuration (GET
+// /api/job/{id}/versions), returns one (GET /api/job/{id}/versions/{v}) or
+// restores it (POST /api/job/{id}/versions/{v}/rollback)
+func (s *Server) handleJobVersions(w http.ResponseWriter, r *http.Request, jobID int, segments []string) {
+	rollback := len(segments) == 2 && segments[1] == "rollback"
+	switch {
+	case len(segments) == 2 && !rollback:
+		s.writeErrorResponse(w, http.StatusNotFound, "not found")
+		return
+	case rollback && r.Method != http.MethodPost, !rollback && r.Method != http.MethodGet:
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	version := 0
+	if len(segments) > 0 {
+		var err error
+		if version, err = strconv.Atoi(segments[0]); err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, "invalid version format (must be a number)")
+			return
+		}
+	}
+
+	if rollback {
+		s.handleRollbackJob(w, jobID, version)
+		return
+	}
+
+	versions, err := s.jobStore.ListJobVersions(jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.writeErrorResponse(w, http.StatusNotFound, "job not found")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list job versions: %v", err))
+		return
+	}
+	if len(segments) == 0 {
+		s.writeJSONResponse(w, http.StatusOK, versions)
+		return
+	}
+	for _, v := range versions {
+		if v.Version == version {
+			s.writeJSONResponse(w, http.StatusOK, v)
+			return
+		}
+	}
+	s.writeErrorResponse(w, http.StatusNotFound, model.ErrJobVersionNotFound.Error())
+}
+
+// handleRollbackJob restores the configuration of one of a job's versions
+// and returns the job
+func (s *Server) handleRollbackJob(w http.ResponseWriter, jobID, version int) {
+	job, err := s.jobStore.RollbackJob(jobID, version, s.config.Defaults.FailureThreshold)
+	switch {
+	case errors.Is(err, model.ErrJobVersionNotFound):
+		s.writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	case model.IsUniqueViolation(err):
+		s.writeErrorResponse(w, http.StatusConflict, "another job has the name and host of this version")
+		return
+	case err != nil && strings.Contains(err.Error(), "not found"):
+		s.writeErrorResponse(w, http.StatusNotFound, "job not found")
+		return
+	case err != nil:
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to roll back job: %v", err))
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"job_id":   job.ID,
+		"job_name": job.Name,
+		"host":     job.Host,
+		"version":  version,
+	}).Info("job rolled back")
+
+	s.writeJSONResponse(w, http.StatusOK, job)
+}
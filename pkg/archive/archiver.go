@@ -0,0 +1,255 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// resultsPrefix is the key prefix of archived job results
+	resultsPrefix = "job_results/"
+	// partitionLayout names the daily partitions: job_results/dt=2025-10-30/
+	partitionLayout = "2006-01-02"
+	// batchSize is the number of results read from the database at a time
+	batchSize = 5000
+)
+
+// Stats summarizes an archival run
+type Stats struct {
+	Results int64 // Results exported and deleted
+	Objects int   // Objects written
+}
+
+// Query selects archived results. Zero fields match everything.
+type Query struct {
+	JobName string
+	Host    string
+	Since   time.Time
+	Until   time.Time
+}
+
+// matches reports whether the result is selected by the query
+func (q *Query) matches(result *model.JobResult) bool {
+	return (q.JobName == "" || result.JobName == q.JobName) &&
+		(q.Host == "" || result.Host == q.Host) &&
+		(q.Since.IsZero() || !result.Timestamp.Before(q.Since)) &&
+		(q.Until.IsZero() || result.Timestamp.Before(q.Until))
+}
+
+// Archiver exports aged job results to a store before deleting them, so
+// history beyond the retention period is kept outside the database. Results
+// are written as gzip-compressed JSON lines, one object per day and batch.
+type Archiver struct {
+	results *model.JobResultStore
+	store   Store
+}
+
+// NewArchiver creates an archiver writing to store
+func NewArchiver(results *model.JobResultStore, store Store) *Archiver {
+	return &Archiver{results: results, store: store}
+}
+
+// Archive exports and deletes every result recorded before the given time.
+// Results are only deleted once their object is written, and object keys
+// depend only on the rows they contain, so an interrupted run can simply be
+// repeated.
+func (a *Archiver) Archive(ctx context.Context, before time.Time) (*Stats, error) {
+	stats := &Stats{}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		results, err := a.results.ListJobResultsBefore(before, batchSize)
+		if err != nil {
+			return stats, err
+		}
+		if len(results) == 0 {
+			return stats, nil
+		}
+
+		// Split the batch into daily partitions
+		days := make(map[string][]*model.JobResult)
+		var order []string
+		for _, result := range results {
+			day := result.Timestamp.UTC().Format(partitionLayout)
+			if _, ok := days[day]; !ok {
+				order = append(order, day)
+			}
+			days[day] = append(days[day], result)
+		}
+
+		for _, day := range order {
+			partition := days[day]
+			data, err := encodeResults(partition)
+			if err != nil {
+				return stats, err
+			}
+
+			key := fmt.Sprintf("%sdt=%s/results-%d-%d.jsonl.gz", resultsPrefix, day, partition[0].ID, partition[len(partition)-1].ID)
+			if err := a.store.Put(ctx, key, data); err != nil {
+				return stats, err
+			}
+
+			ids := make([]int, len(partition))
+			for i, result := range partition {
+				ids[i] = result.ID
+			}
+			deleted, err := a.results.DeleteJobResults(ids)
+			if err != nil {
+				return stats, err
+			}
+
+			stats.Objects++
+			stats.Results += deleted
+			logrus.WithFields(logrus.Fields{
+				"key":     key,
+				"results": len(partition),
+			}).Info("job results archived")
+		}
+	}
+}
+
+// Run archives results older than retention every interval until ctx is
+// cancelled. The first run starts immediately.
+func (a *Archiver) Run(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		stats, err := a.Archive(ctx, time.Now().Add(-retention))
+		if err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Error("job result archival failed")
+		} else if stats.Results > 0 {
+			logrus.WithFields(logrus.Fields{
+				"results": stats.Results,
+				"objects": stats.Objects,
+			}).Info("job result archival completed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Objects lists the archived result objects, oldest partition first
+func (a *Archiver) Objects(ctx context.Context) ([]string, error) {
+	return a.store.List(ctx, resultsPrefix)
+}
+
+// Query returns the archived results selected by q, reading only the daily
+// partitions that can contain them
+func (a *Archiver) Query(ctx context.Context, q Query) ([]*model.JobResult, error) {
+	keys, err := a.Objects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []*model.JobResult
+	for _, key := range keys {
+		if !q.coversPartition(key) {
+			continue
+		}
+
+		data, err := a.store.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		results, err := decodeResults(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+
+		for _, result := range results {
+			if q.matches(result) {
+				selected = append(selected, result)
+			}
+		}
+	}
+
+	return selected, nil
+}
+
+// Restore copies the archived results selected by q back into the database
+// and returns the number of results inserted
+func (a *Archiver) Restore(ctx context.Context, q Query) (int64, error) {
+	results, err := a.Query(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	return a.results.RestoreJobResults(results)
+}
+
+// coversPartition reports whether the daily partition of key may hold
+// results selected by the query. Keys without a partition are always read.
+func (q *Query) coversPartition(key string) bool {
+	rest, ok := strings.CutPrefix(key, resultsPrefix+"dt=")
+	if !ok || len(rest) < len(partitionLayout) {
+		return true
+	}
+	day, err := time.Parse(partitionLayout, rest[:len(partitionLayout)])
+	if err != nil {
+		return true
+	}
+
+	if !q.Since.IsZero() && !day.Add(24*time.Hour).After(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !day.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// encodeResults serializes results as gzip-compressed JSON lines
+func encodeResults(results []*model.JobResult) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(gz)
+	for _, result := range results {
+		if err := encoder.Encode(result); err != nil {
+			return nil, fmt.Errorf("failed to encode job result %d: %w", result.ID, err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress job results: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeResults reads gzip-compressed JSON lines
+func decodeResults(data []byte) ([]*model.JobResult, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var results []*model.JobResult
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		result := &model.JobResult{}
+		if err := json.Unmarshal(scanner.Bytes(), result); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, scanner.Err()
+}
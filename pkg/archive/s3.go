@@ -0,0 +1,234 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/sigv4"
+)
+
+// gcsEndpoint is the S3-compatible (XML API) endpoint of Google Cloud Storage
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// s3Store keeps archived objects in an S3-compatible bucket: AWS S3, Google
+// Cloud Storage through its interoperability API, or MinIO and similar.
+// Requests are signed with AWS Signature Version 4.
+type s3Store struct {
+	client  *http.Client
+	baseURL *url.URL // Bucket URL; object keys are appended to its path
+	prefix  string
+	signer  *sigv4.Signer
+}
+
+// newS3Store creates the store for bucket, addressing it path-style on a
+// custom endpoint and GCS, and virtual-hosted style on AWS
+func newS3Store(cfg *config.ArchiveConfig, scheme, bucket, prefix string) (*s3Store, error) {
+	signer := &sigv4.Signer{
+		AccessKey: cfg.AccessKeyID,
+		SecretKey: cfg.SecretAccessKey,
+		Region:    cfg.Region,
+		Service:   "s3",
+	}
+	if signer.AccessKey == "" {
+		signer.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if signer.SecretKey == "" {
+		signer.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if signer.AccessKey == "" || signer.SecretKey == "" {
+		return nil, fmt.Errorf("archive credentials are required for %s:// destinations", scheme)
+	}
+	if signer.Region == "" {
+		signer.Region = "us-east-1"
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" && scheme == "gs" {
+		endpoint = gcsEndpoint
+		signer.Region = "auto"
+	}
+
+	store := &s3Store{
+		client: &http.Client{Timeout: 5 * time.Minute},
+		prefix: prefix,
+		signer: signer,
+	}
+
+	var rawURL string
+	if endpoint != "" {
+		rawURL = strings.TrimRight(endpoint, "/") + "/" + bucket
+	} else {
+		rawURL = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, signer.Region)
+	}
+
+	baseURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive endpoint: %w", err)
+	}
+	store.baseURL = baseURL
+
+	return store, nil
+}
+
+// objectKey returns the full object key for a store key
+func (s *s3Store) objectKey(key string) string {
+	if s.prefix == "" {
+		return key
+	}
+	return s.prefix + "/" + key
+}
+
+// Put uploads an object
+func (s *s3Store) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, s.objectKey(key), nil, data)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads an object
+func (s *s3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.objectKey(key), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// listBucketResult is the response of ListObjectsV2
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List pages through ListObjectsV2 for keys under prefix
+func (s *s3Store) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	token := ""
+
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.objectKey(prefix)}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list archive: %w", err)
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode archive listing: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			key := object.Key
+			if s.prefix != "" {
+				key = strings.TrimPrefix(key, s.prefix+"/")
+			}
+			keys = append(keys, key)
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// do sends a signed request for an object key, or for the bucket when key
+// is empty. Non-2xx responses are returned as errors.
+func (s *s3Store) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	u := *s.baseURL
+	if key != "" {
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + key
+		u.RawPath = uriEncode(u.Path, false)
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.signer.Sign(req, body, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound && key != "" {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s: %s", method, u.Path, resp.Status, strings.TrimSpace(string(detail)))
+	}
+
+	return resp, nil
+}
+
+// canonicalQuery encodes query parameters sorted by key, as SigV4 requires
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but RFC 3986 unreserved characters,
+// and slashes unless encodeSlash is set
+func uriEncode(s string, encodeSlash bool) string {
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			builder.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			builder.WriteByte(c)
+		default:
+			fmt.Fprintf(&builder, "%%%02X", c)
+		}
+	}
+	return builder.String()
+}
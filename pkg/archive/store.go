@@ -0,0 +1,113 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+)
+
+// ErrNotFound is returned when an archived object does not exist
+var ErrNotFound = errors.New("archive object not found")
+
+// Store is where archived objects are kept. Keys are slash-separated paths
+// relative to the configured destination.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	List(ctx context.Context, prefix string) ([]string, error) // Sorted keys starting with prefix
+}
+
+// NewStore returns the store for the configured destination:
+// file:///path, s3://bucket/prefix or gs://bucket/prefix
+func NewStore(cfg *config.ArchiveConfig) (Store, error) {
+	dest, err := url.Parse(cfg.Destination)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive destination: %w", err)
+	}
+
+	switch dest.Scheme {
+	case "file":
+		if dest.Path == "" {
+			return nil, fmt.Errorf("archive destination %q has no path", cfg.Destination)
+		}
+		return &fileStore{root: filepath.FromSlash(dest.Path)}, nil
+	case "s3", "gs":
+		if dest.Host == "" {
+			return nil, fmt.Errorf("archive destination %q has no bucket", cfg.Destination)
+		}
+		return newS3Store(cfg, dest.Scheme, dest.Host, strings.Trim(dest.Path, "/"))
+	default:
+		return nil, fmt.Errorf("unsupported archive destination %q (use file://, s3:// or gs://)", cfg.Destination)
+	}
+}
+
+// fileStore keeps archived objects in a local directory, e.g. a mounted
+// network share or bucket
+type fileStore struct {
+	root string
+}
+
+// Put writes the object atomically
+func (s *fileStore) Put(ctx context.Context, key string, data []byte) error {
+	path := filepath.Join(s.root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0640); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get reads an object
+func (s *fileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.root, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// List walks the directory for objects under prefix
+func (s *fileStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == s.root {
+			return filepath.SkipDir
+		}
+		if err != nil || d.IsDir() || strings.HasSuffix(path, ".tmp") {
+			return err
+		}
+
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archive: %w", err)
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
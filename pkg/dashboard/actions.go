@@ -0,0 +1,127 @@
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaepetto/cron-exporter/pkg/actionlink"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// actionLinkActor identifies action link uses in the audit log
+const actionLinkActor = "action-link"
+
+// actionLinkJob verifies the signed action link of the request and returns
+// the link and the job it targets. On failure it renders an error page and
+// returns false.
+func (h *Handler) actionLinkJob(c *gin.Context) (*actionlink.Link, *model.Job, bool) {
+	if err := c.Request.ParseForm(); err != nil {
+		h.renderActionLink(c, http.StatusBadRequest, gin.H{"Error": "Malformed action link"})
+		return nil, nil, false
+	}
+
+	link, err := actionlink.Parse(h.settings.Security.ActionLinkSecret, c.Param("action"), c.Request.Form)
+	if err != nil {
+		status := http.StatusForbidden
+		message := "This action link is invalid."
+		if errors.Is(err, actionlink.ErrExpired) {
+			status = http.StatusGone
+			message = "This action link has expired."
+		}
+		h.logger.WithError(err).WithField("action", c.Param("action")).Warn("Rejected action link")
+		h.renderActionLink(c, status, gin.H{"Error": message})
+		return nil, nil, false
+	}
+
+	job, err := h.jobStore.GetJobByID(link.JobID)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", link.JobID).Warn("Failed to get job for action link")
+		h.renderActionLink(c, http.StatusNotFound, gin.H{"Error": "The job this link refers to no longer exists."})
+		return nil, nil, false
+	}
+
+	return link, job, true
+}
+
+// renderActionLink renders the action link page
+func (h *Handler) renderActionLink(c *gin.Context, status int, data gin.H) {
+	data["Title"] = h.config.Title
+	data["Config"] = h.config
+	c.HTML(status, "action_link.html", data)
+}
+
+// ActionLinkConfirm shows what a signed action link will do. The action is
+// only performed on POST, so link previews and mail scanners fetching the
+// URL do not trigger it.
+func (h *Handler) ActionLinkConfirm(c *gin.Context) {
+	link, job, ok := h.actionLinkJob(c)
+	if !ok {
+		return
+	}
+
+	h.renderActionLink(c, http.StatusOK, gin.H{
+		"Link":  link,
+		"Job":   job,
+		"Query": link.Query(h.settings.Security.ActionLinkSecret),
+	})
+}
+
+// ActionLinkExecute performs the action of a signed action link
+func (h *Handler) ActionLinkExecute(c *gin.Context) {
+	link, job, ok := h.actionLinkJob(c)
+	if !ok {
+		return
+	}
+
+	// Acknowledging a failure only records who is looking into it
+	if link.Action == actionlink.ActionAcknowledge {
+		ack := &model.Acknowledgement{JobName: job.Name, Host: job.Host, Author: actionLinkActor}
+		if err := h.ackStore.CreateAcknowledgement(ack); err != nil {
+			h.logger.WithError(err).WithField("job_id", job.ID).Error("Failed to record acknowledgement")
+			h.renderActionLink(c, http.StatusInternalServerError, gin.H{"Error": "Failed to record the acknowledgement, please try again."})
+			return
+		}
+	} else {
+		if link.Action == actionlink.ActionMaintenance {
+			job.Status = "maintenance"
+		} else {
+			job.Status = "active"
+		}
+
+		if err := h.jobStore.UpdateJob(job); err != nil {
+			h.logger.WithError(err).WithField("job_id", job.ID).Error("Failed to apply action link")
+			h.renderActionLink(c, http.StatusInternalServerError, gin.H{"Error": "Failed to update the job, please try again."})
+			return
+		}
+
+		h.broadcaster.BroadcastJobStatusChange(job, false)
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"job_id":    job.ID,
+		"job_name":  job.Name,
+		"host":      job.Host,
+		"action":    link.Action,
+		"client_ip": h.ips.Hash(c.ClientIP()),
+	}).Info("Action link used")
+
+	entry := &model.AuditEntry{
+		Actor:   actionLinkActor,
+		Action:  "action_link." + link.Action,
+		Target:  "job:" + strconv.Itoa(job.ID),
+		Details: fmt.Sprintf("%s@%s from %s", job.Name, job.Host, h.ips.Hash(c.ClientIP())),
+	}
+	if err := h.auditStore.RecordAudit(entry); err != nil {
+		h.logger.WithError(err).WithField("action", entry.Action).Warn("Failed to record audit entry")
+	}
+
+	h.renderActionLink(c, http.StatusOK, gin.H{
+		"Link": link,
+		"Job":  job,
+		"Done": true,
+	})
+}
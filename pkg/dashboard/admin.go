@@ -0,0 +1,321 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// auditLogPageSize is the number of entries shown on the audit log page
+const auditLogPageSize = 200
+
+// rejectionsPageSize is the number of rejections shown on the rejections page
+const rejectionsPageSize = 200
+
+// notificationsPageSize is the number of deliveries shown on the
+// notifications page
+const notificationsPageSize = 200
+
+// isValidAdminKey accepts admin keys from the config file and active keys managed in the database
+func (h *Handler) isValidAdminKey(key string) bool {
+	for _, adminKey := range h.settings.Security.AdminAPIKeys {
+		if key == adminKey {
+			return true
+		}
+	}
+	return h.adminKeyStore.IsValidAdminKey(key)
+}
+
+// recordAudit appends an entry to the audit log. Failures are logged but do
+// not fail the request that triggered them.
+func (h *Handler) recordAudit(c *gin.Context, action, target, details string) {
+	actor := c.GetString("auth_user")
+	if actor == "" {
+		actor = "dashboard"
+	}
+
+	entry := &model.AuditEntry{
+		Actor:   actor,
+		Action:  action,
+		Target:  target,
+		Details: details,
+	}
+	if err := h.auditStore.RecordAudit(entry); err != nil {
+		h.logger.WithError(err).WithField("action", action).Warn("Failed to record audit entry")
+	}
+}
+
+// adminKeysData gathers the data shown on the key management page
+func (h *Handler) adminKeysData() (gin.H, error) {
+	jobs, err := h.jobStore.ListJobs(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	adminKeys, err := h.adminKeyStore.ListAdminKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"Title":          h.config.Title,
+		"Config":         h.config,
+		"Jobs":           jobs,
+		"AdminKeys":      adminKeys,
+		"ConfigKeyCount": len(h.settings.Security.AdminAPIKeys),
+	}, nil
+}
+
+// renderAdminKeys renders the key management page, merging extra into the page data
+func (h *Handler) renderAdminKeys(c *gin.Context, status int, extra gin.H) {
+	data, err := h.adminKeysData()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to load API keys")
+		c.String(http.StatusInternalServerError, "Failed to load API keys")
+		return
+	}
+
+	for key, value := range extra {
+		data[key] = value
+	}
+
+	h.renderPage(c, status, "admin_keys.html", data)
+}
+
+// AdminKeys displays job API keys and admin keys
+func (h *Handler) AdminKeys(c *gin.Context) {
+	h.renderAdminKeys(c, http.StatusOK, nil)
+}
+
+// AdminKeyCreate generates a new admin key and shows it once
+func (h *Handler) AdminKeyCreate(c *gin.Context) {
+	name := c.PostForm("name")
+	if name == "" {
+		c.String(http.StatusBadRequest, "Key name is required")
+		return
+	}
+
+	adminKey, key, err := h.adminKeyStore.CreateAdminKey(name, c.GetString("auth_user"))
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to create admin key")
+		c.String(http.StatusInternalServerError, "Failed to create admin key")
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"admin_key_id": adminKey.ID,
+		"name":         adminKey.Name,
+	}).Info("Admin key created via dashboard")
+
+	h.recordAudit(c, "admin_key.create", "admin_key:"+strconv.Itoa(adminKey.ID), adminKey.Name)
+
+	h.renderAdminKeys(c, http.StatusCreated, gin.H{
+		"NewKey":      key,
+		"NewKeyLabel": "admin key " + adminKey.Name,
+	})
+}
+
+// AdminKeyRevoke revokes a database-managed admin key
+func (h *Handler) AdminKeyRevoke(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid key ID")
+		return
+	}
+
+	if err := h.adminKeyStore.RevokeAdminKey(id); err != nil {
+		h.logger.WithError(err).WithField("admin_key_id", id).Error("Failed to revoke admin key")
+		c.String(http.StatusNotFound, "Admin key not found")
+		return
+	}
+
+	h.logger.WithField("admin_key_id", id).Info("Admin key revoked via dashboard")
+
+	h.recordAudit(c, "admin_key.revoke", "admin_key:"+idStr, "")
+
+	h.setFlash(c, flashSuccess, "Admin key revoked")
+	c.Redirect(http.StatusFound, h.config.Path+"/admin/keys")
+}
+
+// JobRotateKey replaces a job's API key and shows the new key once
+func (h *Handler) JobRotateKey(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.jobStore.GetJobByID(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to get job for key rotation")
+		c.String(http.StatusNotFound, "Job not found")
+		return
+	}
+
+	apiKey, err := util.GenerateAPIKey()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to generate API key")
+		c.String(http.StatusInternalServerError, "Failed to generate API key")
+		return
+	}
+	job.ApiKey = apiKey
+
+	if err := h.jobStore.UpdateJobByID(job); err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to rotate job API key")
+		c.String(http.StatusInternalServerError, "Failed to rotate API key")
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"job_id":   job.ID,
+		"job_name": job.Name,
+		"host":     job.Host,
+	}).Info("Job API key rotated via dashboard")
+
+	h.recordAudit(c, "job.rotate_key", "job:"+idStr, job.Name+"@"+job.Host)
+
+	h.renderAdminKeys(c, http.StatusOK, gin.H{
+		"NewKey":      apiKey,
+		"NewKeyLabel": "job " + job.Name + "@" + job.Host,
+	})
+}
+
+// AdminSettings displays the effective server settings
+func (h *Handler) AdminSettings(c *gin.Context) {
+	data := gin.H{
+		"Title":    h.config.Title,
+		"Config":   h.config,
+		"Settings": h.settings,
+	}
+
+	h.renderPage(c, http.StatusOK, "admin_settings.html", data)
+}
+
+// AdminAudit displays the most recent audit log entries
+func (h *Handler) AdminAudit(c *gin.Context) {
+	entries, err := h.auditStore.ListAuditEntries(auditLogPageSize)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list audit entries")
+		c.String(http.StatusInternalServerError, "Failed to load audit log")
+		return
+	}
+
+	data := gin.H{
+		"Title":   h.config.Title,
+		"Config":  h.config,
+		"Entries": entries,
+	}
+
+	h.renderPage(c, http.StatusOK, "admin_audit.html", data)
+}
+
+// AdminRejections displays the most recent rejected job result submissions
+func (h *Handler) AdminRejections(c *gin.Context) {
+	page, err := h.rejectionStore.ListRejectionsPage("", rejectionsPageSize)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list rejections")
+		c.String(http.StatusInternalServerError, "Failed to load rejections")
+		return
+	}
+
+	data := gin.H{
+		"Title":      h.config.Title,
+		"Config":     h.config,
+		"Rejections": page.Rejections,
+	}
+
+	h.renderPage(c, http.StatusOK, "admin_rejections.html", data)
+}
+
+// AdminNotifications displays the most recent notification deliveries
+func (h *Handler) AdminNotifications(c *gin.Context) {
+	page, err := h.notificationStore.ListDeliveriesPage(model.DeliveryFilter{Status: c.Query("status")}, "", notificationsPageSize)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list notification deliveries")
+		c.String(http.StatusInternalServerError, "Failed to load notification deliveries")
+		return
+	}
+
+	data := gin.H{
+		"Title":        h.config.Title,
+		"Config":       h.config,
+		"Deliveries":   page.Deliveries,
+		"Status":       c.Query("status"),
+		"CanRedeliver": h.deliverer != nil || h.notifierDeliverer != nil,
+	}
+
+	h.renderPage(c, http.StatusOK, "admin_notifications.html", data)
+}
+
+// AdminNotificationRedeliver sends a recorded notification again
+func (h *Handler) AdminNotificationRedeliver(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid notification delivery ID")
+		return
+	}
+
+	delivery, err := h.redeliver(c.Request.Context(), id)
+	if errors.Is(err, model.ErrDeliveryNotFound) {
+		c.String(http.StatusNotFound, "Notification delivery not found")
+		return
+	}
+	if errors.Is(err, errRedeliveryDisabled) {
+		c.String(http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if delivery == nil {
+		h.logger.WithError(err).WithField("delivery_id", id).Error("Failed to redeliver notification")
+		h.setFlash(c, flashError, fmt.Sprintf("Failed to redeliver notification: %v", err))
+		c.Redirect(http.StatusFound, h.config.Path+"/admin/notifications")
+		return
+	}
+
+	h.recordAudit(c, "notification.redeliver", "notification:"+idStr, fmt.Sprintf("%s (delivery %d)", delivery.Status, delivery.ID))
+
+	switch {
+	case delivery.Status == model.DeliveryFailed:
+		h.setFlash(c, flashError, fmt.Sprintf("Redelivery failed: %s", delivery.Error))
+	case delivery.Reference != "":
+		h.setFlash(c, flashSuccess, fmt.Sprintf("Notification redelivered as %s", delivery.Reference))
+	default:
+		h.setFlash(c, flashSuccess, "Notification redelivered")
+	}
+	c.Redirect(http.StatusFound, h.config.Path+"/admin/notifications")
+}
+
+// errRedeliveryDisabled is returned when what sent a notification is
+// disabled
+var errRedeliveryDisabled = errors.New("redelivery disabled")
+
+// redeliver sends a recorded notification again through what sent it: the
+// notifier for its channels, else the ticket trackers
+func (h *Handler) redeliver(ctx context.Context, id int) (*model.NotificationDelivery, error) {
+	previous, err := h.notificationStore.WithContext(ctx).GetDelivery(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(config.NotifierChannelTypes, previous.Channel) {
+		if h.notifierDeliverer == nil {
+			return nil, fmt.Errorf("%w: the notifier is disabled", errRedeliveryDisabled)
+		}
+		return h.notifierDeliverer.Redeliver(ctx, id)
+	}
+	if h.deliverer == nil {
+		return nil, fmt.Errorf("%w: ticket creation is disabled", errRedeliveryDisabled)
+	}
+	return h.deliverer.Redeliver(ctx, id)
+}
@@ -0,0 +1,149 @@
+package dashboard
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// recentResultsLimit is the number of results shown on the job detail page
+const recentResultsLimit = 10
+
+// jobHistory returns the job's most recent results, with their annotations,
+// and all of the job's annotations
+func (h *Handler) jobHistory(job *model.Job) ([]*model.JobResult, []*model.ResultAnnotation, error) {
+	page, err := h.resultStore.ListJobResultsPage(job.Name, job.Host, "", recentResultsLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	annotations, err := h.annotationStore.ListAnnotations(job.Name, job.Host)
+	if err != nil {
+		return nil, nil, err
+	}
+	model.AttachAnnotations(page.Results, annotations)
+
+	return page.Results, annotations, nil
+}
+
+// JobAnnotate adds an annotation to a job, or to one of its results
+func (h *Handler) JobAnnotate(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.jobStore.GetJobByID(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to get job for annotation")
+		c.String(http.StatusNotFound, "Job not found")
+		return
+	}
+
+	annotation := &model.ResultAnnotation{
+		JobName: job.Name,
+		Host:    job.Host,
+		Comment: strings.TrimSpace(c.PostForm("comment")),
+		Author:  c.GetString("auth_user"),
+	}
+	if annotation.Author == "" {
+		annotation.Author = "dashboard"
+	}
+	if resultID := strings.TrimSpace(c.PostForm("result_id")); resultID != "" {
+		annotation.ResultID, err = strconv.Atoi(resultID)
+		if err != nil {
+			h.setFlash(c, flashError, "Result ID must be a number")
+			c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
+			return
+		}
+	}
+
+	if annotation.Comment == "" || len(annotation.Comment) > model.MaxAnnotationLength {
+		h.setFlash(c, flashError, "Comment is required and at most "+strconv.Itoa(model.MaxAnnotationLength)+" characters")
+		c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
+		return
+	}
+
+	if err := h.annotationStore.CreateAnnotation(annotation); err != nil {
+		if errors.Is(err, model.ErrResultNotFound) {
+			h.setFlash(c, flashError, "Result "+strconv.Itoa(annotation.ResultID)+" is not a result of this job")
+		} else {
+			h.logger.WithError(err).WithField("job_id", id).Error("Failed to annotate job")
+			h.setFlash(c, flashError, "Failed to save the annotation")
+		}
+		c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"job_id":    job.ID,
+		"job_name":  job.Name,
+		"host":      job.Host,
+		"result_id": annotation.ResultID,
+	}).Info("Job annotated via dashboard")
+
+	h.recordAudit(c, "job.annotate", "job:"+idStr, annotation.Comment)
+
+	h.setFlash(c, flashSuccess, "Annotation added")
+	c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
+}
+
+// JobAcknowledge records that the user is handling a job's failures,
+// optionally with the ticket tracking the work
+func (h *Handler) JobAcknowledge(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.jobStore.GetJobByID(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to get job for acknowledgement")
+		c.String(http.StatusNotFound, "Job not found")
+		return
+	}
+
+	ack := &model.Acknowledgement{
+		JobName:   job.Name,
+		Host:      job.Host,
+		Author:    c.GetString("auth_user"),
+		TicketID:  strings.TrimSpace(c.PostForm("ticket_id")),
+		TicketURL: strings.TrimSpace(c.PostForm("ticket_url")),
+	}
+	if ack.Author == "" {
+		ack.Author = "dashboard"
+	}
+	if err := ack.Validate(); err != nil {
+		h.setFlash(c, flashError, err.Error())
+		c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
+		return
+	}
+
+	if err := h.ackStore.CreateAcknowledgement(ack); err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to acknowledge job")
+		h.setFlash(c, flashError, "Failed to save the acknowledgement")
+		c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"job_id":    job.ID,
+		"job_name":  job.Name,
+		"host":      job.Host,
+		"ticket_id": ack.TicketID,
+	}).Info("Job acknowledged via dashboard")
+
+	h.recordAudit(c, "job.acknowledge", "job:"+idStr, ack.TicketID)
+
+	h.setFlash(c, flashSuccess, "Job acknowledged")
+	c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
+}
@@ -88,17 +88,20 @@ function updateJobRow(row, job) {
 
 // Create a new job row
 function createJobRow(job) {
+    const dashboardPath = document.getElementById('dashboard-path')?.value || '/dashboard';
+    const readOnly = document.getElementById('read-only')?.value === 'true';
     const row = document.createElement('tr');
     row.setAttribute('data-job-id', job.id);
 
     row.innerHTML = `
+        ${readOnly ? '' : `<td data-label="Select"><input type="checkbox" class="job-select" name="job_id" value="${job.id}" form="bulk-label-form"></td>`}
         <td><strong>${escapeHtml(job.name)}</strong></td>
-        <td>${escapeHtml(job.host)}</td>
-        <td class="job-status">${getStatusBadge(job.status)}</td>
-        <td class="job-last-reported">${formatTimeAgo(job.last_reported_at)}</td>
-        <td>
-            <a href="/dashboard/jobs/${job.id}" class="btn btn-sm btn-primary">View</a>
-            <a href="/dashboard/jobs/${job.id}/edit" class="btn btn-sm btn-secondary">Edit</a>
+        <td data-label="Host">${escapeHtml(job.host)}</td>
+        <td class="job-status" data-label="Status">${getStatusBadge(job.status)}</td>
+        <td class="job-last-reported" data-label="Last Reported">${formatTimeAgo(job.last_reported_at)}</td>
+        <td class="card-actions">
+            <a href="${dashboardPath}/jobs/${job.id}" class="btn btn-sm btn-primary">View</a>
+            ${readOnly ? '' : `<a href="${dashboardPath}/jobs/${job.id}/edit" class="btn btn-sm btn-secondary">Edit</a>`}
         </td>
     `;
 
@@ -164,6 +167,351 @@ function validateJobForm() {
     return true;
 }
 
+// Populate typeahead options for the search form's host and label pickers
+function initFilterPickers() {
+    const hostOptions = document.getElementById('host-options');
+    const labelFilters = document.getElementById('label-filters');
+    if (!hostOptions || !labelFilters) return;
+
+    const dashboardPath = document.getElementById('dashboard-path')?.value || '/dashboard';
+
+    fetch(`${dashboardPath}/api/hosts`)
+        .then(response => response.json())
+        .then(data => {
+            fillOptions(hostOptions, (data.hosts || []).map(host => host.host));
+        })
+        .catch(error => {
+            console.error('Error loading hosts:', error);
+        });
+
+    fetch(`${dashboardPath}/api/labels`)
+        .then(response => response.json())
+        .then(data => {
+            const labels = data.labels || [];
+            fillOptions(document.getElementById('label-key-options'), labels.map(label => label.key));
+
+            // Offer the values of the key in the filter row or label form
+            // being edited
+            document.addEventListener('focusin', event => {
+                if (!event.target.classList.contains('label-value-input')) return;
+
+                const row = event.target.closest('.label-filter-row, form');
+                const key = row.querySelector('.label-key-input').value;
+                const label = labels.find(label => label.key === key);
+                fillOptions(document.getElementById('label-value-options'),
+                    label ? label.values.map(value => value.value) : []);
+            });
+        })
+        .catch(error => {
+            console.error('Error loading labels:', error);
+        });
+}
+
+// Add and remove label filter rows in the search form
+function initLabelFilterRows() {
+    const labelFilters = document.getElementById('label-filters');
+    const addButton = document.getElementById('label-filter-add');
+    if (!labelFilters || !addButton) return;
+
+    addButton.addEventListener('click', function() {
+        const rows = labelFilters.querySelectorAll('.label-filter-row');
+        const row = rows[rows.length - 1].cloneNode(true);
+        row.querySelectorAll('input').forEach(input => { input.value = ''; });
+        addButton.before(row);
+        row.querySelector('.label-key-input').focus();
+    });
+
+    labelFilters.addEventListener('click', function(event) {
+        if (!event.target.classList.contains('label-filter-remove')) return;
+
+        const row = event.target.closest('.label-filter-row');
+        if (labelFilters.querySelectorAll('.label-filter-row').length > 1) {
+            row.remove();
+        } else {
+            row.querySelectorAll('input').forEach(input => { input.value = ''; });
+        }
+        htmx.trigger(labelFilters.closest('form'), 'submit');
+    });
+}
+
+// Sort the job list from the column headers without losing the current filters
+function initSortableHeaders() {
+    const form = document.getElementById('job-search-form');
+    if (!form) return;
+
+    document.querySelectorAll('.sort-link').forEach(link => {
+        link.addEventListener('click', function(event) {
+            event.preventDefault();
+
+            const field = link.dataset.sort;
+            const direction = form.sort_by.value === field && form.sort_dir.value !== 'desc' ? 'desc' : 'asc';
+            form.sort_by.value = field;
+            form.sort_dir.value = direction;
+
+            document.querySelectorAll('.sort-indicator').forEach(indicator => {
+                indicator.textContent = indicator.dataset.sort === field ? (direction === 'desc' ? '▼' : '▲') : '';
+            });
+
+            htmx.trigger(form, 'submit');
+        });
+    });
+}
+
+// Keep the address bar in sync with the search form so filtered views can be shared
+function initSearchURLSync() {
+    const form = document.getElementById('job-search-form');
+    if (!form) return;
+
+    const dashboardPath = document.getElementById('dashboard-path')?.value || '/dashboard';
+
+    form.addEventListener('htmx:afterRequest', function() {
+        const params = new URLSearchParams();
+        for (const [name, value] of new FormData(form)) {
+            // Label keys and values are paired by position, so keep empty ones
+            if (value !== '' || name.startsWith('label_')) {
+                params.append(name, value);
+            }
+        }
+        history.replaceState(null, '', `${dashboardPath}/jobs/search?${params}`);
+    });
+}
+
+// Flag a name+host collision on the job form before it is submitted
+function initJobExistsCheck() {
+    const form = document.getElementById('job-form');
+    const hint = document.getElementById('job-exists-hint');
+    if (!form || !hint) return;
+
+    const nameInput = document.getElementById('name');
+    const hostInput = document.getElementById('host');
+    const dashboardPath = document.getElementById('dashboard-path')?.value || '/dashboard';
+    let timer;
+
+    const check = () => {
+        const name = nameInput.value.trim();
+        const host = hostInput.value.trim();
+        if (!name || !host) {
+            hint.textContent = '';
+            return;
+        }
+
+        const params = new URLSearchParams({ name, host });
+        fetch(`${dashboardPath}/api/jobs/exists?${params}`)
+            .then(response => response.json())
+            .then(data => {
+                hint.textContent = '';
+                // Editing a job must not flag the job itself
+                if (!data.exists || String(data.id) === form.dataset.jobId) return;
+
+                hint.append(`${name}@${host} already exists. `);
+                const link = document.createElement('a');
+                link.href = data.url;
+                link.textContent = 'View the existing job';
+                hint.appendChild(link);
+            })
+            .catch(error => {
+                console.error('Error checking job name:', error);
+            });
+    };
+
+    [nameInput, hostInput].forEach(input => {
+        input.addEventListener('input', () => {
+            clearTimeout(timer);
+            timer = setTimeout(check, 300);
+        });
+    });
+}
+
+// Open and close collapsible sections, such as the advanced search filters
+function initCollapseToggles() {
+    document.querySelectorAll('[data-toggle="collapse"]').forEach(toggle => {
+        const target = document.querySelector(toggle.dataset.target);
+        if (!target) return;
+
+        toggle.setAttribute('aria-expanded', target.classList.contains('show'));
+        toggle.addEventListener('click', function() {
+            const shown = target.classList.toggle('show');
+            toggle.setAttribute('aria-expanded', shown);
+        });
+    });
+}
+
+// Select or clear every job of the job list for bulk label editing
+function initJobSelection() {
+    const selectAll = document.getElementById('job-select-all');
+    if (!selectAll) return;
+
+    selectAll.addEventListener('change', function() {
+        document.querySelectorAll('.job-select').forEach(checkbox => {
+            checkbox.checked = selectAll.checked;
+        });
+    });
+}
+
+// Rotate the kiosk view through screens of tiles, refreshing them on the
+// job events of the SSE stream, or by polling when the stream is disabled
+// or lost and the polling fallback is on
+function initKiosk() {
+    const kiosk = document.getElementById('kiosk');
+    if (!kiosk) return;
+
+    const dashboardPath = document.getElementById('dashboard-path')?.value || '/dashboard';
+    const rotation = parseInt(document.getElementById('kiosk-rotation')?.value) || 15;
+    const pageSize = parseInt(document.getElementById('kiosk-page-size')?.value) || 24;
+    const pollingFallback = document.getElementById('polling-fallback')?.value === 'true';
+    const pollingInterval = parseInt(document.getElementById('polling-interval')?.value) || 5;
+    let page = 0;
+    let refreshTimer = null;
+    let pollTimer = null;
+
+    function showPage() {
+        const tiles = kiosk.querySelectorAll('.kiosk-tile');
+        const pages = Math.max(1, Math.ceil(tiles.length / pageSize));
+        if (page >= pages) page = 0;
+        tiles.forEach((tile, i) => {
+            tile.hidden = Math.floor(i / pageSize) !== page;
+        });
+        document.getElementById('kiosk-page').textContent = pages > 1 ? `${page + 1} / ${pages}` : '';
+    }
+
+    function refresh() {
+        fetch(`${dashboardPath}/kiosk/tiles`)
+            .then(response => {
+                if (!response.ok) throw new Error(`HTTP ${response.status}`);
+                return response.text();
+            })
+            .then(html => {
+                document.getElementById('kiosk-tiles').innerHTML = html;
+                showPage();
+            })
+            .catch(error => console.error('Kiosk refresh failed:', error));
+    }
+
+    // Bursts of events, such as the statuses sent on connection, refresh once
+    function scheduleRefresh() {
+        clearTimeout(refreshTimer);
+        refreshTimer = setTimeout(refresh, 1000);
+    }
+
+    function startPolling() {
+        if (pollingFallback && !pollTimer) pollTimer = setInterval(refresh, pollingInterval * 1000);
+    }
+
+    showPage();
+    setInterval(() => {
+        page++;
+        showPage();
+        // Deadlines pass without events: refresh at the start of each cycle
+        if (page === 0) refresh();
+    }, rotation * 1000);
+
+    if (document.getElementById('sse-enabled')?.value !== 'true' || !window.EventSource) {
+        startPolling();
+        return;
+    }
+
+    const events = new EventSource(`${dashboardPath}/events`);
+    ['job-status-change', 'job-created', 'job-updated', 'job-deleted'].forEach(type => {
+        events.addEventListener(type, scheduleRefresh);
+    });
+    events.addEventListener('open', () => {
+        kiosk.classList.remove('kiosk-offline');
+        clearInterval(pollTimer);
+        pollTimer = null;
+    });
+    events.addEventListener('error', () => {
+        kiosk.classList.add('kiosk-offline');
+        startPolling();
+    });
+}
+
+// Show a browser notification when the SSE stream reports that a job of a
+// notified severity started failing, once the browser opted in. The opt-in
+// is kept in local storage, per browser.
+function initBrowserNotifications() {
+    const toggle = document.getElementById('notifications-toggle');
+    if (!toggle || !('Notification' in window) || !window.EventSource) return;
+
+    const dashboardPath = document.getElementById('dashboard-path')?.value || '/dashboard';
+    const severities = (document.getElementById('notified-severities')?.value || '').split(',');
+    const storageKey = 'cronmetrics-notifications';
+    const failing = new Map();
+    let events = null;
+
+    function enabled() {
+        return localStorage.getItem(storageKey) === 'on' && Notification.permission === 'granted';
+    }
+
+    function render() {
+        toggle.hidden = Notification.permission === 'denied';
+        toggle.textContent = enabled() ? 'Disable Notifications' : 'Enable Notifications';
+    }
+
+    function notify(job) {
+        const notification = new Notification(`${job.name}@${job.host} is failing`, {
+            body: `Severity ${job.severity}. Open the job for its latest results.`,
+            tag: `job-${job.job_id}`,
+        });
+        notification.addEventListener('click', () => {
+            window.focus();
+            window.location.href = `${dashboardPath}/jobs/${job.job_id}`;
+            notification.close();
+        });
+    }
+
+    function connect() {
+        if (events) return;
+        events = new EventSource(`${dashboardPath}/events`);
+        events.addEventListener('job-status-change', event => {
+            const job = JSON.parse(event.data);
+            const wasFailing = failing.get(job.job_id);
+            failing.set(job.job_id, job.is_failure);
+
+            // The statuses sent on connection are the baseline, not news
+            if (job.initial || wasFailing || !job.is_failure) return;
+            if (enabled() && severities.includes(job.severity)) notify(job);
+        });
+    }
+
+    function disconnect() {
+        if (!events) return;
+        events.close();
+        events = null;
+        failing.clear();
+    }
+
+    toggle.addEventListener('click', () => {
+        if (enabled()) {
+            localStorage.removeItem(storageKey);
+            disconnect();
+            render();
+            return;
+        }
+        Notification.requestPermission().then(permission => {
+            if (permission === 'granted') {
+                localStorage.setItem(storageKey, 'on');
+                connect();
+            }
+            render();
+        });
+    });
+
+    render();
+    if (enabled()) connect();
+}
+
+// Replace the options of a datalist
+function fillOptions(datalist, values) {
+    if (!datalist) return;
+
+    datalist.innerHTML = '';
+    values.forEach(value => {
+        const option = document.createElement('option');
+        option.value = value;
+        datalist.appendChild(option);
+    });
+}
+
 // Show error message
 function showError(message) {
     // Simple alert for now, can be enhanced with toast notifications
@@ -181,6 +529,22 @@ document.addEventListener('DOMContentLoaded', function() {
         startAutoRefresh(refreshInterval);
     }
 
+    // Typeahead options for search filters
+    initFilterPickers();
+    initLabelFilterRows();
+    initSortableHeaders();
+    initJobExistsCheck();
+    initSearchURLSync();
+    initJobSelection();
+    initCollapseToggles();
+    initBrowserNotifications();
+    initKiosk();
+
+    // Inline edits answer errors in plain text
+    document.body.addEventListener('htmx:responseError', function(event) {
+        showError(event.detail.xhr.responseText);
+    });
+
     // Form validation
     const jobForm = document.getElementById('job-form');
     if (jobForm) {
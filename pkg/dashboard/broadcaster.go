@@ -37,6 +37,7 @@ type JobStatusUpdate struct {
 	Status         string    `json:"status"`
 	LastReportedAt time.Time `json:"last_reported_at"`
 	IsFailure      bool      `json:"is_failure"`
+	Severity       string    `json:"severity"` // For browser notifications
 }
 
 // SSEClient represents a connected SSE client
@@ -155,6 +156,7 @@ func (b *Broadcaster) BroadcastJobStatusChange(job *model.Job, isFailure bool) {
 			Status:         job.Status,
 			LastReportedAt: job.LastReportedAt,
 			IsFailure:      isFailure,
+			Severity:       jobSeverity(b.config, job),
 		},
 	}
 
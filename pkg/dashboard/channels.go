@@ -0,0 +1,309 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// channelView is a notifier channel as shown on the channels page. The
+// password and header values are never shown.
+type channelView struct {
+	ID          int // 0 for configured channels
+	Name        string
+	Type        string
+	Target      string // Where notifications go: the URL or the recipients
+	HeaderNames []string
+	HasPassword bool
+	Settings    config.NotifierChannel
+}
+
+// newChannelView describes a channel for the channels page
+func newChannelView(id int, name string, settings config.NotifierChannel) *channelView {
+	view := &channelView{
+		ID:          id,
+		Name:        name,
+		Type:        settings.Type,
+		Target:      settings.URL,
+		HasPassword: settings.Password != "",
+		Settings:    settings,
+	}
+	if settings.Type == "email" {
+		view.Target = strings.Join(settings.To, ", ")
+	}
+	for header := range settings.Headers {
+		view.HeaderNames = append(view.HeaderNames, header)
+	}
+	sort.Strings(view.HeaderNames)
+	view.Settings.Password = ""
+	view.Settings.Headers = nil
+	return view
+}
+
+// AdminChannels lists the notifier channels, those of the configuration
+// file and those managed here, with a form to add one
+func (h *Handler) AdminChannels(c *gin.Context) {
+	managed, err := h.channelStore.ListChannels()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list notifier channels")
+		c.String(http.StatusInternalServerError, "Failed to load notifier channels")
+		return
+	}
+
+	configured := make([]*channelView, 0, len(h.settings.Notifier.Channels))
+	for name, settings := range h.settings.Notifier.Channels {
+		configured = append(configured, newChannelView(0, name, settings))
+	}
+	sort.Slice(configured, func(i, j int) bool { return configured[i].Name < configured[j].Name })
+
+	channels := make([]*channelView, 0, len(managed))
+	for _, channel := range managed {
+		var settings config.NotifierChannel
+		if err := json.Unmarshal([]byte(channel.Settings), &settings); err != nil {
+			h.logger.WithError(err).WithField("channel_id", channel.ID).Warn("Invalid notifier channel settings")
+		}
+		settings.Type = channel.Type
+		channels = append(channels, newChannelView(channel.ID, channel.Name, settings))
+	}
+
+	data := gin.H{
+		"Title":      h.config.Title,
+		"Config":     h.config,
+		"Enabled":    h.settings.Notifier.Enabled,
+		"Configured": configured,
+		"Channels":   channels,
+		"NewChannel": &channelView{Type: "webhook"},
+	}
+
+	h.renderPage(c, http.StatusOK, "admin_channels.html", data)
+}
+
+// AdminChannelEdit shows the form editing a managed notifier channel
+func (h *Handler) AdminChannelEdit(c *gin.Context) {
+	channel, ok := h.managedChannel(c)
+	if !ok {
+		return
+	}
+
+	var settings config.NotifierChannel
+	if err := json.Unmarshal([]byte(channel.Settings), &settings); err != nil {
+		h.logger.WithError(err).WithField("channel_id", channel.ID).Warn("Invalid notifier channel settings")
+	}
+	settings.Type = channel.Type
+
+	data := gin.H{
+		"Title":   h.config.Title,
+		"Config":  h.config,
+		"Channel": newChannelView(channel.ID, channel.Name, settings),
+	}
+
+	h.renderPage(c, http.StatusOK, "admin_channel_form.html", data)
+}
+
+// AdminChannelCreate adds a managed notifier channel
+func (h *Handler) AdminChannelCreate(c *gin.Context) {
+	channel := &model.NotifierChannel{}
+	if !h.saveChannel(c, channel, config.NotifierChannel{}, h.config.Path+"/admin/channels") {
+		return
+	}
+
+	h.recordAudit(c, "notifier_channel.create", "notifier_channel:"+strconv.Itoa(channel.ID), channel.Name)
+
+	h.setFlash(c, flashSuccess, "Channel "+channel.Name+" added")
+	c.Redirect(http.StatusFound, h.config.Path+"/admin/channels")
+}
+
+// AdminChannelUpdate changes a managed notifier channel. Blank password and
+// headers keep the current ones, unless they are cleared.
+func (h *Handler) AdminChannelUpdate(c *gin.Context) {
+	channel, ok := h.managedChannel(c)
+	if !ok {
+		return
+	}
+
+	var current config.NotifierChannel
+	if err := json.Unmarshal([]byte(channel.Settings), &current); err != nil {
+		h.logger.WithError(err).WithField("channel_id", channel.ID).Warn("Invalid notifier channel settings")
+	}
+	if c.PostForm("clear_secrets") != "" {
+		current.Password = ""
+		current.Headers = nil
+	}
+
+	formPath := fmt.Sprintf("%s/admin/channels/%d", h.config.Path, channel.ID)
+	if !h.saveChannel(c, channel, current, formPath) {
+		return
+	}
+
+	h.recordAudit(c, "notifier_channel.update", "notifier_channel:"+strconv.Itoa(channel.ID), channel.Name)
+
+	h.setFlash(c, flashSuccess, "Channel "+channel.Name+" updated")
+	c.Redirect(http.StatusFound, h.config.Path+"/admin/channels")
+}
+
+// AdminChannelDelete deletes a managed notifier channel
+func (h *Handler) AdminChannelDelete(c *gin.Context) {
+	channel, ok := h.managedChannel(c)
+	if !ok {
+		return
+	}
+
+	if err := h.channelStore.DeleteChannel(channel.ID); err != nil {
+		h.logger.WithError(err).WithField("channel_id", channel.ID).Error("Failed to delete notifier channel")
+		c.String(http.StatusInternalServerError, "Failed to delete notifier channel")
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"channel_id": channel.ID,
+		"name":       channel.Name,
+	}).Info("Notifier channel deleted via dashboard")
+
+	h.recordAudit(c, "notifier_channel.delete", "notifier_channel:"+strconv.Itoa(channel.ID), channel.Name)
+
+	h.setFlash(c, flashSuccess, "Channel "+channel.Name+" deleted")
+	c.Redirect(http.StatusFound, h.config.Path+"/admin/channels")
+}
+
+// managedChannel returns the managed channel of the request's ID, or
+// writes the error response
+func (h *Handler) managedChannel(c *gin.Context) (*model.NotifierChannel, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid channel ID")
+		return nil, false
+	}
+
+	channel, err := h.channelStore.GetChannel(id)
+	if errors.Is(err, model.ErrChannelNotFound) {
+		c.String(http.StatusNotFound, "Channel not found")
+		return nil, false
+	}
+	if err != nil {
+		h.logger.WithError(err).WithField("channel_id", id).Error("Failed to get notifier channel")
+		c.String(http.StatusInternalServerError, "Failed to load notifier channel")
+		return nil, false
+	}
+	return channel, true
+}
+
+// saveChannel stores the submitted channel form over current, the
+// channel's settings before the change. Invalid forms are redirected back
+// to formPath with their error.
+func (h *Handler) saveChannel(c *gin.Context, channel *model.NotifierChannel, current config.NotifierChannel, formPath string) bool {
+	fail := func(message string) bool {
+		h.setFlash(c, flashError, message)
+		c.Redirect(http.StatusFound, formPath)
+		return false
+	}
+
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		return fail("Channel name is required")
+	}
+	for configured := range h.settings.Notifier.Channels {
+		if strings.EqualFold(configured, name) {
+			return fail("Channel " + name + " is defined in the configuration file")
+		}
+	}
+
+	settings, err := parseChannelForm(c, current)
+	if err != nil {
+		return fail(err.Error())
+	}
+	if err := settings.Validate(); err != nil {
+		return fail("Invalid channel: " + err.Error())
+	}
+
+	encoded, err := json.Marshal(settings)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to encode notifier channel")
+		c.String(http.StatusInternalServerError, "Failed to save notifier channel")
+		return false
+	}
+
+	channel.Name = name
+	channel.Type = settings.Type
+	channel.Settings = string(encoded)
+	channel.UpdatedBy = c.GetString("auth_user")
+	if channel.UpdatedBy == "" {
+		channel.UpdatedBy = "dashboard"
+	}
+	if err := h.channelStore.SaveChannel(channel); err != nil {
+		h.logger.WithError(err).WithField("name", name).Error("Failed to save notifier channel")
+		return fail("Failed to save the channel; is its name already taken?")
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"channel_id": channel.ID,
+		"name":       channel.Name,
+		"type":       channel.Type,
+	}).Info("Notifier channel saved via dashboard")
+	return true
+}
+
+// parseChannelForm reads the channel settings of the form. Blank password
+// and headers keep those of current.
+func parseChannelForm(c *gin.Context, current config.NotifierChannel) (config.NotifierChannel, error) {
+	settings := config.NotifierChannel{
+		Type:     c.PostForm("type"),
+		URL:      strings.TrimSpace(c.PostForm("url")),
+		Headers:  current.Headers,
+		SMTPHost: strings.TrimSpace(c.PostForm("smtp_host")),
+		Username: strings.TrimSpace(c.PostForm("username")),
+		Password: current.Password,
+		From:     strings.TrimSpace(c.PostForm("from")),
+	}
+
+	if password := c.PostForm("password"); password != "" {
+		settings.Password = password
+	}
+	if port := strings.TrimSpace(c.PostForm("smtp_port")); port != "" {
+		value, err := strconv.Atoi(port)
+		if err != nil || value < 1 || value > 65535 {
+			return settings, fmt.Errorf("SMTP port must be a number between 1 and 65535")
+		}
+		settings.SMTPPort = value
+	}
+	for _, to := range strings.Split(c.PostForm("to"), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			settings.To = append(settings.To, to)
+		}
+	}
+
+	// One "Name: value" header per line
+	if headers := strings.TrimSpace(c.PostForm("headers")); headers != "" {
+		settings.Headers = make(map[string]string)
+		for _, line := range strings.Split(headers, "\n") {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+			name, value, ok := strings.Cut(line, ":")
+			if !ok || strings.TrimSpace(name) == "" {
+				return settings, fmt.Errorf("headers must be given as \"Name: value\", one per line")
+			}
+			settings.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+
+	// Keep only the settings of the channel's type
+	if settings.Type == "email" {
+		settings.URL = ""
+		settings.Headers = nil
+	} else {
+		settings.SMTPHost, settings.SMTPPort, settings.Username, settings.Password, settings.From, settings.To = "", 0, "", "", "", nil
+		if settings.Type == "slack" {
+			settings.Headers = nil
+		}
+	}
+	return settings, nil
+}
@@ -0,0 +1,52 @@
+package dashboard
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// JobCheck evaluates a job's status now, e.g. after fixing the job, and
+// pushes the fresh state to open dashboards instead of waiting for the
+// next refresh
+func (h *Handler) JobCheck(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.jobStore.GetJobByID(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to get job for check")
+		c.String(http.StatusNotFound, "Job not found")
+		return
+	}
+
+	check, err := h.collector.CheckJob(c.Request.Context(), job)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to check job")
+		c.String(http.StatusServiceUnavailable, "Failed to check job")
+		return
+	}
+	h.broadcaster.BroadcastJobUpdated(job)
+
+	h.logger.WithFields(logrus.Fields{
+		"job_id":   job.ID,
+		"job_name": job.Name,
+		"host":     job.Host,
+		"reason":   check.Reason,
+	}).Info("Job checked via dashboard")
+
+	flash := flashSuccess
+	if check.Value != 1 {
+		flash = flashError
+	}
+	h.setFlash(c, flash, fmt.Sprintf("Checked %s@%s: %s", job.Name, job.Host, strings.ReplaceAll(check.Reason, "_", " ")))
+	c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
+}
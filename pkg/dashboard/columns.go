@@ -0,0 +1,136 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// columnsCookie keeps the column layout of the jobs table chosen in a browser
+const columnsCookie = "cronmetrics_columns"
+
+// columnsCookieAge is how long a saved layout is kept, in seconds
+const columnsCookieAge = 365 * 24 * 60 * 60
+
+// jobColumns are the optional columns of the jobs table, in display order
+var jobColumns = []struct {
+	Value string
+	Label string
+}{
+	{"last_result", "Last Result"},
+	{"owner", "Owner"},
+	{"threshold", "Threshold"},
+	{"last_duration", "Last Duration"},
+	{"success_rate", "Success Rate (7d)"},
+}
+
+// defaultJobColumns are the optional columns shown until a layout is saved
+var defaultJobColumns = []string{"last_result", "threshold"}
+
+// maxLabelColumns is the most labels shown as their own columns
+const maxLabelColumns = 5
+
+// successRateWindow is the time the success rate column covers
+const successRateWindow = 7 * 24 * time.Hour
+
+// ColumnLayout is the optional columns of the jobs table shown in a browser
+type ColumnLayout struct {
+	Columns []string // Values of jobColumns
+	Labels  []string // Label keys shown as their own columns
+}
+
+// Shows reports whether the layout includes the optional column
+func (l ColumnLayout) Shows(column string) bool {
+	return slices.Contains(l.Columns, column)
+}
+
+// Span returns the number of columns of the jobs table
+func (l ColumnLayout) Span(readOnly bool) int {
+	span := 5 + len(l.Columns) + len(l.Labels) // Name, host, status, last reported and actions
+	if !readOnly {
+		span++ // Selection checkbox
+	}
+	return span
+}
+
+// LabelList returns the label columns, comma separated as in the layout form
+func (l ColumnLayout) LabelList() string {
+	return strings.Join(l.Labels, ",")
+}
+
+// needsResults reports whether the layout shows columns computed from
+// the jobs' results
+func (l ColumnLayout) needsResults() bool {
+	return l.Shows("last_duration") || l.Shows("success_rate")
+}
+
+// newColumnLayout keeps the known columns, in display order, and the first
+// distinct label keys
+func newColumnLayout(columns, labels []string) ColumnLayout {
+	layout := ColumnLayout{Columns: []string{}, Labels: []string{}}
+	for _, column := range jobColumns {
+		if slices.Contains(columns, column.Value) {
+			layout.Columns = append(layout.Columns, column.Value)
+		}
+	}
+	for _, key := range labels {
+		key = strings.TrimSpace(key)
+		if key != "" && !slices.Contains(layout.Labels, key) && len(layout.Labels) < maxLabelColumns {
+			layout.Labels = append(layout.Labels, key)
+		}
+	}
+	return layout
+}
+
+// columnLayout returns the layout saved in the browser, else the default one
+func (h *Handler) columnLayout(c *gin.Context) ColumnLayout {
+	value, err := c.Cookie(columnsCookie)
+	if err != nil {
+		return newColumnLayout(defaultJobColumns, nil)
+	}
+	saved, err := url.ParseQuery(value)
+	if err != nil {
+		return newColumnLayout(defaultJobColumns, nil)
+	}
+	return newColumnLayout(saved["column"], saved["label"])
+}
+
+// attachJobStats sets the computed columns of the jobs. Results are only
+// read when the layout shows a column computed from them; a failure leaves
+// these columns empty.
+func (h *Handler) attachJobStats(jobs []*model.Job, layout ColumnLayout) {
+	if !layout.needsResults() {
+		for _, job := range jobs {
+			job.Stats = &model.JobStats{Owner: job.Owner()}
+		}
+		return
+	}
+
+	if err := h.resultStore.AttachJobStats(jobs, time.Now().UTC().Add(-successRateWindow)); err != nil {
+		h.logger.WithError(err).Error("Failed to compute job stats")
+	}
+}
+
+// JobColumnsSave saves the column layout of the jobs table in the browser.
+// Only the browser's view changes, so read-only dashboards allow it too.
+func (h *Handler) JobColumnsSave(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	if c.PostForm("action") == "reset" {
+		c.SetCookie(columnsCookie, "", -1, h.cookiePath(), "", c.Request.TLS != nil, true)
+		h.setFlash(c, flashSuccess, "Column layout reset")
+		c.Redirect(http.StatusFound, h.config.Path+"/jobs")
+		return
+	}
+
+	layout := newColumnLayout(c.PostFormArray("column"), strings.Split(c.PostForm("labels"), ","))
+	saved := url.Values{"column": layout.Columns, "label": layout.Labels}
+	c.SetCookie(columnsCookie, saved.Encode(), columnsCookieAge, h.cookiePath(), "", c.Request.TLS != nil, true)
+
+	h.setFlash(c, flashSuccess, "Column layout saved")
+	c.Redirect(http.StatusFound, h.config.Path+"/jobs")
+}
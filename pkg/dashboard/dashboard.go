@@ -3,7 +3,11 @@ package dashboard
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/metrics"
 	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/notifier"
+	"github.com/jaepetto/cron-exporter/pkg/slo"
+	"github.com/jaepetto/cron-exporter/pkg/ticket"
 	"github.com/sirupsen/logrus"
 )
 
@@ -15,8 +19,14 @@ type Dashboard struct {
 	logger  *logrus.Logger
 }
 
-// New creates a new dashboard instance
-func New(cfg *config.DashboardConfig, jobStore *model.JobStore, adminAPIKeys []string, logger *logrus.Logger) *Dashboard {
+// New creates a new dashboard instance. Admin keys from the config file and
+// those managed in the database are both accepted for authentication.
+func New(appConfig *config.Config, jobStore *model.JobStore, logger *logrus.Logger) *Dashboard {
+	// Links, redirects and cookies are seen by browsers, so they include
+	// the base path the app is served under
+	cfg := appConfig.Dashboard
+	cfg.Path = appConfig.Server.BasePath + cfg.Path
+
 	// Set Gin mode based on config
 	gin.SetMode(gin.ReleaseMode)
 
@@ -31,13 +41,42 @@ func New(cfg *config.DashboardConfig, jobStore *model.JobStore, adminAPIKeys []s
 	router.SetHTMLTemplate(LoadTemplates())
 
 	// Create handler
-	handler := NewHandler(cfg, jobStore, logger)
+	handler := NewHandler(&cfg, jobStore, logger)
+	handler.settings = appConfig
+	handler.ips = appConfig.Logging.IPHasher()
+	handler.adminKeyStore = model.NewAdminKeyStore(jobStore.DB())
+	handler.auditStore = model.NewAuditStore(jobStore.DB())
+	handler.rejectionStore = model.NewRejectionStore(jobStore.DB())
+	handler.resultStore = model.NewJobResultStore(jobStore.DB())
+	handler.annotationStore = model.NewAnnotationStore(jobStore.DB())
+	handler.ruleStore = model.NewRuleStore(jobStore.DB())
+	handler.ackStore = model.NewAcknowledgementStore(jobStore.DB())
+	handler.notificationStore = model.NewNotificationStore(jobStore.DB())
+	handler.channelStore = model.NewChannelStore(jobStore.DB())
+	if appConfig.Tickets.Enabled {
+		deliverer, err := ticket.NewDeliverer(&appConfig.Tickets, handler.ackStore, handler.notificationStore)
+		if err != nil {
+			logger.WithError(err).Warn("Notification redelivery disabled")
+		} else {
+			handler.deliverer = deliverer
+		}
+	}
+	if appConfig.Notifier.Enabled {
+		deliverer, err := notifier.NewDeliverer(&appConfig.Notifier, handler.channelStore, handler.notificationStore)
+		if err != nil {
+			logger.WithError(err).Warn("Notifier redelivery disabled")
+		} else {
+			handler.notifierDeliverer = deliverer
+		}
+	}
+	handler.sloTracker = slo.NewTracker(&appConfig.SLO, jobStore, handler.resultStore)
+	handler.collector = metrics.NewCollector(jobStore, handler.resultStore)
 
 	// Setup routes
-	SetupRoutes(router, cfg, handler, adminAPIKeys)
+	SetupRoutes(router, &cfg, handler, handler.isValidAdminKey)
 
 	return &Dashboard{
-		config:  cfg,
+		config:  &cfg,
 		handler: handler,
 		router:  router,
 		logger:  logger,
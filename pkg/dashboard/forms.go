@@ -0,0 +1,261 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/util"
+)
+
+// flashCookie carries a one-time message across a redirect
+const flashCookie = "cronmetrics_flash"
+
+// Flash levels
+const (
+	flashSuccess = "success"
+	flashError   = "error"
+)
+
+// Flash is a one-time message shown on the next page the user sees
+type Flash struct {
+	Level   string
+	Message string
+}
+
+// BadgeClass returns the badge variant matching the flash level
+func (f *Flash) BadgeClass() string {
+	if f.Level == flashError {
+		return "danger"
+	}
+	return "success"
+}
+
+// cookiePath scopes dashboard cookies to the dashboard mount point
+func (h *Handler) cookiePath() string {
+	if h.config.Path == "" {
+		return "/"
+	}
+	return h.config.Path
+}
+
+// setFlash stores a message to show after the next redirect
+func (h *Handler) setFlash(c *gin.Context, level, message string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(flashCookie, level+":"+message, 60, h.cookiePath(), "", c.Request.TLS != nil, true)
+}
+
+// takeFlash returns the pending flash message, if any, and clears it
+func (h *Handler) takeFlash(c *gin.Context) *Flash {
+	value, err := c.Cookie(flashCookie)
+	if err != nil || value == "" {
+		return nil
+	}
+	c.SetCookie(flashCookie, "", -1, h.cookiePath(), "", c.Request.TLS != nil, true)
+
+	level, message, ok := strings.Cut(value, ":")
+	if !ok || (level != flashSuccess && level != flashError) {
+		return nil
+	}
+	return &Flash{Level: level, Message: message}
+}
+
+// renderPage renders a full page, adding any pending flash message
+func (h *Handler) renderPage(c *gin.Context, status int, name string, data gin.H) {
+	if flash := h.takeFlash(c); flash != nil {
+		data["Flash"] = flash
+	}
+	c.HTML(status, name, data)
+}
+
+// jobFormStatuses are the statuses selectable in the job form
+var jobFormStatuses = []string{"active", "maintenance", "paused"}
+
+// jobForm holds the submitted job form: the values as typed, for
+// re-rendering, and the errors found per field
+type jobForm struct {
+	Labels    string
+	CIDRs     string
+	Threshold string
+	Floor     string
+	Interval  string
+	Errors    map[string]string
+	Existing  *model.Job // Job the submitted name and host collide with
+}
+
+// valid reports whether the form passed validation
+func (f *jobForm) valid() bool {
+	return len(f.Errors) == 0
+}
+
+// bindJobForm applies the submitted form values to job and validates them.
+// When partial is true, omitted fields keep the job's current value, so
+// API-style updates can send only the fields they change.
+func bindJobForm(c *gin.Context, job *model.Job, partial bool) *jobForm {
+	form := &jobForm{Errors: make(map[string]string)}
+
+	field := func(name string) (string, bool) {
+		value, ok := c.GetPostForm(name)
+		value = strings.TrimSpace(value)
+		return value, ok && (value != "" || !partial)
+	}
+
+	if name, ok := field("name"); ok {
+		job.Name = name
+	}
+	if job.Name == "" {
+		form.Errors["name"] = "Job name is required"
+	} else if model.CheckJobIdentity(job.Name, "") != nil {
+		form.Errors["name"] = "Job name cannot contain control characters"
+	}
+
+	if host, ok := field("host"); ok {
+		job.Host = host
+	}
+	if job.Host == "" {
+		form.Errors["host"] = "Host is required"
+	} else if model.CheckJobIdentity("", job.Host) != nil {
+		form.Errors["host"] = "Host cannot contain control characters"
+	}
+
+	if status, ok := field("status"); ok && status != "" {
+		valid := false
+		for _, allowed := range jobFormStatuses {
+			valid = valid || status == allowed
+		}
+		if valid {
+			job.Status = status
+		} else {
+			form.Errors["status"] = fmt.Sprintf("Status must be one of %s", strings.Join(jobFormStatuses, ", "))
+		}
+	}
+
+	if thresholdStr, ok := field("automatic_failure_threshold"); ok && thresholdStr != "" {
+		threshold, err := util.ParseSeconds(thresholdStr)
+		if err != nil || threshold <= 0 {
+			form.Threshold = thresholdStr
+			form.Errors["automatic_failure_threshold"] = "Threshold must be a positive number of seconds or a duration such as 90m, 2h or 1d"
+		} else {
+			job.AutomaticFailureThreshold = threshold
+			job.ThresholdInherited = false
+		}
+	}
+
+	if jobType, ok := field("type"); ok && jobType != "" {
+		if err := model.CheckJobType(jobType, 0); err != nil {
+			form.Errors["type"] = "Type must be standard or counter"
+		} else {
+			job.Type = jobType
+		}
+	}
+
+	if floorStr, ok := field("throughput_floor"); ok {
+		floor, err := strconv.ParseInt(floorStr, 10, 64)
+		switch {
+		case floorStr == "":
+			job.ThroughputFloor = 0
+		case err != nil || floor < 0:
+			form.Floor = floorStr
+			form.Errors["throughput_floor"] = "Throughput floor must be a number of items"
+		default:
+			job.ThroughputFloor = floor
+		}
+	}
+
+	if intervalStr, ok := field("min_interval"); ok {
+		interval, err := strconv.Atoi(intervalStr)
+		switch {
+		case intervalStr == "":
+			job.MinInterval = 0
+		case err != nil || interval < 0:
+			form.Interval = intervalStr
+			form.Errors["min_interval"] = "Minimum interval must be a number of seconds"
+		default:
+			job.MinInterval = interval
+		}
+	}
+
+	if schedule, ok := field("schedule"); ok {
+		job.Schedule = schedule
+		if err := model.CheckSchedule(schedule); err != nil {
+			form.Errors["schedule"] = `Schedule must be a crontab schedule, e.g. "0 3 * * 1" or "@monthly"`
+		}
+	}
+
+	if policy, ok := field("shard_policy"); ok {
+		if err := model.CheckShardPolicy(policy); err != nil {
+			form.Errors["shard_policy"] = "Shard policy must be all, any or none"
+		} else {
+			job.ShardPolicy = policy
+		}
+	}
+
+	if labelsStr, ok := field("labels"); ok {
+		form.Labels = labelsStr
+		var labels map[string]string
+		if labelsStr == "" {
+			job.Labels = map[string]string{}
+		} else if err := json.Unmarshal([]byte(labelsStr), &labels); err != nil {
+			form.Errors["labels"] = `Labels must be a JSON object of strings, e.g. {"env": "prod"}`
+		} else {
+			job.Labels = labels
+		}
+	}
+
+	if description, ok := field("description"); ok {
+		job.Description = description
+		if len(description) > model.MaxDescriptionLength {
+			form.Errors["description"] = fmt.Sprintf("Description must be at most %d characters", model.MaxDescriptionLength)
+		}
+	}
+
+	if cidrsStr, ok := field("allowed_cidrs"); ok {
+		form.CIDRs = cidrsStr
+		cidrs, err := model.NormalizeCIDRs(strings.FieldsFunc(cidrsStr, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		}))
+		if err != nil {
+			form.Errors["allowed_cidrs"] = "Allowed sources must be addresses or CIDR ranges, e.g. 10.0.0.0/8"
+		} else {
+			job.AllowedCIDRs = cidrs
+		}
+	}
+
+	return form
+}
+
+// renderJobForm re-renders the job form with the submitted values and errors
+func (h *Handler) renderJobForm(c *gin.Context, status int, job *model.Job, form *jobForm, edit bool) {
+	data := gin.H{
+		"Title":  h.config.Title,
+		"Config": h.config,
+		"Job":    job,
+		"Edit":   edit,
+		"Errors": form.Errors,
+	}
+	if form.Labels != "" {
+		data["LabelsInput"] = form.Labels
+	}
+	if form.CIDRs != "" {
+		data["CIDRsInput"] = form.CIDRs
+	}
+	if form.Threshold != "" {
+		data["ThresholdInput"] = form.Threshold
+	}
+	if form.Floor != "" {
+		data["FloorInput"] = form.Floor
+	}
+	if form.Interval != "" {
+		data["IntervalInput"] = form.Interval
+	}
+	if form.Existing != nil {
+		data["Existing"] = form.Existing
+	}
+
+	c.HTML(status, "job_form.html", data)
+}
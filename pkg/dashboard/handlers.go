@@ -5,21 +5,42 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/metrics"
 	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/notifier"
+	"github.com/jaepetto/cron-exporter/pkg/slo"
+	"github.com/jaepetto/cron-exporter/pkg/ticket"
+	"github.com/jaepetto/cron-exporter/pkg/util"
 	"github.com/sirupsen/logrus"
 )
 
 // Handler contains all HTTP handlers for the dashboard
 type Handler struct {
-	config       *config.DashboardConfig
-	jobStore     *model.JobStore
-	assetHandler *AssetHandler
-	broadcaster  *Broadcaster
-	logger       *logrus.Logger
+	config            *config.DashboardConfig
+	settings          *config.Config // Full application config, shown read-only on the settings page
+	ips               *util.IPHasher // nil to keep client addresses
+	jobStore          *model.JobStore
+	adminKeyStore     *model.AdminKeyStore
+	auditStore        *model.AuditStore
+	rejectionStore    *model.RejectionStore
+	resultStore       *model.JobResultStore
+	annotationStore   *model.AnnotationStore
+	ruleStore         *model.RuleStore
+	ackStore          *model.AcknowledgementStore
+	notificationStore *model.NotificationStore
+	channelStore      *model.ChannelStore
+	deliverer         *ticket.Deliverer   // nil unless tickets are enabled
+	notifierDeliverer *notifier.Deliverer // nil unless the notifier is enabled
+	sloTracker        *slo.Tracker        // nil when no SLO is defined
+	collector         *metrics.Collector
+	assetHandler      *AssetHandler
+	broadcaster       *Broadcaster
+	logger            *logrus.Logger
 }
 
 // NewHandler creates a new dashboard handler
@@ -59,17 +80,33 @@ func (h *Handler) JobsList(c *gin.Context) {
 		c.String(http.StatusInternalServerError, "Failed to load jobs")
 		return
 	}
+	layout := h.columnLayout(c)
+	h.attachJobStats(result.Jobs, layout)
 
 	data := gin.H{
-		"Title":        h.config.Title,
-		"Jobs":         result.Jobs,
-		"SearchResult": result,
-		"Config":       h.config,
-		"SearchQuery":  "",
-		"Criteria":     criteria,
+		"Title":         h.config.Title,
+		"Jobs":          result.Jobs,
+		"SearchResult":  result,
+		"Config":        h.config,
+		"SearchQuery":   "",
+		"Criteria":      criteria,
+		"WithinOptions": searchWithinOptions,
+		"StaleOptions":  searchStaleOptions,
+		"Notified":      notifiedSeverities(h.config),
+		"Columns":       layout,
+		"ColumnOptions": jobColumns,
+	}
+
+	// Warn about objectives burning their error budget
+	if h.sloTracker != nil {
+		burning, err := h.sloTracker.Burning(c.Request.Context(), time.Now().UTC())
+		if err != nil {
+			h.logger.WithError(err).Error("Failed to evaluate SLOs")
+		}
+		data["BurningSLOs"] = burning
 	}
 
-	c.HTML(http.StatusOK, "jobs.html", data)
+	h.renderPage(c, http.StatusOK, "jobs.html", data)
 }
 
 // JobCreateForm displays the job creation form
@@ -79,41 +116,40 @@ func (h *Handler) JobCreateForm(c *gin.Context) {
 		"Config": h.config,
 	}
 
-	c.HTML(http.StatusOK, "job_form.html", data)
+	h.renderPage(c, http.StatusOK, "job_form.html", data)
 }
 
 // JobCreate handles creating a new job
 func (h *Handler) JobCreate(c *gin.Context) {
 	job := &model.Job{
-		Name:                      c.PostForm("name"),
-		Host:                      c.PostForm("host"),
-		Status:                    c.PostForm("status"),
-		AutomaticFailureThreshold: 3600, // Default
+		Status: "active",
 	}
 
-	// Parse automatic failure threshold
-	if thresholdStr := c.PostForm("automatic_failure_threshold"); thresholdStr != "" {
-		if threshold, err := strconv.Atoi(thresholdStr); err == nil && threshold > 0 {
-			job.AutomaticFailureThreshold = threshold
-		}
+	form := bindJobForm(c, job, false)
+	job.ApplyDefaultThreshold(h.settings.Defaults.FailureThreshold)
+	if form.valid() && !h.checkLabelLimit(c, form, job.Labels) {
+		return
 	}
-
-	// Parse labels JSON
-	if labelsStr := c.PostForm("labels"); labelsStr != "" {
-		var labels map[string]string
-		if err := json.Unmarshal([]byte(labelsStr), &labels); err == nil {
-			job.Labels = labels
-		}
+	if !form.valid() {
+		h.renderJobForm(c, http.StatusBadRequest, job, form, false)
+		return
 	}
 
-	// Validate required fields
-	if job.Name == "" || job.Host == "" {
-		c.String(http.StatusBadRequest, "Name and host are required")
+	// Every job needs its own key to report results
+	apiKey, err := util.GenerateAPIKey()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to generate API key")
+		c.String(http.StatusInternalServerError, "Failed to generate API key")
 		return
 	}
+	job.ApiKey = apiKey
 
 	// Create job
 	if err := h.jobStore.CreateJob(job); err != nil {
+		if model.IsUniqueViolation(err) {
+			h.renderJobConflict(c, job, form, false)
+			return
+		}
 		h.logger.WithError(err).Error("Failed to create job")
 		c.String(http.StatusInternalServerError, "Failed to create job")
 		return
@@ -124,13 +160,29 @@ func (h *Handler) JobCreate(c *gin.Context) {
 		"host":     job.Host,
 	}).Info("Job created via dashboard")
 
+	h.recordAudit(c, "job.create", "job:"+strconv.Itoa(job.ID), job.Name+"@"+job.Host)
+
 	// Broadcast job created event
 	h.broadcaster.BroadcastJobCreated(job)
 
 	// Redirect to job detail page
+	h.setFlash(c, flashSuccess, fmt.Sprintf("Job %s@%s created", job.Name, job.Host))
 	c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+strconv.Itoa(job.ID))
 }
 
+// renderJobConflict re-renders the job form with the submitted values when
+// a job with the same name and host already exists
+func (h *Handler) renderJobConflict(c *gin.Context, job *model.Job, form *jobForm, edit bool) {
+	form.Errors["name"] = "A job with this name already exists on this host"
+	form.Errors["host"] = "A job with this name already exists on this host"
+
+	if existing, err := h.jobStore.GetJob(job.Name, job.Host); err == nil {
+		form.Existing = existing
+	}
+
+	h.renderJobForm(c, http.StatusConflict, job, form, edit)
+}
+
 // JobDetail displays job details
 func (h *Handler) JobDetail(c *gin.Context) {
 	idStr := c.Param("id")
@@ -147,13 +199,70 @@ func (h *Handler) JobDetail(c *gin.Context) {
 		return
 	}
 
-	data := gin.H{
-		"Title":  h.config.Title,
-		"Job":    job,
-		"Config": h.config,
+	results, annotations, err := h.jobHistory(job)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to get job history")
+		c.String(http.StatusInternalServerError, "Failed to load job history")
+		return
+	}
+
+	acks, err := h.ackStore.ListAcknowledgements(job.Name, job.Host)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to get job acknowledgements")
+		c.String(http.StatusInternalServerError, "Failed to load job history")
+		return
 	}
 
-	c.HTML(http.StatusOK, "job_detail.html", data)
+	rules, err := h.ruleStore.ListRules(job.Name, job.Host)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to get job status rules")
+		c.String(http.StatusInternalServerError, "Failed to load job history")
+		return
+	}
+
+	// Related jobs tell host-wide problems from job-specific ones
+	related, err := h.jobStore.GetRelatedJobs(job)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to get related jobs")
+		c.String(http.StatusInternalServerError, "Failed to load related jobs")
+		return
+	}
+
+	// The shards of the latest run of a fan-out job
+	var shardRun *model.ShardRun
+	if job.ShardPolicy != "" {
+		runs, err := h.resultStore.GetShardRuns(job.Name, job.Host, 1)
+		if err != nil {
+			h.logger.WithError(err).WithField("job_id", id).Error("Failed to get job shards")
+			c.String(http.StatusInternalServerError, "Failed to load job history")
+			return
+		}
+		if len(runs) > 0 {
+			shardRun = runs[0]
+		}
+	}
+
+	overlaps, err := h.resultStore.ListOverlaps(job.Name, job.Host, 5)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to get job overlapping runs")
+		c.String(http.StatusInternalServerError, "Failed to load job history")
+		return
+	}
+
+	data := gin.H{
+		"Title":            h.config.Title,
+		"Job":              job,
+		"Results":          results,
+		"Annotations":      annotations,
+		"Acknowledgements": acks,
+		"Rules":            rules,
+		"Related":          related,
+		"ShardRun":         shardRun,
+		"Overlaps":         overlaps,
+		"Config":           h.config,
+	}
+
+	h.renderPage(c, http.StatusOK, "job_detail.html", data)
 }
 
 // JobEditForm displays the job edit form
@@ -179,7 +288,39 @@ func (h *Handler) JobEditForm(c *gin.Context) {
 		"Edit":   true,
 	}
 
-	c.HTML(http.StatusOK, "job_form.html", data)
+	h.renderPage(c, http.StatusOK, "job_form.html", data)
+}
+
+// JobDuplicateForm displays the job creation form prefilled from an existing job
+func (h *Handler) JobDuplicateForm(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	source, err := h.jobStore.GetJobByID(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to get job")
+		c.String(http.StatusNotFound, "Job not found")
+		return
+	}
+
+	// The copy keeps threshold, labels and description; a new key is generated on submit
+	job := *source
+	job.ApiKey = ""
+	job.Status = "active"
+
+	data := gin.H{
+		"Title":     h.config.Title,
+		"Job":       &job,
+		"Source":    source,
+		"Duplicate": true,
+		"Config":    h.config,
+	}
+
+	h.renderPage(c, http.StatusOK, "job_form.html", data)
 }
 
 // JobUpdate handles updating a job
@@ -199,34 +340,22 @@ func (h *Handler) JobUpdate(c *gin.Context) {
 		return
 	}
 
-	// Update fields from form
-	if name := c.PostForm("name"); name != "" {
-		job.Name = name
-	}
-	if host := c.PostForm("host"); host != "" {
-		job.Host = host
-	}
-	if status := c.PostForm("status"); status != "" {
-		job.Status = status
-	}
-
-	// Parse automatic failure threshold
-	if thresholdStr := c.PostForm("automatic_failure_threshold"); thresholdStr != "" {
-		if threshold, err := strconv.Atoi(thresholdStr); err == nil && threshold > 0 {
-			job.AutomaticFailureThreshold = threshold
-		}
+	form := bindJobForm(c, job, true)
+	job.ApplyDefaultThreshold(h.settings.Defaults.FailureThreshold)
+	if form.valid() && !h.checkLabelLimit(c, form, job.Labels) {
+		return
 	}
-
-	// Parse labels JSON
-	if labelsStr := c.PostForm("labels"); labelsStr != "" {
-		var labels map[string]string
-		if err := json.Unmarshal([]byte(labelsStr), &labels); err == nil {
-			job.Labels = labels
-		}
+	if !form.valid() {
+		h.renderJobForm(c, http.StatusBadRequest, job, form, true)
+		return
 	}
 
 	// Update job
 	if err := h.jobStore.UpdateJob(job); err != nil {
+		if model.IsUniqueViolation(err) {
+			h.renderJobConflict(c, job, form, true)
+			return
+		}
 		h.logger.WithError(err).WithField("job_id", id).Error("Failed to update job")
 		c.String(http.StatusInternalServerError, "Failed to update job")
 		return
@@ -238,10 +367,13 @@ func (h *Handler) JobUpdate(c *gin.Context) {
 		"host":     job.Host,
 	}).Info("Job updated via dashboard")
 
+	h.recordAudit(c, "job.update", "job:"+strconv.Itoa(job.ID), job.Name+"@"+job.Host)
+
 	// Broadcast job updated event
 	h.broadcaster.BroadcastJobUpdated(job)
 
 	// Redirect to job detail page
+	h.setFlash(c, flashSuccess, fmt.Sprintf("Job %s@%s updated", job.Name, job.Host))
 	c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+strconv.Itoa(job.ID))
 }
 
@@ -265,7 +397,8 @@ func (h *Handler) JobDelete(c *gin.Context) {
 	// Delete job
 	if err := h.jobStore.DeleteJob(job.Name, job.Host); err != nil {
 		h.logger.WithError(err).WithField("job_id", id).Error("Failed to delete job")
-		c.String(http.StatusInternalServerError, "Failed to delete job")
+		h.setFlash(c, flashError, "Failed to delete job")
+		c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
 		return
 	}
 
@@ -275,10 +408,13 @@ func (h *Handler) JobDelete(c *gin.Context) {
 		"host":     job.Host,
 	}).Info("Job deleted via dashboard")
 
+	h.recordAudit(c, "job.delete", "job:"+strconv.Itoa(job.ID), job.Name+"@"+job.Host)
+
 	// Broadcast job deleted event
 	h.broadcaster.BroadcastJobDeleted(job.ID, job.Name, job.Host)
 
 	// Redirect to jobs list
+	h.setFlash(c, flashSuccess, fmt.Sprintf("Job %s@%s deleted", job.Name, job.Host))
 	c.Redirect(http.StatusFound, h.config.Path+"/jobs")
 }
 
@@ -327,7 +463,8 @@ func (h *Handler) JobToggle(c *gin.Context) {
 	// Update job
 	if err := h.jobStore.UpdateJob(job); err != nil {
 		h.logger.WithError(err).WithField("job_id", id).Error("Failed to toggle job status")
-		c.String(http.StatusInternalServerError, "Failed to toggle job status")
+		h.setFlash(c, flashError, "Failed to toggle job status")
+		c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
 		return
 	}
 
@@ -338,61 +475,23 @@ func (h *Handler) JobToggle(c *gin.Context) {
 		"new_status": job.Status,
 	}).Info("Job status toggled via dashboard")
 
+	h.recordAudit(c, "job.status", "job:"+strconv.Itoa(job.ID), job.Status)
+
 	// Broadcast job status change
 	isFailure := false
-	if job.AutomaticFailureThreshold > 0 {
-		timeSinceLastReport := time.Since(job.LastReportedAt)
-		if timeSinceLastReport > time.Duration(job.AutomaticFailureThreshold)*time.Second {
-			isFailure = true
-		}
+	if job.AutomaticFailureThreshold > 0 && job.MissedDeadline(time.Now()) {
+		isFailure = true
 	}
 	h.broadcaster.BroadcastJobStatusChange(job, isFailure)
 
 	// Return to job detail page
+	h.setFlash(c, flashSuccess, fmt.Sprintf("Job %s@%s is now %s", job.Name, job.Host, job.Status))
 	c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+strconv.Itoa(job.ID))
 }
 
 // JobSearch handles advanced job search requests with HTMX support
 func (h *Handler) JobSearch(c *gin.Context) {
-	// Parse search criteria from query parameters
-	criteria := &model.JobSearchCriteria{
-		Query:  c.Query("q"),
-		Name:   c.Query("name"),
-		Host:   c.Query("host"),
-		Status: c.Query("status"),
-	}
-
-	// Parse pagination parameters
-	if pageStr := c.Query("page"); pageStr != "" {
-		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
-			criteria.Page = page
-		}
-	}
-	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
-		if pageSize, err := strconv.Atoi(pageSizeStr); err == nil && pageSize > 0 && pageSize <= 100 {
-			criteria.PageSize = pageSize
-		}
-	}
-
-	// Parse time-based filters
-	if beforeStr := c.Query("before"); beforeStr != "" {
-		if before, err := time.Parse(time.RFC3339, beforeStr); err == nil {
-			criteria.LastReportedBefore = &before
-		}
-	}
-	if afterStr := c.Query("after"); afterStr != "" {
-		if after, err := time.Parse(time.RFC3339, afterStr); err == nil {
-			criteria.LastReportedAfter = &after
-		}
-	}
-
-	// Parse label filters (JSON format: {"key1":"value1","key2":"value2"})
-	if labelsStr := c.Query("labels"); labelsStr != "" {
-		var labels map[string]string
-		if err := json.Unmarshal([]byte(labelsStr), &labels); err == nil {
-			criteria.Labels = labels
-		}
-	}
+	criteria := parseSearchCriteria(c)
 
 	// Perform the search
 	result, err := h.jobStore.SearchJobs(criteria)
@@ -401,6 +500,8 @@ func (h *Handler) JobSearch(c *gin.Context) {
 		c.String(http.StatusInternalServerError, "Failed to search jobs")
 		return
 	}
+	layout := h.columnLayout(c)
+	h.attachJobStats(result.Jobs, layout)
 
 	// Check if this is an HTMX request for partial updates
 	if c.GetHeader("HX-Request") == "true" {
@@ -410,64 +511,34 @@ func (h *Handler) JobSearch(c *gin.Context) {
 			"SearchResult": result,
 			"Config":       h.config,
 			"SearchQuery":  criteria.Query,
+			"Columns":      layout,
 		})
 		return
 	}
 
 	// For full page requests, return the complete jobs page with search results
 	data := gin.H{
-		"Title":        h.config.Title,
-		"Jobs":         result.Jobs,
-		"SearchResult": result,
-		"Config":       h.config,
-		"SearchQuery":  criteria.Query,
-		"Criteria":     criteria,
-	}
-
-	c.HTML(http.StatusOK, "jobs.html", data)
+		"Title":         h.config.Title,
+		"Jobs":          result.Jobs,
+		"SearchResult":  result,
+		"Config":        h.config,
+		"SearchQuery":   criteria.Query,
+		"Criteria":      criteria,
+		"Within":        c.Query("within"),
+		"WithinOptions": searchWithinOptions,
+		"Stale":         c.Query("stale"),
+		"StaleOptions":  searchStaleOptions,
+		"Notified":      notifiedSeverities(h.config),
+		"Columns":       layout,
+		"ColumnOptions": jobColumns,
+	}
+
+	h.renderPage(c, http.StatusOK, "jobs.html", data)
 }
 
 // JobSearchAPI handles job search API requests for HTMX
 func (h *Handler) JobSearchAPI(c *gin.Context) {
-	// Parse search criteria from query parameters
-	criteria := &model.JobSearchCriteria{
-		Query:  c.Query("q"),
-		Name:   c.Query("name"),
-		Host:   c.Query("host"),
-		Status: c.Query("status"),
-	}
-
-	// Parse pagination parameters
-	if pageStr := c.Query("page"); pageStr != "" {
-		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
-			criteria.Page = page
-		}
-	}
-	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
-		if pageSize, err := strconv.Atoi(pageSizeStr); err == nil && pageSize > 0 && pageSize <= 100 {
-			criteria.PageSize = pageSize
-		}
-	}
-
-	// Parse time-based filters
-	if beforeStr := c.Query("before"); beforeStr != "" {
-		if before, err := time.Parse(time.RFC3339, beforeStr); err == nil {
-			criteria.LastReportedBefore = &before
-		}
-	}
-	if afterStr := c.Query("after"); afterStr != "" {
-		if after, err := time.Parse(time.RFC3339, afterStr); err == nil {
-			criteria.LastReportedAfter = &after
-		}
-	}
-
-	// Parse label filters
-	if labelsStr := c.Query("labels"); labelsStr != "" {
-		var labels map[string]string
-		if err := json.Unmarshal([]byte(labelsStr), &labels); err == nil {
-			criteria.Labels = labels
-		}
-	}
+	criteria := parseSearchCriteria(c)
 
 	// Perform the search
 	result, err := h.jobStore.SearchJobs(criteria)
@@ -479,6 +550,9 @@ func (h *Handler) JobSearchAPI(c *gin.Context) {
 
 	// Check if this is a request for HTML partial update (HTMX)
 	if c.GetHeader("HX-Request") == "true" {
+		layout := h.columnLayout(c)
+		h.attachJobStats(result.Jobs, layout)
+
 		// Return HTML partial for table body update
 		c.HTML(http.StatusOK, "job_list_partial.html", gin.H{
 			"Jobs":         result.Jobs,
@@ -486,55 +560,19 @@ func (h *Handler) JobSearchAPI(c *gin.Context) {
 			"Config":       h.config,
 			"SearchQuery":  criteria.Query,
 			"Criteria":     criteria,
+			"Columns":      layout,
 		})
 		return
 	}
 
-	// Return JSON for API clients
+	// Return JSON for API clients, with every computed column
+	h.attachJobStats(result.Jobs, ColumnLayout{Columns: []string{"last_duration", "success_rate"}})
 	c.JSON(http.StatusOK, result)
 }
 
 // JobSearchWithPagination handles job search with pagination UI updates
 func (h *Handler) JobSearchWithPagination(c *gin.Context) {
-	// Parse search criteria from query parameters (same as JobSearchAPI)
-	criteria := &model.JobSearchCriteria{
-		Query:  c.Query("q"),
-		Name:   c.Query("name"),
-		Host:   c.Query("host"),
-		Status: c.Query("status"),
-	}
-
-	// Parse pagination parameters
-	if pageStr := c.Query("page"); pageStr != "" {
-		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
-			criteria.Page = page
-		}
-	}
-	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
-		if pageSize, err := strconv.Atoi(pageSizeStr); err == nil && pageSize > 0 && pageSize <= 100 {
-			criteria.PageSize = pageSize
-		}
-	}
-
-	// Parse time-based filters
-	if beforeStr := c.Query("before"); beforeStr != "" {
-		if before, err := time.Parse(time.RFC3339, beforeStr); err == nil {
-			criteria.LastReportedBefore = &before
-		}
-	}
-	if afterStr := c.Query("after"); afterStr != "" {
-		if after, err := time.Parse(time.RFC3339, afterStr); err == nil {
-			criteria.LastReportedAfter = &after
-		}
-	}
-
-	// Parse label filters
-	if labelsStr := c.Query("labels"); labelsStr != "" {
-		var labels map[string]string
-		if err := json.Unmarshal([]byte(labelsStr), &labels); err == nil {
-			criteria.Labels = labels
-		}
-	}
+	criteria := parseSearchCriteria(c)
 
 	// Perform the search
 	result, err := h.jobStore.SearchJobs(criteria)
@@ -543,6 +581,8 @@ func (h *Handler) JobSearchWithPagination(c *gin.Context) {
 		c.String(http.StatusInternalServerError, "Failed to search jobs")
 		return
 	}
+	layout := h.columnLayout(c)
+	h.attachJobStats(result.Jobs, layout)
 
 	// Return both table body and pagination for HTMX multi-target updates
 	data := gin.H{
@@ -551,6 +591,7 @@ func (h *Handler) JobSearchWithPagination(c *gin.Context) {
 		"Config":       h.config,
 		"SearchQuery":  criteria.Query,
 		"Criteria":     criteria,
+		"Columns":      layout,
 	}
 
 	// Check what kind of update is requested
@@ -647,7 +688,8 @@ func (h *Handler) writeSSEMessage(c *gin.Context, eventType string, data interfa
 		return false
 	}
 
-	// Note: Flushing is handled automatically by Gin for streaming responses
+	// Send the event now rather than when the response buffer fills
+	c.Writer.Flush()
 
 	return true
 }
@@ -663,11 +705,8 @@ func (h *Handler) sendCurrentJobStatus(c *gin.Context) {
 	for _, job := range jobs {
 		// Check if job is in failure state based on threshold
 		isFailure := false
-		if job.AutomaticFailureThreshold > 0 {
-			timeSinceLastReport := time.Since(job.LastReportedAt)
-			if timeSinceLastReport > time.Duration(job.AutomaticFailureThreshold)*time.Second {
-				isFailure = true
-			}
+		if job.AutomaticFailureThreshold > 0 && job.MissedDeadline(time.Now()) {
+			isFailure = true
 		}
 
 		if !h.writeSSEMessage(c, "job-status-change", map[string]interface{}{
@@ -677,8 +716,38 @@ func (h *Handler) sendCurrentJobStatus(c *gin.Context) {
 			"status":           job.Status,
 			"last_reported_at": job.LastReportedAt,
 			"is_failure":       isFailure,
+			"severity":         jobSeverity(h.config, job),
+			"initial":          true, // Current state, not a change
 		}) {
 			return
 		}
 	}
 }
+
+// checkLabelLimit applies the label cardinality guard from the metrics
+// config, recording refused labels as a form error. It reports false after
+// writing an error response when the check itself fails.
+func (h *Handler) checkLabelLimit(c *gin.Context, form *jobForm, labels map[string]string) bool {
+	limit := h.settings.Metrics.MaxLabelValues
+
+	exceeded, err := h.jobStore.CheckLabelCardinality(labels, limit)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to check label cardinality")
+		c.String(http.StatusInternalServerError, "Failed to check labels")
+		return false
+	}
+	if len(exceeded) == 0 {
+		return true
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"label_keys": exceeded,
+		"limit":      limit,
+		"rejected":   h.settings.Metrics.RejectNewLabelValues,
+	}).Warn("Label value limit reached")
+
+	if h.settings.Metrics.RejectNewLabelValues {
+		form.Errors["labels"] = fmt.Sprintf("Label value limit reached for %s (max %d distinct values per key)", strings.Join(exceeded, ", "), limit)
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// JobHistory renders the versions of a job's configuration, with the
+// fields each changed
+func (h *Handler) JobHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.jobStore.GetJobByID(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to get job for history")
+		c.String(http.StatusNotFound, "Job not found")
+		return
+	}
+	versions, err := h.jobStore.ListJobVersions(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to list job versions")
+		c.String(http.StatusInternalServerError, "Failed to load job history")
+		return
+	}
+
+	data := gin.H{
+		"Title":    h.config.Title,
+		"Config":   h.config,
+		"Job":      job,
+		"Versions": versions,
+	}
+
+	h.renderPage(c, http.StatusOK, "job_history.html", data)
+}
+
+// JobRollback restores the configuration of one of a job's versions
+func (h *Handler) JobRollback(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid version")
+		return
+	}
+
+	job, err := h.jobStore.RollbackJob(id, version, h.settings.Defaults.FailureThreshold)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrJobVersionNotFound):
+			h.setFlash(c, flashError, fmt.Sprintf("Version %d not found", version))
+		case model.IsUniqueViolation(err):
+			h.setFlash(c, flashError, fmt.Sprintf("Cannot roll back to version %d: another job has its name and host", version))
+		case strings.Contains(err.Error(), "not found"):
+			c.String(http.StatusNotFound, "Job not found")
+			return
+		default:
+			h.logger.WithError(err).WithField("job_id", id).Error("Failed to roll back job")
+			h.setFlash(c, flashError, "Failed to roll back the job")
+		}
+		c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr+"/history")
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"job_id":   job.ID,
+		"job_name": job.Name,
+		"host":     job.Host,
+		"version":  version,
+	}).Info("Job rolled back via dashboard")
+
+	h.recordAudit(c, "job.rollback", "job:"+idStr, fmt.Sprintf("%s@%s to version %d", job.Name, job.Host, version))
+	h.broadcaster.BroadcastJobUpdated(job)
+
+	h.setFlash(c, flashSuccess, fmt.Sprintf("Job %s@%s rolled back to version %d", job.Name, job.Host, version))
+	c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr+"/history")
+}
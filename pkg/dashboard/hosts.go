@@ -0,0 +1,88 @@
+package dashboard
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// hostFailureWindow is the period host pages count results over
+const hostFailureWindow = 24 * time.Hour
+
+// hostTimelineLimit is the number of submissions shown on a host's page
+const hostTimelineLimit = 50
+
+// hostSummaries returns the summary of every host with jobs
+func (h *Handler) hostSummaries() ([]*model.HostSummary, error) {
+	jobs, err := h.jobStore.ListJobs(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := h.resultStore.CountJobResultsSince(time.Now().UTC().Add(-hostFailureWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	return model.SummarizeHosts(jobs, counts), nil
+}
+
+// HostsList displays every host with its jobs, last contact and failures
+func (h *Handler) HostsList(c *gin.Context) {
+	hosts, err := h.hostSummaries()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to summarize hosts")
+		c.String(http.StatusInternalServerError, "Failed to load hosts")
+		return
+	}
+
+	data := gin.H{
+		"Title":  h.config.Title,
+		"Config": h.config,
+		"Hosts":  hosts,
+	}
+
+	h.renderPage(c, http.StatusOK, "hosts.html", data)
+}
+
+// HostDetail displays a host's jobs and its latest submissions
+func (h *Handler) HostDetail(c *gin.Context) {
+	host := c.Param("host")
+
+	hosts, err := h.hostSummaries()
+	if err != nil {
+		h.logger.WithError(err).WithField("host", host).Error("Failed to summarize hosts")
+		c.String(http.StatusInternalServerError, "Failed to load host")
+		return
+	}
+
+	var summary *model.HostSummary
+	for _, candidate := range hosts {
+		if candidate.Host == host {
+			summary = candidate
+			break
+		}
+	}
+	if summary == nil {
+		c.String(http.StatusNotFound, "Host not found")
+		return
+	}
+
+	timeline, err := h.resultStore.ListHostResults(host, hostTimelineLimit)
+	if err != nil {
+		h.logger.WithError(err).WithField("host", host).Error("Failed to list host results")
+		c.String(http.StatusInternalServerError, "Failed to load host")
+		return
+	}
+
+	data := gin.H{
+		"Title":    h.config.Title,
+		"Config":   h.config,
+		"Host":     summary,
+		"Timeline": timeline,
+	}
+
+	h.renderPage(c, http.StatusOK, "host_detail.html", data)
+}
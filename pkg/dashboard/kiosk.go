@@ -0,0 +1,85 @@
+package dashboard
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// kioskStatusOrder ranks the deadline statuses on the kiosk view: failing
+// jobs first, inactive ones last
+var kioskStatusOrder = map[string]int{"danger": 0, "warning": 1, "success": 2, "inactive": 3}
+
+// kioskTile is a job on the kiosk view, with its deadline status
+type kioskTile struct {
+	Job    *model.Job
+	Status string // As deadlineStatus
+}
+
+// kioskTiles returns a tile per job, failing jobs first, then by host and
+// name, with the count of tiles per status
+func (h *Handler) kioskTiles() ([]kioskTile, map[string]int, error) {
+	jobs, err := h.jobStore.ListJobs(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().UTC()
+	tiles := make([]kioskTile, 0, len(jobs))
+	counts := make(map[string]int, len(kioskStatusOrder))
+	for _, job := range jobs {
+		status := deadlineStatus(job, now)
+		tiles = append(tiles, kioskTile{Job: job, Status: status})
+		counts[status]++
+	}
+
+	sort.SliceStable(tiles, func(i, j int) bool {
+		a, b := tiles[i], tiles[j]
+		if a.Status != b.Status {
+			return kioskStatusOrder[a.Status] < kioskStatusOrder[b.Status]
+		}
+		if a.Job.Host != b.Job.Host {
+			return a.Job.Host < b.Job.Host
+		}
+		return a.Job.Name < b.Job.Name
+	})
+	return tiles, counts, nil
+}
+
+// Kiosk displays every job as a large status tile, full screen and without
+// navigation, for TV and wall displays. The page rotates through screens of
+// tiles and refreshes them on the events of the SSE stream.
+func (h *Handler) Kiosk(c *gin.Context) {
+	tiles, counts, err := h.kioskTiles()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list jobs for the kiosk view")
+		c.String(http.StatusInternalServerError, "Failed to load jobs")
+		return
+	}
+
+	c.HTML(http.StatusOK, "kiosk.html", gin.H{
+		"Title":  h.config.Title,
+		"Config": h.config,
+		"Tiles":  tiles,
+		"Counts": counts,
+	})
+}
+
+// KioskTiles renders the tiles of the kiosk view, for its refreshes
+func (h *Handler) KioskTiles(c *gin.Context) {
+	tiles, counts, err := h.kioskTiles()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list jobs for the kiosk view")
+		c.String(http.StatusInternalServerError, "Failed to load jobs")
+		return
+	}
+
+	c.HTML(http.StatusOK, "kiosk_tiles.html", gin.H{
+		"Config": h.config,
+		"Tiles":  tiles,
+		"Counts": counts,
+	})
+}
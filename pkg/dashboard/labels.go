@@ -0,0 +1,227 @@
+package dashboard
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// maxLabelLength caps label keys and values edited inline
+const maxLabelLength = 200
+
+// labelFromForm reads and checks the label key and, when withValue is set,
+// value of an inline label edit. It returns an error message when invalid.
+func labelFromForm(c *gin.Context, withValue bool) (string, string, string) {
+	key := strings.TrimSpace(c.PostForm("key"))
+	value := strings.TrimSpace(c.PostForm("value"))
+
+	if key == "" || len(key) > maxLabelLength {
+		return "", "", fmt.Sprintf("Label key is required and at most %d characters", maxLabelLength)
+	}
+	if withValue && len(value) > maxLabelLength {
+		return "", "", fmt.Sprintf("Label value must be at most %d characters", maxLabelLength)
+	}
+	return key, value, ""
+}
+
+// labelLimitMessage applies the label cardinality guard to a label about to
+// be set. It returns the refusal message, if any, and reports false after
+// writing an error response when the check itself fails.
+func (h *Handler) labelLimitMessage(c *gin.Context, key, value string) (string, bool) {
+	form := &jobForm{Errors: map[string]string{}}
+	if !h.checkLabelLimit(c, form, map[string]string{key: value}) {
+		return "", false
+	}
+	return form.Errors["labels"], true
+}
+
+// saveJobLabels stores a job's edited labels and notifies listeners
+func (h *Handler) saveJobLabels(c *gin.Context, job *model.Job, details string) error {
+	// An inherited threshold follows the defaults for the new labels
+	job.ApplyDefaultThreshold(h.settings.Defaults.FailureThreshold)
+	if err := h.jobStore.UpdateJob(job); err != nil {
+		return err
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"job_id":   job.ID,
+		"job_name": job.Name,
+		"host":     job.Host,
+		"labels":   job.Labels,
+	}).Info("Job labels edited via dashboard")
+
+	h.recordAudit(c, "job.labels", "job:"+strconv.Itoa(job.ID), details)
+	h.broadcaster.BroadcastJobUpdated(job)
+	return nil
+}
+
+// labelJob loads the job of an inline label edit. It writes an error
+// response and returns nil when the job cannot be loaded.
+func (h *Handler) labelJob(c *gin.Context) *model.Job {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid job ID")
+		return nil
+	}
+
+	job, err := h.jobStore.GetJobByID(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to get job for label edit")
+		c.String(http.StatusNotFound, "Job not found")
+		return nil
+	}
+	if job.Labels == nil {
+		job.Labels = map[string]string{}
+	}
+	return job
+}
+
+// renderJobRow renders a job's row of the job list, replacing the edited row
+func (h *Handler) renderJobRow(c *gin.Context, job *model.Job) {
+	jobs := []*model.Job{job}
+	layout := h.columnLayout(c)
+	h.attachJobStats(jobs, layout)
+	if layout.Shows("last_result") {
+		last, err := h.resultStore.GetLastResult(job.Name, job.Host)
+		if err != nil {
+			h.logger.WithError(err).WithField("job_id", job.ID).Error("Failed to get last result")
+		}
+		job.LastResult = last
+	}
+
+	c.HTML(http.StatusOK, "job_list_partial.html", gin.H{
+		"Jobs":        jobs,
+		"Config":      h.config,
+		"SearchQuery": "",
+		"Columns":     layout,
+	})
+}
+
+// JobLabelSet adds or changes one label of a job from the job list
+func (h *Handler) JobLabelSet(c *gin.Context) {
+	job := h.labelJob(c)
+	if job == nil {
+		return
+	}
+
+	key, value, message := labelFromForm(c, true)
+	if message != "" {
+		c.String(http.StatusBadRequest, message)
+		return
+	}
+
+	message, ok := h.labelLimitMessage(c, key, value)
+	if !ok {
+		return
+	}
+	if message != "" {
+		c.String(http.StatusBadRequest, message)
+		return
+	}
+
+	job.Labels[key] = value
+	if err := h.saveJobLabels(c, job, "set "+key+"="+value); err != nil {
+		h.logger.WithError(err).WithField("job_id", job.ID).Error("Failed to set job label")
+		c.String(http.StatusInternalServerError, "Failed to update job")
+		return
+	}
+
+	h.renderJobRow(c, job)
+}
+
+// JobLabelRemove removes one label of a job from the job list
+func (h *Handler) JobLabelRemove(c *gin.Context) {
+	job := h.labelJob(c)
+	if job == nil {
+		return
+	}
+
+	key, _, message := labelFromForm(c, false)
+	if message != "" {
+		c.String(http.StatusBadRequest, message)
+		return
+	}
+
+	if _, ok := job.Labels[key]; ok {
+		delete(job.Labels, key)
+		if err := h.saveJobLabels(c, job, "remove "+key); err != nil {
+			h.logger.WithError(err).WithField("job_id", job.ID).Error("Failed to remove job label")
+			c.String(http.StatusInternalServerError, "Failed to update job")
+			return
+		}
+	}
+
+	h.renderJobRow(c, job)
+}
+
+// JobsLabelBulk sets or removes a label on the jobs selected in the job list
+func (h *Handler) JobsLabelBulk(c *gin.Context) {
+	redirect := h.config.Path + "/jobs"
+
+	remove := c.PostForm("action") == "remove"
+	key, value, message := labelFromForm(c, !remove)
+	if message == "" && len(c.PostFormArray("job_id")) == 0 {
+		message = "Select the jobs to label first"
+	}
+	if message == "" && !remove {
+		var ok bool
+		if message, ok = h.labelLimitMessage(c, key, value); !ok {
+			return
+		}
+	}
+	if message != "" {
+		h.setFlash(c, flashError, message)
+		c.Redirect(http.StatusFound, redirect)
+		return
+	}
+
+	updated := 0
+	for _, idStr := range c.PostFormArray("job_id") {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			continue
+		}
+		job, err := h.jobStore.GetJobByID(id)
+		if err != nil {
+			h.logger.WithError(err).WithField("job_id", id).Warn("Skipping missing job in bulk label edit")
+			continue
+		}
+		if job.Labels == nil {
+			job.Labels = map[string]string{}
+		}
+
+		details := "set " + key + "=" + value
+		if remove {
+			if _, ok := job.Labels[key]; !ok {
+				continue
+			}
+			delete(job.Labels, key)
+			details = "remove " + key
+		} else {
+			if current, ok := job.Labels[key]; ok && current == value {
+				continue
+			}
+			job.Labels[key] = value
+		}
+
+		if err := h.saveJobLabels(c, job, details); err != nil {
+			h.logger.WithError(err).WithField("job_id", id).Error("Failed to edit job labels")
+			h.setFlash(c, flashError, fmt.Sprintf("Failed to update %s@%s after updating %d job(s)", job.Name, job.Host, updated))
+			c.Redirect(http.StatusFound, redirect)
+			return
+		}
+		updated++
+	}
+
+	if remove {
+		h.setFlash(c, flashSuccess, fmt.Sprintf("Label %s removed from %d job(s)", key, updated))
+	} else {
+		h.setFlash(c, flashSuccess, fmt.Sprintf("Label %s=%s applied to %d job(s)", key, value, updated))
+	}
+	c.Redirect(http.StatusFound, redirect)
+}
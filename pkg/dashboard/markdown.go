@@ -0,0 +1,149 @@
+package dashboard
+
+import (
+	"fmt"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	markdownHeading  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	markdownBullet   = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	markdownNumbered = regexp.MustCompile(`^\d+\.\s+(.*)$`)
+	markdownLink     = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^\s)]+)\)`)
+	markdownBold     = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	markdownItalic   = regexp.MustCompile(`\*([^*\s][^*]*)\*`)
+)
+
+// renderMarkdown renders the small markdown subset used in job descriptions:
+// headings, bullet and numbered lists, fenced code blocks, paragraphs, inline
+// code, bold, italic and http(s) links. Everything else is escaped, so
+// descriptions cannot inject markup into the page.
+func renderMarkdown(source string) template.HTML {
+	var out strings.Builder
+	var paragraph []string
+	list := "" // Open list element, "ul" or "ol"
+	inCode := false
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + renderInlineMarkdown(strings.Join(paragraph, " ")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if list != "" {
+			out.WriteString("</" + list + ">\n")
+			list = ""
+		}
+	}
+	openList := func(element string) {
+		if list != element {
+			closeList()
+			out.WriteString("<" + element + ">\n")
+			list = element
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			if inCode {
+				out.WriteString("</code></pre>\n")
+			} else {
+				flushParagraph()
+				closeList()
+				out.WriteString("<pre><code>")
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			out.WriteString(template.HTMLEscapeString(line) + "\n")
+			continue
+		}
+
+		if trimmed == "" {
+			flushParagraph()
+			closeList()
+			continue
+		}
+
+		if match := markdownHeading.FindStringSubmatch(trimmed); match != nil {
+			flushParagraph()
+			closeList()
+			// Headings start at h3 to stay below the page's own headings
+			level := min(len(match[1])+2, 6)
+			out.WriteString(fmt.Sprintf("<h%d>%s</h%d>\n", level, renderInlineMarkdown(match[2]), level))
+			continue
+		}
+
+		if match := markdownBullet.FindStringSubmatch(trimmed); match != nil {
+			flushParagraph()
+			openList("ul")
+			out.WriteString("<li>" + renderInlineMarkdown(match[1]) + "</li>\n")
+			continue
+		}
+		if match := markdownNumbered.FindStringSubmatch(trimmed); match != nil {
+			flushParagraph()
+			openList("ol")
+			out.WriteString("<li>" + renderInlineMarkdown(match[1]) + "</li>\n")
+			continue
+		}
+
+		closeList()
+		paragraph = append(paragraph, trimmed)
+	}
+
+	if inCode {
+		out.WriteString("</code></pre>\n")
+	}
+	flushParagraph()
+	closeList()
+
+	return template.HTML(out.String())
+}
+
+// renderInlineMarkdown escapes a line of text and renders its inline code,
+// links, bold and italic spans
+func renderInlineMarkdown(text string) string {
+	var out strings.Builder
+
+	// Odd segments between backticks are code spans, rendered verbatim
+	segments := strings.Split(text, "`")
+	if len(segments)%2 == 0 {
+		// An unmatched backtick is kept as text
+		last := len(segments) - 1
+		segments[last-1] += "`" + segments[last]
+		segments = segments[:last]
+	}
+
+	for i, segment := range segments {
+		escaped := template.HTMLEscapeString(segment)
+		if i%2 == 1 {
+			out.WriteString("<code>" + escaped + "</code>")
+			continue
+		}
+
+		// Links are swapped for placeholders so emphasis cannot alter their URL
+		var links []string
+		escaped = markdownLink.ReplaceAllStringFunc(escaped, func(match string) string {
+			parts := markdownLink.FindStringSubmatch(match)
+			links = append(links, `<a href="`+parts[2]+`" rel="noopener noreferrer" target="_blank">`+parts[1]+`</a>`)
+			return "\x00" + strconv.Itoa(len(links)-1) + "\x00"
+		})
+
+		escaped = markdownBold.ReplaceAllString(escaped, "<strong>$1</strong>")
+		escaped = markdownItalic.ReplaceAllString(escaped, "<em>$1</em>")
+
+		for index, link := range links {
+			escaped = strings.Replace(escaped, "\x00"+strconv.Itoa(index)+"\x00", link, 1)
+		}
+		out.WriteString(escaped)
+	}
+
+	return out.String()
+}
@@ -16,6 +16,19 @@ func AuthMiddleware() gin.HandlerFunc {
 
 // AuthMiddlewareWithKeys creates HTTP Basic Auth middleware with admin API key validation
 func AuthMiddlewareWithKeys(adminAPIKeys []string) gin.HandlerFunc {
+	return AuthMiddlewareWithValidator(func(password string) bool {
+		for _, key := range adminAPIKeys {
+			if password == key {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// AuthMiddlewareWithValidator creates HTTP Basic Auth middleware that accepts
+// any password for which isValidKey returns true
+func AuthMiddlewareWithValidator(isValidKey func(string) bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get Authorization header
 		auth := c.GetHeader("Authorization")
@@ -41,15 +54,7 @@ func AuthMiddlewareWithKeys(adminAPIKeys []string) gin.HandlerFunc {
 		}
 
 		// Validate password against admin API keys (username can be anything)
-		validKey := false
-		for _, key := range adminAPIKeys {
-			if password == key {
-				validKey = true
-				break
-			}
-		}
-
-		if !validKey {
+		if !isValidKey(password) {
 			c.Header("WWW-Authenticate", `Basic realm="Dashboard"`)
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 			return
@@ -61,6 +66,18 @@ func AuthMiddlewareWithKeys(adminAPIKeys []string) gin.HandlerFunc {
 	}
 }
 
+// ReadOnlyMiddleware refuses the routes it guards when the dashboard is
+// read-only
+func ReadOnlyMiddleware(readOnly bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if readOnly {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "The dashboard is read-only"})
+			return
+		}
+		c.Next()
+	}
+}
+
 // CORSMiddleware handles CORS headers for dashboard
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
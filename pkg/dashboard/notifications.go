@@ -0,0 +1,37 @@
+package dashboard
+
+import (
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// notificationSeverities are the severities of jobs for browser
+// notifications, lowest first
+var notificationSeverities = []string{"info", "warning", "critical"}
+
+// defaultSeverity is the severity of jobs without a known severity label
+const defaultSeverity = "warning"
+
+// jobSeverity returns the severity of a job, from its severity label
+func jobSeverity(config *config.DashboardConfig, job *model.Job) string {
+	severity := strings.ToLower(job.Labels[config.NotificationSeverityLabel])
+	for _, known := range notificationSeverities {
+		if severity == known {
+			return severity
+		}
+	}
+	return defaultSeverity
+}
+
+// notifiedSeverities returns the severities browser notifications are
+// shown for, comma separated: the minimum severity and those above it
+func notifiedSeverities(config *config.DashboardConfig) string {
+	for i, severity := range notificationSeverities {
+		if severity == config.NotificationMinSeverity {
+			return strings.Join(notificationSeverities[i:], ",")
+		}
+	}
+	return strings.Join(notificationSeverities, ",")
+}
@@ -0,0 +1,26 @@
+package dashboard
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Problems displays the jobs and hosts that need attention, the most
+// urgent first
+func (h *Handler) Problems(c *gin.Context) {
+	problems, err := h.collector.Problems(c.Request.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list problems")
+		c.String(http.StatusInternalServerError, "Failed to load problems")
+		return
+	}
+
+	data := gin.H{
+		"Title":    h.config.Title,
+		"Config":   h.config,
+		"Problems": problems,
+	}
+
+	h.renderPage(c, http.StatusOK, "problems.html", data)
+}
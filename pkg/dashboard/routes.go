@@ -8,36 +8,79 @@ import (
 )
 
 // SetupRoutes configures all dashboard routes
-func SetupRoutes(router *gin.Engine, config *config.DashboardConfig, handler *Handler, adminAPIKeys []string) {
+func SetupRoutes(router *gin.Engine, config *config.DashboardConfig, handler *Handler, isValidAdminKey func(string) bool) {
 	// Static assets (no authentication required)
 	router.GET("/assets/*filepath", handler.ServeAssets)
 
+	// Signed action links carry their own authorization
+	router.GET("/actions/:action", handler.ActionLinkConfirm)
+	router.POST("/actions/:action", handler.ActionLinkExecute)
+
 	// Create protected route group for authenticated routes
 	var protectedRoutes gin.IRoutes = router
 	if config.AuthRequired {
 		authGroup := router.Group("/")
-		authGroup.Use(AuthMiddlewareWithKeys(adminAPIKeys))
+		authGroup.Use(AuthMiddlewareWithValidator(isValidAdminKey))
 		protectedRoutes = authGroup
 	}
 
+	// Routes that change jobs or keys are refused on read-only dashboards
+	readOnly := ReadOnlyMiddleware(config.ReadOnly)
+
 	// Main dashboard pages (protected)
 	protectedRoutes.GET("/", handler.RedirectToDashboard)
 	protectedRoutes.GET("/jobs", handler.JobsList)
-	protectedRoutes.GET("/jobs/new", handler.JobCreateForm)
-	protectedRoutes.POST("/jobs", handler.JobCreate)
+	protectedRoutes.GET("/jobs/new", readOnly, handler.JobCreateForm)
+	protectedRoutes.POST("/jobs", readOnly, handler.JobCreate)
+	protectedRoutes.POST("/jobs/labels", readOnly, handler.JobsLabelBulk)
+	protectedRoutes.POST("/jobs/columns", handler.JobColumnsSave)
 	protectedRoutes.GET("/jobs/:id", handler.JobDetail)
-	protectedRoutes.GET("/jobs/:id/edit", handler.JobEditForm)
-	protectedRoutes.PUT("/jobs/:id", handler.JobUpdate)  // For API usage
-	protectedRoutes.POST("/jobs/:id", handler.JobUpdate) // For HTML forms
-	protectedRoutes.DELETE("/jobs/:id", handler.JobDelete)
-	protectedRoutes.POST("/jobs/:id/delete", handler.JobDelete) // For HTML delete forms
+	protectedRoutes.GET("/jobs/:id/edit", readOnly, handler.JobEditForm)
+	protectedRoutes.GET("/jobs/:id/duplicate", readOnly, handler.JobDuplicateForm)
+	protectedRoutes.PUT("/jobs/:id", readOnly, handler.JobUpdate)  // For API usage
+	protectedRoutes.POST("/jobs/:id", readOnly, handler.JobUpdate) // For HTML forms
+	protectedRoutes.DELETE("/jobs/:id", readOnly, handler.JobDelete)
+	protectedRoutes.POST("/jobs/:id/delete", readOnly, handler.JobDelete) // For HTML delete forms
+	protectedRoutes.POST("/jobs/:id/rotate-key", readOnly, handler.JobRotateKey)
+	protectedRoutes.POST("/jobs/:id/annotations", readOnly, handler.JobAnnotate)
+	protectedRoutes.POST("/jobs/:id/acknowledge", readOnly, handler.JobAcknowledge)
+	protectedRoutes.POST("/jobs/:id/rules", readOnly, handler.JobRuleCreate)
+	protectedRoutes.POST("/jobs/:id/rules/:rule/delete", readOnly, handler.JobRuleDelete)
+	protectedRoutes.POST("/jobs/:id/check", handler.JobCheck)
+	protectedRoutes.GET("/jobs/:id/history", handler.JobHistory)
+	protectedRoutes.POST("/jobs/:id/history/:version/rollback", readOnly, handler.JobRollback)
+	protectedRoutes.POST("/jobs/:id/labels", readOnly, handler.JobLabelSet)
+	protectedRoutes.POST("/jobs/:id/labels/remove", readOnly, handler.JobLabelRemove)
+	protectedRoutes.GET("/hosts", handler.HostsList)
+	protectedRoutes.GET("/hosts/:host", handler.HostDetail)
+	protectedRoutes.GET("/problems", handler.Problems)
+	protectedRoutes.GET("/kiosk", handler.Kiosk)
+	protectedRoutes.GET("/kiosk/tiles", handler.KioskTiles)
+
+	// Administration pages (protected)
+	protectedRoutes.GET("/admin/keys", handler.AdminKeys)
+	protectedRoutes.POST("/admin/keys", readOnly, handler.AdminKeyCreate)
+	protectedRoutes.POST("/admin/keys/:id/revoke", readOnly, handler.AdminKeyRevoke)
+	protectedRoutes.GET("/admin/settings", handler.AdminSettings)
+	protectedRoutes.GET("/admin/audit", handler.AdminAudit)
+	protectedRoutes.GET("/admin/rejections", handler.AdminRejections)
+	protectedRoutes.GET("/admin/notifications", handler.AdminNotifications)
+	protectedRoutes.POST("/admin/notifications/:id/redeliver", readOnly, handler.AdminNotificationRedeliver)
+	protectedRoutes.GET("/admin/channels", handler.AdminChannels)
+	protectedRoutes.POST("/admin/channels", readOnly, handler.AdminChannelCreate)
+	protectedRoutes.GET("/admin/channels/:id", readOnly, handler.AdminChannelEdit)
+	protectedRoutes.POST("/admin/channels/:id", readOnly, handler.AdminChannelUpdate)
+	protectedRoutes.POST("/admin/channels/:id/delete", readOnly, handler.AdminChannelDelete)
 
 	// HTMX endpoints for dynamic updates (protected)
 	protectedRoutes.GET("/api/jobs", handler.JobsListAPI)
 	protectedRoutes.GET("/api/jobs/:id/status", handler.JobStatusAPI)
 	protectedRoutes.GET("/api/jobs/search", handler.JobSearchAPI)
 	protectedRoutes.GET("/api/jobs/search-paginated", handler.JobSearchWithPagination)
-	protectedRoutes.POST("/jobs/:id/toggle", handler.JobToggle)
+	protectedRoutes.GET("/api/jobs/exists", handler.JobExistsAPI)
+	protectedRoutes.GET("/api/labels", handler.LabelsAPI)
+	protectedRoutes.GET("/api/hosts", handler.HostsAPI)
+	protectedRoutes.POST("/jobs/:id/toggle", readOnly, handler.JobToggle)
 	protectedRoutes.GET("/jobs/search", handler.JobSearch)
 
 	// Server-sent events for real-time updates (protected)
@@ -0,0 +1,120 @@
+package dashboard
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// JobRuleCreate adds a status rule to a job
+func (h *Handler) JobRuleCreate(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.jobStore.GetJobByID(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to get job for status rule")
+		c.String(http.StatusNotFound, "Job not found")
+		return
+	}
+
+	rule := &model.StatusRule{
+		JobName:     job.Name,
+		Host:        job.Host,
+		Pattern:     c.PostForm("pattern"),
+		Status:      c.PostForm("status"),
+		Description: strings.TrimSpace(c.PostForm("description")),
+		Author:      c.GetString("auth_user"),
+	}
+	if rule.Author == "" {
+		rule.Author = "dashboard"
+	}
+	if exitCode := strings.TrimSpace(c.PostForm("exit_code")); exitCode != "" {
+		code, err := strconv.Atoi(exitCode)
+		if err != nil {
+			h.setFlash(c, flashError, "Exit code must be a number")
+			c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
+			return
+		}
+		rule.ExitCode = &code
+	}
+
+	if err := rule.Validate(); err != nil {
+		h.setFlash(c, flashError, "Invalid status rule: "+err.Error())
+		c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
+		return
+	}
+	if err := h.ruleStore.CreateRule(rule); err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to create status rule")
+		h.setFlash(c, flashError, "Failed to save the status rule")
+		c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"job_id":   job.ID,
+		"job_name": job.Name,
+		"host":     job.Host,
+		"rule_id":  rule.ID,
+	}).Info("Status rule added via dashboard")
+
+	h.recordAudit(c, "job.rule.create", "job:"+idStr, "rule "+strconv.Itoa(rule.ID)+": "+ruleSummary(rule))
+
+	h.setFlash(c, flashSuccess, "Status rule added")
+	c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
+}
+
+// JobRuleDelete removes one of a job's status rules
+func (h *Handler) JobRuleDelete(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+	ruleID, err := strconv.Atoi(c.Param("rule"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid rule ID")
+		return
+	}
+
+	job, err := h.jobStore.GetJobByID(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to get job for status rule")
+		c.String(http.StatusNotFound, "Job not found")
+		return
+	}
+
+	if err := h.ruleStore.DeleteRule(ruleID, job.Name, job.Host); err != nil {
+		h.logger.WithError(err).WithField("job_id", id).Error("Failed to delete status rule")
+		h.setFlash(c, flashError, "Failed to delete the status rule")
+		c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
+		return
+	}
+
+	h.recordAudit(c, "job.rule.delete", "job:"+idStr, "rule "+strconv.Itoa(ruleID))
+
+	h.setFlash(c, flashSuccess, "Status rule deleted")
+	c.Redirect(http.StatusFound, h.config.Path+"/jobs/"+idStr)
+}
+
+// ruleSummary describes a status rule in the audit log, e.g.
+// `output matches "0 rows copied" -> failure`
+func ruleSummary(rule *model.StatusRule) string {
+	var conditions []string
+	if rule.Pattern != "" {
+		conditions = append(conditions, "output matches "+strconv.Quote(rule.Pattern))
+	}
+	if rule.ExitCode != nil {
+		conditions = append(conditions, "exit code "+strconv.Itoa(*rule.ExitCode))
+	}
+	return strings.Join(conditions, " and ") + " -> " + rule.Status
+}
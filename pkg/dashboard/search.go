@@ -0,0 +1,260 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/util"
+)
+
+// searchTimeLayouts are the accepted formats for the before/after filters:
+// RFC 3339 for links and API clients, datetime-local (UTC) for the form
+var searchTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04"}
+
+// searchWithinOptions are the preset time ranges offered by the search form
+var searchWithinOptions = []struct {
+	Value string
+	Label string
+}{
+	{"1h", "Last hour"},
+	{"24h", "Last 24 hours"},
+	{"168h", "Last 7 days"},
+	{"720h", "Last 30 days"},
+}
+
+// searchStaleOptions are the preset "not reported for" durations offered
+// by the search form
+var searchStaleOptions = []struct {
+	Value string
+	Label string
+}{
+	{"1h", "Over an hour"},
+	{"24h", "Over 24 hours"},
+	{"168h", "Over 7 days"},
+	{"720h", "Over 30 days"},
+}
+
+// parseSearchTime parses a before/after filter value
+func parseSearchTime(value string) (*time.Time, bool) {
+	for _, layout := range searchTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return &t, true
+		}
+	}
+	return nil, false
+}
+
+// parseSearchCriteria builds job search criteria from the request query parameters
+func parseSearchCriteria(c *gin.Context) *model.JobSearchCriteria {
+	criteria := &model.JobSearchCriteria{
+		Query: c.Query("q"),
+		Name:  c.Query("name"),
+		Host:  c.Query("host"),
+	}
+
+	// Status chips may select several statuses
+	for _, status := range c.QueryArray("status") {
+		if status != "" {
+			criteria.Statuses = append(criteria.Statuses, status)
+		}
+	}
+
+	// Parse pagination parameters
+	if pageStr := c.Query("page"); pageStr != "" {
+		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
+			criteria.Page = page
+		}
+	}
+	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
+		if pageSize, err := strconv.Atoi(pageSizeStr); err == nil && pageSize > 0 && pageSize <= 100 {
+			criteria.PageSize = pageSize
+		}
+	}
+
+	// Unknown sort options fall back to the default order
+	if sortBy := c.Query("sort_by"); model.IsValidJobSortField(sortBy) {
+		criteria.SortBy = sortBy
+		if sortDir := c.Query("sort_dir"); sortDir == "asc" || sortDir == "desc" {
+			criteria.SortDir = sortDir
+		}
+	}
+
+	// Parse time-based filters; a preset range takes precedence over "after"
+	if before, ok := parseSearchTime(c.Query("before")); ok {
+		criteria.LastReportedBefore = before
+	}
+	if within, err := util.ParseDuration(c.Query("within")); err == nil && within > 0 {
+		criteria.LastReportedWithin = within
+	} else if after, ok := parseSearchTime(c.Query("after")); ok {
+		criteria.LastReportedAfter = after
+	}
+	if stale, err := util.ParseDuration(c.Query("stale")); err == nil && stale > 0 {
+		criteria.NotReportedFor = stale
+	}
+
+	// Parse label filters (JSON format: {"key1":"value1","key2":"value2"}), kept for existing links
+	if labelsStr := c.Query("labels"); labelsStr != "" {
+		var labels map[string]string
+		if err := json.Unmarshal([]byte(labelsStr), &labels); err == nil {
+			criteria.Labels = labels
+		}
+	}
+
+	// Label filter rows from the search form, paired by position
+	keys, values := c.QueryArray("label_key"), c.QueryArray("label_value")
+	for i, key := range keys {
+		if key == "" || i >= len(values) || values[i] == "" {
+			continue
+		}
+		if criteria.Labels == nil {
+			criteria.Labels = make(map[string]string)
+		}
+		criteria.Labels[key] = values[i]
+	}
+
+	return criteria
+}
+
+// encodeSearchCriteria serializes search criteria into the query parameters
+// understood by parseSearchCriteria
+func encodeSearchCriteria(criteria *model.JobSearchCriteria) url.Values {
+	params := url.Values{}
+	if criteria == nil {
+		return params
+	}
+
+	if criteria.Query != "" {
+		params.Set("q", criteria.Query)
+	}
+	if criteria.Name != "" {
+		params.Set("name", criteria.Name)
+	}
+	if criteria.Host != "" {
+		params.Set("host", criteria.Host)
+	}
+	if criteria.Status != "" {
+		params.Add("status", criteria.Status)
+	}
+	for _, status := range criteria.Statuses {
+		params.Add("status", status)
+	}
+	if criteria.LastReportedAfter != nil {
+		params.Set("after", criteria.LastReportedAfter.UTC().Format(time.RFC3339))
+	}
+	if criteria.LastReportedBefore != nil {
+		params.Set("before", criteria.LastReportedBefore.UTC().Format(time.RFC3339))
+	}
+	if criteria.LastReportedWithin > 0 {
+		params.Set("within", util.FormatDuration(criteria.LastReportedWithin))
+	}
+	if criteria.NotReportedFor > 0 {
+		params.Set("stale", util.FormatDuration(criteria.NotReportedFor))
+	}
+
+	keys := make([]string, 0, len(criteria.Labels))
+	for key := range criteria.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		params.Add("label_key", key)
+		params.Add("label_value", criteria.Labels[key])
+	}
+
+	if criteria.SortBy != "" {
+		params.Set("sort_by", criteria.SortBy)
+		if criteria.SortDir != "" {
+			params.Set("sort_dir", criteria.SortDir)
+		}
+	}
+
+	if criteria.PageSize > 0 {
+		params.Set("page_size", strconv.Itoa(criteria.PageSize))
+	}
+
+	return params
+}
+
+// LabelsAPI returns label keys and values in use, for the search form pickers
+func (h *Handler) LabelsAPI(c *gin.Context) {
+	labels, err := h.jobStore.ListLabelUsage()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list labels")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load labels"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"labels": labels})
+}
+
+// HostsAPI returns the distinct hosts running jobs, for the search form pickers
+func (h *Handler) HostsAPI(c *gin.Context) {
+	hosts, err := h.jobStore.ListHosts()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list hosts")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load hosts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"hosts": hosts})
+}
+
+// sortQueryHelper builds the URL query string for a sortable column header:
+// the current filters sorted by field, toggling the direction if the results
+// are already sorted by it
+func sortQueryHelper(criteria *model.JobSearchCriteria, field string) template.URL {
+	params := encodeSearchCriteria(criteria)
+	params.Set("sort_by", field)
+	params.Set("sort_dir", "asc")
+	if criteria != nil && criteria.SortBy == field && criteria.SortDir != "desc" {
+		params.Set("sort_dir", "desc")
+	}
+
+	return template.URL(params.Encode())
+}
+
+// sortIndicatorHelper returns the arrow shown next to the sorted column header
+func sortIndicatorHelper(criteria *model.JobSearchCriteria, field string) string {
+	if criteria == nil || criteria.SortBy != field {
+		return ""
+	}
+	if criteria.SortDir == "desc" {
+		return "▼"
+	}
+	return "▲"
+}
+
+// JobExistsAPI reports whether a job with the given name and host exists, for
+// inline validation of the job form
+func (h *Handler) JobExistsAPI(c *gin.Context) {
+	name, host := c.Query("name"), c.Query("host")
+	if name == "" || host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and host are required"})
+		return
+	}
+
+	job, err := h.jobStore.GetJob(name, host)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusOK, gin.H{"exists": false})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to look up job")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up job"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exists": true,
+		"id":     job.ID,
+		"url":    h.config.Path + "/jobs/" + strconv.Itoa(job.ID),
+	})
+}
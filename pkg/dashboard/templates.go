@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"html/template"
 	"io"
-	"net/url"
 	"regexp"
 	"time"
 
@@ -60,29 +59,7 @@ func NewTemplateManager(config *config.DashboardConfig) *TemplateManager {
 			if !ok {
 				return "unknown"
 			}
-
-			// Jobs in maintenance or paused status
-			if jobData.Status == "maintenance" || jobData.Status == "paused" {
-				return "inactive"
-			}
-
-			now := time.Now().UTC()
-			timeSinceLastReport := now.Sub(jobData.LastReportedAt)
-			thresholdDuration := time.Duration(jobData.AutomaticFailureThreshold) * time.Second
-
-			// Missed deadline
-			if timeSinceLastReport > thresholdDuration {
-				return "danger"
-			}
-
-			// Approaching deadline (80% of threshold)
-			warningThreshold := time.Duration(float64(jobData.AutomaticFailureThreshold)*0.8) * time.Second
-			if timeSinceLastReport > warningThreshold {
-				return "warning"
-			}
-
-			// On time
-			return "success"
+			return deadlineStatus(jobData, time.Now().UTC())
 		},
 		"deadlineStatusText": func(job interface{}) string {
 			// Convert interface{} to Job struct
@@ -100,22 +77,38 @@ func NewTemplateManager(config *config.DashboardConfig) *TemplateManager {
 			}
 
 			now := time.Now().UTC()
-			timeSinceLastReport := now.Sub(jobData.LastReportedAt)
-			thresholdDuration := time.Duration(jobData.AutomaticFailureThreshold) * time.Second
-
-			// Missed deadline
-			if timeSinceLastReport > thresholdDuration {
+			switch deadlineStatus(jobData, now) {
+			case "danger":
 				return "Deadline Missed"
-			}
-
-			// Approaching deadline (80% of threshold)
-			warningThreshold := time.Duration(float64(jobData.AutomaticFailureThreshold)*0.8) * time.Second
-			if timeSinceLastReport > warningThreshold {
+			case "inactive":
+				return "Deadline Missed (Snoozed)"
+			case "warning":
 				return "Deadline Approaching"
+			default:
+				return "On Time"
 			}
-
-			// On time
-			return "On Time"
+		},
+		"maskKey": func(key string) string {
+			if len(key) <= 10 {
+				return "***"
+			}
+			return key[:6] + "..." + key[len(key)-4:]
+		},
+		"hasString": func(values []string, value string) bool {
+			for _, v := range values {
+				if v == value {
+					return true
+				}
+			}
+			return false
+		},
+		"sortQuery":     sortQueryHelper,
+		"sortIndicator": sortIndicatorHelper,
+		"inputTime": func(t *time.Time) string {
+			if t == nil {
+				return ""
+			}
+			return t.UTC().Format("2006-01-02T15:04")
 		},
 		"truncate": func(s string, length int) string {
 			if len(s) <= length {
@@ -130,6 +123,10 @@ func NewTemplateManager(config *config.DashboardConfig) *TemplateManager {
 			}
 			return string(bytes)
 		},
+		"percent": func(ratio float64) string {
+			return fmt.Sprintf("%.0f%%", ratio*100)
+		},
+		"markdown": renderMarkdown,
 	}
 
 	// Create template with functions
@@ -184,29 +181,7 @@ func LoadTemplates() *template.Template {
 			if !ok {
 				return "unknown"
 			}
-
-			// Jobs in maintenance or paused status
-			if jobData.Status == "maintenance" || jobData.Status == "paused" {
-				return "inactive"
-			}
-
-			now := time.Now().UTC()
-			timeSinceLastReport := now.Sub(jobData.LastReportedAt)
-			thresholdDuration := time.Duration(jobData.AutomaticFailureThreshold) * time.Second
-
-			// Missed deadline
-			if timeSinceLastReport > thresholdDuration {
-				return "danger"
-			}
-
-			// Approaching deadline (80% of threshold)
-			warningThreshold := time.Duration(float64(jobData.AutomaticFailureThreshold)*0.8) * time.Second
-			if timeSinceLastReport > warningThreshold {
-				return "warning"
-			}
-
-			// On time
-			return "success"
+			return deadlineStatus(jobData, time.Now().UTC())
 		},
 		"deadlineStatusText": func(job interface{}) string {
 			// Convert interface{} to Job struct
@@ -224,22 +199,38 @@ func LoadTemplates() *template.Template {
 			}
 
 			now := time.Now().UTC()
-			timeSinceLastReport := now.Sub(jobData.LastReportedAt)
-			thresholdDuration := time.Duration(jobData.AutomaticFailureThreshold) * time.Second
-
-			// Missed deadline
-			if timeSinceLastReport > thresholdDuration {
+			switch deadlineStatus(jobData, now) {
+			case "danger":
 				return "Deadline Missed"
-			}
-
-			// Approaching deadline (80% of threshold)
-			warningThreshold := time.Duration(float64(jobData.AutomaticFailureThreshold)*0.8) * time.Second
-			if timeSinceLastReport > warningThreshold {
+			case "inactive":
+				return "Deadline Missed (Snoozed)"
+			case "warning":
 				return "Deadline Approaching"
+			default:
+				return "On Time"
 			}
-
-			// On time
-			return "On Time"
+		},
+		"maskKey": func(key string) string {
+			if len(key) <= 10 {
+				return "***"
+			}
+			return key[:6] + "..." + key[len(key)-4:]
+		},
+		"hasString": func(values []string, value string) bool {
+			for _, v := range values {
+				if v == value {
+					return true
+				}
+			}
+			return false
+		},
+		"sortQuery":     sortQueryHelper,
+		"sortIndicator": sortIndicatorHelper,
+		"inputTime": func(t *time.Time) string {
+			if t == nil {
+				return ""
+			}
+			return t.UTC().Format("2006-01-02T15:04")
 		},
 		"truncate": func(s string, length int) string {
 			if len(s) <= length {
@@ -291,6 +282,10 @@ func LoadTemplates() *template.Template {
 		"eq": func(a, b interface{}) bool {
 			return a == b
 		},
+		"percent": func(ratio float64) string {
+			return fmt.Sprintf("%.0f%%", ratio*100)
+		},
+		"markdown": renderMarkdown,
 	}
 
 	// Create template with functions
@@ -316,6 +311,34 @@ func (tm *TemplateManager) RenderPartial(w io.Writer, name string, data interfac
 }
 
 // formatDuration helper function for timeAgo
+// deadlineStatus returns the deadline status of a job at now: "danger"
+// when it missed its deadline, "warning" when within the last 20% of its
+// threshold before it, "success" when on time, and "inactive" in
+// maintenance, paused or snoozed
+func deadlineStatus(job *model.Job, now time.Time) string {
+	// Jobs in maintenance or paused status
+	if job.Status == "maintenance" || job.Status == "paused" {
+		return "inactive"
+	}
+
+	// Missed deadline, from the job's schedule or threshold
+	if job.MissedDeadline(now) {
+		if job.IsSnoozed(now) {
+			return "inactive"
+		}
+		return "danger"
+	}
+
+	// Approaching deadline (last 20% of threshold)
+	warningMargin := time.Duration(float64(job.AutomaticFailureThreshold)*0.2) * time.Second
+	if now.After(job.Deadline().Add(-warningMargin)) {
+		return "warning"
+	}
+
+	// On time
+	return "success"
+}
+
 func formatDuration(d time.Duration, unit string) string {
 	var value int64
 	switch unit {
@@ -366,26 +389,9 @@ func highlightTextHelperString(text, query string) string {
 
 // buildSearchQueryHelper builds URL query string for pagination links
 func buildSearchQueryHelper(criteria interface{}, page int) string {
-	params := url.Values{}
+	crit, _ := criteria.(*model.JobSearchCriteria)
+	params := encodeSearchCriteria(crit)
 	params.Set("page", fmt.Sprintf("%d", page))
 
-	if crit, ok := criteria.(*model.JobSearchCriteria); ok && crit != nil {
-		if crit.Query != "" {
-			params.Set("q", crit.Query)
-		}
-		if crit.Name != "" {
-			params.Set("name", crit.Name)
-		}
-		if crit.Host != "" {
-			params.Set("host", crit.Host)
-		}
-		if crit.Status != "" {
-			params.Set("status", crit.Status)
-		}
-		if crit.PageSize > 0 {
-			params.Set("page_size", fmt.Sprintf("%d", crit.PageSize))
-		}
-	}
-
 	return params.Encode()
 }
@@ -0,0 +1,173 @@
+// Package demo generates realistic jobs and result histories, so the
+// dashboard and metrics can be explored without wiring real cron jobs
+package demo
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// Options control the generated data
+type Options struct {
+	Jobs    int           // Number of jobs
+	History time.Duration // Span of result history before Now
+	Seed    int64         // Random seed; the same seed generates the same data
+	Now     time.Time     // End of the history, defaults to the current time
+}
+
+// jobTemplate describes a kind of job
+type jobTemplate struct {
+	name     string
+	hosts    []string
+	kind     string        // "type" label
+	team     string        // "team" label
+	interval time.Duration // Time between runs
+	duration int           // Typical run time in seconds
+	errors   []string      // Output of failed runs
+}
+
+var templates = []jobTemplate{
+	{"backup", []string{"db1", "db2", "db3"}, "backup", "data", 24 * time.Hour, 900,
+		[]string{"pg_dump: error: connection to server failed", "upload failed: No space left on device"}},
+	{"db-vacuum", []string{"db1", "db2", "db3"}, "maintenance", "data", 6 * time.Hour, 240,
+		[]string{"ERROR: canceling statement due to lock timeout"}},
+	{"log-rotation", []string{"web1", "web2", "web3", "app1", "app2"}, "maintenance", "platform", 24 * time.Hour, 20,
+		[]string{"logrotate: error: failed to rename /var/log/app.log"}},
+	{"cert-renew", []string{"web1", "web2"}, "security", "platform", 12 * time.Hour, 35,
+		[]string{"certbot: Challenge failed for domain example.com"}},
+	{"report-daily", []string{"app1", "app2"}, "report", "web", 24 * time.Hour, 600,
+		[]string{"Traceback (most recent call last):\n  File \"report.py\", line 42\nKeyError: 'revenue'"}},
+	{"cache-warmup", []string{"web1", "web2", "web3"}, "maintenance", "web", time.Hour, 45,
+		[]string{"curl: (28) Operation timed out after 30000 milliseconds"}},
+	{"index-rebuild", []string{"search1", "search2"}, "maintenance", "data", 6 * time.Hour, 1800,
+		[]string{"OutOfMemoryError: Java heap space"}},
+	{"metrics-rollup", []string{"app1", "app2", "app3"}, "report", "platform", 15 * time.Minute, 12,
+		[]string{"rollup: upstream returned 503"}},
+	{"mail-digest", []string{"app1"}, "report", "web", 24 * time.Hour, 120,
+		[]string{"smtp: 421 Service not available"}},
+	{"sync-inventory", []string{"app2", "app3"}, "sync", "web", time.Hour, 90,
+		[]string{"sync: 1284 records rejected: duplicate key"}},
+}
+
+// Behaviour profiles, picked per job
+const (
+	profileHealthy  = "healthy"  // Rare failures
+	profileFlapping = "flapping" // Alternates between success and failure
+	profileFailing  = "failing"  // Healthy until recently, failing since
+	profileStale    = "stale"    // Stopped reporting, so it misses its deadline
+)
+
+// profiles weighs the behaviours: most jobs are healthy
+var profiles = []string{
+	profileHealthy, profileHealthy, profileHealthy, profileHealthy, profileHealthy,
+	profileHealthy, profileFlapping, profileFlapping, profileFailing, profileStale,
+}
+
+// Generate returns opts.Jobs jobs and their results over opts.History,
+// oldest result first. Each job's last report is the time of its last
+// result; a few jobs are in maintenance or paused.
+func Generate(opts Options) ([]*model.Job, []*model.JobResult) {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	var jobs []*model.Job
+	var results []*model.JobResult
+	for i := 0; i < opts.Jobs; i++ {
+		tmpl := templates[i%len(templates)]
+		round := i / len(templates)
+		host := tmpl.hosts[round%len(tmpl.hosts)]
+		name := tmpl.name
+		if extra := round / len(tmpl.hosts); extra > 0 {
+			name = fmt.Sprintf("%s-%d", tmpl.name, extra+1)
+		}
+
+		env := "prod"
+		if rng.Intn(4) == 0 {
+			env = "staging"
+		}
+		status := "active"
+		switch rng.Intn(20) {
+		case 0:
+			status = "maintenance"
+		case 1:
+			status = "paused"
+		}
+
+		job := &model.Job{
+			Name:                      name,
+			Host:                      host,
+			AutomaticFailureThreshold: int(2 * tmpl.interval / time.Second),
+			Labels:                    map[string]string{"env": env, "team": tmpl.team, "type": tmpl.kind},
+			Status:                    status,
+			LastReportedAt:            now.Add(-opts.History),
+		}
+
+		jobResults := history(rng, &tmpl, job, profiles[rng.Intn(len(profiles))], now, opts.History)
+		if len(jobResults) > 0 {
+			job.LastReportedAt = jobResults[len(jobResults)-1].Timestamp
+		}
+
+		jobs = append(jobs, job)
+		results = append(results, jobResults...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Timestamp.Before(results[j].Timestamp)
+	})
+	return jobs, results
+}
+
+// history generates the results of one job
+func history(rng *rand.Rand, tmpl *jobTemplate, job *model.Job, profile string, now time.Time, span time.Duration) []*model.JobResult {
+	end := now
+	if profile == profileStale {
+		// Silent for between two and four intervals
+		end = now.Add(-tmpl.interval * time.Duration(2+rng.Intn(3)))
+	}
+	failingSince := now.Add(-tmpl.interval * time.Duration(1+rng.Intn(4)))
+
+	// Runs start at a random offset within the first interval
+	start := now.Add(-span).Add(time.Duration(rng.Int63n(int64(tmpl.interval))))
+
+	var results []*model.JobResult
+	for at := start; !at.After(end); at = at.Add(tmpl.interval) {
+		failed := false
+		switch profile {
+		case profileHealthy, profileStale:
+			failed = rng.Float64() < 0.02
+		case profileFlapping:
+			failed = rng.Float64() < 0.35
+		case profileFailing:
+			failed = !at.Before(failingSince) || rng.Float64() < 0.02
+		}
+
+		// Durations vary by up to 50% around the typical run time
+		duration := tmpl.duration/2 + rng.Intn(tmpl.duration+1)
+		result := &model.JobResult{
+			JobName:   job.Name,
+			Host:      job.Host,
+			Status:    "success",
+			Duration:  duration,
+			Output:    fmt.Sprintf("%s completed in %ds", tmpl.name, duration),
+			Timestamp: at.Add(time.Duration(duration) * time.Second),
+		}
+		if failed {
+			result.Status = "failure"
+			result.Output = tmpl.errors[rng.Intn(len(tmpl.errors))]
+		}
+		if result.Timestamp.After(now) {
+			break
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// execOutputLimit is the number of bytes of a failed command's output that
+// are logged
+const execOutputLimit = 1024
+
+// execWaitDelay bounds how long a killed command's children may keep its
+// output open
+const execWaitDelay = 5 * time.Second
+
+// execPublisher runs a local command for each event, with the event on
+// stdin and its type in CRONMETRICS_EVENT_TYPE, so site-specific
+// integrations need no changes to the server. Commands run in the
+// background, at most concurrency at once; failures are logged.
+type execPublisher struct {
+	command []string
+	timeout time.Duration
+	slots   chan struct{}
+	running sync.WaitGroup
+}
+
+// newExecPublisher returns a publisher running command, a program and its
+// arguments, without a shell
+func newExecPublisher(command []string, timeout time.Duration, concurrency int) *execPublisher {
+	return &execPublisher{
+		command: command,
+		timeout: timeout,
+		slots:   make(chan struct{}, max(concurrency, 1)),
+	}
+}
+
+// Publish starts the command once one of the running ones is done, or
+// gives up when ctx ends first
+func (p *execPublisher) Publish(ctx context.Context, event *model.ChangeEvent, data []byte) error {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return fmt.Errorf("%d commands still running: %w", cap(p.slots), ctx.Err())
+	}
+
+	p.running.Add(1)
+	go func() {
+		defer func() {
+			<-p.slots
+			p.running.Done()
+		}()
+		p.run(event.Type, data)
+	}()
+	return nil
+}
+
+// run runs the command for one event until it exits or times out
+func (p *execPublisher) run(eventType string, data []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.command[0], p.command[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(), "CRONMETRICS_EVENT_TYPE="+eventType)
+	cmd.WaitDelay = execWaitDelay
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", p.timeout)
+	}
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"type":    eventType,
+			"command": p.command[0],
+			"output":  strings.TrimSpace(string(output.Bytes()[:min(output.Len(), execOutputLimit)])),
+		}).Error("event command failed")
+	}
+}
+
+// Close waits for the running commands to end
+func (p *execPublisher) Close() error {
+	p.running.Wait()
+	return nil
+}
@@ -0,0 +1,101 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// publishTimeout bounds how long a single event may take to publish
+const publishTimeout = 10 * time.Second
+
+// Publisher delivers encoded events to a message broker
+type Publisher interface {
+	Publish(ctx context.Context, event *model.ChangeEvent, data []byte) error
+	Close() error
+}
+
+// NewPublisher connects to the broker selected by the events configuration
+func NewPublisher(cfg *config.EventsConfig) (Publisher, error) {
+	switch cfg.Driver {
+	case "nats":
+		return newNATSPublisher(cfg.URL, cfg.Subject)
+	case "kafka":
+		return newKafkaPublisher(cfg.Brokers, cfg.Topic), nil
+	case "exec":
+		return newExecPublisher(cfg.Command, time.Duration(cfg.Timeout)*time.Second, cfg.Concurrency), nil
+	default:
+		return nil, fmt.Errorf("unsupported events driver: %s", cfg.Driver)
+	}
+}
+
+// Exporter publishes job and result change events in the background, so
+// API requests never wait on the broker. Events that do not fit in the
+// queue are dropped and logged.
+type Exporter struct {
+	publisher Publisher
+	queue     chan model.ChangeEvent
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewExporter starts an exporter publishing through publisher
+func NewExporter(publisher Publisher, bufferSize int) *Exporter {
+	e := &Exporter{
+		publisher: publisher,
+		queue:     make(chan model.ChangeEvent, bufferSize),
+		done:      make(chan struct{}),
+	}
+
+	go e.run()
+	return e
+}
+
+// Handle queues an event for publishing. It matches model.ChangeHook.
+func (e *Exporter) Handle(event model.ChangeEvent) {
+	select {
+	case e.queue <- event:
+	default:
+		logrus.WithField("type", event.Type).Warn("event queue full, dropping event")
+	}
+}
+
+// Close publishes the queued events and disconnects from the broker. Events
+// handled after Close are dropped.
+func (e *Exporter) Close() error {
+	e.closeOnce.Do(func() {
+		close(e.queue)
+		<-e.done
+	})
+	return e.publisher.Close()
+}
+
+// run publishes queued events until the queue is closed
+func (e *Exporter) run() {
+	defer close(e.done)
+
+	for event := range e.queue {
+		// Per-job API keys never leave the server
+		if event.Job != nil {
+			event.Job.ApiKey = ""
+		}
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			logrus.WithError(err).WithField("type", event.Type).Error("failed to encode event")
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		if err := e.publisher.Publish(ctx, &event, data); err != nil {
+			logrus.WithError(err).WithField("type", event.Type).Error("failed to publish event")
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+)
+
+// kafkaPublisher publishes events to a single topic. Messages are keyed by
+// job so the events of a job stay ordered within its partition.
+type kafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// newKafkaPublisher creates a producer for topic. Connections are opened
+// lazily on the first publish, and messages are batched and sent in the
+// background; delivery failures are logged.
+func newKafkaPublisher(brokers []string, topic string) *kafkaPublisher {
+	return &kafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireOne,
+			BatchTimeout: 100 * time.Millisecond,
+			Async:        true,
+			Completion: func(messages []kafka.Message, err error) {
+				if err != nil {
+					logrus.WithError(err).WithField("messages", len(messages)).Error("failed to publish events to Kafka")
+				}
+			},
+		},
+	}
+}
+
+// Publish writes the event with its type as a header
+func (p *kafkaPublisher) Publish(ctx context.Context, event *model.ChangeEvent, data []byte) error {
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:     []byte(eventKey(event)),
+		Value:   data,
+		Headers: []kafka.Header{{Key: "type", Value: []byte(event.Type)}},
+	})
+}
+
+// Close flushes pending messages and closes the producer
+func (p *kafkaPublisher) Close() error {
+	return p.writer.Close()
+}
+
+// eventKey identifies the job or SLO an event belongs to
+func eventKey(event *model.ChangeEvent) string {
+	switch {
+	case event.SLO != nil:
+		return "slo:" + event.SLO.Name
+	case event.Result != nil:
+		return event.Result.JobName + "@" + event.Result.Host
+	case event.Job != nil && event.Job.Name != "":
+		return event.Job.Name + "@" + event.Job.Host
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,52 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+)
+
+// natsPublisher publishes each event to <subject>.<type>
+type natsPublisher struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// newNATSPublisher connects to the NATS servers at url. The client keeps
+// reconnecting in the background if the connection drops.
+func newNATSPublisher(url, subject string) (*natsPublisher, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("cronmetrics"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				logrus.WithError(err).Warn("disconnected from NATS")
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			logrus.WithField("url", conn.ConnectedUrl()).Info("reconnected to NATS")
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	return &natsPublisher{conn: conn, subject: subject}, nil
+}
+
+// Publish sends the event; delivery is at most once, like core NATS
+func (p *natsPublisher) Publish(ctx context.Context, event *model.ChangeEvent, data []byte) error {
+	return p.conn.Publish(p.subject+"."+event.Type, data)
+}
+
+// Close flushes pending messages and closes the connection
+func (p *natsPublisher) Close() error {
+	err := p.conn.Drain()
+	if err == nats.ErrConnectionClosed {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,600 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MaxDepth is how deeply selections may be nested, so a query cannot make
+// the server resolve an unbounded tree
+const MaxDepth = 10
+
+// Request is a GraphQL request, as POSTed in JSON
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"` // Accepted for client compatibility, ignored
+}
+
+// Response is the result of a request. Data is nil when the request could
+// not be executed at all, e.g. because it does not parse or selects a
+// field that does not exist; a field whose resolver failed is null, with
+// the error and its path in Errors.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is an error of a request, and the path of the field it occurred
+// at, if any
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// Execute runs the request's query against the schema. Resolvers are
+// called with ctx.
+func (s *Schema) Execute(ctx context.Context, req *Request) *Response {
+	failed := func(err error) *Response {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return failed(err)
+	}
+	operation, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return failed(err)
+	}
+	if operation.Type != "query" {
+		return failed(fmt.Errorf("%s operations are not supported, only queries", operation.Type))
+	}
+
+	e := &executor{schema: s, doc: doc}
+	if e.variables, err = s.coerceVariables(operation, req.Variables); err != nil {
+		return failed(err)
+	}
+
+	data, err := e.selectionSet(ctx, s.query, nil, operation.SelectionSet, nil, 1)
+	if err != nil {
+		return failed(err)
+	}
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func selectOperation(doc *Document, name string) (*Operation, error) {
+	if name == "" {
+		if len(doc.Operations) != 1 {
+			return nil, errors.New("operationName is required when the document has several operations")
+		}
+		return doc.Operations[0], nil
+	}
+	for _, operation := range doc.Operations {
+		if operation.Name == name {
+			return operation, nil
+		}
+	}
+	return nil, fmt.Errorf("operation %q is not in the document", name)
+}
+
+// coerceVariables checks the given variables against the operation's
+// definitions, and applies their defaults. Variables that are neither given
+// nor defaulted are left out, so arguments set to them use their defaults.
+func (s *Schema) coerceVariables(operation *Operation, given map[string]interface{}) (map[string]interface{}, error) {
+	variables := make(map[string]interface{})
+	for _, definition := range operation.Variables {
+		if named := namedType(definition.Type); s.objects[named] != nil || !s.isType(named) {
+			return nil, fmt.Errorf("variable $%s has unknown input type %s", definition.Name, definition.Type)
+		}
+
+		value, ok := given[definition.Name]
+		if !ok && definition.Default != nil {
+			value, ok = plainValue(definition.Default), true
+		}
+		if !ok {
+			if strings.HasSuffix(definition.Type, "!") {
+				return nil, fmt.Errorf("variable $%s of type %s is required", definition.Name, definition.Type)
+			}
+			continue
+		}
+
+		coerced, err := coerceInput(definition.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("variable $%s: %w", definition.Name, err)
+		}
+		variables[definition.Name] = coerced
+	}
+
+	// Remember which variables were defined, so that using an undefined one
+	// is an error
+	for _, definition := range operation.Variables {
+		if _, ok := variables[definition.Name]; !ok {
+			variables[definition.Name] = undefinedValue{}
+		}
+	}
+	return variables, nil
+}
+
+// undefinedValue marks a defined variable that was not given
+type undefinedValue struct{}
+
+// executor holds the state of one request's execution
+type executor struct {
+	schema    *Schema
+	doc       *Document
+	variables map[string]interface{}
+	errors    []*Error
+}
+
+// fieldGroup is the fields selected under the same response key, whose
+// sub-selections merge
+type fieldGroup struct {
+	key    string
+	fields []*Field
+}
+
+// selectionSet resolves the selections of an object. An error is returned
+// for an invalid query, which fails the whole request; resolver errors are
+// recorded instead, leaving their field null.
+func (e *executor) selectionSet(ctx context.Context, object *Object, source interface{}, selections []Selection, path []interface{}, depth int) (*orderedMap, error) {
+	if depth > MaxDepth {
+		return nil, fmt.Errorf("the query is nested more than %d levels deep", MaxDepth)
+	}
+
+	groups, err := e.collectFields(object, selections, nil, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &orderedMap{values: make(map[string]interface{}, len(groups))}
+	for _, group := range groups {
+		value, err := e.field(ctx, object, source, group, append(path[:len(path):len(path)], group.key), depth)
+		if err != nil {
+			return nil, err
+		}
+		result.set(group.key, value)
+	}
+	return result, nil
+}
+
+// collectFields groups the fields selected on object by response key, in
+// order, expanding fragments and applying @include and @skip
+func (e *executor) collectFields(object *Object, selections []Selection, groups []*fieldGroup, visited map[string]bool) ([]*fieldGroup, error) {
+	for _, selection := range selections {
+		include, err := e.included(directivesOf(selection))
+		if err != nil {
+			return nil, err
+		}
+		if !include {
+			continue
+		}
+
+		switch selection := selection.(type) {
+		case *Field:
+			key := selection.ResponseKey()
+			var group *fieldGroup
+			for _, g := range groups {
+				if g.key == key {
+					group = g
+					break
+				}
+			}
+			if group == nil {
+				group = &fieldGroup{key: key}
+				groups = append(groups, group)
+			} else if group.fields[0].Name != selection.Name {
+				return nil, fmt.Errorf("fields %q and %q conflict under the response key %q", group.fields[0].Name, selection.Name, key)
+			}
+			group.fields = append(group.fields, selection)
+
+		case *FragmentSpread:
+			fragment, ok := e.doc.Fragments[selection.Name]
+			if !ok {
+				return nil, fmt.Errorf("fragment %q is not defined", selection.Name)
+			}
+			if visited[selection.Name] {
+				continue
+			}
+			visited[selection.Name] = true
+
+			applies, err := e.appliesTo(fragment.TypeCondition, object)
+			if err != nil {
+				return nil, err
+			}
+			if !applies {
+				continue
+			}
+			if groups, err = e.collectFields(object, fragment.SelectionSet, groups, visited); err != nil {
+				return nil, err
+			}
+
+		case *InlineFragment:
+			if selection.TypeCondition != "" {
+				applies, err := e.appliesTo(selection.TypeCondition, object)
+				if err != nil {
+					return nil, err
+				}
+				if !applies {
+					continue
+				}
+			}
+			if groups, err = e.collectFields(object, selection.SelectionSet, groups, visited); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return groups, nil
+}
+
+func directivesOf(selection Selection) []*Directive {
+	switch selection := selection.(type) {
+	case *Field:
+		return selection.Directives
+	case *FragmentSpread:
+		return selection.Directives
+	case *InlineFragment:
+		return selection.Directives
+	}
+	return nil
+}
+
+// appliesTo reports whether a fragment on typeCondition applies to object
+func (e *executor) appliesTo(typeCondition string, object *Object) (bool, error) {
+	if e.schema.objects[typeCondition] == nil {
+		return false, fmt.Errorf("fragments cannot be on %s, which is not an object type", typeCondition)
+	}
+	return typeCondition == object.Name, nil
+}
+
+// included applies the @include and @skip directives of a selection
+func (e *executor) included(directives []*Directive) (bool, error) {
+	for _, directive := range directives {
+		if directive.Name != "include" && directive.Name != "skip" {
+			return false, fmt.Errorf("directive @%s is not supported", directive.Name)
+		}
+		value, err := e.value(directive.Arguments["if"])
+		if err != nil {
+			return false, err
+		}
+		condition, ok := value.(bool)
+		if !ok {
+			return false, fmt.Errorf("directive @%s requires a Boolean if argument", directive.Name)
+		}
+		if condition != (directive.Name == "include") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// field resolves a group of fields of object and completes its value
+func (e *executor) field(ctx context.Context, object *Object, source interface{}, group *fieldGroup, path []interface{}, depth int) (interface{}, error) {
+	field := group.fields[0]
+	if field.Name == "__typename" {
+		return object.Name, nil
+	}
+
+	var def *FieldDef
+	for _, candidate := range object.Fields {
+		if candidate.Name == field.Name {
+			def = candidate
+			break
+		}
+	}
+	if def == nil {
+		if strings.HasPrefix(field.Name, "__") {
+			return nil, fmt.Errorf("introspection is not supported; the schema is published in SDL instead")
+		}
+		return nil, fmt.Errorf("field %q is not defined on type %s", field.Name, object.Name)
+	}
+
+	hasSelection := false
+	for _, f := range group.fields {
+		hasSelection = hasSelection || len(f.SelectionSet) > 0
+	}
+	if isObject := e.schema.objects[namedType(def.Type)] != nil; isObject && !hasSelection {
+		return nil, fmt.Errorf("field %q of type %s must have a selection of subfields", field.Name, def.Type)
+	} else if !isObject && hasSelection {
+		return nil, fmt.Errorf("field %q of type %s cannot have a selection of subfields", field.Name, def.Type)
+	}
+
+	args, err := e.arguments(object, def, field)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := def.Resolve(ctx, source, args)
+	if err != nil {
+		e.errors = append(e.errors, &Error{Message: err.Error(), Path: path})
+		return nil, nil
+	}
+	return e.complete(ctx, def.Type, value, group, path, depth)
+}
+
+// complete shapes a resolved value after its type: lists item by item, and
+// objects after the field's sub-selection
+func (e *executor) complete(ctx context.Context, typ string, value interface{}, group *fieldGroup, path []interface{}, depth int) (interface{}, error) {
+	typ = strings.TrimSuffix(typ, "!")
+	if isNil(value) {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(typ, "[") {
+		list := reflect.ValueOf(value)
+		if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+			return nil, fmt.Errorf("field %q resolved to %T, not a list", group.fields[0].Name, value)
+		}
+		items := make([]interface{}, list.Len())
+		for i := range items {
+			item, err := e.complete(ctx, typ[1:len(typ)-1], list.Index(i).Interface(), group, append(path[:len(path):len(path)], i), depth)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+
+	if object := e.schema.objects[typ]; object != nil {
+		var selections []Selection
+		for _, field := range group.fields {
+			selections = append(selections, field.SelectionSet...)
+		}
+		return e.selectionSet(ctx, object, value, selections, path, depth+1)
+	}
+	return value, nil
+}
+
+// arguments coerces the arguments given to a field, and applies the
+// defaults of those not given
+func (e *executor) arguments(object *Object, def *FieldDef, field *Field) (map[string]interface{}, error) {
+	for name := range field.Arguments {
+		known := false
+		for _, arg := range def.Args {
+			known = known || arg.Name == name
+		}
+		if !known {
+			return nil, fmt.Errorf("field %s.%s has no argument %q", object.Name, def.Name, name)
+		}
+	}
+
+	args := make(map[string]interface{}, len(def.Args))
+	for _, arg := range def.Args {
+		literal, given := field.Arguments[arg.Name]
+		value, err := e.value(literal)
+		if err != nil {
+			return nil, err
+		}
+		if _, undefined := value.(undefinedValue); undefined {
+			given = false
+		}
+
+		if !given {
+			if arg.Default != nil {
+				args[arg.Name] = arg.Default
+			} else if strings.HasSuffix(arg.Type, "!") {
+				return nil, fmt.Errorf("argument %q of field %s.%s is required", arg.Name, object.Name, def.Name)
+			}
+			continue
+		}
+
+		coerced, err := coerceInput(arg.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q of field %s.%s: %w", arg.Name, object.Name, def.Name, err)
+		}
+		args[arg.Name] = coerced
+	}
+	return args, nil
+}
+
+// value replaces the variables of an input value by their values
+func (e *executor) value(value Value) (interface{}, error) {
+	switch value := value.(type) {
+	case Variable:
+		variable, ok := e.variables[string(value)]
+		if !ok {
+			return nil, fmt.Errorf("variable $%s is not defined", value)
+		}
+		return variable, nil
+	case ListValue:
+		list := make([]interface{}, len(value))
+		for i, item := range value {
+			v, err := e.value(item)
+			if err != nil {
+				return nil, err
+			}
+			if _, undefined := v.(undefinedValue); undefined {
+				v = nil
+			}
+			list[i] = v
+		}
+		return list, nil
+	case ObjectValue:
+		object := make(map[string]interface{}, len(value))
+		for name, field := range value {
+			v, err := e.value(field)
+			if err != nil {
+				return nil, err
+			}
+			if _, undefined := v.(undefinedValue); !undefined {
+				object[name] = v
+			}
+		}
+		return object, nil
+	}
+	return plainValue(value), nil
+}
+
+// plainValue converts a constant input value to plain Go values
+func plainValue(value Value) interface{} {
+	switch value := value.(type) {
+	case EnumValue:
+		return string(value)
+	case ListValue:
+		list := make([]interface{}, len(value))
+		for i, item := range value {
+			list[i] = plainValue(item)
+		}
+		return list
+	case ObjectValue:
+		object := make(map[string]interface{}, len(value))
+		for name, field := range value {
+			object[name] = plainValue(field)
+		}
+		return object
+	}
+	return value
+}
+
+// coerceInput converts an argument or variable value to its type: Int to
+// int, Float to float64, String and ID to string, Boolean to bool, and
+// lists to []interface{}. Custom scalars are passed as is.
+func coerceInput(typ string, value interface{}) (interface{}, error) {
+	nonNull := strings.HasSuffix(typ, "!")
+	typ = strings.TrimSuffix(typ, "!")
+	if value == nil {
+		if nonNull {
+			return nil, fmt.Errorf("expected %s!, got null", typ)
+		}
+		return nil, nil
+	}
+
+	if strings.HasPrefix(typ, "[") {
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			coerced, err := coerceInput(typ[1:len(typ)-1], item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = coerced
+		}
+		return list, nil
+	}
+
+	switch typ {
+	case "Int":
+		switch n := value.(type) {
+		case int64:
+			if n >= math.MinInt32 && n <= math.MaxInt32 {
+				return int(n), nil
+			}
+		case int:
+			return n, nil
+		case float64:
+			if n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32 {
+				return int(n), nil
+			}
+		}
+	case "Float":
+		switch n := value.(type) {
+		case int64:
+			return float64(n), nil
+		case int:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+	case "String":
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case "ID":
+		switch id := value.(type) {
+		case string:
+			return id, nil
+		case int64:
+			return strconv.FormatInt(id, 10), nil
+		case int:
+			return strconv.Itoa(id), nil
+		case float64:
+			if id == math.Trunc(id) {
+				return strconv.FormatFloat(id, 'f', -1, 64), nil
+			}
+		}
+	case "Boolean":
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	default:
+		return value, nil
+	}
+	return nil, fmt.Errorf("expected %s, got %s", typ, describe(value))
+}
+
+func describe(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case int, int64, float64:
+		return "a number"
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func:
+		return v.IsNil()
+	}
+	return false
+}
+
+// orderedMap is a JSON object whose fields keep the order they were
+// selected in, as responses must
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// MarshalJSON writes the fields in order
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
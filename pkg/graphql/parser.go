@@ -0,0 +1,763 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Document is a parsed query document
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+// Operation is a query, mutation or subscription of a document
+type Operation struct {
+	Type         string // "query", "mutation" or "subscription"
+	Name         string
+	Variables    []*VariableDefinition
+	SelectionSet []Selection
+}
+
+// VariableDefinition declares a variable of an operation
+type VariableDefinition struct {
+	Name    string
+	Type    string // e.g. "[String!]!"
+	Default Value  // nil without a default
+}
+
+// Fragment is a named fragment definition
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+}
+
+// Selection is a *Field, *FragmentSpread or *InlineFragment
+type Selection interface{}
+
+// Field selects a field of an object, with its arguments and sub-selection
+type Field struct {
+	Alias        string
+	Name         string
+	Arguments    map[string]Value
+	Directives   []*Directive
+	SelectionSet []Selection
+}
+
+// ResponseKey returns the key of the field in the response: its alias, or
+// its name
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// FragmentSpread includes a named fragment
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+}
+
+// InlineFragment includes a selection set, optionally for a type only
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+}
+
+// Directive is a directive such as @include(if: $flag)
+type Directive struct {
+	Name      string
+	Arguments map[string]Value
+}
+
+// Value is an input value: nil, bool, int64, float64, string, EnumValue,
+// Variable, ListValue or ObjectValue
+type Value interface{}
+
+// Variable refers to a variable of the operation
+type Variable string
+
+// EnumValue is an enum literal, e.g. SUCCESS
+type EnumValue string
+
+// ListValue is a list literal
+type ListValue []Value
+
+// ObjectValue is an input object literal
+type ObjectValue map[string]Value
+
+// SyntaxError reports a document that cannot be parsed
+type SyntaxError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at %d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of document"
+	case tokenString:
+		return strconv.Quote(t.value)
+	default:
+		return fmt.Sprintf("%q", t.value)
+	}
+}
+
+// lexer splits a document into tokens, skipping whitespace, commas and
+// comments
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) errorAt(pos int, format string, args ...interface{}) *SyntaxError {
+	line, column := 1, 1
+	for _, r := range l.src[:pos] {
+		if r == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return &SyntaxError{Line: line, Column: column, Message: fmt.Sprintf(format, args...)}
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			l.pos++
+		} else if c == '#' {
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		} else if strings.HasPrefix(l.src[l.pos:], "\ufeff") {
+			l.pos += len("\ufeff")
+		} else {
+			break
+		}
+	}
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+		l.pos++
+		return token{kind: tokenPunct, value: string(c), pos: start}, nil
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokenPunct, value: "...", pos: start}, nil
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenName, value: l.src[start:l.pos], pos: start}, nil
+	case c == '-' || isDigit(c):
+		return l.number()
+	case strings.HasPrefix(l.src[l.pos:], `"""`):
+		return l.blockString()
+	case c == '"':
+		return l.string()
+	}
+
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, l.errorAt(start, "unexpected character %q", r)
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	kind := tokenInt
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	digits := func() error {
+		if l.pos >= len(l.src) || !isDigit(l.src[l.pos]) {
+			return l.errorAt(l.pos, "invalid number")
+		}
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+		return nil
+	}
+
+	if err := digits(); err != nil {
+		return token{}, err
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.pos++
+		if err := digits(); err != nil {
+			return token{}, err
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if err := digits(); err != nil {
+			return token{}, err
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || l.src[l.pos] == '.') {
+		return token{}, l.errorAt(l.pos, "invalid number")
+	}
+	return token{kind: kind, value: l.src[start:l.pos], pos: start}, nil
+}
+
+func (l *lexer) string() (token, error) {
+	start := l.pos
+	l.pos++
+
+	var value strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return token{kind: tokenString, value: value.String(), pos: start}, nil
+		case c == '\n' || c == '\r':
+			return token{}, l.errorAt(l.pos, "unterminated string")
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return token{}, l.errorAt(l.pos, "unterminated string")
+			}
+			escape := l.src[l.pos+1]
+			l.pos += 2
+			switch escape {
+			case '"', '\\', '/':
+				value.WriteByte(escape)
+			case 'b':
+				value.WriteByte('\b')
+			case 'f':
+				value.WriteByte('\f')
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			case 'u':
+				if l.pos+4 > len(l.src) {
+					return token{}, l.errorAt(l.pos, "invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(l.src[l.pos:l.pos+4], 16, 32)
+				if err != nil {
+					return token{}, l.errorAt(l.pos, "invalid unicode escape")
+				}
+				value.WriteRune(rune(code))
+				l.pos += 4
+			default:
+				return token{}, l.errorAt(l.pos-2, "invalid escape \\%c", escape)
+			}
+		default:
+			value.WriteByte(c)
+			l.pos++
+		}
+	}
+	return token{}, l.errorAt(start, "unterminated string")
+}
+
+// blockString reads a """block string""". Its common indentation and
+// leading and trailing blank lines are removed.
+func (l *lexer) blockString() (token, error) {
+	start := l.pos
+	l.pos += 3
+
+	var raw strings.Builder
+	for l.pos < len(l.src) {
+		switch {
+		case strings.HasPrefix(l.src[l.pos:], `\"""`):
+			raw.WriteString(`"""`)
+			l.pos += 4
+		case strings.HasPrefix(l.src[l.pos:], `"""`):
+			l.pos += 3
+			return token{kind: tokenString, value: blockStringValue(raw.String()), pos: start}, nil
+		default:
+			raw.WriteByte(l.src[l.pos])
+			l.pos++
+		}
+	}
+	return token{}, l.errorAt(start, "unterminated string")
+}
+
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(raw, "\r\n", "\n"), "\r", "\n"), "\n")
+
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && (indent < 0 || len(line)-len(trimmed) < indent) {
+			indent = len(line) - len(trimmed)
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = strings.TrimLeft(lines[i], " \t")
+			}
+		}
+	}
+
+	for len(lines) > 0 && strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[len(lines)-1], " \t") == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+// parser is a recursive descent parser of executable documents
+type parser struct {
+	lexer *lexer
+	tok   token
+}
+
+// Parse parses a query document
+func Parse(query string) (*Document, error) {
+	p := &parser{lexer: &lexer{src: query}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &Document{Fragments: make(map[string]*Fragment)}
+	if p.tok.kind == tokenEOF {
+		return nil, p.lexer.errorAt(p.tok.pos, "the document has no operation")
+	}
+	for p.tok.kind != tokenEOF {
+		if p.tok.kind == tokenName && p.tok.value == "fragment" {
+			fragment, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.Fragments[fragment.Name]; ok {
+				return nil, fmt.Errorf("fragment %q is defined more than once", fragment.Name)
+			}
+			doc.Fragments[fragment.Name] = fragment
+			continue
+		}
+
+		operation, err := p.operation()
+		if err != nil {
+			return nil, err
+		}
+		doc.Operations = append(doc.Operations, operation)
+	}
+	return doc, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) unexpected(expected string) error {
+	return p.lexer.errorAt(p.tok.pos, "expected %s, found %s", expected, p.tok)
+}
+
+// peek reports whether the current token is the given punctuator
+func (p *parser) peek(punct string) bool {
+	return p.tok.kind == tokenPunct && p.tok.value == punct
+}
+
+// skip consumes the given punctuator if it is the current token
+func (p *parser) skip(punct string) (bool, error) {
+	if !p.peek(punct) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func (p *parser) expect(punct string) error {
+	if !p.peek(punct) {
+		return p.unexpected(strconv.Quote(punct))
+	}
+	return p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.unexpected("a name")
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+func (p *parser) keyword(keyword string) error {
+	if p.tok.kind != tokenName || p.tok.value != keyword {
+		return p.unexpected(strconv.Quote(keyword))
+	}
+	return p.advance()
+}
+
+func (p *parser) operation() (*Operation, error) {
+	operation := &Operation{Type: "query"}
+	if p.peek("{") {
+		selections, err := p.selectionSet()
+		operation.SelectionSet = selections
+		return operation, err
+	}
+
+	if p.tok.kind != tokenName || (p.tok.value != "query" && p.tok.value != "mutation" && p.tok.value != "subscription") {
+		return nil, p.unexpected(`"query" or "{"`)
+	}
+	operation.Type = p.tok.value
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if p.tok.kind == tokenName {
+		if operation.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek("(") {
+		if operation.Variables, err = p.variableDefinitions(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.directives(true); err != nil {
+		return nil, err
+	}
+	operation.SelectionSet, err = p.selectionSet()
+	return operation, err
+}
+
+func (p *parser) variableDefinitions() ([]*VariableDefinition, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	var definitions []*VariableDefinition
+	for {
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+		definition := &VariableDefinition{}
+		var err error
+		if definition.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if definition.Type, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if ok, err := p.skip("="); err != nil {
+			return nil, err
+		} else if ok {
+			if definition.Default, err = p.value(true); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := p.directives(true); err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, definition)
+
+		if ok, err := p.skip(")"); err != nil || ok {
+			return definitions, err
+		}
+	}
+}
+
+func (p *parser) typeRef() (string, error) {
+	var typ string
+	if ok, err := p.skip("["); err != nil {
+		return "", err
+	} else if ok {
+		inner, err := p.typeRef()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect("]"); err != nil {
+			return "", err
+		}
+		typ = "[" + inner + "]"
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return "", err
+		}
+		typ = name
+	}
+
+	if ok, err := p.skip("!"); err != nil {
+		return "", err
+	} else if ok {
+		typ += "!"
+	}
+	return typ, nil
+}
+
+func (p *parser) fragment() (*Fragment, error) {
+	if err := p.keyword("fragment"); err != nil {
+		return nil, err
+	}
+	fragment := &Fragment{}
+	var err error
+	if fragment.Name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if fragment.Name == "on" {
+		return nil, p.lexer.errorAt(p.tok.pos, `a fragment cannot be named "on"`)
+	}
+	if err := p.keyword("on"); err != nil {
+		return nil, err
+	}
+	if fragment.TypeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if fragment.Directives, err = p.directives(false); err != nil {
+		return nil, err
+	}
+	fragment.SelectionSet, err = p.selectionSet()
+	return fragment, err
+}
+
+func (p *parser) selectionSet() ([]Selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var selections []Selection
+	for {
+		selection, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+
+		if ok, err := p.skip("}"); err != nil || ok {
+			return selections, err
+		}
+	}
+}
+
+func (p *parser) selection() (Selection, error) {
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if !ok {
+		return p.field()
+	}
+
+	if p.tok.kind == tokenName && p.tok.value != "on" {
+		spread := &FragmentSpread{}
+		var err error
+		if spread.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+		spread.Directives, err = p.directives(false)
+		return spread, err
+	}
+
+	fragment := &InlineFragment{}
+	var err error
+	if p.tok.kind == tokenName {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if fragment.TypeCondition, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if fragment.Directives, err = p.directives(false); err != nil {
+		return nil, err
+	}
+	fragment.SelectionSet, err = p.selectionSet()
+	return fragment, err
+}
+
+func (p *parser) field() (*Field, error) {
+	field := &Field{}
+	var err error
+	if field.Name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		field.Alias = field.Name
+		if field.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+
+	if field.Arguments, err = p.arguments(false); err != nil {
+		return nil, err
+	}
+	if field.Directives, err = p.directives(false); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		field.SelectionSet, err = p.selectionSet()
+	}
+	return field, err
+}
+
+func (p *parser) arguments(constant bool) (map[string]Value, error) {
+	arguments := make(map[string]Value)
+	if ok, err := p.skip("("); err != nil || !ok {
+		return arguments, err
+	}
+
+	for {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := arguments[name]; ok {
+			return nil, fmt.Errorf("argument %q is given more than once", name)
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if arguments[name], err = p.value(constant); err != nil {
+			return nil, err
+		}
+
+		if ok, err := p.skip(")"); err != nil || ok {
+			return arguments, err
+		}
+	}
+}
+
+func (p *parser) directives(constant bool) ([]*Directive, error) {
+	var directives []*Directive
+	for p.peek("@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		directive := &Directive{}
+		var err error
+		if directive.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if directive.Arguments, err = p.arguments(constant); err != nil {
+			return nil, err
+		}
+		directives = append(directives, directive)
+	}
+	return directives, nil
+}
+
+// value parses an input value. Constant values, such as variable defaults,
+// cannot refer to variables.
+func (p *parser) value(constant bool) (Value, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokenInt:
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, p.lexer.errorAt(tok.pos, "integer %s out of range", tok.value)
+		}
+		return n, p.advance()
+	case tokenFloat:
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, p.lexer.errorAt(tok.pos, "float %s out of range", tok.value)
+		}
+		return f, p.advance()
+	case tokenString:
+		return tok.value, p.advance()
+	case tokenName:
+		var value Value
+		switch tok.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		default:
+			value = EnumValue(tok.value)
+		}
+		return value, p.advance()
+	}
+
+	switch {
+	case p.peek("$") && !constant:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		return Variable(name), err
+	case p.peek("["):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := ListValue{}
+		for !p.peek("]") {
+			item, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, p.advance()
+	case p.peek("{"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		object := ObjectValue{}
+		for !p.peek("}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.value(constant); err != nil {
+				return nil, err
+			}
+		}
+		return object, p.advance()
+	}
+	return nil, p.unexpected("a value")
+}
@@ -0,0 +1,168 @@
+// Package graphql executes GraphQL queries against a schema of Go
+// resolvers. It covers what read-only API clients use: queries with
+// arguments, variables, aliases, fragments and the @include and @skip
+// directives. Mutations, subscriptions and introspection are not
+// supported; the schema is published in SDL instead.
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ResolveFunc returns the value of a field of source, the value resolved
+// for the parent field (nil for the fields of Query). Args holds the
+// coerced arguments, defaults included.
+type ResolveFunc func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error)
+
+// Object is an object type
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*FieldDef
+}
+
+// FieldDef is a field of an object type
+type FieldDef struct {
+	Name        string
+	Description string
+	Type        string // e.g. "[Job!]!"; object types are selected into, others are returned as is
+	Args        []*Arg
+	Resolve     ResolveFunc
+}
+
+// Arg is an argument of a field
+type Arg struct {
+	Name        string
+	Description string
+	Type        string      // "Int", "Float", "String", "Boolean", "ID", "JSON" or a list of them, e.g. "[String!]"
+	Default     interface{} // Used when the argument is not given; nil for none
+}
+
+// Scalar is a scalar type, returned as JSON as resolved
+type Scalar struct {
+	Name        string
+	Description string
+}
+
+// builtinScalars are the scalar types every schema has
+var builtinScalars = map[string]bool{"Int": true, "Float": true, "String": true, "Boolean": true, "ID": true}
+
+// Schema is a set of types whose root is the Query type
+type Schema struct {
+	query   *Object
+	objects map[string]*Object
+	scalars map[string]*Scalar
+}
+
+// NewSchema creates a schema whose root type is query. Every type a field
+// or argument refers to must be a built-in scalar or be given.
+func NewSchema(query *Object, objects []*Object, scalars []*Scalar) (*Schema, error) {
+	schema := &Schema{
+		query:   query,
+		objects: map[string]*Object{query.Name: query},
+		scalars: make(map[string]*Scalar),
+	}
+	for _, object := range objects {
+		schema.objects[object.Name] = object
+	}
+	for _, scalar := range scalars {
+		schema.scalars[scalar.Name] = scalar
+	}
+
+	for _, object := range schema.objects {
+		for _, field := range object.Fields {
+			if !schema.isType(namedType(field.Type)) {
+				return nil, fmt.Errorf("field %s.%s has unknown type %s", object.Name, field.Name, field.Type)
+			}
+			if field.Resolve == nil {
+				return nil, fmt.Errorf("field %s.%s has no resolver", object.Name, field.Name)
+			}
+			for _, arg := range field.Args {
+				if named := namedType(arg.Type); schema.objects[named] != nil || !schema.isType(named) {
+					return nil, fmt.Errorf("argument %s of %s.%s has invalid type %s", arg.Name, object.Name, field.Name, arg.Type)
+				}
+			}
+		}
+	}
+	return schema, nil
+}
+
+func (s *Schema) isType(name string) bool {
+	return builtinScalars[name] || s.scalars[name] != nil || s.objects[name] != nil
+}
+
+// namedType returns the type a type reference names, without its list and
+// non-null wrappers
+func namedType(typ string) string {
+	return strings.Trim(typ, "[]!")
+}
+
+// SDL returns the schema in the GraphQL schema definition language
+func (s *Schema) SDL() string {
+	var sdl strings.Builder
+
+	scalars := make([]string, 0, len(s.scalars))
+	for name := range s.scalars {
+		scalars = append(scalars, name)
+	}
+	sort.Strings(scalars)
+	for _, name := range scalars {
+		writeDescription(&sdl, "", s.scalars[name].Description)
+		sdl.WriteString("scalar " + name + "\n\n")
+	}
+
+	objects := []*Object{s.query}
+	names := make([]string, 0, len(s.objects))
+	for name := range s.objects {
+		if name != s.query.Name {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		objects = append(objects, s.objects[name])
+	}
+
+	for i, object := range objects {
+		if i > 0 {
+			sdl.WriteString("\n")
+		}
+		writeDescription(&sdl, "", object.Description)
+		sdl.WriteString("type " + object.Name + " {\n")
+		for _, field := range object.Fields {
+			writeDescription(&sdl, "  ", field.Description)
+			sdl.WriteString("  " + field.Name)
+			if len(field.Args) > 0 {
+				args := make([]string, 0, len(field.Args))
+				for _, arg := range field.Args {
+					def := arg.Name + ": " + arg.Type
+					if arg.Default != nil {
+						def += " = " + formatValue(arg.Default)
+					}
+					args = append(args, def)
+				}
+				sdl.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			sdl.WriteString(": " + field.Type + "\n")
+		}
+		sdl.WriteString("}\n")
+	}
+	return sdl.String()
+}
+
+func writeDescription(sdl *strings.Builder, indent, description string) {
+	if description != "" {
+		sdl.WriteString(indent + strconv.Quote(description) + "\n")
+	}
+}
+
+func formatValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,94 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// SourceCronitor is the source of results imported from Cronitor
+const SourceCronitor = "cronitor"
+
+// cronitorMonitor is a monitor as listed by the Cronitor API
+// (/api/monitors), with its telemetry events under "pings"
+type cronitorMonitor struct {
+	Key          string          `json:"key"`
+	Name         string          `json:"name"`
+	Type         string          `json:"type"` // "job", "heartbeat", "check" or "site"
+	Tags         []string        `json:"tags"`
+	Note         string          `json:"note"`
+	Schedule     string          `json:"schedule"` // Cron expression or e.g. "every 5 minutes"
+	GraceSeconds int             `json:"grace_seconds"`
+	Paused       bool            `json:"paused"`
+	Pings        []cronitorEvent `json:"pings"`
+}
+
+// cronitorEvent is a telemetry event of a monitor
+type cronitorEvent struct {
+	Stamp      float64            `json:"stamp"` // Unix time
+	State      string             `json:"state"` // "run", "complete", "ok" or "fail"
+	Duration   *float64           `json:"duration"`
+	Message    string             `json:"message"`
+	StatusCode *int               `json:"status_code"` // Exit code of the job
+	Metrics    map[string]float64 `json:"metrics"`     // e.g. "count" of processed items
+}
+
+// Cronitor maps the job and heartbeat monitors of a Cronitor export into
+// jobs; uptime checks and sites are skipped. Monitors on an interval
+// schedule fail after the interval and grace time; cron schedules follow
+// the configured default thresholds. Complete, ok and fail events become
+// results; run events are dropped.
+func Cronitor(r io.Reader, opts Options) (*Import, error) {
+	cronitorMonitors, err := decodeList[cronitorMonitor](r, "monitors")
+	if err != nil {
+		return nil, err
+	}
+
+	var monitors []monitor
+	for _, cm := range cronitorMonitors {
+		if cm.Type != "" && cm.Type != "job" && cm.Type != "heartbeat" {
+			continue
+		}
+		m := monitor{
+			id:          fmt.Sprintf("%q", cm.Key),
+			name:        cm.Name,
+			tags:        cm.Tags,
+			description: cm.Note,
+			paused:      cm.Paused,
+		}
+		if m.name == "" {
+			m.name = cm.Key
+		}
+		if interval := intervalSeconds(cm.Schedule); interval > 0 {
+			m.threshold = interval + cm.GraceSeconds
+		}
+
+		for _, event := range cm.Pings {
+			status := map[string]string{"complete": "success", "ok": "success", "fail": "failure"}[event.State]
+			if status == "" {
+				continue
+			}
+			seconds, fraction := math.Modf(event.Stamp)
+			result := &model.JobResult{
+				Status:    status,
+				Output:    event.Message,
+				ExitCode:  event.StatusCode,
+				Timestamp: time.Unix(int64(seconds), int64(fraction*1e9)),
+			}
+			if event.Duration != nil {
+				result.Duration = int(math.Round(*event.Duration))
+			}
+			if count, ok := event.Metrics["count"]; ok && count >= 0 {
+				items := int64(count)
+				result.ItemsProcessed = &items
+			}
+			m.pings = append(m.pings, result)
+		}
+		monitors = append(monitors, m)
+	}
+
+	return build(SourceCronitor, monitors, opts)
+}
@@ -0,0 +1,96 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// SourceHealthchecks is the source of results imported from Healthchecks.io
+const SourceHealthchecks = "healthchecks"
+
+// healthchecksCheck is a check as listed by the Healthchecks.io API
+// (/api/v3/checks/), with its pings (/api/v3/checks/<uuid>/pings/) under
+// "pings"
+type healthchecksCheck struct {
+	Name     string             `json:"name"`
+	Slug     string             `json:"slug"`
+	UUID     string             `json:"uuid"`
+	Tags     string             `json:"tags"` // Separated by spaces
+	Desc     string             `json:"desc"`
+	Timeout  int                `json:"timeout"` // Seconds between pings of simple checks
+	Grace    int                `json:"grace"`   // Seconds
+	Schedule string             `json:"schedule"`
+	Status   string             `json:"status"` // e.g. "up", "down", "paused"
+	LastPing *time.Time         `json:"last_ping"`
+	Pings    []healthchecksPing `json:"pings"`
+}
+
+// healthchecksPing is a ping of a check
+type healthchecksPing struct {
+	Type       string    `json:"type"` // "success", "fail", "start", "log" or "ign"
+	Date       time.Time `json:"date"`
+	Duration   *float64  `json:"duration"` // Seconds since the start ping
+	ExitStatus *int      `json:"exitstatus"`
+	RemoteAddr string    `json:"remote_addr"`
+	UserAgent  string    `json:"ua"`
+}
+
+// Healthchecks maps the checks of a Healthchecks.io export into jobs.
+// Simple checks fail after their timeout and grace time; cron checks follow
+// the configured default thresholds. Success and failure pings become
+// results; start, log and ignored pings are dropped.
+func Healthchecks(r io.Reader, opts Options) (*Import, error) {
+	checks, err := decodeList[healthchecksCheck](r, "checks")
+	if err != nil {
+		return nil, err
+	}
+
+	monitors := make([]monitor, 0, len(checks))
+	for i, check := range checks {
+		m := monitor{
+			id:          fmt.Sprintf("#%d (%s)", i+1, check.Name),
+			name:        check.Slug,
+			tags:        strings.Fields(check.Tags),
+			description: check.Desc,
+			paused:      check.Status == "paused",
+		}
+		if m.name == "" {
+			m.name = check.Name
+		}
+		if m.name == "" {
+			m.name = check.UUID
+		}
+		if check.Schedule == "" && check.Timeout > 0 {
+			m.threshold = check.Timeout + check.Grace
+		}
+		if check.LastPing != nil {
+			m.lastPing = *check.LastPing
+		}
+
+		for _, ping := range check.Pings {
+			status := map[string]string{"success": "success", "fail": "failure"}[ping.Type]
+			if status == "" {
+				continue
+			}
+			result := &model.JobResult{
+				Status:    status,
+				Timestamp: ping.Date,
+				ExitCode:  ping.ExitStatus,
+				SourceIP:  ping.RemoteAddr,
+				UserAgent: ping.UserAgent,
+			}
+			if ping.Duration != nil {
+				result.Duration = int(math.Round(*ping.Duration))
+			}
+			m.pings = append(m.pings, result)
+		}
+		monitors = append(monitors, m)
+	}
+
+	return build(SourceHealthchecks, monitors, opts)
+}
@@ -0,0 +1,214 @@
+// Package importer maps the monitors and ping history exported from hosted
+// cron monitoring services, Healthchecks.io and Cronitor, into jobs and
+// results, to migrate from them
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// Options control how monitors are mapped
+type Options struct {
+	Host string    // Host of the jobs; a "host" tag of a monitor overrides it
+	Now  time.Time // Last report of jobs without history, defaults to the current time
+}
+
+// Import is the jobs and results mapped from an export. Results are
+// ordered oldest first and carry the service as their source.
+type Import struct {
+	Jobs    []*model.Job
+	Results []*model.JobResult
+}
+
+// ResultsOf returns the imported results of a job
+func (i *Import) ResultsOf(job *model.Job) []*model.JobResult {
+	var results []*model.JobResult
+	for _, result := range i.Results {
+		if result.JobName == job.Name && result.Host == job.Host {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// monitor is a monitor of any service, as mapped to a job
+type monitor struct {
+	id          string // Identifies the monitor in errors
+	name        string
+	tags        []string
+	description string
+	threshold   int // Seconds; 0 follows the configured defaults
+	paused      bool
+	lastPing    time.Time
+	pings       []*model.JobResult
+}
+
+// build maps monitors into jobs and their results
+func build(source string, monitors []monitor, opts Options) (*Import, error) {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+
+	imported := &Import{}
+	seen := make(map[string]string, len(monitors))
+	for _, m := range monitors {
+		name := slugify(m.name)
+		if name == "" {
+			return nil, fmt.Errorf("monitor %s has no name", m.id)
+		}
+		labels, host := tagLabels(m.tags)
+		if host == "" {
+			host = opts.Host
+		}
+		if host == "" {
+			return nil, fmt.Errorf("monitor %s has no host: set one or tag it host:<name>", m.id)
+		}
+		if err := model.CheckJobIdentity(name, host); err != nil {
+			return nil, fmt.Errorf("monitor %s: %w", m.id, err)
+		}
+		if other, ok := seen[name+"@"+host]; ok {
+			return nil, fmt.Errorf("monitors %s and %s both map to job %s@%s", other, m.id, name, host)
+		}
+		seen[name+"@"+host] = m.id
+
+		description := m.description
+		if len(description) > model.MaxDescriptionLength {
+			description = description[:model.MaxDescriptionLength]
+		}
+		job := &model.Job{
+			Name:                      name,
+			Host:                      host,
+			AutomaticFailureThreshold: m.threshold,
+			Labels:                    labels,
+			Description:               description,
+			Status:                    "active",
+			Type:                      model.JobTypeStandard,
+			LastReportedAt:            now,
+		}
+		if m.paused {
+			job.Status = "paused"
+		}
+
+		// The last report is the last ping, else the time of the import
+		lastReport := m.lastPing.UTC()
+		for _, result := range m.pings {
+			result.JobName, result.Host, result.Source = name, host, source
+			result.Timestamp = result.Timestamp.UTC()
+			if result.Timestamp.After(lastReport) {
+				lastReport = result.Timestamp
+			}
+			imported.Results = append(imported.Results, result)
+		}
+		if !lastReport.IsZero() {
+			job.LastReportedAt = lastReport
+		}
+		imported.Jobs = append(imported.Jobs, job)
+	}
+
+	sort.SliceStable(imported.Results, func(i, j int) bool {
+		return imported.Results[i].Timestamp.Before(imported.Results[j].Timestamp)
+	})
+	return imported, nil
+}
+
+// decodeList decodes an export that is either a list of items, or an
+// object with the list under key, as answered by the service's API
+func decodeList[T any](r io.Reader, key string) ([]T, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid export: %w", err)
+	}
+
+	var items []T
+	if strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, fmt.Errorf("invalid export: %w", err)
+		}
+		return items, nil
+	}
+
+	var wrapped map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &wrapped); err != nil {
+		return nil, fmt.Errorf("invalid export: %w", err)
+	}
+	list, ok := wrapped[key]
+	if !ok {
+		return nil, fmt.Errorf("invalid export: no %q list", key)
+	}
+	if err := json.Unmarshal(list, &items); err != nil {
+		return nil, fmt.Errorf("invalid export: %w", err)
+	}
+	return items, nil
+}
+
+// invalidLabelChars are the characters Prometheus label names cannot hold
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// tagLabels maps tags into labels: "key:value" and "key=value" tags are
+// labels, other tags are labels set to "true". A "host" tag is returned
+// apart.
+func tagLabels(tags []string) (map[string]string, string) {
+	labels := make(map[string]string)
+	host := ""
+	for _, tag := range tags {
+		key, value, found := strings.Cut(tag, "=")
+		if !found {
+			key, value, found = strings.Cut(tag, ":")
+		}
+		if !found {
+			value = "true"
+		}
+
+		key = invalidLabelChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(key)), "_")
+		if key == "" {
+			continue
+		}
+		if key[0] >= '0' && key[0] <= '9' {
+			key = "_" + key
+		}
+		if key == "host" {
+			host = value
+			continue
+		}
+		labels[key] = value
+	}
+	return labels, host
+}
+
+// invalidNameChars are the characters dropped from job names
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// slugify turns a monitor name such as "Nightly DB backup" into a job name
+// such as "nightly-db-backup"
+func slugify(name string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// everyPattern matches the interval schedules of Cronitor, e.g. "every 5 minutes"
+var everyPattern = regexp.MustCompile(`^every\s+(\d+)\s+(second|minute|hour|day|week)s?$`)
+
+// intervalSeconds returns the seconds between runs of an interval schedule,
+// or 0 for cron expressions and other schedules
+func intervalSeconds(schedule string) int {
+	match := everyPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(schedule)))
+	if match == nil {
+		return 0
+	}
+	count, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0
+	}
+	unit := map[string]int{"second": 1, "minute": 60, "hour": 3600, "day": 86400, "week": 604800}[match[2]]
+	return count * unit
+}
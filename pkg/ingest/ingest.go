@@ -0,0 +1,93 @@
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// APIKeyHeader is the message header (or SQS message attribute) carrying
+// the job's API key
+const APIKeyHeader = "X-API-Key"
+
+// Recorder authenticates and records job results. It is implemented by
+// api.Server, so queued results go through the same checks as HTTP ones.
+type Recorder interface {
+	RecordJobResult(apiKey string, result *model.JobResult) error
+}
+
+// Consumer receives job results from a message queue until it is closed
+type Consumer interface {
+	Close() error
+}
+
+// NewConsumer connects to the queue selected by the ingest configuration
+// and starts recording the job results published to it
+func NewConsumer(cfg *config.IngestConfig, recorder Recorder) (Consumer, error) {
+	switch cfg.Driver {
+	case "nats":
+		return newNATSConsumer(cfg, recorder)
+	case "sqs":
+		return newSQSConsumer(cfg, recorder)
+	case "rabbitmq":
+		return newRabbitMQConsumer(cfg, recorder)
+	case "mqtt":
+		return newMQTTConsumer(cfg, recorder)
+	default:
+		return nil, fmt.Errorf("unsupported ingest driver: %s", cfg.Driver)
+	}
+}
+
+// message is a job result published to a queue. Brokers without message
+// headers can carry the job's API key in the body instead.
+type message struct {
+	APIKey string `json:"api_key,omitempty"`
+	model.JobResult
+}
+
+// record decodes a message and records its job result. apiKey, taken from
+// the message headers, takes precedence over the key in the body.
+func record(recorder Recorder, driver string, body []byte, apiKey string) (*model.JobResult, error) {
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, reject(driver, fmt.Errorf("invalid JSON: %w", err))
+	}
+	if apiKey == "" {
+		apiKey = msg.APIKey
+	}
+
+	return &msg.JobResult, submit(recorder, driver, apiKey, &msg.JobResult)
+}
+
+// reject logs a message that could not be decoded and returns err
+func reject(driver string, err error) error {
+	logrus.WithError(err).WithField("driver", driver).Warn("rejected queued job result")
+	return err
+}
+
+// submit records a decoded job result, logging failures
+func submit(recorder Recorder, driver, apiKey string, result *model.JobResult) error {
+	fields := logrus.Fields{"driver": driver, "job_name": result.JobName, "host": result.Host}
+	if err := recorder.RecordJobResult(apiKey, result); err != nil {
+		if temporary(err) {
+			logrus.WithError(err).WithFields(fields).Error("failed to record queued job result")
+		} else {
+			logrus.WithError(err).WithFields(fields).Warn("rejected queued job result")
+		}
+		return err
+	}
+
+	logrus.WithFields(fields).Debug("recorded queued job result")
+	return nil
+}
+
+// temporary reports whether a failed message may succeed when redelivered.
+// Malformed and unauthorized messages never will.
+func temporary(err error) bool {
+	var t interface{ Temporary() bool }
+	return errors.As(err, &t) && t.Temporary()
+}
@@ -0,0 +1,58 @@
+package ingest
+
+import (
+	"context"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// LostRunDetector records the runs that reported starting but whose result
+// never arrived, e.g. because the host rebooted or the job was killed, as
+// "lost" results
+type LostRunDetector struct {
+	jobResultStore  *model.JobResultStore
+	interval        time.Duration
+	defaultDuration time.Duration
+}
+
+// NewLostRunDetector creates a detector for the lost run configuration
+func NewLostRunDetector(cfg *config.LostRunsConfig, jobResultStore *model.JobResultStore) *LostRunDetector {
+	return &LostRunDetector{
+		jobResultStore:  jobResultStore,
+		interval:        time.Duration(cfg.Interval) * time.Second,
+		defaultDuration: time.Duration(cfg.DefaultDuration) * time.Second,
+	}
+}
+
+// Run checks for lost runs every interval until ctx is cancelled
+func (d *LostRunDetector) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Check(ctx, time.Now().UTC()); err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Error("lost run detection failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check records the runs lost as of now and returns their results
+func (d *LostRunDetector) Check(ctx context.Context, now time.Time) ([]*model.JobResult, error) {
+	lost, err := d.jobResultStore.WithContext(ctx).RecordLostRuns(now, d.defaultDuration)
+	for _, result := range lost {
+		logrus.WithFields(logrus.Fields{
+			"job_name": result.JobName,
+			"host":     result.Host,
+		}).Warn("job run lost: started but never reported its result")
+	}
+	return lost, err
+}
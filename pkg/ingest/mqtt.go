@@ -0,0 +1,142 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// mqttConnectTimeout bounds the first connection to the broker
+const mqttConnectTimeout = 10 * time.Second
+
+// mqttConsumer records job results published by edge devices to
+// <prefix>/<host>/<job>/result. Devices log in to the broker with their own
+// credentials; each result is authenticated with its job's API key.
+type mqttConsumer struct {
+	client mqtt.Client
+}
+
+// newMQTTConsumer connects to the broker and subscribes to the result
+// topics. The client reconnects and resubscribes in the background.
+func newMQTTConsumer(cfg *config.IngestConfig, recorder Recorder) (*mqttConsumer, error) {
+	clientID := cfg.ClientID
+	if clientID == "" {
+		hostname, _ := os.Hostname()
+		clientID = "cronmetrics-" + hostname
+	}
+
+	prefix := strings.TrimSuffix(cfg.TopicPrefix, "/")
+	filter := prefix + "/+/+/result"
+	qos := byte(cfg.QoS)
+
+	handler := func(_ mqtt.Client, msg mqtt.Message) {
+		host, job, ok := parseMQTTTopic(prefix, msg.Topic())
+		if !ok {
+			_ = reject("mqtt", fmt.Errorf("unexpected topic %s", msg.Topic()))
+			return
+		}
+
+		apiKey, result, err := parseMQTTPayload(msg.Payload())
+		if err != nil {
+			_ = reject("mqtt", fmt.Errorf("%s: %w", msg.Topic(), err))
+			return
+		}
+		if (result.JobName != "" && result.JobName != job) || (result.Host != "" && result.Host != host) {
+			_ = reject("mqtt", fmt.Errorf("%s: job_name and host do not match the topic", msg.Topic()))
+			return
+		}
+		result.JobName, result.Host = job, host
+
+		_ = submit(recorder, "mqtt", apiKey, result)
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.URL).
+		SetClientID(clientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		// Keep the session so the broker queues QoS 1 results while we are away
+		SetCleanSession(false).
+		SetAutoReconnect(true).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			logrus.WithError(err).Warn("disconnected from MQTT broker")
+		}).
+		SetOnConnectHandler(func(client mqtt.Client) {
+			token := client.Subscribe(filter, qos, handler)
+			if token.Wait() && token.Error() != nil {
+				logrus.WithError(token.Error()).WithField("topic", filter).Error("failed to subscribe to MQTT topic")
+				return
+			}
+			logrus.WithField("topic", filter).Info("subscribed to MQTT job results")
+		})
+
+	client := mqtt.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(mqttConnectTimeout) {
+		client.Disconnect(0)
+		return nil, fmt.Errorf("timed out connecting to MQTT broker %s", cfg.URL)
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+
+	return &mqttConsumer{client: client}, nil
+}
+
+// parseMQTTTopic extracts the host and job name from
+// <prefix>/<host>/<job>/result
+func parseMQTTTopic(prefix, topic string) (host, job string, ok bool) {
+	rest, found := strings.CutPrefix(topic, prefix+"/")
+	if !found {
+		return "", "", false
+	}
+
+	levels := strings.Split(rest, "/")
+	if len(levels) != 3 || levels[0] == "" || levels[1] == "" || levels[2] != "result" {
+		return "", "", false
+	}
+	return levels[0], levels[1], true
+}
+
+// parseMQTTPayload decodes a published result. The payload is either the
+// job result JSON with an api_key field, or the compact form
+// "<api_key> <status> [duration]" for constrained links.
+func parseMQTTPayload(payload []byte) (string, *model.JobResult, error) {
+	text := strings.TrimSpace(string(payload))
+	if strings.HasPrefix(text, "{") {
+		var msg message
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return "", nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return msg.APIKey, &msg.JobResult, nil
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) < 2 || len(fields) > 3 {
+		return "", nil, fmt.Errorf("payload must be JSON or \"<api_key> <status> [duration]\"")
+	}
+
+	result := &model.JobResult{Status: fields[1]}
+	if len(fields) == 3 {
+		duration, err := strconv.Atoi(fields[2])
+		if err != nil || duration < 0 {
+			return "", nil, fmt.Errorf("invalid duration: %s", fields[2])
+		}
+		result.Duration = duration
+	}
+	return fields[0], result, nil
+}
+
+// Close disconnects after the results in flight are recorded
+func (c *mqttConsumer) Close() error {
+	c.client.Disconnect(1000)
+	return nil
+}
@@ -0,0 +1,76 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+)
+
+// natsConsumer records job results published to a NATS subject. Server
+// instances share a queue group, so each result is recorded once.
+type natsConsumer struct {
+	conn *nats.Conn
+}
+
+// newNATSConsumer connects to NATS and subscribes to the results subject.
+// The client keeps reconnecting in the background if the connection drops.
+func newNATSConsumer(cfg *config.IngestConfig, recorder Recorder) (*natsConsumer, error) {
+	conn, err := nats.Connect(cfg.URL,
+		nats.Name("cronmetrics"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				logrus.WithError(err).Warn("disconnected from NATS")
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			logrus.WithField("url", conn.ConnectedUrl()).Info("reconnected to NATS")
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	_, err = conn.QueueSubscribe(cfg.Subject, cfg.QueueGroup, func(msg *nats.Msg) {
+		result, err := record(recorder, "nats", msg.Data, msg.Header.Get(APIKeyHeader))
+		if msg.Reply != "" {
+			respond(msg, result, err)
+		}
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", cfg.Subject, err)
+	}
+
+	return &natsConsumer{conn: conn}, nil
+}
+
+// respond answers a request with the same body as POST /api/job-result, so
+// agents publishing with request/reply learn whether the result was kept
+func respond(msg *nats.Msg, result *model.JobResult, err error) {
+	response := map[string]string{}
+	if err != nil {
+		response["error"] = err.Error()
+	} else {
+		response["status"] = "recorded"
+		response["job"] = fmt.Sprintf("%s@%s", result.JobName, result.Host)
+	}
+
+	data, _ := json.Marshal(response)
+	if err := msg.Respond(data); err != nil {
+		logrus.WithError(err).Warn("failed to reply to queued job result")
+	}
+}
+
+// Close stops consuming after the results in flight are recorded
+func (c *natsConsumer) Close() error {
+	err := c.conn.Drain()
+	if err == nats.ErrConnectionClosed {
+		return nil
+	}
+	return err
+}
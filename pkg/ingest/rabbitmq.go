@@ -0,0 +1,142 @@
+package ingest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+)
+
+// rabbitMQReconnectDelay is the pause between reconnection attempts
+const rabbitMQReconnectDelay = 5 * time.Second
+
+// rabbitMQConsumer records job results from a durable RabbitMQ queue.
+// Messages are acknowledged once recorded or rejected; those failing with
+// a temporary error are requeued.
+type rabbitMQConsumer struct {
+	url      string
+	queue    string
+	recorder Recorder
+
+	mu      sync.Mutex
+	conn    *amqp.Connection
+	closing chan struct{}
+	done    chan struct{}
+}
+
+// newRabbitMQConsumer connects to the broker and starts consuming. After
+// the first connection, the consumer reconnects in the background.
+func newRabbitMQConsumer(cfg *config.IngestConfig, recorder Recorder) (*rabbitMQConsumer, error) {
+	c := &rabbitMQConsumer{
+		url:      cfg.URL,
+		queue:    cfg.Queue,
+		recorder: recorder,
+		closing:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	deliveries, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	go c.run(deliveries)
+	return c, nil
+}
+
+// connect opens a connection and a channel consuming from the queue,
+// declaring it if needed
+func (c *rabbitMQConsumer) connect() (<-chan amqp.Delivery, error) {
+	conn, err := amqp.Dial(c.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err == nil {
+		err = ch.Qos(10, 0, false)
+	}
+	if err == nil {
+		_, err = ch.QueueDeclare(c.queue, true, false, false, false, nil)
+	}
+	var deliveries <-chan amqp.Delivery
+	if err == nil {
+		deliveries, err = ch.Consume(c.queue, "cronmetrics", false, false, false, false, nil)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to consume from %s: %w", c.queue, err)
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+	return deliveries, nil
+}
+
+// run records deliveries, reconnecting whenever the connection is lost,
+// until Close is called
+func (c *rabbitMQConsumer) run(deliveries <-chan amqp.Delivery) {
+	defer close(c.done)
+
+	for {
+		for delivery := range deliveries {
+			c.handle(delivery)
+		}
+
+		for {
+			select {
+			case <-c.closing:
+				return
+			default:
+			}
+			logrus.Warn("lost connection to RabbitMQ, reconnecting")
+
+			select {
+			case <-c.closing:
+				return
+			case <-time.After(rabbitMQReconnectDelay):
+			}
+
+			var err error
+			if deliveries, err = c.connect(); err == nil {
+				logrus.Info("reconnected to RabbitMQ")
+				break
+			}
+			logrus.WithError(err).Warn("failed to reconnect to RabbitMQ")
+		}
+	}
+}
+
+// handle records one delivery and acknowledges it
+func (c *rabbitMQConsumer) handle(delivery amqp.Delivery) {
+	apiKey, _ := delivery.Headers[APIKeyHeader].(string)
+	_, err := record(c.recorder, "rabbitmq", delivery.Body, apiKey)
+
+	if err != nil && temporary(err) {
+		err = delivery.Nack(false, true)
+	} else {
+		err = delivery.Ack(false)
+	}
+	if err != nil {
+		logrus.WithError(err).Warn("failed to acknowledge RabbitMQ message")
+	}
+}
+
+// Close stops consuming; unacknowledged messages are returned to the queue
+func (c *rabbitMQConsumer) Close() error {
+	close(c.closing)
+
+	c.mu.Lock()
+	err := c.conn.Close()
+	c.mu.Unlock()
+
+	<-c.done
+	if err == amqp.ErrClosed {
+		return nil
+	}
+	return err
+}
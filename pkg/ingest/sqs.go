@@ -0,0 +1,189 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/sigv4"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// sqsWaitTime is the long polling duration of each receive, in seconds
+	sqsWaitTime = 20
+	// sqsRetryDelay is the pause after a failed receive
+	sqsRetryDelay = 5 * time.Second
+)
+
+// sqsConsumer records job results from an Amazon SQS queue, or any service
+// speaking the SQS JSON protocol. Messages that fail with a temporary error
+// are left on the queue and delivered again after their visibility timeout.
+type sqsConsumer struct {
+	client   *http.Client
+	queueURL string
+	endpoint string // Service endpoint the actions are posted to
+	signer   *sigv4.Signer
+	recorder Recorder
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// sqsMessage is a message returned by ReceiveMessage
+type sqsMessage struct {
+	ReceiptHandle     string
+	Body              string
+	MessageAttributes map[string]struct {
+		StringValue string
+	}
+}
+
+// newSQSConsumer starts polling the queue at cfg.URL
+func newSQSConsumer(cfg *config.IngestConfig, recorder Recorder) (*sqsConsumer, error) {
+	queueURL, err := url.Parse(cfg.URL)
+	if err != nil || queueURL.Host == "" {
+		return nil, fmt.Errorf("invalid SQS queue URL: %s", cfg.URL)
+	}
+
+	signer := &sigv4.Signer{
+		AccessKey: cfg.AccessKeyID,
+		SecretKey: cfg.SecretAccessKey,
+		Region:    cfg.Region,
+		Service:   "sqs",
+	}
+	if signer.AccessKey == "" {
+		signer.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if signer.SecretKey == "" {
+		signer.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if signer.AccessKey == "" || signer.SecretKey == "" {
+		return nil, fmt.Errorf("ingest credentials are required for the sqs driver")
+	}
+	if signer.Region == "" {
+		signer.Region = sqsRegion(queueURL.Host)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &sqsConsumer{
+		client:   &http.Client{Timeout: (sqsWaitTime + 10) * time.Second},
+		queueURL: cfg.URL,
+		endpoint: queueURL.Scheme + "://" + queueURL.Host + "/",
+		signer:   signer,
+		recorder: recorder,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	go c.run(ctx)
+	return c, nil
+}
+
+// sqsRegion extracts the region from an SQS host name such as
+// sqs.eu-west-1.amazonaws.com
+func sqsRegion(host string) string {
+	parts := strings.Split(host, ".")
+	if len(parts) >= 4 && parts[0] == "sqs" && parts[2] == "amazonaws" {
+		return parts[1]
+	}
+	return "us-east-1"
+}
+
+// Close stops polling after the messages in flight are recorded
+func (c *sqsConsumer) Close() error {
+	c.cancel()
+	<-c.done
+	return nil
+}
+
+// run receives and records messages until ctx is cancelled
+func (c *sqsConsumer) run(ctx context.Context) {
+	defer close(c.done)
+
+	for ctx.Err() == nil {
+		messages, err := c.receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logrus.WithError(err).Error("failed to receive job results from SQS")
+			select {
+			case <-ctx.Done():
+			case <-time.After(sqsRetryDelay):
+			}
+			continue
+		}
+
+		for _, msg := range messages {
+			_, err := record(c.recorder, "sqs", []byte(msg.Body), msg.MessageAttributes[APIKeyHeader].StringValue)
+			if err != nil && temporary(err) {
+				continue
+			}
+
+			// Use a fresh context so shutting down doesn't leave processed
+			// messages on the queue
+			deleteCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := c.call(deleteCtx, "DeleteMessage", map[string]interface{}{
+				"QueueUrl":      c.queueURL,
+				"ReceiptHandle": msg.ReceiptHandle,
+			}, nil); err != nil {
+				logrus.WithError(err).Warn("failed to delete SQS message")
+			}
+			cancel()
+		}
+	}
+}
+
+// receive long-polls the queue for up to 10 messages
+func (c *sqsConsumer) receive(ctx context.Context) ([]sqsMessage, error) {
+	var response struct {
+		Messages []sqsMessage
+	}
+	err := c.call(ctx, "ReceiveMessage", map[string]interface{}{
+		"QueueUrl":              c.queueURL,
+		"MaxNumberOfMessages":   10,
+		"WaitTimeSeconds":       sqsWaitTime,
+		"MessageAttributeNames": []string{"All"},
+	}, &response)
+	return response.Messages, err
+}
+
+// call invokes an SQS action with the JSON protocol and decodes the
+// response into out, when set
+func (c *sqsConsumer) call(ctx context.Context, action string, input interface{}, out interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", "AmazonSQS."+action)
+	c.signer.Sign(req, body, time.Now().UTC())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s: %s", action, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
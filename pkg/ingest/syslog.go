@@ -0,0 +1,292 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// cronCommandLabel is the job label holding (part of) the crontab command,
+// for jobs the name heuristics can't match
+const cronCommandLabel = "cron_command"
+
+// Kinds of cron daemon entries
+const (
+	cronStart  = "CMD"    // A job started
+	cronEnd    = "END"    // A job finished (Debian cron -L 2, cronie CMDEND)
+	cronFailed = "FAILED" // A job exited with a non-zero status (Debian cron -L 4)
+)
+
+var (
+	// syslogLine matches traditional and high-precision syslog lines from
+	// the cron daemon
+	syslogLine = regexp.MustCompile(`^(\w{3} +\d{1,2} \d\d:\d\d:\d\d|\d{4}-\d\d-\d\dT\S+) (\S+) (?i:crond?)\[(\d+)\]: (.*)$`)
+
+	cronCommand = regexp.MustCompile(`^\((\S+)\) (CMD|END|CMDEND) \((.*)\)$`)
+	cronError   = regexp.MustCompile(`^\(\S+\) error \(grandchild #\d+ failed with exit status (\d+)\)$`)
+)
+
+// cronEntry is a parsed cron daemon log entry
+type cronEntry struct {
+	Time       time.Time
+	Host       string
+	PID        string // Process logging the entry; the same for a run's start and end
+	Kind       string
+	User       string
+	Command    string
+	ExitStatus int
+}
+
+// parseCronMessage parses the message of a cron daemon entry
+func parseCronMessage(message string) (*cronEntry, bool) {
+	if m := cronCommand.FindStringSubmatch(message); m != nil {
+		kind := cronStart
+		if m[2] != "CMD" {
+			kind = cronEnd
+		}
+		return &cronEntry{Kind: kind, User: m[1], Command: m[3]}, true
+	}
+	if m := cronError.FindStringSubmatch(message); m != nil {
+		status, _ := strconv.Atoi(m[1])
+		return &cronEntry{Kind: cronFailed, ExitStatus: status}, true
+	}
+	return nil, false
+}
+
+// parseSyslogLine parses a syslog line from the cron daemon. Traditional
+// timestamps have no year, so the most recent matching date before now
+// is used.
+func parseSyslogLine(line string, now time.Time) (*cronEntry, bool) {
+	m := syslogLine.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+
+	entry, ok := parseCronMessage(m[4])
+	if !ok {
+		return nil, false
+	}
+	entry.Host, entry.PID = m[2], m[3]
+
+	if t, err := time.Parse(time.RFC3339Nano, m[1]); err == nil {
+		entry.Time = t
+	} else if t, err := time.ParseInLocation("Jan _2 15:04:05 2006", strings.Join(strings.Fields(m[1]), " ")+" "+strconv.Itoa(now.Year()), now.Location()); err == nil {
+		if t.After(now.Add(24 * time.Hour)) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		entry.Time = t
+	} else {
+		entry.Time = now
+	}
+	return entry, true
+}
+
+// cronRun is a job run seen starting in the log
+type cronRun struct {
+	start      *cronEntry
+	seen       time.Time // When the start entry was read, as hosts' clocks may differ
+	failed     bool
+	exitStatus int
+}
+
+// SyslogTailer records job results from the cron daemon entries in the
+// system log, so existing crontabs need no changes. Runs are matched to
+// registered jobs by host and command.
+type SyslogTailer struct {
+	jobStore   *model.JobStore
+	recorder   Recorder
+	maxRuntime time.Duration
+	runs       map[string]*cronRun // By host and PID
+	cancel     context.CancelFunc
+	done       chan struct{}
+}
+
+// NewSyslogTailer starts following the configured log source
+func NewSyslogTailer(cfg *config.SyslogConfig, jobStore *model.JobStore, recorder Recorder) (*SyslogTailer, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &SyslogTailer{
+		jobStore:   jobStore,
+		recorder:   recorder,
+		maxRuntime: time.Duration(cfg.MaxRuntime) * time.Second,
+		runs:       make(map[string]*cronRun),
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+
+	entries := make(chan *cronEntry, 100)
+	var err error
+	if cfg.Source == "journald" {
+		err = followJournald(ctx, entries)
+	} else {
+		err = followSyslogFile(ctx, cfg.Source, entries)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go t.run(ctx, entries)
+	return t, nil
+}
+
+// Close stops following the log. Runs still in progress are not recorded.
+func (t *SyslogTailer) Close() error {
+	t.cancel()
+	<-t.done
+	return nil
+}
+
+// run handles log entries until ctx is cancelled, recording runs that
+// never logged an end once they exceed the maximum runtime
+func (t *SyslogTailer) run(ctx context.Context, entries <-chan *cronEntry) {
+	defer close(t.done)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry := <-entries:
+			t.handle(entry)
+		case now := <-ticker.C:
+			for key, run := range t.runs {
+				if now.Sub(run.seen) >= t.maxRuntime {
+					delete(t.runs, key)
+					t.record(run, nil)
+				}
+			}
+		}
+	}
+}
+
+// handle tracks a run from its start entry to its end entry
+func (t *SyslogTailer) handle(entry *cronEntry) {
+	key := entry.Host + "/" + entry.PID
+	run := t.runs[key]
+
+	switch entry.Kind {
+	case cronStart:
+		if run != nil {
+			// The PID was reused; the previous run never logged an end
+			t.record(run, nil)
+		}
+		t.runs[key] = &cronRun{start: entry, seen: time.Now()}
+	case cronFailed:
+		if run != nil {
+			run.failed, run.exitStatus = true, entry.ExitStatus
+		}
+	case cronEnd:
+		if run == nil {
+			return
+		}
+		delete(t.runs, key)
+		t.record(run, entry)
+	}
+}
+
+// record submits a finished run for the job it matches. Without an end
+// entry, the run is assumed to have succeeded unless a failure was logged.
+func (t *SyslogTailer) record(run *cronRun, end *cronEntry) {
+	fields := logrus.Fields{"driver": "syslog", "host": run.start.Host, "command": run.start.Command}
+
+	jobs, err := t.jobStore.ListJobs(nil)
+	if err != nil {
+		logrus.WithError(err).WithFields(fields).Error("failed to list jobs")
+		return
+	}
+
+	job := matchCronJob(jobs, run.start.Host, run.start.Command)
+	if job == nil {
+		logrus.WithFields(fields).Debug("no job matches cron entry")
+		return
+	}
+
+	result := &model.JobResult{
+		JobName:   job.Name,
+		Host:      job.Host,
+		Status:    "success",
+		Timestamp: run.start.Time,
+		Output:    fmt.Sprintf("(%s) %s", run.start.User, run.start.Command),
+	}
+	if end != nil {
+		result.Timestamp = end.Time
+		result.Duration = int(end.Time.Sub(run.start.Time).Seconds())
+	}
+	if run.failed {
+		result.Status = "failure"
+		result.Output += fmt.Sprintf("\nexit status %d", run.exitStatus)
+	}
+
+	_ = submit(t.recorder, "syslog", job.ApiKey, result)
+}
+
+// matchCronJob finds the job a crontab command runs on host. In order of
+// preference, a job matches when the command contains its cron_command
+// label, when a program in the command is named after the job (ignoring
+// directories and extensions), or when the command contains the job name
+// as a word. Ambiguous matches are ignored.
+func matchCronJob(jobs []*model.Job, host, command string) *model.Job {
+	var candidates []*model.Job
+	for _, job := range jobs {
+		if sameHost(job.Host, host) {
+			candidates = append(candidates, job)
+		}
+	}
+
+	programs := map[string]bool{}
+	for _, word := range strings.Fields(command) {
+		name := filepath.Base(strings.Trim(word, `"';&|()`))
+		programs[strings.TrimSuffix(name, filepath.Ext(name))] = true
+	}
+
+	matchers := []func(job *model.Job) bool{
+		func(job *model.Job) bool {
+			label := job.Labels[cronCommandLabel]
+			return label != "" && strings.Contains(command, label)
+		},
+		func(job *model.Job) bool {
+			return programs[job.Name]
+		},
+		func(job *model.Job) bool {
+			return regexp.MustCompile(`(^|[^\w-])` + regexp.QuoteMeta(job.Name) + `($|[^\w-])`).MatchString(command)
+		},
+	}
+
+	for _, matches := range matchers {
+		var found []*model.Job
+		for _, job := range candidates {
+			if matches(job) {
+				found = append(found, job)
+			}
+		}
+		if len(found) == 1 {
+			return found[0]
+		}
+		if len(found) > 1 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// sameHost compares host names case-insensitively, also accepting a short
+// name for its fully qualified form
+func sameHost(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	if a == b {
+		return true
+	}
+	shortA, _, _ := strings.Cut(a, ".")
+	shortB, _, _ := strings.Cut(b, ".")
+	return shortA == shortB && (shortA == a || shortB == b)
+}
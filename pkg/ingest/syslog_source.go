@@ -0,0 +1,176 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// syslogPollInterval is how often a followed file is checked for new
+	// lines and rotation
+	syslogPollInterval = time.Second
+	// journaldRestartDelay is the pause before restarting journalctl
+	journaldRestartDelay = 5 * time.Second
+)
+
+// followSyslogFile sends the cron entries appended to path, starting at
+// its current end. The file is reopened when it is rotated or truncated.
+func followSyslogFile(ctx context.Context, path string, entries chan<- *cronEntry) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open syslog file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open syslog file: %w", err)
+	}
+
+	go func() {
+		defer func() { file.Close() }()
+
+		reader := bufio.NewReader(file)
+		var partial string
+		for {
+			line, err := reader.ReadString('\n')
+			if err == nil {
+				if entry, ok := parseSyslogLine(strings.TrimRight(partial+line, "\r\n"), time.Now()); ok {
+					select {
+					case entries <- entry:
+					case <-ctx.Done():
+						return
+					}
+				}
+				partial = ""
+				continue
+			}
+			// Keep an incomplete last line until the rest is written
+			partial += line
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(syslogPollInterval):
+			}
+
+			if reopened := reopenIfRotated(file, path); reopened != nil {
+				file.Close()
+				file, partial = reopened, ""
+				reader.Reset(file)
+			}
+		}
+	}()
+	return nil
+}
+
+// reopenIfRotated opens path again when it no longer is file, or when file
+// was truncated. It returns nil when the current file should be kept.
+func reopenIfRotated(file *os.File, path string) *os.File {
+	current, err := file.Stat()
+	if err != nil {
+		return nil
+	}
+	latest, err := os.Stat(path)
+	if err != nil {
+		// Rotated but not recreated yet
+		return nil
+	}
+
+	offset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil
+	}
+	if os.SameFile(current, latest) && latest.Size() >= offset {
+		return nil
+	}
+
+	reopened, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	logrus.WithField("path", path).Debug("syslog file rotated, reopening")
+	return reopened
+}
+
+// journalEntry holds the journal fields of a cron daemon entry
+type journalEntry struct {
+	Message   interface{} `json:"MESSAGE"` // A string, or an array of bytes for binary messages
+	Hostname  string      `json:"_HOSTNAME"`
+	PID       string      `json:"_PID"`
+	Timestamp string      `json:"__REALTIME_TIMESTAMP"` // Microseconds since the epoch
+}
+
+// followJournald sends the cron entries written to the systemd journal
+// from now on, running journalctl again if it exits
+func followJournald(ctx context.Context, entries chan<- *cronEntry) error {
+	if _, err := exec.LookPath("journalctl"); err != nil {
+		return fmt.Errorf("journalctl is required for the journald source: %w", err)
+	}
+
+	go func() {
+		for {
+			if err := readJournal(ctx, entries); err != nil && ctx.Err() == nil {
+				logrus.WithError(err).Warn("journalctl stopped, restarting")
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(journaldRestartDelay):
+			}
+		}
+	}()
+	return nil
+}
+
+// readJournal runs journalctl until it exits or ctx is cancelled
+func readJournal(ctx context.Context, entries chan<- *cronEntry) error {
+	cmd := exec.CommandContext(ctx, "journalctl", "--follow", "--lines=0", "--output=json",
+		"SYSLOG_IDENTIFIER=CRON", "SYSLOG_IDENTIFIER=cron", "SYSLOG_IDENTIFIER=CROND", "SYSLOG_IDENTIFIER=crond")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		message, ok := record.Message.(string)
+		if !ok {
+			continue
+		}
+
+		entry, ok := parseCronMessage(message)
+		if !ok {
+			continue
+		}
+		entry.Host, entry.PID = record.Hostname, record.PID
+		entry.Time = time.Now()
+		if usec, err := strconv.ParseInt(record.Timestamp, 10, 64); err == nil {
+			entry.Time = time.UnixMicro(usec)
+		}
+
+		select {
+		case entries <- entry:
+		case <-ctx.Done():
+		}
+	}
+
+	return cmd.Wait()
+}
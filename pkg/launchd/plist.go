@@ -0,0 +1,288 @@
+package launchd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/report"
+)
+
+// Environment variables identifying the cronmetrics job of a launchd job.
+// launchd passes them to the program; the reporter reads them back from
+// the plist.
+const (
+	EnvServerURL = report.EnvServerURL
+	EnvJobName   = report.EnvJobName
+	EnvJobHost   = report.EnvJobHost
+	EnvAPIKey    = report.EnvAPIKey
+)
+
+// Job is a launchd job reporting to cronmetrics
+type Job struct {
+	Label      string
+	Program    []string
+	Name       string
+	Host       string
+	APIKey     string
+	ServerURL  string
+	Interval   int                // StartInterval in seconds
+	Calendar   []CalendarInterval // StartCalendarInterval entries
+	StdoutPath string
+	StderrPath string
+}
+
+// CalendarInterval is a StartCalendarInterval entry. Unset fields match
+// any value, like * in a crontab.
+type CalendarInterval map[string]int
+
+// calendarFields are the StartCalendarInterval keys in crontab order
+var calendarFields = []struct {
+	key      string
+	min, max int
+}{
+	{"Minute", 0, 59},
+	{"Hour", 0, 23},
+	{"Day", 1, 31},
+	{"Month", 1, 12},
+	{"Weekday", 0, 7},
+}
+
+// ParseCalendar converts a crontab schedule ("minute hour day month
+// weekday", with * and comma-separated values) to StartCalendarInterval
+// entries
+func ParseCalendar(spec string) ([]CalendarInterval, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(calendarFields) {
+		return nil, fmt.Errorf("calendar must have 5 fields (minute hour day month weekday): %q", spec)
+	}
+
+	intervals := []CalendarInterval{{}}
+	for i, field := range fields {
+		if field == "*" {
+			continue
+		}
+
+		def := calendarFields[i]
+		var expanded []CalendarInterval
+		for _, part := range strings.Split(field, ",") {
+			value, err := strconv.Atoi(part)
+			if err != nil || value < def.min || value > def.max {
+				return nil, fmt.Errorf("invalid %s %q: must be * or values between %d and %d", strings.ToLower(def.key), part, def.min, def.max)
+			}
+			for _, interval := range intervals {
+				entry := CalendarInterval{def.key: value}
+				for k, v := range interval {
+					entry[k] = v
+				}
+				expanded = append(expanded, entry)
+			}
+		}
+		intervals = expanded
+	}
+	return intervals, nil
+}
+
+// Plist renders the launchd property list of job
+func Plist(job *Job) []byte {
+	var buf bytes.Buffer
+	writeHeader(&buf, job.Label, job.Program)
+
+	writeKey(&buf, 1, "EnvironmentVariables")
+	buf.WriteString("\t<dict>\n")
+	writeString(&buf, 2, EnvServerURL, job.ServerURL)
+	writeString(&buf, 2, EnvJobName, job.Name)
+	writeString(&buf, 2, EnvJobHost, job.Host)
+	writeString(&buf, 2, EnvAPIKey, job.APIKey)
+	buf.WriteString("\t</dict>\n")
+
+	if job.Interval > 0 {
+		writeKey(&buf, 1, "StartInterval")
+		writeValue(&buf, 1, "integer", strconv.Itoa(job.Interval))
+	}
+	if len(job.Calendar) > 0 {
+		writeKey(&buf, 1, "StartCalendarInterval")
+		buf.WriteString("\t<array>\n")
+		for _, interval := range job.Calendar {
+			buf.WriteString("\t\t<dict>\n")
+			for _, field := range calendarFields {
+				if value, ok := interval[field.key]; ok {
+					writeKey(&buf, 3, field.key)
+					writeValue(&buf, 3, "integer", strconv.Itoa(value))
+				}
+			}
+			buf.WriteString("\t\t</dict>\n")
+		}
+		buf.WriteString("\t</array>\n")
+	}
+	if job.StdoutPath != "" {
+		writeString(&buf, 1, "StandardOutPath", job.StdoutPath)
+	}
+	if job.StderrPath != "" {
+		writeString(&buf, 1, "StandardErrorPath", job.StderrPath)
+	}
+
+	buf.WriteString("</dict>\n</plist>\n")
+	return buf.Bytes()
+}
+
+// ReporterPlist renders the property list of an agent running program
+// every interval seconds
+func ReporterPlist(label string, interval int, program []string) []byte {
+	var buf bytes.Buffer
+	writeHeader(&buf, label, program)
+	writeKey(&buf, 1, "StartInterval")
+	writeValue(&buf, 1, "integer", strconv.Itoa(interval))
+	buf.WriteString("</dict>\n</plist>\n")
+	return buf.Bytes()
+}
+
+// writeHeader opens a property list with the label and program of a job
+func writeHeader(buf *bytes.Buffer, label string, program []string) {
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	buf.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+
+	writeString(buf, 1, "Label", label)
+	writeKey(buf, 1, "ProgramArguments")
+	buf.WriteString("\t<array>\n")
+	for _, arg := range program {
+		writeValue(buf, 2, "string", arg)
+	}
+	buf.WriteString("\t</array>\n")
+}
+
+func writeKey(buf *bytes.Buffer, depth int, key string) {
+	writeValue(buf, depth, "key", key)
+}
+
+func writeString(buf *bytes.Buffer, depth int, key, value string) {
+	writeKey(buf, depth, key)
+	writeValue(buf, depth, "string", value)
+}
+
+func writeValue(buf *bytes.Buffer, depth int, tag, value string) {
+	buf.WriteString(strings.Repeat("\t", depth) + "<" + tag + ">")
+	_ = xml.EscapeText(buf, []byte(value))
+	buf.WriteString("</" + tag + ">\n")
+}
+
+// LoadJobs reads the jobs reporting to cronmetrics from the XML property
+// lists in dir, such as ~/Library/LaunchAgents. Other plists are skipped.
+func LoadJobs(dir string) ([]*Job, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.plist"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var jobs []*Job
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		plist, err := parsePlist(data)
+		if err != nil {
+			// Binary plists and unrelated files can't be ours
+			continue
+		}
+
+		label, _ := plist["Label"].(string)
+		env, _ := plist["EnvironmentVariables"].(map[string]interface{})
+		job := &Job{Label: label}
+		job.ServerURL, _ = env[EnvServerURL].(string)
+		job.Name, _ = env[EnvJobName].(string)
+		job.Host, _ = env[EnvJobHost].(string)
+		job.APIKey, _ = env[EnvAPIKey].(string)
+		if label == "" || job.APIKey == "" || job.ServerURL == "" || job.Name == "" || job.Host == "" {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// parsePlist decodes the top-level dictionary of an XML property list
+func parsePlist(data []byte) (map[string]interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("not an XML property list: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "dict" {
+			value, err := decodePlistValue(decoder, start)
+			if err != nil {
+				return nil, err
+			}
+			return value.(map[string]interface{}), nil
+		}
+	}
+}
+
+// decodePlistValue decodes the element started by start. Dictionaries
+// become maps, arrays slices, and other values strings.
+func decodePlistValue(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := map[string]interface{}{}
+		var key string
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					if err := decoder.DecodeElement(&key, &t); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				value, err := decodePlistValue(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = value
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		var array []interface{}
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				value, err := decodePlistValue(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			case xml.EndElement:
+				return array, nil
+			}
+		}
+	default:
+		var text string
+		if err := decoder.DecodeElement(&text, &start); err != nil && err != io.EOF {
+			return nil, err
+		}
+		if text == "" && (start.Name.Local == "true" || start.Name.Local == "false") {
+			text = start.Name.Local
+		}
+		return text, nil
+	}
+}
@@ -0,0 +1,184 @@
+package launchd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/report"
+)
+
+// Status is the state of a launchd job as shown by launchctl print
+type Status struct {
+	Running  bool
+	Runs     int
+	ExitCode int    // Last exit code; meaningful once Runs > 0
+	Signal   string // Last terminating signal, when the job was killed
+}
+
+// ParseStatus reads the top-level properties of launchctl print output
+func ParseStatus(output string) *Status {
+	status := &Status{}
+	for _, line := range strings.Split(output, "\n") {
+		// Nested blocks are indented further
+		if !strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "\t\t") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(line), " = ")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "state":
+			status.Running = value == "running"
+		case "runs":
+			status.Runs, _ = strconv.Atoi(value)
+		case "last exit code":
+			status.ExitCode, _ = strconv.Atoi(value)
+		case "last terminating signal":
+			status.Signal = value
+		}
+	}
+	return status
+}
+
+// Launchctl queries launchd jobs in a domain, such as gui/501 for the
+// agents of user 501 or system for daemons
+type Launchctl struct {
+	Path   string
+	Domain string
+}
+
+// Status returns the status of the job with label
+func (l *Launchctl) Status(label string) (*Status, error) {
+	output, err := exec.Command(l.Path, "print", l.Domain+"/"+label).Output()
+	if err != nil {
+		return nil, fmt.Errorf("launchctl print %s/%s: %w", l.Domain, label, err)
+	}
+	return ParseStatus(string(output)), nil
+}
+
+// reportBreakerThreshold is the number of consecutive failed submissions
+// to a server after which a report stops submitting to it
+const reportBreakerThreshold = 3
+
+// Reporter submits a job result for every launchd job run that finished
+// since the previous report. Run counts are kept in a state file between
+// reports.
+type Reporter struct {
+	Launchctl *Launchctl
+	StatePath string
+	Client    *http.Client
+	Retries   int  // Attempts after the first one for each failed submission
+	Sign      bool // Sign results with the job's API key instead of sending the key
+}
+
+// Report checks jobs and submits their new results. It returns the number
+// of results submitted; jobs that could not be checked or submitted are
+// retried by the next report.
+func (r *Reporter) Report(jobs []*Job) (int, error) {
+	state := map[string]int{}
+	if data, err := os.ReadFile(r.StatePath); err == nil {
+		if err := json.Unmarshal(data, &state); err != nil {
+			return 0, fmt.Errorf("invalid state file %s: %w", r.StatePath, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	// Once submissions keep failing, the remaining jobs are left to the
+	// next report instead of each waiting on the server
+	submitter := &report.Submitter{
+		Client:  r.Client,
+		Retries: r.Retries,
+		Breaker: &report.Breaker{Threshold: reportBreakerThreshold, Cooldown: time.Hour},
+	}
+
+	var submitted int
+	var errs []string
+	for _, job := range jobs {
+		status, err := r.Launchctl.Status(job.Label)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+
+		previous, known := state[job.Label]
+		if status.Running || status.Runs == previous {
+			continue
+		}
+		// The first report only records how many times jobs already ran
+		if known {
+			runs := status.Runs - previous
+			if runs < 0 {
+				// The job was reloaded, which resets its run count
+				runs = status.Runs
+			}
+			if runs > 0 {
+				if err := r.submit(submitter, job, status, runs); err != nil {
+					errs = append(errs, fmt.Sprintf("%s: %v", job.Label, err))
+					continue
+				}
+				submitted++
+			}
+		}
+		state[job.Label] = status.Runs
+	}
+
+	if err := r.saveState(state); err != nil {
+		return submitted, err
+	}
+	if len(errs) > 0 {
+		return submitted, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return submitted, nil
+}
+
+// submit posts the last result of job to its server
+func (r *Reporter) submit(submitter *report.Submitter, job *Job, status *Status, runs int) error {
+	result := &model.JobResult{
+		JobName:   job.Name,
+		Host:      job.Host,
+		Status:    "success",
+		Output:    fmt.Sprintf("launchd %s: last exit code %d", job.Label, status.ExitCode),
+		Timestamp: time.Now().UTC(),
+	}
+	if status.Signal != "" {
+		result.Output = fmt.Sprintf("launchd %s: terminated by %s", job.Label, status.Signal)
+	} else {
+		result.ExitCode = &status.ExitCode
+	}
+	if status.ExitCode != 0 || status.Signal != "" {
+		result.Status = "failure"
+	}
+	if runs > 1 {
+		result.Output += fmt.Sprintf(" (%d runs since the last report)", runs)
+	}
+
+	return submitter.Submit(job.ServerURL, job.APIKey, r.Sign, result)
+}
+
+// saveState writes the run counts atomically
+func (r *Reporter) saveState(state map[string]int) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.StatePath), 0o700); err != nil {
+		return err
+	}
+
+	tmp := r.StatePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.StatePath)
+}
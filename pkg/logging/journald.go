@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+// journaldSocket is where journald receives entries in its native protocol
+const journaldSocket = "/run/systemd/journal/socket"
+
+// journaldPriorities maps log levels to syslog priorities
+var journaldPriorities = map[logrus.Level]int{
+	logrus.PanicLevel: 2, // crit
+	logrus.FatalLevel: 2, // crit
+	logrus.ErrorLevel: 3, // err
+	logrus.WarnLevel:  4, // warning
+	logrus.InfoLevel:  6, // info
+	logrus.DebugLevel: 7, // debug
+	logrus.TraceLevel: 7, // debug
+}
+
+// journaldHook sends entries to journald with their fields as journal
+// fields, e.g. job_name becomes JOB_NAME
+type journaldHook struct {
+	conn *net.UnixConn
+	tag  string
+}
+
+// newJournaldHook connects to the journald socket of sink
+func newJournaldHook(sink *config.LogSinkConfig) (logrus.Hook, error) {
+	path := sink.Address
+	if path == "" {
+		path = journaldSocket
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %w", err)
+	}
+	return &journaldHook{conn: conn, tag: sinkTag(sink)}, nil
+}
+
+// Levels returns the levels the hook fires for
+func (h *journaldHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire sends the entry as one datagram
+func (h *journaldHook) Fire(entry *logrus.Entry) error {
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", entry.Message)
+	writeJournalField(&buf, "PRIORITY", fmt.Sprint(journaldPriorities[entry.Level]))
+	writeJournalField(&buf, "SYSLOG_IDENTIFIER", h.tag)
+
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := journalFieldName(key)
+		if name == "" {
+			continue
+		}
+		value := entry.Data[key]
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		writeJournalField(&buf, name, fmt.Sprint(value))
+	}
+
+	_, err := h.conn.Write(buf.Bytes())
+	return err
+}
+
+// writeJournalField appends a field in the native protocol; values with
+// newlines are length-prefixed
+func writeJournalField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalFieldName turns a log field name into a valid journal field
+// name: upper case letters, digits and underscores, starting with a letter
+func journalFieldName(key string) string {
+	var name strings.Builder
+	for _, r := range strings.ToUpper(key) {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			name.WriteRune(r)
+		default:
+			name.WriteByte('_')
+		}
+	}
+	return strings.TrimLeft(name.String(), "_0123456789")
+}
+
+// sinkTag returns the program name entries of sink are logged under
+func sinkTag(sink *config.LogSinkConfig) string {
+	if sink.Tag != "" {
+		return sink.Tag
+	}
+	return "cronmetrics"
+}
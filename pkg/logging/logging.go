@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+// megabyte is the unit of the max_size settings
+const megabyte = 1024 * 1024
+
+// Configure sets up logger from cfg: level, format, output and additional
+// sinks. An output file or sinks that cannot be opened are skipped and
+// reported in the returned error; the rest of the configuration still
+// applies.
+func Configure(logger *logrus.Logger, cfg *config.LoggingConfig) error {
+	level, err := logrus.ParseLevel(cfg.Level)
+	if err != nil {
+		level = logrus.InfoLevel
+	}
+	logger.SetLevel(level)
+
+	if cfg.Format == "text" {
+		logger.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp: true,
+		})
+	} else {
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	}
+
+	// The console outputs keep the logger's writer
+	var failed error
+	if cfg.Output != "" && cfg.Output != "stdout" && cfg.Output != "stderr" {
+		output := &RotatingFile{
+			Path:       cfg.Output,
+			MaxSize:    int64(cfg.MaxSize) * megabyte,
+			MaxAge:     time.Duration(cfg.MaxAge) * 24 * time.Hour,
+			MaxBackups: cfg.MaxBackups,
+		}
+		if err := output.open(); err != nil {
+			failed = err
+		} else {
+			logger.SetOutput(output)
+		}
+	}
+
+	for i := range cfg.Sinks {
+		sink := &cfg.Sinks[i]
+		hook, err := newSinkHook(sink, level)
+		if err != nil {
+			failed = fmt.Errorf("%s sink: %w", sink.Type, err)
+			continue
+		}
+		logger.AddHook(hook)
+	}
+	return failed
+}
+
+// newSinkHook returns the hook sending entries to sink. Entries more
+// verbose than the sink level, or the logger level when unset, are dropped.
+func newSinkHook(sink *config.LogSinkConfig, defaultLevel logrus.Level) (logrus.Hook, error) {
+	level := defaultLevel
+	if sink.Level != "" {
+		parsed, err := logrus.ParseLevel(sink.Level)
+		if err != nil {
+			return nil, err
+		}
+		level = parsed
+	}
+
+	switch sink.Type {
+	case "syslog":
+		hook, err := newSyslogHook(sink)
+		if err != nil {
+			return nil, err
+		}
+		return &levelHook{Hook: hook, levels: levelsUpTo(level)}, nil
+	case "journald":
+		hook, err := newJournaldHook(sink)
+		if err != nil {
+			return nil, err
+		}
+		return &levelHook{Hook: hook, levels: levelsUpTo(level)}, nil
+	case "file":
+		return &writerHook{
+			writer: &RotatingFile{
+				Path:       sink.Path,
+				MaxSize:    int64(sink.MaxSize) * megabyte,
+				MaxAge:     time.Duration(sink.MaxAge) * 24 * time.Hour,
+				MaxBackups: sink.MaxBackups,
+			},
+			levels: levelsUpTo(level),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown sink type")
+	}
+}
+
+// levelsUpTo returns the levels at least as severe as level
+func levelsUpTo(level logrus.Level) []logrus.Level {
+	var levels []logrus.Level
+	for _, l := range logrus.AllLevels {
+		if l <= level {
+			levels = append(levels, l)
+		}
+	}
+	return levels
+}
+
+// levelHook restricts a hook to some levels
+type levelHook struct {
+	logrus.Hook
+	levels []logrus.Level
+}
+
+// Levels returns the levels the hook fires for
+func (h *levelHook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// writerHook writes entries to a writer, formatted like the logger output
+type writerHook struct {
+	writer io.Writer
+	levels []logrus.Level
+}
+
+// Levels returns the levels the hook fires for
+func (h *writerHook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire writes the entry
+func (h *writerHook) Fire(entry *logrus.Entry) error {
+	line, err := entry.Logger.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	_, err = h.writer.Write(line)
+	return err
+}
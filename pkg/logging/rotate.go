@@ -0,0 +1,130 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat names rotated files, e.g. cronmetrics.log.20261016-122233.000
+const backupTimeFormat = "20060102-150405.000"
+
+// RotatingFile is a log file that is renamed once it grows past MaxSize,
+// keeping rotated files for at most MaxAge and at most MaxBackups of them.
+// Zero limits disable rotation or pruning.
+type RotatingFile struct {
+	Path       string
+	MaxSize    int64 // Bytes
+	MaxAge     time.Duration
+	MaxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Write appends p to the file, rotating it first when p would not fit
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if f.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.MaxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// open opens the file for appending
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0o750); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// rotate renames the current file after the current time, opens a new one
+// and prunes old rotated files
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	if err := os.Rename(f.Path, f.Path+"."+time.Now().Format(backupTimeFormat)); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.prune()
+	return nil
+}
+
+// prune removes rotated files beyond MaxBackups or older than MaxAge
+func (f *RotatingFile) prune() {
+	if f.MaxBackups == 0 && f.MaxAge == 0 {
+		return
+	}
+
+	matches, err := filepath.Glob(f.Path + ".*")
+	if err != nil {
+		return
+	}
+	var backups []string
+	for _, match := range matches {
+		if _, err := time.Parse(backupTimeFormat, strings.TrimPrefix(match, f.Path+".")); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	// Newest first; the names sort by rotation time
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	for i, backup := range backups {
+		expired := false
+		if f.MaxAge > 0 {
+			if info, err := os.Stat(backup); err == nil && time.Since(info.ModTime()) > f.MaxAge {
+				expired = true
+			}
+		}
+		if expired || (f.MaxBackups > 0 && i >= f.MaxBackups) {
+			_ = os.Remove(backup)
+		}
+	}
+}
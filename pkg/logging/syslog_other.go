@@ -0,0 +1,16 @@
+//go:build windows || plan9
+
+package logging
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+// newSyslogHook reports that syslog is unavailable on this platform
+func newSyslogHook(sink *config.LogSinkConfig) (logrus.Hook, error) {
+	return nil, fmt.Errorf("syslog is not supported on %s", runtime.GOOS)
+}
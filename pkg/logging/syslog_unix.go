@@ -0,0 +1,42 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/sirupsen/logrus"
+	logrussyslog "github.com/sirupsen/logrus/hooks/syslog"
+)
+
+// syslogFacilities maps facility names to their syslog values
+var syslogFacilities = map[string]syslog.Priority{
+	"kern": syslog.LOG_KERN, "user": syslog.LOG_USER, "mail": syslog.LOG_MAIL,
+	"daemon": syslog.LOG_DAEMON, "auth": syslog.LOG_AUTH, "syslog": syslog.LOG_SYSLOG,
+	"lpr": syslog.LOG_LPR, "news": syslog.LOG_NEWS, "uucp": syslog.LOG_UUCP,
+	"cron": syslog.LOG_CRON, "authpriv": syslog.LOG_AUTHPRIV, "ftp": syslog.LOG_FTP,
+	"local0": syslog.LOG_LOCAL0, "local1": syslog.LOG_LOCAL1, "local2": syslog.LOG_LOCAL2,
+	"local3": syslog.LOG_LOCAL3, "local4": syslog.LOG_LOCAL4, "local5": syslog.LOG_LOCAL5,
+	"local6": syslog.LOG_LOCAL6, "local7": syslog.LOG_LOCAL7,
+}
+
+// newSyslogHook connects to the syslog daemon of sink
+func newSyslogHook(sink *config.LogSinkConfig) (logrus.Hook, error) {
+	facility := sink.Facility
+	if facility == "" {
+		facility = "daemon"
+	}
+	priority, ok := syslogFacilities[facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility: %s", facility)
+	}
+
+	var network, address string
+	if sink.Address != "" {
+		network, address, _ = strings.Cut(sink.Address, "://")
+	}
+	return logrussyslog.NewSyslogHook(network, address, priority|syslog.LOG_INFO, sinkTag(sink))
+}
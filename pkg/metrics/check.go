@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// JobCheck is the state of a job as evaluated on demand, e.g. right after
+// fixing it, instead of at the next scrape
+type JobCheck struct {
+	JobID     int       `json:"job_id"`
+	JobName   string    `json:"job_name"`
+	Host      string    `json:"host"`
+	Value     float64   `json:"value"`              // cronjob_status value, -1 when Excluded
+	Reason    string    `json:"reason"`             // e.g. "success", "failure", "missed_deadline"
+	Excluded  bool      `json:"excluded,omitempty"` // The job's status leaves it out of the metrics
+	CheckedAt time.Time `json:"checked_at"`
+	Metrics   string    `json:"metrics"` // The job's series as the next scrape reports them
+}
+
+// CheckJob evaluates the job's status and metrics now. Pass a job freshly
+// read from the database, so changes since it was last read are seen.
+func (c *Collector) CheckJob(ctx context.Context, job *model.Job) (*JobCheck, error) {
+	now := time.Now().UTC()
+	s := c.settings.Load()
+	value, reason := c.calculateJobStatus(ctx, s, job, now)
+	jobMetrics, err := c.GatherJob(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JobCheck{
+		JobID:     job.ID,
+		JobName:   job.Name,
+		Host:      job.Host,
+		Value:     value,
+		Reason:    reason,
+		Excluded:  s.statusMapping.Excluded(job),
+		CheckedAt: now,
+		Metrics:   jobMetrics,
+	}, nil
+}
+
+// JobStatus evaluates the job's cronjob_status value and its reason now,
+// like CheckJob without gathering the job's metrics
+func (c *Collector) JobStatus(ctx context.Context, job *model.Job) (float64, string) {
+	return c.calculateJobStatus(ctx, c.settings.Load(), job, time.Now().UTC())
+}
@@ -1,29 +1,53 @@
 package metrics
 
 import (
+	"context"
 	"fmt"
-	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jaepetto/cron-exporter/pkg/model"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
-	dto "github.com/prometheus/client_model/go"
+	"github.com/jaepetto/cron-exporter/pkg/slo"
 )
 
 // Collector implements Prometheus metrics collection for cron jobs
 type Collector struct {
 	jobStore       *model.JobStore
 	jobResultStore *model.JobResultStore
-	registry       *prometheus.Registry
-
-	// Metrics
-	jobStatus       *prometheus.GaugeVec
-	jobStatusReason *prometheus.GaugeVec
-	jobLastRun      *prometheus.GaugeVec
-	jobDuration     *prometheus.GaugeVec
-	totalJobs       prometheus.Gauge
+
+	settingsMu sync.Mutex               // Serializes settings changes
+	settings   atomic.Pointer[settings] // Replaced as a whole, never modified
+
+	rejectedMu  sync.Mutex
+	rejected    map[string]uint64       // Rejected job results by reason, since startup
+	rateLimited map[rateLimitKey]uint64 // Rate limited job results by job and action, since startup
+}
+
+// rateLimitKey identifies a job and what its rate limited results became
+type rateLimitKey struct {
+	jobName, host, action string
+}
+
+// settings are the collector's optional features. A gather loads them
+// once, so it sees one consistent set even when they change meanwhile.
+type settings struct {
+	labelFilter   *LabelFilter   // nil emits every user label
+	healthScorer  *HealthScorer  // nil disables the group health score
+	sloTracker    *slo.Tracker   // nil when no SLO is defined
+	statusMapping *StatusMapping // nil reports maintenance and paused as -1
+	sources       []MetricSource
+}
+
+// configure replaces the settings with a changed copy
+func (c *Collector) configure(change func(*settings)) {
+	c.settingsMu.Lock()
+	defer c.settingsMu.Unlock()
+
+	next := *c.settings.Load()
+	change(&next)
+	c.settings.Store(&next)
 }
 
 // NewCollector creates a new metrics collector
@@ -31,210 +55,256 @@ func NewCollector(jobStore *model.JobStore, jobResultStore *model.JobResultStore
 	collector := &Collector{
 		jobStore:       jobStore,
 		jobResultStore: jobResultStore,
-		registry:       prometheus.NewRegistry(),
+		rejected:       make(map[string]uint64),
+		rateLimited:    make(map[rateLimitKey]uint64),
 	}
-
-	// Define metrics - use only fixed labels, dynamic labels will be added at runtime
-	collector.jobStatus = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "cronjob_status",
-			Help: "Status of cron job: 1=success, 0=failure, -1=maintenance/paused",
-		},
-		[]string{"job_name", "host"}, // Start with base labels only
-	)
-
-	collector.jobStatusReason = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "cronjob_status_reason",
-			Help: "Reason for current job status",
-		},
-		[]string{"job_name", "host", "reason"},
-	)
-
-	collector.jobLastRun = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "cronjob_last_run_timestamp",
-			Help: "Timestamp of last job execution",
-		},
-		[]string{"job_name", "host"},
-	)
-
-	collector.jobDuration = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "cronjob_duration_seconds",
-			Help: "Duration of last job execution in seconds",
-		},
-		[]string{"job_name", "host"},
-	)
-
-	collector.totalJobs = prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "cronjob_total",
-			Help: "Total number of registered cron jobs",
-		},
-	)
-
+	for _, reason := range model.RejectionReasons {
+		collector.rejected[reason] = 0
+	}
+	collector.settings.Store(&settings{})
 	return collector
 }
 
-// Register registers the collector with Prometheus
-func (c *Collector) Register() error {
-	// Register metrics with registry
-	c.registry.MustRegister(c.jobStatus)
-	c.registry.MustRegister(c.jobStatusReason)
-	c.registry.MustRegister(c.jobLastRun)
-	c.registry.MustRegister(c.jobDuration)
-	c.registry.MustRegister(c.totalJobs)
-
-	return nil
-}
-
 // Gather collects and returns metrics in Prometheus format
 func (c *Collector) Gather() (string, error) {
+	return c.GatherSelected(context.Background(), nil)
+}
+
+// GatherSelected collects the metrics of the jobs matching selector, or of
+// all jobs when selector is nil. cronjob_total counts the selected jobs, so
+// summing it across shards gives the fleet total; jobs whose status is
+// excluded from the metrics are not counted. The database is queried
+// with ctx; once it is done, an error is returned instead of partial metrics.
+func (c *Collector) GatherSelected(ctx context.Context, selector *JobSelector) (string, error) {
+	var labelFilters map[string]string
+	if selector != nil {
+		labelFilters = selector.Labels
+	}
+
 	// Get all jobs and generate manual metrics
-	jobs, err := c.jobStore.ListJobs(nil)
+	jobs, err := c.jobStore.WithContext(ctx).ListJobs(labelFilters)
 	if err != nil {
 		return "", fmt.Errorf("failed to list jobs: %w", err)
 	}
 
-	var builder strings.Builder
-	now := time.Now().UTC()
-
-	// Write help and type comments for cronjob_status
-	builder.WriteString("# HELP cronjob_status Status of cron job: 1=success, 0=failure, -1=maintenance/paused, -2=missed_deadline\n")
-	builder.WriteString("# TYPE cronjob_status gauge\n")
-
-	// Generate job status metrics (without status label)
-	for _, job := range jobs {
-		status, _ := c.calculateJobStatus(job, now)
-
-		// Build labels string for cronjob_status (no status label)
-		var labels []string
-		labels = append(labels, fmt.Sprintf(`job_name="%s"`, job.Name))
-		labels = append(labels, fmt.Sprintf(`host="%s"`, job.Host))
-
-		// Add user-defined labels
-		for k, v := range job.Labels {
-			labels = append(labels, fmt.Sprintf(`%s="%s"`, k, v))
+	// Group health scores cover every listed job; like the rejection
+	// counters, only one shard exports them
+	listed := jobs
+	if selector != nil {
+		listed = make([]*model.Job, 0, len(jobs))
+		selected := make([]*model.Job, 0, len(jobs))
+		for _, job := range jobs {
+			if !selector.matches(job) {
+				continue
+			}
+			listed = append(listed, job)
+			if selector.inShard(job) {
+				selected = append(selected, job)
+			}
 		}
-
-		labelsStr := strings.Join(labels, ",")
-		builder.WriteString(fmt.Sprintf("cronjob_status{%s} %g\n", labelsStr, status))
+		jobs = selected
 	}
 
-	// Write last run timestamps
-	builder.WriteString("# HELP cronjob_last_run_timestamp Timestamp of last job execution\n")
-	builder.WriteString("# TYPE cronjob_last_run_timestamp gauge\n")
-	for _, job := range jobs {
-		builder.WriteString(fmt.Sprintf("cronjob_last_run_timestamp{job_name=\"%s\",host=\"%s\"} %d\n",
-			job.Name, job.Host, job.LastReportedAt.Unix()))
-	}
+	scrape := c.newScrape(jobs, listed, selector == nil || selector.Shard <= 1)
+	var builder strings.Builder
+	c.writeJobMetrics(ctx, &builder, scrape)
 
 	// Write total jobs
 	builder.WriteString("# HELP cronjob_total Total number of registered cron jobs\n")
 	builder.WriteString("# TYPE cronjob_total gauge\n")
-	builder.WriteString(fmt.Sprintf("cronjob_total %d\n", len(jobs)))
+	builder.WriteString(fmt.Sprintf("cronjob_total %d\n", len(scrape.Jobs)))
 
+	if err := c.writeSources(ctx, &builder, scrape); err != nil {
+		return "", err
+	}
+
+	// Statuses whose last result could not be read were guessed
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("failed to gather metrics: %w", err)
+	}
 	return builder.String(), nil
 }
 
-// Handler returns an HTTP handler for Prometheus metrics scraping
-func (c *Collector) Handler() http.Handler {
-	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{
-		EnableOpenMetrics: true,
-	})
+// RecordRejection counts a rejected job result submission
+func (c *Collector) RecordRejection(reason string) {
+	c.rejectedMu.Lock()
+	defer c.rejectedMu.Unlock()
+	c.rejected[reason]++
 }
 
-// updateMetrics updates all metrics with current job data
-func (c *Collector) updateMetrics() error {
-	// Clear existing metrics
-	c.jobStatus.Reset()
-	c.jobStatusReason.Reset()
-	c.jobLastRun.Reset()
-	c.jobDuration.Reset()
+// RecordRateLimited counts a job result submitted before the job's minimum
+// interval passed, by what became of it: "rejected" or "coalesced"
+func (c *Collector) RecordRateLimited(jobName, host, action string) {
+	c.rejectedMu.Lock()
+	defer c.rejectedMu.Unlock()
+	c.rateLimited[rateLimitKey{jobName, host, action}]++
+}
 
-	// Get all jobs
-	jobs, err := c.jobStore.ListJobs(nil)
-	if err != nil {
-		return fmt.Errorf("failed to list jobs: %w", err)
+// GatherJob returns the metrics of a single job in Prometheus format. Only
+// the job's own series are included, not fleet-wide totals.
+func (c *Collector) GatherJob(ctx context.Context, job *model.Job) (string, error) {
+	jobs := []*model.Job{job}
+	scrape := c.newScrape(jobs, jobs, false)
+	var builder strings.Builder
+	c.writeJobMetrics(ctx, &builder, scrape)
+	if err := c.writeSources(ctx, &builder, scrape); err != nil {
+		return "", err
 	}
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	return builder.String(), nil
+}
 
-	c.totalJobs.Set(float64(len(jobs)))
+// writeJobMetrics writes the per-job series of the scrape's jobs
+func (c *Collector) writeJobMetrics(ctx context.Context, builder *strings.Builder, scrape *Scrape) {
+	// Write help and type comments for cronjob_status
+	builder.WriteString("# HELP cronjob_status " + scrape.settings.statusHelp() + "\n")
+	builder.WriteString("# TYPE cronjob_status gauge\n")
 
-	now := time.Now().UTC()
+	// Generate job status metrics (without status label)
+	for _, job := range scrape.Jobs {
+		status, _ := scrape.Status(ctx, job)
+		builder.WriteString(fmt.Sprintf("cronjob_status{%s} %g\n", scrape.JobLabels(job), status))
+	}
 
-	for _, job := range jobs {
-		// Create base labels from job metadata
-		statusLabels := prometheus.Labels{
-			"job_name": job.Name,
-			"host":     job.Host,
-		}
+	// Write last run timestamps
+	builder.WriteString("# HELP cronjob_last_run_timestamp Timestamp of last job execution\n")
+	builder.WriteString("# TYPE cronjob_last_run_timestamp gauge\n")
+	for _, job := range scrape.Jobs {
+		builder.WriteString(fmt.Sprintf("cronjob_last_run_timestamp{job_name=\"%s\",host=\"%s\"} %d\n",
+			labelValue(job.Name), labelValue(job.Host), job.LastReportedAt.Unix()))
+	}
 
-		// Add user-defined labels to status labels
-		for k, v := range job.Labels {
-			statusLabels[k] = v
+	// Write the age of each job's last report, so alerts can apply their own
+	// thresholds instead of the stored automatic failure threshold
+	builder.WriteString("# HELP cronjob_seconds_since_last_report Seconds since the job last reported, as of the scrape\n")
+	builder.WriteString("# TYPE cronjob_seconds_since_last_report gauge\n")
+	for _, job := range scrape.Jobs {
+		age := scrape.Time.Sub(job.LastReportedAt).Seconds()
+		if age < 0 {
+			age = 0 // Clock skew between the server and the reporting host
 		}
+		builder.WriteString(fmt.Sprintf("cronjob_seconds_since_last_report{%s} %d\n", scrape.JobLabels(job), int64(age)))
+	}
 
-		// Determine job status and reason
-		status, reason := c.calculateJobStatus(job, now)
-
-		// Set status metric with all labels (excluding status)
-		c.jobStatus.With(statusLabels).Set(status)
+	// Write each job's threshold with the same labels as its report age, so
+	// generic rules can compare the two
+	builder.WriteString("# HELP cronjob_failure_threshold_seconds Seconds without a report after which the job counts as missed\n")
+	builder.WriteString("# TYPE cronjob_failure_threshold_seconds gauge\n")
+	for _, job := range scrape.Jobs {
+		builder.WriteString(fmt.Sprintf("cronjob_failure_threshold_seconds{%s} %d\n", scrape.JobLabels(job), job.AutomaticFailureThreshold))
+	}
 
-		// Set status info metric with status as value (not implemented via Prometheus client)
-		// Status info metric is generated manually in Gather() method with string values
+	// Write each job's deadline, which scheduled jobs take from their
+	// schedule rather than from their last report
+	builder.WriteString("# HELP cronjob_deadline_timestamp Time after which the job counts as missed unless it reports\n")
+	builder.WriteString("# TYPE cronjob_deadline_timestamp gauge\n")
+	for _, job := range scrape.Jobs {
+		builder.WriteString(fmt.Sprintf("cronjob_deadline_timestamp{%s} %d\n", scrape.JobLabels(job), job.Deadline().Unix()))
+	}
 
-		// Set reason metric if there's a specific reason
-		if reason != "" {
-			reasonLabels := prometheus.Labels{
-				"job_name": job.Name,
-				"host":     job.Host,
-				"reason":   reason,
-			}
-			c.jobStatusReason.With(reasonLabels).Set(1)
+	// Write the interval of each scheduled job's next runs, to compare with
+	// its threshold
+	builder.WriteString("# HELP cronjob_schedule_interval_seconds Seconds between the next two runs the job's schedule expects\n")
+	builder.WriteString("# TYPE cronjob_schedule_interval_seconds gauge\n")
+	for _, job := range scrape.Jobs {
+		if interval := job.ScheduleInterval(scrape.Time); interval > 0 {
+			builder.WriteString(fmt.Sprintf("cronjob_schedule_interval_seconds{%s} %d\n", scrape.JobLabels(job), int64(interval.Seconds())))
 		}
+	}
 
-		// Set last run timestamp
-		lastRunLabels := prometheus.Labels{
-			"job_name": job.Name,
-			"host":     job.Host,
+	// Write snooze ends of the snoozed jobs
+	builder.WriteString("# HELP cronjob_snoozed_until_timestamp End of the job's snooze, during which failures are not reported\n")
+	builder.WriteString("# TYPE cronjob_snoozed_until_timestamp gauge\n")
+	for _, job := range scrape.Jobs {
+		if job.IsSnoozed(scrape.Time) {
+			builder.WriteString(fmt.Sprintf("cronjob_snoozed_until_timestamp{job_name=\"%s\",host=\"%s\"} %d\n",
+				labelValue(job.Name), labelValue(job.Host), job.SnoozedUntil.Unix()))
 		}
-		c.jobLastRun.With(lastRunLabels).Set(float64(job.LastReportedAt.Unix()))
+	}
+}
 
-		// TODO: Set duration from last job result
-		// This would require querying job results, which we'll implement later
+// jobLabels returns the label set of a job's series: name, host and the
+// user-defined labels permitted by the label filter
+func (s *settings) jobLabels(job *model.Job) string {
+	labels := []string{
+		fmt.Sprintf(`job_name="%s"`, labelValue(job.Name)),
+		fmt.Sprintf(`host="%s"`, labelValue(job.Host)),
+	}
+	for _, k := range s.metricLabelKeys(job) {
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, k, labelValue(job.Labels[k])))
 	}
+	return strings.Join(labels, ",")
+}
 
-	return nil
+// calculateJobStatus determines the current status and reason for a job.
+// Snoozed jobs keep recording results but report -3 instead of a failure.
+func (c *Collector) calculateJobStatus(ctx context.Context, s *settings, job *model.Job, now time.Time) (float64, string) {
+	status, reason := c.unsnoozedJobStatus(ctx, s, job, now)
+	if (status == 0 || status == -2 || status == -4 || status == -5) && job.IsSnoozed(now) {
+		return -3, "snoozed"
+	}
+	return status, reason
 }
 
-// calculateJobStatus determines the current status and reason for a job
-func (c *Collector) calculateJobStatus(job *model.Job, now time.Time) (float64, string) {
-	// Jobs in maintenance or paused status
-	if job.Status == "maintenance" {
-		return -1, "maintenance"
+// unsnoozedJobStatus determines the status and reason of a job ignoring
+// any snooze
+func (c *Collector) unsnoozedJobStatus(ctx context.Context, s *settings, job *model.Job, now time.Time) (float64, string) {
+	// Jobs in maintenance or paused status, valued by the status mapping
+	if job.Status == "maintenance" || job.Status == "paused" {
+		return s.statusMapping.value(job.Status), job.Status
 	}
-	if job.Status == "paused" {
-		return -1, "paused"
+
+	// Check if job has missed its deadline, from its schedule or threshold
+	if job.MissedDeadline(now) {
+		return -2, "missed_deadline"
 	}
 
-	// Check if job has exceeded its failure threshold
-	timeSinceLastReport := now.Sub(job.LastReportedAt)
-	thresholdDuration := time.Duration(job.AutomaticFailureThreshold) * time.Second
+	// A run that started while the previous one was still open overlaps
+	// it until it finishes, whatever the previous run's outcome
+	if c.jobResultStore != nil {
+		start, err := c.jobResultStore.WithContext(ctx).OpenStart(job.Name, job.Host)
+		if err == nil && start != nil && start.Overlapping {
+			return -5, "overlapping"
+		}
+	}
 
-	if timeSinceLastReport > thresholdDuration {
-		return -2, "missed_deadline"
+	// Fan-out jobs take the status of their latest decided run, its shards
+	// aggregated by the job's policy. A run whose shards are still
+	// reporting leaves the previous verdict.
+	if c.jobResultStore != nil && job.ShardPolicy != "" {
+		runs, err := c.jobResultStore.WithContext(ctx).GetShardRuns(job.Name, job.Host, 2)
+		if err == nil && len(runs) > 0 {
+			for _, run := range runs {
+				switch run.Status(job.ShardPolicy) {
+				case "success":
+					return 1, "success"
+				case "failure":
+					return 0, "failure"
+				}
+			}
+			return 1, "success"
+		}
 	}
 
 	// Get the most recent job result to determine actual status
 	if c.jobResultStore != nil {
-		results, err := c.jobResultStore.GetJobResults(job.Name, job.Host, 1)
+		results, err := c.jobResultStore.WithContext(ctx).GetJobResults(job.Name, job.Host, 1)
 		if err == nil && len(results) > 0 {
 			lastResult := results[0]
 			if lastResult.Status == "success" {
+				// A counter job that processed too few items is failing,
+				// however its run ended
+				if job.Type == model.JobTypeCounter && job.ThroughputFloor > 0 &&
+					lastResult.ItemsProcessed != nil && *lastResult.ItemsProcessed < job.ThroughputFloor {
+					return 0, "low_throughput"
+				}
 				return 1, "success"
 			} else if lastResult.Status == "failure" {
 				return 0, "failure"
+			} else if lastResult.Status == model.ResultLost {
+				return -4, "lost"
 			}
 		}
 	}
@@ -242,64 +312,3 @@ func (c *Collector) calculateJobStatus(job *model.Job, now time.Time) (float64,
 	// Fallback: assume success if within threshold and not in maintenance
 	return 1, "success"
 }
-
-// writeMetricFamily writes a metric family in Prometheus text format
-func (c *Collector) writeMetricFamily(builder *strings.Builder, mf *dto.MetricFamily) error {
-	metricName := mf.GetName()
-	metricType := mf.GetType()
-
-	// Write HELP comment
-	if help := mf.GetHelp(); help != "" {
-		builder.WriteString(fmt.Sprintf("# HELP %s %s\n", metricName, help))
-	}
-
-	// Write TYPE comment
-	builder.WriteString(fmt.Sprintf("# TYPE %s %s\n", metricName, strings.ToLower(metricType.String())))
-
-	// Write metrics
-	for _, metric := range mf.GetMetric() {
-		builder.WriteString(metricName)
-
-		// Write labels
-		if len(metric.GetLabel()) > 0 {
-			builder.WriteString("{")
-			first := true
-			for _, label := range metric.GetLabel() {
-				if !first {
-					builder.WriteString(",")
-				}
-				builder.WriteString(fmt.Sprintf(`%s="%s"`, label.GetName(), label.GetValue()))
-				first = false
-			}
-			builder.WriteString("}")
-		}
-
-		// Write value
-		var value float64
-		switch metricType {
-		case dto.MetricType_COUNTER:
-			value = metric.GetCounter().GetValue()
-		case dto.MetricType_GAUGE:
-			value = metric.GetGauge().GetValue()
-		case dto.MetricType_HISTOGRAM:
-			// Handle histogram if needed
-			continue
-		case dto.MetricType_SUMMARY:
-			// Handle summary if needed
-			continue
-		default:
-			continue
-		}
-
-		builder.WriteString(fmt.Sprintf(" %g", value))
-
-		// Write timestamp if present
-		if metric.GetTimestampMs() != 0 {
-			builder.WriteString(fmt.Sprintf(" %d", metric.GetTimestampMs()))
-		}
-
-		builder.WriteString("\n")
-	}
-
-	return nil
-}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// DefaultDurationBuckets are the upper bounds of the cronjob_duration_seconds
+// buckets when none are configured, from a second to a day
+var DefaultDurationBuckets = []float64{1, 5, 15, 30, 60, 300, 900, 1800, 3600, 7200, 21600, 86400}
+
+// durationSource writes the durations of the jobs' runs
+type durationSource struct {
+	store   *model.JobResultStore
+	buckets []float64
+}
+
+// NewDurationSource returns a source of the histogram of each job's run
+// durations over buckets, DefaultDurationBuckets when empty, and of the
+// duration of its latest timed run. The results of a run grouped by run ID
+// carry the duration of the whole run.
+func NewDurationSource(store *model.JobResultStore, buckets []float64) MetricSource {
+	if len(buckets) == 0 {
+		buckets = DefaultDurationBuckets
+	}
+	return durationSource{store, buckets}
+}
+
+func (durationSource) Name() string { return "duration" }
+
+func (d durationSource) WriteMetrics(ctx context.Context, builder *strings.Builder, scrape *Scrape) error {
+	if len(scrape.Jobs) == 0 {
+		return nil
+	}
+
+	store := d.store.WithContext(ctx)
+	histograms, err := store.DurationHistograms(d.buckets)
+	if err != nil {
+		return err
+	}
+	durations, err := store.LastDurations()
+	if err != nil {
+		return err
+	}
+
+	// Like cronjob_items_processed_total, the histogram only covers the
+	// stored results, so its counts drop when old ones are removed; rate()
+	// takes that for a counter reset
+	builder.WriteString("# HELP cronjob_duration_seconds Duration of the job's stored timed runs in seconds\n")
+	builder.WriteString("# TYPE cronjob_duration_seconds histogram\n")
+	for _, job := range scrape.Jobs {
+		histogram, ok := histograms[model.JobRef{Name: job.Name, Host: job.Host}]
+		if !ok {
+			continue
+		}
+		labels := scrape.JobLabels(job)
+		for i, bound := range d.buckets {
+			builder.WriteString(fmt.Sprintf("cronjob_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, strconv.FormatFloat(bound, 'g', -1, 64), histogram.Buckets[i]))
+		}
+		builder.WriteString(fmt.Sprintf("cronjob_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, histogram.Count))
+		builder.WriteString(fmt.Sprintf("cronjob_duration_seconds_sum{%s} %d\n", labels, histogram.Sum))
+		builder.WriteString(fmt.Sprintf("cronjob_duration_seconds_count{%s} %d\n", labels, histogram.Count))
+	}
+
+	builder.WriteString("# HELP cronjob_last_duration_seconds Duration of the job's latest timed run in seconds\n")
+	builder.WriteString("# TYPE cronjob_last_duration_seconds gauge\n")
+	for _, job := range scrape.Jobs {
+		if duration, ok := durations[model.JobRef{Name: job.Name, Host: job.Host}]; ok {
+			builder.WriteString(fmt.Sprintf("cronjob_last_duration_seconds{%s} %d\n", scrape.JobLabels(job), duration))
+		}
+	}
+	return nil
+}
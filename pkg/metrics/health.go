@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// HealthScorer computes a 0-100 health score per group of jobs. Jobs are
+// grouped by the value of one label, and each job weighs in according to
+// its criticality label.
+type HealthScorer struct {
+	groupLabel       string
+	criticalityLabel string
+	weights          map[string]float64 // Weight by criticality value; others weigh 1
+}
+
+// NewHealthScorer creates a scorer grouping jobs by groupLabel. It returns
+// nil, which disables the score, when groupLabel is empty.
+func NewHealthScorer(groupLabel, criticalityLabel string, weights map[string]float64) *HealthScorer {
+	if groupLabel == "" {
+		return nil
+	}
+	return &HealthScorer{
+		groupLabel:       groupLabel,
+		criticalityLabel: criticalityLabel,
+		weights:          weights,
+	}
+}
+
+// SetHealthScorer enables the per-group health score metric
+func (c *Collector) SetHealthScorer(scorer *HealthScorer) {
+	c.configure(func(s *settings) { s.healthScorer = scorer })
+}
+
+// weight returns the job's weight in its group's score. Criticality values
+// match case-insensitively, as configuration keys are lowercased.
+func (s *HealthScorer) weight(job *model.Job) float64 {
+	if weight, ok := s.weights[strings.ToLower(job.Labels[s.criticalityLabel])]; ok && s.criticalityLabel != "" {
+		return weight
+	}
+	return 1
+}
+
+// Scores returns the health score of each group of the scrape's listed
+// jobs: the weighted share of its jobs that are succeeding. Jobs in
+// maintenance, paused or snoozed are left out, and groups without any
+// scored job have no score.
+func (s *HealthScorer) Scores(ctx context.Context, scrape *Scrape) map[string]float64 {
+	healthy := make(map[string]float64)
+	total := make(map[string]float64)
+	for _, job := range scrape.Listed {
+		status, reason := scrape.Status(ctx, job)
+		if reason == "maintenance" || reason == "paused" || reason == "snoozed" {
+			continue
+		}
+
+		group := job.Labels[s.groupLabel]
+		weight := s.weight(job)
+		if weight <= 0 {
+			continue
+		}
+		total[group] += weight
+		if status == 1 {
+			healthy[group] += weight
+		}
+	}
+
+	scores := make(map[string]float64, len(total))
+	for group, weight := range total {
+		scores[group] = 100 * healthy[group] / weight
+	}
+	return scores
+}
+
+// Name identifies the scorer as a MetricSource
+func (s *HealthScorer) Name() string { return "health" }
+
+// WriteMetrics writes the health score of each group of jobs. Like other
+// fleet-wide series, it covers every listed job, not only those of the
+// scrape's shard.
+func (s *HealthScorer) WriteMetrics(ctx context.Context, builder *strings.Builder, scrape *Scrape) error {
+	if !scrape.FleetWide {
+		return nil
+	}
+	scores := s.Scores(ctx, scrape)
+
+	groups := make([]string, 0, len(scores))
+	for group := range scores {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	builder.WriteString("# HELP cronjob_group_health_score Criticality-weighted share of a group's jobs that are succeeding, 0-100\n")
+	builder.WriteString("# TYPE cronjob_group_health_score gauge\n")
+	for _, group := range groups {
+		builder.WriteString(fmt.Sprintf("cronjob_group_health_score{group=\"%s\"} %g\n", labelValue(group), scores[group]))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// LabelFilter decides which user-defined job labels are emitted as metric
+// labels. Filtered labels stay on the job and remain queryable via the API.
+type LabelFilter struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+// NewLabelFilter creates a filter from an allowlist and a denylist of label
+// keys. An empty allowlist allows every key; the denylist always wins.
+func NewLabelFilter(allowlist, denylist []string) *LabelFilter {
+	filter := &LabelFilter{
+		allow: make(map[string]bool, len(allowlist)),
+		deny:  make(map[string]bool, len(denylist)),
+	}
+	for _, key := range allowlist {
+		filter.allow[key] = true
+	}
+	for _, key := range denylist {
+		filter.deny[key] = true
+	}
+	return filter
+}
+
+// Allowed reports whether the label key may be emitted
+func (f *LabelFilter) Allowed(key string) bool {
+	if f == nil {
+		return true
+	}
+	if f.deny[key] {
+		return false
+	}
+	return len(f.allow) == 0 || f.allow[key]
+}
+
+// labelEscaper escapes label values for the text exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelValue escapes a label value written between double quotes, such as
+// a user-defined job label's
+func labelValue(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+// SetLabelFilter restricts the user labels emitted on job metrics
+func (c *Collector) SetLabelFilter(filter *LabelFilter) {
+	c.configure(func(s *settings) { s.labelFilter = filter })
+}
+
+// metricLabelKeys returns the job's label keys that pass the filter, sorted
+// for stable output
+func (s *settings) metricLabelKeys(job *model.Job) []string {
+	keys := make([]string, 0, len(job.Labels))
+	for key := range job.Labels {
+		if s.labelFilter.Allowed(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// notificationSource writes the notification delivery counters
+type notificationSource struct {
+	store *model.NotificationStore
+}
+
+// NewNotificationSource returns a source of the number and latency of the
+// recorded notification deliveries, by channel and status
+func NewNotificationSource(store *model.NotificationStore) MetricSource {
+	return notificationSource{store}
+}
+
+func (notificationSource) Name() string { return "notification" }
+
+func (n notificationSource) WriteMetrics(ctx context.Context, builder *strings.Builder, scrape *Scrape) error {
+	if !scrape.FleetWide {
+		return nil
+	}
+	counts, err := n.store.WithContext(ctx).CountDeliveries()
+	if err != nil {
+		return err
+	}
+
+	builder.WriteString("# HELP cronmetrics_notification_deliveries_total Notification delivery attempts, by channel and status\n")
+	builder.WriteString("# TYPE cronmetrics_notification_deliveries_total counter\n")
+	for _, count := range counts {
+		builder.WriteString(fmt.Sprintf("cronmetrics_notification_deliveries_total{channel=\"%s\",status=\"%s\"} %d\n", labelValue(count.Channel), labelValue(count.Status), count.Count))
+	}
+
+	// Summed over statuses, so the mean latency of a channel is this over
+	// the sum of its deliveries
+	latencies := make(map[string]int64)
+	channels := []string{}
+	for _, count := range counts {
+		if _, ok := latencies[count.Channel]; !ok {
+			channels = append(channels, count.Channel)
+		}
+		latencies[count.Channel] += count.LatencyMs
+	}
+
+	builder.WriteString("# HELP cronmetrics_notification_delivery_seconds_total Time spent delivering notifications, by channel\n")
+	builder.WriteString("# TYPE cronmetrics_notification_delivery_seconds_total counter\n")
+	for _, channel := range channels {
+		builder.WriteString(fmt.Sprintf("cronmetrics_notification_delivery_seconds_total{channel=\"%s\"} %g\n", labelValue(channel), float64(latencies[channel])/1000))
+	}
+	return nil
+}
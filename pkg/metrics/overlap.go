@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// overlapSource writes the overlapping runs of the jobs
+type overlapSource struct {
+	store *model.JobResultStore
+}
+
+// NewOverlapSource returns a source of the number of runs of each job that
+// started while its previous run was still open
+func NewOverlapSource(store *model.JobResultStore) MetricSource {
+	return overlapSource{store}
+}
+
+func (overlapSource) Name() string { return "overlap" }
+
+func (o overlapSource) WriteMetrics(ctx context.Context, builder *strings.Builder, scrape *Scrape) error {
+	if len(scrape.Jobs) == 0 {
+		return nil
+	}
+
+	counts, err := o.store.WithContext(ctx).OverlapCounts()
+	if err != nil {
+		return err
+	}
+
+	// Every job has a series, so increase() sees a job's first overlap
+	builder.WriteString("# HELP cronjob_overlapping_runs_total Runs of the job that started while its previous run was still open\n")
+	builder.WriteString("# TYPE cronjob_overlapping_runs_total counter\n")
+	for _, job := range scrape.Jobs {
+		count := counts[model.JobRef{Name: job.Name, Host: job.Host}]
+		builder.WriteString(fmt.Sprintf("cronjob_overlapping_runs_total{%s} %d\n", scrape.JobLabels(job), count))
+	}
+	return nil
+}
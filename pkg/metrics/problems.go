@@ -0,0 +1,186 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// Problem kinds, from the most to the least urgent
+const (
+	ProblemStaleHost      = "stale_host"      // Every job of a host missed its deadline
+	ProblemFailure        = "failure"         // The job's latest run failed, was lost or processed too few items
+	ProblemMissedDeadline = "missed_deadline" // The job did not report in time
+	ProblemOverlapping    = "overlapping"     // The job's open run started while its previous run was still open
+	ProblemFlapping       = "flapping"        // The job's recent runs alternate between success and failure
+)
+
+// problemPriorities ranks the problem kinds, 1 being the most urgent
+var problemPriorities = map[string]int{
+	ProblemStaleHost:      1,
+	ProblemFailure:        2,
+	ProblemMissedDeadline: 3,
+	ProblemOverlapping:    4,
+	ProblemFlapping:       5,
+}
+
+// flappingWindow is the number of latest results a job's flapping is
+// detected over, and flappingChanges how many times their status must
+// change within them
+const (
+	flappingWindow  = 10
+	flappingChanges = 4
+)
+
+// staleHostMinJobs is the fewest jobs a host needs for its missed deadlines
+// to be reported as a stale host rather than job by job
+const staleHostMinJobs = 2
+
+// Problem is a job or host that needs attention
+type Problem struct {
+	Kind     string    `json:"kind"`
+	Priority int       `json:"priority"`           // 1 is the most urgent
+	JobID    int       `json:"job_id,omitempty"`   // Unset for host problems
+	JobName  string    `json:"job_name,omitempty"` // Unset for host problems
+	Host     string    `json:"host"`
+	Reason   string    `json:"reason"`         // The job's status reason, e.g. "lost"
+	Jobs     []string  `json:"jobs,omitempty"` // Jobs of a stale host, by name
+	Since    time.Time `json:"since"`          // When the problem started
+	Age      int64     `json:"age_seconds"`    // Seconds since the problem started
+}
+
+// Problems evaluates every job now and returns the problems found, the most
+// urgent first, then the oldest. A job appears once, as its most urgent
+// problem, and the jobs of a stale host only within the host's problem.
+// Snoozed jobs and jobs in maintenance or paused are left out.
+func (c *Collector) Problems(ctx context.Context) ([]*Problem, error) {
+	jobs, err := c.jobStore.WithContext(ctx).ListJobs(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	now := time.Now().UTC()
+	s := c.settings.Load()
+	problems := []*Problem{}
+	missed := make(map[string][]*Problem) // Missed deadlines by host
+	monitored := make(map[string]int)     // Monitored jobs by host
+	for _, job := range jobs {
+		_, reason := c.calculateJobStatus(ctx, s, job, now)
+		if reason == "maintenance" || reason == "paused" || reason == "snoozed" {
+			continue
+		}
+		monitored[job.Host]++
+
+		problem := &Problem{JobID: job.ID, JobName: job.Name, Host: job.Host, Reason: reason}
+		switch reason {
+		case "missed_deadline":
+			problem.Kind = ProblemMissedDeadline
+			problem.Since = job.Deadline()
+			missed[job.Host] = append(missed[job.Host], problem)
+			continue
+		case "failure", "lost", "low_throughput":
+			problem.Kind = ProblemFailure
+			if problem.Since, err = c.failingSince(ctx, job); err != nil {
+				return nil, err
+			}
+		case "overlapping":
+			problem.Kind = ProblemOverlapping
+			start, err := c.jobResultStore.WithContext(ctx).OpenStart(job.Name, job.Host)
+			if err != nil {
+				return nil, err
+			}
+			problem.Since = job.LastReportedAt
+			if start != nil {
+				problem.Since = start.StartedAt
+			}
+		default:
+			since, flapping, err := c.flappingSince(ctx, job)
+			if err != nil {
+				return nil, err
+			}
+			if !flapping {
+				continue
+			}
+			problem.Kind = ProblemFlapping
+			problem.Since = since
+		}
+		problems = append(problems, problem)
+	}
+
+	for host, hostMissed := range missed {
+		if len(hostMissed) < staleHostMinJobs || len(hostMissed) < monitored[host] {
+			problems = append(problems, hostMissed...)
+			continue
+		}
+
+		stale := &Problem{Kind: ProblemStaleHost, Host: host, Reason: "missed_deadline", Since: hostMissed[0].Since}
+		for _, problem := range hostMissed {
+			stale.Jobs = append(stale.Jobs, problem.JobName)
+			if problem.Since.Before(stale.Since) {
+				stale.Since = problem.Since
+			}
+		}
+		sort.Strings(stale.Jobs)
+		problems = append(problems, stale)
+	}
+
+	for _, problem := range problems {
+		problem.Priority = problemPriorities[problem.Kind]
+		problem.Age = int64(now.Sub(problem.Since).Seconds())
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		a, b := problems[i], problems[j]
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if !a.Since.Equal(b.Since) {
+			return a.Since.Before(b.Since)
+		}
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		return a.JobName < b.JobName
+	})
+	return problems, nil
+}
+
+// failingSince returns when the job's current failures started: its first
+// failure since its last success, else its last report
+func (c *Collector) failingSince(ctx context.Context, job *model.Job) (time.Time, error) {
+	streak, err := c.jobResultStore.WithContext(ctx).GetFailureStreak(job.Name, job.Host)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if streak.Count > 0 {
+		return streak.Since, nil
+	}
+	return job.LastReportedAt, nil
+}
+
+// flappingSince reports whether the job's latest results changed status at
+// least flappingChanges times, and since when: the oldest of these results.
+// Test results are left out.
+func (c *Collector) flappingSince(ctx context.Context, job *model.Job) (time.Time, bool, error) {
+	results, err := c.jobResultStore.WithContext(ctx).GetJobResults(job.Name, job.Host, flappingWindow)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	changes := 0
+	var previous *model.JobResult
+	var since time.Time
+	for _, result := range results {
+		if result.Test {
+			continue
+		}
+		if previous != nil && result.Status != previous.Status {
+			changes++
+		}
+		previous = result
+		since = result.Timestamp
+	}
+	return since, changes >= flappingChanges, nil
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// DefaultMTTRWindowDays is the window the mean time to recovery covers
+// when none is configured
+const DefaultMTTRWindowDays = 30
+
+// recoverySource writes the time the jobs took to recover from failures
+type recoverySource struct {
+	cache      *model.RecoveryCache
+	groupLabel string // Job label grouping the MTTR, as the health score
+	window     time.Duration
+}
+
+// NewRecoverySource returns a source of the time each job took to recover
+// from its latest incident, from the first failure to the next success, and
+// of its mean time to recovery over the last windowDays,
+// DefaultMTTRWindowDays when not positive. The MTTR of the jobs grouped by
+// groupLabel is written too, unless it is empty. Incidents are found again
+// at most every model.RecoveryRefresh.
+func NewRecoverySource(store *model.JobResultStore, groupLabel string, windowDays int) MetricSource {
+	if windowDays < 1 {
+		windowDays = DefaultMTTRWindowDays
+	}
+	return recoverySource{model.NewRecoveryCache(store), groupLabel, time.Duration(windowDays) * 24 * time.Hour}
+}
+
+func (recoverySource) Name() string { return "recovery" }
+
+func (r recoverySource) WriteMetrics(ctx context.Context, builder *strings.Builder, scrape *Scrape) error {
+	if len(scrape.Jobs) == 0 {
+		return nil
+	}
+
+	recoveries, err := r.cache.RecoveriesSince(ctx, scrape.Time, r.window)
+	if err != nil {
+		return err
+	}
+
+	builder.WriteString("# HELP cronjob_last_recovery_duration_seconds Seconds from the first failure of the job's latest incident to its next success\n")
+	builder.WriteString("# TYPE cronjob_last_recovery_duration_seconds gauge\n")
+	for _, job := range scrape.Jobs {
+		if incidents := recoveries[model.JobRef{Name: job.Name, Host: job.Host}]; len(incidents) > 0 {
+			builder.WriteString(fmt.Sprintf("cronjob_last_recovery_duration_seconds{%s} %g\n", scrape.JobLabels(job), incidents[len(incidents)-1].Duration().Seconds()))
+		}
+	}
+
+	builder.WriteString("# HELP cronjob_mttr_seconds Mean time to recovery of the job's incidents over the MTTR window\n")
+	builder.WriteString("# TYPE cronjob_mttr_seconds gauge\n")
+	for _, job := range scrape.Jobs {
+		if incidents := recoveries[model.JobRef{Name: job.Name, Host: job.Host}]; len(incidents) > 0 {
+			builder.WriteString(fmt.Sprintf("cronjob_mttr_seconds{%s} %g\n", scrape.JobLabels(job), model.MeanRecovery(incidents).Seconds()))
+		}
+	}
+
+	// Like the health score, group series cover every listed job
+	if r.groupLabel == "" || !scrape.FleetWide {
+		return nil
+	}
+	byGroup := make(map[string][]*model.Recovery)
+	for _, job := range scrape.Listed {
+		group := job.Labels[r.groupLabel]
+		byGroup[group] = append(byGroup[group], recoveries[model.JobRef{Name: job.Name, Host: job.Host}]...)
+	}
+	groups := make([]string, 0, len(byGroup))
+	for group, incidents := range byGroup {
+		if len(incidents) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	sort.Strings(groups)
+
+	builder.WriteString("# HELP cronjob_group_mttr_seconds Mean time to recovery of the incidents of a group's jobs over the MTTR window\n")
+	builder.WriteString("# TYPE cronjob_group_mttr_seconds gauge\n")
+	for _, group := range groups {
+		builder.WriteString(fmt.Sprintf("cronjob_group_mttr_seconds{group=\"%s\"} %g\n", labelValue(group), model.MeanRecovery(byGroup[group]).Seconds()))
+	}
+	return nil
+}
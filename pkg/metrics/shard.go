@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// JobSelector restricts the jobs exported in a scrape, so large
+// installations can split scraping across several Prometheus servers and
+// teams can scrape or debug only their jobs
+type JobSelector struct {
+	Labels  map[string]string // Jobs must carry all of these labels
+	Host    string            // Jobs must run on this host, "" for any
+	JobName string            // Jobs must have this name, "" for any
+	Shard   int               // 1-based shard to export, 0 for all jobs
+	Shards  int               // Total number of shards
+}
+
+// ParseJobSelector reads the scrape selector from query parameters:
+// shard=i/n exports the i-th of n shards, label.<key>=<value> keeps jobs
+// carrying that label, and host and job_name keep the jobs of a host or
+// with a name. It returns nil when no selector is given.
+func ParseJobSelector(query url.Values) (*JobSelector, error) {
+	selector := &JobSelector{
+		Labels:  make(map[string]string),
+		Host:    query.Get("host"),
+		JobName: query.Get("job_name"),
+	}
+
+	for key, values := range query {
+		if strings.HasPrefix(key, "label.") && len(values) > 0 {
+			selector.Labels[strings.TrimPrefix(key, "label.")] = values[0]
+		}
+	}
+
+	if shard := query.Get("shard"); shard != "" {
+		indexStr, countStr, ok := strings.Cut(shard, "/")
+		index, indexErr := strconv.Atoi(indexStr)
+		count, countErr := strconv.Atoi(countStr)
+		if !ok || indexErr != nil || countErr != nil || count < 1 || index < 1 || index > count {
+			return nil, fmt.Errorf("shard must be i/n with 1 <= i <= n, got %q", shard)
+		}
+		selector.Shard = index
+		selector.Shards = count
+	}
+
+	if len(selector.Labels) == 0 && selector.Host == "" && selector.JobName == "" && selector.Shards == 0 {
+		return nil, nil
+	}
+	return selector, nil
+}
+
+// matches reports whether the job has the selected host and name
+func (s *JobSelector) matches(job *model.Job) bool {
+	return (s.Host == "" || job.Host == s.Host) && (s.JobName == "" || job.Name == s.JobName)
+}
+
+// inShard reports whether the job belongs to the selected shard. Jobs are
+// assigned by a hash of their name and host, so the split is the same on
+// every scrape and does not depend on database IDs.
+func (s *JobSelector) inShard(job *model.Job) bool {
+	if s.Shards <= 1 {
+		return true
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(job.Name + "\x00" + job.Host))
+	return int(hash.Sum32()%uint32(s.Shards)) == s.Shard-1
+}
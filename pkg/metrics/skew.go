@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// clockSkewSource writes the clock skew of the reporting hosts
+type clockSkewSource struct {
+	store *model.JobResultStore
+}
+
+// NewClockSkewSource returns a source of how far each host's clock was off
+// when it last submitted a timestamped result
+func NewClockSkewSource(store *model.JobResultStore) MetricSource {
+	return clockSkewSource{store}
+}
+
+func (clockSkewSource) Name() string { return "clock skew" }
+
+func (c clockSkewSource) WriteMetrics(ctx context.Context, builder *strings.Builder, scrape *Scrape) error {
+	// Hosts are not tied to a shard
+	if !scrape.FleetWide {
+		return nil
+	}
+	skews, err := c.store.WithContext(ctx).LatestClockSkews()
+	if err != nil {
+		return err
+	}
+
+	hosts := make(map[string]bool, len(scrape.Listed))
+	for _, job := range scrape.Listed {
+		hosts[job.Host] = true
+	}
+
+	builder.WriteString("# HELP cronjob_host_clock_skew_seconds Seconds the host's clock was ahead of the server's at its latest timestamped result\n")
+	builder.WriteString("# TYPE cronjob_host_clock_skew_seconds gauge\n")
+	for _, skew := range skews {
+		if hosts[skew.Host] {
+			builder.WriteString(fmt.Sprintf("cronjob_host_clock_skew_seconds{host=\"%s\"} %g\n", labelValue(skew.Host), skew.ClockSkew))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/slo"
+)
+
+// SetSLOTracker enables the error budget metrics of the tracked objectives
+func (c *Collector) SetSLOTracker(tracker *slo.Tracker) {
+	c.configure(func(s *settings) { s.sloTracker = tracker })
+}
+
+// sloSource writes the error budget, burn rate and MTTR of each objective
+type sloSource struct {
+	tracker *slo.Tracker
+}
+
+func (sloSource) Name() string { return "slo" }
+
+func (s sloSource) WriteMetrics(ctx context.Context, builder *strings.Builder, scrape *Scrape) error {
+	if !scrape.FleetWide {
+		return nil
+	}
+	statuses, err := s.tracker.Evaluate(ctx, scrape.Time)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate SLOs: %w", err)
+	}
+
+	builder.WriteString("# HELP cronjob_slo_error_budget_remaining Share of the SLO's error budget left over its window, negative once overspent\n")
+	builder.WriteString("# TYPE cronjob_slo_error_budget_remaining gauge\n")
+	for _, status := range statuses {
+		builder.WriteString(fmt.Sprintf("cronjob_slo_error_budget_remaining{slo=\"%s\"} %g\n", labelValue(status.Name), status.BudgetRemaining))
+	}
+
+	builder.WriteString("# HELP cronjob_slo_burn_rate Rate the SLO's error budget is being spent at recently; 1 spends it exactly over the window\n")
+	builder.WriteString("# TYPE cronjob_slo_burn_rate gauge\n")
+	for _, status := range statuses {
+		builder.WriteString(fmt.Sprintf("cronjob_slo_burn_rate{slo=\"%s\"} %g\n", labelValue(status.Name), status.BurnRate))
+	}
+
+	builder.WriteString("# HELP cronjob_slo_mttr_seconds Mean time to recovery of the incidents of the SLO's jobs over its window\n")
+	builder.WriteString("# TYPE cronjob_slo_mttr_seconds gauge\n")
+	for _, status := range statuses {
+		if status.MTTR != nil {
+			builder.WriteString(fmt.Sprintf("cronjob_slo_mttr_seconds{slo=\"%s\"} %g\n", labelValue(status.Name), *status.MTTR))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// MetricSource writes a group of series to the collector's scrapes, e.g.
+// result histograms or self-metrics. Sources are written after the job
+// series, the built-in ones first (group health, SLOs, rejections and rate
+// limits), then those added with AddSource, in the order added.
+type MetricSource interface {
+	// Name identifies the source in errors, e.g. "slo"
+	Name() string
+
+	// WriteMetrics writes the source's series in Prometheus text format,
+	// with their HELP and TYPE lines. Database queries use ctx.
+	WriteMetrics(ctx context.Context, builder *strings.Builder, scrape *Scrape) error
+}
+
+// Scrape is one gathering of metrics, as seen by its sources
+type Scrape struct {
+	Time time.Time
+
+	// Jobs are the jobs whose series the scrape writes: those of its shard,
+	// or the job of a single job scrape
+	Jobs []*model.Job
+
+	// Listed are the jobs matching the scrape's label selector, across
+	// shards
+	Listed []*model.Job
+
+	// FleetWide is set when the scrape writes the series not tied to a job:
+	// on unsharded scrapes and on those of the first shard, so sums across
+	// shards hold. Single job scrapes write none.
+	FleetWide bool
+
+	collector *Collector
+	settings  *settings
+	statuses  map[*model.Job]jobStatus // Computed once per scrape
+}
+
+// jobStatus is a cronjob_status value and its reason
+type jobStatus struct {
+	value  float64
+	reason string
+}
+
+// newScrape creates a scrape of jobs with the current settings. Jobs whose
+// status is excluded from the metrics are left out.
+func (c *Collector) newScrape(jobs, listed []*model.Job, fleetWide bool) *Scrape {
+	s := c.settings.Load()
+	return &Scrape{
+		Time:      time.Now().UTC(),
+		Jobs:      s.included(jobs),
+		Listed:    s.included(listed),
+		FleetWide: fleetWide,
+		collector: c,
+		settings:  s,
+		statuses:  make(map[*model.Job]jobStatus),
+	}
+}
+
+// Status returns the job's cronjob_status value and its reason, e.g.
+// "missed_deadline", as of the scrape's time
+func (s *Scrape) Status(ctx context.Context, job *model.Job) (float64, string) {
+	status, ok := s.statuses[job]
+	if !ok {
+		status.value, status.reason = s.collector.calculateJobStatus(ctx, s.settings, job, s.Time)
+		s.statuses[job] = status
+	}
+	return status.value, status.reason
+}
+
+// JobLabels returns the job's labels in Prometheus format: job_name, host
+// and the user labels allowed by the label filter
+func (s *Scrape) JobLabels(job *model.Job) string {
+	return s.settings.jobLabels(job)
+}
+
+// AddSource adds a source to the scrapes of the collector
+func (c *Collector) AddSource(source MetricSource) {
+	c.configure(func(s *settings) {
+		s.sources = append(s.sources[:len(s.sources):len(s.sources)], source)
+	})
+}
+
+// writeSources writes the series of the built-in and added sources
+func (c *Collector) writeSources(ctx context.Context, builder *strings.Builder, scrape *Scrape) error {
+	sources := make([]MetricSource, 0, 4+len(scrape.settings.sources))
+	if scrape.settings.healthScorer != nil {
+		sources = append(sources, scrape.settings.healthScorer)
+	}
+	if scrape.settings.sloTracker != nil {
+		sources = append(sources, sloSource{scrape.settings.sloTracker})
+	}
+	sources = append(sources, rejectionSource{c}, rateLimitSource{c})
+	sources = append(sources, scrape.settings.sources...)
+
+	for _, source := range sources {
+		if err := source.WriteMetrics(ctx, builder, scrape); err != nil {
+			return fmt.Errorf("failed to write %s metrics: %w", source.Name(), err)
+		}
+	}
+	return nil
+}
+
+// rejectionSource writes the rejected result counters
+type rejectionSource struct {
+	collector *Collector
+}
+
+func (rejectionSource) Name() string { return "rejection" }
+
+func (r rejectionSource) WriteMetrics(_ context.Context, builder *strings.Builder, scrape *Scrape) error {
+	if !scrape.FleetWide {
+		return nil
+	}
+
+	c := r.collector
+	c.rejectedMu.Lock()
+	defer c.rejectedMu.Unlock()
+
+	reasons := make([]string, 0, len(c.rejected))
+	for reason := range c.rejected {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	builder.WriteString("# HELP cronmetrics_rejected_results_total Job result submissions rejected, by reason\n")
+	builder.WriteString("# TYPE cronmetrics_rejected_results_total counter\n")
+	for _, reason := range reasons {
+		builder.WriteString(fmt.Sprintf("cronmetrics_rejected_results_total{reason=\"%s\"} %d\n", reason, c.rejected[reason]))
+	}
+	return nil
+}
+
+// rateLimitSource writes the rate limited result counters of the scrape's
+// jobs that were rate limited
+type rateLimitSource struct {
+	collector *Collector
+}
+
+func (rateLimitSource) Name() string { return "rate limit" }
+
+func (r rateLimitSource) WriteMetrics(_ context.Context, builder *strings.Builder, scrape *Scrape) error {
+	c := r.collector
+	c.rejectedMu.Lock()
+	defer c.rejectedMu.Unlock()
+
+	builder.WriteString("# HELP cronjob_rate_limited_results_total Job results submitted before the job's minimum interval passed, by action\n")
+	builder.WriteString("# TYPE cronjob_rate_limited_results_total counter\n")
+	for _, job := range scrape.Jobs {
+		for _, action := range []string{"coalesced", "rejected"} {
+			if count := c.rateLimited[rateLimitKey{job.Name, job.Host, action}]; count > 0 {
+				builder.WriteString(fmt.Sprintf("cronjob_rate_limited_results_total{job_name=\"%s\",host=\"%s\",action=\"%s\"} %d\n", labelValue(job.Name), labelValue(job.Host), action, count))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// StatusExcluded is the status value that leaves a job out of the metrics
+const StatusExcluded = "exclude"
+
+// defaultStatusValue is the cronjob_status value of jobs in maintenance or
+// paused when no mapping says otherwise
+const defaultStatusValue = -1
+
+// StatusMapping sets what the metrics report for jobs an operator took out
+// of monitoring, by job status: a cronjob_status value, or no series at all
+// when the status is excluded. Some alert pipelines treat -1 as an error.
+type StatusMapping struct {
+	values   map[string]float64
+	excluded map[string]bool
+}
+
+// NewStatusMapping creates a mapping from the values of maintenance and
+// paused jobs: each a number, or StatusExcluded
+func NewStatusMapping(maintenance, paused string) (*StatusMapping, error) {
+	mapping := &StatusMapping{
+		values:   make(map[string]float64, 2),
+		excluded: make(map[string]bool, 2),
+	}
+	for status, value := range map[string]string{"maintenance": maintenance, "paused": paused} {
+		if strings.EqualFold(value, StatusExcluded) {
+			mapping.excluded[status] = true
+			continue
+		}
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s status value %q: want a number or %q", status, value, StatusExcluded)
+		}
+		mapping.values[status] = number
+	}
+	return mapping, nil
+}
+
+// value returns the cronjob_status value of jobs with status
+func (m *StatusMapping) value(status string) float64 {
+	if m != nil {
+		if value, ok := m.values[status]; ok {
+			return value
+		}
+	}
+	return defaultStatusValue
+}
+
+// Excluded reports whether the job's status leaves it out of the metrics
+func (m *StatusMapping) Excluded(job *model.Job) bool {
+	return m != nil && m.excluded[job.Status]
+}
+
+// SetStatusMapping sets what the metrics report for maintenance and paused
+// jobs
+func (c *Collector) SetStatusMapping(mapping *StatusMapping) {
+	c.configure(func(s *settings) { s.statusMapping = mapping })
+}
+
+// included returns the jobs whose status is not excluded from the metrics
+func (s *settings) included(jobs []*model.Job) []*model.Job {
+	if s.statusMapping == nil || len(s.statusMapping.excluded) == 0 {
+		return jobs
+	}
+	kept := make([]*model.Job, 0, len(jobs))
+	for _, job := range jobs {
+		if !s.statusMapping.Excluded(job) {
+			kept = append(kept, job)
+		}
+	}
+	return kept
+}
+
+// statusHelp returns the HELP text of cronjob_status, listing the values
+// the mapping gives maintenance and paused jobs
+func (s *settings) statusHelp() string {
+	parts := []string{"1=success", "0=failure"}
+	var values []float64
+	names := make(map[float64][]string)
+	for _, status := range []string{"maintenance", "paused"} {
+		if s.statusMapping.Excluded(&model.Job{Status: status}) {
+			continue
+		}
+		value := s.statusMapping.value(status)
+		if _, ok := names[value]; !ok {
+			values = append(values, value)
+		}
+		names[value] = append(names[value], status)
+	}
+	for _, value := range values {
+		parts = append(parts, fmt.Sprintf("%g=%s", value, strings.Join(names[value], "/")))
+	}
+	parts = append(parts, "-2=missed_deadline", "-3=snoozed", "-4=lost", "-5=overlapping")
+	return "Status of cron job: " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+)
+
+// throughputSource writes the items processed by counter jobs
+type throughputSource struct {
+	store *model.JobResultStore
+}
+
+// NewThroughputSource returns a source of the items processed by counter
+// jobs and of their throughput floors
+func NewThroughputSource(store *model.JobResultStore) MetricSource {
+	return throughputSource{store}
+}
+
+func (throughputSource) Name() string { return "throughput" }
+
+func (t throughputSource) WriteMetrics(ctx context.Context, builder *strings.Builder, scrape *Scrape) error {
+	var counters []*model.Job
+	for _, job := range scrape.Jobs {
+		if job.Type == model.JobTypeCounter {
+			counters = append(counters, job)
+		}
+	}
+	if len(counters) == 0 {
+		return nil
+	}
+
+	throughputs, err := t.store.WithContext(ctx).Throughputs()
+	if err != nil {
+		return err
+	}
+	byJob := make(map[string]*model.JobThroughput, len(throughputs))
+	for _, throughput := range throughputs {
+		byJob[throughput.JobName+"@"+throughput.Host] = throughput
+	}
+
+	// The total only covers the stored results, so it drops when old ones
+	// are removed; rate() takes that for a counter reset
+	builder.WriteString("# HELP cronjob_items_processed_total Items processed by the job's stored runs\n")
+	builder.WriteString("# TYPE cronjob_items_processed_total counter\n")
+	for _, job := range counters {
+		if throughput := byJob[job.Name+"@"+job.Host]; throughput != nil {
+			builder.WriteString(fmt.Sprintf("cronjob_items_processed_total{%s} %d\n", scrape.JobLabels(job), throughput.Total))
+		}
+	}
+
+	builder.WriteString("# HELP cronjob_items_processed Items processed by the job's latest run\n")
+	builder.WriteString("# TYPE cronjob_items_processed gauge\n")
+	for _, job := range counters {
+		if throughput := byJob[job.Name+"@"+job.Host]; throughput != nil {
+			builder.WriteString(fmt.Sprintf("cronjob_items_processed{%s} %d\n", scrape.JobLabels(job), throughput.Last))
+		}
+	}
+
+	// Runs that report no duration have no rate
+	builder.WriteString("# HELP cronjob_items_per_second Items processed per second by the job's latest run\n")
+	builder.WriteString("# TYPE cronjob_items_per_second gauge\n")
+	for _, job := range counters {
+		if throughput := byJob[job.Name+"@"+job.Host]; throughput != nil && throughput.LastDuration > 0 {
+			builder.WriteString(fmt.Sprintf("cronjob_items_per_second{%s} %g\n", scrape.JobLabels(job), float64(throughput.Last)/float64(throughput.LastDuration)))
+		}
+	}
+
+	builder.WriteString("# HELP cronjob_throughput_floor Fewest items a successful run of the job may process\n")
+	builder.WriteString("# TYPE cronjob_throughput_floor gauge\n")
+	for _, job := range counters {
+		if job.ThroughputFloor > 0 {
+			builder.WriteString(fmt.Sprintf("cronjob_throughput_floor{%s} %d\n", scrape.JobLabels(job), job.ThroughputFloor))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Acknowledgement records that someone is handling a job's failures,
+// optionally with the ticket tracking the work
+type Acknowledgement struct {
+	ID        int       `json:"id" db:"id"`
+	JobName   string    `json:"job_name" db:"job_name"`
+	Host      string    `json:"host" db:"host"`
+	Author    string    `json:"author" db:"author"`
+	TicketID  string    `json:"ticket_id,omitempty" db:"ticket_id"`   // e.g. "OPS-123" or "org/repo#45"
+	TicketURL string    `json:"ticket_url,omitempty" db:"ticket_url"` // Link to the ticket
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// maxTicketIDLength is the longest ticket ID an acknowledgement may carry
+const maxTicketIDLength = 100
+
+// Validate checks the ticket of an acknowledgement
+func (a *Acknowledgement) Validate() error {
+	if len(a.TicketID) > maxTicketIDLength {
+		return fmt.Errorf("ticket_id must be at most %d characters", maxTicketIDLength)
+	}
+	if a.TicketURL != "" {
+		u, err := url.Parse(a.TicketURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("ticket_url must be an http or https URL")
+		}
+	}
+	return nil
+}
+
+// FailureStreak describes the failures a job reported since its last
+// success
+type FailureStreak struct {
+	Count int       // Failed results since the last success
+	Since time.Time // Timestamp of the first of them; zero without failures
+}
+
+// AcknowledgementStore provides database operations for acknowledgements
+type AcknowledgementStore struct {
+	db *sqlx.DB
+	storeContext
+}
+
+// NewAcknowledgementStore creates a new AcknowledgementStore instance
+func NewAcknowledgementStore(db *sqlx.DB) *AcknowledgementStore {
+	return &AcknowledgementStore{db: db}
+}
+
+// WithContext returns a copy of the store whose queries run with ctx
+func (s *AcknowledgementStore) WithContext(ctx context.Context) *AcknowledgementStore {
+	clone := *s
+	clone.ctx = ctx
+	return &clone
+}
+
+// CreateAcknowledgement stores an acknowledgement
+func (s *AcknowledgementStore) CreateAcknowledgement(ack *Acknowledgement) error {
+	if ack.CreatedAt.IsZero() {
+		ack.CreatedAt = time.Now().UTC()
+	}
+
+	query := `
+		INSERT INTO job_acknowledgements (job_name, host, author, ticket_id, ticket_url, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	id, err := insertID(s.context(), s.db, query, ack.JobName, ack.Host, ack.Author, ack.TicketID, ack.TicketURL, ack.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create acknowledgement: %w", err)
+	}
+	ack.ID = int(id)
+
+	return nil
+}
+
+// ListAcknowledgements returns the acknowledgements of a job, newest first
+func (s *AcknowledgementStore) ListAcknowledgements(jobName, host string) ([]*Acknowledgement, error) {
+	query := `
+		SELECT id, job_name, host, author, ticket_id, ticket_url, created_at
+		FROM job_acknowledgements
+		WHERE job_name = ? AND host = ?
+		ORDER BY created_at DESC, id DESC
+	`
+
+	acks := []*Acknowledgement{}
+	if err := s.db.SelectContext(s.context(), &acks, s.db.Rebind(query), jobName, host); err != nil {
+		return nil, fmt.Errorf("failed to list acknowledgements: %w", err)
+	}
+
+	return acks, nil
+}
+
+// IsAcknowledgedSince reports whether a job was acknowledged at or after
+// the given time
+func (s *AcknowledgementStore) IsAcknowledgedSince(jobName, host string, since time.Time) (bool, error) {
+	var count int
+	err := s.db.GetContext(s.context(), &count, s.db.Rebind(`SELECT COUNT(*) FROM job_acknowledgements WHERE job_name = ? AND host = ? AND created_at >= ?`),
+		jobName, host, since.UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to check acknowledgements: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetFailureStreak returns the failures a job reported since its last
+// successful result. Test results neither count nor end a streak.
+func (s *JobResultStore) GetFailureStreak(jobName, host string) (*FailureStreak, error) {
+	query := `
+		SELECT timestamp
+		FROM job_results
+		WHERE job_name = ? AND host = ? AND status = 'failure' AND NOT test
+		AND id > COALESCE((SELECT MAX(id) FROM job_results WHERE job_name = ? AND host = ? AND status = 'success' AND NOT test), 0)
+		ORDER BY id
+	`
+
+	var timestamps []time.Time
+	if err := s.db.SelectContext(s.context(), &timestamps, s.db.Rebind(query), jobName, host, jobName, host); err != nil {
+		return nil, fmt.Errorf("failed to get failure streak: %w", err)
+	}
+
+	streak := &FailureStreak{Count: len(timestamps)}
+	if len(timestamps) > 0 {
+		streak.Since = timestamps[0]
+	}
+
+	return streak, nil
+}
@@ -0,0 +1,207 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/util"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminKey represents an admin API key managed at runtime. The key itself is
+// only returned once, at creation; afterwards only its prefix is known.
+type AdminKey struct {
+	ID        int        `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	KeyPrefix string     `json:"key_prefix" db:"key_prefix"`
+	CreatedBy string     `json:"created_by" db:"created_by"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// Revoked reports whether the key has been revoked
+func (k *AdminKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// AuditEntry represents a single administrative action
+type AuditEntry struct {
+	ID        int       `json:"id" db:"id"`
+	Actor     string    `json:"actor" db:"actor"`
+	Action    string    `json:"action" db:"action"`
+	Target    string    `json:"target" db:"target"`
+	Details   string    `json:"details" db:"details"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// AdminKeyStore provides database operations for runtime-managed admin keys
+type AdminKeyStore struct {
+	db *sqlx.DB
+	storeContext
+}
+
+// NewAdminKeyStore creates a new AdminKeyStore instance
+func NewAdminKeyStore(db *sqlx.DB) *AdminKeyStore {
+	return &AdminKeyStore{db: db}
+}
+
+// WithContext returns a copy of the store whose queries run with ctx
+func (s *AdminKeyStore) WithContext(ctx context.Context) *AdminKeyStore {
+	clone := *s
+	clone.ctx = ctx
+	return &clone
+}
+
+// hashAdminKey returns the stored representation of an admin key
+func hashAdminKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAdminKey generates and stores a new admin key. The plaintext key is
+// returned alongside the record and cannot be retrieved again.
+func (s *AdminKeyStore) CreateAdminKey(name, createdBy string) (*AdminKey, string, error) {
+	key, err := util.GenerateAPIKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate admin key: %w", err)
+	}
+
+	adminKey := &AdminKey{
+		Name:      name,
+		KeyPrefix: key[:7],
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	query := `
+		INSERT INTO admin_keys (name, key_hash, key_prefix, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	id, err := insertID(s.context(), s.db, query, adminKey.Name, hashAdminKey(key), adminKey.KeyPrefix, adminKey.CreatedBy, adminKey.CreatedAt)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create admin key: %w", err)
+	}
+	adminKey.ID = int(id)
+
+	logrus.WithFields(logrus.Fields{
+		"name":       adminKey.Name,
+		"created_by": adminKey.CreatedBy,
+	}).Info("admin key created successfully")
+
+	return adminKey, key, nil
+}
+
+// ListAdminKeys returns all runtime-managed admin keys, newest first
+func (s *AdminKeyStore) ListAdminKeys() ([]*AdminKey, error) {
+	query := `
+		SELECT id, name, key_prefix, created_by, created_at, revoked_at
+		FROM admin_keys
+		ORDER BY created_at DESC, id DESC
+	`
+
+	keys := []*AdminKey{}
+	if err := s.db.SelectContext(s.context(), &keys, query); err != nil {
+		return nil, fmt.Errorf("failed to list admin keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAdminKey marks an admin key as revoked
+func (s *AdminKeyStore) RevokeAdminKey(id int) error {
+	query := `UPDATE admin_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`
+
+	result, err := s.db.ExecContext(s.context(), s.db.Rebind(query), time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke admin key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("active admin key not found with ID: %d", id)
+	}
+
+	logrus.WithField("admin_key_id", id).Info("admin key revoked successfully")
+	return nil
+}
+
+// IsValidAdminKey reports whether key matches an active runtime-managed admin key
+func (s *AdminKeyStore) IsValidAdminKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	var id int
+	query := `SELECT id FROM admin_keys WHERE key_hash = ? AND revoked_at IS NULL`
+	err := s.db.QueryRowContext(s.context(), s.db.Rebind(query), hashAdminKey(key)).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		logrus.WithError(err).Warn("failed to look up admin key")
+	}
+
+	return err == nil
+}
+
+// AuditStore provides database operations for the audit log
+type AuditStore struct {
+	db *sqlx.DB
+	storeContext
+}
+
+// NewAuditStore creates a new AuditStore instance
+func NewAuditStore(db *sqlx.DB) *AuditStore {
+	return &AuditStore{db: db}
+}
+
+// WithContext returns a copy of the store whose queries run with ctx
+func (s *AuditStore) WithContext(ctx context.Context) *AuditStore {
+	clone := *s
+	clone.ctx = ctx
+	return &clone
+}
+
+// RecordAudit appends an entry to the audit log
+func (s *AuditStore) RecordAudit(entry *AuditEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+
+	query := `
+		INSERT INTO audit_log (actor, action, target, details, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	id, err := insertID(s.context(), s.db, query, entry.Actor, entry.Action, entry.Target, entry.Details, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	entry.ID = int(id)
+
+	return nil
+}
+
+// ListAuditEntries returns the most recent audit entries, newest first
+func (s *AuditStore) ListAuditEntries(limit int) ([]*AuditEntry, error) {
+	query := `
+		SELECT id, actor, action, target, details, created_at
+		FROM audit_log
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`
+
+	entries := []*AuditEntry{}
+	if err := s.db.SelectContext(s.context(), &entries, s.db.Rebind(query), limit); err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	return entries, nil
+}
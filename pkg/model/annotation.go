@@ -0,0 +1,131 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MaxAnnotationLength is the longest comment an annotation may carry
+const MaxAnnotationLength = 1000
+
+// ErrResultNotFound is returned when an annotated result does not exist or
+// belongs to another job
+var ErrResultNotFound = errors.New("result not found")
+
+// ResultAnnotation is an operator comment on a job result, e.g. "known
+// issue, vendor outage". Annotations without a result ID cover the job's
+// failures as a whole.
+type ResultAnnotation struct {
+	ID        int       `json:"id" db:"id"`
+	JobName   string    `json:"job_name" db:"job_name"`
+	Host      string    `json:"host" db:"host"`
+	ResultID  int       `json:"result_id,omitempty" db:"result_id"`
+	Comment   string    `json:"comment" db:"comment"`
+	Author    string    `json:"author" db:"author"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// AnnotationStore provides database operations for result annotations
+type AnnotationStore struct {
+	db *sqlx.DB
+	storeContext
+}
+
+// NewAnnotationStore creates a new AnnotationStore instance
+func NewAnnotationStore(db *sqlx.DB) *AnnotationStore {
+	return &AnnotationStore{db: db}
+}
+
+// WithContext returns a copy of the store whose queries run with ctx
+func (s *AnnotationStore) WithContext(ctx context.Context) *AnnotationStore {
+	clone := *s
+	clone.ctx = ctx
+	return &clone
+}
+
+// CreateAnnotation stores an annotation. A result ID must name one of the
+// job's results.
+func (s *AnnotationStore) CreateAnnotation(annotation *ResultAnnotation) error {
+	if annotation.ResultID != 0 {
+		var count int
+		err := s.db.GetContext(s.context(), &count, s.db.Rebind(`SELECT COUNT(*) FROM job_results WHERE id = ? AND job_name = ? AND host = ?`),
+			annotation.ResultID, annotation.JobName, annotation.Host)
+		if err != nil {
+			return fmt.Errorf("failed to check annotated result: %w", err)
+		}
+		if count == 0 {
+			return ErrResultNotFound
+		}
+	}
+
+	if annotation.CreatedAt.IsZero() {
+		annotation.CreatedAt = time.Now().UTC()
+	}
+
+	query := `
+		INSERT INTO result_annotations (job_name, host, result_id, comment, author, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	id, err := insertID(s.context(), s.db, query, annotation.JobName, annotation.Host, annotation.ResultID,
+		annotation.Comment, annotation.Author, annotation.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create annotation: %w", err)
+	}
+	annotation.ID = int(id)
+
+	return nil
+}
+
+// ListAnnotations returns the annotations of a job, newest first
+func (s *AnnotationStore) ListAnnotations(jobName, host string) ([]*ResultAnnotation, error) {
+	query := `
+		SELECT id, job_name, host, result_id, comment, author, created_at
+		FROM result_annotations
+		WHERE job_name = ? AND host = ?
+		ORDER BY created_at DESC, id DESC
+	`
+
+	annotations := []*ResultAnnotation{}
+	if err := s.db.SelectContext(s.context(), &annotations, s.db.Rebind(query), jobName, host); err != nil {
+		return nil, fmt.Errorf("failed to list annotations: %w", err)
+	}
+
+	return annotations, nil
+}
+
+// DeleteAnnotation removes one of a job's annotations
+func (s *AnnotationStore) DeleteAnnotation(id int, jobName, host string) error {
+	result, err := s.db.ExecContext(s.context(), s.db.Rebind(`DELETE FROM result_annotations WHERE id = ? AND job_name = ? AND host = ?`), id, jobName, host)
+	if err != nil {
+		return fmt.Errorf("failed to delete annotation: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("annotation with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// AttachAnnotations sets the annotations of each result from a job's
+// annotations
+func AttachAnnotations(results []*JobResult, annotations []*ResultAnnotation) {
+	byResult := make(map[int][]*ResultAnnotation)
+	for _, annotation := range annotations {
+		if annotation.ResultID != 0 {
+			byResult[annotation.ResultID] = append(byResult[annotation.ResultID], annotation)
+		}
+	}
+	for _, result := range results {
+		result.Annotations = byResult[result.ID]
+	}
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// ListJobResultsBefore returns up to limit results recorded before the given
+// time, oldest row first
+func (s *JobResultStore) ListJobResultsBefore(before time.Time, limit int) ([]*JobResult, error) {
+	query := `
+		SELECT id, job_name, host, status, labels, duration, output, timestamp, ` + resultSourceColumns + `
+		FROM job_results
+		WHERE timestamp < ?
+		ORDER BY id
+		LIMIT ?
+	`
+
+	rows, err := s.db.QueryxContext(s.context(), s.db.Rebind(query), before.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*JobResult
+	for rows.Next() {
+		result := &JobResult{}
+		var labelsJSON string
+		var output sql.NullString
+		var duration sql.NullInt64
+		var source resultSource
+
+		dest := append([]interface{}{&result.ID, &result.JobName, &result.Host, &result.Status, &labelsJSON, &duration, &output, &result.Timestamp}, source.dest()...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan job result row: %w", err)
+		}
+		source.apply(result)
+
+		if duration.Valid {
+			result.Duration = int(duration.Int64)
+		}
+		if output.Valid {
+			result.Output = output.String
+		}
+
+		if labelsJSON != "{}" && labelsJSON != "" {
+			if err := json.Unmarshal([]byte(labelsJSON), &result.Labels); err != nil {
+				logrus.WithError(err).Warn("failed to unmarshal job result labels")
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// DeleteJobResults deletes the results with the given IDs and returns the
+// number of rows removed
+func (s *JobResultStore) DeleteJobResults(ids []int) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	query, args, err := sqlx.In("DELETE FROM job_results WHERE id IN (?)", ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build delete query: %w", err)
+	}
+
+	res, err := s.db.ExecContext(s.context(), s.db.Rebind(query), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete job results: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+// RestoreJobResults inserts previously exported results, keeping their IDs.
+// Results still present in the database are skipped, so restoring the same
+// data twice is harmless, and so are results of jobs that no longer exist.
+// It returns the number of rows inserted.
+func (s *JobResultStore) RestoreJobResults(results []*JobResult) (int64, error) {
+	tx, err := s.db.BeginTxx(s.context(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := ignoringDuplicates(tx, `
+		INSERT INTO job_results (id, job_name, host, status, labels, duration, output, timestamp, `+resultSourceColumns+`)
+		SELECT ?, name, host, ?, ?, ?, ?, ?, `+resultSourcePlaceholders+` FROM jobs WHERE name = ? AND host = ?
+	`)
+
+	var restored int64
+	for _, result := range results {
+		labelsJSON := "{}"
+		if result.Labels != nil {
+			if bytes, err := json.Marshal(result.Labels); err == nil {
+				labelsJSON = string(bytes)
+			}
+		}
+
+		args := append([]interface{}{result.ID, result.Status, labelsJSON, result.Duration, result.Output, result.Timestamp}, sourceArgs(result)...)
+		res, err := tx.Exec(tx.Rebind(query), append(args, result.JobName, result.Host)...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to restore job result %d: %w", result.ID, err)
+		}
+		inserted, _ := res.RowsAffected()
+		restored += inserted
+	}
+
+	// PostgreSQL does not move the id sequence past ids inserted explicitly
+	if restored > 0 && dialectOf(tx) == DriverPostgres {
+		if _, err := tx.Exec(`SELECT setval(pg_get_serial_sequence('job_results', 'id'), (SELECT MAX(id) FROM job_results))`); err != nil {
+			return 0, fmt.Errorf("failed to move the job result id sequence: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit restored job results: %w", err)
+	}
+
+	return restored, nil
+}
@@ -0,0 +1,175 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrCursorExpired is returned when the changes after a cursor were
+// pruned, so the feed cannot be resumed from it
+var ErrCursorExpired = errors.New("cursor expired")
+
+// Change is a job or result change event recorded for the change feed
+type Change struct {
+	ID int `json:"id"`
+	ChangeEvent
+}
+
+// ChangePage is the changes after a cursor, oldest first
+type ChangePage struct {
+	Changes    []*Change `json:"changes"`
+	NextCursor string    `json:"next_cursor"`        // Resumes after the last change; the given cursor when there is none
+	HasMore    bool      `json:"has_more,omitempty"` // More changes follow right away
+}
+
+// ChangeStore provides database operations for the change feed
+type ChangeStore struct {
+	db *sqlx.DB
+	storeContext
+}
+
+// NewChangeStore creates a new ChangeStore instance
+func NewChangeStore(db *sqlx.DB) *ChangeStore {
+	return &ChangeStore{db: db}
+}
+
+// WithContext returns a copy of the store whose queries run with ctx
+func (s *ChangeStore) WithContext(ctx context.Context) *ChangeStore {
+	clone := *s
+	clone.ctx = ctx
+	return &clone
+}
+
+// recordChange appends a change to the change feed in tx, the transaction
+// making the change, so the change is in the feed if and only if it is
+// committed
+func recordChange(ctx context.Context, tx *sqlx.Tx, event ChangeEvent) error {
+	// Per-job API keys never leave the server
+	if event.Job != nil {
+		job := *event.Job
+		job.ApiKey = ""
+		event.Job = &job
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal change: %w", err)
+	}
+	if err := lockChanges(ctx, tx); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, tx.Rebind(`INSERT INTO changes (type, time, payload) VALUES (?, ?, ?)`), event.Type, event.Time, string(payload)); err != nil {
+		return fmt.Errorf("failed to record change: %w", err)
+	}
+	return nil
+}
+
+// lockChanges keeps other transactions from recording changes until tx
+// ends. Ids are taken when a change is inserted but become visible when it
+// is committed, so without it a poller could move its cursor past the id
+// of a change still in flight and never see it. SQLite already runs one
+// writing transaction at a time.
+func lockChanges(ctx context.Context, tx *sqlx.Tx) error {
+	var query string
+	switch dialectOf(tx) {
+	case DriverPostgres:
+		// Reads of the feed are not blocked
+		query = `LOCK TABLE changes IN EXCLUSIVE MODE`
+	case DriverMySQL:
+		// Locks the latest id and the gap after it
+		query = `SELECT MAX(id) FROM changes FOR UPDATE`
+	default:
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to lock change feed: %w", err)
+	}
+	return nil
+}
+
+// ListChanges returns up to limit changes after cursor, oldest first. An
+// empty cursor starts from the oldest change kept; ErrCursorExpired is
+// returned when changes after cursor were pruned since.
+func (s *ChangeStore) ListChanges(cursor string, limit int) (*ChangePage, error) {
+	afterID, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if afterID > 0 {
+		var oldest sql.NullInt64
+		if err := s.db.GetContext(s.context(), &oldest, `SELECT MIN(id) FROM changes`); err != nil {
+			return nil, fmt.Errorf("failed to get oldest change: %w", err)
+		}
+		if oldest.Valid && int64(afterID) < oldest.Int64-1 {
+			return nil, ErrCursorExpired
+		}
+	}
+
+	query := `SELECT id, payload FROM changes WHERE id > ? ORDER BY id LIMIT ?`
+	rows, err := s.db.QueryxContext(s.context(), s.db.Rebind(query), afterID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+	defer rows.Close()
+
+	page := &ChangePage{Changes: []*Change{}, NextCursor: cursor}
+	for rows.Next() {
+		var id int
+		var payload string
+		if err := rows.Scan(&id, &payload); err != nil {
+			return nil, fmt.Errorf("failed to scan change row: %w", err)
+		}
+		if len(page.Changes) == limit {
+			page.HasMore = true
+			break
+		}
+
+		change := &Change{ID: id}
+		if err := json.Unmarshal([]byte(payload), &change.ChangeEvent); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal change %d: %w", id, err)
+		}
+		page.Changes = append(page.Changes, change)
+		page.NextCursor = encodeCursor(id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating change rows: %w", err)
+	}
+
+	return page, nil
+}
+
+// LatestCursor returns the cursor after the latest change, to follow the
+// feed from now on; empty when nothing changed yet
+func (s *ChangeStore) LatestCursor() (string, error) {
+	var latest sql.NullInt64
+	if err := s.db.GetContext(s.context(), &latest, `SELECT MAX(id) FROM changes`); err != nil {
+		return "", fmt.Errorf("failed to get latest change: %w", err)
+	}
+	if !latest.Valid {
+		return "", nil
+	}
+	return encodeCursor(int(latest.Int64)), nil
+}
+
+// PruneChanges deletes the changes recorded before the given time, and
+// returns how many were deleted. The latest change is always kept, so
+// expired cursors can be told apart.
+func (s *ChangeStore) PruneChanges(before time.Time) (int64, error) {
+	// MySQL refuses to read the table a DELETE changes, unless through a
+	// derived table
+	query := `DELETE FROM changes WHERE time < ? AND id < (SELECT latest FROM (SELECT MAX(id) AS latest FROM changes) AS latest_change)`
+
+	result, err := s.db.ExecContext(s.context(), s.db.Rebind(query), before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune changes: %w", err)
+	}
+	return result.RowsAffected()
+}
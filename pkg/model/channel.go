@@ -0,0 +1,117 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrChannelNotFound is returned for an unknown notifier channel
+var ErrChannelNotFound = errors.New("notifier channel not found")
+
+// NotifierChannel is a notification channel managed from the dashboard, in
+// addition to those of the configuration file. Settings holds the JSON of
+// the channel's configuration.
+type NotifierChannel struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Type      string    `json:"type" db:"type"`
+	Settings  string    `json:"-" db:"settings"` // May hold passwords and tokens
+	UpdatedBy string    `json:"updated_by" db:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ChannelStore provides database operations for notifier channels
+type ChannelStore struct {
+	db *sqlx.DB
+	storeContext
+}
+
+// NewChannelStore creates a new ChannelStore instance
+func NewChannelStore(db *sqlx.DB) *ChannelStore {
+	return &ChannelStore{db: db}
+}
+
+// WithContext returns a copy of the store whose queries run with ctx
+func (s *ChannelStore) WithContext(ctx context.Context) *ChannelStore {
+	clone := *s
+	clone.ctx = ctx
+	return &clone
+}
+
+// ListChannels returns every notifier channel, by name
+func (s *ChannelStore) ListChannels() ([]*NotifierChannel, error) {
+	channels := []*NotifierChannel{}
+	if err := s.db.SelectContext(s.context(), &channels, `SELECT * FROM notifier_channels ORDER BY name`); err != nil {
+		return nil, fmt.Errorf("failed to list notifier channels: %w", err)
+	}
+	return channels, nil
+}
+
+// GetChannel returns a notifier channel by ID
+func (s *ChannelStore) GetChannel(id int) (*NotifierChannel, error) {
+	channel := &NotifierChannel{}
+	if err := s.db.GetContext(s.context(), channel, s.db.Rebind(`SELECT * FROM notifier_channels WHERE id = ?`), id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %d", ErrChannelNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get notifier channel: %w", err)
+	}
+	return channel, nil
+}
+
+// SaveChannel creates the channel, or updates it when it has an ID
+func (s *ChannelStore) SaveChannel(channel *NotifierChannel) error {
+	channel.UpdatedAt = time.Now().UTC()
+
+	if channel.ID == 0 {
+		query := `
+			INSERT INTO notifier_channels (name, type, settings, updated_by, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+		`
+		id, err := insertID(s.context(), s.db, query, channel.Name, channel.Type, channel.Settings, channel.UpdatedBy, channel.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create notifier channel: %w", err)
+		}
+		channel.ID = int(id)
+	} else {
+		query := `
+			UPDATE notifier_channels SET name = ?, type = ?, settings = ?, updated_by = ?, updated_at = ?
+			WHERE id = ?
+		`
+		result, err := s.db.ExecContext(s.context(), s.db.Rebind(query), channel.Name, channel.Type, channel.Settings,
+			channel.UpdatedBy, channel.UpdatedAt, channel.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update notifier channel: %w", err)
+		}
+		if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+			return fmt.Errorf("%w: %d", ErrChannelNotFound, channel.ID)
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"channel_id": channel.ID,
+		"name":       channel.Name,
+		"type":       channel.Type,
+	}).Info("notifier channel saved")
+	return nil
+}
+
+// DeleteChannel deletes a notifier channel
+func (s *ChannelStore) DeleteChannel(id int) error {
+	result, err := s.db.ExecContext(s.context(), s.db.Rebind(`DELETE FROM notifier_channels WHERE id = ?`), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete notifier channel: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("%w: %d", ErrChannelNotFound, id)
+	}
+
+	logrus.WithField("channel_id", id).Info("notifier channel deleted")
+	return nil
+}
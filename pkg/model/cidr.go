@@ -0,0 +1,57 @@
+package model
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// NormalizeCIDRs validates source ranges and returns them in canonical
+// form. A bare address is taken as a single-address range.
+func NormalizeCIDRs(cidrs []string) ([]string, error) {
+	if cidrs == nil {
+		return nil, nil
+	}
+
+	normalized := make([]string, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(cidr)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid CIDR %q", cidr)
+			}
+			prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+		normalized = append(normalized, prefix.Masked().String())
+	}
+	return normalized, nil
+}
+
+// AllowsSource reports whether results for the job may be submitted from
+// addr. Jobs without allowed CIDRs accept any source; addresses that cannot
+// be parsed, such as those of Unix socket clients, match no range.
+func (j *Job) AllowsSource(addr string) bool {
+	return len(j.AllowedCIDRs) == 0 || SourceInCIDRs(addr, j.AllowedCIDRs)
+}
+
+// SourceInCIDRs reports whether addr is in one of cidrs, which may hold
+// bare addresses. Addresses that cannot be parsed match no range.
+func SourceInCIDRs(addr string, cidrs []string) bool {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+
+	for _, cidr := range cidrs {
+		normalized, err := NormalizeCIDRs([]string{cidr})
+		if err != nil {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(normalized[0])
+		if err == nil && prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+package model
+
+import "context"
+
+// storeContext is the context a store's queries run with: a query stops
+// when it is canceled or past its deadline. Stores have none by default;
+// their WithContext method returns a copy bound to one, e.g. to a request.
+type storeContext struct {
+	ctx context.Context
+}
+
+// context returns the store's context, the background context when unset
+func (c storeContext) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
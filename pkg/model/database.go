@@ -1,12 +1,18 @@
 package model
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
@@ -16,26 +22,144 @@ type Database struct {
 	db *sqlx.DB
 }
 
-// NewDatabase creates a new Database instance
+// DatabaseOptions tune the connection pool and the SQLite pragmas. Zero
+// values keep the defaults. Other drivers ignore the pragmas.
+type DatabaseOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	BusyTimeout     time.Duration // How long a statement waits on a locked database, 5s by default
+	JournalMode     string        // e.g. "wal" (the default) or "delete"
+}
+
+// NewDatabase creates a new Database instance with the default options
 func NewDatabase(dbPath string) (*Database, error) {
+	return NewDatabaseWithOptions(dbPath, DatabaseOptions{})
+}
+
+// OpenDatabase opens the database of a driver, as set by database.driver:
+// the SQLite file at source, or the PostgreSQL or MySQL server at the data
+// source name source. Migrations are run.
+func OpenDatabase(driverName, source string, opts DatabaseOptions) (*Database, error) {
+	switch driverName {
+	case "", DriverSQLite:
+		return NewDatabaseWithOptions(source, opts)
+	case DriverPostgres:
+		return openServerDatabase(DriverPostgres, source, opts)
+	case DriverMySQL:
+		dsn, err := mysqlDSN(source)
+		if err != nil {
+			return nil, err
+		}
+		return openServerDatabase(DriverMySQL, dsn, opts)
+	default:
+		return nil, fmt.Errorf("invalid database driver %q: must be one of %s", driverName, strings.Join(Drivers, ", "))
+	}
+}
+
+// openServerDatabase opens a database shared over the network, which
+// several instances can use at once
+func openServerDatabase(driverName, dsn string, opts DatabaseOptions) (*Database, error) {
+	db, err := sqlx.Open(driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if opts.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	if opts.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	database := &Database{db: db}
+	if err := database.RunMigrations(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	// The data source name may hold a password, so it is not logged
+	logrus.WithField("driver", dialectOf(db)).Info("database initialized successfully")
+	return database, nil
+}
+
+// NewDatabaseWithOptions creates a new Database instance. Foreign keys are
+// always enforced.
+func NewDatabaseWithOptions(dbPath string, opts DatabaseOptions) (*Database, error) {
+	if opts.BusyTimeout <= 0 {
+		opts.BusyTimeout = 5 * time.Second
+	}
+	if opts.JournalMode == "" {
+		opts.JournalMode = "wal"
+	}
+	opts.JournalMode = strings.ToLower(opts.JournalMode)
+	if !slices.Contains(journalModes, opts.JournalMode) {
+		return nil, fmt.Errorf("invalid journal mode %q: must be one of %s", opts.JournalMode, strings.Join(journalModes, ", "))
+	}
+
 	// Ensure the directory exists
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0750); err != nil {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 
-	db, err := sqlx.Open("sqlite", dbPath+"?_foreign_keys=on")
+	// The driver applies _pragma parameters to every new connection
+	pragmas := []string{"foreign_keys(1)", fmt.Sprintf("busy_timeout(%d)", opts.BusyTimeout.Milliseconds())}
+	inMemory := dbPath == ":memory:"
+	if !inMemory {
+		pragmas = append(pragmas, "journal_mode("+opts.JournalMode+")")
+	}
+	dsn := dbPath + "?_pragma=" + strings.Join(pragmas, "&_pragma=")
+
+	db, err := sqlx.Open(DriverSQLite, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	if opts.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	if opts.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
+	// Each connection to ":memory:" opens its own empty database, so an
+	// in-memory database must stay on a single connection that never expires
+	if inMemory {
+		db.SetMaxOpenConns(1)
+		db.SetConnMaxLifetime(0)
+	}
+
 	// Test the connection
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
 	database := &Database{db: db}
 
+	expected := map[string]string{
+		"foreign_keys": "1",
+		"busy_timeout": strconv.FormatInt(opts.BusyTimeout.Milliseconds(), 10),
+	}
+	if !inMemory {
+		expected["journal_mode"] = opts.JournalMode
+	}
+	if err := database.checkPragmas(expected); err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	// Run migrations
 	if err := database.RunMigrations(); err != nil {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
@@ -45,6 +169,39 @@ func NewDatabase(dbPath string) (*Database, error) {
 	return database, nil
 }
 
+// journalModes are the SQLite journal modes accepted by DatabaseOptions
+var journalModes = []string{"wal", "delete", "truncate", "persist", "memory", "off"}
+
+// Pragma returns the current value of a pragma, as seen by one connection
+func (d *Database) Pragma(name string) (string, error) {
+	var value string
+	if err := d.db.Get(&value, "PRAGMA "+name); err != nil {
+		return "", fmt.Errorf("failed to read pragma %s: %w", name, err)
+	}
+	return value, nil
+}
+
+// checkPragmas fails unless the pragmas have the expected values, so
+// settings a driver ignores are noticed instead of silently lost
+func (d *Database) checkPragmas(expected map[string]string) error {
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, err := d.Pragma(name)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(value, expected[name]) {
+			return fmt.Errorf("pragma %s is %q instead of %q: the %s driver did not apply it", name, value, expected[name], DriverSQLite)
+		}
+	}
+	return nil
+}
+
 // GetDB returns the underlying sqlx database connection
 func (d *Database) GetDB() *sqlx.DB {
 	return d.db
@@ -57,13 +214,43 @@ func (d *Database) Close() error {
 
 // RunMigrations applies all pending migrations
 func (d *Database) RunMigrations() error {
+	ctx := context.Background()
+	conn, err := d.db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get a connection: %w", err)
+	}
+	defer conn.Close()
+
+	sqlite := dialectOf(d.db) == DriverSQLite
+	if sqlite {
+		// Migrations rebuild tables, and dropping a table with foreign keys
+		// on deletes the rows referencing it. They run on one connection
+		// with foreign keys off, checked once all are applied.
+		if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+			return fmt.Errorf("failed to disable foreign keys: %w", err)
+		}
+		defer func() {
+			if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = ON"); err != nil {
+				// Never hand the connection back to the pool without foreign keys
+				_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+			}
+		}()
+	} else {
+		// Instances sharing the database may start together
+		unlock, err := d.lockMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
 	// Create migrations table if it doesn't exist
-	if err := d.createMigrationsTable(); err != nil {
+	if err := d.createMigrationsTable(ctx, conn); err != nil {
 		return fmt.Errorf("failed to create migrations table: %w", err)
 	}
 
 	// Get applied migrations
-	appliedMigrations, err := d.getAppliedMigrations()
+	appliedMigrations, err := d.getAppliedMigrations(ctx, conn)
 	if err != nil {
 		return fmt.Errorf("failed to get applied migrations: %w", err)
 	}
@@ -77,32 +264,76 @@ func (d *Database) RunMigrations() error {
 	// Apply pending migrations
 	for _, filename := range migrationFiles {
 		if _, applied := appliedMigrations[filename]; !applied {
-			if err := d.applyMigration(filename); err != nil {
+			if err := d.applyMigration(ctx, conn, filename); err != nil {
 				return fmt.Errorf("failed to apply migration %s: %w", filename, err)
 			}
 		}
 	}
+
+	if !sqlite {
+		return nil
+	}
+
+	// Rows referencing missing rows, e.g. results of jobs deleted before
+	// foreign keys were enforced, are kept but reported
+	var violations int
+	if err := conn.GetContext(ctx, &violations, "SELECT COUNT(*) FROM pragma_foreign_key_check"); err != nil {
+		return fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	if violations > 0 {
+		logrus.WithField("rows", violations).Warn("database has rows referencing deleted rows; run PRAGMA foreign_key_check for details")
+	}
 	return nil
 }
 
+// migrationLock names the lock held while migrations run on PostgreSQL
+// and MySQL
+const migrationLock = "cronmetrics_migrations"
+
+// lockMigrations waits until no other instance runs migrations on the
+// database, and returns the function ending the wait of the next one
+func (d *Database) lockMigrations(ctx context.Context, conn *sqlx.Conn) (func(), error) {
+	lock, unlock := "SELECT pg_advisory_lock(hashtext(?))", "SELECT pg_advisory_unlock(hashtext(?))"
+	if dialectOf(d.db) == DriverMySQL {
+		lock, unlock = "SELECT GET_LOCK(?, -1)", "SELECT RELEASE_LOCK(?)"
+	}
+
+	if _, err := conn.ExecContext(ctx, conn.Rebind(lock), migrationLock); err != nil {
+		return nil, fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	return func() {
+		if _, err := conn.ExecContext(ctx, conn.Rebind(unlock), migrationLock); err != nil {
+			logrus.WithError(err).Warn("failed to unlock migrations")
+		}
+	}, nil
+}
+
 // createMigrationsTable creates the migrations tracking table
-func (d *Database) createMigrationsTable() error {
+func (d *Database) createMigrationsTable(ctx context.Context, conn *sqlx.Conn) error {
 	query := `
 		CREATE TABLE IF NOT EXISTS migrations (
 			filename TEXT PRIMARY KEY,
 			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)
 	`
+	if dialectOf(d.db) != DriverSQLite {
+		query = `
+			CREATE TABLE IF NOT EXISTS migrations (
+				filename VARCHAR(255) PRIMARY KEY,
+				applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)
+		`
+	}
 
-	_, err := d.db.Exec(query)
+	_, err := conn.ExecContext(ctx, query)
 	return err
 }
 
 // getAppliedMigrations returns a map of applied migration filenames
-func (d *Database) getAppliedMigrations() (map[string]bool, error) {
+func (d *Database) getAppliedMigrations(ctx context.Context, conn *sqlx.Conn) (map[string]bool, error) {
 	query := `SELECT filename FROM migrations`
 
-	rows, err := d.db.Query(query)
+	rows, err := conn.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -122,6 +353,15 @@ func (d *Database) getAppliedMigrations() (map[string]bool, error) {
 
 // getMigrationFiles returns sorted list of migration files
 func (d *Database) getMigrationFiles() ([]string, error) {
+	if migrations := d.serverMigrations(); migrations != nil {
+		files := make([]string, 0, len(migrations))
+		for filename := range migrations {
+			files = append(files, filename)
+		}
+		sort.Strings(files)
+		return files, nil
+	}
+
 	// For embedded migrations, we'll define them inline
 	// In a real application, you might read from a migrations/ directory
 	migrations := []string{
@@ -129,6 +369,32 @@ func (d *Database) getMigrationFiles() ([]string, error) {
 		"002_create_job_results_table.sql",
 		"003_add_api_key_to_jobs.sql",
 		"004_add_job_id_column.sql",
+		"005_create_admin_keys_and_audit_log.sql",
+		"006_create_result_rejections.sql",
+		"007_create_result_nonces.sql",
+		"008_add_allowed_cidrs_to_jobs.sql",
+		"009_add_snoozed_until_to_jobs.sql",
+		"010_create_result_annotations.sql",
+		"011_create_job_acknowledgements.sql",
+		"012_add_description_to_jobs.sql",
+		"013_create_notification_deliveries.sql",
+		"014_add_threshold_inherited_to_jobs.sql",
+		"015_create_job_starts.sql",
+		"016_add_source_to_job_results.sql",
+		"017_add_clock_skew_to_job_results.sql",
+		"018_create_maintenance_windows.sql",
+		"019_create_changes.sql",
+		"020_add_submission_source_to_job_results.sql",
+		"021_create_status_rules.sql",
+		"022_add_test_to_job_results.sql",
+		"023_add_counter_jobs.sql",
+		"024_create_job_versions.sql",
+		"025_add_schedule_to_jobs.sql",
+		"026_add_run_id.sql",
+		"027_add_shards.sql",
+		"028_create_job_overlaps.sql",
+		"029_create_notifier_channels.sql",
+		"030_add_min_interval_to_jobs.sql",
 	}
 
 	sort.Strings(migrations)
@@ -136,26 +402,36 @@ func (d *Database) getMigrationFiles() ([]string, error) {
 }
 
 // applyMigration applies a single migration
-func (d *Database) applyMigration(filename string) error {
+func (d *Database) applyMigration(ctx context.Context, conn *sqlx.Conn, filename string) error {
 	sql, err := d.getMigrationSQL(filename)
 	if err != nil {
 		return fmt.Errorf("failed to get migration SQL: %w", err)
 	}
 
 	// Execute the migration in a transaction
-	tx, err := d.db.Begin()
+	tx, err := conn.BeginTxx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Execute migration SQL
-	if _, err := tx.Exec(sql); err != nil {
-		return fmt.Errorf("failed to execute migration SQL: %w", err)
+	// Execute migration SQL. The server drivers run one statement at a
+	// time, and MySQL commits each statement changing the schema.
+	statements := []string{sql}
+	if dialectOf(d.db) != DriverSQLite {
+		statements = strings.Split(sql, ";")
+	}
+	for _, statement := range statements {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("failed to execute migration SQL: %w", err)
+		}
 	}
 
 	// Record migration as applied
-	if _, err := tx.Exec("INSERT INTO migrations (filename) VALUES (?)", filename); err != nil {
+	if _, err := tx.Exec(tx.Rebind("INSERT INTO migrations (filename) VALUES (?)"), filename); err != nil {
 		return fmt.Errorf("failed to record migration: %w", err)
 	}
 
@@ -167,8 +443,29 @@ func (d *Database) applyMigration(filename string) error {
 	return nil
 }
 
+// serverMigrations returns the migrations of PostgreSQL or MySQL
+// databases, or nil for SQLite ones
+func (d *Database) serverMigrations() map[string]string {
+	switch dialectOf(d.db) {
+	case DriverPostgres:
+		return postgresMigrations
+	case DriverMySQL:
+		return mysqlMigrations
+	default:
+		return nil
+	}
+}
+
 // getMigrationSQL returns the SQL for a migration file
 func (d *Database) getMigrationSQL(filename string) (string, error) {
+	if migrations := d.serverMigrations(); migrations != nil {
+		sql, ok := migrations[filename]
+		if !ok {
+			return "", fmt.Errorf("unknown migration file: %s", filename)
+		}
+		return sql, nil
+	}
+
 	switch filename {
 	case "001_create_jobs_table.sql":
 		return `
@@ -260,6 +557,333 @@ func (d *Database) getMigrationSQL(filename string) (string, error) {
 			CREATE INDEX idx_job_results_job_id ON job_results(job_id);
 		`, nil
 
+	case "005_create_admin_keys_and_audit_log.sql":
+		return `
+			-- Admin API keys managed at runtime, in addition to those in the config file.
+			-- Only a SHA-256 hash of the key is stored.
+			CREATE TABLE admin_keys (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				key_hash TEXT NOT NULL UNIQUE,
+				key_prefix TEXT NOT NULL,
+				created_by TEXT NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				revoked_at DATETIME
+			);
+
+			-- Audit trail of administrative actions
+			CREATE TABLE audit_log (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				actor TEXT NOT NULL,
+				action TEXT NOT NULL,
+				target TEXT NOT NULL DEFAULT '',
+				details TEXT NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+		`, nil
+
+	case "006_create_result_rejections.sql":
+		return `
+			-- Job result submissions refused by the API or the ingestion consumers
+			CREATE TABLE result_rejections (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				reason TEXT NOT NULL,
+				message TEXT NOT NULL,
+				job_name TEXT NOT NULL DEFAULT '',
+				host TEXT NOT NULL DEFAULT '',
+				key_prefix TEXT NOT NULL DEFAULT '',
+				source TEXT NOT NULL DEFAULT '',
+				fingerprint TEXT NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX idx_result_rejections_reason ON result_rejections(reason);
+		`, nil
+
+	case "007_create_result_nonces.sql":
+		return `
+			-- Nonces of accepted job results, kept until their timestamp leaves the
+			-- allowed clock skew and a replay would be refused anyway
+			CREATE TABLE result_nonces (
+				job_name TEXT NOT NULL,
+				host TEXT NOT NULL,
+				nonce TEXT NOT NULL,
+				expires_at INTEGER NOT NULL, -- Unix time
+				PRIMARY KEY (job_name, host, nonce)
+			);
+
+			CREATE INDEX idx_result_nonces_expires_at ON result_nonces(expires_at);
+		`, nil
+
+	case "008_add_allowed_cidrs_to_jobs.sql":
+		return `
+			-- JSON array of the source ranges a job's results are accepted from
+			ALTER TABLE jobs ADD COLUMN allowed_cidrs TEXT NOT NULL DEFAULT '[]';
+		`, nil
+
+	case "009_add_snoozed_until_to_jobs.sql":
+		return `
+			-- End of a job's snooze, during which its failures are not reported
+			ALTER TABLE jobs ADD COLUMN snoozed_until DATETIME;
+		`, nil
+
+	case "010_create_result_annotations.sql":
+		return `
+			-- Operator comments on a job's results, or on its failures as a whole
+			CREATE TABLE result_annotations (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				job_name TEXT NOT NULL,
+				host TEXT NOT NULL,
+				result_id INTEGER NOT NULL DEFAULT 0, -- 0 annotates the job rather than one result
+				comment TEXT NOT NULL,
+				author TEXT NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX idx_result_annotations_job ON result_annotations(job_name, host);
+		`, nil
+
+	case "011_create_job_acknowledgements.sql":
+		return `
+			-- Records of someone handling a job's failures, with the ticket tracking the work
+			CREATE TABLE job_acknowledgements (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				job_name TEXT NOT NULL,
+				host TEXT NOT NULL,
+				author TEXT NOT NULL DEFAULT '',
+				ticket_id TEXT NOT NULL DEFAULT '',
+				ticket_url TEXT NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX idx_job_acknowledgements_job ON job_acknowledgements(job_name, host);
+		`, nil
+
+	case "012_add_description_to_jobs.sql":
+		return `
+			-- Markdown notes on what a job does and how to rerun it
+			ALTER TABLE jobs ADD COLUMN description TEXT NOT NULL DEFAULT '';
+		`, nil
+
+	case "013_create_notification_deliveries.sql":
+		return `
+			-- Attempts to send notifications about jobs, kept after the job is deleted
+			CREATE TABLE notification_deliveries (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				job_name TEXT NOT NULL,
+				host TEXT NOT NULL,
+				channel TEXT NOT NULL,
+				team TEXT NOT NULL DEFAULT '',
+				target TEXT NOT NULL DEFAULT '',
+				status TEXT NOT NULL,
+				latency_ms INTEGER NOT NULL DEFAULT 0,
+				error TEXT NOT NULL DEFAULT '',
+				reference TEXT NOT NULL DEFAULT '',
+				url TEXT NOT NULL DEFAULT '',
+				subject TEXT NOT NULL DEFAULT '',
+				body TEXT NOT NULL DEFAULT '',
+				redelivery_of INTEGER REFERENCES notification_deliveries(id) ON DELETE SET NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX idx_notification_deliveries_job ON notification_deliveries(job_name, host);
+		`, nil
+
+	case "014_add_threshold_inherited_to_jobs.sql":
+		return `
+			-- Set when a job's failure threshold follows the configured defaults
+			-- rather than its own; existing jobs keep theirs
+			ALTER TABLE jobs ADD COLUMN threshold_inherited BOOLEAN NOT NULL DEFAULT 0;
+		`, nil
+
+	case "015_create_job_starts.sql":
+		return `
+			-- The run each job last reported starting, until its result
+			-- arrives or it is recorded as lost
+			CREATE TABLE job_starts (
+				job_name TEXT NOT NULL,
+				host TEXT NOT NULL,
+				started_at DATETIME NOT NULL,
+				PRIMARY KEY (job_name, host)
+			);
+		`, nil
+
+	case "016_add_source_to_job_results.sql":
+		return `
+			-- Where each result came from and when the server received it;
+			-- unknown for earlier results
+			ALTER TABLE job_results ADD COLUMN source_ip TEXT;
+			ALTER TABLE job_results ADD COLUMN user_agent TEXT;
+			ALTER TABLE job_results ADD COLUMN client_version TEXT;
+			ALTER TABLE job_results ADD COLUMN received_at DATETIME;
+		`, nil
+
+	case "017_add_clock_skew_to_job_results.sql":
+		return `
+			-- How far the client's clock was off when it submitted each
+			-- result; NULL when it sent no timestamp
+			ALTER TABLE job_results ADD COLUMN clock_skew REAL;
+			ALTER TABLE job_results ADD COLUMN clock_skewed BOOLEAN NOT NULL DEFAULT 0;
+		`, nil
+
+	case "018_create_maintenance_windows.sql":
+		return `
+			-- Planned downtime of the jobs matching a label selector, and the
+			-- jobs each window put in maintenance, to restore when it ends
+			CREATE TABLE maintenance_windows (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				selector TEXT NOT NULL,
+				until DATETIME,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				ended_at DATETIME
+			);
+
+			CREATE TABLE maintenance_window_jobs (
+				window_id INTEGER NOT NULL REFERENCES maintenance_windows(id) ON DELETE CASCADE,
+				job_id INTEGER NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+				PRIMARY KEY (window_id, job_id)
+			);
+		`, nil
+
+	case "019_create_changes.sql":
+		return `
+			-- Job and result change events, in order, for pollers of the
+			-- change feed
+			CREATE TABLE changes (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				type TEXT NOT NULL,
+				time DATETIME NOT NULL,
+				payload TEXT NOT NULL
+			);
+
+			CREATE INDEX idx_changes_time ON changes(time);
+		`, nil
+
+	case "020_add_submission_source_to_job_results.sql":
+		return `
+			-- How each result was submitted when not through the JSON API,
+			-- e.g. "ping"
+			ALTER TABLE job_results ADD COLUMN source TEXT;
+		`, nil
+
+	case "021_create_status_rules.sql":
+		return `
+			-- Per-job rules overriding the reported status of results whose
+			-- output or exit code match, and the trail of each override
+			CREATE TABLE status_rules (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				job_name TEXT NOT NULL,
+				host TEXT NOT NULL,
+				pattern TEXT NOT NULL DEFAULT '',
+				exit_code INTEGER,
+				status TEXT NOT NULL,
+				description TEXT NOT NULL DEFAULT '',
+				author TEXT NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX idx_status_rules_job ON status_rules(job_name, host);
+
+			ALTER TABLE job_results ADD COLUMN exit_code INTEGER;
+			ALTER TABLE job_results ADD COLUMN reported_status TEXT;
+			ALTER TABLE job_results ADD COLUMN status_rule_id INTEGER;
+		`, nil
+
+	case "022_add_test_to_job_results.sql":
+		return `
+			-- Synthetic results of test submissions kept in the history
+			ALTER TABLE job_results ADD COLUMN test BOOLEAN NOT NULL DEFAULT 0;
+		`, nil
+
+	case "023_add_counter_jobs.sql":
+		return `
+			-- Counter jobs report the items each run processed, and fail
+			-- when a successful run processed fewer than their floor
+			ALTER TABLE jobs ADD COLUMN type TEXT NOT NULL DEFAULT 'standard';
+			ALTER TABLE jobs ADD COLUMN throughput_floor INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE job_results ADD COLUMN items_processed INTEGER;
+		`, nil
+
+	case "024_create_job_versions.sql":
+		return `
+			-- Configurations jobs had before each update changed them
+			CREATE TABLE job_versions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				job_id INTEGER NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+				version INTEGER NOT NULL,
+				config TEXT NOT NULL,
+				created_at DATETIME NOT NULL,
+				replaced_at DATETIME NOT NULL,
+				UNIQUE (job_id, version)
+			);
+		`, nil
+
+	case "025_add_schedule_to_jobs.sql":
+		return `
+			-- Crontab schedule a job runs on, from which its deadlines are
+			-- computed; empty for jobs with a plain threshold
+			ALTER TABLE jobs ADD COLUMN schedule TEXT NOT NULL DEFAULT '';
+		`, nil
+
+	case "026_add_run_id.sql":
+		return `
+			-- Client-chosen identifier grouping the start and the results of
+			-- one logical run, such as a failed attempt and its retry
+			ALTER TABLE job_results ADD COLUMN run_id TEXT;
+			ALTER TABLE job_starts ADD COLUMN run_id TEXT NOT NULL DEFAULT '';
+
+			CREATE INDEX idx_job_results_run_id ON job_results(job_name, host, run_id);
+		`, nil
+
+	case "027_add_shards.sql":
+		return `
+			-- Fan-out jobs take their status from the shards of their latest
+			-- run, the child tasks reporting under the job
+			ALTER TABLE jobs ADD COLUMN shard_policy TEXT NOT NULL DEFAULT '';
+			ALTER TABLE job_results ADD COLUMN shard INTEGER;
+			ALTER TABLE job_results ADD COLUMN shards INTEGER;
+		`, nil
+
+	case "028_create_job_overlaps.sql":
+		return `
+			-- Runs that started while a previous run of the job was still
+			-- open, and whether the open start is such a run
+			CREATE TABLE job_overlaps (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				job_name TEXT NOT NULL,
+				host TEXT NOT NULL,
+				run_id TEXT NOT NULL DEFAULT '',
+				started_at DATETIME NOT NULL,
+				previous_run_id TEXT NOT NULL DEFAULT '',
+				previous_started_at DATETIME NOT NULL
+			);
+			CREATE INDEX idx_job_overlaps_job ON job_overlaps(job_name, host);
+			ALTER TABLE job_starts ADD COLUMN overlapping BOOLEAN NOT NULL DEFAULT FALSE;
+		`, nil
+
+	case "029_create_notifier_channels.sql":
+		return `
+			-- Notification channels managed from the dashboard, whose
+			-- settings are the JSON of their configuration
+			CREATE TABLE notifier_channels (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE,
+				type TEXT NOT NULL,
+				settings TEXT NOT NULL,
+				updated_by TEXT NOT NULL DEFAULT '',
+				updated_at DATETIME NOT NULL
+			);
+		`, nil
+
+	case "030_add_min_interval_to_jobs.sql":
+		return `
+			-- Minimum seconds between the recorded results of a job,
+			-- overriding the server's rate limit; 0 follows it
+			ALTER TABLE jobs ADD COLUMN min_interval INTEGER NOT NULL DEFAULT 0;
+		`, nil
+
 	default:
 		return "", fmt.Errorf("unknown migration file: %s", filename)
 	}
@@ -268,6 +892,8 @@ func (d *Database) getMigrationSQL(filename string) (string, error) {
 // JobResultStore provides database operations for job results
 type JobResultStore struct {
 	db *sqlx.DB
+	storeContext
+	hook ChangeHook // Called after each recorded result, may be nil
 }
 
 // NewJobResultStore creates a new JobResultStore instance
@@ -275,8 +901,20 @@ func NewJobResultStore(db *sqlx.DB) *JobResultStore {
 	return &JobResultStore{db: db}
 }
 
-// CreateJobResult creates a new job result record
+// WithContext returns a copy of the store whose queries run with ctx
+func (s *JobResultStore) WithContext(ctx context.Context) *JobResultStore {
+	clone := *s
+	clone.ctx = ctx
+	return &clone
+}
+
+// CreateJobResult creates a new job result record. A result of a run with
+// earlier records is recorded with the duration of the whole run.
 func (s *JobResultStore) CreateJobResult(result *JobResult) error {
+	if err := s.applyRunDuration(result); err != nil {
+		return err
+	}
+
 	labelsJSON := "{}"
 	if result.Labels != nil {
 		if bytes, err := json.Marshal(result.Labels); err == nil {
@@ -285,14 +923,36 @@ func (s *JobResultStore) CreateJobResult(result *JobResult) error {
 	}
 
 	query := `
-		INSERT INTO job_results (job_name, host, status, labels, duration, output, timestamp)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO job_results (job_name, host, status, labels, duration, output, timestamp, ` + resultSourceColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ` + resultSourcePlaceholders + `)
 	`
 
-	_, err := s.db.Exec(query, result.JobName, result.Host, result.Status, labelsJSON, result.Duration, result.Output, result.Timestamp)
+	tx, err := s.db.BeginTxx(s.context(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	args := append([]interface{}{result.JobName, result.Host, result.Status, labelsJSON, result.Duration, result.Output, result.Timestamp}, sourceArgs(result)...)
+	id, err := insertID(s.context(), tx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to create job result: %w", err)
 	}
+	result.ID = int(id)
+	// A test result does not finish the job's running run
+	if !result.Test {
+		if err := s.finishStart(tx, result); err != nil {
+			return err
+		}
+	}
+
+	event, err := s.recordChange(tx, result)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit job result: %w", err)
+	}
 
 	logrus.WithFields(logrus.Fields{
 		"job_name": result.JobName,
@@ -301,20 +961,59 @@ func (s *JobResultStore) CreateJobResult(result *JobResult) error {
 		"duration": result.Duration,
 	}).Info("job result recorded")
 
+	s.notify(event)
+	return nil
+}
+
+// CreateJobResults records results in a single transaction, without logging
+// each one or emitting change events. It is meant for bulk imports such as
+// demo data.
+func (s *JobResultStore) CreateJobResults(results []*JobResult) error {
+	tx, err := s.db.BeginTxx(s.context(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO job_results (job_name, host, status, labels, duration, output, timestamp, ` + resultSourceColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ` + resultSourcePlaceholders + `)
+	`
+
+	for _, result := range results {
+		labelsJSON := "{}"
+		if result.Labels != nil {
+			if bytes, err := json.Marshal(result.Labels); err == nil {
+				labelsJSON = string(bytes)
+			}
+		}
+
+		args := append([]interface{}{result.JobName, result.Host, result.Status, labelsJSON, result.Duration, result.Output, result.Timestamp}, sourceArgs(result)...)
+		id, err := insertID(s.context(), tx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to create job result: %w", err)
+		}
+		result.ID = int(id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit job results: %w", err)
+	}
+
 	return nil
 }
 
 // GetJobResults retrieves job results with optional filtering
 func (s *JobResultStore) GetJobResults(jobName, host string, limit int) ([]*JobResult, error) {
 	query := `
-		SELECT job_name, host, status, labels, duration, output, timestamp
+		SELECT job_name, host, status, labels, duration, output, timestamp, ` + resultSourceColumns + `
 		FROM job_results
 		WHERE job_name = ? AND host = ?
 		ORDER BY timestamp DESC
 		LIMIT ?
 	`
 
-	rows, err := s.db.Queryx(query, jobName, host, limit)
+	rows, err := s.db.QueryxContext(s.context(), s.db.Rebind(query), jobName, host, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get job results: %w", err)
 	}
@@ -326,11 +1025,13 @@ func (s *JobResultStore) GetJobResults(jobName, host string, limit int) ([]*JobR
 		var labelsJSON string
 		var output sql.NullString
 		var duration sql.NullInt64
+		var source resultSource
 
-		err := rows.Scan(&result.JobName, &result.Host, &result.Status, &labelsJSON, &duration, &output, &result.Timestamp)
-		if err != nil {
+		dest := append([]interface{}{&result.JobName, &result.Host, &result.Status, &labelsJSON, &duration, &output, &result.Timestamp}, source.dest()...)
+		if err := rows.Scan(dest...); err != nil {
 			return nil, fmt.Errorf("failed to scan job result row: %w", err)
 		}
+		source.apply(result)
 
 		if duration.Valid {
 			result.Duration = int(duration.Int64)
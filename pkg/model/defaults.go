@@ -0,0 +1,72 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultThreshold returns the default failure threshold of a job with
+// labels, e.g. config.DefaultsConfig.FailureThreshold
+type DefaultThreshold func(labels map[string]string) int
+
+// ApplyDefaultThreshold makes a job without a failure threshold inherit the
+// default for its labels, and sets the threshold of an inheriting job to
+// that default. It reports whether the threshold changed.
+func (j *Job) ApplyDefaultThreshold(defaultFor DefaultThreshold) bool {
+	if j.AutomaticFailureThreshold == 0 {
+		j.ThresholdInherited = true
+	}
+	if !j.ThresholdInherited {
+		return false
+	}
+
+	threshold := defaultFor(j.Labels)
+	changed := threshold != j.AutomaticFailureThreshold
+	j.AutomaticFailureThreshold = threshold
+	return changed
+}
+
+// PropagateDefaultThresholds sets the failure threshold of the jobs that
+// inherit it to the current default for their labels, and returns the jobs
+// whose threshold changed
+func (s *JobStore) PropagateDefaultThresholds(defaultFor DefaultThreshold) ([]*Job, error) {
+	jobs, err := s.ListJobs(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTxx(s.context(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	updated := []*Job{}
+	var events []ChangeEvent
+	for _, job := range jobs {
+		if !job.ThresholdInherited || !job.ApplyDefaultThreshold(defaultFor) {
+			continue
+		}
+		job.UpdatedAt = now
+
+		query := `UPDATE jobs SET automatic_failure_threshold = ?, updated_at = ? WHERE id = ? AND threshold_inherited`
+		if _, err := tx.ExecContext(s.context(), tx.Rebind(query), job.AutomaticFailureThreshold, job.UpdatedAt, job.ID); err != nil {
+			return nil, fmt.Errorf("failed to update threshold of job %s@%s: %w", job.Name, job.Host, err)
+		}
+		event, err := s.recordChange(tx, EventJobUpdated, job)
+		if err != nil {
+			return nil, err
+		}
+		updated = append(updated, job)
+		events = append(events, event)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit threshold updates: %w", err)
+	}
+	for _, event := range events {
+		s.notify(event)
+	}
+	return updated, nil
+}
@@ -0,0 +1,173 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Database drivers, as set by database.driver. SQLite is
+// modernc.org/sqlite, a pure Go driver, so builds need no C toolchain and
+// cross-compile freely.
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// Drivers are the database drivers OpenDatabase accepts
+var Drivers = []string{DriverSQLite, DriverPostgres, DriverMySQL}
+
+// The stores write their queries with "?" placeholders and pass them
+// through the Rebind of their database or transaction, which rewrites them
+// into the "$1", "$2" and so on of PostgreSQL. sqlx picks the placeholders
+// from the driver name, "postgres" being lib/pq.
+
+// mysqlDSN sets the options of a MySQL data source name the stores rely
+// on: times are read as UTC time.Time values, and updates report the rows
+// they matched rather than those they changed
+func mysqlDSN(dsn string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("invalid MySQL data source name: %w", err)
+	}
+	cfg.ParseTime = true
+	cfg.Loc = time.UTC
+	cfg.ClientFoundRows = true
+	return cfg.FormatDSN(), nil
+}
+
+// dialectOf returns the driver, as set by database.driver, of a database
+// or transaction
+func dialectOf(db interface{ DriverName() string }) string {
+	switch db.DriverName() {
+	case DriverPostgres, DriverMySQL:
+		return db.DriverName()
+	default:
+		return DriverSQLite
+	}
+}
+
+// inserter runs the statements of insertID, on a database or in a
+// transaction
+type inserter interface {
+	DriverName() string
+	Rebind(query string) string
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// getter reads a row on a database or in a transaction
+type getter interface {
+	Rebind(query string) string
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+// rowQueryer reads a row to scan on a database or in a transaction
+type rowQueryer interface {
+	Rebind(query string) string
+	QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row
+}
+
+// insertID runs an INSERT into a table with an id column and returns the
+// id of the new row. lib/pq does not report the last insert id, so on
+// PostgreSQL the statement returns it instead.
+func insertID(ctx context.Context, db inserter, query string, args ...any) (int64, error) {
+	if dialectOf(db) == DriverPostgres {
+		var id int64
+		err := db.QueryRowContext(ctx, db.Rebind(strings.TrimSpace(query)+" RETURNING id"), args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := db.ExecContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// ignoringDuplicates rewrites an "INSERT INTO" statement to skip the rows
+// that would duplicate a unique key instead of failing
+func ignoringDuplicates(db interface{ DriverName() string }, query string) string {
+	switch dialectOf(db) {
+	case DriverPostgres:
+		return strings.TrimSpace(query) + " ON CONFLICT DO NOTHING"
+	case DriverMySQL:
+		return strings.Replace(query, "INSERT INTO", "INSERT IGNORE INTO", 1)
+	default:
+		return strings.Replace(query, "INSERT INTO", "INSERT OR IGNORE INTO", 1)
+	}
+}
+
+// forUpdate returns the clause of a SELECT in a transaction that locks the
+// rows it reads until the transaction ends. SQLite has none, as a
+// transaction writing to it locks the whole database.
+func forUpdate(db interface{ DriverName() string }) string {
+	if dialectOf(db) == DriverSQLite {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+// labelMatch returns the condition on jobs that their labels have a key,
+// the first argument, with a value, the second one
+func labelMatch(db interface{ DriverName() string }) string {
+	switch dialectOf(db) {
+	case DriverPostgres:
+		return "EXISTS (SELECT 1 FROM json_each_text(jobs.labels::json) AS je WHERE je.key = ? AND je.value = ?)"
+	case DriverMySQL:
+		return `JSON_UNQUOTE(JSON_EXTRACT(jobs.labels, CONCAT('$."', ?, '"'))) = ?`
+	default:
+		return "EXISTS (SELECT 1 FROM json_each(jobs.labels) WHERE json_each.key = ? AND json_each.value = ?)"
+	}
+}
+
+// labelPairs returns a table of the labels of jobs, to join with jobs, and
+// the expressions of its keys and values
+func labelPairs(db interface{ DriverName() string }) (table, key, value string) {
+	switch dialectOf(db) {
+	case DriverPostgres:
+		return "json_each_text(jobs.labels::json) AS je", "je.key", "je.value"
+	case DriverMySQL:
+		return `JSON_TABLE(JSON_KEYS(jobs.labels), '$[*]' COLUMNS (label_key VARCHAR(255) PATH '$')) AS je`,
+			"je.label_key", `JSON_UNQUOTE(JSON_EXTRACT(jobs.labels, CONCAT('$."', je.label_key, '"')))`
+	default:
+		return "json_each(jobs.labels) AS je", "je.key", "je.value"
+	}
+}
+
+// containsMatch returns the condition that a column contains the pattern
+// of the argument, ignoring case as SQLite's LIKE does
+func containsMatch(db interface{ DriverName() string }, column string) string {
+	switch dialectOf(db) {
+	case DriverPostgres:
+		return column + " ILIKE ?"
+	case DriverMySQL:
+		// Text columns compare as binary, as on SQLite
+		return "LOWER(" + column + ") LIKE LOWER(?)"
+	default:
+		return column + " LIKE ?"
+	}
+}
+
+// IsUniqueViolation reports whether err comes from a statement that would
+// have duplicated a unique key, such as creating a job with the name and
+// host of another
+func IsUniqueViolation(err error) bool {
+	var pgErr *pq.Error
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1062
+	}
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
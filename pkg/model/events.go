@@ -0,0 +1,117 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Change event types
+const (
+	EventJobCreated     = "job.created"
+	EventJobUpdated     = "job.updated"
+	EventJobDeleted     = "job.deleted"
+	EventResultRecorded = "result.recorded"
+	EventSLOBurning     = "slo.burning"
+	EventSLORecovered   = "slo.recovered"
+)
+
+// ChangeEvent describes a committed change to a job, a newly recorded job
+// result or a change in an SLO's burn state
+type ChangeEvent struct {
+	Type   string     `json:"type"`
+	Time   time.Time  `json:"time"`
+	Job    *Job       `json:"job,omitempty"`    // Deleted jobs only carry the fields used to delete them
+	Result *JobResult `json:"result,omitempty"` // Set for result.recorded
+	SLO    *SLOStatus `json:"slo,omitempty"`    // Set for slo.burning and slo.recovered
+}
+
+// ChangeHook is called after a change is committed. It runs on the
+// caller's goroutine and must not block.
+type ChangeHook func(event ChangeEvent)
+
+// ChainHooks returns a hook calling each non-nil hook in turn, or nil when
+// there is none
+func ChainHooks(hooks ...ChangeHook) ChangeHook {
+	var chained []ChangeHook
+	for _, hook := range hooks {
+		if hook != nil {
+			chained = append(chained, hook)
+		}
+	}
+
+	switch len(chained) {
+	case 0:
+		return nil
+	case 1:
+		return chained[0]
+	}
+	return func(event ChangeEvent) {
+		for _, hook := range chained {
+			hook(event)
+		}
+	}
+}
+
+// SetChangeHook registers a hook called after every job change
+func (s *JobStore) SetChangeHook(hook ChangeHook) {
+	s.hook = hook
+}
+
+// recordChange records a job change in the change feed within tx, the
+// transaction making it, and returns the event to notify once tx is
+// committed
+func (s *JobStore) recordChange(tx *sqlx.Tx, eventType string, job *Job) (ChangeEvent, error) {
+	event := ChangeEvent{Type: eventType, Time: time.Now().UTC(), Job: job}
+	return event, recordChange(s.context(), tx, event)
+}
+
+// notify passes a copy of a committed job change to the change hook, if
+// any
+func (s *JobStore) notify(event ChangeEvent) {
+	if s.hook == nil {
+		return
+	}
+
+	job := event.Job
+	copied := *job
+	copied.Labels = make(map[string]string, len(job.Labels))
+	for key, value := range job.Labels {
+		copied.Labels[key] = value
+	}
+
+	event.Job = &copied
+	s.hook(event)
+}
+
+// SetChangeHook registers a hook called after every recorded result
+func (s *JobResultStore) SetChangeHook(hook ChangeHook) {
+	s.hook = hook
+}
+
+// recordChange records a new result in the change feed within tx, the
+// transaction inserting it, and returns the event to notify once tx is
+// committed
+func (s *JobResultStore) recordChange(tx *sqlx.Tx, result *JobResult) (ChangeEvent, error) {
+	event := ChangeEvent{Type: EventResultRecorded, Time: time.Now().UTC(), Result: result}
+	return event, recordChange(s.context(), tx, event)
+}
+
+// notify passes a copy of a committed result to the change hook, if any
+func (s *JobResultStore) notify(event ChangeEvent) {
+	if s.hook == nil {
+		return
+	}
+
+	result := event.Result
+	copied := *result
+	if result.Labels != nil {
+		copied.Labels = make(map[string]string, len(result.Labels))
+		for key, value := range result.Labels {
+			copied.Labels[key] = value
+		}
+	}
+
+	event.Result = &copied
+	s.hook(event)
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// HostSummary aggregates the jobs of a host
+type HostSummary struct {
+	Host          string
+	Jobs          []*Job
+	LastContactAt time.Time // Latest report of any of the host's jobs
+	Results       int       // Results recorded in the summary window
+	Failures      int       // Failed results recorded in the summary window
+}
+
+// SummarizeHosts groups jobs by host, ordered by host, with the result
+// counts of their jobs
+func SummarizeHosts(jobs []*Job, counts map[JobRef]ResultCounts) []*HostSummary {
+	byHost := make(map[string]*HostSummary)
+	for _, job := range jobs {
+		summary, ok := byHost[job.Host]
+		if !ok {
+			summary = &HostSummary{Host: job.Host}
+			byHost[job.Host] = summary
+		}
+
+		summary.Jobs = append(summary.Jobs, job)
+		if job.LastReportedAt.After(summary.LastContactAt) {
+			summary.LastContactAt = job.LastReportedAt
+		}
+		count := counts[JobRef{Name: job.Name, Host: job.Host}]
+		summary.Results += count.Total
+		summary.Failures += count.Failed
+	}
+
+	summaries := make([]*HostSummary, 0, len(byHost))
+	for _, summary := range byHost {
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Host < summaries[j].Host })
+
+	return summaries
+}
+
+// ListHostResults returns the latest results of all jobs on a host, newest
+// first. Labels and output are left out.
+func (s *JobResultStore) ListHostResults(host string, limit int) ([]*JobResult, error) {
+	query := `
+		SELECT id, job_name, host, status, duration, timestamp
+		FROM job_results
+		WHERE host = ?
+		ORDER BY timestamp DESC, id DESC
+		LIMIT ?
+	`
+
+	rows, err := s.db.QueryContext(s.context(), s.db.Rebind(query), host, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list host results: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*JobResult{}
+	for rows.Next() {
+		result := &JobResult{}
+		var duration sql.NullInt64
+		if err := rows.Scan(&result.ID, &result.JobName, &result.Host, &result.Status, &duration, &result.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan job result row: %w", err)
+		}
+		if duration.Valid {
+			result.Duration = int(duration.Int64)
+		}
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
@@ -1,17 +1,115 @@
 package model
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
+	"github.com/jaepetto/cron-exporter/pkg/util"
 	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
 	_ "modernc.org/sqlite"
 )
 
+// MaxDescriptionLength is the longest description a job may carry
+const MaxDescriptionLength = 10000
+
+// Job types. The results of counter jobs report the items they processed.
+const (
+	JobTypeStandard = "standard"
+	JobTypeCounter  = "counter"
+)
+
+// CheckJobIdentity rejects job names and hosts with control characters.
+// Both end up in email headers, metric lines and logs, where a line break
+// would start a line of its own.
+func CheckJobIdentity(name, host string) error {
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return fmt.Errorf("job name cannot contain control characters")
+	}
+	if strings.IndexFunc(host, unicode.IsControl) >= 0 {
+		return fmt.Errorf("host cannot contain control characters")
+	}
+	return nil
+}
+
+// CheckJobType rejects unknown job types and negative throughput floors.
+// An empty type keeps a job's type, or makes a new job a standard one. The
+// floor only applies to counter jobs.
+func CheckJobType(jobType string, throughputFloor int64) error {
+	if jobType != "" && jobType != JobTypeStandard && jobType != JobTypeCounter {
+		return fmt.Errorf("type must be '%s' or '%s'", JobTypeStandard, JobTypeCounter)
+	}
+	if throughputFloor < 0 {
+		return fmt.Errorf("throughput_floor cannot be negative")
+	}
+	return nil
+}
+
+// CheckSchedule rejects schedules that are not crontab schedules. An empty
+// schedule leaves a job unscheduled.
+func CheckSchedule(schedule string) error {
+	if schedule == "" {
+		return nil
+	}
+	_, err := parseSchedule(schedule)
+	return err
+}
+
+// maxCachedSchedules bounds the parsed schedules kept by parseSchedule
+const maxCachedSchedules = 1024
+
+var (
+	schedulesMu sync.Mutex
+	schedules   = map[string]*util.Schedule{}
+)
+
+// parseSchedule parses a crontab schedule, once per expression: the
+// deadline of every scheduled job is computed on each scrape, and jobs
+// mostly share a few schedules
+func parseSchedule(expr string) (*util.Schedule, error) {
+	schedulesMu.Lock()
+	defer schedulesMu.Unlock()
+	if schedule, ok := schedules[expr]; ok {
+		return schedule, nil
+	}
+
+	schedule, err := util.ParseSchedule(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedules) >= maxCachedSchedules {
+		// Schedules of jobs since changed or deleted
+		clear(schedules)
+	}
+	schedules[expr] = schedule
+	return schedule, nil
+}
+
+// CheckMinInterval rejects negative minimum intervals between results. 0
+// leaves the job to the server's rate limit.
+func CheckMinInterval(interval int) error {
+	if interval < 0 {
+		return fmt.Errorf("min_interval cannot be negative")
+	}
+	return nil
+}
+
+// CheckShardPolicy rejects unknown shard policies. An empty policy keeps a
+// job's policy, or makes a new job a job without shards.
+func CheckShardPolicy(policy string) error {
+	if policy != "" && policy != ShardPolicyAll && policy != ShardPolicyAny {
+		return fmt.Errorf("shard_policy must be '%s' or '%s'", ShardPolicyAll, ShardPolicyAny)
+	}
+	return nil
+}
+
 // Job represents a cron job definition with its configuration and status
 type Job struct {
 	ID                        int               `json:"id" db:"id"` // Auto-incrementing primary key
@@ -19,22 +117,77 @@ type Job struct {
 	Host                      string            `json:"host" db:"host"`
 	ApiKey                    string            `json:"api_key,omitempty" db:"api_key"`                               // Per-job API key for authentication
 	AutomaticFailureThreshold int               `json:"automatic_failure_threshold" db:"automatic_failure_threshold"` // Seconds since last result
+	ThresholdInherited        bool              `json:"threshold_inherited" db:"threshold_inherited"`                 // Threshold follows the configured defaults
 	Labels                    map[string]string `json:"labels" db:"labels"`                                           // Arbitrary user labels
+	AllowedCIDRs              []string          `json:"allowed_cidrs,omitempty" db:"allowed_cidrs"`                   // Source ranges results are accepted from; empty allows any
 	Status                    string            `json:"status" db:"status"`                                           // "active", "maintenance", "paused"
 	LastReportedAt            time.Time         `json:"last_reported_at" db:"last_reported_at"`                       // For auto-failure logic
+	SnoozedUntil              *time.Time        `json:"snoozed_until,omitempty" db:"snoozed_until"`                   // Failures are not reported before this time
+	Description               string            `json:"description,omitempty" db:"description"`                       // Markdown notes: what the job does, how to rerun it
+	Type                      string            `json:"type" db:"type"`                                               // "standard" or "counter"
+	ThroughputFloor           int64             `json:"throughput_floor,omitempty" db:"throughput_floor"`             // Fewest items a successful run of a counter job may process
+	Schedule                  string            `json:"schedule,omitempty" db:"schedule"`                             // Crontab schedule the job runs on, in UTC; empty for unscheduled jobs
+	ShardPolicy               string            `json:"shard_policy,omitempty" db:"shard_policy"`                     // How the shards of a fan-out run make its status: "all" or "any"; empty for jobs without shards
+	MinInterval               int               `json:"min_interval,omitempty" db:"min_interval"`                     // Seconds between the recorded results of the job; 0 for the server's rate_limit.min_interval
 	CreatedAt                 time.Time         `json:"created_at" db:"created_at"`
 	UpdatedAt                 time.Time         `json:"updated_at" db:"updated_at"`
+
+	Stats      *JobStats   `json:"stats,omitempty" db:"-"`       // Computed columns, set by job listings
+	LastResult *LastResult `json:"last_result,omitempty" db:"-"` // Latest non-test result, set by job searches
+}
+
+// LastResult is the latest result a job reported
+type LastResult struct {
+	Status     string    `json:"status"`
+	Duration   int       `json:"duration,omitempty"` // Execution duration in seconds
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// Owner returns the "owner" label of the job, else its "team" label
+func (j *Job) Owner() string {
+	for _, key := range []string{"owner", "team"} {
+		if owner := j.Labels[key]; owner != "" {
+			return owner
+		}
+	}
+	return ""
 }
 
 // JobResult represents a job execution result submission
 type JobResult struct {
-	JobName   string            `json:"job_name"`
-	Host      string            `json:"host"`
-	Status    string            `json:"status"` // "success", "failure"
-	Labels    map[string]string `json:"labels,omitempty"`
-	Duration  int               `json:"duration,omitempty"` // Execution duration in seconds
-	Output    string            `json:"output,omitempty"`   // Optional execution output
-	Timestamp time.Time         `json:"timestamp"`
+	ID             int               `json:"id,omitempty"`
+	JobName        string            `json:"job_name"`
+	Host           string            `json:"host"`
+	Status         string            `json:"status"` // "success", "failure"
+	Labels         map[string]string `json:"labels,omitempty"`
+	Duration       int               `json:"duration,omitempty"`        // Execution duration in seconds
+	Output         string            `json:"output,omitempty"`          // Optional execution output
+	ExitCode       *int              `json:"exit_code,omitempty"`       // Optional exit code of the job's command
+	ItemsProcessed *int64            `json:"items_processed,omitempty"` // Items the run processed, required for counter jobs
+	Timestamp      time.Time         `json:"timestamp"`
+	Nonce          string            `json:"nonce,omitempty"`  // Single-use value for replay protection, not stored
+	RunID          string            `json:"run_id,omitempty"` // Groups the start and results of one logical run, e.g. a failure and its retry
+	Shard          *int              `json:"shard,omitempty"`  // Index of the child task of a fan-out run, from 0
+	Shards         int               `json:"shards,omitempty"` // Child tasks the fan-out run spawned, when known
+
+	// Where the result came from, set by the server to debug clock-skewed
+	// or misconfigured agents
+	SourceIP      string     `json:"source_ip,omitempty"`      // Address the result was submitted from
+	UserAgent     string     `json:"user_agent,omitempty"`     // User-Agent of the submission
+	ClientVersion string     `json:"client_version,omitempty"` // Version of the submitting client, e.g. cronmetrics wrap
+	ReceivedAt    *time.Time `json:"received_at,omitempty"`    // Server clock; Timestamp is the client's
+	ClockSkew     *float64   `json:"clock_skew,omitempty"`     // Seconds the client's timestamp was ahead of ReceivedAt
+	ClockSkewed   bool       `json:"clock_skewed,omitempty"`   // The skew exceeded the configured threshold
+	Source        string     `json:"source,omitempty"`         // How the result was submitted, e.g. "ping"; empty for the JSON API
+
+	// Set by the server when a status rule of the job overrode the status
+	// the result was reported with
+	ReportedStatus string `json:"reported_status,omitempty"`
+	StatusRuleID   int    `json:"status_rule_id,omitempty"`
+
+	Test bool `json:"test,omitempty"` // Synthetic result of a test submission, set by the server
+
+	Annotations []*ResultAnnotation `json:"annotations,omitempty"` // Operator comments, set by history views
 }
 
 // JobSearchCriteria represents advanced search and filtering options for jobs
@@ -43,9 +196,10 @@ type JobSearchCriteria struct {
 	Query string `json:"query,omitempty"` // Search across name, host, and labels
 
 	// Specific field filters
-	Name   string `json:"name,omitempty"`   // Filter by job name (partial match)
-	Host   string `json:"host,omitempty"`   // Filter by host (partial match)
-	Status string `json:"status,omitempty"` // Filter by job status (exact match)
+	Name     string   `json:"name,omitempty"`     // Filter by job name (partial match)
+	Host     string   `json:"host,omitempty"`     // Filter by host (partial match)
+	Status   string   `json:"status,omitempty"`   // Filter by job status (exact match)
+	Statuses []string `json:"statuses,omitempty"` // Filter by any of several statuses (exact match)
 
 	// Label filters
 	Labels map[string]string `json:"labels,omitempty"` // Filter by labels (exact match)
@@ -54,11 +208,55 @@ type JobSearchCriteria struct {
 	LastReportedBefore *time.Time `json:"last_reported_before,omitempty"` // Jobs reported before this time
 	LastReportedAfter  *time.Time `json:"last_reported_after,omitempty"`  // Jobs reported after this time
 
+	// Relative time filters, measured back from when the search runs
+	LastReportedWithin time.Duration `json:"last_reported_within,omitempty"` // Jobs reported within this long
+	NotReportedFor     time.Duration `json:"not_reported_for,omitempty"`     // Stale jobs, not reported for at least this long
+
+	// Sorting
+	SortBy  string `json:"sort_by,omitempty"`  // Sort field, see JobSortFields (default: id)
+	SortDir string `json:"sort_dir,omitempty"` // "asc" (default) or "desc"
+
 	// Pagination
 	Page     int `json:"page,omitempty"`      // Page number (1-based)
 	PageSize int `json:"page_size,omitempty"` // Number of items per page
 }
 
+// JobSortFields lists the fields SearchJobs can sort by
+var JobSortFields = []string{"name", "host", "status", "last_reported_at", "created_at"}
+
+// IsValidJobSortField reports whether field is an accepted sort field
+func IsValidJobSortField(field string) bool {
+	for _, sortField := range JobSortFields {
+		if field == sortField {
+			return true
+		}
+	}
+	return false
+}
+
+// orderClause returns the ORDER BY clause for the criteria. The sort column
+// is checked against JobSortFields since it is interpolated into the query;
+// id is always appended so pagination is stable across equal values.
+func (c *JobSearchCriteria) orderClause() (string, error) {
+	direction := "ASC"
+	switch strings.ToLower(c.SortDir) {
+	case "", "asc":
+	case "desc":
+		direction = "DESC"
+	default:
+		return "", fmt.Errorf("invalid sort direction: %s", c.SortDir)
+	}
+
+	if c.SortBy == "" {
+		return "ORDER BY id " + direction, nil
+	}
+	if !IsValidJobSortField(c.SortBy) {
+		return "", fmt.Errorf("invalid sort field: %s", c.SortBy)
+	}
+
+	return fmt.Sprintf("ORDER BY %s %s, id %s", c.SortBy, direction, direction), nil
+}
+
 // JobSearchResult represents paginated search results
 type JobSearchResult struct {
 	Jobs        []*Job `json:"jobs"`
@@ -74,6 +272,8 @@ type JobSearchResult struct {
 // JobStore provides database operations for jobs
 type JobStore struct {
 	db *sqlx.DB
+	storeContext
+	hook ChangeHook // Called after each committed change, may be nil
 }
 
 // NewJobStore creates a new JobStore instance
@@ -81,55 +281,99 @@ func NewJobStore(db *sqlx.DB) *JobStore {
 	return &JobStore{db: db}
 }
 
+// WithContext returns a copy of the store whose queries run with ctx
+func (s *JobStore) WithContext(ctx context.Context) *JobStore {
+	clone := *s
+	clone.ctx = ctx
+	return &clone
+}
+
+// DB returns the underlying database connection so related stores can share it
+func (s *JobStore) DB() *sqlx.DB {
+	return s.db
+}
+
 // CreateJob creates a new job in the database
 func (s *JobStore) CreateJob(job *Job) error {
+	tx, err := s.db.BeginTxx(s.context(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.insertJob(tx, job); err != nil {
+		return err
+	}
+	event, err := s.recordChange(tx, EventJobCreated, job)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"job_name": job.Name,
+		"host":     job.Host,
+		"status":   job.Status,
+	}).Info("job created successfully")
+
+	s.notify(event)
+	return nil
+}
+
+// insertJob inserts job on db, a database or a transaction, and sets its ID
+func (s *JobStore) insertJob(db inserter, job *Job) error {
 	labelsJSON, err := json.Marshal(job.Labels)
 	if err != nil {
 		return fmt.Errorf("failed to marshal labels: %w", err)
 	}
 
+	allowedCIDRsJSON, err := json.Marshal(job.AllowedCIDRs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal allowed CIDRs: %w", err)
+	}
+
+	if job.Type == "" {
+		job.Type = JobTypeStandard
+	}
+
 	now := time.Now().UTC()
 	job.CreatedAt = now
 	job.UpdatedAt = now
 
 	query := `
-	       INSERT INTO jobs (name, host, api_key, automatic_failure_threshold, labels, status, last_reported_at, created_at, updated_at)
-	       VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	       INSERT INTO jobs (name, host, api_key, automatic_failure_threshold, labels, allowed_cidrs, status, last_reported_at, description, threshold_inherited, type, throughput_floor, schedule, shard_policy, min_interval, created_at, updated_at)
+	       VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
        `
 
-	result, err := s.db.Exec(query, job.Name, job.Host, job.ApiKey, job.AutomaticFailureThreshold, string(labelsJSON), job.Status, job.LastReportedAt, job.CreatedAt, job.UpdatedAt)
+	id, err := insertID(s.context(), db, query, job.Name, job.Host, job.ApiKey, job.AutomaticFailureThreshold, string(labelsJSON), string(allowedCIDRsJSON), job.Status, job.LastReportedAt, job.Description, job.ThresholdInherited, job.Type, job.ThroughputFloor, job.Schedule, job.ShardPolicy, job.MinInterval, job.CreatedAt, job.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create job: %w", err)
 	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("failed to get job ID: %w", err)
-	}
 	job.ID = int(id)
-
-	logrus.WithFields(logrus.Fields{
-		"job_name": job.Name,
-		"host":     job.Host,
-		"status":   job.Status,
-	}).Info("job created successfully")
-
 	return nil
 }
 
 // GetJobByID retrieves a job by its ID
 func (s *JobStore) GetJobByID(id int) (*Job, error) {
+	return s.getJobByID(s.db, id)
+}
+
+// getJobByID retrieves a job by its ID on db, a database or a transaction
+func (s *JobStore) getJobByID(db rowQueryer, id int) (*Job, error) {
 	query := `
-	       SELECT id, name, host, api_key, automatic_failure_threshold, labels, status, last_reported_at, created_at, updated_at
+	       SELECT id, name, host, api_key, automatic_failure_threshold, labels, allowed_cidrs, status, last_reported_at, snoozed_until, description, threshold_inherited, type, throughput_floor, schedule, shard_policy, min_interval, created_at, updated_at
 	       FROM jobs
 	       WHERE id = ?
        `
 
 	job := &Job{}
-	var labelsJSON string
+	var labelsJSON, allowedCIDRsJSON string
 	var apiKeyNull sql.NullString
+	var snoozedUntil sql.NullTime
 
-	err := s.db.QueryRowx(query, id).Scan(&job.ID, &job.Name, &job.Host, &apiKeyNull, &job.AutomaticFailureThreshold, &labelsJSON, &job.Status, &job.LastReportedAt, &job.CreatedAt, &job.UpdatedAt)
+	err := db.QueryRowxContext(s.context(), db.Rebind(query), id).Scan(&job.ID, &job.Name, &job.Host, &apiKeyNull, &job.AutomaticFailureThreshold, &labelsJSON, &allowedCIDRsJSON, &job.Status, &job.LastReportedAt, &snoozedUntil, &job.Description, &job.ThresholdInherited, &job.Type, &job.ThroughputFloor, &job.Schedule, &job.ShardPolicy, &job.MinInterval, &job.CreatedAt, &job.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("job not found with ID: %d", id)
@@ -140,27 +384,35 @@ func (s *JobStore) GetJobByID(id int) (*Job, error) {
 	if apiKeyNull.Valid {
 		job.ApiKey = apiKeyNull.String
 	}
+	if snoozedUntil.Valid {
+		job.SnoozedUntil = &snoozedUntil.Time
+	}
 
 	if err := json.Unmarshal([]byte(labelsJSON), &job.Labels); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal labels: %w", err)
 	}
 
+	if err := json.Unmarshal([]byte(allowedCIDRsJSON), &job.AllowedCIDRs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal allowed CIDRs: %w", err)
+	}
+
 	return job, nil
 }
 
 // GetJob retrieves a job by name and host (kept for backward compatibility)
 func (s *JobStore) GetJob(name, host string) (*Job, error) {
 	query := `
-	       SELECT id, name, host, api_key, automatic_failure_threshold, labels, status, last_reported_at, created_at, updated_at
+	       SELECT id, name, host, api_key, automatic_failure_threshold, labels, allowed_cidrs, status, last_reported_at, snoozed_until, description, threshold_inherited, type, throughput_floor, schedule, shard_policy, min_interval, created_at, updated_at
 	       FROM jobs
 	       WHERE name = ? AND host = ?
        `
 
 	job := &Job{}
-	var labelsJSON string
+	var labelsJSON, allowedCIDRsJSON string
 	var apiKeyNull sql.NullString
+	var snoozedUntil sql.NullTime
 
-	err := s.db.QueryRowx(query, name, host).Scan(&job.ID, &job.Name, &job.Host, &apiKeyNull, &job.AutomaticFailureThreshold, &labelsJSON, &job.Status, &job.LastReportedAt, &job.CreatedAt, &job.UpdatedAt)
+	err := s.db.QueryRowxContext(s.context(), s.db.Rebind(query), name, host).Scan(&job.ID, &job.Name, &job.Host, &apiKeyNull, &job.AutomaticFailureThreshold, &labelsJSON, &allowedCIDRsJSON, &job.Status, &job.LastReportedAt, &snoozedUntil, &job.Description, &job.ThresholdInherited, &job.Type, &job.ThroughputFloor, &job.Schedule, &job.ShardPolicy, &job.MinInterval, &job.CreatedAt, &job.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("job not found: %s@%s", name, host)
@@ -171,23 +423,30 @@ func (s *JobStore) GetJob(name, host string) (*Job, error) {
 	if apiKeyNull.Valid {
 		job.ApiKey = apiKeyNull.String
 	}
+	if snoozedUntil.Valid {
+		job.SnoozedUntil = &snoozedUntil.Time
+	}
 
 	if err := json.Unmarshal([]byte(labelsJSON), &job.Labels); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal labels: %w", err)
 	}
 
+	if err := json.Unmarshal([]byte(allowedCIDRsJSON), &job.AllowedCIDRs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal allowed CIDRs: %w", err)
+	}
+
 	return job, nil
 }
 
 // ListJobs retrieves all jobs with optional label filtering
 func (s *JobStore) ListJobs(labelFilters map[string]string) ([]*Job, error) {
 	query := `
-	       SELECT id, name, host, api_key, automatic_failure_threshold, labels, status, last_reported_at, created_at, updated_at
+	       SELECT id, name, host, api_key, automatic_failure_threshold, labels, allowed_cidrs, status, last_reported_at, snoozed_until, description, threshold_inherited, type, throughput_floor, schedule, shard_policy, min_interval, created_at, updated_at
 	       FROM jobs
 	       ORDER BY id
        `
 
-	rows, err := s.db.Queryx(query)
+	rows, err := s.db.QueryxContext(s.context(), query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list jobs: %w", err)
 	}
@@ -196,10 +455,11 @@ func (s *JobStore) ListJobs(labelFilters map[string]string) ([]*Job, error) {
 	var jobs []*Job
 	for rows.Next() {
 		job := &Job{}
-		var labelsJSON string
+		var labelsJSON, allowedCIDRsJSON string
 		var apiKeyNull sql.NullString
+		var snoozedUntil sql.NullTime
 
-		err := rows.Scan(&job.ID, &job.Name, &job.Host, &apiKeyNull, &job.AutomaticFailureThreshold, &labelsJSON, &job.Status, &job.LastReportedAt, &job.CreatedAt, &job.UpdatedAt)
+		err := rows.Scan(&job.ID, &job.Name, &job.Host, &apiKeyNull, &job.AutomaticFailureThreshold, &labelsJSON, &allowedCIDRsJSON, &job.Status, &job.LastReportedAt, &snoozedUntil, &job.Description, &job.ThresholdInherited, &job.Type, &job.ThroughputFloor, &job.Schedule, &job.ShardPolicy, &job.MinInterval, &job.CreatedAt, &job.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan job row: %w", err)
 		}
@@ -207,11 +467,18 @@ func (s *JobStore) ListJobs(labelFilters map[string]string) ([]*Job, error) {
 		if apiKeyNull.Valid {
 			job.ApiKey = apiKeyNull.String
 		}
+		if snoozedUntil.Valid {
+			job.SnoozedUntil = &snoozedUntil.Time
+		}
 
 		if err := json.Unmarshal([]byte(labelsJSON), &job.Labels); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal labels: %w", err)
 		}
 
+		if err := json.Unmarshal([]byte(allowedCIDRsJSON), &job.AllowedCIDRs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal allowed CIDRs: %w", err)
+		}
+
 		// Apply label filters if provided
 		if len(labelFilters) > 0 {
 			match := true
@@ -250,30 +517,35 @@ func (s *JobStore) SearchJobs(criteria *JobSearchCriteria) (*JobSearchResult, er
 		criteria.PageSize = 25 // Default page size
 	}
 
+	orderClause, err := criteria.orderClause()
+	if err != nil {
+		return nil, err
+	}
+
 	// Build the WHERE clause dynamically
 	var whereConditions []string
 	var args []interface{}
 	argIndex := 0
 
-	// Handle text query search across name, host, and labels
+	// Handle text query search across name, host, labels and description
 	if criteria.Query != "" {
-		// Search in name, host, and labels JSON
+		// Search in name, host, labels JSON and description
 		whereConditions = append(whereConditions,
-			"(name LIKE ? OR host LIKE ? OR labels LIKE ?)")
+			"("+containsMatch(s.db, "name")+" OR "+containsMatch(s.db, "host")+" OR "+containsMatch(s.db, "labels")+" OR "+containsMatch(s.db, "description")+")")
 		searchTerm := "%" + criteria.Query + "%"
-		args = append(args, searchTerm, searchTerm, searchTerm)
-		argIndex += 3
+		args = append(args, searchTerm, searchTerm, searchTerm, searchTerm)
+		argIndex += 4
 	}
 
 	// Handle specific field filters
 	if criteria.Name != "" {
-		whereConditions = append(whereConditions, "name LIKE ?")
+		whereConditions = append(whereConditions, containsMatch(s.db, "name"))
 		args = append(args, "%"+criteria.Name+"%")
 		argIndex++
 	}
 
 	if criteria.Host != "" {
-		whereConditions = append(whereConditions, "host LIKE ?")
+		whereConditions = append(whereConditions, containsMatch(s.db, "host"))
 		args = append(args, "%"+criteria.Host+"%")
 		argIndex++
 	}
@@ -284,6 +556,27 @@ func (s *JobStore) SearchJobs(criteria *JobSearchCriteria) (*JobSearchResult, er
 		argIndex++
 	}
 
+	if len(criteria.Statuses) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(criteria.Statuses)), ",")
+		whereConditions = append(whereConditions, "status IN ("+placeholders+")")
+		for _, status := range criteria.Statuses {
+			args = append(args, status)
+		}
+		argIndex += len(criteria.Statuses)
+	}
+
+	// Handle label filters (exact match on every key)
+	labelKeys := make([]string, 0, len(criteria.Labels))
+	for key := range criteria.Labels {
+		labelKeys = append(labelKeys, key)
+	}
+	sort.Strings(labelKeys)
+	for _, key := range labelKeys {
+		whereConditions = append(whereConditions, labelMatch(s.db))
+		args = append(args, key, criteria.Labels[key])
+		argIndex += 2
+	}
+
 	// Handle time-based filters
 	if criteria.LastReportedBefore != nil {
 		whereConditions = append(whereConditions, "last_reported_at < ?")
@@ -297,6 +590,19 @@ func (s *JobStore) SearchJobs(criteria *JobSearchCriteria) (*JobSearchResult, er
 		argIndex++
 	}
 
+	now := time.Now().UTC()
+	if criteria.LastReportedWithin > 0 {
+		whereConditions = append(whereConditions, "last_reported_at > ?")
+		args = append(args, now.Add(-criteria.LastReportedWithin))
+		argIndex++
+	}
+
+	if criteria.NotReportedFor > 0 {
+		whereConditions = append(whereConditions, "last_reported_at <= ?")
+		args = append(args, now.Add(-criteria.NotReportedFor))
+		argIndex++
+	}
+
 	// Build the complete WHERE clause
 	whereClause := ""
 	if len(whereConditions) > 0 {
@@ -307,7 +613,7 @@ func (s *JobStore) SearchJobs(criteria *JobSearchCriteria) (*JobSearchResult, er
 	countQuery := "SELECT COUNT(*) FROM jobs " + whereClause
 
 	var totalCount int
-	err := s.db.Get(&totalCount, countQuery, args...)
+	err = s.db.GetContext(s.context(), &totalCount, s.db.Rebind(countQuery), args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count jobs: %w", err)
 	}
@@ -317,12 +623,13 @@ func (s *JobStore) SearchJobs(criteria *JobSearchCriteria) (*JobSearchResult, er
 	offset := (criteria.Page - 1) * criteria.PageSize
 
 	// Build the main query with pagination
-	query := "SELECT id, name, host, api_key, automatic_failure_threshold, labels, status, last_reported_at, created_at, updated_at FROM jobs " + whereClause + " ORDER BY id LIMIT ? OFFSET ?"
+	query := `SELECT id, name, host, api_key, automatic_failure_threshold, labels, allowed_cidrs, status, last_reported_at, snoozed_until, description, threshold_inherited, type, throughput_floor, schedule, shard_policy, min_interval, created_at, updated_at
+		FROM jobs ` + whereClause + " " + orderClause + " LIMIT ? OFFSET ?"
 
 	// Add pagination parameters
 	paginationArgs := append(args, criteria.PageSize, offset)
 
-	rows, err := s.db.Queryx(query, paginationArgs...)
+	rows, err := s.db.QueryxContext(s.context(), s.db.Rebind(query), paginationArgs...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search jobs: %w", err)
 	}
@@ -331,10 +638,11 @@ func (s *JobStore) SearchJobs(criteria *JobSearchCriteria) (*JobSearchResult, er
 	var jobs []*Job
 	for rows.Next() {
 		job := &Job{}
-		var labelsJSON string
+		var labelsJSON, allowedCIDRsJSON string
 		var apiKeyNull sql.NullString
+		var snoozedUntil sql.NullTime
 
-		err := rows.Scan(&job.ID, &job.Name, &job.Host, &apiKeyNull, &job.AutomaticFailureThreshold, &labelsJSON, &job.Status, &job.LastReportedAt, &job.CreatedAt, &job.UpdatedAt)
+		err := rows.Scan(&job.ID, &job.Name, &job.Host, &apiKeyNull, &job.AutomaticFailureThreshold, &labelsJSON, &allowedCIDRsJSON, &job.Status, &job.LastReportedAt, &snoozedUntil, &job.Description, &job.ThresholdInherited, &job.Type, &job.ThroughputFloor, &job.Schedule, &job.ShardPolicy, &job.MinInterval, &job.CreatedAt, &job.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan job row: %w", err)
 		}
@@ -342,23 +650,16 @@ func (s *JobStore) SearchJobs(criteria *JobSearchCriteria) (*JobSearchResult, er
 		if apiKeyNull.Valid {
 			job.ApiKey = apiKeyNull.String
 		}
+		if snoozedUntil.Valid {
+			job.SnoozedUntil = &snoozedUntil.Time
+		}
 
 		if err := json.Unmarshal([]byte(labelsJSON), &job.Labels); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal labels: %w", err)
 		}
 
-		// Apply label filters if provided (post-query filtering for complex JSON matching)
-		if len(criteria.Labels) > 0 {
-			match := true
-			for key, value := range criteria.Labels {
-				if job.Labels[key] != value {
-					match = false
-					break
-				}
-			}
-			if !match {
-				continue
-			}
+		if err := json.Unmarshal([]byte(allowedCIDRsJSON), &job.AllowedCIDRs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal allowed CIDRs: %w", err)
 		}
 
 		jobs = append(jobs, job)
@@ -368,6 +669,10 @@ func (s *JobStore) SearchJobs(criteria *JobSearchCriteria) (*JobSearchResult, er
 		return nil, fmt.Errorf("error iterating job rows: %w", err)
 	}
 
+	if err := s.attachLastResults(jobs); err != nil {
+		return nil, err
+	}
+
 	// Build the result
 	result := &JobSearchResult{
 		Jobs:        jobs,
@@ -383,33 +688,68 @@ func (s *JobStore) SearchJobs(criteria *JobSearchCriteria) (*JobSearchResult, er
 	return result, nil
 }
 
-// UpdateJobByID updates an existing job by ID
-func (s *JobStore) UpdateJobByID(job *Job) error {
-	labelsJSON, err := json.Marshal(job.Labels)
-	if err != nil {
-		return fmt.Errorf("failed to marshal labels: %w", err)
+// attachLastResults sets the latest non-test result of each job. Only the
+// given jobs' results are read, with one indexed lookup per job.
+func (s *JobStore) attachLastResults(jobs []*Job) error {
+	if len(jobs) == 0 {
+		return nil
 	}
 
-	job.UpdatedAt = time.Now().UTC()
-
+	ids := make([]interface{}, 0, len(jobs))
+	byID := make(map[int]*Job, len(jobs))
+	for _, job := range jobs {
+		ids = append(ids, job.ID)
+		byID[job.ID] = job
+	}
 	query := `
-	       UPDATE jobs
-	       SET name = ?, host = ?, api_key = ?, automatic_failure_threshold = ?, labels = ?, status = ?, last_reported_at = ?, updated_at = ?
-	       WHERE id = ?
-       `
+		SELECT jobs.id, last_result.status, last_result.duration, last_result.timestamp
+		FROM jobs
+		JOIN job_results last_result ON last_result.id = (
+			SELECT id FROM job_results
+			WHERE job_name = jobs.name AND host = jobs.host AND NOT test
+			ORDER BY timestamp DESC, id DESC
+			LIMIT 1
+		)
+		WHERE jobs.id IN (` + strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",") + `)
+	`
+
+	rows, err := s.db.QueryContext(s.context(), s.db.Rebind(query), ids...)
+	if err != nil {
+		return fmt.Errorf("failed to get last results: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var last LastResult
+		var duration sql.NullInt64
+		if err := rows.Scan(&id, &last.Status, &duration, &last.FinishedAt); err != nil {
+			return fmt.Errorf("failed to scan last result: %w", err)
+		}
+		last.Duration = int(duration.Int64)
+		byID[id].LastResult = &last
+	}
+	return rows.Err()
+}
 
-	result, err := s.db.Exec(query, job.Name, job.Host, job.ApiKey, job.AutomaticFailureThreshold, string(labelsJSON), job.Status, job.LastReportedAt, job.UpdatedAt, job.ID)
+// UpdateJobByID updates an existing job by ID
+func (s *JobStore) UpdateJobByID(job *Job) error {
+	tx, err := s.db.BeginTxx(s.context(), nil)
 	if err != nil {
-		return fmt.Errorf("failed to update job: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	if err := updateJobInTx(tx, job); err != nil {
+		return err
+	}
+	event, err := s.recordChange(tx, EventJobUpdated, job)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("job not found with ID: %d", job.ID)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
 	}
 
 	logrus.WithFields(logrus.Fields{
@@ -419,6 +759,56 @@ func (s *JobStore) UpdateJobByID(job *Job) error {
 		"status":   job.Status,
 	}).Info("job updated successfully")
 
+	s.notify(event)
+	return nil
+}
+
+// updateJobInTx updates job by ID in tx, keeping its previous version
+func updateJobInTx(tx *sqlx.Tx, job *Job) error {
+	labelsJSON, err := json.Marshal(job.Labels)
+	if err != nil {
+		return fmt.Errorf("failed to marshal labels: %w", err)
+	}
+
+	allowedCIDRsJSON, err := json.Marshal(job.AllowedCIDRs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal allowed CIDRs: %w", err)
+	}
+
+	job.UpdatedAt = time.Now().UTC()
+
+	previous, err := recordJobVersion(tx, job)
+	if err != nil {
+		return err
+	}
+
+	// Results reference their job by name and host: a renamed job takes its
+	// results along, with the foreign key checked once both are updated.
+	// Other databases cascade the rename to the results instead.
+	renamed := previous.Name != job.Name || previous.Host != job.Host
+	if renamed && dialectOf(tx) == DriverSQLite {
+		if _, err := tx.Exec(`PRAGMA defer_foreign_keys = ON`); err != nil {
+			return fmt.Errorf("failed to defer foreign keys: %w", err)
+		}
+	}
+
+	query := `
+	       UPDATE jobs
+	       SET name = ?, host = ?, api_key = ?, automatic_failure_threshold = ?, labels = ?, allowed_cidrs = ?, status = ?, last_reported_at = ?, description = ?, threshold_inherited = ?, type = ?, throughput_floor = ?, schedule = ?, shard_policy = ?, min_interval = ?, updated_at = ?
+	       WHERE id = ?
+       `
+
+	if _, err := tx.Exec(tx.Rebind(query), job.Name, job.Host, job.ApiKey, job.AutomaticFailureThreshold, string(labelsJSON), string(allowedCIDRsJSON), job.Status, job.LastReportedAt, job.Description, job.ThresholdInherited, job.Type, job.ThroughputFloor, job.Schedule, job.ShardPolicy, job.MinInterval, job.UpdatedAt, job.ID); err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+
+	if renamed {
+		if _, err := tx.Exec(tx.Rebind(`UPDATE job_results SET job_name = ?, host = ? WHERE job_name = ? AND host = ?`),
+			job.Name, job.Host, previous.Name, previous.Host); err != nil {
+			return fmt.Errorf("failed to move job results: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -429,26 +819,45 @@ func (s *JobStore) UpdateJob(job *Job) error {
 		return fmt.Errorf("failed to marshal labels: %w", err)
 	}
 
+	allowedCIDRsJSON, err := json.Marshal(job.AllowedCIDRs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal allowed CIDRs: %w", err)
+	}
+
 	job.UpdatedAt = time.Now().UTC()
 
+	tx, err := s.db.BeginTxx(s.context(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.Get(&job.ID, tx.Rebind(`SELECT id FROM jobs WHERE name = ? AND host = ?`), job.Name, job.Host); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("job not found: %s@%s", job.Name, job.Host)
+		}
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+	if _, err := recordJobVersion(tx, job); err != nil {
+		return err
+	}
+
 	query := `
 	       UPDATE jobs
-	       SET api_key = ?, automatic_failure_threshold = ?, labels = ?, status = ?, last_reported_at = ?, updated_at = ?
-	       WHERE name = ? AND host = ?
+	       SET api_key = ?, automatic_failure_threshold = ?, labels = ?, allowed_cidrs = ?, status = ?, last_reported_at = ?, description = ?, threshold_inherited = ?, type = ?, throughput_floor = ?, schedule = ?, shard_policy = ?, min_interval = ?, updated_at = ?
+	       WHERE id = ?
        `
 
-	result, err := s.db.Exec(query, job.ApiKey, job.AutomaticFailureThreshold, string(labelsJSON), job.Status, job.LastReportedAt, job.UpdatedAt, job.Name, job.Host)
-	if err != nil {
+	if _, err := tx.Exec(tx.Rebind(query), job.ApiKey, job.AutomaticFailureThreshold, string(labelsJSON), string(allowedCIDRsJSON), job.Status, job.LastReportedAt, job.Description, job.ThresholdInherited, job.Type, job.ThroughputFloor, job.Schedule, job.ShardPolicy, job.MinInterval, job.UpdatedAt, job.ID); err != nil {
 		return fmt.Errorf("failed to update job: %w", err)
 	}
-
-	rowsAffected, err := result.RowsAffected()
+	event, err := s.recordChange(tx, EventJobUpdated, job)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("job not found: %s@%s", job.Name, job.Host)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
 	}
 
 	logrus.WithFields(logrus.Fields{
@@ -457,6 +866,7 @@ func (s *JobStore) UpdateJob(job *Job) error {
 		"status":   job.Status,
 	}).Info("job updated successfully")
 
+	s.notify(event)
 	return nil
 }
 
@@ -464,7 +874,13 @@ func (s *JobStore) UpdateJob(job *Job) error {
 func (s *JobStore) DeleteJobByID(id int) error {
 	query := `DELETE FROM jobs WHERE id = ?`
 
-	result, err := s.db.Exec(query, id)
+	tx, err := s.db.BeginTxx(s.context(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(s.context(), tx.Rebind(query), id)
 	if err != nil {
 		return fmt.Errorf("failed to delete job: %w", err)
 	}
@@ -478,10 +894,87 @@ func (s *JobStore) DeleteJobByID(id int) error {
 		return fmt.Errorf("job not found with ID: %d", id)
 	}
 
+	event, err := s.recordChange(tx, EventJobDeleted, &Job{ID: id})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete job: %w", err)
+	}
+
 	logrus.WithFields(logrus.Fields{
 		"job_id": id,
 	}).Info("job deleted successfully")
 
+	s.notify(event)
+	return nil
+}
+
+// JobChanges are the jobs a bulk change of the inventory creates, updates
+// and deletes
+type JobChanges struct {
+	Create []*Job
+	Update []*Job
+	Delete []*Job
+}
+
+// ApplyJobChanges creates, updates and deletes the jobs of changes in one
+// transaction, so either all of them are applied or none is
+func (s *JobStore) ApplyJobChanges(changes *JobChanges) error {
+	tx, err := s.db.BeginTxx(s.context(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var events []ChangeEvent
+	record := func(eventType string, job *Job) error {
+		event, err := s.recordChange(tx, eventType, job)
+		if err != nil {
+			return fmt.Errorf("%s@%s: %w", job.Name, job.Host, err)
+		}
+		events = append(events, event)
+		return nil
+	}
+
+	for _, job := range changes.Create {
+		if err := s.insertJob(tx, job); err != nil {
+			return fmt.Errorf("%s@%s: %w", job.Name, job.Host, err)
+		}
+		if err := record(EventJobCreated, job); err != nil {
+			return err
+		}
+	}
+	for _, job := range changes.Update {
+		if err := updateJobInTx(tx, job); err != nil {
+			return fmt.Errorf("%s@%s: %w", job.Name, job.Host, err)
+		}
+		if err := record(EventJobUpdated, job); err != nil {
+			return err
+		}
+	}
+	for _, job := range changes.Delete {
+		if _, err := tx.ExecContext(s.context(), tx.Rebind(`DELETE FROM jobs WHERE id = ?`), job.ID); err != nil {
+			return fmt.Errorf("%s@%s: failed to delete job: %w", job.Name, job.Host, err)
+		}
+		if err := record(EventJobDeleted, job); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit job changes: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"created": len(changes.Create),
+		"updated": len(changes.Update),
+		"deleted": len(changes.Delete),
+	}).Info("job changes applied")
+
+	for _, event := range events {
+		s.notify(event)
+	}
 	return nil
 }
 
@@ -489,7 +982,13 @@ func (s *JobStore) DeleteJobByID(id int) error {
 func (s *JobStore) DeleteJob(name, host string) error {
 	query := `DELETE FROM jobs WHERE name = ? AND host = ?`
 
-	result, err := s.db.Exec(query, name, host)
+	tx, err := s.db.BeginTxx(s.context(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(s.context(), tx.Rebind(query), name, host)
 	if err != nil {
 		return fmt.Errorf("failed to delete job: %w", err)
 	}
@@ -503,14 +1002,129 @@ func (s *JobStore) DeleteJob(name, host string) error {
 		return fmt.Errorf("job not found: %s@%s", name, host)
 	}
 
+	event, err := s.recordChange(tx, EventJobDeleted, &Job{Name: name, Host: host})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete job: %w", err)
+	}
+
 	logrus.WithFields(logrus.Fields{
 		"job_name": name,
 		"host":     host,
 	}).Info("job deleted successfully")
 
+	s.notify(event)
 	return nil
 }
 
+// SnoozeJobByID suppresses the failures of a job until the given time, or
+// ends its snooze when until is nil
+func (s *JobStore) SnoozeJobByID(id int, until *time.Time) (*Job, error) {
+	query := `
+	       UPDATE jobs
+	       SET snoozed_until = ?, updated_at = ?
+	       WHERE id = ?
+       `
+
+	var snoozedUntil sql.NullTime
+	if until != nil {
+		snoozedUntil = sql.NullTime{Time: until.UTC(), Valid: true}
+	}
+
+	tx, err := s.db.BeginTxx(s.context(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(s.context(), tx.Rebind(query), snoozedUntil, time.Now().UTC(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snooze job: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("job not found with ID: %d", id)
+	}
+
+	job, err := s.getJobByID(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.recordChange(tx, EventJobUpdated, job)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to snooze job: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"job_id":        job.ID,
+		"job_name":      job.Name,
+		"host":          job.Host,
+		"snoozed_until": job.SnoozedUntil,
+	}).Info("job snooze updated")
+
+	s.notify(event)
+	return job, nil
+}
+
+// IsSnoozed reports whether the job's failures are suppressed at now
+func (j *Job) IsSnoozed(now time.Time) bool {
+	return j.SnoozedUntil != nil && now.Before(*j.SnoozedUntil)
+}
+
+// Deadline returns when the job misses its deadline unless it reports
+// again. An unscheduled job has its threshold from its last report; a
+// scheduled job has it from the first run its schedule expected after its
+// last report, so weekly or monthly jobs need no threshold of a week or a
+// month.
+func (j *Job) Deadline() time.Time {
+	threshold := time.Duration(j.AutomaticFailureThreshold) * time.Second
+	if j.Schedule != "" {
+		if schedule, err := parseSchedule(j.Schedule); err == nil {
+			if next := schedule.Next(j.LastReportedAt); !next.IsZero() {
+				return next.Add(threshold)
+			}
+		}
+	}
+	return j.LastReportedAt.Add(threshold)
+}
+
+// ScheduleInterval returns the time between the next two runs the job's
+// schedule expects after now, or 0 for an unscheduled job. Schedules such
+// as "0 3 * * 1-5" have intervals varying from run to run.
+func (j *Job) ScheduleInterval(now time.Time) time.Duration {
+	if j.Schedule == "" {
+		return 0
+	}
+	schedule, err := parseSchedule(j.Schedule)
+	if err != nil {
+		return 0
+	}
+	next := schedule.Next(now)
+	if next.IsZero() {
+		return 0
+	}
+	after := schedule.Next(next)
+	if after.IsZero() {
+		return 0
+	}
+	return after.Sub(next)
+}
+
+// MissedDeadline reports whether the job is past its deadline at now
+func (j *Job) MissedDeadline(now time.Time) bool {
+	return now.After(j.Deadline())
+}
+
 // UpdateJobLastReported updates the last_reported_at timestamp for a job
 func (s *JobStore) UpdateJobLastReported(name, host string, timestamp time.Time) error {
 	query := `
@@ -520,7 +1134,7 @@ func (s *JobStore) UpdateJobLastReported(name, host string, timestamp time.Time)
        `
 
 	now := time.Now().UTC()
-	result, err := s.db.Exec(query, timestamp, now, name, host)
+	result, err := s.db.ExecContext(s.context(), s.db.Rebind(query), timestamp, now, name, host)
 	if err != nil {
 		return fmt.Errorf("failed to update job last reported: %w", err)
 	}
@@ -544,16 +1158,17 @@ func (s *JobStore) GetJobByApiKey(apiKey string) (*Job, error) {
 	}
 
 	query := `
-	       SELECT id, name, host, api_key, automatic_failure_threshold, labels, status, last_reported_at, created_at, updated_at
+	       SELECT id, name, host, api_key, automatic_failure_threshold, labels, allowed_cidrs, status, last_reported_at, snoozed_until, description, threshold_inherited, type, throughput_floor, schedule, shard_policy, min_interval, created_at, updated_at
 	       FROM jobs
 	       WHERE api_key = ?
        `
 
 	job := &Job{}
-	var labelsJSON string
+	var labelsJSON, allowedCIDRsJSON string
 	var apiKeyNull sql.NullString
+	var snoozedUntil sql.NullTime
 
-	err := s.db.QueryRowx(query, apiKey).Scan(&job.ID, &job.Name, &job.Host, &apiKeyNull, &job.AutomaticFailureThreshold, &labelsJSON, &job.Status, &job.LastReportedAt, &job.CreatedAt, &job.UpdatedAt)
+	err := s.db.QueryRowxContext(s.context(), s.db.Rebind(query), apiKey).Scan(&job.ID, &job.Name, &job.Host, &apiKeyNull, &job.AutomaticFailureThreshold, &labelsJSON, &allowedCIDRsJSON, &job.Status, &job.LastReportedAt, &snoozedUntil, &job.Description, &job.ThresholdInherited, &job.Type, &job.ThroughputFloor, &job.Schedule, &job.ShardPolicy, &job.MinInterval, &job.CreatedAt, &job.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("job not found for API key")
@@ -564,10 +1179,17 @@ func (s *JobStore) GetJobByApiKey(apiKey string) (*Job, error) {
 	if apiKeyNull.Valid {
 		job.ApiKey = apiKeyNull.String
 	}
+	if snoozedUntil.Valid {
+		job.SnoozedUntil = &snoozedUntil.Time
+	}
 
 	if err := json.Unmarshal([]byte(labelsJSON), &job.Labels); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal labels: %w", err)
 	}
 
+	if err := json.Unmarshal([]byte(allowedCIDRsJSON), &job.AllowedCIDRs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal allowed CIDRs: %w", err)
+	}
+
 	return job, nil
 }
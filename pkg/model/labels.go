@@ -0,0 +1,121 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+)
+
+// LabelValueUsage reports how many jobs carry a given label value
+type LabelValueUsage struct {
+	Value string `json:"value" db:"value"`
+	Count int    `json:"count" db:"count"`
+}
+
+// LabelUsage reports the distinct values and usage of a label key
+type LabelUsage struct {
+	Key        string            `json:"key"`
+	JobCount   int               `json:"job_count"`
+	ValueCount int               `json:"value_count"`
+	Values     []LabelValueUsage `json:"values"`
+}
+
+// HostUsage reports how many jobs run on a host
+type HostUsage struct {
+	Host     string `json:"host" db:"host"`
+	JobCount int    `json:"job_count" db:"job_count"`
+}
+
+// ListHosts returns every distinct host with its number of jobs, ordered by host
+func (s *JobStore) ListHosts() ([]*HostUsage, error) {
+	query := `
+		SELECT host, COUNT(*) AS job_count
+		FROM jobs
+		GROUP BY host
+		ORDER BY host
+	`
+
+	hosts := []*HostUsage{}
+	if err := s.db.SelectContext(s.context(), &hosts, query); err != nil {
+		return nil, fmt.Errorf("failed to list hosts: %w", err)
+	}
+
+	return hosts, nil
+}
+
+// ListLabelUsage returns every label key with its distinct values and the
+// number of jobs using each, ordered by key and value
+func (s *JobStore) ListLabelUsage() ([]*LabelUsage, error) {
+	pairs, key, value := labelPairs(s.db)
+	query := `
+		SELECT ` + key + ` AS label_key, ` + value + ` AS value, COUNT(*) AS count
+		FROM jobs, ` + pairs + `
+		GROUP BY ` + key + `, ` + value + `
+		ORDER BY ` + key + `, ` + value
+
+	rows := []struct {
+		Key   string `db:"label_key"`
+		Value string `db:"value"`
+		Count int    `db:"count"`
+	}{}
+	if err := s.db.SelectContext(s.context(), &rows, query); err != nil {
+		return nil, fmt.Errorf("failed to list label usage: %w", err)
+	}
+
+	usage := []*LabelUsage{}
+	for _, row := range rows {
+		if len(usage) == 0 || usage[len(usage)-1].Key != row.Key {
+			usage = append(usage, &LabelUsage{Key: row.Key, Values: []LabelValueUsage{}})
+		}
+		current := usage[len(usage)-1]
+		current.Values = append(current.Values, LabelValueUsage{Value: row.Value, Count: row.Count})
+		current.JobCount += row.Count
+		current.ValueCount++
+	}
+
+	return usage, nil
+}
+
+// GetLabelValues returns the distinct values of a label key with their usage counts
+func (s *JobStore) GetLabelValues(key string) (map[string]int, error) {
+	pairs, labelKey, value := labelPairs(s.db)
+	query := `
+		SELECT ` + value + ` AS value, COUNT(*) AS count
+		FROM jobs, ` + pairs + `
+		WHERE ` + labelKey + ` = ?
+		GROUP BY ` + value
+
+	rows := []LabelValueUsage{}
+	if err := s.db.SelectContext(s.context(), &rows, s.db.Rebind(query), key); err != nil {
+		return nil, fmt.Errorf("failed to get label values: %w", err)
+	}
+
+	values := make(map[string]int, len(rows))
+	for _, row := range rows {
+		values[row.Value] = row.Count
+	}
+
+	return values, nil
+}
+
+// CheckLabelCardinality returns the label keys for which labels introduces a
+// value not yet in use while the key already has limit or more distinct
+// values. A limit of zero or less disables the check.
+func (s *JobStore) CheckLabelCardinality(labels map[string]string, limit int) ([]string, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	var exceeded []string
+	for key, value := range labels {
+		values, err := s.GetLabelValues(key)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := values[value]; !exists && len(values) >= limit {
+			exceeded = append(exceeded, key)
+		}
+	}
+
+	sort.Strings(exceeded)
+	return exceeded, nil
+}
@@ -0,0 +1,257 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrMaintenanceWindowNotFound is returned for unknown maintenance windows
+var ErrMaintenanceWindowNotFound = errors.New("maintenance window not found")
+
+// MaintenanceWindow is planned downtime of the jobs matching a label
+// selector. Starting it puts the matching active jobs in maintenance;
+// ending it, at Until or on request, makes those of them still in
+// maintenance active again. Jobs already in maintenance or paused are left
+// as they are.
+type MaintenanceWindow struct {
+	ID        int               `json:"id"`
+	Selector  map[string]string `json:"selector"`
+	Until     *time.Time        `json:"until,omitempty"` // Nil lasts until ended
+	CreatedAt time.Time         `json:"created_at"`
+	EndedAt   *time.Time        `json:"ended_at,omitempty"`
+	Jobs      []*Job            `json:"jobs"` // Jobs the window put in maintenance
+}
+
+// maintenanceWindowRow is a row of maintenance_windows
+type maintenanceWindowRow struct {
+	ID        int          `db:"id"`
+	Selector  string       `db:"selector"`
+	Until     sql.NullTime `db:"until"`
+	CreatedAt time.Time    `db:"created_at"`
+	EndedAt   sql.NullTime `db:"ended_at"`
+}
+
+// StartMaintenance puts the active jobs matching every label of selector in
+// maintenance until the given time, or until the window is ended when until
+// is nil
+func (s *JobStore) StartMaintenance(selector map[string]string, until *time.Time) (*MaintenanceWindow, error) {
+	selectorJSON, err := json.Marshal(selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal selector: %w", err)
+	}
+
+	jobs, err := s.ListJobs(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	window := &MaintenanceWindow{Selector: selector, CreatedAt: now, Jobs: []*Job{}}
+	var untilValue sql.NullTime
+	if until != nil {
+		utc := until.UTC()
+		window.Until = &utc
+		untilValue = sql.NullTime{Time: utc, Valid: true}
+	}
+
+	tx, err := s.db.BeginTxx(s.context(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := insertID(s.context(), tx, `INSERT INTO maintenance_windows (selector, until, created_at) VALUES (?, ?, ?)`,
+		string(selectorJSON), untilValue, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create maintenance window: %w", err)
+	}
+	window.ID = int(id)
+
+	var events []ChangeEvent
+	for _, job := range jobs {
+		// Only jobs still active when the update runs are taken over
+		result, err := tx.ExecContext(s.context(), tx.Rebind(`UPDATE jobs SET status = 'maintenance', updated_at = ? WHERE id = ? AND status = 'active'`), now, job.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to put job %s@%s in maintenance: %w", job.Name, job.Host, err)
+		}
+		if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(s.context(), tx.Rebind(`INSERT INTO maintenance_window_jobs (window_id, job_id) VALUES (?, ?)`), window.ID, job.ID); err != nil {
+			return nil, fmt.Errorf("failed to record job %s@%s in maintenance window: %w", job.Name, job.Host, err)
+		}
+		job.Status = "maintenance"
+		job.UpdatedAt = now
+		event, err := s.recordChange(tx, EventJobUpdated, job)
+		if err != nil {
+			return nil, err
+		}
+		window.Jobs = append(window.Jobs, job)
+		events = append(events, event)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit maintenance window: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"window_id": window.ID,
+		"selector":  selector,
+		"until":     window.Until,
+		"jobs":      len(window.Jobs),
+	}).Info("maintenance window started")
+
+	for _, event := range events {
+		s.notify(event)
+	}
+	return window, nil
+}
+
+// EndMaintenance ends a maintenance window, making the jobs it put in
+// maintenance that are still in maintenance active again. The window is
+// returned with those jobs; ending an ended window changes nothing.
+func (s *JobStore) EndMaintenance(id int) (*MaintenanceWindow, error) {
+	window, err := s.GetMaintenanceWindow(id)
+	if err != nil {
+		return nil, err
+	}
+	if window.EndedAt != nil {
+		window.Jobs = []*Job{}
+		return window, nil
+	}
+
+	now := time.Now().UTC()
+	tx, err := s.db.BeginTxx(s.context(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	restored := []*Job{}
+	var events []ChangeEvent
+	for _, job := range window.Jobs {
+		// Jobs someone changed since the window started keep their status
+		result, err := tx.ExecContext(s.context(), tx.Rebind(`UPDATE jobs SET status = 'active', updated_at = ? WHERE id = ? AND status = 'maintenance'`), now, job.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to end maintenance of job %s@%s: %w", job.Name, job.Host, err)
+		}
+		if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+			continue
+		}
+		job.Status = "active"
+		job.UpdatedAt = now
+		event, err := s.recordChange(tx, EventJobUpdated, job)
+		if err != nil {
+			return nil, err
+		}
+		restored = append(restored, job)
+		events = append(events, event)
+	}
+
+	if _, err := tx.ExecContext(s.context(), tx.Rebind(`UPDATE maintenance_windows SET ended_at = ? WHERE id = ?`), now, id); err != nil {
+		return nil, fmt.Errorf("failed to end maintenance window: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit maintenance window end: %w", err)
+	}
+	window.EndedAt = &now
+	window.Jobs = restored
+
+	logrus.WithFields(logrus.Fields{
+		"window_id": window.ID,
+		"selector":  window.Selector,
+		"jobs":      len(restored),
+	}).Info("maintenance window ended")
+
+	for _, event := range events {
+		s.notify(event)
+	}
+	return window, nil
+}
+
+// EndExpiredMaintenance ends the maintenance windows whose end time is at
+// or before now, and returns them
+func (s *JobStore) EndExpiredMaintenance(now time.Time) ([]*MaintenanceWindow, error) {
+	var ids []int
+	query := `SELECT id FROM maintenance_windows WHERE ended_at IS NULL AND until IS NOT NULL AND until <= ? ORDER BY id`
+	if err := s.db.SelectContext(s.context(), &ids, s.db.Rebind(query), now.UTC()); err != nil {
+		return nil, fmt.Errorf("failed to list expired maintenance windows: %w", err)
+	}
+
+	ended := []*MaintenanceWindow{}
+	for _, id := range ids {
+		window, err := s.EndMaintenance(id)
+		if err != nil {
+			return ended, err
+		}
+		ended = append(ended, window)
+	}
+	return ended, nil
+}
+
+// GetMaintenanceWindow returns a maintenance window with the jobs it put in
+// maintenance
+func (s *JobStore) GetMaintenanceWindow(id int) (*MaintenanceWindow, error) {
+	var row maintenanceWindowRow
+	query := `SELECT id, selector, until, created_at, ended_at FROM maintenance_windows WHERE id = ?`
+	if err := s.db.GetContext(s.context(), &row, s.db.Rebind(query), id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMaintenanceWindowNotFound
+		}
+		return nil, fmt.Errorf("failed to get maintenance window: %w", err)
+	}
+	return s.maintenanceWindow(&row)
+}
+
+// ListMaintenanceWindows returns the maintenance windows that have not
+// ended, oldest first
+func (s *JobStore) ListMaintenanceWindows() ([]*MaintenanceWindow, error) {
+	var rows []*maintenanceWindowRow
+	query := `SELECT id, selector, until, created_at, ended_at FROM maintenance_windows WHERE ended_at IS NULL ORDER BY id`
+	if err := s.db.SelectContext(s.context(), &rows, query); err != nil {
+		return nil, fmt.Errorf("failed to list maintenance windows: %w", err)
+	}
+
+	windows := make([]*MaintenanceWindow, 0, len(rows))
+	for _, row := range rows {
+		window, err := s.maintenanceWindow(row)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+// maintenanceWindow converts a row to a window with the jobs it put in
+// maintenance, leaving out deleted jobs
+func (s *JobStore) maintenanceWindow(row *maintenanceWindowRow) (*MaintenanceWindow, error) {
+	window := &MaintenanceWindow{ID: row.ID, CreatedAt: row.CreatedAt, Jobs: []*Job{}}
+	if err := json.Unmarshal([]byte(row.Selector), &window.Selector); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal selector: %w", err)
+	}
+	if row.Until.Valid {
+		window.Until = &row.Until.Time
+	}
+	if row.EndedAt.Valid {
+		window.EndedAt = &row.EndedAt.Time
+	}
+
+	var jobIDs []int
+	if err := s.db.SelectContext(s.context(), &jobIDs, s.db.Rebind(`SELECT job_id FROM maintenance_window_jobs WHERE window_id = ? ORDER BY job_id`), row.ID); err != nil {
+		return nil, fmt.Errorf("failed to list maintenance window jobs: %w", err)
+	}
+	for _, jobID := range jobIDs {
+		job, err := s.GetJobByID(jobID)
+		if err != nil {
+			return nil, err
+		}
+		window.Jobs = append(window.Jobs, job)
+	}
+	return window, nil
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NonceStore remembers the nonces of accepted job results, to refuse
+// replayed submissions
+type NonceStore struct {
+	db *sqlx.DB
+	storeContext
+}
+
+// NewNonceStore creates a new NonceStore instance
+func NewNonceStore(db *sqlx.DB) *NonceStore {
+	return &NonceStore{db: db}
+}
+
+// WithContext returns a copy of the store whose queries run with ctx
+func (s *NonceStore) WithContext(ctx context.Context) *NonceStore {
+	clone := *s
+	clone.ctx = ctx
+	return &clone
+}
+
+// UseNonce records nonce for a job until expiresAt. It returns false when
+// the nonce was already used for the job. Expired nonces are pruned.
+func (s *NonceStore) UseNonce(jobName, host, nonce string, expiresAt time.Time) (bool, error) {
+	now := time.Now().Unix()
+	if _, err := s.db.ExecContext(s.context(), s.db.Rebind(`DELETE FROM result_nonces WHERE expires_at < ?`), now); err != nil {
+		return false, fmt.Errorf("failed to prune nonces: %w", err)
+	}
+
+	query := ignoringDuplicates(s.db, `
+		INSERT INTO result_nonces (job_name, host, nonce, expires_at)
+		VALUES (?, ?, ?, ?)
+	`)
+
+	result, err := s.db.ExecContext(s.context(), s.db.Rebind(query), jobName, host, nonce, expiresAt.Unix())
+	if err != nil {
+		return false, fmt.Errorf("failed to record nonce: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// ReleaseNonce forgets a nonce, so a submission that failed after its nonce
+// was recorded can be retried
+func (s *NonceStore) ReleaseNonce(jobName, host, nonce string) error {
+	query := `DELETE FROM result_nonces WHERE job_name = ? AND host = ? AND nonce = ?`
+	if _, err := s.db.ExecContext(s.context(), s.db.Rebind(query), jobName, host, nonce); err != nil {
+		return fmt.Errorf("failed to release nonce: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Statuses of a notification delivery
+const (
+	DeliveryDelivered = "delivered" // The channel accepted the notification
+	DeliveryFailed    = "failed"    // Sending failed; Error says why
+)
+
+// maxDeliveryErrorLength caps the error kept for a failed delivery
+const maxDeliveryErrorLength = 1000
+
+// ErrDeliveryNotFound is returned for an unknown notification delivery
+var ErrDeliveryNotFound = errors.New("notification delivery not found")
+
+// NotificationDelivery records one attempt to send a notification about a
+// job, e.g. an issue opened in a team's tracker. The notification itself is
+// kept so it can be delivered again.
+type NotificationDelivery struct {
+	ID           int       `json:"id" db:"id"`
+	JobName      string    `json:"job_name" db:"job_name"`
+	Host         string    `json:"host" db:"host"`
+	Channel      string    `json:"channel" db:"channel"` // e.g. "jira" or "github"
+	Team         string    `json:"team" db:"team"`       // Team whose channel was used
+	Target       string    `json:"target" db:"target"`   // e.g. the Jira project or GitHub repository
+	Status       string    `json:"status" db:"status"`
+	LatencyMs    int64     `json:"latency_ms" db:"latency_ms"`
+	Error        string    `json:"error,omitempty" db:"error"`
+	Reference    string    `json:"reference,omitempty" db:"reference"` // e.g. the created ticket's ID
+	URL          string    `json:"url,omitempty" db:"url"`             // Link to what was created
+	Subject      string    `json:"subject" db:"subject"`
+	Body         string    `json:"body" db:"body"`
+	RedeliveryOf *int      `json:"redelivery_of,omitempty" db:"redelivery_of"` // The delivery this one retried
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// NotificationPage is one page of deliveries from keyset pagination
+type NotificationPage struct {
+	Deliveries []*NotificationDelivery `json:"deliveries"`
+	NextCursor string                  `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// DeliveryFilter selects deliveries; empty fields match all
+type DeliveryFilter struct {
+	JobName string
+	Host    string
+	Status  string
+}
+
+// DeliveryCount sums the deliveries of a channel with a status
+type DeliveryCount struct {
+	Channel   string `db:"channel"`
+	Status    string `db:"status"`
+	Count     int64  `db:"count"`
+	LatencyMs int64  `db:"latency_ms"`
+}
+
+// NotificationStore provides database operations for notification
+// deliveries
+type NotificationStore struct {
+	db *sqlx.DB
+	storeContext
+}
+
+// NewNotificationStore creates a new NotificationStore instance
+func NewNotificationStore(db *sqlx.DB) *NotificationStore {
+	return &NotificationStore{db: db}
+}
+
+// WithContext returns a copy of the store whose queries run with ctx
+func (s *NotificationStore) WithContext(ctx context.Context) *NotificationStore {
+	clone := *s
+	clone.ctx = ctx
+	return &clone
+}
+
+// RecordDelivery stores a delivery attempt
+func (s *NotificationStore) RecordDelivery(delivery *NotificationDelivery) error {
+	if delivery.CreatedAt.IsZero() {
+		delivery.CreatedAt = time.Now().UTC()
+	}
+	if len(delivery.Error) > maxDeliveryErrorLength {
+		cut := maxDeliveryErrorLength
+		for cut > 0 && !utf8.RuneStart(delivery.Error[cut]) {
+			cut--
+		}
+		delivery.Error = delivery.Error[:cut]
+	}
+
+	query := `
+		INSERT INTO notification_deliveries (job_name, host, channel, team, target, status, latency_ms, error,
+			reference, url, subject, body, redelivery_of, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	id, err := insertID(s.context(), s.db, query, delivery.JobName, delivery.Host, delivery.Channel, delivery.Team,
+		delivery.Target, delivery.Status, delivery.LatencyMs, delivery.Error, delivery.Reference, delivery.URL,
+		delivery.Subject, delivery.Body, delivery.RedeliveryOf, delivery.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record notification delivery: %w", err)
+	}
+	delivery.ID = int(id)
+
+	return nil
+}
+
+// GetDelivery returns a delivery by ID
+func (s *NotificationStore) GetDelivery(id int) (*NotificationDelivery, error) {
+	delivery := &NotificationDelivery{}
+	if err := s.db.GetContext(s.context(), delivery, s.db.Rebind(`SELECT * FROM notification_deliveries WHERE id = ?`), id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %d", ErrDeliveryNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get notification delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// ListDeliveriesPage returns up to limit deliveries matching filter, most
+// recent first, starting after cursor
+func (s *NotificationStore) ListDeliveriesPage(filter DeliveryFilter, cursor string, limit int) (*NotificationPage, error) {
+	beforeID, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT * FROM notification_deliveries
+		WHERE (? = 0 OR id < ?)
+			AND (? = '' OR job_name = ?)
+			AND (? = '' OR host = ?)
+			AND (? = '' OR status = ?)
+		ORDER BY id DESC
+		LIMIT ?
+	`
+
+	// Fetch one extra row to know whether another page follows
+	page := &NotificationPage{Deliveries: []*NotificationDelivery{}}
+	err = s.db.SelectContext(s.context(), &page.Deliveries, s.db.Rebind(query), beforeID, beforeID,
+		filter.JobName, filter.JobName, filter.Host, filter.Host, filter.Status, filter.Status, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification deliveries: %w", err)
+	}
+
+	if len(page.Deliveries) > limit {
+		page.Deliveries = page.Deliveries[:limit]
+		page.NextCursor = encodeCursor(page.Deliveries[limit-1].ID)
+	}
+
+	return page, nil
+}
+
+// CountDeliveries returns the number and total latency of the deliveries
+// by channel and status
+func (s *NotificationStore) CountDeliveries() ([]*DeliveryCount, error) {
+	query := `
+		SELECT channel, status, COUNT(*) AS count, COALESCE(SUM(latency_ms), 0) AS latency_ms
+		FROM notification_deliveries
+		GROUP BY channel, status
+		ORDER BY channel, status
+	`
+
+	counts := []*DeliveryCount{}
+	if err := s.db.SelectContext(s.context(), &counts, query); err != nil {
+		return nil, fmt.Errorf("failed to count notification deliveries: %w", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,233 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// JobPage is one page of jobs from keyset pagination
+type JobPage struct {
+	Jobs       []*Job `json:"jobs"`
+	NextCursor string `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// JobResultPage is one page of job results from keyset pagination
+type JobResultPage struct {
+	Results    []*JobResult `json:"results"`
+	NextCursor string       `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// encodeCursor returns the opaque cursor for the row with the given ID
+func encodeCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("id:" + strconv.Itoa(id)))
+}
+
+// decodeCursor returns the row ID a cursor points after. An empty cursor
+// starts from the beginning and decodes to 0.
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(string(raw), "id:"))
+	if err != nil || !strings.HasPrefix(string(raw), "id:") || id <= 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return id, nil
+}
+
+// ListJobsPage returns up to limit jobs matching labelFilters, ordered by ID,
+// starting after cursor. Unlike offset pagination the cost of a page does not
+// grow with its position.
+func (s *JobStore) ListJobsPage(labelFilters map[string]string, cursor string, limit int) (*JobPage, error) {
+	afterID, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := []string{"id > ?"}
+	args := []interface{}{afterID}
+
+	keys := make([]string, 0, len(labelFilters))
+	for key := range labelFilters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		conditions = append(conditions, labelMatch(s.db))
+		args = append(args, key, labelFilters[key])
+	}
+
+	// Fetch one extra row to know whether another page follows
+	query := "SELECT id, name, host, api_key, automatic_failure_threshold, labels, allowed_cidrs, status, last_reported_at, snoozed_until, description, threshold_inherited, type, throughput_floor, schedule, shard_policy, min_interval, created_at, updated_at FROM jobs WHERE " +
+		strings.Join(conditions, " AND ") + " ORDER BY id LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := s.db.QueryxContext(s.context(), s.db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	page := &JobPage{Jobs: []*Job{}}
+	for rows.Next() {
+		job := &Job{}
+		var labelsJSON, allowedCIDRsJSON string
+		var apiKeyNull sql.NullString
+		var snoozedUntil sql.NullTime
+
+		err := rows.Scan(&job.ID, &job.Name, &job.Host, &apiKeyNull, &job.AutomaticFailureThreshold, &labelsJSON, &allowedCIDRsJSON, &job.Status, &job.LastReportedAt, &snoozedUntil, &job.Description, &job.ThresholdInherited, &job.Type, &job.ThroughputFloor, &job.Schedule, &job.ShardPolicy, &job.MinInterval, &job.CreatedAt, &job.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job row: %w", err)
+		}
+
+		if apiKeyNull.Valid {
+			job.ApiKey = apiKeyNull.String
+		}
+		if snoozedUntil.Valid {
+			job.SnoozedUntil = &snoozedUntil.Time
+		}
+
+		if err := json.Unmarshal([]byte(labelsJSON), &job.Labels); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal labels: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(allowedCIDRsJSON), &job.AllowedCIDRs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal allowed CIDRs: %w", err)
+		}
+
+		page.Jobs = append(page.Jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	if len(page.Jobs) > limit {
+		page.Jobs = page.Jobs[:limit]
+		page.NextCursor = encodeCursor(page.Jobs[limit-1].ID)
+	}
+
+	return page, nil
+}
+
+// JobResultFilter narrows the results listed by ListResultsPage; its zero
+// fields match any result
+type JobResultFilter struct {
+	JobName string
+	Host    string
+	Status  string
+	RunID   string
+	Since   time.Time // Results timestamped at or after
+	Until   time.Time // Results timestamped before
+}
+
+// ListJobResultsPage returns up to limit results of a job, most recently
+// recorded first, starting after cursor
+func (s *JobResultStore) ListJobResultsPage(jobName, host, cursor string, limit int) (*JobResultPage, error) {
+	return s.ListResultsPage(JobResultFilter{JobName: jobName, Host: host}, cursor, limit)
+}
+
+// ListResultsPage returns up to limit results matching filter, most
+// recently recorded first, starting after cursor
+func (s *JobResultStore) ListResultsPage(filter JobResultFilter, cursor string, limit int) (*JobResultPage, error) {
+	beforeID, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := []string{"(? = 0 OR id < ?)"}
+	args := []interface{}{beforeID, beforeID}
+	if filter.JobName != "" {
+		conditions = append(conditions, "job_name = ?")
+		args = append(args, filter.JobName)
+	}
+	if filter.Host != "" {
+		conditions = append(conditions, "host = ?")
+		args = append(args, filter.Host)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.RunID != "" {
+		conditions = append(conditions, "run_id = ?")
+		args = append(args, filter.RunID)
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, filter.Until.UTC())
+	}
+
+	// Fetch one extra row to know whether another page follows
+	query := "SELECT id, job_name, host, status, labels, duration, output, timestamp, " + resultSourceColumns +
+		" FROM job_results WHERE " + strings.Join(conditions, " AND ") + " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := s.db.QueryxContext(s.context(), s.db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job results: %w", err)
+	}
+	defer rows.Close()
+
+	page := &JobResultPage{Results: []*JobResult{}}
+	for rows.Next() {
+		result := &JobResult{}
+		var labelsJSON string
+		var output sql.NullString
+		var duration sql.NullInt64
+		var source resultSource
+
+		dest := append([]interface{}{&result.ID, &result.JobName, &result.Host, &result.Status, &labelsJSON, &duration, &output, &result.Timestamp}, source.dest()...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan job result row: %w", err)
+		}
+		source.apply(result)
+
+		if duration.Valid {
+			result.Duration = int(duration.Int64)
+		}
+		if output.Valid {
+			result.Output = output.String
+		}
+
+		if labelsJSON != "{}" && labelsJSON != "" {
+			if err := json.Unmarshal([]byte(labelsJSON), &result.Labels); err != nil {
+				logrus.WithError(err).Warn("failed to unmarshal job result labels")
+			}
+		}
+
+		page.Results = append(page.Results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get job results: %w", err)
+	}
+
+	if len(page.Results) > limit {
+		page.Results = page.Results[:limit]
+		page.NextCursor = encodeCursor(page.Results[limit-1].ID)
+	}
+
+	return page, nil
+}
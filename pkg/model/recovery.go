@@ -0,0 +1,166 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Recovery is an incident of a job: from its first failed or lost result
+// after a success, to its next successful result
+type Recovery struct {
+	FailedAt    time.Time
+	RecoveredAt time.Time
+}
+
+// Duration returns the time the job took to recover
+func (r *Recovery) Duration() time.Duration {
+	return r.RecoveredAt.Sub(r.FailedAt)
+}
+
+// MeanRecovery returns the mean time to recovery of the incidents, 0
+// without any
+func MeanRecovery(recoveries []*Recovery) time.Duration {
+	if len(recoveries) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, recovery := range recoveries {
+		total += recovery.Duration()
+	}
+	return total / time.Duration(len(recoveries))
+}
+
+// RecoveriesSince returns the incidents each job recovered from among its
+// results since the given time, oldest first. Incidents still open are left
+// out, and one open at since counts from its first failure after since.
+// Test results are left out. The shards of a fan-out job's runs count as
+// one result per run, aggregated by the job's policy as its status is: a
+// run fails or succeeds when its shards decide it, and a retried shard
+// deciding it again is a new result.
+func (s *JobResultStore) RecoveriesSince(since time.Time) (map[JobRef][]*Recovery, error) {
+	query := `
+		SELECT r.job_name, r.host, r.status, r.timestamp, COALESCE(r.run_id, ''), r.shard, COALESCE(r.shards, 0), COALESCE(j.shard_policy, '')
+		FROM job_results r
+		LEFT JOIN jobs j ON j.name = r.job_name AND j.host = r.host
+		WHERE r.timestamp >= ? AND NOT r.test
+		ORDER BY r.job_name, r.host, r.timestamp, r.id
+	`
+
+	rows, err := s.db.QueryContext(s.context(), s.db.Rebind(query), since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recoveries: %w", err)
+	}
+	defer rows.Close()
+
+	recoveries := make(map[JobRef][]*Recovery)
+	var current JobRef
+	var failedAt *time.Time // Start of the current job's open incident
+	var runs map[string]*recoveryRun
+	for rows.Next() {
+		var ref JobRef
+		var status, runID, policy string
+		var timestamp time.Time
+		var shard sql.NullInt64
+		var shards int
+		if err := rows.Scan(&ref.Name, &ref.Host, &status, &timestamp, &runID, &shard, &shards, &policy); err != nil {
+			return nil, fmt.Errorf("failed to scan recovery: %w", err)
+		}
+		if ref != current {
+			current, failedAt, runs = ref, nil, make(map[string]*recoveryRun)
+		}
+
+		if policy != "" && shard.Valid && runID != "" {
+			run, ok := runs[runID]
+			if !ok {
+				run = &recoveryRun{ShardRun: ShardRun{RunID: runID}, shards: make(map[int]int)}
+				runs[runID] = run
+			}
+			if status = run.add(int(shard.Int64), shards, status, policy); status == "" {
+				continue
+			}
+		}
+
+		switch {
+		case status == "success" && failedAt != nil:
+			recoveries[ref] = append(recoveries[ref], &Recovery{FailedAt: *failedAt, RecoveredAt: timestamp})
+			failedAt = nil
+		case (status == "failure" || status == ResultLost) && failedAt == nil:
+			failedAt = &timestamp
+		}
+	}
+
+	return recoveries, rows.Err()
+}
+
+// recoveryRun is a fan-out run whose shards RecoveriesSince has read so far
+type recoveryRun struct {
+	ShardRun
+	shards map[int]int // Index in Results of each shard's latest result
+	status string      // Latest status the shards decided, empty while undecided
+}
+
+// add records a shard's result and returns the run's status when the
+// result decides it anew, else ""
+func (r *recoveryRun) add(shard, shards int, status, policy string) string {
+	result := &JobResult{Status: status, Shard: &shard}
+	if i, ok := r.shards[shard]; ok {
+		r.Results[i] = result
+	} else {
+		r.shards[shard] = len(r.Results)
+		r.Results = append(r.Results, result)
+	}
+	if shards > r.Shards {
+		r.Shards = shards
+	}
+
+	decided := r.Status(policy)
+	if decided == "" || decided == r.status {
+		return ""
+	}
+	r.status = decided
+	return decided
+}
+
+// RecoveryRefresh is how long a RecoveryCache reuses the incidents it found
+const RecoveryRefresh = time.Minute
+
+// RecoveryCache keeps the incidents found over a window for RecoveryRefresh,
+// so that readers on every scrape, such as the MTTR metrics, do not scan the
+// window's results each time
+type RecoveryCache struct {
+	store *JobResultStore
+
+	mu      sync.Mutex
+	entries map[time.Duration]*recoveryEntry // By window
+}
+
+type recoveryEntry struct {
+	at         time.Time
+	recoveries map[JobRef][]*Recovery
+}
+
+// NewRecoveryCache creates a cache of the incidents of the store's results
+func NewRecoveryCache(store *JobResultStore) *RecoveryCache {
+	return &RecoveryCache{store: store, entries: make(map[time.Duration]*recoveryEntry)}
+}
+
+// RecoveriesSince returns the incidents each job recovered from over the
+// window ending at now, as JobResultStore.RecoveriesSince, found at most
+// RecoveryRefresh earlier. The database is queried with ctx.
+func (c *RecoveryCache) RecoveriesSince(ctx context.Context, now time.Time, window time.Duration) (map[JobRef][]*Recovery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[window]; ok && now.Sub(entry.at) < RecoveryRefresh && !now.Before(entry.at) {
+		return entry.recoveries, nil
+	}
+
+	recoveries, err := c.store.WithContext(ctx).RecoveriesSince(now.Add(-window))
+	if err != nil {
+		return nil, err
+	}
+	c.entries[window] = &recoveryEntry{at: now, recoveries: recoveries}
+	return recoveries, nil
+}
@@ -0,0 +1,144 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Reasons a job result submission is rejected for
+const (
+	RejectionMissingKey     = "missing_key"     // No API key was sent
+	RejectionInvalidKey     = "invalid_key"     // The API key belongs to no job
+	RejectionBadSignature   = "bad_signature"   // The body signature does not match the job's key
+	RejectionSourceDenied   = "source_denied"   // The client address is outside the job's allowed CIDRs
+	RejectionJobMismatch    = "job_mismatch"    // The result names another job than the key's
+	RejectionInvalidPayload = "invalid_payload" // Malformed JSON or missing/invalid fields
+	RejectionStaleTimestamp = "stale_timestamp" // The timestamp is outside the allowed clock skew
+	RejectionReplay         = "replay"          // The nonce was already used
+	RejectionRateLimited    = "rate_limited"    // The job submitted again before rate_limit.min_interval passed
+	RejectionScriptLimit    = "script_limit"    // A result script ran past its steps, memory or time
+)
+
+// RejectionReasons lists every rejection reason
+var RejectionReasons = []string{
+	RejectionMissingKey,
+	RejectionInvalidKey,
+	RejectionBadSignature,
+	RejectionSourceDenied,
+	RejectionJobMismatch,
+	RejectionInvalidPayload,
+	RejectionStaleTimestamp,
+	RejectionReplay,
+	RejectionRateLimited,
+	RejectionScriptLimit,
+}
+
+// maxRejections is the number of rejections kept; older ones are pruned so
+// a flood of bad submissions cannot grow the database without bound
+const maxRejections = 10000
+
+// ResultRejection records a refused job result submission. The payload
+// itself is not stored, only its fingerprint, so repeated submissions of the
+// same payload can be told apart from different ones.
+type ResultRejection struct {
+	ID          int       `json:"id" db:"id"`
+	Reason      string    `json:"reason" db:"reason"`
+	Message     string    `json:"message" db:"message"`
+	JobName     string    `json:"job_name,omitempty" db:"job_name"`
+	Host        string    `json:"host,omitempty" db:"host"`
+	KeyPrefix   string    `json:"key_prefix,omitempty" db:"key_prefix"` // First characters of the key sent
+	Source      string    `json:"source" db:"source"`                   // Client address, or the ingestion path
+	Fingerprint string    `json:"fingerprint" db:"fingerprint"`         // SHA-256 of the payload
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// RejectionPage is one page of rejections from keyset pagination
+type RejectionPage struct {
+	Rejections []*ResultRejection `json:"rejections"`
+	NextCursor string             `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// PayloadFingerprint returns the fingerprint of a submitted payload
+func PayloadFingerprint(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// RejectionStore provides database operations for rejected job results
+type RejectionStore struct {
+	db *sqlx.DB
+	storeContext
+}
+
+// NewRejectionStore creates a new RejectionStore instance
+func NewRejectionStore(db *sqlx.DB) *RejectionStore {
+	return &RejectionStore{db: db}
+}
+
+// WithContext returns a copy of the store whose queries run with ctx
+func (s *RejectionStore) WithContext(ctx context.Context) *RejectionStore {
+	clone := *s
+	clone.ctx = ctx
+	return &clone
+}
+
+// RecordRejection stores a rejection and prunes the oldest ones beyond the
+// retention limit
+func (s *RejectionStore) RecordRejection(rejection *ResultRejection) error {
+	if rejection.CreatedAt.IsZero() {
+		rejection.CreatedAt = time.Now().UTC()
+	}
+
+	query := `
+		INSERT INTO result_rejections (reason, message, job_name, host, key_prefix, source, fingerprint, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	id, err := insertID(s.context(), s.db, query, rejection.Reason, rejection.Message, rejection.JobName, rejection.Host,
+		rejection.KeyPrefix, rejection.Source, rejection.Fingerprint, rejection.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record rejection: %w", err)
+	}
+	rejection.ID = int(id)
+
+	if _, err := s.db.ExecContext(s.context(), s.db.Rebind(`DELETE FROM result_rejections WHERE id <= ?`), rejection.ID-maxRejections); err != nil {
+		return fmt.Errorf("failed to prune rejections: %w", err)
+	}
+
+	return nil
+}
+
+// ListRejectionsPage returns up to limit rejections, most recent first,
+// starting after cursor
+func (s *RejectionStore) ListRejectionsPage(cursor string, limit int) (*RejectionPage, error) {
+	beforeID, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, reason, message, job_name, host, key_prefix, source, fingerprint, created_at
+		FROM result_rejections
+		WHERE ? = 0 OR id < ?
+		ORDER BY id DESC
+		LIMIT ?
+	`
+
+	// Fetch one extra row to know whether another page follows
+	page := &RejectionPage{Rejections: []*ResultRejection{}}
+	if err := s.db.SelectContext(s.context(), &page.Rejections, s.db.Rebind(query), beforeID, beforeID, limit+1); err != nil {
+		return nil, fmt.Errorf("failed to list rejections: %w", err)
+	}
+
+	if len(page.Rejections) > limit {
+		page.Rejections = page.Rejections[:limit]
+		page.NextCursor = encodeCursor(page.Rejections[limit-1].ID)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// RelatedJobs lists the jobs sharing a host or a name with a job
+type RelatedJobs struct {
+	SameHost []*Job // Other jobs on the job's host
+	SameName []*Job // The same job on other hosts
+}
+
+// GetRelatedJobs returns the other jobs on a job's host and the jobs of the
+// same name on other hosts, ordered by name and host
+func (s *JobStore) GetRelatedJobs(job *Job) (*RelatedJobs, error) {
+	query := `
+		SELECT id, name, host, api_key, automatic_failure_threshold, labels, allowed_cidrs, status, last_reported_at, snoozed_until, description, threshold_inherited, type, throughput_floor, schedule, shard_policy, min_interval, created_at, updated_at
+		FROM jobs
+		WHERE (host = ? OR name = ?) AND id != ?
+		ORDER BY name, host
+	`
+
+	rows, err := s.db.QueryxContext(s.context(), s.db.Rebind(query), job.Host, job.Name, job.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get related jobs: %w", err)
+	}
+	defer rows.Close()
+
+	related := &RelatedJobs{SameHost: []*Job{}, SameName: []*Job{}}
+	for rows.Next() {
+		other := &Job{}
+		var labelsJSON, allowedCIDRsJSON string
+		var apiKeyNull sql.NullString
+		var snoozedUntil sql.NullTime
+
+		err := rows.Scan(&other.ID, &other.Name, &other.Host, &apiKeyNull, &other.AutomaticFailureThreshold, &labelsJSON, &allowedCIDRsJSON, &other.Status, &other.LastReportedAt, &snoozedUntil, &other.Description, &other.ThresholdInherited, &other.Type, &other.ThroughputFloor, &other.Schedule, &other.ShardPolicy, &other.MinInterval, &other.CreatedAt, &other.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job row: %w", err)
+		}
+
+		if apiKeyNull.Valid {
+			other.ApiKey = apiKeyNull.String
+		}
+		if snoozedUntil.Valid {
+			other.SnoozedUntil = &snoozedUntil.Time
+		}
+
+		if err := json.Unmarshal([]byte(labelsJSON), &other.Labels); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal labels: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(allowedCIDRsJSON), &other.AllowedCIDRs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal allowed CIDRs: %w", err)
+		}
+
+		// A job's name and host are unique together, so each related job
+		// shares exactly one of them
+		if other.Host == job.Host {
+			related.SameHost = append(related.SameHost, other)
+		} else {
+			related.SameName = append(related.SameName, other)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating job rows: %w", err)
+	}
+
+	return related, nil
+}
@@ -0,0 +1,177 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MaxRulePatternLength is the longest pattern a status rule may have
+const MaxRulePatternLength = 500
+
+// StatusRule overrides the reported status of a job's results whose output
+// matches Pattern and whose exit code is ExitCode, e.g. to record "0 rows
+// copied" as a failure. Empty conditions match any result, but a rule has
+// at least one.
+type StatusRule struct {
+	ID          int       `json:"id" db:"id"`
+	JobName     string    `json:"job_name" db:"job_name"`
+	Host        string    `json:"host" db:"host"`
+	Pattern     string    `json:"pattern,omitempty" db:"pattern"`     // Regular expression searched for in the output
+	ExitCode    *int      `json:"exit_code,omitempty" db:"exit_code"` // Results without an exit code never match
+	Status      string    `json:"status" db:"status"`                 // "success" or "failure"
+	Description string    `json:"description,omitempty" db:"description"`
+	Author      string    `json:"author" db:"author"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+
+	pattern *regexp.Regexp
+}
+
+// Validate checks the rule and compiles its pattern
+func (r *StatusRule) Validate() error {
+	if r.Pattern == "" && r.ExitCode == nil {
+		return fmt.Errorf("a pattern or an exit code is required")
+	}
+	if len(r.Pattern) > MaxRulePatternLength {
+		return fmt.Errorf("pattern must be at most %d characters", MaxRulePatternLength)
+	}
+	if r.Status != "success" && r.Status != "failure" {
+		return fmt.Errorf("status must be 'success' or 'failure'")
+	}
+
+	pattern, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	r.pattern = pattern
+	return nil
+}
+
+// Matches reports whether the rule applies to result
+func (r *StatusRule) Matches(result *JobResult) bool {
+	if r.ExitCode != nil && (result.ExitCode == nil || *result.ExitCode != *r.ExitCode) {
+		return false
+	}
+	// Rules read back from the database are compiled on first use
+	if r.pattern == nil && r.Validate() != nil {
+		return false
+	}
+	return r.pattern.MatchString(result.Output)
+}
+
+// ApplyStatusRules overrides the status of result with that of the first of
+// rules matching it. When the status changes, the reported status and the
+// rule are recorded with the result. It returns the rule that changed the
+// status, if any.
+func ApplyStatusRules(rules []*StatusRule, result *JobResult) *StatusRule {
+	for _, rule := range rules {
+		if !rule.Matches(result) {
+			continue
+		}
+		if rule.Status == result.Status {
+			return nil
+		}
+		result.ReportedStatus, result.StatusRuleID = result.Status, rule.ID
+		result.Status = rule.Status
+		return rule
+	}
+	return nil
+}
+
+// RuleStore provides database operations for status rules
+type RuleStore struct {
+	db *sqlx.DB
+	storeContext
+}
+
+// NewRuleStore creates a new RuleStore instance
+func NewRuleStore(db *sqlx.DB) *RuleStore {
+	return &RuleStore{db: db}
+}
+
+// WithContext returns a copy of the store whose queries run with ctx
+func (s *RuleStore) WithContext(ctx context.Context) *RuleStore {
+	clone := *s
+	clone.ctx = ctx
+	return &clone
+}
+
+// CreateRule validates and stores a status rule
+func (s *RuleStore) CreateRule(rule *StatusRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	if rule.CreatedAt.IsZero() {
+		rule.CreatedAt = time.Now().UTC()
+	}
+
+	query := `
+		INSERT INTO status_rules (job_name, host, pattern, exit_code, status, description, author, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	id, err := insertID(s.context(), s.db, query, rule.JobName, rule.Host, rule.Pattern, rule.ExitCode,
+		rule.Status, rule.Description, rule.Author, rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create status rule: %w", err)
+	}
+	rule.ID = int(id)
+
+	return nil
+}
+
+// ListRules returns the status rules of a job in the order they apply,
+// oldest first
+func (s *RuleStore) ListRules(jobName, host string) ([]*StatusRule, error) {
+	query := `
+		SELECT id, job_name, host, pattern, exit_code, status, description, author, created_at
+		FROM status_rules
+		WHERE job_name = ? AND host = ?
+		ORDER BY id
+	`
+
+	rows, err := s.db.QueryxContext(s.context(), s.db.Rebind(query), jobName, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list status rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []*StatusRule{}
+	for rows.Next() {
+		rule := &StatusRule{}
+		var exitCode sql.NullInt64
+		if err := rows.Scan(&rule.ID, &rule.JobName, &rule.Host, &rule.Pattern, &exitCode, &rule.Status,
+			&rule.Description, &rule.Author, &rule.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status rule: %w", err)
+		}
+		if exitCode.Valid {
+			code := int(exitCode.Int64)
+			rule.ExitCode = &code
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// DeleteRule removes one of a job's status rules
+func (s *RuleStore) DeleteRule(id int, jobName, host string) error {
+	result, err := s.db.ExecContext(s.context(), s.db.Rebind(`DELETE FROM status_rules WHERE id = ? AND job_name = ? AND host = ?`), id, jobName, host)
+	if err != nil {
+		return fmt.Errorf("failed to delete status rule: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("status rule with ID %d not found", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,492 @@
+package model
+
+// PostgreSQL and MySQL databases start from the schema SQLite databases
+// reach with the migrations up to 024_create_job_versions.sql. Their later
+// migrations follow it. Statements are separated by semicolons, which
+// comments must not contain.
+
+// postgresMigrations are the migrations of PostgreSQL databases
+var postgresMigrations = map[string]string{
+	"001_create_schema.sql": `
+		CREATE TABLE jobs (
+			id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			name TEXT NOT NULL,
+			host TEXT NOT NULL,
+			api_key TEXT,
+			automatic_failure_threshold INTEGER NOT NULL DEFAULT 3600,
+			labels TEXT NOT NULL DEFAULT '{}',
+			status TEXT NOT NULL DEFAULT 'active',
+			last_reported_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			allowed_cidrs TEXT NOT NULL DEFAULT '[]',
+			snoozed_until TIMESTAMPTZ,
+			description TEXT NOT NULL DEFAULT '',
+			threshold_inherited BOOLEAN NOT NULL DEFAULT FALSE,
+			type TEXT NOT NULL DEFAULT 'standard',
+			throughput_floor BIGINT NOT NULL DEFAULT 0,
+			UNIQUE (name, host)
+		);
+
+		CREATE UNIQUE INDEX idx_jobs_api_key ON jobs(api_key) WHERE api_key IS NOT NULL;
+		CREATE INDEX idx_jobs_status ON jobs(status);
+		CREATE INDEX idx_jobs_last_reported ON jobs(last_reported_at);
+
+		-- Renaming a job renames its results
+		CREATE TABLE job_results (
+			id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			job_name TEXT NOT NULL,
+			host TEXT NOT NULL,
+			status TEXT NOT NULL,
+			labels TEXT DEFAULT '{}',
+			duration INTEGER,
+			output TEXT,
+			timestamp TIMESTAMPTZ NOT NULL,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			job_id BIGINT REFERENCES jobs(id),
+			source_ip TEXT,
+			user_agent TEXT,
+			client_version TEXT,
+			received_at TIMESTAMPTZ,
+			clock_skew DOUBLE PRECISION,
+			clock_skewed BOOLEAN NOT NULL DEFAULT FALSE,
+			source TEXT,
+			exit_code INTEGER,
+			reported_status TEXT,
+			status_rule_id BIGINT,
+			test BOOLEAN NOT NULL DEFAULT FALSE,
+			items_processed BIGINT,
+			FOREIGN KEY (job_name, host) REFERENCES jobs(name, host) ON UPDATE CASCADE ON DELETE CASCADE
+		);
+
+		CREATE INDEX idx_job_results_job ON job_results(job_name, host);
+		CREATE INDEX idx_job_results_timestamp ON job_results(timestamp);
+		CREATE INDEX idx_job_results_status ON job_results(status);
+		CREATE INDEX idx_job_results_job_id ON job_results(job_id);
+
+		CREATE TABLE admin_keys (
+			id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			name TEXT NOT NULL,
+			key_hash TEXT NOT NULL UNIQUE,
+			key_prefix TEXT NOT NULL,
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			revoked_at TIMESTAMPTZ
+		);
+
+		CREATE TABLE audit_log (
+			id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			actor TEXT NOT NULL,
+			action TEXT NOT NULL,
+			target TEXT NOT NULL DEFAULT '',
+			details TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+
+		CREATE TABLE result_rejections (
+			id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			reason TEXT NOT NULL,
+			message TEXT NOT NULL,
+			job_name TEXT NOT NULL DEFAULT '',
+			host TEXT NOT NULL DEFAULT '',
+			key_prefix TEXT NOT NULL DEFAULT '',
+			source TEXT NOT NULL DEFAULT '',
+			fingerprint TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX idx_result_rejections_reason ON result_rejections(reason);
+
+		CREATE TABLE result_nonces (
+			job_name TEXT NOT NULL,
+			host TEXT NOT NULL,
+			nonce TEXT NOT NULL,
+			expires_at BIGINT NOT NULL,
+			PRIMARY KEY (job_name, host, nonce)
+		);
+
+		CREATE INDEX idx_result_nonces_expires_at ON result_nonces(expires_at);
+
+		CREATE TABLE result_annotations (
+			id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			job_name TEXT NOT NULL,
+			host TEXT NOT NULL,
+			result_id BIGINT NOT NULL DEFAULT 0,
+			comment TEXT NOT NULL,
+			author TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX idx_result_annotations_job ON result_annotations(job_name, host);
+
+		CREATE TABLE job_acknowledgements (
+			id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			job_name TEXT NOT NULL,
+			host TEXT NOT NULL,
+			author TEXT NOT NULL DEFAULT '',
+			ticket_id TEXT NOT NULL DEFAULT '',
+			ticket_url TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX idx_job_acknowledgements_job ON job_acknowledgements(job_name, host);
+
+		CREATE TABLE notification_deliveries (
+			id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			job_name TEXT NOT NULL,
+			host TEXT NOT NULL,
+			channel TEXT NOT NULL,
+			team TEXT NOT NULL DEFAULT '',
+			target TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL,
+			latency_ms BIGINT NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT '',
+			reference TEXT NOT NULL DEFAULT '',
+			url TEXT NOT NULL DEFAULT '',
+			subject TEXT NOT NULL DEFAULT '',
+			body TEXT NOT NULL DEFAULT '',
+			redelivery_of BIGINT REFERENCES notification_deliveries(id) ON DELETE SET NULL,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX idx_notification_deliveries_job ON notification_deliveries(job_name, host);
+
+		CREATE TABLE job_starts (
+			job_name TEXT NOT NULL,
+			host TEXT NOT NULL,
+			started_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (job_name, host)
+		);
+
+		CREATE TABLE maintenance_windows (
+			id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			selector TEXT NOT NULL,
+			until TIMESTAMPTZ,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			ended_at TIMESTAMPTZ
+		);
+
+		CREATE TABLE maintenance_window_jobs (
+			window_id BIGINT NOT NULL REFERENCES maintenance_windows(id) ON DELETE CASCADE,
+			job_id BIGINT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+			PRIMARY KEY (window_id, job_id)
+		);
+
+		CREATE TABLE changes (
+			id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			type TEXT NOT NULL,
+			time TIMESTAMPTZ NOT NULL,
+			payload TEXT NOT NULL
+		);
+
+		CREATE INDEX idx_changes_time ON changes(time);
+
+		CREATE TABLE status_rules (
+			id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			job_name TEXT NOT NULL,
+			host TEXT NOT NULL,
+			pattern TEXT NOT NULL DEFAULT '',
+			exit_code INTEGER,
+			status TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			author TEXT NOT NULL,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX idx_status_rules_job ON status_rules(job_name, host);
+
+		CREATE TABLE job_versions (
+			id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			job_id BIGINT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+			version INTEGER NOT NULL,
+			config TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			replaced_at TIMESTAMPTZ NOT NULL,
+			UNIQUE (job_id, version)
+		)
+	`,
+	"025_add_schedule_to_jobs.sql": `
+		ALTER TABLE jobs ADD COLUMN schedule TEXT NOT NULL DEFAULT ''
+	`,
+	"026_add_run_id.sql": `
+		ALTER TABLE job_results ADD COLUMN run_id TEXT;
+		ALTER TABLE job_starts ADD COLUMN run_id TEXT NOT NULL DEFAULT '';
+
+		CREATE INDEX idx_job_results_run_id ON job_results(job_name, host, run_id)
+	`,
+	"027_add_shards.sql": `
+		ALTER TABLE jobs ADD COLUMN shard_policy TEXT NOT NULL DEFAULT '';
+		ALTER TABLE job_results ADD COLUMN shard INTEGER;
+		ALTER TABLE job_results ADD COLUMN shards INTEGER
+	`,
+	"028_create_job_overlaps.sql": `
+		CREATE TABLE job_overlaps (
+			id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			job_name TEXT NOT NULL,
+			host TEXT NOT NULL,
+			run_id TEXT NOT NULL DEFAULT '',
+			started_at TIMESTAMPTZ NOT NULL,
+			previous_run_id TEXT NOT NULL DEFAULT '',
+			previous_started_at TIMESTAMPTZ NOT NULL
+		);
+
+		CREATE INDEX idx_job_overlaps_job ON job_overlaps(job_name, host);
+		ALTER TABLE job_starts ADD COLUMN overlapping BOOLEAN NOT NULL DEFAULT FALSE
+	`,
+	"029_create_notifier_channels.sql": `
+		CREATE TABLE notifier_channels (
+			id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			type TEXT NOT NULL,
+			settings TEXT NOT NULL,
+			updated_by TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMPTZ NOT NULL
+		)
+	`,
+	"030_add_min_interval_to_jobs.sql": `
+		ALTER TABLE jobs ADD COLUMN min_interval INTEGER NOT NULL DEFAULT 0
+	`,
+}
+
+// mysqlMigrations are the migrations of MySQL databases. Keyed text
+// columns are VARCHAR, and text compares as binary, as on SQLite. Text
+// defaults are expressions, which need MySQL 8.0.13 or later.
+var mysqlMigrations = map[string]string{
+	"001_create_schema.sql": `
+		CREATE TABLE jobs (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			host VARCHAR(255) NOT NULL,
+			api_key VARCHAR(255) UNIQUE,
+			automatic_failure_threshold INTEGER NOT NULL DEFAULT 3600,
+			labels TEXT NOT NULL DEFAULT ('{}'),
+			status VARCHAR(32) NOT NULL DEFAULT 'active',
+			last_reported_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+			created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+			updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+			allowed_cidrs TEXT NOT NULL DEFAULT ('[]'),
+			snoozed_until DATETIME(6),
+			description TEXT NOT NULL DEFAULT (''),
+			threshold_inherited BOOLEAN NOT NULL DEFAULT FALSE,
+			type VARCHAR(32) NOT NULL DEFAULT 'standard',
+			throughput_floor BIGINT NOT NULL DEFAULT 0,
+			UNIQUE (name, host),
+			INDEX idx_jobs_status (status),
+			INDEX idx_jobs_last_reported (last_reported_at)
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+		-- Renaming a job renames its results
+		CREATE TABLE job_results (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			job_name VARCHAR(255) NOT NULL,
+			host VARCHAR(255) NOT NULL,
+			status VARCHAR(32) NOT NULL,
+			labels TEXT DEFAULT ('{}'),
+			duration INTEGER,
+			output MEDIUMTEXT,
+			timestamp DATETIME(6) NOT NULL,
+			created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+			job_id BIGINT,
+			source_ip VARCHAR(64),
+			user_agent TEXT,
+			client_version VARCHAR(255),
+			received_at DATETIME(6),
+			clock_skew DOUBLE,
+			clock_skewed BOOLEAN NOT NULL DEFAULT FALSE,
+			source VARCHAR(64),
+			exit_code INTEGER,
+			reported_status VARCHAR(32),
+			status_rule_id BIGINT,
+			test BOOLEAN NOT NULL DEFAULT FALSE,
+			items_processed BIGINT,
+			FOREIGN KEY (job_name, host) REFERENCES jobs(name, host) ON UPDATE CASCADE ON DELETE CASCADE,
+			FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE,
+			INDEX idx_job_results_job (job_name, host),
+			INDEX idx_job_results_timestamp (timestamp),
+			INDEX idx_job_results_status (status),
+			INDEX idx_job_results_job_id (job_id)
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+		CREATE TABLE admin_keys (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			key_hash VARCHAR(255) NOT NULL UNIQUE,
+			key_prefix VARCHAR(255) NOT NULL,
+			created_by VARCHAR(255) NOT NULL DEFAULT '',
+			created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+			revoked_at DATETIME(6)
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+		CREATE TABLE audit_log (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			actor VARCHAR(255) NOT NULL,
+			action VARCHAR(255) NOT NULL,
+			target TEXT NOT NULL DEFAULT (''),
+			details TEXT NOT NULL DEFAULT (''),
+			created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+			INDEX idx_audit_log_created_at (created_at)
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+		CREATE TABLE result_rejections (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			reason VARCHAR(64) NOT NULL,
+			message TEXT NOT NULL,
+			job_name VARCHAR(255) NOT NULL DEFAULT '',
+			host VARCHAR(255) NOT NULL DEFAULT '',
+			key_prefix VARCHAR(255) NOT NULL DEFAULT '',
+			source VARCHAR(255) NOT NULL DEFAULT '',
+			fingerprint VARCHAR(255) NOT NULL DEFAULT '',
+			created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+			INDEX idx_result_rejections_reason (reason)
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+		CREATE TABLE result_nonces (
+			job_name VARCHAR(255) NOT NULL,
+			host VARCHAR(255) NOT NULL,
+			nonce VARCHAR(255) NOT NULL,
+			expires_at BIGINT NOT NULL,
+			PRIMARY KEY (job_name, host, nonce),
+			INDEX idx_result_nonces_expires_at (expires_at)
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+		CREATE TABLE result_annotations (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			job_name VARCHAR(255) NOT NULL,
+			host VARCHAR(255) NOT NULL,
+			result_id BIGINT NOT NULL DEFAULT 0,
+			comment TEXT NOT NULL,
+			author VARCHAR(255) NOT NULL DEFAULT '',
+			created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+			INDEX idx_result_annotations_job (job_name, host)
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+		CREATE TABLE job_acknowledgements (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			job_name VARCHAR(255) NOT NULL,
+			host VARCHAR(255) NOT NULL,
+			author VARCHAR(255) NOT NULL DEFAULT '',
+			ticket_id VARCHAR(255) NOT NULL DEFAULT '',
+			ticket_url TEXT NOT NULL DEFAULT (''),
+			created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+			INDEX idx_job_acknowledgements_job (job_name, host)
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+		CREATE TABLE notification_deliveries (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			job_name VARCHAR(255) NOT NULL,
+			host VARCHAR(255) NOT NULL,
+			channel VARCHAR(64) NOT NULL,
+			team VARCHAR(255) NOT NULL DEFAULT '',
+			target TEXT NOT NULL DEFAULT (''),
+			status VARCHAR(32) NOT NULL,
+			latency_ms BIGINT NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT (''),
+			reference VARCHAR(255) NOT NULL DEFAULT '',
+			url TEXT NOT NULL DEFAULT (''),
+			subject TEXT NOT NULL DEFAULT (''),
+			body MEDIUMTEXT NOT NULL DEFAULT (''),
+			redelivery_of BIGINT,
+			created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+			FOREIGN KEY (redelivery_of) REFERENCES notification_deliveries(id) ON DELETE SET NULL,
+			INDEX idx_notification_deliveries_job (job_name, host)
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+		CREATE TABLE job_starts (
+			job_name VARCHAR(255) NOT NULL,
+			host VARCHAR(255) NOT NULL,
+			started_at DATETIME(6) NOT NULL,
+			PRIMARY KEY (job_name, host)
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+		CREATE TABLE maintenance_windows (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			selector TEXT NOT NULL,
+			until DATETIME(6),
+			created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+			ended_at DATETIME(6)
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+		CREATE TABLE maintenance_window_jobs (
+			window_id BIGINT NOT NULL,
+			job_id BIGINT NOT NULL,
+			PRIMARY KEY (window_id, job_id),
+			FOREIGN KEY (window_id) REFERENCES maintenance_windows(id) ON DELETE CASCADE,
+			FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+		CREATE TABLE changes (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			type VARCHAR(64) NOT NULL,
+			time DATETIME(6) NOT NULL,
+			payload MEDIUMTEXT NOT NULL,
+			INDEX idx_changes_time (time)
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+		CREATE TABLE status_rules (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			job_name VARCHAR(255) NOT NULL,
+			host VARCHAR(255) NOT NULL,
+			pattern TEXT NOT NULL DEFAULT (''),
+			exit_code INTEGER,
+			status VARCHAR(32) NOT NULL,
+			description TEXT NOT NULL DEFAULT (''),
+			author VARCHAR(255) NOT NULL,
+			created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+			INDEX idx_status_rules_job (job_name, host)
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+		CREATE TABLE job_versions (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			job_id BIGINT NOT NULL,
+			version INTEGER NOT NULL,
+			config TEXT NOT NULL,
+			created_at DATETIME(6) NOT NULL,
+			replaced_at DATETIME(6) NOT NULL,
+			UNIQUE (job_id, version),
+			FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin
+	`,
+	"025_add_schedule_to_jobs.sql": `
+		ALTER TABLE jobs ADD COLUMN schedule VARCHAR(255) NOT NULL DEFAULT ''
+	`,
+	"026_add_run_id.sql": `
+		ALTER TABLE job_results ADD COLUMN run_id VARCHAR(128);
+		ALTER TABLE job_starts ADD COLUMN run_id VARCHAR(128) NOT NULL DEFAULT '';
+
+		CREATE INDEX idx_job_results_run_id ON job_results(job_name, host, run_id)
+	`,
+	"027_add_shards.sql": `
+		ALTER TABLE jobs ADD COLUMN shard_policy VARCHAR(16) NOT NULL DEFAULT '';
+		ALTER TABLE job_results ADD COLUMN shard INTEGER;
+		ALTER TABLE job_results ADD COLUMN shards INTEGER
+	`,
+	"028_create_job_overlaps.sql": `
+		CREATE TABLE job_overlaps (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			job_name VARCHAR(255) NOT NULL,
+			host VARCHAR(255) NOT NULL,
+			run_id VARCHAR(128) NOT NULL DEFAULT '',
+			started_at DATETIME(6) NOT NULL,
+			previous_run_id VARCHAR(128) NOT NULL DEFAULT '',
+			previous_started_at DATETIME(6) NOT NULL,
+			INDEX idx_job_overlaps_job (job_name, host)
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+		ALTER TABLE job_starts ADD COLUMN overlapping BOOLEAN NOT NULL DEFAULT FALSE
+	`,
+	"029_create_notifier_channels.sql": `
+		CREATE TABLE notifier_channels (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL UNIQUE,
+			type VARCHAR(16) NOT NULL,
+			settings TEXT NOT NULL,
+			updated_by VARCHAR(255) NOT NULL DEFAULT '',
+			updated_at DATETIME(6) NOT NULL
+		) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin
+	`,
+	"030_add_min_interval_to_jobs.sql": `
+		ALTER TABLE jobs ADD COLUMN min_interval INTEGER NOT NULL DEFAULT 0
+	`,
+}
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Shard policies of fan-out jobs, whose runs spawn child tasks reporting
+// under the job with a shard index
+const (
+	ShardPolicyAll = "all" // The run succeeds when every shard succeeded
+	ShardPolicyAny = "any" // The run succeeds when a shard succeeded
+)
+
+// MaxShards caps the child tasks of a fan-out run
+const MaxShards = 1000
+
+// CheckShard rejects shard indexes and counts out of range, and shards
+// reported outside of a run
+func CheckShard(result *JobResult) error {
+	if result.Shards < 0 || result.Shards > MaxShards {
+		return fmt.Errorf("shards must be between 0 and %d", MaxShards)
+	}
+	if result.Shard == nil {
+		if result.Shards != 0 {
+			return fmt.Errorf("shards requires shard")
+		}
+		return nil
+	}
+	if *result.Shard < 0 || *result.Shard >= MaxShards || (result.Shards > 0 && *result.Shard >= result.Shards) {
+		return fmt.Errorf("shard must be between 0 and shards - 1")
+	}
+	if result.RunID == "" {
+		return fmt.Errorf("shard requires run_id, shared by the shards of the run")
+	}
+	return nil
+}
+
+// ShardRun is a run of a fan-out job, with the results of its shards
+type ShardRun struct {
+	RunID   string       `json:"run_id"`
+	Shards  int          `json:"shards,omitempty"` // Child tasks the run spawned, the largest count its shards gave; 0 when none did
+	Results []*JobResult `json:"results"`          // Latest result of each reported shard, by shard index
+}
+
+// Missing returns the shards of the run that have not reported, when its
+// shard count is known
+func (r *ShardRun) Missing() []int {
+	reported := make(map[int]bool, len(r.Results))
+	for _, result := range r.Results {
+		reported[*result.Shard] = true
+	}
+
+	var missing []int
+	for shard := 0; shard < r.Shards; shard++ {
+		if !reported[shard] {
+			missing = append(missing, shard)
+		}
+	}
+	return missing
+}
+
+// Status aggregates the shards of the run by policy into "success" or
+// "failure". Lost shards are failures. It is empty while the run is
+// undecided: shards are missing, and those reported so far do not settle
+// it. Runs of unknown shard count are judged on the shards reported.
+func (r *ShardRun) Status(policy string) string {
+	succeeded, failed := 0, 0
+	for _, result := range r.Results {
+		if result.Status == "success" {
+			succeeded++
+		} else {
+			failed++
+		}
+	}
+	complete := len(r.Missing()) == 0
+
+	if policy == ShardPolicyAny {
+		if succeeded > 0 {
+			return "success"
+		}
+	} else if failed > 0 {
+		return "failure"
+	}
+	if !complete {
+		return ""
+	}
+	if failed > 0 && (policy != ShardPolicyAny || succeeded == 0) {
+		return "failure"
+	}
+	return "success"
+}
+
+// GetShardRuns returns the latest runs of the job with shard results, most
+// recent first. Test results are left out.
+func (s *JobResultStore) GetShardRuns(jobName, host string, limit int) ([]*ShardRun, error) {
+	query := `
+		SELECT run_id FROM job_results
+		WHERE job_name = ? AND host = ? AND shard IS NOT NULL AND run_id IS NOT NULL AND NOT test
+		GROUP BY run_id
+		ORDER BY MAX(id) DESC
+		LIMIT ?
+	`
+
+	var runIDs []string
+	if err := s.db.SelectContext(s.context(), &runIDs, s.db.Rebind(query), jobName, host, limit); err != nil {
+		return nil, fmt.Errorf("failed to list shard runs: %w", err)
+	}
+
+	runs := make([]*ShardRun, 0, len(runIDs))
+	for _, runID := range runIDs {
+		// Retried shards report several times; the latest result counts
+		page, err := s.ListResultsPage(JobResultFilter{JobName: jobName, Host: host, RunID: runID}, "", MaxShards*2)
+		if err != nil {
+			return nil, err
+		}
+
+		run := &ShardRun{RunID: runID, Results: []*JobResult{}}
+		seen := make(map[int]bool)
+		for _, result := range page.Results {
+			if result.Shard == nil || result.Test {
+				continue
+			}
+			if result.Shards > run.Shards {
+				run.Shards = result.Shards
+			}
+			if !seen[*result.Shard] {
+				seen[*result.Shard] = true
+				run.Results = append(run.Results, result)
+			}
+		}
+		sort.Slice(run.Results, func(i, j int) bool {
+			return *run.Results[i].Shard < *run.Results[j].Shard
+		})
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
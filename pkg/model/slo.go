@@ -0,0 +1,63 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// SLOStatus is the state of a service level objective's error budget
+type SLOStatus struct {
+	Name            string   `json:"name"`
+	Target          float64  `json:"target"` // Share of results that must succeed, e.g. 0.99
+	WindowDays      int      `json:"window_days"`
+	Jobs            int      `json:"jobs"`                   // Jobs the objective covers
+	Total           int      `json:"total"`                  // Results in the window
+	Failed          int      `json:"failed"`                 // Failed results in the window
+	BudgetRemaining float64  `json:"error_budget_remaining"` // Share of the error budget left, negative once overspent
+	BurnRate        float64  `json:"burn_rate"`              // Recent budget consumption; 1 spends exactly the budget over the window
+	Burning         bool     `json:"burning"`                // Burn rate at or above the warning level, or budget spent
+	Recoveries      int      `json:"recoveries"`             // Incidents the covered jobs recovered from in the window
+	MTTR            *float64 `json:"mttr_seconds,omitempty"` // Mean time to recovery of the incidents; nil without any
+}
+
+// JobRef identifies a job by name and host
+type JobRef struct {
+	Name string
+	Host string
+}
+
+// ResultCounts counts the results of a job
+type ResultCounts struct {
+	Total  int
+	Failed int
+}
+
+// CountJobResultsSince returns the number of results, and of failed
+// results, of each job recorded since the given time. Lost runs count as
+// failed.
+func (s *JobResultStore) CountJobResultsSince(since time.Time) (map[JobRef]ResultCounts, error) {
+	query := `
+		SELECT job_name, host, COUNT(*), COALESCE(SUM(CASE WHEN status IN ('failure', 'lost') THEN 1 ELSE 0 END), 0)
+		FROM job_results
+		WHERE timestamp >= ?
+		GROUP BY job_name, host
+	`
+
+	rows, err := s.db.QueryContext(s.context(), s.db.Rebind(query), since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to count job results: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[JobRef]ResultCounts)
+	for rows.Next() {
+		var ref JobRef
+		var count ResultCounts
+		if err := rows.Scan(&ref.Name, &ref.Host, &count.Total, &count.Failed); err != nil {
+			return nil, fmt.Errorf("failed to scan job result counts: %w", err)
+		}
+		counts[ref] = count
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,151 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// resultSourceColumns are the job_results columns recording where a
+// result came from, how its status was decided, whether it was a test, the
+// items it processed and the run and shard it belongs to
+const resultSourceColumns = "source_ip, user_agent, client_version, received_at, clock_skew, clock_skewed, source, exit_code, reported_status, status_rule_id, test, items_processed, run_id, shard, shards"
+
+// resultSourcePlaceholders are the query placeholders of sourceArgs
+const resultSourcePlaceholders = "?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?"
+
+// resultSource scans the source columns of a job result row, which are
+// NULL for results recorded before they existed
+type resultSource struct {
+	sourceIP       sql.NullString
+	userAgent      sql.NullString
+	clientVersion  sql.NullString
+	receivedAt     sql.NullTime
+	clockSkew      sql.NullFloat64
+	clockSkewed    sql.NullBool
+	source         sql.NullString
+	exitCode       sql.NullInt64
+	reportedStatus sql.NullString
+	statusRuleID   sql.NullInt64
+	test           sql.NullBool
+	items          sql.NullInt64
+	runID          sql.NullString
+	shard          sql.NullInt64
+	shards         sql.NullInt64
+}
+
+// dest returns the scan destinations of resultSourceColumns
+func (s *resultSource) dest() []interface{} {
+	return []interface{}{&s.sourceIP, &s.userAgent, &s.clientVersion, &s.receivedAt, &s.clockSkew, &s.clockSkewed, &s.source,
+		&s.exitCode, &s.reportedStatus, &s.statusRuleID, &s.test, &s.items, &s.runID, &s.shard, &s.shards}
+}
+
+// apply sets the scanned source on result
+func (s *resultSource) apply(result *JobResult) {
+	result.SourceIP = s.sourceIP.String
+	result.UserAgent = s.userAgent.String
+	result.ClientVersion = s.clientVersion.String
+	if s.receivedAt.Valid {
+		receivedAt := s.receivedAt.Time
+		result.ReceivedAt = &receivedAt
+	}
+	if s.clockSkew.Valid {
+		clockSkew := s.clockSkew.Float64
+		result.ClockSkew = &clockSkew
+	}
+	result.ClockSkewed = s.clockSkewed.Bool
+	result.Source = s.source.String
+	if s.exitCode.Valid {
+		exitCode := int(s.exitCode.Int64)
+		result.ExitCode = &exitCode
+	}
+	result.ReportedStatus = s.reportedStatus.String
+	result.StatusRuleID = int(s.statusRuleID.Int64)
+	result.Test = s.test.Bool
+	if s.items.Valid {
+		items := s.items.Int64
+		result.ItemsProcessed = &items
+	}
+	result.RunID = s.runID.String
+	if s.shard.Valid {
+		shard := int(s.shard.Int64)
+		result.Shard = &shard
+	}
+	result.Shards = int(s.shards.Int64)
+}
+
+// sourceArgs returns the values of resultSourceColumns for result
+func sourceArgs(result *JobResult) []interface{} {
+	var receivedAt *time.Time
+	if result.ReceivedAt != nil {
+		utc := result.ReceivedAt.UTC()
+		receivedAt = &utc
+	}
+	var statusRuleID *int
+	if result.StatusRuleID != 0 {
+		statusRuleID = &result.StatusRuleID
+	}
+	var runID *string
+	if result.RunID != "" {
+		runID = &result.RunID
+	}
+	var shards *int
+	if result.Shards != 0 {
+		shards = &result.Shards
+	}
+	return []interface{}{result.SourceIP, result.UserAgent, result.ClientVersion, receivedAt, result.ClockSkew, result.ClockSkewed, result.Source,
+		result.ExitCode, result.ReportedStatus, statusRuleID, result.Test, result.ItemsProcessed, runID, result.Shard, shards}
+}
+
+// HostClockSkew is how far a host's clock was off when it last submitted
+// a timestamped result
+type HostClockSkew struct {
+	Host      string  `db:"host"`
+	ClockSkew float64 `db:"clock_skew"` // Seconds ahead of the server
+}
+
+// LatestClockSkews returns the clock skew of each host's latest timestamped
+// result
+func (s *JobResultStore) LatestClockSkews() ([]*HostClockSkew, error) {
+	query := `
+		SELECT host, clock_skew FROM job_results
+		WHERE id IN (SELECT MAX(id) FROM job_results WHERE clock_skew IS NOT NULL GROUP BY host)
+		ORDER BY host
+	`
+
+	var skews []*HostClockSkew
+	if err := s.db.SelectContext(s.context(), &skews, query); err != nil {
+		return nil, fmt.Errorf("failed to get clock skews: %w", err)
+	}
+	return skews, nil
+}
+
+// JobThroughput is the number of items a counter job's runs processed
+type JobThroughput struct {
+	JobName      string `db:"job_name"`
+	Host         string `db:"host"`
+	Total        int64  `db:"total"`         // Over the stored results
+	Last         int64  `db:"last"`          // By the latest run
+	LastDuration int    `db:"last_duration"` // Seconds the latest run took
+}
+
+// Throughputs returns the items processed by each job whose results report
+// them. Test results are left out.
+func (s *JobResultStore) Throughputs() ([]*JobThroughput, error) {
+	query := `
+		SELECT r.job_name, r.host, t.total, r.items_processed AS last, COALESCE(r.duration, 0) AS last_duration
+		FROM job_results r
+		JOIN (
+			SELECT SUM(items_processed) AS total, MAX(id) AS last_id FROM job_results
+			WHERE items_processed IS NOT NULL AND NOT test
+			GROUP BY job_name, host
+		) t ON r.id = t.last_id
+		ORDER BY r.job_name, r.host
+	`
+
+	var throughputs []*JobThroughput
+	if err := s.db.SelectContext(s.context(), &throughputs, query); err != nil {
+		return nil, fmt.Errorf("failed to get job throughputs: %w", err)
+	}
+	return throughputs, nil
+}
@@ -0,0 +1,321 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Result statuses besides "success" and "failure"
+const (
+	ResultStarted = "started" // Submitted when a run starts; not stored as a result
+	ResultLost    = "lost"    // Recorded when a started run never reported its result
+)
+
+// lostRunSamples is how many recent timed results a job's expected
+// duration is averaged over
+const lostRunSamples = 10
+
+// JobStart is the run a job last reported starting, still without result
+type JobStart struct {
+	JobName     string    `db:"job_name"`
+	Host        string    `db:"host"`
+	StartedAt   time.Time `db:"started_at"`
+	RunID       string    `db:"run_id"`      // Empty when the start named no run
+	Overlapping bool      `db:"overlapping"` // The run started while a previous run was still open
+}
+
+// JobOverlap is a run that started while a previous run of the job was
+// still open
+type JobOverlap struct {
+	ID                int       `json:"id" db:"id"`
+	JobName           string    `json:"job_name" db:"job_name"`
+	Host              string    `json:"host" db:"host"`
+	RunID             string    `json:"run_id,omitempty" db:"run_id"`
+	StartedAt         time.Time `json:"started_at" db:"started_at"`
+	PreviousRunID     string    `json:"previous_run_id,omitempty" db:"previous_run_id"`
+	PreviousStartedAt time.Time `json:"previous_started_at" db:"previous_started_at"`
+}
+
+// RecordStart records that a run of the job started at the given time. It
+// replaces the job's previous start, whose result may still arrive. When
+// the previous start is still open and belongs to another run, the new run
+// overlaps it: the overlap is recorded and returned, else it returns nil.
+// Starts of the same run, such as the shards of a fan-out run, do not
+// overlap. The open start is locked until the new one replaces it, so
+// concurrent starts each see the one before.
+func (s *JobResultStore) RecordStart(jobName, host, runID string, startedAt time.Time) (*JobOverlap, error) {
+	tx, err := s.db.BeginTxx(s.context(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if dialectOf(tx) == DriverSQLite {
+		// Take the write lock before reading: a transaction that reads
+		// first fails with SQLITE_BUSY when another wrote in the meantime
+		query := `UPDATE job_starts SET run_id = run_id WHERE job_name = ? AND host = ?`
+		if _, err := tx.ExecContext(s.context(), query, jobName, host); err != nil {
+			return nil, fmt.Errorf("failed to lock job start: %w", err)
+		}
+	}
+	open, err := openStart(s.context(), tx, jobName, host, forUpdate(tx))
+	if err != nil {
+		return nil, err
+	}
+
+	var overlap *JobOverlap
+	if open != nil && !open.StartedAt.After(startedAt) && (runID == "" || open.RunID != runID) {
+		overlap = &JobOverlap{
+			JobName:           jobName,
+			Host:              host,
+			RunID:             runID,
+			StartedAt:         startedAt.UTC(),
+			PreviousRunID:     open.RunID,
+			PreviousStartedAt: open.StartedAt.UTC(),
+		}
+		query := `
+			INSERT INTO job_overlaps (job_name, host, run_id, started_at, previous_run_id, previous_started_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`
+		id, err := insertID(s.context(), tx, query, overlap.JobName, overlap.Host, overlap.RunID, overlap.StartedAt, overlap.PreviousRunID, overlap.PreviousStartedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record overlapping run: %w", err)
+		}
+		overlap.ID = int(id)
+	}
+	// A start of the open run keeps its overlap
+	overlapping := overlap != nil || open != nil && open.Overlapping && runID != "" && open.RunID == runID
+
+	query := `
+		INSERT INTO job_starts (job_name, host, started_at, run_id, overlapping) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (job_name, host) DO UPDATE SET started_at = excluded.started_at, run_id = excluded.run_id, overlapping = excluded.overlapping
+	`
+	if dialectOf(s.db) == DriverMySQL {
+		query = `
+			INSERT INTO job_starts (job_name, host, started_at, run_id, overlapping) VALUES (?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE started_at = VALUES(started_at), run_id = VALUES(run_id), overlapping = VALUES(overlapping)
+		`
+	}
+
+	if _, err := tx.ExecContext(s.context(), tx.Rebind(query), jobName, host, startedAt.UTC(), runID, overlapping); err != nil {
+		return nil, fmt.Errorf("failed to record job start: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit job start: %w", err)
+	}
+	return overlap, nil
+}
+
+// OpenStart returns the job's start still without result, or nil when it
+// has no open run
+func (s *JobResultStore) OpenStart(jobName, host string) (*JobStart, error) {
+	return openStart(s.context(), s.db, jobName, host, "")
+}
+
+// openStart returns the job's open start, read with the clause lock, on a
+// database or in a transaction
+func openStart(ctx context.Context, db getter, jobName, host, lock string) (*JobStart, error) {
+	query := `SELECT job_name, host, started_at, run_id, overlapping FROM job_starts WHERE job_name = ? AND host = ?` + lock
+
+	start := &JobStart{}
+	if err := db.GetContext(ctx, start, db.Rebind(query), jobName, host); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get job start: %w", err)
+	}
+	return start, nil
+}
+
+// OverlapCounts returns the number of overlapping runs recorded for each job
+func (s *JobResultStore) OverlapCounts() (map[JobRef]int64, error) {
+	query := `SELECT job_name, host, COUNT(*) FROM job_overlaps GROUP BY job_name, host`
+
+	rows, err := s.db.QueryContext(s.context(), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count overlapping runs: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[JobRef]int64)
+	for rows.Next() {
+		var ref JobRef
+		var count int64
+		if err := rows.Scan(&ref.Name, &ref.Host, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan overlapping runs: %w", err)
+		}
+		counts[ref] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// ListOverlaps returns the job's latest overlapping runs, most recent first
+func (s *JobResultStore) ListOverlaps(jobName, host string, limit int) ([]*JobOverlap, error) {
+	query := `
+		SELECT id, job_name, host, run_id, started_at, previous_run_id, previous_started_at
+		FROM job_overlaps WHERE job_name = ? AND host = ?
+		ORDER BY id DESC LIMIT ?
+	`
+
+	overlaps := []*JobOverlap{}
+	if err := s.db.SelectContext(s.context(), &overlaps, s.db.Rebind(query), jobName, host, limit); err != nil {
+		return nil, fmt.Errorf("failed to list overlapping runs: %w", err)
+	}
+	return overlaps, nil
+}
+
+// finishStart forgets the job's start once a result at or after it is
+// recorded in tx. A result of another run leaves the start of a run open.
+func (s *JobResultStore) finishStart(tx *sqlx.Tx, result *JobResult) error {
+	query := `
+		DELETE FROM job_starts
+		WHERE job_name = ? AND host = ? AND started_at <= ? AND (? = '' OR run_id = '' OR run_id = ?)
+	`
+
+	if _, err := tx.ExecContext(s.context(), tx.Rebind(query), result.JobName, result.Host, result.Timestamp.UTC(), result.RunID, result.RunID); err != nil {
+		return fmt.Errorf("failed to finish job start: %w", err)
+	}
+	return nil
+}
+
+// runStart returns when the run of result started: its recorded start, or
+// the start of its earliest result, whichever is first. The shard of a
+// fan-out run only counts its own earlier results, so each shard keeps its
+// own duration. It is zero when nothing of the run was recorded yet. Test
+// results are left out.
+func (s *JobResultStore) runStart(result *JobResult) (time.Time, error) {
+	var starts []time.Time
+	query := `
+		SELECT timestamp, duration FROM job_results
+		WHERE job_name = ? AND host = ? AND run_id = ? AND NOT test
+	`
+	args := []interface{}{result.JobName, result.Host, result.RunID}
+	if result.Shard != nil {
+		query += " AND shard = ?"
+		args = append(args, *result.Shard)
+	} else {
+		startQuery := `SELECT started_at FROM job_starts WHERE job_name = ? AND host = ? AND run_id = ?`
+		if err := s.db.SelectContext(s.context(), &starts, s.db.Rebind(startQuery), result.JobName, result.Host, result.RunID); err != nil {
+			return time.Time{}, fmt.Errorf("failed to get run start: %w", err)
+		}
+	}
+
+	rows, err := s.db.QueryContext(s.context(), s.db.Rebind(query), args...)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get run results: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var timestamp time.Time
+		var duration sql.NullInt64
+		if err := rows.Scan(&timestamp, &duration); err != nil {
+			return time.Time{}, fmt.Errorf("failed to scan run result: %w", err)
+		}
+		starts = append(starts, timestamp.Add(-time.Duration(duration.Int64)*time.Second))
+	}
+	if err := rows.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	var start time.Time
+	for _, candidate := range starts {
+		if start.IsZero() || candidate.Before(start) {
+			start = candidate
+		}
+	}
+	return start, nil
+}
+
+// applyRunDuration extends the duration of a result of a run with earlier
+// records to the whole run, from its start to the result, so retried runs
+// report how long they took in all
+func (s *JobResultStore) applyRunDuration(result *JobResult) error {
+	if result.RunID == "" || result.Test {
+		return nil
+	}
+
+	start, err := s.runStart(result)
+	if err != nil || start.IsZero() {
+		return err
+	}
+	if duration := int(result.Timestamp.Sub(start) / time.Second); duration > result.Duration {
+		result.Duration = duration
+	}
+	return nil
+}
+
+// ExpectedDuration returns the average duration of the job's recent timed
+// results, or zero when none of them has a duration
+func (s *JobResultStore) ExpectedDuration(jobName, host string) (time.Duration, error) {
+	query := `
+		SELECT AVG(duration) FROM (
+			SELECT duration FROM job_results
+			WHERE job_name = ? AND host = ? AND status IN ('success', 'failure') AND duration > 0
+			ORDER BY timestamp DESC
+			LIMIT ?
+		) AS recent
+	`
+
+	var seconds sql.NullFloat64
+	if err := s.db.GetContext(s.context(), &seconds, s.db.Rebind(query), jobName, host, lostRunSamples); err != nil {
+		return 0, fmt.Errorf("failed to get expected duration: %w", err)
+	}
+	return time.Duration(seconds.Float64 * float64(time.Second)), nil
+}
+
+// RecordLostRuns records a lost result for each started run of a
+// registered job that has not reported its result within twice its
+// expected duration, or twice defaultDuration for jobs without timed
+// results. A lost run is distinct from a run that never started: the job
+// was seen starting, but its outcome is unknown.
+func (s *JobResultStore) RecordLostRuns(now time.Time, defaultDuration time.Duration) ([]*JobResult, error) {
+	query := `
+		SELECT s.job_name, s.host, s.started_at, s.run_id
+		FROM job_starts s
+		JOIN jobs j ON j.name = s.job_name AND j.host = s.host
+		ORDER BY s.started_at
+	`
+
+	var starts []*JobStart
+	if err := s.db.SelectContext(s.context(), &starts, query); err != nil {
+		return nil, fmt.Errorf("failed to list job starts: %w", err)
+	}
+
+	var lost []*JobResult
+	for _, start := range starts {
+		expected, err := s.ExpectedDuration(start.JobName, start.Host)
+		if err != nil {
+			return lost, err
+		}
+		if expected <= 0 {
+			expected = defaultDuration
+		}
+
+		deadline := 2 * expected
+		if now.Sub(start.StartedAt) < deadline {
+			continue
+		}
+
+		result := &JobResult{
+			JobName:   start.JobName,
+			Host:      start.Host,
+			Status:    ResultLost,
+			Duration:  int(now.Sub(start.StartedAt) / time.Second),
+			Output:    fmt.Sprintf("started at %s, but no result arrived within %s, twice the expected duration", start.StartedAt.UTC().Format(time.RFC3339), deadline.Round(time.Second)),
+			Timestamp: now,
+			RunID:     start.RunID,
+		}
+		if err := s.CreateJobResult(result); err != nil {
+			return lost, err
+		}
+		lost = append(lost, result)
+	}
+
+	return lost, nil
+}
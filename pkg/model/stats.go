@@ -0,0 +1,139 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JobStats are the columns of a job listing computed from the job and its
+// results
+type JobStats struct {
+	Owner        string   `json:"owner,omitempty"`         // As Job.Owner
+	LastDuration *int     `json:"last_duration,omitempty"` // Seconds the latest timed run took
+	SuccessRate  *float64 `json:"success_rate,omitempty"`  // Share of the results since the window start that succeeded, 0-1
+}
+
+// LastDurations returns the duration of each job's latest timed result, in
+// seconds. Test results are left out.
+func (s *JobResultStore) LastDurations() (map[JobRef]int, error) {
+	query := `
+		SELECT job_name, host, duration FROM job_results
+		WHERE id IN (SELECT MAX(id) FROM job_results WHERE duration > 0 AND NOT test GROUP BY job_name, host)
+	`
+
+	rows, err := s.db.QueryContext(s.context(), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last durations: %w", err)
+	}
+	defer rows.Close()
+
+	durations := make(map[JobRef]int)
+	for rows.Next() {
+		var ref JobRef
+		var duration int
+		if err := rows.Scan(&ref.Name, &ref.Host, &duration); err != nil {
+			return nil, fmt.Errorf("failed to scan last duration: %w", err)
+		}
+		durations[ref] = duration
+	}
+
+	return durations, rows.Err()
+}
+
+// DurationHistogram counts the timed runs of a job by duration
+type DurationHistogram struct {
+	Buckets []int64 // Runs that took at most each bound, cumulative like Prometheus buckets
+	Count   int64
+	Sum     int64 // Seconds
+}
+
+// DurationHistograms returns the histogram of each job's stored timed
+// results over the given ascending bounds, in seconds. Test results are
+// left out.
+func (s *JobResultStore) DurationHistograms(bounds []float64) (map[JobRef]*DurationHistogram, error) {
+	columns := make([]string, 0, len(bounds))
+	args := make([]interface{}, 0, len(bounds))
+	for _, bound := range bounds {
+		columns = append(columns, "SUM(CASE WHEN duration <= ? THEN 1 ELSE 0 END)")
+		args = append(args, bound)
+	}
+	query := "SELECT job_name, host, COUNT(*), SUM(duration)"
+	if len(columns) > 0 {
+		query += ", " + strings.Join(columns, ", ")
+	}
+	query += " FROM job_results WHERE duration > 0 AND NOT test GROUP BY job_name, host"
+
+	rows, err := s.db.QueryContext(s.context(), s.db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duration histograms: %w", err)
+	}
+	defer rows.Close()
+
+	histograms := make(map[JobRef]*DurationHistogram)
+	for rows.Next() {
+		var ref JobRef
+		histogram := &DurationHistogram{Buckets: make([]int64, len(bounds))}
+		dest := []interface{}{&ref.Name, &ref.Host, &histogram.Count, &histogram.Sum}
+		for i := range histogram.Buckets {
+			dest = append(dest, &histogram.Buckets[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan duration histogram: %w", err)
+		}
+		histograms[ref] = histogram
+	}
+
+	return histograms, rows.Err()
+}
+
+// AttachJobStats sets the stats of each job, with its success rate over the
+// results recorded since the given time
+func (s *JobResultStore) AttachJobStats(jobs []*Job, since time.Time) error {
+	durations, err := s.LastDurations()
+	if err != nil {
+		return err
+	}
+	counts, err := s.CountJobResultsSince(since)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		ref := JobRef{Name: job.Name, Host: job.Host}
+		stats := &JobStats{Owner: job.Owner()}
+		if duration, ok := durations[ref]; ok {
+			stats.LastDuration = &duration
+		}
+		if count := counts[ref]; count.Total > 0 {
+			rate := float64(count.Total-count.Failed) / float64(count.Total)
+			stats.SuccessRate = &rate
+		}
+		job.Stats = stats
+	}
+	return nil
+}
+
+// GetLastResult returns the latest result of a job, as set by job
+// searches, or nil when it has none. Test results are left out.
+func (s *JobResultStore) GetLastResult(jobName, host string) (*LastResult, error) {
+	query := `
+		SELECT status, duration, timestamp FROM job_results
+		WHERE job_name = ? AND host = ? AND NOT test
+		ORDER BY timestamp DESC, id DESC
+		LIMIT 1
+	`
+
+	var last LastResult
+	var duration sql.NullInt64
+	err := s.db.QueryRowContext(s.context(), s.db.Rebind(query), jobName, host).Scan(&last.Status, &duration, &last.FinishedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last result: %w", err)
+	}
+	last.Duration = int(duration.Int64)
+	return &last, nil
+}
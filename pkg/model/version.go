@@ -0,0 +1,287 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrJobVersionNotFound is returned for an unknown version of a job
+var ErrJobVersionNotFound = errors.New("job version not found")
+
+// JobConfig is the part of a job its owners configure, as kept in its
+// version history. The API key, last report and snooze are not part of it.
+type JobConfig struct {
+	Name                      string            `json:"job_name"`
+	Host                      string            `json:"host"`
+	AutomaticFailureThreshold int               `json:"automatic_failure_threshold"`
+	ThresholdInherited        bool              `json:"threshold_inherited"`
+	Labels                    map[string]string `json:"labels"`
+	AllowedCIDRs              []string          `json:"allowed_cidrs,omitempty"`
+	Description               string            `json:"description,omitempty"`
+	Status                    string            `json:"status"`
+	Type                      string            `json:"type"`
+	ThroughputFloor           int64             `json:"throughput_floor,omitempty"`
+	Schedule                  string            `json:"schedule,omitempty"`
+	ShardPolicy               string            `json:"shard_policy,omitempty"`
+	MinInterval               int               `json:"min_interval,omitempty"`
+}
+
+// Config returns the job's configuration
+func (j *Job) Config() JobConfig {
+	return JobConfig{
+		Name:                      j.Name,
+		Host:                      j.Host,
+		AutomaticFailureThreshold: j.AutomaticFailureThreshold,
+		ThresholdInherited:        j.ThresholdInherited,
+		Labels:                    j.Labels,
+		AllowedCIDRs:              j.AllowedCIDRs,
+		Description:               j.Description,
+		Status:                    j.Status,
+		Type:                      j.Type,
+		ThroughputFloor:           j.ThroughputFloor,
+		Schedule:                  j.Schedule,
+		ShardPolicy:               j.ShardPolicy,
+		MinInterval:               j.MinInterval,
+	}
+}
+
+// ApplyConfig sets the job's configuration to config
+func (j *Job) ApplyConfig(config JobConfig) {
+	j.Name = config.Name
+	j.Host = config.Host
+	j.AutomaticFailureThreshold = config.AutomaticFailureThreshold
+	j.ThresholdInherited = config.ThresholdInherited
+	j.Labels = config.Labels
+	j.AllowedCIDRs = config.AllowedCIDRs
+	j.Description = config.Description
+	j.Status = config.Status
+	j.Type = config.Type
+	j.ThroughputFloor = config.ThroughputFloor
+	j.Schedule = config.Schedule
+	j.ShardPolicy = config.ShardPolicy
+	j.MinInterval = config.MinInterval
+}
+
+// JobVersion is a configuration a job had, or has for its latest version
+type JobVersion struct {
+	Version    int           `json:"version"` // From 1, the configuration the job was created with
+	Config     JobConfig     `json:"config"`
+	CreatedAt  time.Time     `json:"created_at"`            // When the job took this configuration
+	ReplacedAt *time.Time    `json:"replaced_at,omitempty"` // Unset on the current version
+	Changes    []FieldChange `json:"changes,omitempty"`     // From the previous version
+}
+
+// Current reports whether the version is the job's current configuration
+func (v *JobVersion) Current() bool {
+	return v.ReplacedAt == nil
+}
+
+// FieldChange is a field of a job's configuration that differs between two
+// versions, with its values as displayed
+type FieldChange struct {
+	Field string `json:"field"` // As named in the API, e.g. "automatic_failure_threshold"
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// DiffJobConfigs returns the fields that changed from old to new
+func DiffJobConfigs(old, new JobConfig) []FieldChange {
+	oldFields, newFields := old.fields(), new.fields()
+	var changes []FieldChange
+	for i, field := range oldFields {
+		if field[1] != newFields[i][1] {
+			changes = append(changes, FieldChange{Field: field[0], Old: field[1], New: newFields[i][1]})
+		}
+	}
+	return changes
+}
+
+// fields returns the names and displayed values of the configuration's
+// fields. Empty and missing labels or sources display alike.
+func (c JobConfig) fields() [][2]string {
+	labels := make([]string, 0, len(c.Labels))
+	for key, value := range c.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+
+	return [][2]string{
+		{"job_name", c.Name},
+		{"host", c.Host},
+		{"automatic_failure_threshold", strconv.Itoa(c.AutomaticFailureThreshold)},
+		{"threshold_inherited", strconv.FormatBool(c.ThresholdInherited)},
+		{"labels", strings.Join(labels, ", ")},
+		{"allowed_cidrs", strings.Join(c.AllowedCIDRs, ", ")},
+		{"description", c.Description},
+		{"status", c.Status},
+		{"type", c.Type},
+		{"throughput_floor", strconv.FormatInt(c.ThroughputFloor, 10)},
+		{"schedule", c.Schedule},
+		{"shard_policy", c.ShardPolicy},
+		{"min_interval", strconv.Itoa(c.MinInterval)},
+	}
+}
+
+// recordJobVersion keeps the configuration job had before its update, in
+// the update's transaction, unless the update leaves it unchanged. It
+// returns that configuration.
+func recordJobVersion(tx *sqlx.Tx, job *Job) (*JobConfig, error) {
+	var row struct {
+		Name                      string    `db:"name"`
+		Host                      string    `db:"host"`
+		AutomaticFailureThreshold int       `db:"automatic_failure_threshold"`
+		ThresholdInherited        bool      `db:"threshold_inherited"`
+		Labels                    string    `db:"labels"`
+		AllowedCIDRs              string    `db:"allowed_cidrs"`
+		Description               string    `db:"description"`
+		Status                    string    `db:"status"`
+		Type                      string    `db:"type"`
+		ThroughputFloor           int64     `db:"throughput_floor"`
+		Schedule                  string    `db:"schedule"`
+		ShardPolicy               string    `db:"shard_policy"`
+		MinInterval               int       `db:"min_interval"`
+		CreatedAt                 time.Time `db:"created_at"`
+	}
+	query := `
+		SELECT name, host, automatic_failure_threshold, threshold_inherited, labels, allowed_cidrs, description, status, type, throughput_floor, schedule, shard_policy, min_interval, created_at
+		FROM jobs WHERE id = ?
+	`
+	if err := tx.Get(&row, tx.Rebind(query), job.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("job not found with ID: %d", job.ID)
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	previous := &JobConfig{
+		Name:                      row.Name,
+		Host:                      row.Host,
+		AutomaticFailureThreshold: row.AutomaticFailureThreshold,
+		ThresholdInherited:        row.ThresholdInherited,
+		Description:               row.Description,
+		Status:                    row.Status,
+		Type:                      row.Type,
+		ThroughputFloor:           row.ThroughputFloor,
+		Schedule:                  row.Schedule,
+		ShardPolicy:               row.ShardPolicy,
+		MinInterval:               row.MinInterval,
+	}
+	if err := json.Unmarshal([]byte(row.Labels), &previous.Labels); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal labels: %w", err)
+	}
+	if err := json.Unmarshal([]byte(row.AllowedCIDRs), &previous.AllowedCIDRs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal allowed CIDRs: %w", err)
+	}
+	if len(DiffJobConfigs(*previous, job.Config())) == 0 {
+		return previous, nil
+	}
+
+	config, err := json.Marshal(previous)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job version: %w", err)
+	}
+	// The previous configuration was taken when the version before it was
+	// replaced, or when the job was created
+	insert := `
+		INSERT INTO job_versions (job_id, version, config, created_at, replaced_at)
+		SELECT ?, COALESCE(MAX(version), 0) + 1, ?, COALESCE(MAX(replaced_at), ?), ?
+		FROM job_versions WHERE job_id = ?
+	`
+	if _, err := tx.Exec(tx.Rebind(insert), job.ID, string(config), row.CreatedAt, job.UpdatedAt, job.ID); err != nil {
+		return nil, fmt.Errorf("failed to record job version: %w", err)
+	}
+	return previous, nil
+}
+
+// ListJobVersions returns the versions of a job's configuration, newest
+// first: its current one, then those it replaced, each with the changes it
+// made to the one before
+func (s *JobStore) ListJobVersions(jobID int) ([]*JobVersion, error) {
+	job, err := s.GetJobByID(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT version, config, created_at, replaced_at FROM job_versions
+		WHERE job_id = ?
+		ORDER BY version
+	`
+	rows, err := s.db.QueryxContext(s.context(), s.db.Rebind(query), jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*JobVersion
+	for rows.Next() {
+		version := &JobVersion{}
+		var config string
+		var replacedAt time.Time
+		if err := rows.Scan(&version.Version, &config, &version.CreatedAt, &replacedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan job version: %w", err)
+		}
+		if err := json.Unmarshal([]byte(config), &version.Config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal job version: %w", err)
+		}
+		version.ReplacedAt = &replacedAt
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list job versions: %w", err)
+	}
+
+	current := &JobVersion{Version: 1, Config: job.Config(), CreatedAt: job.CreatedAt}
+	if len(versions) > 0 {
+		last := versions[len(versions)-1]
+		current.Version, current.CreatedAt = last.Version+1, *last.ReplacedAt
+	}
+	versions = append(versions, current)
+
+	for i := 1; i < len(versions); i++ {
+		versions[i].Changes = DiffJobConfigs(versions[i-1].Config, versions[i].Config)
+	}
+	for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+		versions[i], versions[j] = versions[j], versions[i]
+	}
+	return versions, nil
+}
+
+// RollbackJob restores the configuration a job had at one of its versions.
+// The configuration it replaces is kept as a new version, so a rollback can
+// itself be rolled back. A restored threshold that followed the defaults
+// follows their current value.
+func (s *JobStore) RollbackJob(jobID, version int, defaultFor DefaultThreshold) (*Job, error) {
+	versions, err := s.ListJobVersions(jobID)
+	if err != nil {
+		return nil, err
+	}
+	var target *JobVersion
+	for _, v := range versions {
+		if v.Version == version {
+			target = v
+		}
+	}
+	if target == nil {
+		return nil, ErrJobVersionNotFound
+	}
+
+	job, err := s.GetJobByID(jobID)
+	if err != nil {
+		return nil, err
+	}
+	job.ApplyConfig(target.Config)
+	job.ApplyDefaultThreshold(defaultFor)
+	if err := s.UpdateJobByID(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
@@ -0,0 +1,151 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+)
+
+// defaultSMTPPort is the mail submission port
+const defaultSMTPPort = 587
+
+// Channel sends notifications to a destination
+type Channel interface {
+	Send(ctx context.Context, notification *Notification) error
+}
+
+// NewChannel creates the channel of a configuration
+func NewChannel(cfg *config.NotifierChannel) (Channel, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	switch cfg.Type {
+	case "webhook":
+		return &webhookChannel{client: client, url: cfg.URL, headers: cfg.Headers}, nil
+	case "slack":
+		return &slackChannel{client: client, url: cfg.URL}, nil
+	case "email":
+		return &emailChannel{config: cfg}, nil
+	default:
+		return nil, fmt.Errorf("unsupported notifier channel type: %s", cfg.Type)
+	}
+}
+
+// webhookChannel posts notifications as JSON
+type webhookChannel struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+}
+
+// Send posts the notification
+func (c *webhookChannel) Send(ctx context.Context, notification *Notification) error {
+	return post(ctx, c.client, c.url, c.headers, notification)
+}
+
+// slackChannel posts notifications to a Slack incoming webhook
+type slackChannel struct {
+	client *http.Client
+	url    string
+}
+
+// Send posts the notification as a Slack message
+func (c *slackChannel) Send(ctx context.Context, notification *Notification) error {
+	text := "*" + notification.Subject() + "*\n" + notification.details()
+	if notification.URL != "" {
+		text += fmt.Sprintf("\n<%s|View job>", notification.URL)
+	}
+	return post(ctx, c.client, c.url, nil, map[string]string{"text": text})
+}
+
+// emailChannel mails notifications through an SMTP server
+type emailChannel struct {
+	config *config.NotifierChannel
+}
+
+// Send mails the notification. The connection is upgraded with STARTTLS
+// when the server offers it.
+func (c *emailChannel) Send(ctx context.Context, notification *Notification) error {
+	port := c.config.SMTPPort
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	addr := net.JoinHostPort(c.config.SMTPHost, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if c.config.Username != "" {
+		auth = smtp.PlainAuth("", c.config.Username, c.config.Password, c.config.SMTPHost)
+	}
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "From: %s\r\n", c.config.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(c.config.To, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", headerValue(notification.Subject()))
+	fmt.Fprintf(&message, "Date: %s\r\n", notification.Time.Format(time.RFC1123Z))
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	message.WriteString(strings.ReplaceAll(notification.Text(), "\n", "\r\n"))
+
+	// net/smtp has no context, so a cancelled send finishes in the background
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, c.config.From, c.config.To, []byte(message.String()))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// headerValue makes value safe to use in a mail header. Control characters
+// become spaces, so a line break in a job name cannot start a header of its
+// own, and non-ASCII text is encoded.
+func headerValue(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, value)
+	return mime.QEncoding.Encode("utf-8", value)
+}
+
+// post sends body as JSON. Non-2xx responses are returned as errors.
+func post(ctx context.Context, client *http.Client, url string, headers map[string]string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNoChannel is returned when redelivering to a channel that no longer
+// exists
+var ErrNoChannel = errors.New("no notifier channel")
+
+// namedChannel is a channel with the type its deliveries are recorded with
+type namedChannel struct {
+	Channel
+	kind string // e.g. "slack"
+}
+
+// Deliverer sends notifications to the configured channels and those
+// managed from the dashboard. Every attempt is recorded as a notification
+// delivery, so operators can check that notifications went out and send
+// failed ones again.
+type Deliverer struct {
+	configured    map[string]*namedChannel // Channels of the configuration, by lowercased name
+	channelStore  *model.ChannelStore
+	deliveryStore *model.NotificationStore
+}
+
+// NewDeliverer creates a deliverer for the channels of the notifier
+// configuration and those of channelStore
+func NewDeliverer(cfg *config.NotifierConfig, channelStore *model.ChannelStore, deliveryStore *model.NotificationStore) (*Deliverer, error) {
+	configured := make(map[string]*namedChannel, len(cfg.Channels))
+	for name, channelConfig := range cfg.Channels {
+		channelConfig := channelConfig
+		channel, err := NewChannel(&channelConfig)
+		if err != nil {
+			return nil, fmt.Errorf("notifier channel %q: %w", name, err)
+		}
+		configured[strings.ToLower(name)] = &namedChannel{Channel: channel, kind: channelConfig.Type}
+	}
+
+	return &Deliverer{
+		configured:    configured,
+		channelStore:  channelStore,
+		deliveryStore: deliveryStore,
+	}, nil
+}
+
+// loadChannels returns the configured channels and those managed from the
+// dashboard. A configured channel hides a managed one of the same name, and
+// invalid managed channels are skipped.
+func (d *Deliverer) loadChannels(ctx context.Context) (map[string]*namedChannel, error) {
+	managed, err := d.channelStore.WithContext(ctx).ListChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	channels := make(map[string]*namedChannel, len(d.configured)+len(managed))
+	for name, channel := range d.configured {
+		channels[name] = channel
+	}
+	for _, managedChannel := range managed {
+		name := strings.ToLower(managedChannel.Name)
+		fields := logrus.Fields{"channel": managedChannel.Name}
+		if _, ok := channels[name]; ok {
+			logrus.WithFields(fields).Warn("notifier channel is also configured, ignoring the dashboard's")
+			continue
+		}
+
+		var channelConfig config.NotifierChannel
+		if err := json.Unmarshal([]byte(managedChannel.Settings), &channelConfig); err != nil {
+			logrus.WithError(err).WithFields(fields).Error("invalid notifier channel settings")
+			continue
+		}
+		channel, err := NewChannel(&channelConfig)
+		if err != nil {
+			logrus.WithError(err).WithFields(fields).Error("invalid notifier channel")
+			continue
+		}
+		channels[name] = &namedChannel{Channel: channel, kind: channelConfig.Type}
+	}
+	return channels, nil
+}
+
+// Redeliver sends the notification of a recorded delivery again, with the
+// current settings of its channel. The new attempt is recorded and
+// returned, also when it failed.
+func (d *Deliverer) Redeliver(ctx context.Context, id int) (*model.NotificationDelivery, error) {
+	previous, err := d.deliveryStore.WithContext(ctx).GetDelivery(id)
+	if err != nil {
+		return nil, err
+	}
+	var notification Notification
+	if err := json.Unmarshal([]byte(previous.Body), &notification); err != nil {
+		return nil, fmt.Errorf("delivery %d is not a notifier notification: %w", id, err)
+	}
+
+	channels, err := d.loadChannels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load channels: %w", err)
+	}
+	name := strings.ToLower(previous.Target)
+	channel, ok := channels[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrNoChannel, previous.Target)
+	}
+
+	return d.deliver(ctx, name, channel, &notification, &previous.ID), nil
+}
+
+// deliver sends the notification to the channel of the given name and
+// records the attempt, which is returned. Its body is the notification, so
+// it can be sent again.
+func (d *Deliverer) deliver(ctx context.Context, name string, channel *namedChannel, notification *Notification, redeliveryOf *int) *model.NotificationDelivery {
+	body, _ := json.Marshal(notification)
+	delivery := &model.NotificationDelivery{
+		JobName:      notification.JobName,
+		Host:         notification.Host,
+		Channel:      channel.kind,
+		Target:       name,
+		Subject:      notification.Subject(),
+		Body:         string(body),
+		RedeliveryOf: redeliveryOf,
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+	start := time.Now()
+	err := channel.Send(sendCtx, notification)
+	delivery.LatencyMs = time.Since(start).Milliseconds()
+	cancel()
+
+	if err != nil {
+		delivery.Status = model.DeliveryFailed
+		delivery.Error = err.Error()
+	} else {
+		delivery.Status = model.DeliveryDelivered
+	}
+
+	// The delivery is recorded even when the request that triggered it is
+	// done, as the notification may have gone out
+	if recordErr := d.deliveryStore.WithContext(context.WithoutCancel(ctx)).RecordDelivery(delivery); recordErr != nil {
+		logrus.WithError(recordErr).WithFields(logrus.Fields{
+			"job_name": delivery.JobName,
+			"host":     delivery.Host,
+			"channel":  name,
+		}).Warn("failed to record notification delivery")
+	}
+	return delivery
+}
@@ -0,0 +1,346 @@
+// Package notifier sends notifications to webhooks, Slack and email when
+// jobs start failing, miss their deadline or recover
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/metrics"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Notified events, as config.NotifierEvents
+const (
+	EventFailure        = "failure"         // The job's latest run failed, was lost or processed too few items, or its runs overlap
+	EventMissedDeadline = "missed_deadline" // The job did not report in time
+	EventRecovery       = "recovery"        // The job succeeds again
+)
+
+// sendTimeout bounds the delivery of a notification to a channel
+const sendTimeout = 30 * time.Second
+
+// Notification is a change of a job's state
+type Notification struct {
+	Event    string            `json:"event"`
+	JobID    int               `json:"job_id"`
+	JobName  string            `json:"job_name"`
+	Host     string            `json:"host"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Reason   string            `json:"reason"`        // The job's status reason, e.g. "lost"
+	Previous string            `json:"previous"`      // The job's status reason before the change
+	Time     time.Time         `json:"time"`          // When the change was seen
+	URL      string            `json:"url,omitempty"` // The job's dashboard page, with an external URL configured
+}
+
+// Subject summarizes the notification
+func (n *Notification) Subject() string {
+	switch n.Event {
+	case EventFailure:
+		return fmt.Sprintf("Cron job %s on %s is failing", n.JobName, n.Host)
+	case EventMissedDeadline:
+		return fmt.Sprintf("Cron job %s on %s missed its deadline", n.JobName, n.Host)
+	default:
+		return fmt.Sprintf("Cron job %s on %s recovered", n.JobName, n.Host)
+	}
+}
+
+// Text describes the notification in full
+func (n *Notification) Text() string {
+	text := n.Subject() + ".\n\n" + n.details() + "\n"
+	if n.URL != "" {
+		text += "\nDashboard: " + n.URL + "\n"
+	}
+	return text
+}
+
+// details lists the reasons and time of the change
+func (n *Notification) details() string {
+	return fmt.Sprintf("Status: %s (was %s)\nTime: %s", n.Reason, n.Previous, n.Time.UTC().Format(time.RFC3339))
+}
+
+// jobState is the state of a job as last checked
+type jobState struct {
+	event  string // EventFailure or EventMissedDeadline while failing, else empty
+	reason string
+}
+
+// Notifier watches the state of every job, and notifies the channels of
+// the matching routes when it changes. Jobs are checked periodically and
+// when they record a result. The first check only learns the jobs' states,
+// so jobs already failing at startup are not notified again. Channels
+// managed from the dashboard are reloaded at each periodic check, and
+// every send is recorded as a notification delivery.
+type Notifier struct {
+	config    *config.Config
+	deliverer *Deliverer
+	jobStore  *model.JobStore
+	collector *metrics.Collector
+
+	mu       sync.Mutex
+	channels map[string]*namedChannel // Configured and managed channels, by lowercased name
+	unknown  map[string]bool          // Channels named by routes that were found missing, to warn once
+	states   map[int]jobState         // By job ID
+	started  bool                     // The first check learned the jobs' states
+
+	queue     chan model.JobRef
+	done      chan struct{}
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewNotifier starts a notifier for the channels and routes of the
+// notifier configuration. Job states are evaluated by collector.
+func NewNotifier(cfg *config.Config, jobStore *model.JobStore, collector *metrics.Collector) (*Notifier, error) {
+	deliverer, err := NewDeliverer(&cfg.Notifier, model.NewChannelStore(jobStore.DB()), model.NewNotificationStore(jobStore.DB()))
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Notifier{
+		config:    cfg,
+		deliverer: deliverer,
+		jobStore:  jobStore,
+		collector: collector,
+		channels:  deliverer.configured,
+		unknown:   make(map[string]bool),
+		states:    make(map[int]jobState),
+		queue:     make(chan model.JobRef, 100),
+		done:      make(chan struct{}),
+		stop:      make(chan struct{}),
+	}
+
+	go n.run()
+	return n, nil
+}
+
+// Handle queues the job of a recorded result for checking, so failures are
+// notified without waiting for the next periodic check. Test results are
+// ignored. It matches model.ChangeHook.
+func (n *Notifier) Handle(event model.ChangeEvent) {
+	if event.Type != model.EventResultRecorded || event.Result.Test {
+		return
+	}
+
+	select {
+	case n.queue <- model.JobRef{Name: event.Result.JobName, Host: event.Result.Host}:
+	default:
+		logrus.WithFields(logrus.Fields{
+			"job_name": event.Result.JobName,
+			"host":     event.Result.Host,
+		}).Warn("notifier queue full, checking the job at the next interval")
+	}
+}
+
+// Close stops the notifier. Results handled after Close are dropped.
+func (n *Notifier) Close() {
+	n.closeOnce.Do(func() {
+		close(n.stop)
+		<-n.done
+	})
+}
+
+// run checks every job at each interval, and the queued jobs as they
+// come, until the notifier is closed
+func (n *Notifier) run() {
+	defer close(n.done)
+
+	ticker := time.NewTicker(time.Duration(n.config.Notifier.Interval) * time.Second)
+	defer ticker.Stop()
+
+	if err := n.Check(context.Background()); err != nil {
+		logrus.WithError(err).Error("notifier check failed")
+	}
+	for {
+		select {
+		case <-n.stop:
+			return
+		case ref := <-n.queue:
+			if err := n.checkJob(context.Background(), ref); err != nil {
+				logrus.WithError(err).WithFields(logrus.Fields{
+					"job_name": ref.Name,
+					"host":     ref.Host,
+				}).Error("notifier check failed")
+			}
+		case <-ticker.C:
+			if err := n.Check(context.Background()); err != nil {
+				logrus.WithError(err).Error("notifier check failed")
+			}
+		}
+	}
+}
+
+// Check reloads the channels, evaluates every job now and sends the
+// notifications of the jobs whose state changed since the previous check
+func (n *Notifier) Check(ctx context.Context) error {
+	channels, err := n.deliverer.loadChannels(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load channels: %w", err)
+	}
+	jobs, err := n.jobStore.WithContext(ctx).ListJobs(nil)
+	if err != nil {
+		return fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.channels = channels
+	n.warnUnknownChannels()
+
+	seen := make(map[int]bool, len(jobs))
+	for _, job := range jobs {
+		seen[job.ID] = true
+		n.update(ctx, job)
+	}
+	// Forget deleted jobs
+	for id := range n.states {
+		if !seen[id] {
+			delete(n.states, id)
+		}
+	}
+	n.started = true
+	return nil
+}
+
+// warnUnknownChannels logs, once each, the channels named by routes that
+// are neither configured nor managed. Must be called with mu held.
+func (n *Notifier) warnUnknownChannels() {
+	for i, route := range n.config.Notifier.Routes {
+		for _, name := range route.Channels {
+			name = strings.ToLower(name)
+			_, found := n.channels[name]
+			if !found && !n.unknown[name] {
+				logrus.WithFields(logrus.Fields{"route": i + 1, "channel": name}).Warn("notifier route names an unknown channel")
+			}
+			n.unknown[name] = !found
+		}
+	}
+}
+
+// checkJob evaluates a job now and sends its notifications if its state
+// changed. Jobs are only checked on their own once every job was.
+func (n *Notifier) checkJob(ctx context.Context, ref model.JobRef) error {
+	job, err := n.jobStore.WithContext(ctx).GetJob(ref.Name, ref.Host)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.started {
+		n.update(ctx, job)
+	}
+	return nil
+}
+
+// update records the job's current state and notifies its change. Jobs in
+// maintenance, paused or snoozed keep their previous state. Must be called
+// with mu held.
+func (n *Notifier) update(ctx context.Context, job *model.Job) {
+	_, reason := n.collector.JobStatus(ctx, job)
+
+	var event string
+	switch reason {
+	case "maintenance", "paused", "snoozed":
+		return
+	case "failure", "lost", "low_throughput", "overlapping":
+		event = EventFailure
+	case "missed_deadline":
+		event = EventMissedDeadline
+	}
+
+	previous, known := n.states[job.ID]
+	n.states[job.ID] = jobState{event: event, reason: reason}
+	if !n.started || event == previous.event {
+		return
+	}
+	if event == "" {
+		event = EventRecovery
+	}
+
+	previousReason := previous.reason
+	if !known {
+		previousReason = "success"
+	}
+	n.notify(ctx, job, &Notification{
+		Event:    event,
+		JobID:    job.ID,
+		JobName:  job.Name,
+		Host:     job.Host,
+		Labels:   job.Labels,
+		Reason:   reason,
+		Previous: previousReason,
+		Time:     time.Now().UTC(),
+		URL:      n.jobURL(job),
+	})
+}
+
+// notify sends the notification to the channels of the routes matching
+// the job and event, once per channel
+func (n *Notifier) notify(ctx context.Context, job *model.Job, notification *Notification) {
+	names := n.routeChannels(job, notification.Event)
+	for _, name := range names {
+		fields := logrus.Fields{
+			"job_name": job.Name,
+			"host":     job.Host,
+			"event":    notification.Event,
+			"channel":  name,
+		}
+
+		delivery := n.deliverer.deliver(ctx, name, n.channels[name], notification, nil)
+		if delivery.Status == model.DeliveryFailed {
+			logrus.WithFields(fields).WithField("error", delivery.Error).Error("failed to send notification")
+			continue
+		}
+		logrus.WithFields(fields).Info("notification sent")
+	}
+}
+
+// routeChannels returns the lowercased names of the channels of the routes
+// matching the job and event, in route order without duplicates
+func (n *Notifier) routeChannels(job *model.Job, event string) []string {
+	var names []string
+	for i := range n.config.Notifier.Routes {
+		route := &n.config.Notifier.Routes[i]
+		if !matches(route, job, event) {
+			continue
+		}
+		for _, name := range route.Channels {
+			name = strings.ToLower(name)
+			if _, ok := n.channels[name]; ok && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// jobURL returns the job's dashboard page, or empty without an external
+// URL or dashboard
+func (n *Notifier) jobURL(job *model.Job) string {
+	if n.config.Server.ExternalURL == "" || !n.config.Dashboard.Enabled {
+		return ""
+	}
+	return fmt.Sprintf("%s%s%s/jobs/%d", strings.TrimRight(n.config.Server.ExternalURL, "/"),
+		n.config.Server.BasePath, n.config.Dashboard.Path, job.ID)
+}
+
+// matches reports whether the route covers the job and event
+func matches(route *config.NotifierRoute, job *model.Job, event string) bool {
+	if len(route.Events) > 0 && !slices.Contains(route.Events, event) {
+		return false
+	}
+	for key, value := range route.Labels {
+		if job.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
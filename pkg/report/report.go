@@ -0,0 +1,99 @@
+// Package report submits job results to a cronmetrics server on behalf of
+// the jobs themselves
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/unixsocket"
+	"github.com/jaepetto/cron-exporter/pkg/util"
+)
+
+// Environment variables identifying the cronmetrics job a process reports
+// for, set by the generated launchd plists, crontab lines and systemd units
+const (
+	EnvServerURL = "CRONMETRICS_URL"
+	EnvJobName   = "CRONMETRICS_JOB_NAME"
+	EnvJobHost   = "CRONMETRICS_JOB_HOST"
+	EnvAPIKey    = "CRONMETRICS_API_KEY"
+)
+
+// EnvRunID sets the run ID of a wrapped command, so the attempts of a
+// retried run are grouped into one run
+const EnvRunID = "CRONMETRICS_RUN_ID"
+
+// Version is the cronmetrics version sent with submissions, so the server
+// can tell outdated agents apart
+var Version = func() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "unknown"
+}()
+
+// StatusError is a submission the server answered with an error status
+type StatusError struct {
+	Code   int
+	Status string
+	Detail string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("server answered %s: %s", e.Status, e.Detail)
+}
+
+// Temporary reports whether the submission may succeed when retried
+func (e *StatusError) Temporary() bool {
+	return e.Code == http.StatusTooManyRequests || e.Code >= 500
+}
+
+// Submit posts result once to the server at serverURL, which may be a
+// "unix:/path" socket. The result gets a nonce so it is accepted by servers
+// with replay protection. With sign, the body is signed with apiKey instead
+// of sending the key.
+func Submit(httpClient *http.Client, serverURL, apiKey string, sign bool, result *model.JobResult) error {
+	if result.Nonce == "" {
+		nonce, err := util.GenerateNonce()
+		if err != nil {
+			return err
+		}
+		result.Nonce = nonce
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	client, baseURL := unixsocket.Client(serverURL, httpClient)
+	req, err := http.NewRequest(http.MethodPost, baseURL+"/api/job-result", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cronmetrics/"+Version)
+	req.Header.Set(util.ClientVersionHeader, Version)
+	if sign {
+		req.Header.Set(util.SignatureHeader, util.SignPayload(apiKey, body))
+	} else {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &StatusError{Code: resp.StatusCode, Status: resp.Status, Detail: strings.TrimSpace(string(detail))}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Defaults of a Submitter
+const (
+	DefaultRetries    = 3
+	DefaultMinBackoff = time.Second
+	DefaultMaxBackoff = 15 * time.Second
+)
+
+// ErrCircuitOpen is returned, without contacting the server, while a
+// breaker's circuit is open
+var ErrCircuitOpen = errors.New("circuit open")
+
+// Submitter submits results, retrying failed submissions with exponential
+// backoff and jitter. Only network errors, 429 and 5xx answers are
+// retried; other errors are returned at once.
+type Submitter struct {
+	Client     *http.Client
+	Retries    int           // Attempts after the first one
+	MinBackoff time.Duration // Wait before the first retry, doubled by each retry
+	MaxBackoff time.Duration // Longest wait between attempts
+	Breaker    *Breaker      // Stops contacting unreachable servers, when set
+}
+
+// Submit posts result to the server at serverURL, see Submit
+func (s *Submitter) Submit(serverURL, apiKey string, sign bool, result *model.JobResult) error {
+	if s.Breaker != nil {
+		if err := s.Breaker.Allow(serverURL); err != nil {
+			return err
+		}
+	}
+
+	err := s.submit(serverURL, apiKey, sign, result)
+
+	if s.Breaker != nil {
+		if saveErr := s.Breaker.Record(serverURL, err == nil || !retryable(err)); saveErr != nil {
+			logrus.WithError(saveErr).Warn("failed to save the circuit breaker state")
+		}
+	}
+	return err
+}
+
+// submit posts result, retrying temporary failures
+func (s *Submitter) submit(serverURL, apiKey string, sign bool, result *model.JobResult) error {
+	backoff, maxBackoff := s.MinBackoff, s.MaxBackoff
+	if backoff <= 0 {
+		backoff = DefaultMinBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		// Attempts share the result's nonce, so a retry cannot record it twice
+		err := Submit(s.Client, serverURL, apiKey, sign, result)
+
+		var statusErr *StatusError
+		if attempt > 0 && errors.As(err, &statusErr) && statusErr.Code == http.StatusConflict {
+			// An earlier attempt was recorded but its answer was lost
+			return nil
+		}
+		if err == nil || !retryable(err) {
+			return err
+		}
+		if attempt >= s.Retries {
+			if attempt > 0 {
+				return fmt.Errorf("%w (gave up after %d attempts)", err, attempt+1)
+			}
+			return err
+		}
+
+		// Equal jitter: wait between half and all of the backoff
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"attempt":  attempt + 1,
+			"retry_in": wait.Round(time.Millisecond).String(),
+		}).Warn("failed to submit job result, retrying")
+		time.Sleep(wait)
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// retryable reports whether a failed submission may succeed when retried
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	return true
+}
+
+// Breaker opens a server's circuit after Threshold consecutive failed
+// submissions: for Cooldown, submissions fail at once instead of waiting
+// on the server. The next submission after the cooldown is let through,
+// and closes the circuit when it succeeds. With StatePath set, the state
+// is kept in that file so it carries over between short-lived commands.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+	StatePath string
+
+	mu     sync.Mutex
+	states map[string]*breakerState // By server URL, loaded on first use
+}
+
+// breakerState is the circuit of one server
+type breakerState struct {
+	Failures  int       `json:"failures"`
+	OpenUntil time.Time `json:"open_until,omitempty"`
+}
+
+// Allow returns ErrCircuitOpen while the circuit of serverURL is open
+func (b *Breaker) Allow(serverURL string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.load()[serverURL]
+	if state != nil && time.Now().Before(state.OpenUntil) {
+		return fmt.Errorf("%w: the last %d submissions to %s failed, next attempt after %s",
+			ErrCircuitOpen, state.Failures, serverURL, state.OpenUntil.Local().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Record counts a submission to serverURL, opening the circuit on the
+// Threshold-th consecutive failure
+func (b *Breaker) Record(serverURL string, ok bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	states := b.load()
+	state := states[serverURL]
+	switch {
+	case ok && state == nil:
+		return nil
+	case ok:
+		delete(states, serverURL)
+	default:
+		if state == nil {
+			state = &breakerState{}
+			states[serverURL] = state
+		}
+		state.Failures++
+		if state.Failures >= b.Threshold {
+			state.OpenUntil = time.Now().UTC().Add(b.Cooldown)
+		}
+	}
+	return b.save()
+}
+
+// load returns the circuits, reading the state file on first use. A
+// missing or unreadable file starts with all circuits closed.
+func (b *Breaker) load() map[string]*breakerState {
+	if b.states != nil {
+		return b.states
+	}
+	b.states = make(map[string]*breakerState)
+	if b.StatePath == "" {
+		return b.states
+	}
+	if data, err := os.ReadFile(b.StatePath); err == nil {
+		if err := json.Unmarshal(data, &b.states); err != nil {
+			b.states = make(map[string]*breakerState)
+		}
+	}
+	return b.states
+}
+
+// save writes the circuits atomically
+func (b *Breaker) save() error {
+	if b.StatePath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(b.states, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.StatePath), 0o700); err != nil {
+		return err
+	}
+
+	tmp := b.StatePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, b.StatePath)
+}
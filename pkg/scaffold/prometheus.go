@@ -0,0 +1,89 @@
+package scaffold
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scrape is the scrape of a server's metrics by Prometheus
+type Scrape struct {
+	ServerURL   string // Base URL of the server, with its base path
+	MetricsPath string
+	Auth        string // "none", "basic" or "bearer", as metrics.auth
+	Username    string
+	Password    string
+	BearerToken string
+}
+
+// scrapeConfig is a Prometheus scrape config, with the fields Scrape sets
+type scrapeConfig struct {
+	JobName       string         `yaml:"job_name"`
+	Scheme        string         `yaml:"scheme"`
+	MetricsPath   string         `yaml:"metrics_path"`
+	HonorLabels   bool           `yaml:"honor_labels"` // Keep job labels such as "instance" as they are
+	BasicAuth     *basicAuth     `yaml:"basic_auth,omitempty"`
+	Authorization *authorization `yaml:"authorization,omitempty"`
+	StaticConfigs []staticConfig `yaml:"static_configs"`
+}
+
+type basicAuth struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type authorization struct {
+	Type        string `yaml:"type"`
+	Credentials string `yaml:"credentials"`
+}
+
+type staticConfig struct {
+	Targets []string `yaml:"targets"`
+}
+
+// PrometheusScrapeConfig renders the scrape_configs entry scraping the
+// server's metrics, with its scrape credentials
+func PrometheusScrapeConfig(scrape *Scrape) (string, error) {
+	if strings.HasPrefix(scrape.ServerURL, "unix:") {
+		return "", fmt.Errorf("prometheus cannot scrape a unix socket: set the server's URL")
+	}
+	server, err := url.Parse(scrape.ServerURL)
+	if err != nil || server.Host == "" || (server.Scheme != "http" && server.Scheme != "https") {
+		return "", fmt.Errorf("invalid server URL %q: must be an http or https URL", scrape.ServerURL)
+	}
+
+	// Prometheus does not add default ports to targets
+	target := server.Host
+	if server.Port() == "" {
+		port := "80"
+		if server.Scheme == "https" {
+			port = "443"
+		}
+		target = net.JoinHostPort(server.Hostname(), port)
+	}
+
+	config := scrapeConfig{
+		JobName:       "cronmetrics",
+		Scheme:        server.Scheme,
+		MetricsPath:   strings.TrimRight(server.Path, "/") + scrape.MetricsPath,
+		HonorLabels:   true,
+		StaticConfigs: []staticConfig{{Targets: []string{target}}},
+	}
+	switch scrape.Auth {
+	case "basic":
+		config.BasicAuth = &basicAuth{Username: scrape.Username, Password: scrape.Password}
+	case "bearer":
+		config.Authorization = &authorization{Type: "Bearer", Credentials: scrape.BearerToken}
+	}
+
+	var rendered strings.Builder
+	encoder := yaml.NewEncoder(&rendered)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(map[string][]scrapeConfig{"scrape_configs": {config}}); err != nil {
+		return "", err
+	}
+	return rendered.String(), nil
+}
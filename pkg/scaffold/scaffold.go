@@ -0,0 +1,239 @@
+// Package scaffold generates the crontab line or systemd units running a
+// command under "cronmetrics wrap", which reports every run to the job,
+// and the Prometheus scrape config of the server
+package scaffold
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jaepetto/cron-exporter/pkg/report"
+)
+
+// Job is a command reporting its runs to a cronmetrics job
+type Job struct {
+	Name       string
+	Host       string
+	APIKey     string
+	ServerURL  string   // Base URL of the server, or a "unix:/path" socket
+	Schedule   string   // Crontab schedule, e.g. "30 2 * * 1-5" or "@daily"
+	Command    []string // Program and arguments
+	Executable string   // Path of cronmetrics on the job's host
+	Sign       bool     // Sign results with the API key instead of sending the key
+}
+
+// wrapArgs returns the cronmetrics invocation running the job's command
+func (j *Job) wrapArgs() []string {
+	args := []string{j.Executable, "wrap"}
+	if j.Sign {
+		args = append(args, "--sign")
+	}
+	args = append(append(args, "--"), j.Command...)
+	return args
+}
+
+// environment returns the variables identifying the job, in a stable order
+func (j *Job) environment() [][2]string {
+	return [][2]string{
+		{report.EnvServerURL, j.ServerURL},
+		{report.EnvJobName, j.Name},
+		{report.EnvJobHost, j.Host},
+		{report.EnvAPIKey, j.APIKey},
+	}
+}
+
+// macros are the crontab schedule shorthands, with their systemd equivalent
+var macros = map[string]string{
+	"@hourly":   "hourly",
+	"@daily":    "daily",
+	"@midnight": "daily",
+	"@weekly":   "weekly",
+	"@monthly":  "monthly",
+	"@yearly":   "yearly",
+	"@annually": "yearly",
+}
+
+// Crontab renders the crontab line of job. The job's variables are set on
+// the command line so they apply to this entry only.
+func Crontab(job *Job) (string, error) {
+	if _, err := convertSchedule(job.Schedule); err != nil {
+		return "", err
+	}
+
+	var line strings.Builder
+	line.WriteString(strings.Join(strings.Fields(job.Schedule), " "))
+	for _, variable := range job.environment() {
+		line.WriteString(" " + variable[0] + "=" + shellQuote(variable[1]))
+	}
+	for _, arg := range job.wrapArgs() {
+		line.WriteString(" " + shellQuote(arg))
+	}
+
+	// cron turns unescaped % into newlines
+	return strings.ReplaceAll(line.String(), "%", `\%`) + "\n", nil
+}
+
+// unitNameUnsafe matches the characters replaced in unit names
+var unitNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// UnitName returns the name of the systemd units of job, without suffix
+func UnitName(job *Job) string {
+	return "cronmetrics-" + unitNameUnsafe.ReplaceAllString(job.Name, "-") + "-" + unitNameUnsafe.ReplaceAllString(job.Host, "-")
+}
+
+// SystemdUnits renders the oneshot service running job and the timer
+// starting it on the job's schedule
+func SystemdUnits(job *Job) (service, timer string, err error) {
+	calendar, err := OnCalendar(job.Schedule)
+	if err != nil {
+		return "", "", err
+	}
+
+	var unit strings.Builder
+	fmt.Fprintf(&unit, "[Unit]\nDescription=%s@%s, reported to cronmetrics\n\n", job.Name, job.Host)
+	unit.WriteString("[Service]\nType=oneshot\n")
+	for _, variable := range job.environment() {
+		unit.WriteString("Environment=" + systemdQuote(variable[0]+"="+variable[1]) + "\n")
+	}
+	args := job.wrapArgs()
+	for i, arg := range args {
+		args[i] = systemdQuote(arg)
+	}
+	unit.WriteString("ExecStart=" + strings.Join(args, " ") + "\n")
+
+	timer = fmt.Sprintf("[Unit]\nDescription=Run %s@%s on schedule\n\n[Timer]\nOnCalendar=%s\nPersistent=true\n\n[Install]\nWantedBy=timers.target\n",
+		job.Name, job.Host, calendar)
+	return unit.String(), timer, nil
+}
+
+// weekdays are the systemd names of crontab weekdays; 0 and 7 are Sunday
+var weekdays = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// scheduleFields are the crontab fields in order, with their value range
+var scheduleFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day", 1, 31},
+	{"month", 1, 12},
+	{"weekday", 0, 7},
+}
+
+// OnCalendar converts a crontab schedule to a systemd calendar expression.
+// Fields may be *, */step, or comma-separated values and ranges.
+// Schedules restricting both the day and the weekday are rejected: cron
+// runs them when either matches, systemd only when both do.
+func OnCalendar(schedule string) (string, error) {
+	if calendar, ok := macros[strings.TrimSpace(schedule)]; ok {
+		return calendar, nil
+	}
+
+	converted, err := convertSchedule(schedule)
+	if err != nil {
+		return "", err
+	}
+	minute, hour, day, month, weekday := converted[0], converted[1], converted[2], converted[3], converted[4]
+
+	if day != "*" && weekday != "*" {
+		return "", fmt.Errorf("schedule %q restricts both the day and the weekday, which systemd cannot express", schedule)
+	}
+	calendar := fmt.Sprintf("*-%s-%s %s:%s:00", month, day, hour, minute)
+	if weekday != "*" {
+		calendar = weekday + " " + calendar
+	}
+	return calendar, nil
+}
+
+// convertSchedule validates a crontab schedule and returns its fields in
+// systemd form, or nil for a macro
+func convertSchedule(schedule string) ([]string, error) {
+	if _, ok := macros[strings.TrimSpace(schedule)]; ok {
+		return nil, nil
+	}
+
+	fields := strings.Fields(schedule)
+	if len(fields) != len(scheduleFields) {
+		return nil, fmt.Errorf("schedule must be a crontab macro or have 5 fields (minute hour day month weekday): %q", schedule)
+	}
+
+	converted := make([]string, len(fields))
+	for i, field := range fields {
+		value, err := convertField(field, i)
+		if err != nil {
+			return nil, err
+		}
+		converted[i] = value
+	}
+	return converted, nil
+}
+
+// convertField converts the crontab field at index to its systemd form
+func convertField(field string, index int) (string, error) {
+	def := scheduleFields[index]
+	isWeekday := def.name == "weekday"
+
+	if field == "*" {
+		return "*", nil
+	}
+	if step, ok := strings.CutPrefix(field, "*/"); ok {
+		n, err := strconv.Atoi(step)
+		if err != nil || n < 1 || isWeekday {
+			return "", fmt.Errorf("invalid %s %q", def.name, field)
+		}
+		return fmt.Sprintf("%d/%d", def.min, n), nil
+	}
+
+	parse := func(s string) (string, error) {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < def.min || n > def.max {
+			return "", fmt.Errorf("invalid %s %q: must be *, */step, or values and ranges between %d and %d", def.name, field, def.min, def.max)
+		}
+		if isWeekday {
+			return weekdays[n], nil
+		}
+		return strconv.Itoa(n), nil
+	}
+
+	var parts []string
+	for _, part := range strings.Split(field, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := parse(from)
+		if err != nil {
+			return "", err
+		}
+		if !isRange {
+			parts = append(parts, first)
+			continue
+		}
+		last, err := parse(to)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, first+".."+last)
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// shellQuote quotes s for sh when it contains special characters
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@%+=:,./_-", r))
+	}) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// systemdQuote quotes s for a unit file directive, escaping specifiers and
+// variable expansion
+func systemdQuote(s string) string {
+	s = strings.NewReplacer("%", "%%", "$", "$$").Replace(s)
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\;") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,99 @@
+package script
+
+import (
+	"fmt"
+
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"go.starlark.net/starlark"
+)
+
+// resultValue returns the dict a script receives for result. Scripts may
+// change its "status", "output" and "labels"; the other keys are for
+// reading only.
+func resultValue(job *model.Job, result *model.JobResult) *starlark.Dict {
+	value := starlark.NewDict(9)
+	value.SetKey(starlark.String("job_name"), starlark.String(result.JobName))
+	value.SetKey(starlark.String("host"), starlark.String(result.Host))
+	value.SetKey(starlark.String("status"), starlark.String(result.Status))
+	value.SetKey(starlark.String("output"), starlark.String(result.Output))
+	value.SetKey(starlark.String("duration"), starlark.MakeInt(result.Duration))
+	value.SetKey(starlark.String("exit_code"), starlark.None)
+	if result.ExitCode != nil {
+		value.SetKey(starlark.String("exit_code"), starlark.MakeInt(*result.ExitCode))
+	}
+	value.SetKey(starlark.String("items_processed"), starlark.None)
+	if result.ItemsProcessed != nil {
+		value.SetKey(starlark.String("items_processed"), starlark.MakeInt64(*result.ItemsProcessed))
+	}
+	value.SetKey(starlark.String("labels"), stringDict(result.Labels))
+
+	jobLabels := stringDict(job.Labels)
+	jobLabels.Freeze()
+	value.SetKey(starlark.String("job_labels"), jobLabels)
+	return value
+}
+
+// applyResultValue copies the fields a script may change from value back
+// into result
+func applyResultValue(value *starlark.Dict, result *model.JobResult) error {
+	status, err := stringField(value, "status")
+	if err != nil {
+		return err
+	}
+	if status != "success" && status != "failure" {
+		return fmt.Errorf("status must be 'success' or 'failure', got %q", status)
+	}
+	output, err := stringField(value, "output")
+	if err != nil {
+		return err
+	}
+
+	field, _, _ := value.Get(starlark.String("labels"))
+	dict, ok := field.(*starlark.Dict)
+	if !ok {
+		return fmt.Errorf("labels must be a dict, got %s", typeName(field))
+	}
+	labels := make(map[string]string, dict.Len())
+	for _, item := range dict.Items() {
+		key, keyOK := starlark.AsString(item[0])
+		value, valueOK := starlark.AsString(item[1])
+		if !keyOK || !valueOK {
+			return fmt.Errorf("labels must map strings to strings, got %s: %s", item[0].Type(), item[1].Type())
+		}
+		labels[key] = value
+	}
+
+	result.Status, result.Output = status, output
+	result.Labels = labels
+	if len(labels) == 0 {
+		result.Labels = nil
+	}
+	return nil
+}
+
+// stringField returns the string stored under key in value
+func stringField(value *starlark.Dict, key string) (string, error) {
+	field, _, _ := value.Get(starlark.String(key))
+	s, ok := starlark.AsString(field)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string, got %s", key, typeName(field))
+	}
+	return s, nil
+}
+
+// stringDict converts a map of strings to a Starlark dict
+func stringDict(m map[string]string) *starlark.Dict {
+	dict := starlark.NewDict(len(m))
+	for key, value := range m {
+		dict.SetKey(starlark.String(key), starlark.String(value))
+	}
+	return dict
+}
+
+// typeName names the type of a value a script left in the result
+func typeName(value starlark.Value) string {
+	if value == nil {
+		return "nothing"
+	}
+	return value.Type()
+}
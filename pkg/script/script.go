@@ -0,0 +1,196 @@
+package script
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime/metrics"
+	"sync/atomic"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+	"go.starlark.net/starlark"
+)
+
+// processFunction is the function a script defines to process a result
+const processFunction = "process"
+
+// memoryCheckInterval is how often the memory a running script allocated
+// is checked
+const memoryCheckInterval = 2 * time.Millisecond
+
+// allocatedBytesMetric counts the bytes the process allocated so far
+const allocatedBytesMetric = "/gc/heap/allocs:bytes"
+
+// ErrLimitExceeded is returned when a script is stopped for running past
+// its steps, memory or time
+var ErrLimitExceeded = errors.New("script limit exceeded")
+
+// Runner runs the Starlark scripts assigned to jobs on their incoming
+// results. Scripts are sandboxed: they cannot read files, reach the network
+// or keep state between results, and each run is stopped past the
+// configured steps, memory and time.
+type Runner struct {
+	config  *config.ScriptsConfig
+	scripts []assignedScript
+}
+
+// assignedScript is a loaded script and the jobs it runs for
+type assignedScript struct {
+	assignment *config.ScriptAssignment
+	process    *starlark.Function
+}
+
+// NewRunner loads the assigned scripts. It returns nil when no script is
+// assigned.
+func NewRunner(cfg *config.ScriptsConfig) (*Runner, error) {
+	if len(cfg.Assignments) == 0 {
+		return nil, nil
+	}
+
+	runner := &Runner{config: cfg}
+	loaded := make(map[string]*starlark.Function)
+	for i := range cfg.Assignments {
+		assignment := &cfg.Assignments[i]
+		process, ok := loaded[assignment.Path]
+		if !ok {
+			var err error
+			if process, err = runner.load(assignment.Path); err != nil {
+				return nil, err
+			}
+			loaded[assignment.Path] = process
+		}
+		runner.scripts = append(runner.scripts, assignedScript{assignment: assignment, process: process})
+	}
+	return runner, nil
+}
+
+// load runs the top level of the script at path and returns its process
+// function
+func (r *Runner) load(path string) (*starlark.Function, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+
+	var globals starlark.StringDict
+	err = r.run(path, func(thread *starlark.Thread) error {
+		var err error
+		globals, err = starlark.ExecFile(thread, path, src, nil)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load script %s: %w", path, err)
+	}
+
+	process, ok := globals[processFunction].(*starlark.Function)
+	if !ok || process.NumParams() != 1 {
+		return nil, fmt.Errorf("script %s must define %s(result)", path, processFunction)
+	}
+	return process, nil
+}
+
+// Process runs the scripts assigned to job on result, which they may
+// change. It stops at the first script that fails; the changes of the
+// scripts before it are kept. A script stopped at a limit fails with
+// ErrLimitExceeded.
+func (r *Runner) Process(job *model.Job, result *model.JobResult) error {
+	for _, script := range r.scripts {
+		if !matches(script.assignment, job) {
+			continue
+		}
+
+		value := resultValue(job, result)
+		err := r.run(script.assignment.Path, func(thread *starlark.Thread) error {
+			_, err := starlark.Call(thread, script.process, starlark.Tuple{value}, nil)
+			return err
+		})
+		if err == nil {
+			err = applyResultValue(value, result)
+		}
+		if err != nil {
+			return fmt.Errorf("script %s: %w", script.assignment.Path, err)
+		}
+	}
+	return nil
+}
+
+// run calls fn on a new thread, cancelling it once it exceeds the limits
+func (r *Runner) run(path string, fn func(thread *starlark.Thread) error) error {
+	var exceeded atomic.Bool
+	thread := &starlark.Thread{
+		Name: path,
+		Print: func(_ *starlark.Thread, msg string) {
+			logrus.WithField("script", path).Debug(msg)
+		},
+		OnMaxSteps: func(thread *starlark.Thread) {
+			exceeded.Store(true)
+			thread.Cancel("too many steps")
+		},
+	}
+	thread.SetMaxExecutionSteps(uint64(r.config.MaxSteps))
+
+	done := make(chan struct{})
+	defer close(done)
+	go r.watch(thread, done, &exceeded)
+
+	err := fn(thread)
+	if err != nil && exceeded.Load() {
+		return fmt.Errorf("%w: %v", ErrLimitExceeded, err)
+	}
+	return err
+}
+
+// watch cancels thread when it runs past the timeout or the allocations
+// of the process grow by more than the memory limit, and sets exceeded,
+// until done is closed
+func (r *Runner) watch(thread *starlark.Thread, done <-chan struct{}, exceeded *atomic.Bool) {
+	timeout := time.NewTimer(time.Duration(r.config.Timeout) * time.Second)
+	defer timeout.Stop()
+	ticker := time.NewTicker(memoryCheckInterval)
+	defer ticker.Stop()
+
+	limit := uint64(r.config.MaxMemory) << 20
+	start := allocatedBytes()
+	for {
+		select {
+		case <-done:
+			return
+		case <-timeout.C:
+			exceeded.Store(true)
+			thread.Cancel(fmt.Sprintf("timed out after %ds", r.config.Timeout))
+			return
+		case <-ticker.C:
+			if allocatedBytes()-start > limit {
+				exceeded.Store(true)
+				thread.Cancel(fmt.Sprintf("allocated more than %d MB", r.config.MaxMemory))
+				return
+			}
+		}
+	}
+}
+
+// allocatedBytes returns the bytes the process allocated so far
+func allocatedBytes() uint64 {
+	sample := []metrics.Sample{{Name: allocatedBytesMetric}}
+	metrics.Read(sample)
+	return sample[0].Value.Uint64()
+}
+
+// matches reports whether the assignment covers the job
+func matches(assignment *config.ScriptAssignment, job *model.Job) bool {
+	if assignment.JobName != "" && assignment.JobName != job.Name {
+		return false
+	}
+	if assignment.Host != "" && assignment.Host != job.Host {
+		return false
+	}
+	for key, value := range assignment.Labels {
+		if job.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
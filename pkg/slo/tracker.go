@@ -0,0 +1,208 @@
+// Package slo tracks the error budgets of service level objectives defined
+// over job results
+package slo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Tracker evaluates objectives against the recorded job results. Failed
+// results spend the error budget; jobs without results in a window do not.
+type Tracker struct {
+	config      *config.SLOConfig
+	jobStore    *model.JobStore
+	resultStore *model.JobResultStore
+	recoveries  *model.RecoveryCache // Incidents of the objectives' windows, for their MTTR
+
+	mu      sync.Mutex
+	burning map[string]bool // Objectives last reported as burning by Run
+}
+
+// NewTracker creates a tracker of the configured objectives. It returns
+// nil, which disables tracking, when no objective is configured.
+func NewTracker(cfg *config.SLOConfig, jobStore *model.JobStore, resultStore *model.JobResultStore) *Tracker {
+	if len(cfg.Objectives) == 0 {
+		return nil
+	}
+	return &Tracker{
+		config:      cfg,
+		jobStore:    jobStore,
+		resultStore: resultStore,
+		recoveries:  model.NewRecoveryCache(resultStore),
+		burning:     make(map[string]bool),
+	}
+}
+
+// Evaluate returns the status of every objective, in configuration order.
+// The database is queried with ctx.
+func (t *Tracker) Evaluate(ctx context.Context, now time.Time) ([]*model.SLOStatus, error) {
+	jobs, err := t.jobStore.WithContext(ctx).ListJobs(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	// Objectives sharing a window share its counts
+	counts := make(map[time.Duration]map[model.JobRef]model.ResultCounts)
+	countSince := func(window time.Duration) (map[model.JobRef]model.ResultCounts, error) {
+		if cached, ok := counts[window]; ok {
+			return cached, nil
+		}
+		windowCounts, err := t.resultStore.WithContext(ctx).CountJobResultsSince(now.Add(-window))
+		if err != nil {
+			return nil, err
+		}
+		counts[window] = windowCounts
+		return windowCounts, nil
+	}
+
+	burnWindow := time.Duration(t.config.BurnWindow) * time.Second
+	statuses := make([]*model.SLOStatus, 0, len(t.config.Objectives))
+	for _, objective := range t.config.Objectives {
+		window := time.Duration(objective.WindowDays) * 24 * time.Hour
+		windowCounts, err := countSince(window)
+		if err != nil {
+			return nil, err
+		}
+		recoveries, err := t.recoveries.RecoveriesSince(ctx, now, window)
+		if err != nil {
+			return nil, err
+		}
+		burnCounts, err := countSince(burnWindow)
+		if err != nil {
+			return nil, err
+		}
+
+		status := &model.SLOStatus{
+			Name:            objective.Name,
+			Target:          objective.Target,
+			WindowDays:      objective.WindowDays,
+			BudgetRemaining: 1,
+		}
+		var recent model.ResultCounts
+		var incidents []*model.Recovery
+		for _, job := range jobs {
+			if !matches(&objective, job) {
+				continue
+			}
+			ref := model.JobRef{Name: job.Name, Host: job.Host}
+			status.Jobs++
+			status.Total += windowCounts[ref].Total
+			status.Failed += windowCounts[ref].Failed
+			recent.Total += burnCounts[ref].Total
+			recent.Failed += burnCounts[ref].Failed
+			incidents = append(incidents, recoveries[ref]...)
+		}
+		if status.Recoveries = len(incidents); status.Recoveries > 0 {
+			mttr := model.MeanRecovery(incidents).Seconds()
+			status.MTTR = &mttr
+		}
+
+		allowed := 1 - objective.Target
+		if status.Total > 0 {
+			status.BudgetRemaining = 1 - float64(status.Failed)/float64(status.Total)/allowed
+		}
+		if recent.Total > 0 {
+			status.BurnRate = float64(recent.Failed) / float64(recent.Total) / allowed
+		}
+		status.Burning = status.BurnRate >= t.config.BurnRateWarning || (status.Total > 0 && status.BudgetRemaining <= 0)
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Burning returns the objectives currently burning their error budget
+func (t *Tracker) Burning(ctx context.Context, now time.Time) ([]*model.SLOStatus, error) {
+	statuses, err := t.Evaluate(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	burning := statuses[:0]
+	for _, status := range statuses {
+		if status.Burning {
+			burning = append(burning, status)
+		}
+	}
+	return burning, nil
+}
+
+// Run checks the objectives every configured interval until ctx is done.
+// An objective starting or stopping to burn is logged and passed to hook,
+// which may be nil, as an slo.burning or slo.recovered event.
+func (t *Tracker) Run(ctx context.Context, hook model.ChangeHook) {
+	ticker := time.NewTicker(time.Duration(t.config.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if err := t.check(time.Now().UTC(), hook); err != nil {
+			logrus.WithError(err).Error("SLO evaluation failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check evaluates the objectives and reports burn state changes
+func (t *Tracker) check(now time.Time, hook model.ChangeHook) error {
+	statuses, err := t.Evaluate(context.Background(), now)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, status := range statuses {
+		if status.Burning == t.burning[status.Name] {
+			continue
+		}
+		t.burning[status.Name] = status.Burning
+
+		fields := logrus.Fields{
+			"slo":                    status.Name,
+			"burn_rate":              status.BurnRate,
+			"error_budget_remaining": status.BudgetRemaining,
+		}
+		eventType := model.EventSLORecovered
+		if status.Burning {
+			eventType = model.EventSLOBurning
+			logrus.WithFields(fields).Warn("SLO is burning its error budget")
+		} else {
+			logrus.WithFields(fields).Info("SLO is no longer burning its error budget")
+		}
+
+		if hook != nil {
+			hook(model.ChangeEvent{Type: eventType, Time: now, SLO: status})
+		}
+	}
+	return nil
+}
+
+// matches reports whether the objective covers the job
+func matches(objective *config.SLOObjective, job *model.Job) bool {
+	if objective.JobName != "" && objective.JobName != job.Name {
+		return false
+	}
+	if objective.Host != "" && objective.Host != job.Host {
+		return false
+	}
+	for key, value := range objective.Labels {
+		if job.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
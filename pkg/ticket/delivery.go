@@ -0,0 +1,177 @@
+package ticket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNoTracker is returned when no tracker is configured for a team
+var ErrNoTracker = errors.New("no ticket tracker for team")
+
+// teamTracker is the tracker of a team and how deliveries name it
+type teamTracker struct {
+	Tracker
+	channel string // The provider, e.g. "jira"
+	target  string // The Jira project or GitHub repository
+}
+
+// Deliverer opens issues in the teams' trackers. Every attempt is recorded
+// as a notification delivery, so operators can check that issues went out
+// and deliver failed ones again.
+type Deliverer struct {
+	trackers      map[string]*teamTracker // By lowercased team name
+	ackStore      *model.AcknowledgementStore
+	deliveryStore *model.NotificationStore
+}
+
+// NewDeliverer creates a deliverer for the teams of the ticket configuration
+func NewDeliverer(cfg *config.TicketsConfig, ackStore *model.AcknowledgementStore, deliveryStore *model.NotificationStore) (*Deliverer, error) {
+	trackers := make(map[string]*teamTracker, len(cfg.Teams))
+	for team, trackerConfig := range cfg.Teams {
+		trackerConfig := trackerConfig
+		tracker, err := NewTracker(&trackerConfig)
+		if err != nil {
+			return nil, fmt.Errorf("tickets team %q: %w", team, err)
+		}
+
+		target := trackerConfig.Project
+		if trackerConfig.Provider == "github" {
+			target = trackerConfig.Repository
+		}
+		trackers[strings.ToLower(team)] = &teamTracker{Tracker: tracker, channel: trackerConfig.Provider, target: target}
+	}
+
+	return &Deliverer{
+		trackers:      trackers,
+		ackStore:      ackStore,
+		deliveryStore: deliveryStore,
+	}, nil
+}
+
+// tracker returns the tracker of team, else that of the default team, with
+// the team it belongs to
+func (d *Deliverer) tracker(team string) (string, *teamTracker, error) {
+	team = strings.ToLower(team)
+	if tracker, ok := d.trackers[team]; ok {
+		return team, tracker, nil
+	}
+	if tracker, ok := d.trackers[defaultTeam]; ok {
+		return defaultTeam, tracker, nil
+	}
+	return "", nil, fmt.Errorf("%w %q", ErrNoTracker, team)
+}
+
+// Deliver opens issue for job in the tracker of team and, once opened,
+// acknowledges the job's failures with the ticket. The attempt is
+// recorded and returned, also when it failed.
+func (d *Deliverer) Deliver(ctx context.Context, job *model.Job, team string, issue *Issue) (*model.NotificationDelivery, error) {
+	team, tracker, err := d.tracker(team)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &model.NotificationDelivery{
+		JobName: job.Name,
+		Host:    job.Host,
+		Channel: tracker.channel,
+		Team:    team,
+		Target:  tracker.target,
+		Subject: issue.Title,
+		Body:    issue.Body,
+	}
+	return delivery, d.send(ctx, tracker, delivery, true)
+}
+
+// DeliverTest opens a test issue for job in the tracker of team, so
+// operators can check that notifications reach it. Unlike Deliver, it
+// does not fall back to the default team nor acknowledge the job's
+// failures. The attempt is recorded and returned, also when it failed.
+func (d *Deliverer) DeliverTest(ctx context.Context, job *model.Job, team string) (*model.NotificationDelivery, error) {
+	tracker, ok := d.trackers[strings.ToLower(team)]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrNoTracker, team)
+	}
+
+	delivery := &model.NotificationDelivery{
+		JobName: job.Name,
+		Host:    job.Host,
+		Channel: tracker.channel,
+		Team:    strings.ToLower(team),
+		Target:  tracker.target,
+		Subject: fmt.Sprintf("[test] %s on %s", job.Name, job.Host),
+		Body: fmt.Sprintf("Test notification for job %s on %s, sent by a test submission. No action is needed.",
+			job.Name, job.Host),
+	}
+	return delivery, d.send(ctx, tracker, delivery, false)
+}
+
+// Redeliver opens the issue of a recorded delivery again, in the current
+// tracker of its team. The new attempt is recorded and returned, also when
+// it failed.
+func (d *Deliverer) Redeliver(ctx context.Context, id int) (*model.NotificationDelivery, error) {
+	previous, err := d.deliveryStore.WithContext(ctx).GetDelivery(id)
+	if err != nil {
+		return nil, err
+	}
+	team, tracker, err := d.tracker(previous.Team)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &model.NotificationDelivery{
+		JobName:      previous.JobName,
+		Host:         previous.Host,
+		Channel:      tracker.channel,
+		Team:         team,
+		Target:       tracker.target,
+		Subject:      previous.Subject,
+		Body:         previous.Body,
+		RedeliveryOf: &previous.ID,
+	}
+	return delivery, d.send(ctx, tracker, delivery, true)
+}
+
+// send opens the delivery's issue and records the attempt. With
+// acknowledge, the job's failures are acknowledged with the opened issue.
+func (d *Deliverer) send(ctx context.Context, tracker *teamTracker, delivery *model.NotificationDelivery, acknowledge bool) error {
+	start := time.Now()
+	ticket, err := tracker.CreateIssue(ctx, &Issue{Title: delivery.Subject, Body: delivery.Body})
+	delivery.LatencyMs = time.Since(start).Milliseconds()
+
+	if err != nil {
+		delivery.Status = model.DeliveryFailed
+		delivery.Error = err.Error()
+	} else {
+		delivery.Status = model.DeliveryDelivered
+		delivery.Reference = ticket.ID
+		delivery.URL = ticket.URL
+	}
+
+	// The delivery is recorded even when the request that triggered it is
+	// done, as the issue may have been opened
+	if recordErr := d.deliveryStore.WithContext(context.WithoutCancel(ctx)).RecordDelivery(delivery); recordErr != nil {
+		logrus.WithError(recordErr).WithFields(logrus.Fields{
+			"job_name": delivery.JobName,
+			"host":     delivery.Host,
+		}).Warn("failed to record notification delivery")
+	}
+	if err != nil || !acknowledge {
+		return err
+	}
+
+	ack := &model.Acknowledgement{
+		JobName:   delivery.JobName,
+		Host:      delivery.Host,
+		Author:    Author,
+		TicketID:  ticket.ID,
+		TicketURL: ticket.URL,
+	}
+	return d.ackStore.WithContext(context.WithoutCancel(ctx)).CreateAcknowledgement(ack)
+}
@@ -0,0 +1,244 @@
+package ticket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Author is the author of the acknowledgements recording created issues
+const Author = "tickets"
+
+// defaultTeam is the tracker of jobs without a configured team
+const defaultTeam = "default"
+
+// Caps on the runbook and output snippets included in issues, so the
+// issue stays readable and within tracker limits
+const (
+	maxIssueRunbookLines = 15   // First lines of the job description
+	maxIssueRunbookBytes = 2000 // Bytes of the job description
+	maxIssueOutputLines  = 40   // Last lines of the job output
+	maxIssueOutput       = 4000 // Bytes of the job output
+)
+
+// Filer opens an issue when a job reaches the configured number of
+// consecutive failures, then acknowledges the failures with the issue.
+// Failures already acknowledged when the threshold is reached do not open
+// an issue. Results are handled in the background, so recording a result
+// never waits on the tracker.
+type Filer struct {
+	config      *config.Config
+	deliverer   *Deliverer
+	jobStore    *model.JobStore
+	resultStore *model.JobResultStore
+	ackStore    *model.AcknowledgementStore
+
+	queue     chan *model.JobResult
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewFiler starts a filer for the teams of the ticket configuration
+func NewFiler(cfg *config.Config, jobStore *model.JobStore, resultStore *model.JobResultStore, ackStore *model.AcknowledgementStore) (*Filer, error) {
+	deliverer, err := NewDeliverer(&cfg.Tickets, ackStore, model.NewNotificationStore(jobStore.DB()))
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Filer{
+		config:      cfg,
+		deliverer:   deliverer,
+		jobStore:    jobStore,
+		resultStore: resultStore,
+		ackStore:    ackStore,
+		queue:       make(chan *model.JobResult, 100),
+		done:        make(chan struct{}),
+	}
+
+	go f.run()
+	return f, nil
+}
+
+// Handle queues failed results for checking. Test results never open an
+// issue. It matches model.ChangeHook.
+func (f *Filer) Handle(event model.ChangeEvent) {
+	if event.Type != model.EventResultRecorded || event.Result.Status != "failure" || event.Result.Test {
+		return
+	}
+
+	select {
+	case f.queue <- event.Result:
+	default:
+		logrus.WithFields(logrus.Fields{
+			"job_name": event.Result.JobName,
+			"host":     event.Result.Host,
+		}).Warn("ticket queue full, dropping failed result")
+	}
+}
+
+// Close checks the queued results and stops the filer. Results handled
+// after Close are dropped.
+func (f *Filer) Close() {
+	f.closeOnce.Do(func() {
+		close(f.queue)
+		<-f.done
+	})
+}
+
+// run checks queued results until the queue is closed
+func (f *Filer) run() {
+	defer close(f.done)
+
+	for result := range f.queue {
+		if err := f.check(result); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"job_name": result.JobName,
+				"host":     result.Host,
+			}).Error("failed to open ticket")
+		}
+	}
+}
+
+// check opens an issue for the result's job if its failures just reached
+// the threshold and nobody acknowledged them yet
+func (f *Filer) check(result *model.JobResult) error {
+	streak, err := f.resultStore.GetFailureStreak(result.JobName, result.Host)
+	if err != nil {
+		return err
+	}
+	if streak.Count != f.config.Tickets.FailureThreshold {
+		return nil
+	}
+
+	acknowledged, err := f.ackStore.IsAcknowledgedSince(result.JobName, result.Host, streak.Since)
+	if err != nil {
+		return err
+	}
+	if acknowledged {
+		return nil
+	}
+
+	job, err := f.jobStore.GetJob(result.JobName, result.Host)
+	if err != nil {
+		return err
+	}
+
+	team := job.Labels[f.config.Tickets.TeamLabel]
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	delivery, err := f.deliverer.Deliver(ctx, job, team, f.issue(job, streak, result))
+	if errors.Is(err, ErrNoTracker) {
+		logrus.WithFields(logrus.Fields{
+			"job_name": job.Name,
+			"host":     job.Host,
+			"team":     team,
+		}).Debug("no ticket tracker for team")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"job_name":  job.Name,
+		"host":      job.Host,
+		"ticket_id": delivery.Reference,
+	}).Info("ticket opened for failing job")
+	return nil
+}
+
+// issue describes the failures of a job
+func (f *Filer) issue(job *model.Job, streak *model.FailureStreak, last *model.JobResult) *Issue {
+	var body strings.Builder
+	fmt.Fprintf(&body, "Job %s on %s failed %d times in a row since %s.\n",
+		job.Name, job.Host, streak.Count, streak.Since.UTC().Format(time.RFC3339))
+
+	if f.config.Server.ExternalURL != "" && f.config.Dashboard.Enabled {
+		fmt.Fprintf(&body, "\nDashboard: %s%s%s/jobs/%d\n", strings.TrimRight(f.config.Server.ExternalURL, "/"),
+			f.config.Server.BasePath, f.config.Dashboard.Path, job.ID)
+	}
+
+	if runbook, truncated := firstLines(job.Description, maxIssueRunbookLines, maxIssueRunbookBytes); runbook != "" {
+		body.WriteString("\nRunbook:\n\n" + runbook + "\n")
+		if truncated {
+			body.WriteString("...\n")
+		}
+	}
+
+	if len(job.Labels) > 0 {
+		keys := make([]string, 0, len(job.Labels))
+		for key := range job.Labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		body.WriteString("\nLabels:\n")
+		for _, key := range keys {
+			fmt.Fprintf(&body, "- %s: %s\n", key, job.Labels[key])
+		}
+	}
+
+	// The end of the output usually holds the error
+	if output, truncated := lastLines(last.Output, maxIssueOutputLines, maxIssueOutput); output != "" {
+		body.WriteString("\nLast output:\n\n")
+		if truncated {
+			body.WriteString("...\n")
+		}
+		body.WriteString(output + "\n")
+	}
+
+	return &Issue{
+		Title: fmt.Sprintf("Cron job %s on %s is failing", job.Name, job.Host),
+		Body:  body.String(),
+	}
+}
+
+// firstLines returns the start of text, at most maxLines lines and maxBytes
+// bytes, and whether anything was cut
+func firstLines(text string, maxLines, maxBytes int) (string, bool) {
+	text = strings.TrimSpace(text)
+	lines := strings.Split(text, "\n")
+	truncated := len(lines) > maxLines
+	if truncated {
+		text = strings.Join(lines[:maxLines], "\n")
+	}
+
+	if len(text) > maxBytes {
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text, truncated = text[:cut], true
+	}
+	return text, truncated
+}
+
+// lastLines returns the end of text, at most maxLines lines and maxBytes
+// bytes, and whether anything was cut
+func lastLines(text string, maxLines, maxBytes int) (string, bool) {
+	text = strings.TrimSpace(text)
+	lines := strings.Split(text, "\n")
+	truncated := len(lines) > maxLines
+	if truncated {
+		text = strings.Join(lines[len(lines)-maxLines:], "\n")
+	}
+
+	if len(text) > maxBytes {
+		cut := len(text) - maxBytes
+		for cut < len(text) && !utf8.RuneStart(text[cut]) {
+			cut++
+		}
+		text, truncated = text[cut:], true
+	}
+	return text, truncated
+}
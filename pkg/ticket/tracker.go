@@ -0,0 +1,167 @@
+// Package ticket opens issues in Jira or GitHub for jobs that keep failing
+package ticket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+)
+
+// githubAPIURL is the API of github.com
+const githubAPIURL = "https://api.github.com"
+
+// Issue is an issue to open
+type Issue struct {
+	Title string
+	Body  string
+}
+
+// Ticket identifies an opened issue
+type Ticket struct {
+	ID  string // e.g. "OPS-123" or "org/repo#45"
+	URL string // Web page of the issue
+}
+
+// Tracker opens issues in an issue tracker
+type Tracker interface {
+	CreateIssue(ctx context.Context, issue *Issue) (*Ticket, error)
+}
+
+// NewTracker creates the tracker configured for a team
+func NewTracker(cfg *config.TicketTracker) (Tracker, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	switch cfg.Provider {
+	case "jira":
+		issueType := cfg.IssueType
+		if issueType == "" {
+			issueType = "Task"
+		}
+		return &jiraTracker{client: client, config: cfg, issueType: issueType}, nil
+	case "github":
+		apiURL := cfg.URL
+		if apiURL == "" {
+			apiURL = githubAPIURL
+		}
+		return &githubTracker{client: client, config: cfg, apiURL: strings.TrimRight(apiURL, "/")}, nil
+	default:
+		return nil, fmt.Errorf("unsupported ticket provider: %s", cfg.Provider)
+	}
+}
+
+// jiraTracker creates issues through the Jira REST API
+type jiraTracker struct {
+	client    *http.Client
+	config    *config.TicketTracker
+	issueType string
+}
+
+// CreateIssue creates a Jira issue in the configured project
+func (t *jiraTracker) CreateIssue(ctx context.Context, issue *Issue) (*Ticket, error) {
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": t.config.Project},
+		"issuetype":   map[string]string{"name": t.issueType},
+		"summary":     issue.Title,
+		"description": issue.Body,
+	}
+	if len(t.config.Labels) > 0 {
+		fields["labels"] = t.config.Labels
+	}
+
+	baseURL := strings.TrimRight(t.config.URL, "/")
+	req, err := newJSONRequest(ctx, baseURL+"/rest/api/2/issue", map[string]interface{}{"fields": fields})
+	if err != nil {
+		return nil, err
+	}
+	if t.config.Username != "" {
+		req.SetBasicAuth(t.config.Username, t.config.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+t.config.Token)
+	}
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := send(t.client, req, &created); err != nil {
+		return nil, err
+	}
+
+	return &Ticket{ID: created.Key, URL: baseURL + "/browse/" + created.Key}, nil
+}
+
+// githubTracker creates issues through the GitHub REST API
+type githubTracker struct {
+	client *http.Client
+	config *config.TicketTracker
+	apiURL string
+}
+
+// CreateIssue creates a GitHub issue in the configured repository
+func (t *githubTracker) CreateIssue(ctx context.Context, issue *Issue) (*Ticket, error) {
+	body := map[string]interface{}{
+		"title": issue.Title,
+		"body":  issue.Body,
+	}
+	if len(t.config.Labels) > 0 {
+		body["labels"] = t.config.Labels
+	}
+
+	req, err := newJSONRequest(ctx, t.apiURL+"/repos/"+t.config.Repository+"/issues", body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+t.config.Token)
+
+	var created struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := send(t.client, req, &created); err != nil {
+		return nil, err
+	}
+
+	return &Ticket{ID: t.config.Repository + "#" + strconv.Itoa(created.Number), URL: created.HTMLURL}, nil
+}
+
+// newJSONRequest creates a POST request with a JSON body
+func newJSONRequest(ctx context.Context, url string, body interface{}) (*http.Request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// send sends a request and decodes its JSON response into out. Non-2xx
+// responses are returned as errors.
+func send(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(detail)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", req.URL.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package unixsocket
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Prefix marks a Unix domain socket address, e.g. "unix:/run/cronmetrics.sock"
+const Prefix = "unix:"
+
+// BaseURL is the URL requests sent over a socket are addressed to; the host
+// is only used for the Host header
+const BaseURL = "http://localhost"
+
+// Path returns the socket path of addr and whether addr is a socket address
+func Path(addr string) (string, bool) {
+	if !strings.HasPrefix(addr, Prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(addr, Prefix), true
+}
+
+// ParseMode parses socket file permissions written in octal, e.g. "0660"
+func ParseMode(mode string) (os.FileMode, error) {
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || value > 0o777 {
+		return 0, fmt.Errorf("invalid socket mode %q (must be octal permissions such as 0660)", mode)
+	}
+	return os.FileMode(value), nil
+}
+
+// Listen listens on the socket at path and sets its permissions to mode. A
+// socket left behind by a previous run is replaced, but not one a running
+// server still accepts connections on, nor any other kind of file.
+func Listen(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another server", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// Transport returns an HTTP transport that sends every request over the
+// socket at path, whatever host the request URL names
+func Transport(path string) *http.Transport {
+	dialer := &net.Dialer{}
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", path)
+		},
+	}
+}
+
+// Client returns the HTTP client and base URL for requests to serverURL,
+// which is either an http(s) URL or a socket address. Requests to an
+// http(s) URL are sent with client.
+func Client(serverURL string, client *http.Client) (*http.Client, string) {
+	path, ok := Path(serverURL)
+	if !ok {
+		return client, strings.TrimRight(serverURL, "/")
+	}
+	return &http.Client{Transport: Transport(path), Timeout: client.Timeout}, BaseURL
+}
@@ -3,6 +3,7 @@ package util
 import (
 	"crypto/rand"
 	"encoding/base32"
+	"encoding/hex"
 	"fmt"
 	"strings"
 )
@@ -49,3 +50,13 @@ func ValidateAPIKeyFormat(apiKey string) bool {
 	_, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(keyPart))
 	return err == nil
 }
+
+// GenerateNonce generates a random single-use value for job result
+// submissions, 16 bytes (128 bits) of entropy encoded as hex
+func GenerateNonce() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
@@ -122,3 +122,23 @@ func TestAPIKeyUniqueness(t *testing.T) {
 		t.Errorf("Expected %d unique keys, got %d", numKeys, len(keys))
 	}
 }
+
+func TestGenerateNonce(t *testing.T) {
+	nonce1, err := GenerateNonce()
+	if err != nil {
+		t.Fatalf("Failed to generate nonce: %v", err)
+	}
+
+	if len(nonce1) != 32 {
+		t.Errorf("Nonce should be 32 characters long, got %d: %s", len(nonce1), nonce1)
+	}
+
+	nonce2, err := GenerateNonce()
+	if err != nil {
+		t.Fatalf("Failed to generate second nonce: %v", err)
+	}
+
+	if nonce1 == nonce2 {
+		t.Errorf("Generated nonces should be unique, but got identical nonces: %s", nonce1)
+	}
+}
@@ -0,0 +1,5 @@
+package util
+
+// ClientVersionHeader carries the version of the client submitting a job
+// result, recorded with the result
+const ClientVersionHeader = "X-Client-Version"
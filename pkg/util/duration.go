@@ -0,0 +1,98 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationPart matches one number and unit of a duration such as "1d12h"
+var durationPart = regexp.MustCompile(`(\d+(?:\.\d+)?)(ms|s|m|h|d|w)`)
+
+// ParseDuration parses a human-friendly duration: a number of seconds
+// ("3600"), or numbers with units ms, s, m, h, d (24 hours) and w (7 days),
+// such as "90m", "2h", "1d" or "1w2d"
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	matches := durationPart.FindAllStringSubmatchIndex(value, -1)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("invalid duration %q: use seconds or units such as 90m, 2h or 1d", value)
+	}
+
+	var total time.Duration
+	end := 0
+	for _, match := range matches {
+		if match[0] != end {
+			return 0, fmt.Errorf("invalid duration %q: use seconds or units such as 90m, 2h or 1d", value)
+		}
+		end = match[1]
+
+		number, unit := value[match[2]:match[3]], value[match[4]:match[5]]
+		switch unit {
+		case "d", "w":
+			days, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q: %w", value, err)
+			}
+			if unit == "w" {
+				days *= 7
+			}
+			total += time.Duration(days * float64(24*time.Hour))
+		default:
+			part, err := time.ParseDuration(number + unit)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q: %w", value, err)
+			}
+			total += part
+		}
+	}
+	if end != len(value) {
+		return 0, fmt.Errorf("invalid duration %q: use seconds or units such as 90m, 2h or 1d", value)
+	}
+
+	return total, nil
+}
+
+// ParseSeconds parses a duration like ParseDuration and returns it in
+// whole seconds
+func ParseSeconds(value string) (int, error) {
+	duration, err := ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration%time.Second != 0 {
+		return 0, fmt.Errorf("invalid duration %q: must be a whole number of seconds", value)
+	}
+	return int(duration / time.Second), nil
+}
+
+// FormatDuration formats a duration in the units ParseDuration accepts,
+// largest first, e.g. "1d2h" or "90s"
+func FormatDuration(d time.Duration) string {
+	if d <= 0 || d%time.Second != 0 {
+		return d.String()
+	}
+
+	var out strings.Builder
+	for _, unit := range []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	} {
+		if n := d / unit.size; n > 0 {
+			fmt.Fprintf(&out, "%d%s", n, unit.suffix)
+			d -= n * unit.size
+		}
+	}
+	return out.String()
+}
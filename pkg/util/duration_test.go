@@ -0,0 +1,67 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	valid := map[string]time.Duration{
+		"3600":    time.Hour,
+		"0":       0,
+		"90m":     90 * time.Minute,
+		"2h":      2 * time.Hour,
+		"1d":      24 * time.Hour,
+		"1.5d":    36 * time.Hour,
+		"1w2d":    9 * 24 * time.Hour,
+		"1h30m":   90 * time.Minute,
+		"500ms":   500 * time.Millisecond,
+		" 45s ":   45 * time.Second,
+		"1d12h5m": 36*time.Hour + 5*time.Minute,
+	}
+	for value, expected := range valid {
+		got, err := ParseDuration(value)
+		if err != nil {
+			t.Errorf("ParseDuration(%q) failed: %v", value, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("ParseDuration(%q) = %s, expected %s", value, got, expected)
+		}
+	}
+
+	for _, value := range []string{"", "d", "2 h", "2hours", "-1h", "1y", "h2", "1d-2h"} {
+		if got, err := ParseDuration(value); err == nil {
+			t.Errorf("ParseDuration(%q) = %s, expected an error", value, got)
+		}
+	}
+}
+
+func TestParseSeconds(t *testing.T) {
+	if seconds, err := ParseSeconds("2h"); err != nil || seconds != 7200 {
+		t.Errorf("ParseSeconds(\"2h\") = %d, %v, expected 7200", seconds, err)
+	}
+	if _, err := ParseSeconds("1500ms"); err == nil {
+		t.Error("ParseSeconds should reject fractions of a second")
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	cases := map[time.Duration]string{
+		90 * time.Second:             "1m30s",
+		26 * time.Hour:               "1d2h",
+		7 * 24 * time.Hour:           "7d",
+		1500 * time.Millisecond:      "1.5s",
+		36*time.Hour + 5*time.Minute: "1d12h5m",
+	}
+	for duration, expected := range cases {
+		if got := FormatDuration(duration); got != expected {
+			t.Errorf("FormatDuration(%s) = %q, expected %q", duration, got, expected)
+		}
+		if duration%time.Second == 0 {
+			if parsed, err := ParseDuration(FormatDuration(duration)); err != nil || parsed != duration {
+				t.Errorf("ParseDuration(FormatDuration(%s)) = %s, %v", duration, parsed, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// processIPKey keys the IP hashers created without a key of their own
+var (
+	processIPKey     []byte
+	processIPKeyOnce sync.Once
+)
+
+// IPHasher pseudonymizes client addresses in logs and audit entries. The
+// same address always gets the same hash, so a client's requests can still
+// be followed, but the address cannot be read back: the hash is keyed, so
+// it cannot be reversed by hashing every IPv4 address either. A nil
+// IPHasher keeps addresses as they are.
+type IPHasher struct {
+	key []byte
+}
+
+// NewIPHasher returns a hasher keyed with key. Without a key, one is drawn
+// at random once per process, so hashes change when the server restarts.
+func NewIPHasher(key string) *IPHasher {
+	if key != "" {
+		return &IPHasher{key: []byte(key)}
+	}
+	processIPKeyOnce.Do(func() {
+		processIPKey = make([]byte, 32)
+		if _, err := rand.Read(processIPKey); err != nil {
+			panic("failed to generate IP hash key: " + err.Error())
+		}
+	})
+	return &IPHasher{key: processIPKey}
+}
+
+// Hash returns the pseudonym of addr, e.g. "ip-3f9c2a7d1e4b8c6a", or addr
+// itself when h is nil. Empty addresses stay empty.
+func (h *IPHasher) Hash(addr string) string {
+	if h == nil || addr == "" {
+		return addr
+	}
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(addr))
+	return "ip-" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
@@ -0,0 +1,40 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIPHasher(t *testing.T) {
+	hasher := NewIPHasher("test-key")
+
+	hash := hasher.Hash("10.0.4.17")
+	if !strings.HasPrefix(hash, "ip-") || len(hash) != 19 {
+		t.Errorf("Hash should be ip- and 16 hex digits, got: %s", hash)
+	}
+	if strings.Contains(hash, "10.0.4.17") {
+		t.Errorf("Hash should not contain the address, got: %s", hash)
+	}
+	if hash != NewIPHasher("test-key").Hash("10.0.4.17") {
+		t.Errorf("The same key should give the same hash")
+	}
+	if hash == hasher.Hash("10.0.4.18") {
+		t.Errorf("Different addresses should give different hashes")
+	}
+	if hash == NewIPHasher("other-key").Hash("10.0.4.17") {
+		t.Errorf("Different keys should give different hashes")
+	}
+
+	// Without a key, hashes are stable within the process
+	if NewIPHasher("").Hash("10.0.4.17") != NewIPHasher("").Hash("10.0.4.17") {
+		t.Errorf("Hashers without a key should share the process key")
+	}
+
+	if hasher.Hash("") != "" {
+		t.Errorf("Empty addresses should stay empty")
+	}
+	var none *IPHasher
+	if none.Hash("10.0.4.17") != "10.0.4.17" {
+		t.Errorf("A nil hasher should keep addresses")
+	}
+}
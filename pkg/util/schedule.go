@@ -0,0 +1,193 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed crontab schedule. Each field is a set of the values
+// it matches, as bits.
+type Schedule struct {
+	minute, hour, day, month, weekday uint64
+
+	// Cron runs a schedule restricting both the day and the weekday when
+	// either matches
+	dayRestricted, weekdayRestricted bool
+}
+
+// scheduleMacros are the crontab schedule shorthands
+var scheduleMacros = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+// scheduleField is a crontab field, with its value range and the names its
+// values may be given by
+type scheduleField struct {
+	name     string
+	min, max int
+	names    []string // Names of the values from min
+}
+
+var (
+	minuteField  = scheduleField{name: "minute", min: 0, max: 59}
+	hourField    = scheduleField{name: "hour", min: 0, max: 23}
+	dayField     = scheduleField{name: "day", min: 1, max: 31}
+	monthField   = scheduleField{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	weekdayField = scheduleField{name: "weekday", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat", "sun"}}
+)
+
+// scheduleSearchYears bounds the search for the next run of a schedule,
+// covering the leap years of schedules such as "0 0 29 2 *"
+const scheduleSearchYears = 8
+
+// ParseSchedule parses a standard 5-field crontab schedule (minute hour day
+// month weekday), or a macro such as "@daily". Fields may be *, values,
+// ranges and lists, with /step; months and weekdays may also be given by
+// their three-letter English names. Schedules are evaluated in UTC.
+func ParseSchedule(schedule string) (*Schedule, error) {
+	expr := strings.TrimSpace(schedule)
+	if strings.HasPrefix(expr, "@") {
+		expanded, ok := scheduleMacros[strings.ToLower(expr)]
+		if !ok {
+			return nil, fmt.Errorf("invalid schedule %q: unknown macro", schedule)
+		}
+		expr = expanded
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: must be a crontab macro or have 5 fields (minute hour day month weekday)", schedule)
+	}
+
+	s := &Schedule{
+		dayRestricted:     !strings.HasPrefix(fields[2], "*"),
+		weekdayRestricted: !strings.HasPrefix(fields[4], "*"),
+	}
+	for i, target := range []struct {
+		field scheduleField
+		bits  *uint64
+	}{
+		{minuteField, &s.minute},
+		{hourField, &s.hour},
+		{dayField, &s.day},
+		{monthField, &s.month},
+		{weekdayField, &s.weekday},
+	} {
+		bits, err := target.field.parse(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", schedule, err)
+		}
+		*target.bits = bits
+	}
+	// 7 is another Sunday
+	if s.weekday&(1<<7) != 0 {
+		s.weekday = s.weekday&^(1<<7) | 1
+	}
+
+	if s.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("invalid schedule %q: never runs", schedule)
+	}
+	return s, nil
+}
+
+// parse returns the set of values matched by a field of a schedule
+func (f scheduleField) parse(field string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		values, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid %s %q: step must be a positive number", f.name, part)
+			}
+			step = n
+		}
+
+		first, last := f.min, f.max
+		if values != "*" {
+			from, to, isRange := strings.Cut(values, "-")
+			var err error
+			if first, err = f.value(from); err != nil {
+				return 0, err
+			}
+			last = first
+			if isRange {
+				if last, err = f.value(to); err != nil {
+					return 0, err
+				}
+				if last < first {
+					return 0, fmt.Errorf("invalid %s %q: range ends before it starts", f.name, part)
+				}
+			} else if hasStep {
+				// "5/15" runs from 5 to the end of the range
+				last = f.max
+			}
+		}
+
+		for v := first; v <= last; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value parses a single value of a field, a number or a name
+func (f scheduleField) value(text string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(text, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("invalid %s %q: must be between %d and %d", f.name, text, f.min, f.max)
+	}
+	return n, nil
+}
+
+// Next returns the first time after t the schedule runs, in UTC, or the
+// zero time if it runs in none of the following years
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(scheduleSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchesDay reports whether the schedule runs on the day of t
+func (s *Schedule) matchesDay(t time.Time) bool {
+	day := s.day&(1<<uint(t.Day())) != 0
+	weekday := s.weekday&(1<<uint(t.Weekday())) != 0
+	if s.dayRestricted && s.weekdayRestricted {
+		return day || weekday
+	}
+	return day && weekday
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"* * * * *":       time.Date(2025, 1, 15, 10, 31, 0, 0, time.UTC),
+		"*/15 * * * *":    time.Date(2025, 1, 15, 10, 45, 0, 0, time.UTC),
+		"5/20 * * * *":    time.Date(2025, 1, 15, 10, 45, 0, 0, time.UTC),
+		"0 3 * * *":       time.Date(2025, 1, 16, 3, 0, 0, 0, time.UTC),
+		"30 2 * * 1-5":    time.Date(2025, 1, 16, 2, 30, 0, 0, time.UTC),
+		"0 4 * * sun":     time.Date(2025, 1, 19, 4, 0, 0, 0, time.UTC),
+		"0 4 * * 7":       time.Date(2025, 1, 19, 4, 0, 0, 0, time.UTC),
+		"0 0 1 * *":       time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 1 1 *":       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":      time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		"0 12 1,20 * *":   time.Date(2025, 1, 20, 12, 0, 0, 0, time.UTC),
+		"0 9 31 * *":      time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC),
+		"0 0 1 Mar-May *": time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		"0 6 13 * fri":    time.Date(2025, 1, 17, 6, 0, 0, 0, time.UTC), // Either the day or the weekday
+		"@hourly":         time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC),
+		"@weekly":         time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC),
+		"  @Monthly  ":    time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		"30 10 15 1 3":    time.Date(2025, 1, 22, 10, 30, 0, 0, time.UTC), // Strictly after
+		"0 0 * * 0,4-5":   time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC),
+	}
+	for schedule, expected := range cases {
+		parsed, err := ParseSchedule(schedule)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q) failed: %v", schedule, err)
+			continue
+		}
+		if got := parsed.Next(from); !got.Equal(expected) {
+			t.Errorf("ParseSchedule(%q).Next(%s) = %s, expected %s", schedule, from, got, expected)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, schedule := range []string{
+		"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *",
+		"* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *",
+		"@every", "0 0 30 2 *", "* * * foo *",
+	} {
+		if _, err := ParseSchedule(schedule); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, expected an error", schedule)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// SignatureHeader carries the signature of a signed job result submission
+const SignatureHeader = "X-Signature"
+
+// signaturePrefix names the algorithm of a signature
+const signaturePrefix = "sha256="
+
+// SignPayload returns the signature of a request body signed with a job's
+// API key: "sha256=" followed by the hex encoded HMAC-SHA256 of the body
+func SignPayload(apiKey string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(apiKey))
+	mac.Write(payload)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a signature from SignPayload in constant time
+func VerifySignature(apiKey string, payload []byte, signature string) bool {
+	if apiKey == "" || !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(apiKey))
+	mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSignPayload(t *testing.T) {
+	payload := []byte(`{"job_name":"backup","host":"db1","status":"success"}`)
+
+	// Same as: printf '%s' "$payload" | openssl dgst -sha256 -hmac cm_test_key
+	signature := SignPayload("cm_test_key", payload)
+	expected := "sha256=c4292b4fc3c93162c45ee7884fa84c446a7e155d4966472bf8658962656105a8"
+	if signature != expected {
+		t.Errorf("Signature should be %s, got: %s", expected, signature)
+	}
+
+	if signature != SignPayload("cm_test_key", payload) {
+		t.Errorf("Signing the same payload should give the same signature")
+	}
+	if signature == SignPayload("cm_other_key", payload) {
+		t.Errorf("Different keys should give different signatures")
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	payload := []byte(`{"job_name":"backup","host":"db1","status":"success"}`)
+	signature := SignPayload("cm_test_key", payload)
+
+	testCases := []struct {
+		name      string
+		apiKey    string
+		payload   []byte
+		signature string
+		valid     bool
+	}{
+		{"valid signature", "cm_test_key", payload, signature, true},
+		{"upper case hex", "cm_test_key", payload, "sha256=" + strings.ToUpper(strings.TrimPrefix(signature, "sha256=")), true},
+		{"wrong key", "cm_other_key", payload, signature, false},
+		{"modified payload", "cm_test_key", []byte(`{"job_name":"backup","host":"db1","status":"failure"}`), signature, false},
+		{"missing prefix", "cm_test_key", payload, strings.TrimPrefix(signature, "sha256="), false},
+		{"invalid hex", "cm_test_key", payload, "sha256=not-hex", false},
+		{"empty signature", "cm_test_key", payload, "", false},
+		{"empty key", "", payload, SignPayload("", payload), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := VerifySignature(tc.apiKey, tc.payload, tc.signature); got != tc.valid {
+				t.Errorf("VerifySignature() = %v, want %v", got, tc.valid)
+			}
+		})
+	}
+}
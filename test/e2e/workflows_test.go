@@ -65,7 +65,7 @@ func TestCompleteWorkflow(t *testing.T) {
 			"host":     "production-db",
 			"status":   "success",
 			"duration": 1800, // 30 minutes
-			"message":  "Backup completed successfully - 500GB processed",
+			"output":   "Backup completed successfully - 500GB processed",
 		}
 
 		jobClient.POST("/api/job-result", successResult).ExpectStatus(201)
@@ -83,7 +83,7 @@ func TestCompleteWorkflow(t *testing.T) {
 			"host":     "production-db",
 			"status":   "failure",
 			"duration": 300, // 5 minutes before failure
-			"message":  "Database connection timeout",
+			"output":   "Database connection timeout",
 		}
 
 		jobClient.POST("/api/job-result", failureResult).ExpectStatus(201)
@@ -140,7 +140,7 @@ func TestCompleteWorkflow(t *testing.T) {
 			"host":     "production-db",
 			"status":   "success",
 			"duration": 1650, // 27.5 minutes
-			"message":  "Backup resumed and completed",
+			"output":   "Backup resumed and completed",
 		}
 
 		jobClient.POST("/api/job-result", finalResult).ExpectStatus(201)
@@ -245,7 +245,7 @@ func TestMultiJobWorkflow(t *testing.T) {
 				"host":     job.Host,
 				"status":   scenario.status,
 				"duration": scenario.duration,
-				"message":  scenario.message,
+				"output":   scenario.message,
 			}
 
 			jobClient.POST("/api/job-result", resultRequest).ExpectStatus(201)
@@ -325,7 +325,7 @@ func TestAutoFailureDetectionWorkflow(t *testing.T) {
 			"host":     "test-server",
 			"status":   "success",
 			"duration": 30,
-			"message":  "Initial successful run",
+			"output":   "Initial successful run",
 		}
 
 		jobClient.POST("/api/job-result", initialResult).ExpectStatus(201)
@@ -365,7 +365,7 @@ func TestAutoFailureDetectionWorkflow(t *testing.T) {
 			"host":     "test-server",
 			"status":   "success",
 			"duration": 25,
-			"message":  "Job recovered",
+			"output":   "Job recovered",
 		}
 
 		jobClient.POST("/api/job-result", recoveryResult).ExpectStatus(201)
@@ -0,0 +1,171 @@
+package integration
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/api"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const acmeDomain = "cronmetrics.example.com"
+
+// seedACMECache writes a self-signed certificate for domain into an autocert
+// cache directory, so the manager serves it without contacting a CA
+func seedACMECache(t *testing.T, dir, domain string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	var data bytes.Buffer
+	require.NoError(t, pem.Encode(&data, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	require.NoError(t, pem.Encode(&data, &pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, domain), data.Bytes(), 0600))
+
+	return cert
+}
+
+func TestACMECertificates(t *testing.T) {
+	cacheDir := t.TempDir()
+	cert := seedACMECache(t, cacheDir, acmeDomain)
+
+	manager := api.NewACMEManager(&config.ACMEConfig{
+		Enabled:  true,
+		Domains:  []string{acmeDomain},
+		Email:    "ops@example.com",
+		CacheDir: cacheDir,
+		// Never reached: the certificate comes from the cache
+		DirectoryURL: "http://127.0.0.1:1/directory",
+	})
+
+	t.Run("CachedCertificate", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "ok")
+		}))
+		server.TLS = manager.TLSConfig()
+		server.StartTLS()
+		defer server.Close()
+
+		roots := x509.NewCertPool()
+		roots.AddCert(cert)
+		client := &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: acmeDomain},
+			},
+		}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NotNil(t, resp.TLS)
+		require.NotEmpty(t, resp.TLS.PeerCertificates)
+		assert.Equal(t, cert.SerialNumber, resp.TLS.PeerCertificates[0].SerialNumber)
+	})
+
+	t.Run("UnknownHost", func(t *testing.T) {
+		_, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.example.com"})
+		assert.Error(t, err)
+
+		_, err = manager.GetCertificate(&tls.ClientHelloInfo{})
+		assert.Error(t, err, "connections without SNI cannot be served")
+	})
+
+	t.Run("HTTPRedirect", func(t *testing.T) {
+		handler := manager.HTTPHandler(nil)
+
+		req := httptest.NewRequest(http.MethodGet, "http://"+acmeDomain+"/metrics?job=backup", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://"+acmeDomain+"/metrics?job=backup", rec.Header().Get("Location"))
+
+		req = httptest.NewRequest(http.MethodGet, "http://"+acmeDomain+"/.well-known/acme-challenge/unknown", nil)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestACMEConfig(t *testing.T) {
+	buildBinary(t)
+
+	cliTest := testutil.NewCLITest(t)
+	cliTest.CreateDefaultTestConfig()
+
+	render := func(overrides ...string) *testutil.CLIResult {
+		args := []string{"--config", cliTest.ConfigFile, "config", "render",
+			"--set", "security.require_https=true"}
+		for _, override := range overrides {
+			args = append(args, "--set", override)
+		}
+		return cliTest.RunCommand(args...)
+	}
+
+	// ACME replaces the cert/key files
+	render("security.acme.enabled=true", "security.acme.domains=["+acmeDomain+"]").
+		ExpectSuccess().
+		ExpectStdoutContains(acmeDomain)
+
+	render().
+		ExpectFailure().
+		ExpectStderrContains("TLS cert and key files must be specified")
+
+	render("security.acme.enabled=true").
+		ExpectFailure().
+		ExpectStderrContains("security.acme.domains")
+
+	render("security.acme.enabled=true", "security.acme.domains=["+acmeDomain+"]", "security.acme.cache_dir=").
+		ExpectFailure().
+		ExpectStderrContains("security.acme.cache_dir")
+
+	render("security.acme.enabled=true", "security.acme.domains=["+acmeDomain+"]", "security.acme.http_port=8080", "server.port=8080").
+		ExpectFailure().
+		ExpectStderrContains("must differ from server.port")
+
+	cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render",
+		"--set", "security.require_https=false", "--set", "security.acme.enabled=true",
+		"--set", "security.acme.domains=["+acmeDomain+"]").
+		ExpectFailure().
+		ExpectStderrContains("requires security.require_https")
+}
@@ -0,0 +1,155 @@
+package integration
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/actionlink"
+	"github.com/jaepetto/cron-exporter/pkg/api"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionLinks(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders())
+	// Action links are used without any credentials
+	anonymous := testutil.NewHTTPClient(t, server.URL())
+
+	var job model.Job
+	admin.POST("/api/job", map[string]interface{}{"job_name": "backup", "host": "db1"}).
+		ExpectStatus(201).
+		ExpectJSON(&job)
+
+	secret := server.Config.Security.ActionLinkSecret
+
+	// linkPath returns the path and query of an action link URL
+	linkPath := func(t *testing.T, rawURL string) (string, url.Values) {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		return u.Path, u.Query()
+	}
+
+	t.Run("Maintenance", func(t *testing.T) {
+		var link api.ActionLinkResponse
+		admin.POST(fmt.Sprintf("/api/job/%d/action-link", job.ID), map[string]interface{}{"action": "maintenance"}).
+			ExpectStatus(201).
+			ExpectJSON(&link)
+
+		assert.Equal(t, job.ID, link.JobID)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), link.ExpiresAt, time.Minute)
+		path, query := linkPath(t, link.URL)
+		assert.Equal(t, "/dashboard/actions/maintenance", path)
+
+		// Opening the link only asks for confirmation
+		anonymous.GET(path + "?" + query.Encode()).
+			ExpectStatus(200).
+			ExpectContains("Put in maintenance").
+			ExpectContains(fmt.Sprintf(`name="sig" value="%s"`, query.Get("sig")))
+
+		updated, err := server.Database.GetJobStore().GetJobByID(job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "active", updated.Status)
+
+		anonymous.PostForm(path, query).
+			ExpectStatus(200).
+			ExpectContains("backup@db1")
+
+		updated, err = server.Database.GetJobStore().GetJobByID(job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "maintenance", updated.Status)
+
+		dashboard.GET("/dashboard/admin/audit").
+			ExpectStatus(200).
+			ExpectContains("action_link.maintenance")
+	})
+
+	t.Run("Acknowledge", func(t *testing.T) {
+		link := actionlink.Link{JobID: job.ID, Action: actionlink.ActionAcknowledge, Expires: time.Now().Add(time.Minute)}
+
+		anonymous.PostForm("/dashboard/actions/acknowledge", link.Query(secret)).
+			ExpectStatus(200).
+			ExpectContains("acknowledged")
+
+		updated, err := server.Database.GetJobStore().GetJobByID(job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "maintenance", updated.Status)
+
+		dashboard.GET("/dashboard/admin/audit").
+			ExpectStatus(200).
+			ExpectContains("action_link.acknowledge")
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		link := actionlink.Link{JobID: job.ID, Action: actionlink.ActionActivate, Expires: time.Now().Add(time.Minute)}
+
+		tampered := link.Query(secret)
+		tampered.Set("expires", fmt.Sprint(time.Now().Add(time.Hour).Unix()))
+		anonymous.PostForm("/dashboard/actions/activate", tampered).ExpectStatus(403)
+
+		// A link is only valid for the action it was signed for
+		anonymous.PostForm("/dashboard/actions/maintenance", link.Query(secret)).ExpectStatus(403)
+		anonymous.PostForm("/dashboard/actions/activate", link.Query("some-other-secret-0123456789abcdef")).ExpectStatus(403)
+
+		expired := actionlink.Link{JobID: job.ID, Action: actionlink.ActionActivate, Expires: time.Now().Add(-time.Minute)}
+		anonymous.PostForm("/dashboard/actions/activate", expired.Query(secret)).
+			ExpectStatus(410).
+			ExpectContains("expired")
+
+		missing := actionlink.Link{JobID: job.ID + 1000, Action: actionlink.ActionActivate, Expires: time.Now().Add(time.Minute)}
+		anonymous.PostForm("/dashboard/actions/activate", missing.Query(secret)).ExpectStatus(404)
+
+		updated, err := server.Database.GetJobStore().GetJobByID(job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "maintenance", updated.Status)
+	})
+
+	t.Run("CreateValidation", func(t *testing.T) {
+		path := fmt.Sprintf("/api/job/%d/action-link", job.ID)
+
+		admin.POST(path, map[string]interface{}{"action": "delete"}).ExpectStatus(400)
+		admin.POST(path, map[string]interface{}{"action": "activate", "ttl": -5}).ExpectStatus(400)
+		admin.POST(fmt.Sprintf("/api/job/%d/action-link", job.ID+1000), map[string]interface{}{"action": "activate"}).
+			ExpectStatus(404)
+
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"Authorization": "Bearer test-api-key"}).
+			POST(path, map[string]interface{}{"action": "activate"}).
+			ExpectStatus(401)
+
+		server.Config.Server.ExternalURL = "https://cron.example.com/"
+		defer func() { server.Config.Server.ExternalURL = "" }()
+
+		var link api.ActionLinkResponse
+		admin.POST(path, map[string]interface{}{"action": "activate", "ttl": 60}).
+			ExpectStatus(201).
+			ExpectJSON(&link)
+		assert.True(t, strings.HasPrefix(link.URL, "https://cron.example.com/dashboard/actions/activate?"), link.URL)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), link.ExpiresAt, 5*time.Second)
+	})
+}
+
+func TestActionLinksDisabled(t *testing.T) {
+	// Without the dashboard there is nothing to serve the links
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+
+	server.Database.SeedTestData()
+	job, err := server.Database.GetJobStore().GetJob("backup", "db1")
+	require.NoError(t, err)
+
+	testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders()).
+		POST(fmt.Sprintf("/api/job/%d/action-link", job.ID), map[string]interface{}{"action": "maintenance"}).
+		ExpectStatus(503)
+}
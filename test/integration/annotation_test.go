@@ -0,0 +1,93 @@
+package integration
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultAnnotations(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	for _, status := range []string{"success", "failure"} {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": status}).
+			ExpectStatus(201)
+	}
+
+	var page model.JobResultPage
+	admin.GET("/api/job/1/results").ExpectStatus(200).ExpectJSON(&page)
+	require.Len(t, page.Results, 2)
+	failure := page.Results[0]
+	require.Equal(t, "failure", failure.Status)
+
+	var annotation model.ResultAnnotation
+	t.Run("Create", func(t *testing.T) {
+		admin.POST("/api/job/1/annotations", map[string]interface{}{
+			"comment": "known issue, vendor outage", "result_id": failure.ID, "author": "alice",
+		}).ExpectStatus(201).ExpectJSON(&annotation)
+		assert.Equal(t, failure.ID, annotation.ResultID)
+		assert.Equal(t, "alice", annotation.Author)
+
+		var episode model.ResultAnnotation
+		admin.POST("/api/job/1/annotations", map[string]interface{}{"comment": "vendor ticket #123"}).
+			ExpectStatus(201).ExpectJSON(&episode)
+		assert.Zero(t, episode.ResultID)
+		assert.Equal(t, "api", episode.Author)
+
+		var annotations []model.ResultAnnotation
+		admin.GET("/api/job/1/annotations").ExpectStatus(200).ExpectJSON(&annotations)
+		require.Len(t, annotations, 2)
+		assert.Equal(t, "vendor ticket #123", annotations[0].Comment, "newest first")
+	})
+
+	t.Run("History", func(t *testing.T) {
+		var page model.JobResultPage
+		admin.GET("/api/job/1/results").ExpectStatus(200).ExpectJSON(&page)
+		require.Len(t, page.Results, 2)
+		require.Len(t, page.Results[0].Annotations, 1)
+		assert.Equal(t, "known issue, vendor outage", page.Results[0].Annotations[0].Comment)
+		assert.Empty(t, page.Results[1].Annotations)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		admin.POST("/api/job/1/annotations", map[string]interface{}{"comment": "  "}).
+			ExpectStatus(400).
+			ExpectContains("comment is required")
+		admin.POST("/api/job/2/annotations", map[string]interface{}{"comment": "wrong job", "result_id": failure.ID}).
+			ExpectStatus(400).
+			ExpectContains("is not a result of this job")
+		admin.POST("/api/job/999/annotations", map[string]interface{}{"comment": "x"}).ExpectStatus(404)
+		admin.PUT("/api/job/1/annotations", map[string]interface{}{"comment": "x"}).ExpectStatus(405)
+		testutil.NewHTTPClient(t, server.URL()).GET("/api/job/1/annotations").ExpectStatus(401)
+	})
+
+	t.Run("Dashboard", func(t *testing.T) {
+		dashboard := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders()).WithCookies()
+		dashboard.PostForm("/dashboard/jobs/1/annotations", url.Values{"comment": {"rerun scheduled"}}).ExpectStatus(200).ExpectContains("Annotation added")
+
+		body := dashboard.GET("/dashboard/jobs/1").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, "Recent Results")
+		assert.Contains(t, body, "known issue, vendor outage")
+		assert.Contains(t, body, "rerun scheduled")
+
+		dashboard.PostForm("/dashboard/jobs/1/annotations", url.Values{"comment": {"x"}, "result_id": {"999"}}).
+			ExpectStatus(200).
+			ExpectContains("Result 999 is not a result of this job")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		admin.DELETE(fmt.Sprintf("/api/job/1/annotations/%d", annotation.ID)).ExpectStatus(204)
+		admin.DELETE(fmt.Sprintf("/api/job/1/annotations/%d", annotation.ID)).ExpectStatus(404)
+		admin.DELETE("/api/job/1/annotations/abc").ExpectStatus(400)
+	})
+}
@@ -2,10 +2,12 @@ package integration
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/api"
 	"github.com/jaepetto/cron-exporter/pkg/model"
 	"github.com/stretchr/testify/assert"
 )
@@ -183,7 +185,7 @@ func TestJobResultSubmission(t *testing.T) {
 			"host":     "db1",
 			"status":   "success",
 			"duration": 120,
-			"message":  "Backup completed successfully",
+			"output":   "Backup completed successfully",
 		}
 
 		var response map[string]interface{}
@@ -209,7 +211,7 @@ func TestJobResultSubmission(t *testing.T) {
 			"host":     "db1",
 			"status":   "failure",
 			"duration": 45,
-			"message":  "Database connection failed",
+			"output":   "Database connection failed",
 		}
 
 		var response map[string]interface{}
@@ -235,7 +237,7 @@ func TestJobResultSubmission(t *testing.T) {
 
 		client.POST("/api/job-result", resultRequest).
 			ExpectStatus(400).
-			ExpectContains("status must be 'success' or 'failure'")
+			ExpectContains("status must be 'success', 'failure' or 'started'")
 	})
 
 	t.Run("MissingJobResult", func(t *testing.T) {
@@ -355,6 +357,60 @@ func TestJobCRUDValidation(t *testing.T) {
 	})
 }
 
+func TestCloneJob(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+
+	client := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+
+	var source model.Job
+	client.POST("/api/job", map[string]interface{}{
+		"job_name":                    "backup",
+		"host":                        "db1",
+		"automatic_failure_threshold": 7200,
+		"labels":                      map[string]string{"owner": "infra", "env": "prod"},
+		"status":                      "maintenance",
+	}).ExpectStatus(201).ExpectJSON(&source)
+
+	t.Run("CloneToNewHost", func(t *testing.T) {
+		var clone model.Job
+		client.POST(fmt.Sprintf("/api/job/%d/clone", source.ID), map[string]interface{}{
+			"host": "db2",
+		}).ExpectStatus(201).ExpectJSON(&clone)
+
+		assert.NotEqual(t, source.ID, clone.ID)
+		assert.Equal(t, "backup", clone.Name)
+		assert.Equal(t, "db2", clone.Host)
+		assert.Equal(t, 7200, clone.AutomaticFailureThreshold)
+		assert.Equal(t, source.Labels, clone.Labels)
+		assert.Equal(t, "active", clone.Status)
+		assert.NotEmpty(t, clone.ApiKey)
+		assert.NotEqual(t, source.ApiKey, clone.ApiKey)
+	})
+
+	t.Run("CloneRequiresNewIdentity", func(t *testing.T) {
+		client.POST(fmt.Sprintf("/api/job/%d/clone", source.ID), map[string]interface{}{}).
+			ExpectStatus(400)
+	})
+
+	t.Run("CloneConflict", func(t *testing.T) {
+		client.POST(fmt.Sprintf("/api/job/%d/clone", source.ID), map[string]interface{}{
+			"host": "db2",
+		}).ExpectStatus(409)
+	})
+
+	t.Run("CloneMissingSource", func(t *testing.T) {
+		client.POST("/api/job/9999/clone", map[string]interface{}{"host": "db3"}).
+			ExpectStatus(404)
+	})
+
+	t.Run("UnknownSubresource", func(t *testing.T) {
+		client.GET(fmt.Sprintf("/api/job/%d/unknown", source.ID)).
+			ExpectStatus(404)
+	})
+}
+
 func TestSwaggerUIEndpoints(t *testing.T) {
 	server := testutil.NewTestServer(t)
 	defer server.Close()
@@ -409,6 +465,26 @@ func TestSwaggerUIEndpoints(t *testing.T) {
 		defer response.Close()
 	})
 
+	t.Run("TryItOut", func(t *testing.T) {
+		// Try-it-out requests go to the server the spec was fetched from
+		client.GET("/api/openapi.yaml").
+			ExpectStatus(200).
+			ExpectContains("url: " + server.URL() + "\n").
+			ExpectContains("AdminAPIKey: []")
+		assert.NotContains(t, client.GET("/api/openapi.yaml").BodyString(), "cronmetrics.example.com")
+
+		proxied := testutil.NewHTTPClient(t, server.URL()).WithHeaders(map[string]string{"X-Forwarded-Proto": "https"})
+		proxied.GET("/api/openapi.yaml").
+			ExpectStatus(200).
+			ExpectHeader("Vary", "Host, X-Forwarded-Proto").
+			ExpectContains("url: https://" + strings.TrimPrefix(server.URL(), "http://") + "\n")
+
+		// Entered API keys persist and endpoints are ready to try
+		page := client.GET("/swagger/index.html").ExpectStatus(200).BodyString()
+		assert.Regexp(t, `persistAuthorization:\s*true`, page)
+		assert.Contains(t, page, "tryItOutEnabled: true")
+	})
+
 	t.Run("OpenAPISpecCaching", func(t *testing.T) {
 		// Test that OpenAPI spec has appropriate caching headers
 		response := client.GET("/api/openapi.yaml").
@@ -427,3 +503,36 @@ func TestSwaggerUIEndpoints(t *testing.T) {
 		defer response.Close()
 	})
 }
+
+func TestJobExists(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+
+	client := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+
+	var job model.Job
+	client.POST("/api/job", map[string]interface{}{"job_name": "backup", "host": "db1"}).
+		ExpectStatus(201).
+		ExpectJSON(&job)
+
+	var found api.JobExistsResponse
+	client.GET("/api/job/exists?name=backup&host=db1").ExpectStatus(200).ExpectJSON(&found)
+	assert.True(t, found.Exists)
+	assert.Equal(t, job.ID, found.ID)
+
+	var missing api.JobExistsResponse
+	client.GET("/api/job/exists?name=backup&host=db2").ExpectStatus(200).ExpectJSON(&missing)
+	assert.False(t, missing.Exists)
+	assert.Zero(t, missing.ID)
+
+	t.Run("MissingParameters", func(t *testing.T) {
+		client.GET("/api/job/exists?name=backup").ExpectStatus(400)
+	})
+
+	t.Run("RequiresAdmin", func(t *testing.T) {
+		testutil.NewHTTPClient(t, server.URL()).
+			GET("/api/job/exists?name=backup&host=db1").
+			ExpectStatus(401)
+	})
+}
@@ -0,0 +1,215 @@
+package integration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/archive"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedArchiveResults creates backup@db1 and records its results at the
+// given ages
+func seedArchiveResults(t *testing.T, db *testutil.TestDatabase, now time.Time, ages ...time.Duration) {
+	require.NoError(t, db.GetJobStore().CreateJob(&model.Job{
+		Name:                      "backup",
+		Host:                      "db1",
+		AutomaticFailureThreshold: 3600,
+		Status:                    "active",
+		LastReportedAt:            now,
+	}))
+	results := db.GetJobResultStore()
+	for i, age := range ages {
+		require.NoError(t, results.CreateJobResult(&model.JobResult{
+			JobName:   "backup",
+			Host:      "db1",
+			Status:    "success",
+			Duration:  i + 1,
+			Labels:    map[string]string{"run": fmt.Sprint(i)},
+			Timestamp: now.Add(-age),
+		}))
+	}
+}
+
+func TestArchiveToFileStore(t *testing.T) {
+	db := testutil.NewInMemoryTestDatabase(t)
+	now := time.Now().UTC()
+	day := 24 * time.Hour
+	seedArchiveResults(t, db, now, 100*day, 100*day+time.Hour, 95*day, 10*day, time.Hour)
+
+	store, err := archive.NewStore(&config.ArchiveConfig{Destination: "file://" + t.TempDir()})
+	require.NoError(t, err)
+	archiver := archive.NewArchiver(db.GetJobResultStore(), store)
+	ctx := context.Background()
+
+	t.Run("Archive", func(t *testing.T) {
+		stats, err := archiver.Archive(ctx, now.Add(-90*day))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), stats.Results)
+		assert.Equal(t, 2, db.CountJobResults())
+
+		keys, err := archiver.Objects(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, keys)
+		for _, key := range keys {
+			assert.True(t, strings.HasPrefix(key, "job_results/dt="), key)
+			assert.True(t, strings.HasSuffix(key, ".jsonl.gz"), key)
+		}
+
+		// Nothing left to archive
+		stats, err = archiver.Archive(ctx, now.Add(-90*day))
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), stats.Results)
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		results, err := archiver.Query(ctx, archive.Query{JobName: "backup", Host: "db1"})
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		assert.Equal(t, "success", results[0].Status)
+		assert.NotEmpty(t, results[0].Labels["run"])
+
+		results, err = archiver.Query(ctx, archive.Query{Since: now.Add(-96 * day)})
+		require.NoError(t, err)
+		assert.Len(t, results, 1)
+
+		results, err = archiver.Query(ctx, archive.Query{Host: "db2"})
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		restored, err := archiver.Restore(ctx, archive.Query{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), restored)
+		assert.Equal(t, 5, db.CountJobResults())
+
+		// Restoring again does not duplicate results
+		restored, err = archiver.Restore(ctx, archive.Query{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), restored)
+		assert.Equal(t, 5, db.CountJobResults())
+	})
+
+	t.Run("RestoreDeletedJob", func(t *testing.T) {
+		// Deleting the job deletes its results, and its archived results
+		// are not restored without it
+		require.NoError(t, db.GetJobStore().DeleteJob("backup", "db1"))
+		assert.Equal(t, 0, db.CountJobResults())
+
+		restored, err := archiver.Restore(ctx, archive.Query{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), restored)
+		assert.Equal(t, 0, db.CountJobResults())
+	})
+}
+
+// fakeS3 is a minimal in-memory S3 endpoint checking request signatures
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	sum := sha256.Sum256(body)
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=test-access-key/") ||
+		!strings.Contains(auth, "SignedHeaders=host;x-amz-content-sha256;x-amz-date") ||
+		r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/archive-bucket/")
+	switch {
+	case r.Method == http.MethodPut:
+		f.objects[key] = body
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><ListBucketResult>`)
+		for _, k := range keys {
+			fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", k)
+		}
+		fmt.Fprint(w, "<IsTruncated>false</IsTruncated></ListBucketResult>")
+	case r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	default:
+		http.Error(w, "unsupported", http.StatusMethodNotAllowed)
+	}
+}
+
+func TestArchiveToS3Store(t *testing.T) {
+	s3 := &fakeS3{objects: make(map[string][]byte)}
+	endpoint := httptest.NewServer(s3)
+	defer endpoint.Close()
+
+	db := testutil.NewInMemoryTestDatabase(t)
+	now := time.Now().UTC()
+	seedArchiveResults(t, db, now, 40*24*time.Hour, 35*24*time.Hour)
+
+	store, err := archive.NewStore(&config.ArchiveConfig{
+		Destination:     "s3://archive-bucket/cronmetrics",
+		Endpoint:        endpoint.URL,
+		Region:          "eu-west-1",
+		AccessKeyID:     "test-access-key",
+		SecretAccessKey: "test-secret-key",
+	})
+	require.NoError(t, err)
+	archiver := archive.NewArchiver(db.GetJobResultStore(), store)
+	ctx := context.Background()
+
+	stats, err := archiver.Archive(ctx, now.Add(-30*24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Results)
+	assert.Equal(t, 0, db.CountJobResults())
+
+	for key := range s3.objects {
+		assert.True(t, strings.HasPrefix(key, "cronmetrics/job_results/dt="), key)
+	}
+
+	results, err := archiver.Query(ctx, archive.Query{JobName: "backup"})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	t.Run("MissingCredentials", func(t *testing.T) {
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+		_, err := archive.NewStore(&config.ArchiveConfig{Destination: "gs://bucket"})
+		assert.Error(t, err)
+	})
+
+	t.Run("UnsupportedDestination", func(t *testing.T) {
+		_, err := archive.NewStore(&config.ArchiveConfig{Destination: "ftp://example.com/archive"})
+		assert.Error(t, err)
+	})
+}
@@ -469,3 +469,32 @@ func TestAuthenticationErrorMessages(t *testing.T) {
 		assert.IsType(t, "", errorResp["timestamp"])
 	})
 }
+
+func TestSpoofedAuthHeaders(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	t.Run("AdminLevel", func(t *testing.T) {
+		// A job key claiming admin access is still refused on admin endpoints
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "X-Auth-Level": "admin"}).
+			POST("/api/job", map[string]interface{}{"job_name": "spoofed", "host": "db1"}).
+			ExpectStatus(401)
+	})
+
+	t.Run("JobIdentity", func(t *testing.T) {
+		// The identity comes from the key, not from client headers
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{
+				"X-API-Key":       "cm_test_backup_key",
+				"X-Auth-Level":    "job",
+				"X-Auth-Job-Name": "log-rotation",
+				"X-Auth-Job-Host": "web1",
+				"Content-Type":    "application/json",
+			}).
+			POST("/api/job-result", map[string]interface{}{"job_name": "log-rotation", "host": "web1", "status": "success"}).
+			ExpectStatus(403).
+			ExpectContains("job result does not match authenticated job")
+	})
+}
@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/api"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBasePath(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Server.BasePath = "/cron"
+		cfg.Server.ExternalURL = "https://proxy.example.com"
+		cfg.Dashboard = config.DashboardConfig{
+			Enabled:         true,
+			Path:            "/dashboard",
+			Title:           "Test Dashboard",
+			RefreshInterval: 5,
+			AuthRequired:    true,
+			PageSize:        25,
+			SSEHeartbeat:    30,
+		}
+	})
+	defer server.Close()
+
+	admin := testutil.NewHTTPClient(t, server.URL()+"/cron").
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()+"/cron").
+		WithHeaders(server.DashboardHeaders())
+	unprefixed := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+
+	var job model.Job
+	admin.POST("/api/job", map[string]interface{}{
+		"job_name": "backup", "host": "db1",
+	}).ExpectStatus(201).ExpectJSON(&job)
+
+	t.Run("RoutesUnderPrefix", func(t *testing.T) {
+		admin.GET("/health").ExpectStatus(200)
+		admin.GET(fmt.Sprintf("/api/job/%d", job.ID)).ExpectStatus(200)
+		admin.GET("/metrics").ExpectStatus(200).ExpectContains(`job_name="backup"`)
+
+		unprefixed.GET("/health").ExpectStatus(404)
+		unprefixed.GET("/api/job").ExpectStatus(404)
+		unprefixed.GET("/dashboard/jobs").ExpectStatus(404)
+	})
+
+	t.Run("Swagger", func(t *testing.T) {
+		admin.GET("/swagger/index.html").
+			ExpectStatus(200).
+			ExpectContains(`url: "\/cron\/api\/openapi.yaml"`)
+
+		spec := admin.GET("/api/openapi.yaml").ExpectStatus(200).BodyString()
+		assert.Contains(t, spec, "url: https://proxy.example.com/cron", "the external URL is preferred")
+		assert.NotContains(t, spec, "cronmetrics.example.com")
+		assert.Contains(t, spec, "/api/job-result:", "paths are kept")
+	})
+
+	t.Run("DashboardURLs", func(t *testing.T) {
+		page := dashboard.GET("/dashboard/jobs").ExpectStatus(200).BodyString()
+		assert.Contains(t, page, `href="/cron/dashboard/assets/tailwind.css"`)
+		assert.Contains(t, page, `sse-connect="/cron/dashboard/events"`)
+		assert.Contains(t, page, `id="dashboard-path" value="/cron/dashboard"`)
+		assert.Contains(t, page, fmt.Sprintf(`href="/cron/dashboard/jobs/%d"`, job.ID))
+
+		dashboard.GET("/dashboard/assets/dashboard.js").ExpectStatus(200)
+
+		resp := dashboard.GET("/dashboard/").ExpectStatus(200)
+		assert.Equal(t, "/cron/dashboard/jobs", resp.Request.URL.Path, "redirects stay under the prefix")
+	})
+
+	t.Run("ActionLinks", func(t *testing.T) {
+		var link api.ActionLinkResponse
+		admin.POST(fmt.Sprintf("/api/job/%d/action-link", job.ID), map[string]interface{}{"action": "maintenance"}).
+			ExpectStatus(201).
+			ExpectJSON(&link)
+
+		assert.True(t, strings.HasPrefix(link.URL, "https://proxy.example.com/cron/dashboard/actions/maintenance?"), link.URL)
+	})
+}
@@ -0,0 +1,108 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeFeed(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	poll := func(query string) *model.ChangePage {
+		var page model.ChangePage
+		admin.GET("/api/changes" + query).ExpectStatus(200).ExpectJSON(&page)
+		return &page
+	}
+
+	start := poll("?since=now")
+	assert.Empty(t, start.Changes)
+
+	var job model.Job
+	admin.POST("/api/job", map[string]interface{}{"job_name": "reports", "host": "db2"}).ExpectStatus(201).ExpectJSON(&job)
+	testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{"X-API-Key": job.ApiKey, "Content-Type": "application/json"}).
+		POST("/api/job-result", map[string]interface{}{"job_name": "reports", "host": "db2", "status": "failure"}).
+		ExpectStatus(201)
+	admin.PUT(fmt.Sprintf("/api/job/%d", job.ID), map[string]interface{}{"job_name": "reports", "host": "db2", "status": "paused"}).ExpectStatus(200)
+	admin.DELETE(fmt.Sprintf("/api/job/%d", job.ID)).ExpectStatus(204)
+
+	page := poll("?since=" + start.NextCursor)
+	require.Len(t, page.Changes, 4)
+	types := make([]string, 0, len(page.Changes))
+	for _, change := range page.Changes {
+		types = append(types, change.Type)
+	}
+	assert.Equal(t, []string{model.EventJobCreated, model.EventResultRecorded, model.EventJobUpdated, model.EventJobDeleted}, types)
+	assert.Equal(t, "reports", page.Changes[0].Job.Name)
+	assert.Empty(t, page.Changes[0].Job.ApiKey, "API keys are not served")
+	assert.Equal(t, "failure", page.Changes[1].Result.Status)
+	assert.Equal(t, "paused", page.Changes[2].Job.Status)
+	assert.Less(t, page.Changes[0].ID, page.Changes[3].ID)
+	assert.False(t, page.HasMore)
+
+	t.Run("Pages", func(t *testing.T) {
+		first := poll("?limit=3&since=" + start.NextCursor)
+		require.Len(t, first.Changes, 3)
+		assert.True(t, first.HasMore)
+
+		rest := poll("?limit=3&since=" + first.NextCursor)
+		require.Len(t, rest.Changes, 1)
+		assert.Equal(t, model.EventJobDeleted, rest.Changes[0].Type)
+
+		// Polling with no new changes keeps the cursor
+		idle := poll("?since=" + rest.NextCursor)
+		assert.Empty(t, idle.Changes)
+		assert.Equal(t, rest.NextCursor, idle.NextCursor)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		pruned, err := model.NewChangeStore(server.Database.GetJobStore().DB()).PruneChanges(time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Positive(t, pruned)
+
+		admin.GET("/api/changes?since=" + start.NextCursor).
+			ExpectStatus(410).
+			ExpectContains("cursor expired")
+
+		// The latest change is kept, so up to date pollers resume
+		assert.Empty(t, poll("?since="+page.NextCursor).Changes)
+	})
+
+	t.Run("Unrecorded", func(t *testing.T) {
+		// A change the feed refuses is not made either
+		db := server.Database.GetJobStore().DB()
+		_, err := db.Exec(`CREATE TRIGGER refuse_changes BEFORE INSERT ON changes BEGIN SELECT RAISE(ABORT, 'feed unavailable'); END`)
+		require.NoError(t, err)
+		defer db.Exec(`DROP TRIGGER refuse_changes`)
+
+		admin.POST("/api/job", map[string]interface{}{"job_name": "unrecorded", "host": "db2"}).ExpectStatus(500)
+		_, err = server.Database.GetJobStore().GetJob("unrecorded", "db2")
+		assert.Error(t, err)
+
+		backup, err := server.Database.GetJobStore().GetJob("backup", "db1")
+		require.NoError(t, err)
+		_, err = server.Database.GetJobStore().SnoozeJobByID(backup.ID, nil)
+		assert.Error(t, err)
+		err = server.Database.GetJobResultStore().CreateJobResult(&model.JobResult{JobName: "backup", Host: "db1", Status: "success", Timestamp: time.Now().UTC()})
+		assert.Error(t, err)
+		results, err := server.Database.GetJobResultStore().GetJobResults("backup", "db1", 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		admin.GET("/api/changes?since=bogus").ExpectStatus(400).ExpectContains("invalid cursor")
+		admin.GET("/api/changes?limit=0").ExpectStatus(400)
+		admin.POST("/api/changes", nil).ExpectStatus(405)
+		testutil.NewHTTPClient(t, server.URL()).GET("/api/changes").ExpectStatus(401)
+	})
+}
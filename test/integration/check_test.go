@@ -0,0 +1,69 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobCheckNow(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	submit := func(status string) {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": status}).
+			ExpectStatus(201)
+	}
+	check := func() metrics.JobCheck {
+		var result metrics.JobCheck
+		admin.POST("/api/job/1/check", nil).ExpectStatus(200).ExpectJSON(&result)
+		return result
+	}
+
+	t.Run("API", func(t *testing.T) {
+		submit("failure")
+		result := check()
+		assert.Equal(t, 1, result.JobID)
+		assert.Equal(t, "failure", result.Reason)
+		assert.Equal(t, float64(0), result.Value)
+		assert.WithinDuration(t, time.Now(), result.CheckedAt, time.Minute)
+		assert.Contains(t, result.Metrics, `cronjob_status{job_name="backup",host="db1",env="prod",type="backup"} 0`)
+
+		// A fixed job is reported as such right away
+		submit("success")
+		assert.Equal(t, "success", check().Reason)
+
+		// Changes made behind the server's back are seen too
+		server.Database.Exec(`UPDATE jobs SET last_reported_at = ? WHERE id = 1`, time.Now().UTC().Add(-48*time.Hour))
+		result = check()
+		assert.Equal(t, "missed_deadline", result.Reason)
+		assert.Equal(t, float64(-2), result.Value)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		admin.POST("/api/job/999/check", nil).ExpectStatus(404)
+		admin.GET("/api/job/1/check").ExpectStatus(405)
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"}).
+			POST("/api/job/1/check", nil).
+			ExpectStatus(401)
+	})
+
+	t.Run("Dashboard", func(t *testing.T) {
+		dashboard := testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(server.DashboardHeaders()).
+			WithCookies()
+
+		dashboard.GET("/dashboard/jobs/1").ExpectStatus(200).ExpectContains(`action="/dashboard/jobs/1/check"`)
+		dashboard.PostForm("/dashboard/jobs/1/check", nil).
+			ExpectStatus(200).
+			ExpectContains("Checked backup@db1: missed deadline")
+	})
+}
@@ -0,0 +1,110 @@
+package integration
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowedCIDRs(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	var job model.Job
+	admin.POST("/api/job", map[string]interface{}{
+		"job_name": "backup", "host": "db1", "api_key": "cm_cidr_key",
+		"allowed_cidrs": []string{"10.1.2.3/8", "127.0.0.1", "::1"},
+	}).ExpectStatus(201).ExpectJSON(&job)
+	assert.Equal(t, []string{"10.0.0.0/8", "127.0.0.1/32", "::1/128"}, job.AllowedCIDRs, "stored in canonical form")
+
+	submit := func() *testutil.HTTPResponse {
+		return testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_cidr_key", "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "success"})
+	}
+	jobPath := fmt.Sprintf("/api/job/%d", job.ID)
+
+	t.Run("Allowed", func(t *testing.T) {
+		submit().ExpectStatus(201)
+	})
+
+	t.Run("Denied", func(t *testing.T) {
+		admin.PUT(jobPath, map[string]interface{}{"allowed_cidrs": []string{"10.0.0.0/8"}}).ExpectStatus(200)
+		submit().ExpectStatus(403).ExpectContains("source address not allowed for this job")
+
+		var page model.RejectionPage
+		admin.GET("/api/result-rejections").ExpectStatus(200).ExpectJSON(&page)
+		require.Len(t, page.Rejections, 1)
+		assert.Equal(t, model.RejectionSourceDenied, page.Rejections[0].Reason)
+		assert.Equal(t, "backup", page.Rejections[0].JobName)
+		assert.Equal(t, "127.0.0.1", page.Rejections[0].Source)
+		assert.Empty(t, page.Rejections[0].KeyPrefix, "the key was valid")
+
+		body := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, `cronmetrics_rejected_results_total{reason="source_denied"} 1`)
+	})
+
+	t.Run("Cleared", func(t *testing.T) {
+		var updated model.Job
+		admin.PUT(jobPath, map[string]interface{}{"allowed_cidrs": []string{}}).ExpectStatus(200).ExpectJSON(&updated)
+		assert.Empty(t, updated.AllowedCIDRs)
+		submit().ExpectStatus(201)
+
+		// Omitting the field leaves the ranges unchanged
+		admin.PUT(jobPath, map[string]interface{}{"allowed_cidrs": []string{"10.0.0.0/8"}}).ExpectStatus(200)
+		admin.PUT(jobPath, map[string]interface{}{"automatic_failure_threshold": 600}).ExpectStatus(200).ExpectJSON(&updated)
+		assert.Equal(t, []string{"10.0.0.0/8"}, updated.AllowedCIDRs)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		admin.POST("/api/job", map[string]interface{}{"job_name": "other", "host": "db1", "allowed_cidrs": []string{"10.0.0.0/33"}}).
+			ExpectStatus(400).
+			ExpectContains(`invalid allowed_cidrs: invalid CIDR \"10.0.0.0/33\"`)
+		admin.PUT(jobPath, map[string]interface{}{"allowed_cidrs": []string{"db1.example.com"}}).ExpectStatus(400)
+		admin.POST("/api/job/reconcile", map[string]interface{}{
+			"jobs": []map[string]interface{}{{"job_name": "other", "host": "db1", "allowed_cidrs": []string{"nope"}}},
+		}).ExpectStatus(400).ExpectContains("jobs[0]: invalid allowed_cidrs")
+	})
+
+	t.Run("Dashboard", func(t *testing.T) {
+		dashboard := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders())
+		body := dashboard.GET(fmt.Sprintf("/dashboard/jobs/%d", job.ID)).ExpectStatus(200).BodyString()
+		assert.Contains(t, body, "Allowed Sources")
+		assert.Contains(t, body, "10.0.0.0/8")
+
+		dashboard.PostForm(fmt.Sprintf("/dashboard/jobs/%d", job.ID), url.Values{
+			"name": {"backup"}, "host": {"db1"}, "allowed_cidrs": {"bogus"},
+		}).ExpectStatus(400).ExpectContains("Allowed sources must be addresses or CIDR ranges")
+
+		stored, err := server.Database.GetJobStore().GetJobByID(job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.0/8"}, stored.AllowedCIDRs)
+	})
+}
+
+func TestJobAllowsSource(t *testing.T) {
+	job := &model.Job{AllowedCIDRs: []string{"10.0.0.0/8", "2001:db8::/32"}}
+
+	tests := []struct {
+		addr    string
+		allowed bool
+	}{
+		{"10.20.30.40", true},
+		{"::ffff:10.20.30.40", true},
+		{"11.0.0.1", false},
+		{"2001:db8::1", true},
+		{"2001:db9::1", false},
+		{"@", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, job.AllowsSource(tt.addr), tt.addr)
+	}
+
+	assert.True(t, (&model.Job{}).AllowsSource("@"), "no ranges allow any source")
+}
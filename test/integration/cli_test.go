@@ -2,15 +2,27 @@ package integration
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/api"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/unixsocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestCLIServeCommand(t *testing.T) {
@@ -237,6 +249,43 @@ func TestCLIJobCommands(t *testing.T) {
 		showResult.ExpectFailure().
 			ExpectStderrContains("not found")
 	})
+
+	t.Run("JobHumaneDurations", func(t *testing.T) {
+		addResult := cliTest.RunCommand("job", "add",
+			"--name", "humane-test",
+			"--host", "test-host",
+			"--threshold", "90m")
+		addResult.ExpectSuccess()
+
+		var jobID string
+		for _, line := range strings.Split(addResult.Stdout, "\n") {
+			if parts := strings.Split(line, " "); strings.Contains(line, "Job ID") && len(parts) >= 3 {
+				jobID = parts[2]
+				break
+			}
+		}
+		require.NotEmpty(t, jobID, "Could not extract job ID from output")
+
+		cliTest.RunCommand("job", "show", jobID).
+			ExpectSuccess().
+			ExpectStdoutContains("Threshold: 5400 seconds (1h30m)")
+
+		cliTest.RunCommand("job", "update", jobID, "--threshold", "1d").ExpectSuccess()
+		cliTest.RunCommand("job", "show", jobID).
+			ExpectSuccess().
+			ExpectStdoutContains("Threshold: 86400 seconds (1d)")
+
+		cliTest.RunCommand("job", "snooze", jobID, "--for", "1d").
+			ExpectSuccess().
+			ExpectStdoutContains("snoozed until")
+
+		cliTest.RunCommand("job", "update", jobID, "--threshold", "soon").
+			ExpectFailure().
+			ExpectStderrContains("invalid --threshold")
+		cliTest.RunCommand("job", "add", "--name", "humane-zero", "--host", "test-host", "--threshold", "0").
+			ExpectFailure().
+			ExpectStderrContains("--threshold must be positive")
+	})
 }
 
 func TestCLIConfigCommand(t *testing.T) {
@@ -261,6 +310,166 @@ func TestCLIConfigCommand(t *testing.T) {
 			ExpectStdoutContains("logging:").
 			ExpectStdoutContains("security:")
 	})
+
+	t.Run("ConfigRender", func(t *testing.T) {
+		cliTest.CreateDefaultTestConfig()
+
+		result := cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render",
+			"--set", "server.port=9090",
+			"--set", "dashboard.enabled=true",
+			"--set", "security.admin_api_keys=[key-one, key-two]")
+		result.ExpectSuccess()
+
+		var rendered map[string]map[string]interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(result.Stdout), &rendered))
+		assert.Equal(t, 9090, rendered["server"]["port"])
+		assert.Equal(t, "localhost", rendered["server"]["host"], "values from the config file are kept")
+		assert.Equal(t, true, rendered["dashboard"]["enabled"])
+		assert.Equal(t, "/dashboard", rendered["dashboard"]["path"], "defaults are included")
+		assert.Equal(t, []interface{}{"key-one", "key-two"}, rendered["security"]["admin_api_keys"])
+	})
+
+	t.Run("ConfigRenderDirectory", func(t *testing.T) {
+		confDir := filepath.Join(cliTest.TempDir, "conf.d")
+		require.NoError(t, os.MkdirAll(confDir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(confDir, "10-base.yaml"), []byte("server:\n  port: 8081\n  host: 127.0.0.1\nlogging:\n  level: warn\nsecurity:\n  require_https: false\n"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(confDir, "20-override.yml"), []byte("server:\n  port: 8082\n"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(confDir, ".hidden.yaml"), []byte("server:\n  port: 1\n"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(confDir, "README"), []byte("not config"), 0o600))
+
+		result := cliTest.WithEnv("CRONMETRICS_LOGGING_LEVEL", "error").
+			RunCommand("--config", confDir, "config", "render")
+		result.ExpectSuccess()
+
+		var rendered map[string]map[string]interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(result.Stdout), &rendered))
+		assert.Equal(t, 8082, rendered["server"]["port"], "later fragments win")
+		assert.Equal(t, "127.0.0.1", rendered["server"]["host"], "fragments are merged, not replaced")
+		assert.Equal(t, "error", rendered["logging"]["level"], "environment overrides files")
+	})
+
+	t.Run("ConfigRenderDurations", func(t *testing.T) {
+		cliTest.CreateDefaultTestConfig()
+
+		result := cliTest.WithEnv("CRONMETRICS_SLO_BURN_WINDOW", "2h").
+			RunCommand("--config", cliTest.ConfigFile, "config", "render",
+				"--set", "archive.retention_days=12w",
+				"--set", "server.read_timeout=1m30s",
+				"--set", "server.idle_timeout=600")
+		result.ExpectSuccess()
+
+		var rendered map[string]map[string]interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(result.Stdout), &rendered))
+		assert.Equal(t, 84, rendered["archive"]["retention_days"], "day settings are converted to days")
+		assert.Equal(t, 90, rendered["server"]["read_timeout"], "second settings are converted to seconds")
+		assert.Equal(t, 600, rendered["server"]["idle_timeout"], "numbers keep their unit")
+		assert.Equal(t, 7200, rendered["slo"]["burn_window"], "environment variables accept durations")
+
+		cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render", "--set", "archive.retention_days=36h").
+			ExpectFailure().
+			ExpectStderrContains("not a whole number of days")
+		cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render", "--set", "server.read_timeout=soon").
+			ExpectFailure().
+			ExpectStderrContains("server.read_timeout")
+	})
+
+	t.Run("ConfigRenderDefaults", func(t *testing.T) {
+		cliTest.CreateDefaultTestConfig()
+		config, err := os.ReadFile(cliTest.ConfigFile)
+		require.NoError(t, err)
+		cliTest.CreateTestConfig(string(config) + "\ndefaults:\n  automatic_failure_threshold: 2h\n  rules:\n    - labels: {team: data}\n      automatic_failure_threshold: 6h\n")
+
+		result := cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render")
+		result.ExpectSuccess()
+
+		var rendered struct {
+			Defaults struct {
+				Threshold int `yaml:"automatic_failure_threshold"`
+				Rules     []struct {
+					Threshold int `yaml:"automatic_failure_threshold"`
+				} `yaml:"rules"`
+			} `yaml:"defaults"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(result.Stdout), &rendered))
+		assert.Equal(t, 7200, rendered.Defaults.Threshold)
+		require.Len(t, rendered.Defaults.Rules, 1)
+		assert.Equal(t, 21600, rendered.Defaults.Rules[0].Threshold, "rule thresholds accept durations")
+
+		cliTest.CreateTestConfig(string(config) + "\ndefaults:\n  rules:\n    - automatic_failure_threshold: 60\n")
+		cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render").
+			ExpectFailure().
+			ExpectStderrContains("defaults rule 0: labels cannot be empty")
+	})
+
+	t.Run("ConfigScrape", func(t *testing.T) {
+		cliTest.CreateDefaultTestConfig()
+		config, err := os.ReadFile(cliTest.ConfigFile)
+		require.NoError(t, err)
+		cliTest.CreateTestConfig(strings.Replace(string(config), `  path: "/metrics"`,
+			"  path: \"/metrics\"\n  auth: bearer\n  bearer_token: \"scrape-token\"", 1))
+
+		result := cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "scrape", "--server", "https://cron.example.com/base")
+		result.ExpectSuccess()
+
+		var snippet struct {
+			ScrapeConfigs []struct {
+				Scheme        string `yaml:"scheme"`
+				MetricsPath   string `yaml:"metrics_path"`
+				Authorization struct {
+					Type        string `yaml:"type"`
+					Credentials string `yaml:"credentials"`
+				} `yaml:"authorization"`
+				StaticConfigs []struct {
+					Targets []string `yaml:"targets"`
+				} `yaml:"static_configs"`
+			} `yaml:"scrape_configs"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(result.Stdout), &snippet))
+		require.Len(t, snippet.ScrapeConfigs, 1)
+		scrape := snippet.ScrapeConfigs[0]
+		assert.Equal(t, "https", scrape.Scheme)
+		assert.Equal(t, "/base/metrics", scrape.MetricsPath)
+		assert.Equal(t, "Bearer", scrape.Authorization.Type)
+		assert.Equal(t, "scrape-token", scrape.Authorization.Credentials)
+		require.Len(t, scrape.StaticConfigs, 1)
+		assert.Equal(t, []string{"cron.example.com:443"}, scrape.StaticConfigs[0].Targets)
+
+		// Without --server, the configured listen address is scraped
+		cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "scrape").
+			ExpectSuccess().
+			ExpectStdoutContains("localhost:8080")
+		cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "scrape", "--server", "unix:/run/cronmetrics.sock").
+			ExpectFailure().
+			ExpectStderrContains("prometheus cannot scrape a unix socket")
+	})
+
+	t.Run("ConfigRenderInvalid", func(t *testing.T) {
+		cliTest.CreateDefaultTestConfig()
+
+		cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render", "--set", "server.port=0").
+			ExpectFailure().
+			ExpectStderrContains("invalid server port")
+
+		cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render", "--set", "metrics.paused_status=hidden").
+			ExpectFailure().
+			ExpectStderrContains(`metrics paused status must be a number or \"exclude\"`)
+
+		cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render", "--set", "metrics.auth=basic").
+			ExpectFailure().
+			ExpectStderrContains("metrics basic auth requires a username and a password")
+
+		cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render", "--set", "metrics.allowed_cidrs=[10.0.0.0/33]").
+			ExpectFailure().
+			ExpectStderrContains(`metrics allowed CIDR \"10.0.0.0/33\" is not a CIDR or an address`)
+
+		cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render", "--set", "server.port").
+			ExpectFailure().
+			ExpectStderrContains("must be key=value")
+
+		cliTest.RunCommand("--config", t.TempDir(), "config", "render").
+			ExpectFailure().
+			ExpectStderrContains("no config files")
+	})
 }
 
 func TestCLIGlobalFlags(t *testing.T) {
@@ -353,3 +562,599 @@ func TestCLIErrorHandling(t *testing.T) {
 		// Should fail due to missing required flags
 	})
 }
+
+func TestCLIDevSeed(t *testing.T) {
+	// Ensure binary is built
+	buildBinary(t)
+
+	cliTest := testutil.NewCLITest(t)
+	cliTest.CreateDefaultTestConfig()
+
+	t.Run("Seed", func(t *testing.T) {
+		cliTest.RunCommand("dev", "seed", "--jobs", "25", "--history", "2d", "--seed", "7").
+			ExpectSuccess().
+			ExpectStdoutContains("Created 25 jobs with")
+
+		result := cliTest.RunCommand("job", "list")
+		result.ExpectSuccess()
+		assert.Contains(t, result.Stdout, "backup")
+		assert.Contains(t, result.Stdout, "metrics-rollup")
+	})
+
+	t.Run("ExistingJobsAreSkipped", func(t *testing.T) {
+		cliTest.RunCommand("dev", "seed", "--jobs", "30", "--history", "2d", "--seed", "7").
+			ExpectSuccess().
+			ExpectStdoutContains("Created 5 jobs with").
+			ExpectStdoutContains("Skipped 25 jobs that already exist")
+	})
+
+	t.Run("InvalidHistory", func(t *testing.T) {
+		result := cliTest.RunCommand("dev", "seed", "--history", "soon")
+		result.ExpectFailure()
+		assert.Contains(t, result.Stdout+result.Stderr, "invalid --history")
+	})
+}
+
+func TestCLIPing(t *testing.T) {
+	// Ensure binary is built
+	buildBinary(t)
+
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+
+	cliTest := testutil.NewCLITest(t)
+
+	t.Run("Healthy", func(t *testing.T) {
+		cliTest.RunCommand("ping", "--url", server.URL()).
+			ExpectSuccess().
+			ExpectStdoutContains("OK /health")
+	})
+
+	t.Run("Unhealthy", func(t *testing.T) {
+		cliTest.RunCommand("ping", "--url", server.URL(), "--path", "/missing").ExpectFailure()
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		closedURL := "http://" + listener.Addr().String()
+		listener.Close()
+		cliTest.RunCommand("ping", "--url", closedURL, "--timeout", "1s").ExpectFailure()
+	})
+
+	t.Run("ConfiguredSocket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cronmetrics.sock")
+		listener, err := unixsocket.Listen(path, 0o660)
+		require.NoError(t, err)
+		socketServer := &http.Server{Handler: server.Server.Config.Handler}
+		go func() { _ = socketServer.Serve(listener) }()
+		defer socketServer.Close()
+
+		cliTest.CreateTestConfig(fmt.Sprintf("server:\n  listen: %q\nsecurity:\n  require_https: false\n", unixsocket.Prefix+path))
+		cliTest.RunCommand("ping").
+			ExpectSuccess().
+			ExpectStdoutContains("OK /health")
+	})
+}
+
+func TestCLIOutputFormats(t *testing.T) {
+	// Ensure binary is built
+	buildBinary(t)
+
+	cliTest := testutil.NewCLITest(t)
+	cliTest.CreateDefaultTestConfig()
+	cliTest.RunCommand("job", "add", "--name", "backup", "--host", "db1", "--label", "owner=dba", "--label", "env=prod",
+		"--allowed-cidr", "10.0.0.0/8").ExpectSuccess()
+	cliTest.RunCommand("job", "add", "--name", "cleanup", "--host", "web1", "--quiet").
+		ExpectSuccess().
+		ExpectStdoutContains("2\n")
+
+	t.Run("JSON", func(t *testing.T) {
+		result := cliTest.RunCommand("job", "list", "-o", "json")
+		result.ExpectSuccess()
+
+		var jobs []map[string]any
+		require.NoError(t, json.Unmarshal([]byte(result.Stdout), &jobs))
+		require.Len(t, jobs, 2)
+		for _, field := range []string{"id", "job_name", "host", "status", "automatic_failure_threshold", "labels", "last_reported_at"} {
+			assert.Contains(t, jobs[0], field)
+		}
+		assert.Equal(t, "backup", jobs[0]["job_name"])
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		result := cliTest.RunCommand("job", "show", "1", "-o", "yaml")
+		result.ExpectSuccess().
+			ExpectStdoutContains("id: 1\n").
+			ExpectStdoutContains("job_name: backup\n").
+			ExpectStdoutContains("automatic_failure_threshold: 3600\n").
+			ExpectStdoutContains("  owner: dba\n").
+			ExpectStdoutContains("allowed_cidrs:\n  - 10.0.0.0/8\n")
+	})
+
+	t.Run("Wide", func(t *testing.T) {
+		result := cliTest.RunCommand("job", "list", "-o", "wide")
+		result.ExpectSuccess().
+			ExpectStdoutContains("OWNER").
+			ExpectStdoutContains("ALLOWED_CIDRS").
+			ExpectStdoutContains("dba").
+			ExpectStdoutContains("10.0.0.0/8").
+			ExpectStdoutContains("env=prod,owner=dba")
+		assert.NotContains(t, cliTest.RunCommand("job", "list").Stdout, "OWNER")
+	})
+
+	t.Run("Quiet", func(t *testing.T) {
+		cliTest.RunCommand("job", "list", "-q", "--label", "env=prod").
+			ExpectSuccess().
+			ExpectStdoutContains("1\n")
+		assert.Equal(t, "1\n2\n", cliTest.RunCommand("job", "list", "--quiet").Stdout)
+	})
+
+	t.Run("DeprecatedJSONFlag", func(t *testing.T) {
+		result := cliTest.RunCommand("job", "show", "1", "--json")
+		result.ExpectSuccess().
+			ExpectStdoutContains(`"job_name": "backup"`).
+			ExpectStderrContains("use --output json instead")
+	})
+
+	t.Run("InvalidFormat", func(t *testing.T) {
+		cliTest.RunCommand("job", "list", "-o", "xml").
+			ExpectFailure().
+			ExpectStderrContains(`invalid output format \"xml\"`)
+	})
+}
+
+func TestCLIJobStatus(t *testing.T) {
+	// Ensure binary is built
+	buildBinary(t)
+
+	cliTest := testutil.NewCLITest(t)
+	cliTest.CreateDefaultTestConfig()
+	cliTest.RunCommand("job", "add", "--name", "healthy", "--host", "db1").ExpectSuccess()
+	cliTest.RunCommand("job", "add", "--name", "failing", "--host", "db1").ExpectSuccess()
+	cliTest.RunCommand("job", "add", "--name", "maintenance", "--host", "db1", "--status", "maintenance").ExpectSuccess()
+	cliTest.RunCommand("job", "add", "--name", "missed", "--host", "db1").ExpectSuccess()
+
+	db, err := model.NewDatabase(cliTest.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, model.NewJobResultStore(db.GetDB()).CreateJobResult(&model.JobResult{
+		JobName: "failing", Host: "db1", Status: "failure", Timestamp: time.Now().UTC(),
+	}))
+	_, err = db.GetDB().Exec("UPDATE jobs SET last_reported_at = ? WHERE name = 'missed'", time.Now().UTC().Add(-2*time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	cliTest.RunCommand("job", "status", "1").
+		ExpectExitCode(0).
+		ExpectStdoutContains("healthy@db1: success")
+	cliTest.RunCommand("job", "status", "2").
+		ExpectExitCode(1).
+		ExpectStdoutContains("failing@db1: failure")
+	cliTest.RunCommand("job", "status", "3").
+		ExpectExitCode(2).
+		ExpectStdoutContains("maintenance@db1: maintenance")
+	cliTest.RunCommand("job", "status", "4").
+		ExpectExitCode(3).
+		ExpectStdoutContains("missed@db1: missed_deadline (last reported 2h")
+
+	result := cliTest.RunCommand("job", "status", "4", "--quiet")
+	result.ExpectExitCode(3)
+	assert.Empty(t, result.Stdout)
+
+	cliTest.RunCommand("job", "status", "99").
+		ExpectExitCode(4).
+		ExpectStderrContains("failed to check job status")
+}
+
+func TestCLIJobScaffold(t *testing.T) {
+	// Ensure binary is built
+	buildBinary(t)
+
+	cliTest := testutil.NewCLITest(t)
+	cliTest.CreateDefaultTestConfig()
+	cliTest.RunCommand("job", "add", "--name", "backup", "--host", "db1", "--api-key", "cm_scaffold_key").ExpectSuccess()
+
+	t.Run("Cron", func(t *testing.T) {
+		cliTest.RunCommand("job", "scaffold", "1", "--schedule", "0 3 * * 1-5", "--server", "https://cron.example.com",
+			"--", "/usr/local/bin/backup.sh", "--target", "daily backups", "100%").
+			ExpectSuccess().
+			ExpectStdoutContains("0 3 * * 1-5 CRONMETRICS_URL=https://cron.example.com CRONMETRICS_JOB_NAME=backup " +
+				"CRONMETRICS_JOB_HOST=db1 CRONMETRICS_API_KEY=cm_scaffold_key /usr/local/bin/cronmetrics wrap -- " +
+				`/usr/local/bin/backup.sh --target 'daily backups' 100\%`)
+	})
+
+	t.Run("Systemd", func(t *testing.T) {
+		result := cliTest.RunCommand("job", "scaffold", "1", "--type", "systemd", "--schedule", "*/15 2,14 * * 1-5", "--sign",
+			"--", "/usr/local/bin/backup.sh")
+		result.ExpectSuccess().
+			ExpectStdoutContains("# /etc/systemd/system/cronmetrics-backup-db1.service").
+			ExpectStdoutContains("Environment=CRONMETRICS_API_KEY=cm_scaffold_key").
+			ExpectStdoutContains("ExecStart=/usr/local/bin/cronmetrics wrap --sign -- /usr/local/bin/backup.sh").
+			ExpectStdoutContains("# /etc/systemd/system/cronmetrics-backup-db1.timer").
+			ExpectStdoutContains("OnCalendar=Mon..Fri *-*-* 2,14:0/15:00")
+		// The server URL defaults to the configured address
+		assert.Contains(t, result.Stdout, "Environment=CRONMETRICS_URL=http://localhost:8080")
+	})
+
+	t.Run("InvalidSchedule", func(t *testing.T) {
+		result := cliTest.RunCommand("job", "scaffold", "1", "--schedule", "0 3 * *", "--", "/bin/true")
+		result.ExpectFailure()
+		assert.Contains(t, result.Stdout+result.Stderr, "5 fields")
+
+		// systemd cannot run on either a day or a weekday, cron can
+		cliTest.RunCommand("job", "scaffold", "1", "--schedule", "0 3 1 * 1", "--", "/bin/true").ExpectSuccess()
+		result = cliTest.RunCommand("job", "scaffold", "1", "--type", "systemd", "--schedule", "0 3 1 * 1", "--", "/bin/true")
+		result.ExpectFailure()
+		assert.Contains(t, result.Stdout+result.Stderr, "restricts both the day and the weekday")
+	})
+
+	t.Run("JobSchedule", func(t *testing.T) {
+		result := cliTest.RunCommand("job", "scaffold", "1", "--", "/bin/true")
+		result.ExpectFailure()
+		assert.Contains(t, result.Stdout+result.Stderr, "has no schedule")
+
+		cliTest.RunCommand("job", "update", "1", "--schedule", "0 3 * *").ExpectFailure()
+		cliTest.RunCommand("job", "update", "1", "--schedule", "30 4 * * 0").ExpectSuccess()
+		cliTest.RunCommand("job", "show", "1").
+			ExpectSuccess().
+			ExpectStdoutContains("Schedule: 30 4 * * 0 (UTC)")
+		cliTest.RunCommand("job", "scaffold", "1", "--", "/bin/true").
+			ExpectSuccess().
+			ExpectStdoutContains("30 4 * * 0 CRONMETRICS_URL=")
+
+		cliTest.RunCommand("job", "update", "1", "--schedule", "").ExpectSuccess()
+		result = cliTest.RunCommand("job", "show", "1")
+		result.ExpectSuccess()
+		assert.NotContains(t, result.Stdout, "Schedule:")
+	})
+}
+
+func TestCLIJobApply(t *testing.T) {
+	// Ensure binary is built
+	buildBinary(t)
+
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	cliTest := testutil.NewCLITest(t).WithEnv("CRONMETRICS_ADMIN_API_KEY", "admin-api-key")
+	cliTest.CreateDefaultTestConfig()
+	specFile := filepath.Join(cliTest.TempDir, "job.yaml")
+	writeSpec := func(spec string) {
+		require.NoError(t, os.WriteFile(specFile, []byte(spec), 0o600))
+	}
+	getJob := func(name, host string) *model.Job {
+		job, err := server.Database.GetJobStore().GetJob(name, host)
+		require.NoError(t, err)
+		return job
+	}
+
+	t.Run("DryRun", func(t *testing.T) {
+		before := getJob("backup", "db1")
+		writeSpec("job_name: backup\nhost: db1\nautomatic_failure_threshold: 2h\nstatus: maintenance\n")
+
+		result := cliTest.RunCommand("job", "apply", "-f", specFile, "--server", server.URL(), "--dry-run")
+		result.ExpectSuccess().
+			ExpectStdoutContains("Job 'backup@db1' would be updated:").
+			ExpectStdoutContains(fmt.Sprintf("  automatic_failure_threshold: %d -> 7200\n", before.AutomaticFailureThreshold)).
+			ExpectStdoutContains(`  status: "active" -> "maintenance"`)
+		assert.NotContains(t, result.Stdout, "labels")
+
+		// Nothing changed
+		assert.Equal(t, before.Status, getJob("backup", "db1").Status)
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		labels := getJob("backup", "db1").Labels
+		cliTest.RunCommand("job", "apply", "-f", specFile, "--server", server.URL()).
+			ExpectSuccess().
+			ExpectStdoutContains("('backup@db1') updated")
+
+		job := getJob("backup", "db1")
+		assert.Equal(t, "maintenance", job.Status)
+		assert.Equal(t, 7200, job.AutomaticFailureThreshold)
+		// Fields missing from the spec are kept
+		assert.Equal(t, labels, job.Labels)
+
+		cliTest.RunCommand("job", "apply", "-f", specFile, "--server", server.URL(), "--dry-run").
+			ExpectSuccess().
+			ExpectStdoutContains("Job 'backup@db1' is up to date")
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		writeSpec(`{"job_name": "reindex", "host": "search1", "labels": {"tier": 1}}`)
+		result := cliTest.RunCommand("job", "apply", "-f", specFile, "--server", server.URL(), "-q")
+		result.ExpectSuccess()
+
+		job := getJob("reindex", "search1")
+		assert.Equal(t, fmt.Sprintf("%d\n", job.ID), result.Stdout)
+		assert.Equal(t, map[string]string{"tier": "1"}, job.Labels)
+	})
+
+	t.Run("ShowOutputRoundTrips", func(t *testing.T) {
+		// Server-managed fields printed by "job show -o yaml" are ignored
+		job := getJob("backup", "db1")
+		writeSpec(fmt.Sprintf("id: %d\njob_name: backup\nhost: db1\nstatus: maintenance\nlast_reported_at: 2024-01-01T00:00:00Z\n", job.ID))
+		cliTest.RunCommand("job", "apply", "-f", specFile, "--server", server.URL(), "--dry-run").
+			ExpectSuccess().
+			ExpectStdoutContains("is up to date")
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		writeSpec("job_name: backup\n")
+		cliTest.RunCommand("job", "apply", "-f", specFile, "--server", server.URL()).
+			ExpectFailure().
+			ExpectStderrContains("job name and host are required")
+
+		writeSpec("job_name: backup\nhost: db1\n")
+		cliTest.RunCommand("job", "apply", "-f", specFile, "--server", server.URL(), "--admin-key", "wrong").
+			ExpectFailure().
+			ExpectStderrContains("401")
+	})
+}
+
+func TestCLIJobTest(t *testing.T) {
+	// Ensure binary is built
+	buildBinary(t)
+
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	cliTest := testutil.NewCLITest(t).WithEnv("CRONMETRICS_ADMIN_API_KEY", "admin-api-key")
+	cliTest.CreateDefaultTestConfig()
+
+	cliTest.RunCommand("job", "test", "1", "--server", server.URL()).
+		ExpectSuccess().
+		ExpectStdoutContains("auth").
+		ExpectStdoutContains("notification  skipped  tickets are disabled").
+		ExpectStdoutContains("Test passed")
+
+	results, err := server.Database.GetJobResultStore().GetJobResults("backup", "db1", 10)
+	require.NoError(t, err)
+	assert.Empty(t, results, "the test result is removed")
+
+	result := cliTest.RunCommand("job", "test", "1", "--server", server.URL(), "--keep", "--status", "failure", "-o", "json")
+	result.ExpectSuccess()
+	var report api.TestSubmissionResponse
+	require.NoError(t, json.Unmarshal([]byte(result.Stdout), &report))
+	assert.True(t, report.Kept)
+	assert.Equal(t, "failure", report.Result.Status)
+
+	cliTest.RunCommand("job", "test", "1", "--server", server.URL(), "--status", "maybe").
+		ExpectFailure().
+		ExpectStderrContains("status must be")
+}
+
+func TestCLIWrap(t *testing.T) {
+	// Ensure binary is built
+	buildBinary(t)
+
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	cliTest := testutil.NewCLITest(t).
+		WithEnv("CRONMETRICS_URL", server.URL()).
+		WithEnv("CRONMETRICS_JOB_NAME", "backup").
+		WithEnv("CRONMETRICS_JOB_HOST", "db1").
+		WithEnv("CRONMETRICS_API_KEY", "cm_test_backup_key")
+	results := func() []*model.JobResult {
+		results, err := server.Database.GetJobResultStore().GetJobResults("backup", "db1", 10)
+		require.NoError(t, err)
+		return results
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		cliTest.RunCommand("wrap", "--", "echo", "backup complete").
+			ExpectSuccess().
+			ExpectStdoutContains("backup complete")
+
+		latest := results()
+		require.NotEmpty(t, latest)
+		assert.Equal(t, "success", latest[0].Status)
+		assert.Equal(t, "backup complete\n", latest[0].Output)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		// The command's exit code is kept
+		result := cliTest.RunCommand("wrap", "--sign", "--", "ls", "/nonexistent-backup-dir")
+		result.ExpectExitCode(2)
+		assert.Contains(t, result.Stderr, "nonexistent-backup-dir")
+
+		latest := results()
+		require.NotEmpty(t, latest)
+		assert.Equal(t, "failure", latest[0].Status)
+		assert.Contains(t, latest[0].Output, "nonexistent-backup-dir")
+		assert.Contains(t, latest[0].Output, "exit status 2")
+	})
+
+	t.Run("ReportAlias", func(t *testing.T) {
+		cliTest.RunCommand("report", "--api-key", "cm_test_backup_key", "--", "echo", "reported run").
+			ExpectSuccess()
+
+		latest := results()
+		require.NotEmpty(t, latest)
+		assert.Equal(t, "reported run\n", latest[0].Output)
+		require.NotNil(t, latest[0].ExitCode)
+		assert.Equal(t, 0, *latest[0].ExitCode)
+	})
+
+	t.Run("RetriesTemporaryFailures", func(t *testing.T) {
+		// The first submission is answered 503, the retry reaches the server
+		var attempts atomic.Int32
+		flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) == 1 {
+				http.Error(w, "starting up", http.StatusServiceUnavailable)
+				return
+			}
+			server.Server.Config.Handler.ServeHTTP(w, r)
+		}))
+		defer flaky.Close()
+
+		result := testutil.NewCLITest(t).
+			WithEnv("CRONMETRICS_URL", flaky.URL).
+			WithEnv("CRONMETRICS_JOB_NAME", "backup").
+			WithEnv("CRONMETRICS_JOB_HOST", "db1").
+			WithEnv("CRONMETRICS_API_KEY", "cm_test_backup_key").
+			RunCommand("wrap", "--breaker-state", filepath.Join(t.TempDir(), "breaker.json"), "--", "echo", "retried run")
+		result.ExpectSuccess()
+		assert.Contains(t, result.Stderr, "retrying")
+		assert.Equal(t, int32(2), attempts.Load())
+
+		latest := results()
+		require.NotEmpty(t, latest)
+		assert.Equal(t, "retried run\n", latest[0].Output)
+	})
+
+	t.Run("PingStart", func(t *testing.T) {
+		var statuses, runIDs []string
+		recording := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var result model.JobResult
+			_ = json.Unmarshal(body, &result)
+			statuses = append(statuses, result.Status)
+			runIDs = append(runIDs, result.RunID)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			server.Server.Config.Handler.ServeHTTP(w, r)
+		}))
+		defer recording.Close()
+
+		testutil.NewCLITest(t).
+			WithEnv("CRONMETRICS_URL", recording.URL).
+			WithEnv("CRONMETRICS_JOB_NAME", "backup").
+			WithEnv("CRONMETRICS_JOB_HOST", "db1").
+			WithEnv("CRONMETRICS_API_KEY", "cm_test_backup_key").
+			RunCommand("wrap", "--breaker-state", filepath.Join(t.TempDir(), "breaker.json"), "--ping-start", "--", "echo", "started run").
+			ExpectSuccess()
+		assert.Equal(t, []string{"started", "success"}, statuses)
+		// Both belong to the same run
+		require.Len(t, runIDs, 2)
+		assert.NotEmpty(t, runIDs[0])
+		assert.Equal(t, runIDs[0], runIDs[1])
+
+		// The result finished the run, which is not lost
+		lost, err := server.Database.GetJobResultStore().RecordLostRuns(time.Now().Add(48*time.Hour), time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, lost)
+	})
+
+	t.Run("CircuitBreaker", func(t *testing.T) {
+		unreachable := testutil.NewCLITest(t).
+			WithEnv("CRONMETRICS_URL", "unix:/nonexistent/cronmetrics.sock").
+			WithEnv("CRONMETRICS_JOB_NAME", "backup").
+			WithEnv("CRONMETRICS_API_KEY", "cm_test_backup_key")
+		statePath := filepath.Join(t.TempDir(), "breaker.json")
+		args := []string{"wrap", "--retries", "0", "--breaker-threshold", "2", "--breaker-state", statePath, "--", "true"}
+
+		// Failed submissions are reported but keep the command's exit code
+		for i := 0; i < 2; i++ {
+			result := unreachable.RunCommand(args...)
+			result.ExpectSuccess()
+			assert.Contains(t, result.Stderr, "failed to submit the job result")
+			assert.NotContains(t, result.Stderr, "circuit open")
+		}
+
+		// The breaker is open: the next run does not try the server
+		result := unreachable.RunCommand(args...)
+		result.ExpectSuccess()
+		assert.Contains(t, result.Stderr, "circuit open")
+		assert.FileExists(t, statePath)
+	})
+}
+
+func TestCLIImport(t *testing.T) {
+	// Ensure binary is built
+	buildBinary(t)
+
+	cliTest := testutil.NewCLITest(t)
+	cliTest.CreateDefaultTestConfig()
+
+	healthchecks := filepath.Join(cliTest.TempDir, "healthchecks.json")
+	require.NoError(t, os.WriteFile(healthchecks, []byte(`{"checks": [
+		{"name": "Nightly Backup", "slug": "nightly-backup", "tags": "prod team:dba", "desc": "pg_dump of the main database",
+		 "timeout": 86400, "grace": 3600, "status": "up", "pings": [
+			{"type": "start", "date": "2026-10-01T02:00:00+00:00"},
+			{"type": "success", "date": "2026-10-01T02:15:00+00:00", "duration": 900.4, "exitstatus": 0},
+			{"type": "fail", "date": "2026-10-02T02:01:00+00:00", "exitstatus": 2},
+			{"type": "log", "date": "2026-10-02T02:02:00+00:00"}
+		]},
+		{"name": "Cert renewal", "tags": "host:web1", "schedule": "0 3 * * 1", "grace": 600, "status": "paused"}
+	]}`), 0600))
+
+	cronitor := filepath.Join(cliTest.TempDir, "cronitor.json")
+	require.NoError(t, os.WriteFile(cronitor, []byte(`{"monitors": [
+		{"key": "sync-inv", "name": "Sync inventory", "type": "job", "schedule": "every 1 hour", "grace_seconds": 300,
+		 "tags": ["env=staging"], "pings": [
+			{"stamp": 1790000000.5, "state": "run"},
+			{"stamp": 1790000060.5, "state": "complete", "duration": 60, "message": "synced", "metrics": {"count": 42}}
+		]},
+		{"key": "homepage", "name": "Homepage", "type": "check"}
+	]}`), 0600))
+
+	t.Run("DryRun", func(t *testing.T) {
+		cliTest.RunCommand("import", "healthchecks", healthchecks, "--host", "db1", "--dry-run").
+			ExpectSuccess().
+			ExpectStdoutContains("Would import 2 jobs with 2 results from Healthchecks.io")
+		assert.Contains(t, cliTest.RunCommand("job", "list").Stdout, "No jobs found")
+	})
+
+	t.Run("Healthchecks", func(t *testing.T) {
+		cliTest.RunCommand("import", "healthchecks", healthchecks, "--host", "db1").
+			ExpectSuccess().
+			ExpectStdoutContains("Imported 2 jobs with 2 results from Healthchecks.io")
+
+		cliTest.RunCommand("import", "healthchecks", healthchecks, "--host", "db1").
+			ExpectSuccess().
+			ExpectStdoutContains("Imported 0 jobs with 0 results").
+			ExpectStdoutContains("Skipped 2 jobs that already exist")
+	})
+
+	t.Run("Cronitor", func(t *testing.T) {
+		cliTest.RunCommand("import", "cronitor", cronitor).
+			ExpectSuccess().
+			ExpectStdoutContains("Imported 1 jobs with 1 results from Cronitor")
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		invalid := filepath.Join(cliTest.TempDir, "invalid.json")
+		require.NoError(t, os.WriteFile(invalid, []byte(`{"monitors": []}`), 0600))
+		cliTest.RunCommand("import", "healthchecks", invalid).
+			ExpectFailure().
+			ExpectStderrContains(`no \"checks\" list`)
+	})
+
+	db, err := model.NewDatabase(cliTest.DBFile)
+	require.NoError(t, err)
+	defer db.Close()
+	jobStore, resultStore := model.NewJobStore(db.GetDB()), model.NewJobResultStore(db.GetDB())
+
+	backup, err := jobStore.GetJob("nightly-backup", "db1")
+	require.NoError(t, err)
+	assert.Equal(t, 90000, backup.AutomaticFailureThreshold)
+	assert.Equal(t, map[string]string{"prod": "true", "team": "dba"}, backup.Labels)
+	assert.Equal(t, "pg_dump of the main database", backup.Description)
+	assert.Equal(t, "2026-10-02T02:01:00Z", backup.LastReportedAt.UTC().Format(time.RFC3339))
+	results, err := resultStore.GetJobResults("nightly-backup", "db1", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "failure", results[0].Status)
+	require.NotNil(t, results[0].ExitCode)
+	assert.Equal(t, 2, *results[0].ExitCode)
+	assert.Equal(t, 900, results[1].Duration)
+	assert.Equal(t, "healthchecks", results[1].Source)
+
+	certs, err := jobStore.GetJob("cert-renewal", "web1")
+	require.NoError(t, err)
+	assert.Equal(t, "paused", certs.Status)
+	assert.True(t, certs.ThresholdInherited, "cron checks follow the defaults")
+
+	sync, err := jobStore.GetJob("sync-inventory", "imported")
+	require.NoError(t, err)
+	assert.Equal(t, 3900, sync.AutomaticFailureThreshold)
+	results, err = resultStore.GetJobResults("sync-inventory", "imported", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "synced", results[0].Output)
+	require.NotNil(t, results[0].ItemsProcessed)
+	assert.Equal(t, int64(42), *results[0].ItemsProcessed)
+	assert.Equal(t, "cronitor", results[0].Source)
+}
@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// The tests of this file exercise shared state from many goroutines; run
+// them with the race detector: mise run test-race
+
+func TestConcurrentMetricsCollector(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Metrics.HealthGroupLabel = "env"
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	// request sends a request and checks its status, without require so it
+	// can run off the test goroutine
+	request := func(method, path string, headers map[string]string, body any, want int) string {
+		var reader io.Reader
+		if body != nil {
+			data, _ := json.Marshal(body)
+			reader = bytes.NewReader(data)
+		}
+		req, err := http.NewRequest(method, server.URL()+path, reader)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if !assert.NoError(t, err, "%s %s", method, path) {
+			return ""
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, want, resp.StatusCode, "%s %s: %s", method, path, data)
+		return string(data)
+	}
+	jobHeaders := map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json"}
+	wrongKeyHeaders := map[string]string{"X-API-Key": "cm_wrong_key", "Content-Type": "application/json"}
+
+	workers := map[string]func(i int){
+		"scrape": func(int) {
+			body := request(http.MethodGet, "/metrics", nil, nil, http.StatusOK)
+			assert.Contains(t, body, `job_name="backup"`)
+			assert.Contains(t, body, "cronmetrics_rejected_results_total")
+		},
+		"scrape shard": func(int) {
+			request(http.MethodGet, "/metrics?shard=1/2", nil, nil, http.StatusOK)
+		},
+		"job metrics": func(int) {
+			request(http.MethodGet, "/metrics/job/1", nil, nil, http.StatusOK)
+		},
+		"check": func(int) {
+			request(http.MethodPost, "/api/job/1/check", server.AdminHeaders(), nil, http.StatusOK)
+		},
+		"submit": func(i int) {
+			status := "success"
+			if i%2 == 1 {
+				status = "failure"
+			}
+			request(http.MethodPost, "/api/job-result", jobHeaders,
+				map[string]any{"job_name": "backup", "host": "db1", "status": status}, http.StatusCreated)
+		},
+		"reject": func(int) {
+			request(http.MethodPost, "/api/job-result", wrongKeyHeaders,
+				map[string]any{"job_name": "backup", "host": "db1", "status": "success"}, http.StatusUnauthorized)
+		},
+		"reconfigure": func(i int) {
+			server.Metrics.SetLabelFilter(metrics.NewLabelFilter(nil, []string{fmt.Sprintf("label-%d", i)}))
+			server.Metrics.SetHealthScorer(metrics.NewHealthScorer("env", "", nil))
+		},
+	}
+
+	var wg sync.WaitGroup
+	for name, work := range workers {
+		for worker := 0; worker < 4; worker++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 10; i++ {
+					work(i)
+				}
+			}()
+		}
+		t.Logf("started %s workers", name)
+	}
+	wg.Wait()
+
+	// Every accepted submission was recorded
+	results, err := server.Database.GetJobResultStore().GetJobResults("backup", "db1", 100)
+	assert.NoError(t, err)
+	assert.Len(t, results, 40)
+}
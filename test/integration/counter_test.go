@@ -0,0 +1,125 @@
+package integration
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/api"
+	"github.com/jaepetto/cron-exporter/pkg/metrics"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounterJobs(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	var job model.Job
+	admin.POST("/api/job", map[string]interface{}{
+		"job_name": "import", "host": "etl1", "api_key": "cm_import_key", "type": "counter", "throughput_floor": 100,
+	}).ExpectStatus(201).ExpectJSON(&job)
+	assert.Equal(t, model.JobTypeCounter, job.Type)
+	assert.Equal(t, int64(100), job.ThroughputFloor)
+
+	reporter := testutil.NewHTTPClient(t, server.URL()).WithHeaders(map[string]string{"X-API-Key": "cm_import_key"})
+	submit := func(result map[string]interface{}) *testutil.HTTPResponse {
+		result["job_name"], result["host"] = "import", "etl1"
+		return reporter.POST("/api/job-result", result)
+	}
+	check := func() *metrics.JobCheck {
+		var check metrics.JobCheck
+		admin.POST(fmt.Sprintf("/api/job/%d/check", job.ID), nil).ExpectStatus(200).ExpectJSON(&check)
+		return &check
+	}
+
+	submit(map[string]interface{}{"status": "success", "items_processed": 500, "duration": 10}).ExpectStatus(201)
+	assert.Equal(t, "success", check().Reason)
+
+	// A run that succeeded with too few items is failing
+	submit(map[string]interface{}{"status": "success", "items_processed": 40, "duration": 20}).ExpectStatus(201)
+	low := check()
+	assert.Equal(t, float64(0), low.Value)
+	assert.Equal(t, "low_throughput", low.Reason)
+
+	body := admin.GET("/metrics").ExpectStatus(200).BodyString()
+	assert.Contains(t, body, `cronjob_status{job_name="import",host="etl1"} 0`)
+	assert.Contains(t, body, "# TYPE cronjob_items_processed_total counter")
+	assert.Contains(t, body, `cronjob_items_processed_total{job_name="import",host="etl1"} 540`)
+	assert.Contains(t, body, `cronjob_items_processed{job_name="import",host="etl1"} 40`)
+	assert.Contains(t, body, `cronjob_items_per_second{job_name="import",host="etl1"} 2`)
+	assert.Contains(t, body, `cronjob_throughput_floor{job_name="import",host="etl1"} 100`)
+
+	var page model.JobResultPage
+	admin.GET(fmt.Sprintf("/api/job/%d/results", job.ID)).ExpectStatus(200).ExpectJSON(&page)
+	require.Len(t, page.Results, 2)
+	require.NotNil(t, page.Results[0].ItemsProcessed)
+	assert.Equal(t, int64(40), *page.Results[0].ItemsProcessed)
+
+	t.Run("Invalid", func(t *testing.T) {
+		submit(map[string]interface{}{"status": "success"}).
+			ExpectStatus(400).
+			ExpectContains("items_processed is required for counter jobs")
+		submit(map[string]interface{}{"status": "success", "items_processed": -1}).
+			ExpectStatus(400).
+			ExpectContains("items_processed cannot be negative")
+		admin.POST("/api/job", map[string]interface{}{"job_name": "x", "host": "y", "type": "gauge"}).
+			ExpectStatus(400).
+			ExpectContains("type must be")
+		admin.PUT(fmt.Sprintf("/api/job/%d", job.ID), map[string]interface{}{"throughput_floor": -5}).
+			ExpectStatus(400).
+			ExpectContains("throughput_floor cannot be negative")
+	})
+
+	t.Run("Standard", func(t *testing.T) {
+		server.SeedTestData()
+		backup, err := server.Database.GetJobStore().GetJob("backup", "db1")
+		require.NoError(t, err)
+		assert.Equal(t, model.JobTypeStandard, backup.Type)
+
+		// Standard jobs may report items, but have no throughput series
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"}).
+			POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "success", "items_processed": 3}).
+			ExpectStatus(201)
+		assert.NotContains(t, admin.GET("/metrics").BodyString(), `cronjob_items_processed{job_name="backup"`)
+	})
+
+	t.Run("Ping", func(t *testing.T) {
+		testutil.NewHTTPClient(t, server.URL()).
+			GET("/api/ping?key=cm_import_key&items_processed=250").
+			ExpectStatus(200)
+		assert.Equal(t, "success", check().Reason)
+	})
+
+	t.Run("Test", func(t *testing.T) {
+		// Test submissions of counter jobs process the floor by default
+		var report api.TestSubmissionResponse
+		admin.POST(fmt.Sprintf("/api/job/%d/test", job.ID), nil).ExpectStatus(200).ExpectJSON(&report)
+		assert.True(t, report.Passed)
+		require.NotNil(t, report.Result.ItemsProcessed)
+		assert.Equal(t, int64(100), *report.Result.ItemsProcessed)
+		assert.Equal(t, "success", report.Metrics.Reason)
+	})
+
+	t.Run("Dashboard", func(t *testing.T) {
+		dashboard := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders()).WithCookies()
+		body := dashboard.GET(fmt.Sprintf("/dashboard/jobs/%d", job.ID)).ExpectStatus(200).BodyString()
+		assert.Contains(t, body, "100 items per run")
+		assert.Contains(t, body, "40 items")
+
+		dashboard.PostForm("/dashboard/jobs", url.Values{
+			"name": {"sync"}, "host": {"etl1"}, "type": {"counter"}, "throughput_floor": {"many"},
+		}).ExpectStatus(400).ExpectContains("Throughput floor must be a number of items")
+		dashboard.PostForm("/dashboard/jobs", url.Values{
+			"name": {"sync"}, "host": {"etl1"}, "type": {"counter"}, "throughput_floor": {"10"},
+		})
+		created, err := server.Database.GetJobStore().GetJob("sync", "etl1")
+		require.NoError(t, err)
+		assert.Equal(t, model.JobTypeCounter, created.Type)
+		assert.Equal(t, int64(10), created.ThroughputFloor)
+	})
+}
@@ -0,0 +1,1008 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var generatedKeyPattern = regexp.MustCompile(`cm_[a-z2-7]{52}`)
+
+func TestDashboardAdmin(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	api := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders())
+
+	var job model.Job
+	api.POST("/api/job", map[string]interface{}{
+		"job_name": "backup", "host": "db1",
+	}).ExpectStatus(201).ExpectJSON(&job)
+
+	t.Run("RequiresAuth", func(t *testing.T) {
+		testutil.NewHTTPClient(t, server.URL()).
+			GET("/dashboard/admin/keys").
+			ExpectStatus(401)
+	})
+
+	t.Run("ListKeys", func(t *testing.T) {
+		dashboard.GET("/dashboard/admin/keys").
+			ExpectStatus(200).
+			ExpectContains("Job API Keys").
+			ExpectContains("backup")
+	})
+
+	t.Run("RotateJobKey", func(t *testing.T) {
+		body := dashboard.PostForm(fmt.Sprintf("/dashboard/jobs/%d/rotate-key", job.ID), nil).
+			ExpectStatus(200).
+			BodyString()
+
+		newKey := generatedKeyPattern.FindString(body)
+		require.NotEmpty(t, newKey, "rotated key should be shown once")
+		assert.NotEqual(t, job.ApiKey, newKey)
+
+		var stored model.Job
+		api.GET(fmt.Sprintf("/api/job/%d", job.ID)).ExpectStatus(200).ExpectJSON(&stored)
+		assert.Equal(t, newKey, stored.ApiKey)
+	})
+
+	t.Run("CreateAndRevokeAdminKey", func(t *testing.T) {
+		body := dashboard.PostForm("/dashboard/admin/keys", url.Values{"name": {"ci-pipeline"}}).
+			ExpectStatus(201).
+			BodyString()
+
+		adminKey := generatedKeyPattern.FindString(body)
+		require.NotEmpty(t, adminKey, "new admin key should be shown once")
+
+		// The managed key authenticates against the API
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"Authorization": "Bearer " + adminKey}).
+			GET("/api/job").
+			ExpectStatus(200)
+
+		dashboard.PostForm("/dashboard/admin/keys/1/revoke", nil).
+			ExpectStatus(200).
+			ExpectContains("revoked")
+
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"Authorization": "Bearer " + adminKey}).
+			GET("/api/job").
+			ExpectStatus(401)
+
+		dashboard.PostForm("/dashboard/admin/keys/1/revoke", nil).
+			ExpectStatus(404)
+	})
+
+	t.Run("AuditLog", func(t *testing.T) {
+		dashboard.GET("/dashboard/admin/audit").
+			ExpectStatus(200).
+			ExpectContains("job.rotate_key").
+			ExpectContains("admin_key.create").
+			ExpectContains("admin_key.revoke")
+	})
+
+	t.Run("Settings", func(t *testing.T) {
+		dashboard.GET("/dashboard/admin/settings").
+			ExpectStatus(200).
+			ExpectContains("/dashboard").
+			ExpectContains("Admin Keys in Config")
+	})
+}
+
+func TestDashboardDuplicateJob(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	api := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders())
+
+	var source model.Job
+	api.POST("/api/job", map[string]interface{}{
+		"job_name": "backup", "host": "db1", "automatic_failure_threshold": 7200,
+	}).ExpectStatus(201).ExpectJSON(&source)
+
+	dashboard.GET(fmt.Sprintf("/dashboard/jobs/%d/duplicate", source.ID)).
+		ExpectStatus(200).
+		ExpectContains("Duplicate backup@db1").
+		ExpectContains(`value="7200"`)
+
+	dashboard.PostForm("/dashboard/jobs", url.Values{
+		"name":                        {"backup"},
+		"host":                        {"db2"},
+		"automatic_failure_threshold": {"7200"},
+		"status":                      {"active"},
+	}).ExpectStatus(200)
+
+	var jobs []model.Job
+	api.GET("/api/job").ExpectStatus(200).ExpectJSON(&jobs)
+	require.Len(t, jobs, 2)
+	assert.NotEmpty(t, jobs[1].ApiKey)
+	assert.NotEqual(t, jobs[0].ApiKey, jobs[1].ApiKey)
+}
+
+func TestDashboardSearchPickers(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	api := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders())
+
+	for i := 0; i < 30; i++ {
+		env := "staging"
+		if i%10 == 0 {
+			env = "prod"
+		}
+		api.POST("/api/job", map[string]interface{}{
+			"job_name": fmt.Sprintf("job-%02d", i), "host": fmt.Sprintf("host-%d", i%2),
+			"labels": map[string]string{"env": env},
+		}).ExpectStatus(201)
+	}
+
+	dashboard.GET("/dashboard/api/hosts").
+		ExpectStatus(200).
+		ExpectContains(`"host":"host-0"`).
+		ExpectContains(`"host":"host-1"`)
+
+	dashboard.GET("/dashboard/api/labels").
+		ExpectStatus(200).
+		ExpectContains(`"key":"env"`).
+		ExpectContains(`"value":"prod"`)
+
+	// Label filters are applied before pagination
+	var result model.JobSearchResult
+	dashboard.GET("/dashboard/api/jobs/search?label_key=env&label_value=prod&page_size=10").
+		ExpectStatus(200).
+		ExpectJSON(&result)
+
+	assert.Equal(t, 3, result.TotalCount)
+	assert.Len(t, result.Jobs, 3)
+	for _, job := range result.Jobs {
+		assert.Equal(t, "prod", job.Labels["env"])
+	}
+}
+
+func TestDashboardSearchFilterBuilder(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	api := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders())
+
+	jobs := []map[string]interface{}{
+		{"job_name": "backup", "host": "db1", "status": "active", "labels": map[string]string{"env": "prod", "team": "data"}},
+		{"job_name": "report", "host": "db1", "status": "paused", "labels": map[string]string{"env": "prod", "team": "data"}},
+		{"job_name": "cleanup", "host": "web1", "status": "maintenance", "labels": map[string]string{"env": "prod", "team": "web"}},
+		{"job_name": "sync", "host": "web1", "status": "active", "labels": map[string]string{"env": "staging", "team": "data"}},
+	}
+	for _, job := range jobs {
+		api.POST("/api/job", job).ExpectStatus(201)
+	}
+
+	t.Run("MultipleStatusesAndLabels", func(t *testing.T) {
+		var result model.JobSearchResult
+		dashboard.GET("/dashboard/api/jobs/search?status=active&status=paused" +
+			"&label_key=env&label_value=prod&label_key=team&label_value=data&label_key=&label_value=").
+			ExpectStatus(200).
+			ExpectJSON(&result)
+
+		names := make([]string, 0, len(result.Jobs))
+		for _, job := range result.Jobs {
+			names = append(names, job.Name)
+		}
+		assert.ElementsMatch(t, []string{"backup", "report"}, names)
+	})
+
+	t.Run("TimeRange", func(t *testing.T) {
+		var result model.JobSearchResult
+		dashboard.GET("/dashboard/api/jobs/search?after=2000-01-01T00:00&before=2000-01-02T00:00").
+			ExpectStatus(200).
+			ExpectJSON(&result)
+
+		assert.Equal(t, 0, result.TotalCount)
+	})
+
+	t.Run("RelativeTimeRange", func(t *testing.T) {
+		server.Database.Exec(`UPDATE jobs SET last_reported_at = ? WHERE name = 'report'`, time.Now().UTC().Add(-3*time.Hour))
+
+		var stale model.JobSearchResult
+		dashboard.GET("/dashboard/api/jobs/search?stale=2h").
+			ExpectStatus(200).
+			ExpectJSON(&stale)
+		require.Len(t, stale.Jobs, 1)
+		assert.Equal(t, "report", stale.Jobs[0].Name)
+
+		var recent model.JobSearchResult
+		dashboard.GET("/dashboard/api/jobs/search?within=1h").
+			ExpectStatus(200).
+			ExpectJSON(&recent)
+		assert.Equal(t, 3, recent.TotalCount)
+
+		// Both filters select jobs last reported between 1d and 2h ago
+		dashboard.GET("/dashboard/api/jobs/search?within=1d&stale=2h").
+			ExpectStatus(200).
+			ExpectJSON(&stale)
+		assert.Equal(t, 1, stale.TotalCount)
+
+		dashboard.GET("/dashboard/jobs/search?stale=24h").
+			ExpectStatus(200).
+			ExpectContains(`id="stale-quick-filter"`).
+			ExpectContains(`<option value="24h" selected>Over 24 hours</option>`)
+	})
+
+	t.Run("PagePrefillsFromURL", func(t *testing.T) {
+		dashboard.GET("/dashboard/jobs/search?status=paused&label_key=team&label_value=data&within=24h").
+			ExpectStatus(200).
+			ExpectContains(`value="paused"`).
+			ExpectContains(`value="team"`).
+			ExpectContains(`value="data"`).
+			ExpectContains(`<option value="24h" selected>`)
+	})
+}
+
+func TestDashboardSearchSorting(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	api := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders())
+
+	for _, job := range []struct{ name, host string }{
+		{"bravo", "h3"}, {"alpha", "h2"}, {"charlie", "h1"},
+	} {
+		api.POST("/api/job", map[string]interface{}{"job_name": job.name, "host": job.host}).
+			ExpectStatus(201)
+	}
+
+	names := func(query string) []string {
+		var result model.JobSearchResult
+		dashboard.GET("/dashboard/api/jobs/search?" + query).
+			ExpectStatus(200).
+			ExpectJSON(&result)
+
+		names := make([]string, 0, len(result.Jobs))
+		for _, job := range result.Jobs {
+			names = append(names, job.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"bravo", "alpha", "charlie"}, names(""))
+	assert.Equal(t, []string{"alpha", "bravo", "charlie"}, names("sort_by=name"))
+	assert.Equal(t, []string{"charlie", "bravo", "alpha"}, names("sort_by=name&sort_dir=desc"))
+	assert.Equal(t, []string{"charlie", "alpha", "bravo"}, names("sort_by=host"))
+
+	// Unknown sort fields are ignored rather than interpolated into the query
+	assert.Equal(t, []string{"bravo", "alpha", "charlie"}, names("sort_by=api_key;DROP"))
+
+	t.Run("HeadersToggleDirection", func(t *testing.T) {
+		dashboard.GET("/dashboard/jobs/search?sort_by=name").
+			ExpectStatus(200).
+			ExpectContains("sort_by=name&amp;sort_dir=desc").
+			ExpectContains("▲")
+	})
+}
+
+func TestDashboardDuplicateJobConflict(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	api := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders())
+
+	var job model.Job
+	api.POST("/api/job", map[string]interface{}{"job_name": "backup", "host": "db1"}).
+		ExpectStatus(201).
+		ExpectJSON(&job)
+
+	t.Run("ExistsLookup", func(t *testing.T) {
+		dashboard.GET("/dashboard/api/jobs/exists?name=backup&host=db1").
+			ExpectStatus(200).
+			ExpectContains(`"exists":true`).
+			ExpectContains(fmt.Sprintf(`"url":"/dashboard/jobs/%d"`, job.ID))
+
+		dashboard.GET("/dashboard/api/jobs/exists?name=backup&host=db2").
+			ExpectStatus(200).
+			ExpectContains(`"exists":false`)
+	})
+
+	t.Run("CreateConflict", func(t *testing.T) {
+		dashboard.PostForm("/dashboard/jobs", url.Values{
+			"name":                        {"backup"},
+			"host":                        {"db1"},
+			"automatic_failure_threshold": {"7200"},
+		}).
+			ExpectStatus(409).
+			ExpectContains(fmt.Sprintf(`href="/dashboard/jobs/%d"`, job.ID)).
+			ExpectContains("A job with this name already exists on this host").
+			ExpectContains(`value="7200"`)
+
+		assert.Equal(t, 1, server.Database.CountJobs())
+	})
+}
+
+func TestDashboardFormValidationAndFlash(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders()).
+		WithCookies()
+
+	t.Run("FieldErrorsKeepValues", func(t *testing.T) {
+		dashboard.PostForm("/dashboard/jobs", url.Values{
+			"name":                        {"nightly-report"},
+			"host":                        {""},
+			"automatic_failure_threshold": {"-5"},
+			"labels":                      {`{"env": `},
+		}).
+			ExpectStatus(400).
+			ExpectContains("Host is required").
+			ExpectContains("Threshold must be a positive number of seconds").
+			ExpectContains("Labels must be a JSON object of strings").
+			ExpectContains(`value="nightly-report"`).
+			ExpectContains(`value="-5"`).
+			ExpectContains(`{&#34;env&#34;:</textarea>`)
+
+		assert.Equal(t, 0, server.Database.CountJobs())
+	})
+
+	t.Run("FlashAfterRedirect", func(t *testing.T) {
+		dashboard.PostForm("/dashboard/jobs", url.Values{
+			"name": {"nightly-report"},
+			"host": {"web1"},
+		}).
+			ExpectStatus(200).
+			ExpectContains(`id="flash"`).
+			ExpectContains("Job nightly-report@web1 created")
+
+		// The message is shown only once
+		body := dashboard.GET("/dashboard/jobs").ExpectStatus(200).BodyString()
+		assert.NotContains(t, body, `id="flash"`)
+	})
+}
+
+func TestDashboardRelatedJobs(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	api := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders())
+
+	var backup model.Job
+	api.POST("/api/job", map[string]interface{}{"job_name": "backup", "host": "db1"}).ExpectStatus(201).ExpectJSON(&backup)
+	api.POST("/api/job", map[string]interface{}{"job_name": "vacuum", "host": "db1", "status": "maintenance"}).ExpectStatus(201)
+	api.POST("/api/job", map[string]interface{}{"job_name": "backup", "host": "db2"}).ExpectStatus(201)
+	var report model.Job
+	api.POST("/api/job", map[string]interface{}{"job_name": "report", "host": "app1"}).ExpectStatus(201).ExpectJSON(&report)
+
+	body := dashboard.GET(fmt.Sprintf("/dashboard/jobs/%d", backup.ID)).ExpectStatus(200).BodyString()
+
+	sameHost := body[strings.Index(body, `id="same-host-jobs"`):strings.Index(body, `id="same-name-jobs"`)]
+	assert.Contains(t, sameHost, "vacuum@db1")
+	assert.Contains(t, sameHost, "maintenance")
+	assert.NotContains(t, sameHost, "backup@")
+
+	sameName := body[strings.Index(body, `id="same-name-jobs"`):strings.Index(body, `id="acknowledgements"`)]
+	assert.Contains(t, sameName, "backup@db2")
+	assert.NotContains(t, sameName, "backup@db1")
+	assert.NotContains(t, body, "report@app1")
+
+	// A job alone on its host with a unique name has no related jobs
+	dashboard.GET(fmt.Sprintf("/dashboard/jobs/%d", report.ID)).
+		ExpectStatus(200).
+		ExpectContains("No other job runs on this host.").
+		ExpectContains("This job runs on no other host.")
+}
+
+func TestDashboardHosts(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders())
+	for _, status := range []string{"success", "failure", "failure"} {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": status, "duration": 42}).
+			ExpectStatus(201)
+	}
+
+	t.Run("List", func(t *testing.T) {
+		body := dashboard.GET("/dashboard/hosts").ExpectStatus(200).BodyString()
+		for _, host := range []string{"db1", "web1", "app1"} {
+			assert.Contains(t, body, `href="/dashboard/hosts/`+host+`"`)
+		}
+		assert.Contains(t, body, `<span class="badge badge-danger">2</span>`)
+	})
+
+	t.Run("Detail", func(t *testing.T) {
+		body := dashboard.GET("/dashboard/hosts/db1").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, "Host: db1")
+		assert.Contains(t, body, "2 failure(s) in 3 result(s)")
+
+		jobs := body[strings.Index(body, `id="host-jobs"`):strings.Index(body, `id="host-timeline"`)]
+		assert.Contains(t, jobs, `href="/dashboard/jobs/1"`)
+
+		timeline := body[strings.Index(body, `id="host-timeline"`):]
+		assert.Equal(t, 3, strings.Count(timeline, `<td data-label="Job">backup</td>`))
+		assert.Contains(t, timeline, "42s")
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		dashboard.GET("/dashboard/hosts/nowhere").ExpectStatus(404)
+	})
+}
+
+func TestDashboardInlineLabels(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	api := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders())
+	getLabels := func(id int) map[string]string {
+		var job model.Job
+		api.GET(fmt.Sprintf("/api/job/%d", id)).ExpectStatus(200).ExpectJSON(&job)
+		return job.Labels
+	}
+
+	t.Run("Set", func(t *testing.T) {
+		row := dashboard.PostForm("/dashboard/jobs/1/labels", url.Values{"key": {"team"}, "value": {"ops"}}).
+			ExpectStatus(200).BodyString()
+		assert.Contains(t, row, `id="job-row-1"`)
+		assert.Contains(t, row, "team:ops")
+		assert.Equal(t, "ops", getLabels(1)["team"])
+		assert.Equal(t, "prod", getLabels(1)["env"], "other labels are kept")
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		row := dashboard.PostForm("/dashboard/jobs/1/labels/remove", url.Values{"key": {"team"}}).
+			ExpectStatus(200).BodyString()
+		assert.NotContains(t, row, "team:ops")
+		assert.NotContains(t, getLabels(1), "team")
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		dashboard.PostForm("/dashboard/jobs/1/labels", url.Values{"key": {" "}, "value": {"x"}}).
+			ExpectStatus(400).
+			ExpectContains("Label key is required")
+		dashboard.PostForm("/dashboard/jobs/999/labels", url.Values{"key": {"team"}}).ExpectStatus(404)
+	})
+
+	t.Run("Bulk", func(t *testing.T) {
+		flash := dashboard.WithCookies()
+		flash.PostForm("/dashboard/jobs/labels", url.Values{
+			"job_id": {"1", "2"}, "key": {"team"}, "value": {"ops"}, "action": {"add"},
+		}).ExpectStatus(200).ExpectContains("Label team=ops applied to 2 job(s)")
+		assert.Equal(t, "ops", getLabels(1)["team"])
+		assert.Equal(t, "ops", getLabels(2)["team"])
+
+		flash.PostForm("/dashboard/jobs/labels", url.Values{
+			"job_id": {"2"}, "key": {"team"}, "action": {"remove"},
+		}).ExpectStatus(200).ExpectContains("Label team removed from 1 job(s)")
+		assert.NotContains(t, getLabels(2), "team")
+		assert.Equal(t, "ops", getLabels(1)["team"])
+
+		flash.PostForm("/dashboard/jobs/labels", url.Values{"key": {"team"}, "value": {"ops"}, "action": {"add"}}).
+			ExpectStatus(200).
+			ExpectContains("Select the jobs to label first")
+	})
+
+	t.Run("List", func(t *testing.T) {
+		body := dashboard.GET("/dashboard/jobs").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, `id="bulk-label-form"`)
+		assert.Contains(t, body, `hx-post="/dashboard/jobs/1/labels"`)
+	})
+}
+
+func TestDashboardJobDescription(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	api := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders())
+
+	description := "## Rerun\n\n1. Check **disk space**\n2. Run `backup.sh --full`\n\nSee [the runbook](https://wiki.example.com/backup).\n\n<script>alert(1)</script>"
+
+	var job model.Job
+	api.POST("/api/job", map[string]interface{}{
+		"job_name": "backup", "host": "db1", "description": description,
+	}).ExpectStatus(201).ExpectJSON(&job)
+	assert.Equal(t, description, job.Description)
+
+	t.Run("RenderedAsMarkdown", func(t *testing.T) {
+		body := dashboard.GET(fmt.Sprintf("/dashboard/jobs/%d", job.ID)).ExpectStatus(200).BodyString()
+		assert.Contains(t, body, `id="job-description"`)
+		assert.Contains(t, body, "<h4>Rerun</h4>")
+		assert.Contains(t, body, "<li>Check <strong>disk space</strong></li>")
+		assert.Contains(t, body, "<code>backup.sh --full</code>")
+		assert.Contains(t, body, `<a href="https://wiki.example.com/backup" rel="noopener noreferrer" target="_blank">the runbook</a>`)
+		assert.Contains(t, body, "&lt;script&gt;alert(1)&lt;/script&gt;")
+		assert.NotContains(t, body, "<script>alert(1)</script>")
+	})
+
+	t.Run("Searchable", func(t *testing.T) {
+		var result model.JobSearchResult
+		dashboard.GET("/dashboard/api/jobs/search?q=disk+space").
+			ExpectStatus(200).
+			ExpectJSON(&result)
+		require.Len(t, result.Jobs, 1)
+		assert.Equal(t, job.ID, result.Jobs[0].ID)
+	})
+
+	t.Run("PartialUpdateKeepsDescription", func(t *testing.T) {
+		var updated model.Job
+		api.PUT(fmt.Sprintf("/api/job/%d", job.ID), map[string]interface{}{"automatic_failure_threshold": 600}).
+			ExpectStatus(200).
+			ExpectJSON(&updated)
+		assert.Equal(t, description, updated.Description)
+	})
+
+	t.Run("TooLong", func(t *testing.T) {
+		api.POST("/api/job", map[string]interface{}{
+			"job_name": "report", "host": "db1", "description": strings.Repeat("x", model.MaxDescriptionLength+1),
+		}).ExpectStatus(400).ExpectContains("description must be at most")
+
+		dashboard.PostForm("/dashboard/jobs", url.Values{
+			"name":        {"report"},
+			"host":        {"db1"},
+			"description": {strings.Repeat("x", model.MaxDescriptionLength+1)},
+		}).ExpectStatus(400).ExpectContains("Description must be at most")
+	})
+}
+
+func TestDashboardReadOnly(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Dashboard = config.DashboardConfig{
+			Enabled:         true,
+			Path:            "/dashboard",
+			Title:           "Test Dashboard",
+			RefreshInterval: 5,
+			AuthRequired:    true,
+			ReadOnly:        true,
+			PageSize:        25,
+			SSEHeartbeat:    30,
+		}
+	})
+	defer server.Close()
+
+	api := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders()).
+		WithCookies()
+
+	var job model.Job
+	api.POST("/api/job", map[string]interface{}{
+		"job_name": "backup", "host": "db1", "labels": map[string]string{"env": "prod"},
+	}).ExpectStatus(201).ExpectJSON(&job)
+	jobPath := fmt.Sprintf("/dashboard/jobs/%d", job.ID)
+
+	t.Run("PagesVisibleWithoutActions", func(t *testing.T) {
+		body := dashboard.GET("/dashboard/jobs").ExpectStatus(200).ExpectContains("backup").BodyString()
+		assert.Contains(t, body, "Read-only")
+		assert.NotContains(t, body, "Add New Job")
+		assert.NotContains(t, body, "bulk-label-form")
+		assert.NotContains(t, body, jobPath+"/edit")
+		assert.NotContains(t, body, jobPath+"/labels")
+
+		body = dashboard.GET(jobPath).ExpectStatus(200).ExpectContains("backup").BodyString()
+		assert.Contains(t, body, jobPath+"/check")
+		assert.Contains(t, body, jobPath+"/history")
+		for _, action := range []string{"/edit", "/duplicate", "/toggle", "/rotate-key", "/delete", "/annotations", "/acknowledge", "/rules"} {
+			assert.NotContains(t, body, `"`+jobPath+action+`"`)
+		}
+
+		body = dashboard.GET("/dashboard/admin/keys").ExpectStatus(200).ExpectContains("backup").BodyString()
+		assert.NotContains(t, body, "Create Admin Key")
+		assert.NotContains(t, body, "/rotate-key")
+	})
+
+	t.Run("ActionsRefused", func(t *testing.T) {
+		dashboard.GET("/dashboard/jobs/new").ExpectStatus(403).ExpectContains("read-only")
+		dashboard.GET(jobPath + "/edit").ExpectStatus(403)
+		dashboard.PostForm("/dashboard/jobs", url.Values{"name": {"report"}, "host": {"db1"}}).ExpectStatus(403)
+		dashboard.PostForm(jobPath+"/toggle", nil).ExpectStatus(403)
+		dashboard.PostForm(jobPath+"/labels", url.Values{"key": {"team"}, "value": {"ops"}}).ExpectStatus(403)
+		dashboard.PostForm(jobPath+"/delete", nil).ExpectStatus(403)
+		dashboard.DELETE(jobPath).ExpectStatus(403)
+		dashboard.PostForm("/dashboard/admin/keys", url.Values{"name": {"wall"}}).ExpectStatus(403)
+
+		var stored model.Job
+		api.GET(fmt.Sprintf("/api/job/%d", job.ID)).ExpectStatus(200).ExpectJSON(&stored)
+		assert.Equal(t, "active", stored.Status)
+		assert.Equal(t, map[string]string{"env": "prod"}, stored.Labels)
+
+		var jobs []model.Job
+		api.GET("/api/job").ExpectStatus(200).ExpectJSON(&jobs)
+		assert.Len(t, jobs, 1)
+	})
+
+	t.Run("CheckNowAllowed", func(t *testing.T) {
+		dashboard.PostForm(jobPath+"/check", nil).
+			ExpectStatus(200).
+			ExpectContains("Checked backup@db1")
+	})
+}
+
+func TestDashboardKiosk(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	api := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders())
+
+	for _, job := range []map[string]interface{}{
+		{"job_name": "archive", "host": "db1", "status": "maintenance"},
+		{"job_name": "backup", "host": "db1"},
+		{"job_name": "report", "host": "app1", "automatic_failure_threshold": 60},
+	} {
+		api.POST("/api/job", job).ExpectStatus(201)
+	}
+	server.Database.Exec(`UPDATE jobs SET last_reported_at = ? WHERE name = 'report'`, time.Now().UTC().Add(-time.Hour))
+
+	t.Run("RequiresAuth", func(t *testing.T) {
+		testutil.NewHTTPClient(t, server.URL()).GET("/dashboard/kiosk").ExpectStatus(401)
+	})
+
+	t.Run("FailingJobsFirst", func(t *testing.T) {
+		body := dashboard.GET("/dashboard/kiosk").
+			ExpectStatus(200).
+			ExpectContains(`id="kiosk-rotation" value="15"`).
+			ExpectContains(`id="kiosk-page-size" value="24"`).
+			ExpectContains("1 failing").
+			ExpectContains("1 inactive").
+			BodyString()
+		assert.NotContains(t, body, "navbar")
+
+		report := strings.Index(body, `class="kiosk-tile kiosk-danger"`)
+		backup := strings.Index(body, `class="kiosk-tile kiosk-success"`)
+		archive := strings.Index(body, `class="kiosk-tile kiosk-inactive"`)
+		require.True(t, report > 0 && backup > 0 && archive > 0, "every job should have a tile")
+		assert.Less(t, report, backup)
+		assert.Less(t, backup, archive)
+		assert.Contains(t, body[report:backup], "report")
+		assert.Contains(t, body[report:backup], "Deadline Missed")
+	})
+
+	t.Run("TilesRefresh", func(t *testing.T) {
+		api.POST("/api/job", map[string]interface{}{"job_name": "cleanup", "host": "app1"}).ExpectStatus(201)
+
+		body := dashboard.GET("/dashboard/kiosk/tiles").
+			ExpectStatus(200).
+			ExpectContains("cleanup").
+			ExpectContains("2 on time").
+			BodyString()
+		assert.NotContains(t, body, "<html")
+	})
+}
+
+func TestDashboardMobileLayout(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	api := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders())
+
+	var job model.Job
+	api.POST("/api/job", map[string]interface{}{"job_name": "backup", "host": "db1"}).ExpectStatus(201).ExpectJSON(&job)
+	testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{"X-API-Key": job.ApiKey, "Content-Type": "application/json"}).
+		POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "success"}).
+		ExpectStatus(201)
+
+	t.Run("TablesCollapseIntoCards", func(t *testing.T) {
+		dashboard.GET("/dashboard/jobs").
+			ExpectStatus(200).
+			ExpectContains(`<meta name="viewport" content="width=device-width, initial-scale=1.0">`).
+			ExpectContains(`class="table table-cards" id="jobs-table"`).
+			ExpectContains(`data-label="Host">db1</td>`).
+			ExpectContains(`data-label="Last Reported"`)
+
+		dashboard.GET(fmt.Sprintf("/dashboard/jobs/%d", job.ID)).
+			ExpectStatus(200).
+			ExpectContains(`class="card-body job-actions"`).
+			ExpectContains(`data-label="Status"><span class="badge badge-success">success</span>`)
+
+		dashboard.GET("/dashboard/hosts/db1").
+			ExpectStatus(200).
+			ExpectContains(`data-label="Job"><a href="/dashboard/jobs/`)
+	})
+
+	t.Run("StylesheetHasPhoneLayout", func(t *testing.T) {
+		dashboard.GET("/dashboard/assets/tailwind.css").
+			ExpectStatus(200).
+			ExpectContains("@media (max-width:767px)").
+			ExpectContains(".table-cards td[data-label]::before{content:attr(data-label)")
+	})
+}
+
+func TestDashboardBrowserNotifications(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Dashboard = config.DashboardConfig{
+			Enabled:                   true,
+			Path:                      "/dashboard",
+			Title:                     "Test Dashboard",
+			RefreshInterval:           5,
+			AuthRequired:              true,
+			PageSize:                  25,
+			SSEEnabled:                true,
+			SSETimeout:                30,
+			SSEHeartbeat:              30,
+			SSEMaxClients:             10,
+			Notifications:             true,
+			NotificationSeverityLabel: "severity",
+			NotificationMinSeverity:   "warning",
+		}
+	})
+	defer server.Close()
+
+	api := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders())
+
+	var job model.Job
+	api.POST("/api/job", map[string]interface{}{
+		"job_name": "backup", "host": "db1", "labels": map[string]string{"severity": "critical"},
+	}).ExpectStatus(201).ExpectJSON(&job)
+
+	t.Run("OptIn", func(t *testing.T) {
+		dashboard.GET("/dashboard/jobs").
+			ExpectStatus(200).
+			ExpectContains(`id="notifications-toggle"`).
+			ExpectContains(`id="notified-severities" value="warning,critical"`)
+	})
+
+	t.Run("StatusEventsCarrySeverity", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL()+"/dashboard/events", nil)
+		require.NoError(t, err)
+		for key, value := range server.DashboardHeaders() {
+			req.Header.Set(key, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		stream := bufio.NewScanner(resp.Body)
+		nextStatus := func() map[string]interface{} {
+			event := ""
+			for stream.Scan() {
+				line := stream.Text()
+				if name, ok := strings.CutPrefix(line, "event: "); ok {
+					event = name
+				}
+				if data, ok := strings.CutPrefix(line, "data: "); ok && event == "job-status-change" {
+					var status map[string]interface{}
+					require.NoError(t, json.Unmarshal([]byte(data), &status))
+					return status
+				}
+			}
+			t.Fatalf("stream ended: %v", stream.Err())
+			return nil
+		}
+
+		initial := nextStatus()
+		assert.Equal(t, "backup", initial["name"])
+		assert.Equal(t, "critical", initial["severity"])
+		assert.Equal(t, true, initial["initial"])
+
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": job.ApiKey, "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "failure"}).
+			ExpectStatus(201)
+
+		failure := nextStatus()
+		assert.Equal(t, true, failure["is_failure"])
+		assert.Equal(t, "critical", failure["severity"])
+		assert.Nil(t, failure["initial"])
+	})
+}
+
+func TestDashboardColumnLayout(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	api := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders()).
+		WithCookies()
+
+	var job model.Job
+	api.POST("/api/job", map[string]interface{}{
+		"job_name": "backup", "host": "db1", "labels": map[string]string{"env": "prod", "owner": "dba"},
+	}).ExpectStatus(201).ExpectJSON(&job)
+	reporter := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{"X-API-Key": job.ApiKey, "Content-Type": "application/json"})
+	reporter.POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "failure", "duration": 30}).ExpectStatus(201)
+	reporter.POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "success", "duration": 42}).ExpectStatus(201)
+
+	t.Run("DefaultLayout", func(t *testing.T) {
+		body := dashboard.GET("/dashboard/jobs").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, `<td class="job-threshold" data-label="Threshold">3600s`)
+		assert.Contains(t, body, `id="column-settings-form"`)
+		assert.NotContains(t, body, `class="job-owner"`)
+		assert.NotContains(t, body, `class="job-success-rate"`)
+	})
+
+	t.Run("SavedLayout", func(t *testing.T) {
+		body := dashboard.PostForm("/dashboard/jobs/columns", url.Values{
+			"column": {"success_rate", "owner", "last_duration", "unknown"},
+			"labels": {"env, env ,team"},
+		}).ExpectStatus(200).ExpectContains("Column layout saved").BodyString()
+
+		assert.Contains(t, body, `<th class="job-label-column">env</th>`)
+		assert.Contains(t, body, `<th class="job-label-column">team</th>`)
+		assert.Contains(t, body, `<td class="job-label-column" data-label="env">prod</td>`)
+		assert.Contains(t, body, `<td class="job-owner" data-label="Owner">dba</td>`)
+		assert.Contains(t, body, `<td class="job-last-duration" data-label="Last Duration">42s</td>`)
+		assert.Contains(t, body, `<td class="job-success-rate" data-label="Success Rate">50%</td>`)
+		assert.Contains(t, body, `value="env,team"`)
+		assert.NotContains(t, body, `class="job-threshold"`)
+		assert.Less(t, strings.Index(body, "<th>Owner</th>"), strings.Index(body, "<th>Last Duration</th>"), "columns keep their order")
+
+		// Refreshes of the table keep the layout
+		dashboard.GET("/dashboard/api/jobs/search-paginated?target=table").
+			ExpectStatus(200).
+			ExpectContains(`<td class="job-owner" data-label="Owner">dba</td>`).
+			ExpectContains(`data-label="env">prod</td>`)
+	})
+
+	t.Run("SearchAPIReturnsComputedFields", func(t *testing.T) {
+		var result struct {
+			Jobs []struct {
+				Stats struct {
+					Owner        string   `json:"owner"`
+					LastDuration *int     `json:"last_duration"`
+					SuccessRate  *float64 `json:"success_rate"`
+				} `json:"stats"`
+			} `json:"jobs"`
+		}
+		dashboard.GET("/dashboard/api/jobs/search?q=backup").ExpectStatus(200).ExpectJSON(&result)
+		require.Len(t, result.Jobs, 1)
+		stats := result.Jobs[0].Stats
+		assert.Equal(t, "dba", stats.Owner)
+		require.NotNil(t, stats.LastDuration)
+		assert.Equal(t, 42, *stats.LastDuration)
+		require.NotNil(t, stats.SuccessRate)
+		assert.InDelta(t, 0.5, *stats.SuccessRate, 0.001)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		dashboard.PostForm("/dashboard/jobs/columns", url.Values{"action": {"reset"}}).
+			ExpectStatus(200).
+			ExpectContains("Column layout reset").
+			ExpectContains(`class="job-threshold"`)
+	})
+}
+
+func TestDashboardLastResult(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	api := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.DashboardHeaders()).
+		WithCookies()
+	results := server.Database.GetJobResultStore()
+
+	var backup model.Job
+	api.POST("/api/job", map[string]interface{}{"job_name": "backup", "host": "db1"}).ExpectStatus(201).ExpectJSON(&backup)
+	for _, name := range []string{"cleanup", "idle"} {
+		api.POST("/api/job", map[string]interface{}{"job_name": name, "host": "db1"}).ExpectStatus(201)
+	}
+	finished := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	for _, result := range []*model.JobResult{
+		{JobName: "backup", Host: "db1", Status: "failure", Duration: 30, Timestamp: finished.Add(-time.Hour)},
+		{JobName: "backup", Host: "db1", Status: "success", Duration: 42, Timestamp: finished},
+		{JobName: "backup", Host: "db1", Status: "failure", Timestamp: finished.Add(time.Minute), Test: true},
+		{JobName: "cleanup", Host: "db1", Status: "lost", Timestamp: finished},
+	} {
+		require.NoError(t, results.CreateJobResult(result))
+	}
+
+	t.Run("Table", func(t *testing.T) {
+		body := dashboard.GET("/dashboard/jobs").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, "<th>Last Result</th>")
+		assert.Regexp(t, `<td class="job-last-result" data-label="Last Result"><span class="badge badge-success" title="Finished [^"]+">success</span> <small class="text-muted">42s</small></td>`, body)
+		assert.Contains(t, body, `<span class="badge badge-warning" title="Finished`)
+		assert.Contains(t, body, `<td class="job-last-result" data-label="Last Result"><span class="text-muted">-</span></td>`)
+	})
+
+	t.Run("SearchAPI", func(t *testing.T) {
+		var result struct {
+			Jobs []*model.Job `json:"jobs"`
+		}
+		dashboard.GET("/dashboard/api/jobs/search?q=backup").ExpectStatus(200).ExpectJSON(&result)
+		require.Len(t, result.Jobs, 1)
+		last := result.Jobs[0].LastResult
+		require.NotNil(t, last, "test results are left out")
+		assert.Equal(t, "success", last.Status)
+		assert.Equal(t, 42, last.Duration)
+		assert.True(t, finished.Equal(last.FinishedAt))
+	})
+
+	t.Run("FiltersAndSorting", func(t *testing.T) {
+		var result model.JobSearchResult
+		dashboard.GET("/dashboard/api/jobs/search?host=db1&status=active&sort_by=status&sort_dir=desc").
+			ExpectStatus(200).
+			ExpectJSON(&result)
+		assert.Equal(t, 3, result.TotalCount)
+		assert.Len(t, result.Jobs, 3)
+	})
+
+	t.Run("Paged", func(t *testing.T) {
+		// Each page has the last results of its own jobs
+		lastResults := func(page int) map[string]*model.LastResult {
+			var result model.JobSearchResult
+			dashboard.GET(fmt.Sprintf("/dashboard/api/jobs/search?sort_by=name&page_size=2&page=%d", page)).
+				ExpectStatus(200).
+				ExpectJSON(&result)
+			last := make(map[string]*model.LastResult, len(result.Jobs))
+			for _, job := range result.Jobs {
+				last[job.Name] = job.LastResult
+			}
+			return last
+		}
+
+		first := lastResults(1)
+		require.Len(t, first, 2)
+		require.NotNil(t, first["backup"])
+		assert.Equal(t, "success", first["backup"].Status)
+		require.NotNil(t, first["cleanup"])
+		assert.Equal(t, "lost", first["cleanup"].Status)
+
+		second := lastResults(2)
+		require.Len(t, second, 1)
+		assert.Contains(t, second, "idle")
+		assert.Nil(t, second["idle"])
+	})
+
+	t.Run("InlineLabelEdit", func(t *testing.T) {
+		dashboard.PostForm(fmt.Sprintf("/dashboard/jobs/%d/labels", backup.ID), url.Values{"key": {"env"}, "value": {"prod"}}).
+			ExpectStatus(200).
+			ExpectContains(`<span class="badge badge-success" title="Finished`)
+	})
+}
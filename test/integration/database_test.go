@@ -0,0 +1,141 @@
+package integration
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabasePragmas(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		testDB := testutil.NewTestDatabase(t)
+		defer testDB.Close()
+
+		for name, want := range map[string]string{"foreign_keys": "1", "busy_timeout": "5000", "journal_mode": "wal"} {
+			value, err := testDB.DB.Pragma(name)
+			require.NoError(t, err)
+			assert.Equal(t, want, value, name)
+		}
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		db, err := model.NewDatabaseWithOptions(filepath.Join(t.TempDir(), "test.db"), model.DatabaseOptions{
+			MaxOpenConns: 4,
+			BusyTimeout:  1500 * time.Millisecond,
+			JournalMode:  "DELETE",
+		})
+		require.NoError(t, err)
+		defer db.Close()
+
+		// Every connection of the pool gets the pragmas
+		for i := 0; i < 8; i++ {
+			value, err := db.Pragma("busy_timeout")
+			require.NoError(t, err)
+			assert.Equal(t, "1500", value)
+		}
+		value, err := db.Pragma("journal_mode")
+		require.NoError(t, err)
+		assert.Equal(t, "delete", value)
+	})
+
+	t.Run("InvalidJournalMode", func(t *testing.T) {
+		_, err := model.NewDatabaseWithOptions(filepath.Join(t.TempDir(), "test.db"), model.DatabaseOptions{JournalMode: "sideways"})
+		assert.ErrorContains(t, err, `invalid journal mode "sideways"`)
+	})
+}
+
+func TestDatabaseForeignKeys(t *testing.T) {
+	testDB := testutil.NewTestDatabase(t)
+	defer testDB.Close()
+	jobStore := model.NewJobStore(testDB.DB.GetDB())
+	resultStore := model.NewJobResultStore(testDB.DB.GetDB())
+
+	job := &model.Job{Name: "backup", Host: "db1", AutomaticFailureThreshold: 3600, Status: "active"}
+	require.NoError(t, jobStore.CreateJob(job))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, resultStore.CreateJobResult(&model.JobResult{
+			JobName: "backup", Host: "db1", Status: "success", Timestamp: time.Now().UTC(),
+		}))
+	}
+
+	t.Run("ResultOfUnknownJob", func(t *testing.T) {
+		err := resultStore.CreateJobResult(&model.JobResult{
+			JobName: "unknown", Host: "db1", Status: "success", Timestamp: time.Now().UTC(),
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("RenameKeepsResults", func(t *testing.T) {
+		job.Name = "nightly-backup"
+		job.Host = "db2"
+		require.NoError(t, jobStore.UpdateJobByID(job))
+
+		results, err := resultStore.GetJobResults("nightly-backup", "db2", 10)
+		require.NoError(t, err)
+		assert.Len(t, results, 3)
+
+		results, err = resultStore.GetJobResults("backup", "db1", 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("DeleteRemovesResults", func(t *testing.T) {
+		require.NoError(t, jobStore.DeleteJobByID(job.ID))
+
+		var count int
+		require.NoError(t, testDB.DB.GetDB().Get(&count, `SELECT COUNT(*) FROM job_results`))
+		assert.Zero(t, count)
+	})
+}
+
+func TestDatabaseDrivers(t *testing.T) {
+	t.Run("SQLite", func(t *testing.T) {
+		db, err := model.OpenDatabase(model.DriverSQLite, filepath.Join(t.TempDir(), "test.db"), model.DatabaseOptions{})
+		require.NoError(t, err)
+		defer db.Close()
+
+		value, err := db.Pragma("foreign_keys")
+		require.NoError(t, err)
+		assert.Equal(t, "1", value)
+
+		jobStore := model.NewJobStore(db.GetDB())
+		require.NoError(t, jobStore.CreateJob(&model.Job{Name: "backup", Host: "db1", AutomaticFailureThreshold: 3600, Status: "active"}))
+		err = jobStore.CreateJob(&model.Job{Name: "backup", Host: "db1", AutomaticFailureThreshold: 3600, Status: "active"})
+		assert.True(t, model.IsUniqueViolation(err), "duplicate job: %v", err)
+	})
+
+	t.Run("InvalidDriver", func(t *testing.T) {
+		_, err := model.OpenDatabase("oracle", "", model.DatabaseOptions{})
+		assert.ErrorContains(t, err, `invalid database driver "oracle"`)
+	})
+
+	t.Run("InvalidMySQLDSN", func(t *testing.T) {
+		_, err := model.OpenDatabase(model.DriverMySQL, "cronmetrics@db1", model.DatabaseOptions{})
+		assert.ErrorContains(t, err, "invalid MySQL data source name")
+	})
+
+	t.Run("UnreachablePostgres", func(t *testing.T) {
+		_, err := model.OpenDatabase(model.DriverPostgres, "postgres://cronmetrics@127.0.0.1:1/cronmetrics?sslmode=disable&connect_timeout=2", model.DatabaseOptions{})
+		assert.ErrorContains(t, err, "failed to ping database")
+	})
+
+	t.Run("Config", func(t *testing.T) {
+		buildBinary(t)
+		cliTest := testutil.NewCLITest(t)
+		cliTest.CreateDefaultTestConfig()
+
+		cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render", "--set", "database.driver=oracle").
+			ExpectFailure().
+			ExpectStderrContains("invalid database driver: oracle")
+		cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render", "--set", "database.driver=postgres").
+			ExpectFailure().
+			ExpectStderrContains("database.dsn must be specified for the postgres driver")
+		cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render", "--set", "database.driver=mysql", "--set", "database.dsn=cronmetrics:secret@tcp(db1:3306)/cronmetrics").
+			ExpectSuccess()
+	})
+}
@@ -0,0 +1,64 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestDeadline(t *testing.T) {
+	// Requests get 90% of the write timeout
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Server.WriteTimeout = 1
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	get := func(path string) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, server.URL()+path, nil)
+		require.NoError(t, err)
+		for name, value := range server.AdminHeaders() {
+			req.Header.Set(name, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	t.Run("FastRequests", func(t *testing.T) {
+		status, _ := get("/api/job")
+		assert.Equal(t, http.StatusOK, status)
+		status, _ = get("/metrics")
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("BlockedDatabase", func(t *testing.T) {
+		// The in-memory test database has a single connection: holding it
+		// blocks every query
+		tx, err := server.Database.DB.GetDB().Begin()
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		for _, path := range []string{"/api/job", "/api/job/1", "/metrics", "/metrics/job/1"} {
+			start := time.Now()
+			status, body := get(path)
+			assert.Equal(t, http.StatusServiceUnavailable, status, "%s: %s", path, body)
+			assert.Contains(t, body, "context deadline exceeded", path)
+			assert.Less(t, time.Since(start), 2*time.Second, path)
+		}
+	})
+
+	t.Run("Recovered", func(t *testing.T) {
+		status, body := get("/metrics")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `job_name="backup"`)
+	})
+}
@@ -0,0 +1,104 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultThresholds(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Defaults = config.DefaultsConfig{
+			AutomaticFailureThreshold: 3600,
+			Rules: []config.DefaultsRule{
+				{Labels: map[string]string{"team": "data"}, AutomaticFailureThreshold: 21600},
+				{Labels: map[string]string{"team": "data", "env": "prod"}, AutomaticFailureThreshold: 60}, // Shadowed by the first
+			},
+		}
+	})
+	defer server.Close()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	create := func(body map[string]interface{}) *model.Job {
+		var job model.Job
+		admin.POST("/api/job", body).ExpectStatus(201).ExpectJSON(&job)
+		return &job
+	}
+	get := func(id int) *model.Job {
+		var job model.Job
+		admin.GET(fmt.Sprintf("/api/job/%d", id)).ExpectStatus(200).ExpectJSON(&job)
+		return &job
+	}
+
+	etl := create(map[string]interface{}{"job_name": "etl", "host": "dw1", "labels": map[string]string{"team": "data", "env": "prod"}})
+	plain := create(map[string]interface{}{"job_name": "cleanup", "host": "web1"})
+	pinned := create(map[string]interface{}{"job_name": "export", "host": "dw1", "labels": map[string]string{"team": "data"}, "automatic_failure_threshold": 600})
+
+	t.Run("Create", func(t *testing.T) {
+		assert.Equal(t, 21600, etl.AutomaticFailureThreshold, "first matching rule")
+		assert.True(t, etl.ThresholdInherited)
+		assert.Equal(t, 3600, plain.AutomaticFailureThreshold, "global default")
+		assert.True(t, plain.ThresholdInherited)
+		assert.Equal(t, 600, pinned.AutomaticFailureThreshold)
+		assert.False(t, pinned.ThresholdInherited)
+
+		var upserted model.Job
+		admin.PUT("/api/job", map[string]interface{}{"job_name": "load", "host": "dw2", "labels": map[string]string{"team": "data"}}).
+			ExpectStatus(201).ExpectJSON(&upserted)
+		assert.Equal(t, 21600, upserted.AutomaticFailureThreshold)
+		assert.True(t, upserted.ThresholdInherited)
+
+		var clone model.Job
+		admin.POST(fmt.Sprintf("/api/job/%d/clone", etl.ID), map[string]interface{}{"host": "dw3"}).ExpectStatus(201).ExpectJSON(&clone)
+		assert.Equal(t, 21600, clone.AutomaticFailureThreshold)
+		assert.True(t, clone.ThresholdInherited)
+	})
+
+	t.Run("LabelsChange", func(t *testing.T) {
+		admin.PUT(fmt.Sprintf("/api/job/%d", etl.ID), map[string]interface{}{"labels": map[string]string{"team": "web"}}).ExpectStatus(200)
+		job := get(etl.ID)
+		assert.Equal(t, 3600, job.AutomaticFailureThreshold, "inherited thresholds follow the labels")
+		assert.True(t, job.ThresholdInherited)
+
+		admin.PUT(fmt.Sprintf("/api/job/%d", pinned.ID), map[string]interface{}{"labels": map[string]string{"team": "web"}}).ExpectStatus(200)
+		assert.Equal(t, 600, get(pinned.ID).AutomaticFailureThreshold, "own thresholds are kept")
+	})
+
+	t.Run("PinAndInheritAgain", func(t *testing.T) {
+		admin.PUT(fmt.Sprintf("/api/job/%d", plain.ID), map[string]interface{}{"automatic_failure_threshold": 900}).ExpectStatus(200)
+		job := get(plain.ID)
+		assert.Equal(t, 900, job.AutomaticFailureThreshold)
+		assert.False(t, job.ThresholdInherited)
+
+		admin.PUT(fmt.Sprintf("/api/job/%d", plain.ID), map[string]interface{}{"threshold_inherited": true}).ExpectStatus(200)
+		job = get(plain.ID)
+		assert.Equal(t, 3600, job.AutomaticFailureThreshold)
+		assert.True(t, job.ThresholdInherited)
+	})
+
+	t.Run("Propagate", func(t *testing.T) {
+		server.Config.Defaults.AutomaticFailureThreshold = 7200
+		server.Config.Defaults.Rules[0].AutomaticFailureThreshold = 43200
+
+		updated, err := server.Database.GetJobStore().PropagateDefaultThresholds(server.Config.Defaults.FailureThreshold)
+		require.NoError(t, err)
+		names := []string{}
+		for _, job := range updated {
+			names = append(names, job.Name+"@"+job.Host)
+		}
+		assert.ElementsMatch(t, []string{"etl@dw1", "cleanup@web1", "load@dw2", "etl@dw3"}, names)
+
+		assert.Equal(t, 7200, get(etl.ID).AutomaticFailureThreshold)
+		assert.Equal(t, 7200, get(plain.ID).AutomaticFailureThreshold)
+		assert.Equal(t, 600, get(pinned.ID).AutomaticFailureThreshold)
+
+		updated, err = server.Database.GetJobStore().PropagateDefaultThresholds(server.Config.Defaults.FailureThreshold)
+		require.NoError(t, err)
+		assert.Empty(t, updated, "nothing left to update")
+	})
+}
@@ -0,0 +1,136 @@
+package integration
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/events"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventExportToNATS(t *testing.T) {
+	broker := newFakeNATS(t)
+
+	publisher, err := events.NewPublisher(&config.EventsConfig{Driver: "nats", URL: broker.URL(), Subject: "cronmetrics"})
+	require.NoError(t, err)
+	exporter := events.NewExporter(publisher, 100)
+
+	db := testutil.NewInMemoryTestDatabase(t)
+	jobStore := db.GetJobStore()
+	jobResultStore := db.GetJobResultStore()
+	jobStore.SetChangeHook(exporter.Handle)
+	jobResultStore.SetChangeHook(exporter.Handle)
+
+	job := &model.Job{Name: "backup", Host: "db1", ApiKey: "cm_secret_key", AutomaticFailureThreshold: 3600, Status: "active", Labels: map[string]string{"env": "prod"}}
+	require.NoError(t, jobStore.CreateJob(job))
+	job.Status = "maintenance"
+	require.NoError(t, jobStore.UpdateJob(job))
+	require.NoError(t, jobResultStore.CreateJobResult(&model.JobResult{JobName: "backup", Host: "db1", Status: "failure", Timestamp: time.Now().UTC()}))
+	require.NoError(t, jobStore.DeleteJobByID(job.ID))
+
+	// Close publishes everything still queued
+	require.NoError(t, exporter.Close())
+
+	require.Eventually(t, func() bool { return len(broker.Messages()) == 4 }, 5*time.Second, 10*time.Millisecond)
+	var messages []model.ChangeEvent
+	var subjects []string
+	for _, msg := range broker.Messages() {
+		var event model.ChangeEvent
+		require.NoError(t, json.Unmarshal(msg.Data, &event))
+		messages = append(messages, event)
+		subjects = append(subjects, msg.Subject)
+	}
+
+	assert.Equal(t, "cronmetrics.job.created", subjects[0])
+	require.NotNil(t, messages[0].Job)
+	assert.Equal(t, "backup", messages[0].Job.Name)
+	assert.Equal(t, "prod", messages[0].Job.Labels["env"])
+	assert.Empty(t, messages[0].Job.ApiKey, "API keys must not be exported")
+
+	assert.Equal(t, "cronmetrics.job.updated", subjects[1])
+	assert.Equal(t, "maintenance", messages[1].Job.Status)
+
+	assert.Equal(t, "cronmetrics.result.recorded", subjects[2])
+	require.NotNil(t, messages[2].Result)
+	assert.Equal(t, "failure", messages[2].Result.Status)
+	assert.NotZero(t, messages[2].Result.ID)
+
+	assert.Equal(t, "cronmetrics.job.deleted", subjects[3])
+	assert.Equal(t, job.ID, messages[3].Job.ID)
+}
+
+func TestEventExportToCommand(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "events.log")
+	publisher, err := events.NewPublisher(&config.EventsConfig{
+		Driver:      "exec",
+		Command:     []string{"sh", "-c", `printf '%s ' "$CRONMETRICS_EVENT_TYPE" >> "$0"; cat >> "$0"; echo >> "$0"`, out},
+		Timeout:     5,
+		Concurrency: 1,
+	})
+	require.NoError(t, err)
+	exporter := events.NewExporter(publisher, 100)
+
+	db := testutil.NewInMemoryTestDatabase(t)
+	jobStore := db.GetJobStore()
+	jobResultStore := db.GetJobResultStore()
+	jobStore.SetChangeHook(exporter.Handle)
+	jobResultStore.SetChangeHook(exporter.Handle)
+	job := &model.Job{Name: "backup", Host: "db1", ApiKey: "cm_secret_key", AutomaticFailureThreshold: 3600, Status: "active"}
+	require.NoError(t, jobStore.CreateJob(job))
+	require.NoError(t, jobResultStore.CreateJobResult(&model.JobResult{JobName: "backup", Host: "db1", Status: "failure", Timestamp: time.Now().UTC()}))
+
+	// Close waits for the commands to end
+	require.NoError(t, exporter.Close())
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2, "one command per event, one at a time")
+
+	eventType, body, _ := strings.Cut(lines[0], " ")
+	assert.Equal(t, "job.created", eventType)
+	var event model.ChangeEvent
+	require.NoError(t, json.Unmarshal([]byte(body), &event))
+	require.NotNil(t, event.Job)
+	assert.Equal(t, "backup", event.Job.Name)
+	assert.Empty(t, event.Job.ApiKey, "API keys must not be exported")
+	assert.True(t, strings.HasPrefix(lines[1], "result.recorded {"))
+
+	t.Run("Timeout", func(t *testing.T) {
+		publisher, err := events.NewPublisher(&config.EventsConfig{Driver: "exec", Command: []string{"sleep", "30"}, Timeout: 1, Concurrency: 2})
+		require.NoError(t, err)
+		exporter := events.NewExporter(publisher, 100)
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			exporter.Handle(model.ChangeEvent{Type: "job.updated", Time: start})
+		}
+		require.NoError(t, exporter.Close())
+
+		// Two commands are killed after a second, then the third one
+		elapsed := time.Since(start)
+		assert.GreaterOrEqual(t, elapsed, 2*time.Second)
+		assert.Less(t, elapsed, 10*time.Second)
+	})
+}
+
+func TestEventExportConfig(t *testing.T) {
+	_, err := events.NewPublisher(&config.EventsConfig{Driver: "nats", URL: "nats://127.0.0.1:1"})
+	assert.Error(t, err, "unreachable NATS server should fail at startup")
+
+	_, err = events.NewPublisher(&config.EventsConfig{Driver: "amqp"})
+	assert.Error(t, err)
+
+	// Kafka connects lazily
+	publisher, err := events.NewPublisher(&config.EventsConfig{Driver: "kafka", Brokers: []string{"127.0.0.1:1"}, Topic: "events"})
+	require.NoError(t, err)
+	assert.NoError(t, publisher.Close())
+}
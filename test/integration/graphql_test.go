@@ -0,0 +1,217 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// graphQLResponse is a GraphQL response with its data left to decode
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string        `json:"message"`
+		Path    []interface{} `json:"path"`
+	} `json:"errors"`
+}
+
+func TestGraphQL(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.SLO.Objectives = []config.SLOObjective{
+			{Name: "prod", Labels: map[string]string{"env": "prod"}, Target: 0.99, WindowDays: 30},
+		}
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	for i, status := range []string{"success", "failure"} {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{
+				"job_name":  "backup",
+				"host":      "db1",
+				"status":    status,
+				"timestamp": time.Now().UTC().Add(time.Duration(i-2) * time.Minute).Format(time.RFC3339),
+			}).
+			ExpectStatus(201)
+	}
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	query := func(t *testing.T, status int, query string, variables map[string]interface{}, data interface{}) *graphQLResponse {
+		var response graphQLResponse
+		admin.POST("/api/graphql", map[string]interface{}{"query": query, "variables": variables}).
+			ExpectStatus(status).
+			ExpectJSON(&response)
+		if data != nil {
+			require.NoError(t, json.Unmarshal(response.Data, data))
+		}
+		return &response
+	}
+
+	t.Run("Job", func(t *testing.T) {
+		var data struct {
+			Backup struct {
+				Name       string            `json:"name"`
+				Labels     map[string]string `json:"labels"`
+				Threshold  int               `json:"threshold"`
+				Typename   string            `json:"__typename"`
+				Check      struct{ Reason string }
+				LastResult []struct{ Status string } `json:"lastResult"`
+				Results    []struct {
+					Status     string     `json:"status"`
+					ReceivedAt *time.Time `json:"receivedAt"`
+				} `json:"results"`
+				SLA struct {
+					Total       int      `json:"total"`
+					Failed      int      `json:"failed"`
+					SuccessRate *float64 `json:"successRate"`
+				} `json:"sla"`
+			} `json:"backup"`
+		}
+		response := query(t, 200, `
+			query Job($name: String!, $host: String = "db1") {
+				backup: job(name: $name, host: $host) {
+					name labels
+					check { reason }
+					lastResult: results(limit: 1) { status }
+					results { status receivedAt }
+					sla(windowDays: 7) { total failed successRate }
+					...Meta
+				}
+			}
+			fragment Meta on Job { threshold __typename }`,
+			map[string]interface{}{"name": "backup"}, &data)
+
+		assert.Empty(t, response.Errors)
+		assert.Equal(t, "backup", data.Backup.Name)
+		assert.Equal(t, "prod", data.Backup.Labels["env"])
+		assert.Equal(t, "Job", data.Backup.Typename)
+		assert.Positive(t, data.Backup.Threshold)
+		assert.Equal(t, "failure", data.Backup.Check.Reason)
+		require.Len(t, data.Backup.LastResult, 1)
+		require.Len(t, data.Backup.Results, 2)
+		assert.Equal(t, "failure", data.Backup.Results[0].Status, "newest first")
+		assert.NotNil(t, data.Backup.Results[0].ReceivedAt)
+		assert.Equal(t, 2, data.Backup.SLA.Total)
+		assert.Equal(t, 1, data.Backup.SLA.Failed)
+		require.NotNil(t, data.Backup.SLA.SuccessRate)
+		assert.InDelta(t, 0.5, *data.Backup.SLA.SuccessRate, 0.001)
+
+		// Fields keep the order they were selected in
+		assert.Regexp(t, `^\{"backup":\{"name":.*"labels":.*"check":.*"lastResult":.*"results":.*"sla":.*"threshold":.*"__typename":"Job"\}\}$`, string(response.Data))
+
+		var missing struct{ Job *struct{ ID int } }
+		query(t, 200, `{ job(id: 999) { id } }`, nil, &missing)
+		assert.Nil(t, missing.Job)
+	})
+
+	t.Run("Fleet", func(t *testing.T) {
+		var data struct {
+			Jobs  []struct{ Name string }
+			Hosts []struct {
+				Host     string
+				JobCount int
+				Jobs     []struct{ Name string }
+			}
+			Rollup []struct {
+				Value       string
+				Total       int
+				Failing     int
+				Excluded    int
+				HealthScore *float64
+			}
+			SLOs []struct {
+				Name       string
+				Total      int
+				Failed     int
+				Recoveries int
+				MTTR       *float64 `json:"mttr"`
+			} `json:"slos"`
+		}
+		query(t, 200, `{
+			jobs(labels: {env: "prod"}, status: "active") { name }
+			hosts { host jobCount jobs { name } }
+			rollup(label: "env") { value total failing excluded healthScore }
+			slos { name total failed recoveries mttr }
+		}`, nil, &data)
+
+		require.Len(t, data.Jobs, 2)
+		require.Len(t, data.Hosts, 3)
+		assert.Equal(t, "app1", data.Hosts[0].Host)
+		assert.Equal(t, 1, data.Hosts[0].JobCount)
+		require.Len(t, data.Hosts[0].Jobs, 1)
+		assert.Equal(t, "maintenance-job", data.Hosts[0].Jobs[0].Name)
+
+		require.Len(t, data.Rollup, 2)
+		assert.Equal(t, "prod", data.Rollup[0].Value)
+		assert.Equal(t, 2, data.Rollup[0].Total)
+		assert.GreaterOrEqual(t, data.Rollup[0].Failing, 1)
+		require.NotNil(t, data.Rollup[0].HealthScore)
+		assert.Equal(t, "staging", data.Rollup[1].Value)
+		assert.Equal(t, 1, data.Rollup[1].Excluded)
+		assert.Nil(t, data.Rollup[1].HealthScore, "no job left to score")
+
+		require.Len(t, data.SLOs, 1)
+		assert.Equal(t, "prod", data.SLOs[0].Name)
+		assert.Equal(t, 2, data.SLOs[0].Total)
+		assert.Equal(t, 1, data.SLOs[0].Failed)
+		assert.Zero(t, data.SLOs[0].Recoveries)
+		assert.Nil(t, data.SLOs[0].MTTR, "the failure has not recovered")
+	})
+
+	t.Run("GET", func(t *testing.T) {
+		params := url.Values{
+			"query":     {`query($id: Int!) { job(id: $id) { name } }`},
+			"variables": {`{"id": 1}`},
+		}
+		admin.GET("/api/graphql?" + params.Encode()).ExpectStatus(200).ExpectContains(`"job":{"name":"backup"}`)
+
+		sdl := admin.GET("/api/graphql").ExpectStatus(200).BodyString()
+		assert.Contains(t, sdl, "type Query {")
+		assert.Contains(t, sdl, "results(limit: Int = 10): [Result!]!")
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		// A failing field is null, with its error and path
+		var data struct {
+			Job struct{ Results []interface{} }
+		}
+		response := query(t, 200, `{ job(id: 1) { results(limit: 0) { status } } }`, nil, &data)
+		require.Len(t, response.Errors, 1)
+		assert.Contains(t, response.Errors[0].Message, "limit must be between 1 and 100")
+		assert.Equal(t, []interface{}{"job", "results"}, response.Errors[0].Path)
+		assert.Nil(t, data.Job.Results)
+
+		// Invalid queries are not executed
+		for document, message := range map[string]string{
+			`{ job(id: 1) { name }`:                     "syntax error at 1:22",
+			`{ job(id: 1) { apiKey } }`:                 `field "apiKey" is not defined on type Job`,
+			`{ job(id: "one") { name } }`:               `argument "id" of field Query.job: expected Int, got a string`,
+			`{ job(id: 1) }`:                            "must have a selection of subfields",
+			`{ rollup { value } }`:                      `argument "label" of field Query.rollup is required`,
+			`mutation { job(id: 1) { name } }`:          "mutation operations are not supported",
+			`{ __schema { types { name } } }`:           "introspection is not supported",
+			`query($id: Int!) { job(id: $id) { id } }`:  "variable $id of type Int! is required",
+			`{ job(id: 1) { ...Missing } }`:             `fragment "Missing" is not defined`,
+			`{ a: job(id: 1) { id } a: jobs { id } }`:   "conflict",
+			`{ job(id: 1) { id @include(if: "yes") } }`: "requires a Boolean if argument",
+		} {
+			response := query(t, 400, document, nil, nil)
+			require.Len(t, response.Errors, 1, document)
+			assert.Contains(t, response.Errors[0].Message, message, document)
+			assert.Empty(t, response.Data, document)
+		}
+
+		admin.POST("/api/graphql", map[string]interface{}{}).ExpectStatus(400).ExpectContains("query is required")
+		admin.DELETE("/api/graphql").ExpectStatus(405)
+		testutil.NewHTTPClient(t, server.URL()).
+			POST("/api/graphql", map[string]interface{}{"query": "{ jobs { name } }"}).
+			ExpectStatus(401)
+	})
+}
@@ -0,0 +1,127 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/api"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelsEndpoint(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+
+	client := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+
+	for i, env := range []string{"prod", "prod", "staging"} {
+		client.POST("/api/job", map[string]interface{}{
+			"job_name": fmt.Sprintf("job-%d", i),
+			"host":     "h1",
+			"labels":   map[string]string{"env": env, "team": "infra"},
+		}).ExpectStatus(201)
+	}
+	client.POST("/api/job", map[string]interface{}{"job_name": "unlabelled", "host": "h1"}).
+		ExpectStatus(201)
+
+	var resp api.LabelsResponse
+	client.GET("/api/labels").ExpectStatus(200).ExpectJSON(&resp)
+
+	require.Len(t, resp.Labels, 2)
+	env := resp.Labels[0]
+	assert.Equal(t, "env", env.Key)
+	assert.Equal(t, 3, env.JobCount)
+	assert.Equal(t, 2, env.ValueCount)
+	assert.Equal(t, []model.LabelValueUsage{{Value: "prod", Count: 2}, {Value: "staging", Count: 1}}, env.Values)
+
+	team := resp.Labels[1]
+	assert.Equal(t, "team", team.Key)
+	assert.Equal(t, 1, team.ValueCount)
+
+	t.Run("RequiresAdmin", func(t *testing.T) {
+		testutil.NewHTTPClient(t, server.URL()).GET("/api/labels").ExpectStatus(401)
+	})
+}
+
+func TestHostsEndpoint(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+
+	client := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+
+	for _, job := range []struct{ name, host string }{{"a", "web1"}, {"b", "web1"}, {"c", "db1"}} {
+		client.POST("/api/job", map[string]interface{}{"job_name": job.name, "host": job.host}).
+			ExpectStatus(201)
+	}
+
+	var resp api.HostsResponse
+	client.GET("/api/hosts").ExpectStatus(200).ExpectJSON(&resp)
+
+	require.Len(t, resp.Hosts, 2)
+	assert.Equal(t, model.HostUsage{Host: "db1", JobCount: 1}, *resp.Hosts[0])
+	assert.Equal(t, model.HostUsage{Host: "web1", JobCount: 2}, *resp.Hosts[1])
+}
+
+func TestLabelCardinalityGuard(t *testing.T) {
+	limitConfig := func(reject bool) func(cfg *config.Config) {
+		return func(cfg *config.Config) {
+			cfg.Metrics.MaxLabelValues = 2
+			cfg.Metrics.RejectNewLabelValues = reject
+		}
+	}
+
+	t.Run("WarnOnly", func(t *testing.T) {
+		server := testutil.NewTestServerWithConfig(t, limitConfig(false))
+		defer server.Close()
+
+		client := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+		for i := 0; i < 3; i++ {
+			client.POST("/api/job", map[string]interface{}{
+				"job_name": fmt.Sprintf("job-%d", i), "host": "h1",
+				"labels": map[string]string{"build": fmt.Sprintf("b%d", i)},
+			}).ExpectStatus(201)
+		}
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		server := testutil.NewTestServerWithConfig(t, limitConfig(true))
+		defer server.Close()
+
+		client := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+		var job model.Job
+		for i := 0; i < 2; i++ {
+			client.POST("/api/job", map[string]interface{}{
+				"job_name": fmt.Sprintf("job-%d", i), "host": "h1",
+				"labels": map[string]string{"build": fmt.Sprintf("b%d", i)},
+			}).ExpectStatus(201).ExpectJSON(&job)
+		}
+
+		client.POST("/api/job", map[string]interface{}{
+			"job_name": "job-new", "host": "h1",
+			"labels": map[string]string{"build": "b2"},
+		}).ExpectStatus(400).ExpectContains("label value limit reached for build")
+
+		// Existing values remain usable
+		client.POST("/api/job", map[string]interface{}{
+			"job_name": "job-reuse", "host": "h1",
+			"labels": map[string]string{"build": "b0"},
+		}).ExpectStatus(201)
+
+		client.PUT(fmt.Sprintf("/api/job/%d", job.ID), map[string]interface{}{
+			"labels": map[string]string{"build": "b3"},
+		}).ExpectStatus(400)
+
+		client.PUT("/api/job", map[string]interface{}{
+			"job_name": "job-upsert", "host": "h1",
+			"labels": map[string]string{"build": "b4"},
+		}).ExpectStatus(400)
+
+		assert.Equal(t, 3, server.Database.CountJobs())
+	})
+}
@@ -0,0 +1,155 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/launchd"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLaunchctl writes a launchctl stand-in printing the status file of
+// the requested label, and returns its path
+func fakeLaunchctl(t *testing.T, statusDir string) string {
+	path := filepath.Join(t.TempDir(), "launchctl")
+	script := fmt.Sprintf("#!/bin/sh\n[ \"$1\" = print ] || exit 64\nexec cat \"%s/$(basename \"$2\")\"\n", statusDir)
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o755))
+	return path
+}
+
+// setLaunchdStatus writes the launchctl print output of a job
+func setLaunchdStatus(t *testing.T, statusDir, label, state string, runs, exitCode int) {
+	output := fmt.Sprintf("gui/501/%s = {\n\tactive count = 0\n\tstate = %s\n\tprogram = /usr/local/bin/backup.sh\n"+
+		"\tevent triggers = {\n\t\tstate = running\n\t}\n\truns = %d\n\tlast exit code = %d\n}\n", label, state, runs, exitCode)
+	require.NoError(t, os.WriteFile(filepath.Join(statusDir, label), []byte(output), 0o644))
+}
+
+func TestLaunchdReporting(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	jobStore := server.Database.GetJobStore()
+	require.NoError(t, jobStore.CreateJob(&model.Job{Name: "backup", Host: "mac-mini", ApiKey: "cm_launchd_key", AutomaticFailureThreshold: 86400, Status: "active"}))
+
+	agentsDir, statusDir := t.TempDir(), t.TempDir()
+	calendar, err := launchd.ParseCalendar("0 3 * * *")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(agentsDir, "com.example.backup.plist"), launchd.Plist(&launchd.Job{
+		Label:     "com.example.backup",
+		Program:   []string{"/usr/local/bin/backup.sh", "--full"},
+		Name:      "backup",
+		Host:      "mac-mini",
+		APIKey:    "cm_launchd_key",
+		ServerURL: server.Server.URL,
+		Calendar:  calendar,
+	}), 0o600))
+	// Plists of other jobs and binary plists are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(agentsDir, "com.example.other.plist"),
+		launchd.ReporterPlist("com.example.other", 60, []string{"/bin/true"}), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(agentsDir, "com.apple.binary.plist"), []byte("bplist00\x00\x01"), 0o644))
+
+	jobs, err := launchd.LoadJobs(agentsDir)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "com.example.backup", jobs[0].Label)
+	assert.Equal(t, "backup", jobs[0].Name)
+	assert.Equal(t, "mac-mini", jobs[0].Host)
+	assert.Equal(t, "cm_launchd_key", jobs[0].APIKey)
+
+	reporter := &launchd.Reporter{
+		Launchctl: &launchd.Launchctl{Path: fakeLaunchctl(t, statusDir), Domain: "gui/501"},
+		StatePath: filepath.Join(t.TempDir(), "state", "launchd-state.json"),
+		Client:    &http.Client{Timeout: 5 * time.Second},
+	}
+	results := func() []*model.JobResult {
+		results, err := server.Database.GetJobResultStore().GetJobResults("backup", "mac-mini", 10)
+		require.NoError(t, err)
+		return results
+	}
+
+	// The first report only records past runs
+	setLaunchdStatus(t, statusDir, "com.example.backup", "not running", 2, 0)
+	submitted, err := reporter.Report(jobs)
+	require.NoError(t, err)
+	assert.Equal(t, 0, submitted)
+
+	setLaunchdStatus(t, statusDir, "com.example.backup", "not running", 3, 1)
+	submitted, err = reporter.Report(jobs)
+	require.NoError(t, err)
+	assert.Equal(t, 1, submitted)
+	require.Len(t, results(), 1)
+	assert.Equal(t, "failure", results()[0].Status)
+	assert.Contains(t, results()[0].Output, "last exit code 1")
+
+	// Nothing new, then a run in progress
+	submitted, err = reporter.Report(jobs)
+	require.NoError(t, err)
+	assert.Equal(t, 0, submitted)
+	setLaunchdStatus(t, statusDir, "com.example.backup", "running", 3, 1)
+	submitted, err = reporter.Report(jobs)
+	require.NoError(t, err)
+	assert.Equal(t, 0, submitted)
+
+	setLaunchdStatus(t, statusDir, "com.example.backup", "not running", 5, 0)
+	submitted, err = reporter.Report(jobs)
+	require.NoError(t, err)
+	assert.Equal(t, 1, submitted)
+	require.Len(t, results(), 2)
+	assert.Contains(t, results()[0].Output, "2 runs since the last report")
+
+	// Reloading the job resets its run count
+	setLaunchdStatus(t, statusDir, "com.example.backup", "not running", 1, 0)
+	submitted, err = reporter.Report(jobs)
+	require.NoError(t, err)
+	assert.Equal(t, 1, submitted)
+	assert.Len(t, results(), 3)
+
+	job, err := jobStore.GetJob("backup", "mac-mini")
+	require.NoError(t, err)
+	assert.False(t, job.LastReportedAt.IsZero())
+
+	t.Run("rejected submission is retried", func(t *testing.T) {
+		jobs[0].APIKey = "cm_wrong"
+		setLaunchdStatus(t, statusDir, "com.example.backup", "not running", 2, 0)
+		_, err := reporter.Report(jobs)
+		assert.ErrorContains(t, err, "401")
+
+		jobs[0].APIKey = "cm_launchd_key"
+		submitted, err := reporter.Report(jobs)
+		require.NoError(t, err)
+		assert.Equal(t, 1, submitted)
+	})
+
+	t.Run("signed submission", func(t *testing.T) {
+		reporter.Sign = true
+		defer func() { reporter.Sign = false }()
+
+		setLaunchdStatus(t, statusDir, "com.example.backup", "not running", 3, 0)
+		submitted, err := reporter.Report(jobs)
+		require.NoError(t, err)
+		assert.Equal(t, 1, submitted)
+		assert.Len(t, results(), 5)
+	})
+}
+
+func TestLaunchdCalendar(t *testing.T) {
+	calendar, err := launchd.ParseCalendar("30 2,14 * * 1")
+	require.NoError(t, err)
+	assert.Equal(t, []launchd.CalendarInterval{
+		{"Minute": 30, "Hour": 2, "Weekday": 1},
+		{"Minute": 30, "Hour": 14, "Weekday": 1},
+	}, calendar)
+
+	for _, spec := range []string{"* * * *", "60 * * * *", "*/5 * * * *", "0 0 0 * *"} {
+		_, err := launchd.ParseCalendar(spec)
+		assert.Error(t, err, spec)
+	}
+
+	status := launchd.ParseStatus("gui/501/x = {\n\tstate = not running\n\truns = 4\n\tlast exit code = 0\n\tlast terminating signal = Killed: 9\n}\n")
+	assert.Equal(t, &launchd.Status{Runs: 4, Signal: "Killed: 9"}, status)
+}
@@ -0,0 +1,288 @@
+package integration
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/logging"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/util"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureRequestLogs records the request entries of the standard logger,
+// including debug ones
+func captureRequestLogs(t *testing.T) func() []*logrus.Entry {
+	hook := logrustest.NewGlobal()
+	level := logrus.GetLevel()
+	logrus.SetLevel(logrus.DebugLevel)
+	t.Cleanup(func() {
+		logrus.SetLevel(level)
+		logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+	})
+
+	return func() []*logrus.Entry {
+		var entries []*logrus.Entry
+		for _, entry := range hook.AllEntries() {
+			if entry.Message == "http request" {
+				entries = append(entries, entry)
+			}
+		}
+		hook.Reset()
+		return entries
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	requests := captureRequestLogs(t)
+
+	t.Run("PathOverrides", func(t *testing.T) {
+		server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+			cfg.Logging.Access = config.AccessLogConfig{
+				Level: "info",
+				Paths: []config.AccessLogPath{
+					{Path: "/metrics", Level: "off"},
+					{Path: "/health", Level: "debug"},
+					{Path: "/api/job/", Level: "warn"},
+					{Path: "/api/job/exists", Level: "error"},
+				},
+			}
+		})
+		defer server.Close()
+		client := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+
+		client.GET("/metrics").ExpectStatus(200)
+		client.GET("/metrics").ExpectStatus(200)
+		assert.Empty(t, requests(), "silenced path")
+
+		client.GET("/health").ExpectStatus(200)
+		client.GET("/api/job").ExpectStatus(200)
+		client.GET("/api/job/999").ExpectStatus(404)
+		client.GET("/api/job/exists?name=backup&host=db1").ExpectStatus(200)
+
+		entries := requests()
+		require.Len(t, entries, 4)
+		assert.Equal(t, logrus.DebugLevel, entries[0].Level)
+		assert.Equal(t, "/health", entries[0].Data["path"])
+		assert.Equal(t, logrus.InfoLevel, entries[1].Level, "default level")
+		assert.Equal(t, logrus.WarnLevel, entries[2].Level, "prefix override")
+		assert.Equal(t, 404, entries[2].Data["status"])
+		assert.Equal(t, logrus.ErrorLevel, entries[3].Level, "most specific override")
+	})
+
+	t.Run("Sampling", func(t *testing.T) {
+		server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+			cfg.Logging.Access = config.AccessLogConfig{Level: "info", Sample: 3}
+		})
+		defer server.Close()
+		client := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+
+		for i := 0; i < 7; i++ {
+			client.GET("/health").ExpectStatus(200)
+		}
+		assert.Len(t, requests(), 3, "first of every 3 successful requests")
+
+		for i := 0; i < 4; i++ {
+			client.GET("/api/job/999").ExpectStatus(404)
+		}
+		assert.Len(t, requests(), 4, "failed requests are not sampled")
+	})
+
+	t.Run("BasePath", func(t *testing.T) {
+		server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+			cfg.Server.BasePath = "/cron"
+			cfg.Logging.Access = config.AccessLogConfig{Paths: []config.AccessLogPath{{Path: "/health", Level: "off"}}}
+		})
+		defer server.Close()
+		client := testutil.NewHTTPClient(t, server.URL()+"/cron")
+
+		client.GET("/health").ExpectStatus(200)
+		assert.Empty(t, requests(), "paths are matched below the base path")
+
+		client.GET("/metrics").ExpectStatus(200)
+		entries := requests()
+		require.Len(t, entries, 1)
+		assert.Equal(t, logrus.InfoLevel, entries[0].Level, "unset level is info")
+		assert.Equal(t, "/cron/metrics", entries[0].Data["path"])
+	})
+
+	t.Run("Fields", func(t *testing.T) {
+		server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+			cfg.Logging.Access = config.AccessLogConfig{
+				Fields:      []string{"method", "path", "query", "status"},
+				RedactQuery: true,
+			}
+		})
+		defer server.Close()
+		client := testutil.NewHTTPClient(t, server.URL())
+
+		client.GET("/api/ping?key=cm_test_backup_key&flag").ExpectStatus(401)
+		client.GET("/health").ExpectStatus(200)
+
+		entries := requests()
+		require.Len(t, entries, 2)
+		assert.Equal(t, logrus.Fields{
+			"method": "GET",
+			"path":   "/api/ping",
+			"query":  "key=REDACTED&flag",
+			"status": 401,
+		}, entries[0].Data)
+		assert.Equal(t, "", entries[1].Data["query"])
+	})
+
+	t.Run("ClientIPs", func(t *testing.T) {
+		server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+			cfg.Logging.ClientIPs = "hash"
+			cfg.Logging.ClientIPKey = "test-client-ip-key"
+		})
+		defer server.Close()
+		server.SeedTestData()
+		client := testutil.NewHTTPClient(t, server.URL())
+
+		client.GET("/api/ping?key=cm_wrong_key_123").ExpectStatus(401)
+		hash := util.NewIPHasher("test-client-ip-key").Hash("127.0.0.1")
+
+		entries := requests()
+		require.Len(t, entries, 1)
+		assert.Equal(t, hash, entries[0].Data["remote_addr"])
+		assert.NotContains(t, entries[0].Data, "query", "not logged by default")
+		assert.Contains(t, entries[0].Data, "user_agent")
+
+		var rejections model.RejectionPage
+		testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders()).
+			GET("/api/result-rejections").ExpectStatus(200).ExpectJSON(&rejections)
+		require.Len(t, rejections.Rejections, 1)
+		assert.Equal(t, hash, rejections.Rejections[0].Source)
+	})
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cronmetrics.log")
+
+	// A rotated file older than max age
+	expired := path + ".20200101-000000.000"
+	require.NoError(t, os.WriteFile(expired, []byte("old\n"), 0o600))
+	require.NoError(t, os.Chtimes(expired, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour)))
+	// Files that only share the prefix are left alone
+	unrelated := path + ".bak"
+	require.NoError(t, os.WriteFile(unrelated, []byte("keep\n"), 0o600))
+
+	file := &logging.RotatingFile{Path: path, MaxSize: 100, MaxAge: 24 * time.Hour, MaxBackups: 2}
+	defer file.Close()
+
+	line := []byte(strings.Repeat("x", 39) + "\n")
+	for i := 0; i < 12; i++ {
+		_, err := file.Write(line)
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond) // Distinct rotation timestamps
+	}
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, info.Size(), int64(100))
+
+	backups, err := filepath.Glob(path + ".2*")
+	require.NoError(t, err)
+	assert.Len(t, backups, 2, "only max_backups rotated files are kept")
+	assert.NotContains(t, backups, expired)
+	for _, backup := range backups {
+		data, err := os.ReadFile(backup)
+		require.NoError(t, err)
+		assert.Equal(t, 80, len(data), "files are rotated before exceeding max_size")
+	}
+	assert.FileExists(t, unrelated)
+}
+
+func TestLoggingSinks(t *testing.T) {
+	dir := t.TempDir()
+
+	syslogServer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer syslogServer.Close()
+
+	journalPath := filepath.Join(dir, "journal.sock")
+	journal, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: journalPath, Net: "unixgram"})
+	require.NoError(t, err)
+	defer journal.Close()
+
+	receive := func(conn net.PacketConn) string {
+		buf := make([]byte, 65536)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		return string(buf[:n])
+	}
+
+	filePath := filepath.Join(dir, "logs", "warnings.log")
+	logger := logrus.New()
+	var console bytes.Buffer
+	logger.SetOutput(&console)
+
+	err = logging.Configure(logger, &config.LoggingConfig{
+		Level:  "info",
+		Format: "json",
+		Output: "stdout",
+		Sinks: []config.LogSinkConfig{
+			{Type: "syslog", Address: "udp://" + syslogServer.LocalAddr().String(), Facility: "local3", Tag: "cronmetrics-test"},
+			{Type: "journald", Address: journalPath},
+			{Type: "file", Path: filePath, Level: "warn"},
+		},
+	})
+	require.NoError(t, err)
+
+	logger.WithFields(logrus.Fields{"job_name": "backup", "_private": "x", "output": "line one\nline two"}).Info("job result recorded")
+	logger.Debug("not logged anywhere")
+	logger.Warn("slow request")
+
+	assert.Contains(t, console.String(), "job result recorded", "console output is kept")
+
+	message := receive(syslogServer)
+	assert.Contains(t, message, "cronmetrics-test")
+	assert.Contains(t, message, "<158>", "local3.info")
+	assert.Contains(t, message, "job result recorded")
+	assert.Contains(t, receive(syslogServer), "slow request")
+
+	entry := receive(journal)
+	assert.Contains(t, entry, "MESSAGE=job result recorded\n")
+	assert.Contains(t, entry, "PRIORITY=6\n")
+	assert.Contains(t, entry, "SYSLOG_IDENTIFIER=cronmetrics\n")
+	assert.Contains(t, entry, "JOB_NAME=backup\n")
+	assert.Contains(t, entry, "PRIVATE=x\n", "field names cannot start with an underscore")
+	assert.Contains(t, entry, "OUTPUT\n\x11\x00\x00\x00\x00\x00\x00\x00line one\nline two\n", "multi-line values are length-prefixed")
+	assert.Contains(t, receive(journal), "PRIORITY=4\n")
+
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "job result recorded", "below the sink level")
+	assert.Contains(t, string(data), `"msg":"slow request"`)
+
+	t.Run("UnavailableSink", func(t *testing.T) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		err := logging.Configure(logger, &config.LoggingConfig{
+			Level: "info",
+			Sinks: []config.LogSinkConfig{
+				{Type: "journald", Address: filepath.Join(dir, "missing.sock")},
+				{Type: "file", Path: filepath.Join(dir, "other.log")},
+			},
+		})
+		assert.ErrorContains(t, err, "journald")
+
+		logger.Info("still written")
+		data, err := os.ReadFile(filepath.Join(dir, "other.log"))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "still written")
+	})
+}
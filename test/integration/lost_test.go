@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/ingest"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLostRuns(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	submit := func(key, name, host, status string, at time.Time, duration int) {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": key, "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{
+				"job_name": name, "host": host, "status": status,
+				"timestamp": at.UTC().Format(time.RFC3339), "duration": duration,
+			}).
+			ExpectStatus(201)
+	}
+	results := func(name, host string) []*model.JobResult {
+		results, err := server.Database.GetJobResultStore().GetJobResults(name, host, 10)
+		require.NoError(t, err)
+		return results
+	}
+
+	detector := ingest.NewLostRunDetector(&server.Config.LostRuns, server.Database.GetJobResultStore())
+	now := time.Now().UTC()
+
+	// Backup runs take a minute: a run started five minutes ago is lost
+	submit("cm_test_backup_key", "backup", "db1", "success", now.Add(-20*time.Minute), 60)
+	submit("cm_test_backup_key", "backup", "db1", "success", now.Add(-10*time.Minute), 60)
+	submit("cm_test_backup_key", "backup", "db1", "started", now.Add(-5*time.Minute), 0)
+	require.Len(t, results("backup", "db1"), 2, "starts are not results")
+
+	// Log rotation has no timed results and reported its result
+	submit("cm_test_logrotation_key", "log-rotation", "web1", "started", now.Add(-3*time.Hour), 0)
+	submit("cm_test_logrotation_key", "log-rotation", "web1", "success", now.Add(-time.Minute), 0)
+
+	lost, err := detector.Check(context.Background(), now)
+	require.NoError(t, err)
+	require.Len(t, lost, 1)
+	assert.Equal(t, "backup", lost[0].JobName)
+
+	latest := results("backup", "db1")
+	require.Len(t, latest, 3)
+	assert.Equal(t, "lost", latest[0].Status)
+	assert.Equal(t, 300, latest[0].Duration)
+	assert.Contains(t, latest[0].Output, "no result arrived within 2m0s")
+	assert.Equal(t, "success", results("log-rotation", "web1")[0].Status)
+
+	// Lost runs are reported distinctly from missed deadlines
+	metrics := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+	assert.Contains(t, metrics, `cronjob_status{job_name="backup",host="db1",env="prod",type="backup"} -4`)
+	assert.Contains(t, metrics, "-3=snoozed, -4=lost")
+
+	t.Run("RecordedOnce", func(t *testing.T) {
+		lost, err := detector.Check(context.Background(), now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, lost)
+	})
+
+	t.Run("DefaultDuration", func(t *testing.T) {
+		// Without timed results, runs are expected to take default_duration
+		submit("cm_test_logrotation_key", "log-rotation", "web1", "started", now, 0)
+
+		lost, err := detector.Check(context.Background(), now.Add(90*time.Minute))
+		require.NoError(t, err)
+		assert.Empty(t, lost)
+
+		lost, err = detector.Check(context.Background(), now.Add(2*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, lost, 1)
+		assert.Equal(t, "log-rotation", lost[0].JobName)
+	})
+
+	t.Run("ClientsCannotReportLost", func(t *testing.T) {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "lost"}).
+			ExpectStatus(400)
+	})
+}
@@ -0,0 +1,109 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkMaintenance(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	status := func(name, host string) string {
+		job, err := server.Database.GetJobStore().GetJob(name, host)
+		require.NoError(t, err)
+		return job.Status
+	}
+
+	// A second backup job, paused, is left alone
+	admin.POST("/api/job", map[string]interface{}{
+		"job_name": "reports", "host": "db2", "status": "paused", "labels": map[string]string{"type": "backup"},
+	}).ExpectStatus(201)
+
+	until := time.Now().UTC().Add(2 * time.Hour).Truncate(time.Second)
+	var window model.MaintenanceWindow
+	admin.POST("/api/maintenance", map[string]interface{}{
+		"selector": map[string]string{"type": "backup"},
+		"until":    until.Format(time.RFC3339),
+	}).ExpectStatus(201).ExpectJSON(&window)
+
+	require.Len(t, window.Jobs, 1)
+	assert.Equal(t, "backup", window.Jobs[0].Name)
+	assert.Equal(t, map[string]string{"type": "backup"}, window.Selector)
+	require.NotNil(t, window.Until)
+	assert.True(t, window.Until.Equal(until))
+	assert.Equal(t, "maintenance", status("backup", "db1"))
+	assert.Equal(t, "paused", status("reports", "db2"))
+	assert.Equal(t, "active", status("log-rotation", "web1"))
+
+	var windows []model.MaintenanceWindow
+	admin.GET("/api/maintenance").ExpectStatus(200).ExpectJSON(&windows)
+	require.Len(t, windows, 1)
+	assert.Equal(t, window.ID, windows[0].ID)
+
+	t.Run("End", func(t *testing.T) {
+		var ended model.MaintenanceWindow
+		admin.DELETE(fmt.Sprintf("/api/maintenance/%d", window.ID)).ExpectStatus(200).ExpectJSON(&ended)
+		require.NotNil(t, ended.EndedAt)
+		require.Len(t, ended.Jobs, 1)
+		assert.Equal(t, "active", status("backup", "db1"))
+		assert.Equal(t, "paused", status("reports", "db2"))
+
+		// Ending it again changes nothing
+		admin.DELETE(fmt.Sprintf("/api/maintenance/%d", window.ID)).ExpectStatus(200).ExpectJSON(&ended)
+		assert.Empty(t, ended.Jobs)
+
+		admin.GET("/api/maintenance").ExpectStatus(200).ExpectJSON(&windows)
+		assert.Empty(t, windows)
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		var window model.MaintenanceWindow
+		admin.POST("/api/maintenance", map[string]interface{}{
+			"selector": map[string]string{"env": "prod"},
+			"until":    time.Now().UTC().Add(time.Hour).Format(time.RFC3339),
+		}).ExpectStatus(201).ExpectJSON(&window)
+
+		require.Len(t, window.Jobs, 2)
+
+		// Jobs changed during the window keep their status
+		server.Database.Exec(`UPDATE jobs SET status = 'paused' WHERE name = 'log-rotation'`)
+
+		store := server.Database.GetJobStore()
+		ended, err := store.EndExpiredMaintenance(time.Now().UTC())
+		require.NoError(t, err)
+		assert.Empty(t, ended)
+		assert.Equal(t, "maintenance", status("backup", "db1"))
+
+		ended, err = store.EndExpiredMaintenance(time.Now().UTC().Add(2 * time.Hour))
+		require.NoError(t, err)
+		require.Len(t, ended, 1)
+		assert.Equal(t, window.ID, ended[0].ID)
+		assert.Equal(t, "active", status("backup", "db1"))
+		assert.Equal(t, "paused", status("log-rotation", "web1"))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		admin.POST("/api/maintenance", map[string]interface{}{}).
+			ExpectStatus(400).
+			ExpectContains("selector must name at least one label")
+		admin.POST("/api/maintenance", map[string]interface{}{
+			"selector": map[string]string{"env": "prod"},
+			"until":    time.Now().UTC().Add(-time.Hour).Format(time.RFC3339),
+		}).ExpectStatus(400).ExpectContains("until must be in the future")
+		admin.POST("/api/maintenance", map[string]interface{}{
+			"selector": map[string]string{"env": "qa"},
+		}).ExpectStatus(404).ExpectContains("no job matches the selector")
+		admin.DELETE("/api/maintenance/999").ExpectStatus(404)
+		admin.GET("/api/maintenance/abc").ExpectStatus(400)
+		testutil.NewHTTPClient(t, server.URL()).GET("/api/maintenance").ExpectStatus(401)
+	})
+}
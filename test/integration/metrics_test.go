@@ -1,13 +1,23 @@
 package integration
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/metrics"
+	"github.com/jaepetto/cron-exporter/pkg/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -63,6 +73,44 @@ func TestPrometheusMetricsFormat(t *testing.T) {
 	})
 }
 
+func TestMetricsLabelFilter(t *testing.T) {
+	t.Run("Allowlist", func(t *testing.T) {
+		server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+			cfg.Metrics.LabelAllowlist = []string{"env"}
+		})
+		defer server.Close()
+		server.SeedTestData()
+
+		body := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+
+		assert.Contains(t, body, `env="prod"`)
+		assert.NotContains(t, body, `type="backup"`)
+	})
+
+	t.Run("Denylist", func(t *testing.T) {
+		server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+			cfg.Metrics.LabelDenylist = []string{"type"}
+		})
+		defer server.Close()
+		server.SeedTestData()
+
+		body := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+
+		assert.Contains(t, body, `env="prod"`)
+		assert.NotContains(t, body, `type="backup"`)
+		assert.NotContains(t, body, `type="maintenance"`)
+
+		// Filtered labels remain available through the API
+		var jobs []model.Job
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(server.AdminHeaders()).
+			GET("/api/job?label.type=backup").
+			ExpectStatus(200).
+			ExpectJSON(&jobs)
+		assert.NotEmpty(t, jobs)
+	})
+}
+
 func TestMetricsWithJobResults(t *testing.T) {
 	server := testutil.NewTestServer(t)
 	defer server.Close()
@@ -82,7 +130,7 @@ func TestMetricsWithJobResults(t *testing.T) {
 			"host":     "db1",
 			"status":   "success",
 			"duration": 120,
-			"message":  "Backup completed successfully",
+			"output":   "Backup completed successfully",
 		}
 
 		resultClient.POST("/api/job-result", resultRequest).ExpectStatus(201)
@@ -110,7 +158,7 @@ func TestMetricsWithJobResults(t *testing.T) {
 			"host":     "db1",
 			"status":   "failure",
 			"duration": 45,
-			"message":  "Database connection failed",
+			"output":   "Database connection failed",
 		}
 
 		resultClient.POST("/api/job-result", resultRequest).ExpectStatus(201)
@@ -207,6 +255,307 @@ func TestMetricsMaintenanceMode(t *testing.T) {
 	assert.True(t, statusFound, "Could not find cronjob_status line for maintenance-job")
 }
 
+func TestMetricsStatusMapping(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Metrics.MaintenanceStatus = "2"
+		cfg.Metrics.PausedStatus = "exclude"
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	server.Database.Exec(`UPDATE jobs SET status = 'paused' WHERE name = 'log-rotation'`)
+	paused, err := server.Database.GetJobStore().GetJob("log-rotation", "web1")
+	require.NoError(t, err)
+
+	client := testutil.NewHTTPClient(t, server.URL())
+	body := client.GET("/metrics").ExpectStatus(200).BodyString()
+
+	assert.Contains(t, body, "# HELP cronjob_status Status of cron job: 1=success, 0=failure, 2=maintenance, -2=missed_deadline, -3=snoozed, -4=lost, -5=overlapping\n")
+	assert.Regexp(t, `cronjob_status\{job_name="maintenance-job",host="app1"[^}]*\} 2\n`, body)
+
+	// Paused jobs have no series and are not counted
+	assert.NotContains(t, body, `job_name="log-rotation"`)
+	assert.Contains(t, body, "cronjob_total 2\n")
+	assert.NotContains(t, client.GET(fmt.Sprintf("/metrics/job/%d", paused.ID)).ExpectStatus(200).BodyString(), "log-rotation")
+
+	// Checks say the job is left out
+	var check map[string]interface{}
+	testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders()).
+		POST(fmt.Sprintf("/api/job/%d/check", paused.ID), nil).ExpectStatus(200).ExpectJSON(&check)
+	assert.Equal(t, "paused", check["reason"])
+	assert.Equal(t, true, check["excluded"])
+}
+
+func TestMetricsAuth(t *testing.T) {
+	basic := func(username, password string) map[string]string {
+		return map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))}
+	}
+
+	t.Run("Basic", func(t *testing.T) {
+		server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+			cfg.Metrics.Auth = "basic"
+			cfg.Metrics.Username = "prometheus"
+			cfg.Metrics.Password = "scrape-secret"
+		})
+		defer server.Close()
+		server.SeedTestData()
+
+		testutil.NewHTTPClient(t, server.URL()).GET("/metrics").
+			ExpectStatus(401).
+			ExpectHeader("WWW-Authenticate", `Basic realm="cronmetrics metrics"`)
+		testutil.NewHTTPClient(t, server.URL()).WithHeaders(basic("prometheus", "wrong")).GET("/metrics").ExpectStatus(401)
+		// Admin keys are no scrape credentials
+		testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders()).GET("/metrics").ExpectStatus(401)
+
+		scraper := testutil.NewHTTPClient(t, server.URL()).WithHeaders(basic("prometheus", "scrape-secret"))
+		scraper.GET("/metrics").ExpectStatus(200).ExpectContains("cronjob_total")
+		scraper.GET("/metrics/job/1").ExpectStatus(200).ExpectContains(`job_name="backup"`)
+		testutil.NewHTTPClient(t, server.URL()).GET("/metrics/job/1").ExpectStatus(401)
+
+		// Health checks stay open
+		testutil.NewHTTPClient(t, server.URL()).GET("/health").ExpectStatus(200)
+	})
+
+	t.Run("Bearer", func(t *testing.T) {
+		server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+			cfg.Metrics.Auth = "bearer"
+			cfg.Metrics.BearerToken = "scrape-token"
+		})
+		defer server.Close()
+
+		testutil.NewHTTPClient(t, server.URL()).GET("/metrics").
+			ExpectStatus(401).
+			ExpectHeader("WWW-Authenticate", `Bearer realm="cronmetrics metrics"`)
+		testutil.NewHTTPClient(t, server.URL()).WithHeaders(map[string]string{"Authorization": "Bearer other"}).GET("/metrics").ExpectStatus(401)
+		testutil.NewHTTPClient(t, server.URL()).WithHeaders(map[string]string{"Authorization": "Bearer scrape-token"}).
+			GET("/metrics").
+			ExpectStatus(200)
+	})
+}
+
+func TestMetricsAllowedSources(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Metrics.AllowedCIDRs = []string{"10.0.0.0/8"}
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	// Test clients connect from the loopback address
+	client := testutil.NewHTTPClient(t, server.URL())
+	client.GET("/metrics").ExpectStatus(403).ExpectContains("source address not allowed")
+	client.GET("/metrics/job/1").ExpectStatus(403)
+	client.GET("/health").ExpectStatus(403)
+	// Other endpoints are not restricted
+	testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders()).GET("/api/job/1").ExpectStatus(200)
+
+	allowed := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Metrics.AllowedCIDRs = []string{"10.0.0.0/8", "127.0.0.1"}
+	})
+	defer allowed.Close()
+	testutil.NewHTTPClient(t, allowed.URL()).GET("/metrics").ExpectStatus(200).ExpectContains("cronjob_total")
+	testutil.NewHTTPClient(t, allowed.URL()).GET("/health").ExpectStatus(200)
+}
+
+func TestJobMetricsEndpoint(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	job, err := server.Database.GetJobStore().GetJob("backup", "db1")
+	require.NoError(t, err)
+
+	client := testutil.NewHTTPClient(t, server.URL())
+
+	t.Run("OnlyThatJob", func(t *testing.T) {
+		body := client.GET(fmt.Sprintf("/metrics/job/%d", job.ID)).
+			ExpectStatus(200).
+			ExpectHeader("Content-Type", "text/plain; version=0.0.4; charset=utf-8").
+			BodyString()
+
+		assert.Contains(t, body, "# TYPE cronjob_status gauge")
+		assert.Regexp(t, `cronjob_status\{job_name="backup",host="db1",env="prod",type="backup"\} -?\d+\n`, body)
+		assert.Contains(t, body, `cronjob_last_run_timestamp{job_name="backup",host="db1"}`)
+		assert.NotContains(t, body, "log-rotation")
+		assert.NotContains(t, body, "maintenance-job")
+		assert.NotContains(t, body, "cronjob_total")
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		client.GET(fmt.Sprintf("/metrics/job/%d", job.ID+1000)).ExpectStatus(404)
+		client.GET("/metrics/job/backup").ExpectStatus(400)
+		client.POST(fmt.Sprintf("/metrics/job/%d", job.ID), nil).ExpectStatus(405)
+	})
+
+	t.Run("Escaping", func(t *testing.T) {
+		var quoted model.Job
+		testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders()).
+			POST("/api/job", map[string]interface{}{"job_name": `nightly "full"`, "host": `db\2`}).
+			ExpectStatus(201).
+			ExpectJSON(&quoted)
+
+		body := client.GET(fmt.Sprintf("/metrics/job/%d", quoted.ID)).ExpectStatus(200).BodyString()
+		assert.Contains(t, body, `cronjob_last_run_timestamp{job_name="nightly \"full\"",host="db\\2"}`)
+	})
+}
+
+func TestMetricsSharding(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+
+	jobStore := server.Database.GetJobStore()
+	for i := 0; i < 20; i++ {
+		team := "infra"
+		if i%2 == 1 {
+			team = "data"
+		}
+		require.NoError(t, jobStore.CreateJob(&model.Job{
+			Name:                      fmt.Sprintf("job-%02d", i),
+			Host:                      "host1",
+			AutomaticFailureThreshold: 3600,
+			Labels:                    map[string]string{"team": team},
+			Status:                    "active",
+		}))
+	}
+
+	client := testutil.NewHTTPClient(t, server.URL())
+	statusLine := regexp.MustCompile(`(?m)^cronjob_status\{job_name="([^"]+)"`)
+
+	// scrapedJobs returns the jobs exported by a scrape of path
+	scrapedJobs := func(t *testing.T, path string) []string {
+		body := client.GET(path).ExpectStatus(200).BodyString()
+		var names []string
+		for _, match := range statusLine.FindAllStringSubmatch(body, -1) {
+			names = append(names, match[1])
+		}
+		assert.Contains(t, body, fmt.Sprintf("cronjob_total %d\n", len(names)))
+		return names
+	}
+
+	t.Run("ShardsPartitionJobs", func(t *testing.T) {
+		seen := make(map[string]int)
+		for shard := 1; shard <= 3; shard++ {
+			names := scrapedJobs(t, fmt.Sprintf("/metrics?shard=%d/3", shard))
+			assert.NotEmpty(t, names)
+			for _, name := range names {
+				seen[name]++
+			}
+
+			// The same shard always exports the same jobs
+			assert.Equal(t, names, scrapedJobs(t, fmt.Sprintf("/metrics?shard=%d/3", shard)))
+		}
+
+		assert.Len(t, seen, 20)
+		for name, count := range seen {
+			assert.Equal(t, 1, count, "job %s exported by %d shards", name, count)
+		}
+	})
+
+	t.Run("LabelSelector", func(t *testing.T) {
+		names := scrapedJobs(t, "/metrics?label.team=data")
+		assert.Len(t, names, 10)
+		for _, name := range names {
+			assert.Contains(t, []string{"1", "3", "5", "7", "9"}, name[len(name)-1:])
+		}
+
+		// Selectors combine with sharding
+		combined := 0
+		for shard := 1; shard <= 2; shard++ {
+			combined += len(scrapedJobs(t, fmt.Sprintf("/metrics?label.team=data&shard=%d/2", shard)))
+		}
+		assert.Equal(t, 10, combined)
+	})
+
+	t.Run("HostAndNameSelectors", func(t *testing.T) {
+		require.NoError(t, jobStore.CreateJob(&model.Job{
+			Name:                      "job-00",
+			Host:                      "host2",
+			AutomaticFailureThreshold: 3600,
+			Labels:                    map[string]string{"team": "data"},
+			Status:                    "active",
+		}))
+
+		assert.Equal(t, []string{"job-00"}, scrapedJobs(t, "/metrics?host=host2"))
+		assert.Len(t, scrapedJobs(t, "/metrics?job_name=job-00"), 2)
+		assert.Len(t, scrapedJobs(t, "/metrics?label.team=data&host=host1"), 10)
+		assert.Len(t, scrapedJobs(t, "/metrics?label.team=infra&host=host2"), 0)
+
+		body := client.GET("/metrics?job_name=job-00&host=host1").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, `cronjob_status{job_name="job-00",host="host1",team="infra"}`)
+		assert.NotContains(t, body, `host="host2"`)
+	})
+
+	t.Run("InvalidShard", func(t *testing.T) {
+		for _, shard := range []string{"0/3", "4/3", "1/0", "1", "a/b"} {
+			client.GET("/metrics?shard=" + shard).ExpectStatus(400)
+		}
+	})
+}
+
+// testSource is a MetricSource exporting each job's status as seen by the
+// scrape, and a fleet-wide count of the listed jobs
+type testSource struct {
+	err error
+}
+
+func (s *testSource) Name() string { return "test" }
+
+func (s *testSource) WriteMetrics(ctx context.Context, builder *strings.Builder, scrape *metrics.Scrape) error {
+	if s.err != nil {
+		return s.err
+	}
+	for _, job := range scrape.Jobs {
+		_, reason := scrape.Status(ctx, job)
+		fmt.Fprintf(builder, "test_job_reason{%s,reason=\"%s\"} 1\n", scrape.JobLabels(job), reason)
+	}
+	if scrape.FleetWide {
+		fmt.Fprintf(builder, "test_listed_jobs %d\n", len(scrape.Listed))
+	}
+	return nil
+}
+
+func TestMetricSources(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	source := &testSource{}
+	server.Metrics.AddSource(source)
+	client := testutil.NewHTTPClient(t, server.URL())
+
+	t.Run("Scrape", func(t *testing.T) {
+		body := client.GET("/metrics").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, `test_job_reason{job_name="maintenance-job",host="app1",env="staging",reason="maintenance"} 1`)
+		assert.Contains(t, body, "test_listed_jobs 3\n")
+
+		// Added sources come after the built-in ones
+		assert.Less(t, strings.Index(body, "cronmetrics_rejected_results_total"), strings.Index(body, "test_job_reason"))
+	})
+
+	t.Run("Shards", func(t *testing.T) {
+		reasons := 0
+		for shard := 1; shard <= 2; shard++ {
+			body := client.GET(fmt.Sprintf("/metrics?shard=%d/2", shard)).ExpectStatus(200).BodyString()
+			reasons += strings.Count(body, "test_job_reason{")
+			assert.Equal(t, shard == 1, strings.Contains(body, "test_listed_jobs 3\n"), "shard %d", shard)
+		}
+		assert.Equal(t, 3, reasons)
+	})
+
+	t.Run("SingleJob", func(t *testing.T) {
+		body := client.GET("/metrics/job/1").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, `test_job_reason{job_name="backup",host="db1"`)
+		assert.NotContains(t, body, "test_listed_jobs")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		source.err = errors.New("boom")
+		defer func() { source.err = nil }()
+
+		body := client.GET("/metrics").ExpectStatus(500).BodyString()
+		assert.Contains(t, body, "failed to write test metrics: boom")
+	})
+}
+
 func TestMetricsValidation(t *testing.T) {
 	server := testutil.NewTestServer(t)
 	defer server.Close()
@@ -346,6 +695,134 @@ func TestMetricsPerformance(t *testing.T) {
 	})
 
 	t.Run("ConcurrentMetricsRequests", func(t *testing.T) {
-		t.Skip("Skipping concurrent metrics test - database connection issues under load")
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 5; j++ {
+					resp, err := http.Get(server.URL() + "/metrics")
+					if !assert.NoError(t, err) {
+						return
+					}
+					body, err := io.ReadAll(resp.Body)
+					resp.Body.Close()
+					assert.NoError(t, err)
+					assert.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+					assert.Contains(t, string(body), `job_name="perf-job-50"`)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestGroupHealthScore(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Metrics.HealthGroupLabel = "env"
+		cfg.Metrics.CriticalityLabel = "criticality"
+		cfg.Metrics.CriticalityWeights = map[string]float64{"critical": 3}
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	metrics := func(path string) string {
+		return testutil.NewHTTPClient(t, server.URL()).GET(path).ExpectStatus(200).BodyString()
+	}
+	submit := func(name, host, key, status string) {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": key, "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{"job_name": name, "host": host, "status": status}).
+			ExpectStatus(201)
+	}
+
+	submit("backup", "db1", "cm_test_backup_key", "success")
+	submit("log-rotation", "web1", "cm_test_logrotation_key", "success")
+	body := metrics("/metrics")
+	assert.Contains(t, body, "# TYPE cronjob_group_health_score gauge")
+	assert.Contains(t, body, `cronjob_group_health_score{group="prod"} 100`)
+	assert.NotContains(t, body, `cronjob_group_health_score{group="staging"}`, "jobs in maintenance are not scored")
+
+	// A failing critical job weighs three times as much as a normal one
+	testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders()).
+		PUT("/api/job/1", map[string]interface{}{"labels": map[string]string{"env": "prod", "criticality": "critical"}}).
+		ExpectStatus(200)
+	submit("backup", "db1", "cm_test_backup_key", "failure")
+	assert.Contains(t, metrics("/metrics"), `cronjob_group_health_score{group="prod"} 25`)
+
+	// Scores cover all jobs and only the first shard exports them
+	assert.Contains(t, metrics("/metrics?shard=1/2"), `cronjob_group_health_score{group="prod"} 25`)
+	assert.NotContains(t, metrics("/metrics?shard=2/2"), "cronjob_group_health_score")
+
+	t.Run("Disabled", func(t *testing.T) {
+		server := testutil.NewTestServer(t)
+		defer server.Close()
+		server.SeedTestData()
+
+		body := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+		assert.NotContains(t, body, "cronjob_group_health_score")
 	})
 }
+
+func TestMetricsSecondsSinceLastReport(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	server.Database.Exec(`UPDATE jobs SET last_reported_at = ? WHERE name = 'backup'`, time.Now().UTC().Add(-time.Hour))
+
+	body := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+	assert.Contains(t, body, "# TYPE cronjob_seconds_since_last_report gauge")
+
+	// Series carry the job's labels, so alerts can pick thresholds per label
+	match := regexp.MustCompile(`cronjob_seconds_since_last_report\{job_name="backup",host="db1",env="prod",type="backup"\} (\d+)\n`).
+		FindStringSubmatch(body)
+	require.NotNil(t, match, body)
+	age, err := strconv.Atoi(match[1])
+	require.NoError(t, err)
+	assert.InDelta(t, 3600, age, 60)
+}
+
+func TestMetricsReportThreshold(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	body := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+	assert.Contains(t, body, "# TYPE cronjob_failure_threshold_seconds gauge")
+
+	// The threshold shares the label set of the report age, so the two
+	// compare directly
+	assert.Contains(t, body, `cronjob_failure_threshold_seconds{job_name="backup",host="db1",env="prod",type="backup"} 3600`+"\n")
+	assert.Contains(t, body, `cronjob_seconds_since_last_report{job_name="backup",host="db1",env="prod",type="backup"} `)
+}
+
+func TestMetricsDurationHistogram(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Metrics.DurationBuckets = []float64{30, 300}
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	store := server.Database.GetJobResultStore()
+	for _, duration := range []int{10, 30, 120, 900} {
+		require.NoError(t, store.CreateJobResult(&model.JobResult{
+			JobName: "backup", Host: "db1", Status: "success", Duration: duration, Timestamp: time.Now().UTC(),
+		}))
+	}
+	// Untimed and test results are left out
+	require.NoError(t, store.CreateJobResult(&model.JobResult{JobName: "backup", Host: "db1", Status: "success", Timestamp: time.Now().UTC()}))
+	require.NoError(t, store.CreateJobResult(&model.JobResult{JobName: "backup", Host: "db1", Status: "success", Duration: 5, Test: true, Timestamp: time.Now().UTC()}))
+
+	body := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+	labels := `job_name="backup",host="db1",env="prod",type="backup"`
+	assert.Contains(t, body, "# TYPE cronjob_duration_seconds histogram\n")
+	assert.Contains(t, body, `cronjob_duration_seconds_bucket{`+labels+`,le="30"} 2`+"\n")
+	assert.Contains(t, body, `cronjob_duration_seconds_bucket{`+labels+`,le="300"} 3`+"\n")
+	assert.Contains(t, body, `cronjob_duration_seconds_bucket{`+labels+`,le="+Inf"} 4`+"\n")
+	assert.Contains(t, body, `cronjob_duration_seconds_sum{`+labels+`} 1060`+"\n")
+	assert.Contains(t, body, `cronjob_duration_seconds_count{`+labels+`} 4`+"\n")
+	assert.Contains(t, body, `cronjob_last_duration_seconds{`+labels+`} 900`+"\n")
+	assert.NotContains(t, body, `cronjob_duration_seconds_count{job_name="log-rotation"`, "jobs without timed runs have no histogram")
+}
@@ -0,0 +1,203 @@
+package integration
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/ingest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMQTT speaks just enough MQTT 3.1.1 to accept a client, acknowledge
+// its subscriptions and publish QoS 0 messages to it
+type fakeMQTT struct {
+	listener net.Listener
+	mu       sync.Mutex
+	conns    []net.Conn
+	filters  []string
+	username string
+}
+
+func newFakeMQTT(t *testing.T) *fakeMQTT {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	f := &fakeMQTT{listener: listener}
+	go f.serve()
+	t.Cleanup(func() { listener.Close() })
+	return f
+}
+
+func (f *fakeMQTT) URL() string {
+	return "tcp://" + f.listener.Addr().String()
+}
+
+func (f *fakeMQTT) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeMQTT) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		header, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+		body, err := readMQTTPacket(reader)
+		if err != nil {
+			return
+		}
+
+		switch header >> 4 {
+		case 1: // CONNECT
+			f.mu.Lock()
+			f.conns = append(f.conns, conn)
+			if body[7]&0x80 != 0 {
+				// Skip protocol name, level, flags, keep alive and client ID
+				offset := 10
+				offset += 2 + int(binary.BigEndian.Uint16(body[offset:]))
+				length := int(binary.BigEndian.Uint16(body[offset:]))
+				f.username = string(body[offset+2 : offset+2+length])
+			}
+			f.mu.Unlock()
+			_, _ = conn.Write([]byte{0x20, 0x02, 0x00, 0x00})
+		case 8: // SUBSCRIBE
+			length := int(binary.BigEndian.Uint16(body[2:]))
+			f.mu.Lock()
+			f.filters = append(f.filters, string(body[4:4+length]))
+			f.mu.Unlock()
+			_, _ = conn.Write([]byte{0x90, 0x03, body[0], body[1], body[4+length]})
+		case 12: // PINGREQ
+			_, _ = conn.Write([]byte{0xD0, 0x00})
+		case 14: // DISCONNECT
+			return
+		}
+	}
+}
+
+// readMQTTPacket reads the variable-length remaining length and the rest
+// of a packet
+func readMQTTPacket(reader *bufio.Reader) ([]byte, error) {
+	length, multiplier := 0, 1
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		length += int(b&0x7F) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+
+	body := make([]byte, length)
+	_, err := io.ReadFull(reader, body)
+	return body, err
+}
+
+// Filters returns the topic filters subscribed to so far
+func (f *fakeMQTT) Filters() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.filters...)
+}
+
+// Username returns the user name the client connected with
+func (f *fakeMQTT) Username() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.username
+}
+
+// Publish sends a QoS 0 message to every connected client
+func (f *fakeMQTT) Publish(topic, payload string) {
+	body := binary.BigEndian.AppendUint16(nil, uint16(len(topic)))
+	body = append(body, topic...)
+	body = append(body, payload...)
+
+	packet := []byte{0x30}
+	for length := len(body); ; {
+		b := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if length == 0 {
+			break
+		}
+	}
+	packet = append(packet, body...)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, conn := range f.conns {
+		_, _ = conn.Write(packet)
+	}
+}
+
+func TestIngestFromMQTT(t *testing.T) {
+	broker := newFakeMQTT(t)
+	recorder, db := newIngestRecorder(t)
+
+	consumer, err := ingest.NewConsumer(&config.IngestConfig{
+		Driver:      "mqtt",
+		URL:         broker.URL(),
+		TopicPrefix: "cronmetrics",
+		ClientID:    "cronmetrics-test",
+		Username:    "cronmetrics-server",
+		Password:    "secret",
+		QoS:         1,
+	}, recorder)
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	require.Eventually(t, func() bool { return len(broker.Filters()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "cronmetrics/+/+/result", broker.Filters()[0])
+	assert.Equal(t, "cronmetrics-server", broker.Username())
+
+	// Rejected: wrong key, key of another job, payload contradicting the
+	// topic and malformed payloads
+	broker.Publish("cronmetrics/db1/backup/result", "cm_wrong success")
+	broker.Publish("cronmetrics/db1/backup/result", "cm_other_key success")
+	broker.Publish("cronmetrics/db1/backup/result", `{"api_key":"`+ingestAPIKey+`","job_name":"restore","status":"success"}`)
+	broker.Publish("cronmetrics/db1/backup/result", ingestAPIKey+" success soon")
+	broker.Publish("cronmetrics/db1/backup/result", ingestAPIKey)
+	broker.Publish("cronmetrics/db1/backup/result", ingestAPIKey+" done")
+
+	// Recorded, in order
+	broker.Publish("cronmetrics/db1/backup/result", ingestAPIKey+" failure 42")
+	broker.Publish("cronmetrics/db1/backup/result", `{"api_key":"`+ingestAPIKey+`","status":"success","output":"ok"}`)
+
+	require.Eventually(t, func() bool { return db.CountJobResults() >= 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, db.CountJobResults())
+
+	results, err := db.GetJobResultStore().GetJobResults("backup", "db1", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	statuses := map[string]int{}
+	for _, result := range results {
+		statuses[result.Status] = result.Duration
+		if result.Status == "success" {
+			assert.Equal(t, "ok", result.Output)
+		}
+	}
+	assert.Equal(t, map[string]int{"failure": 42, "success": 0}, statuses)
+}
@@ -0,0 +1,133 @@
+package integration
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// natsMessage is a message published to fakeNATS
+type natsMessage struct {
+	Subject string
+	Data    []byte
+}
+
+// natsSubscription is a subscription made on fakeNATS
+type natsSubscription struct {
+	conn    net.Conn
+	subject string
+	sid     string
+}
+
+// fakeNATS speaks just enough of the NATS client protocol to accept
+// connections, record published messages and deliver messages to
+// subscribers
+type fakeNATS struct {
+	listener      net.Listener
+	mu            sync.Mutex
+	messages      []natsMessage
+	subscriptions []natsSubscription
+}
+
+func newFakeNATS(t *testing.T) *fakeNATS {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	f := &fakeNATS{listener: listener}
+	go f.serve()
+	t.Cleanup(func() { listener.Close() })
+	return f
+}
+
+func (f *fakeNATS) URL() string {
+	return "nats://" + f.listener.Addr().String()
+}
+
+func (f *fakeNATS) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeNATS) handle(conn net.Conn) {
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"fake\",\"version\":\"2.10.0\",\"proto\":1,\"max_payload\":1048576}\r\n")
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+		case "SUB":
+			f.mu.Lock()
+			f.subscriptions = append(f.subscriptions, natsSubscription{conn: conn, subject: fields[1], sid: fields[len(fields)-1]})
+			f.mu.Unlock()
+		case "PUB":
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+
+			f.mu.Lock()
+			f.messages = append(f.messages, natsMessage{Subject: fields[1], Data: payload[:size]})
+			f.mu.Unlock()
+		}
+	}
+}
+
+// Messages returns the messages published so far
+func (f *fakeNATS) Messages() []natsMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]natsMessage(nil), f.messages...)
+}
+
+// Subscribed reports whether a client subscribed to subject
+func (f *fakeNATS) Subscribed(subject string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, sub := range f.subscriptions {
+		if sub.subject == subject {
+			return true
+		}
+	}
+	return false
+}
+
+// Deliver sends a message to the subscribers of subject, asking for a
+// reply on reply when set
+func (f *fakeNATS) Deliver(subject, reply string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, sub := range f.subscriptions {
+		if sub.subject != subject {
+			continue
+		}
+		if reply != "" {
+			fmt.Fprintf(sub.conn, "MSG %s %s %s %d\r\n%s\r\n", subject, sub.sid, reply, len(data), data)
+		} else {
+			fmt.Fprintf(sub.conn, "MSG %s %s %d\r\n%s\r\n", subject, sub.sid, len(data), data)
+		}
+	}
+}
@@ -0,0 +1,419 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/notifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTP speaks just enough SMTP to accept mails and record their data
+type fakeSMTP struct {
+	listener net.Listener
+	mu       sync.Mutex
+	mails    []string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	f := &fakeSMTP{listener: listener}
+	go f.serve()
+	t.Cleanup(func() { listener.Close() })
+	return f
+}
+
+func (f *fakeSMTP) Port() int {
+	return f.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeSMTP) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeSMTP) handle(conn net.Conn) {
+	defer conn.Close()
+
+	fmt.Fprint(conn, "220 fake ESMTP\r\n")
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		switch command := strings.ToUpper(strings.Fields(line + " ")[0]); command {
+		case "EHLO", "HELO":
+			fmt.Fprint(conn, "250 fake\r\n")
+		case "DATA":
+			fmt.Fprint(conn, "354 go ahead\r\n")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			f.mu.Lock()
+			f.mails = append(f.mails, data.String())
+			f.mu.Unlock()
+			fmt.Fprint(conn, "250 queued\r\n")
+		case "QUIT":
+			fmt.Fprint(conn, "221 bye\r\n")
+			return
+		default:
+			fmt.Fprint(conn, "250 ok\r\n")
+		}
+	}
+}
+
+// Mails returns the mails received so far
+func (f *fakeSMTP) Mails() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.mails...)
+}
+
+func TestNotifier(t *testing.T) {
+	var mu sync.Mutex
+	var hooks []notifier.Notification
+	var messages []string
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer hook-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var notification notifier.Notification
+		_ = json.NewDecoder(r.Body).Decode(&notification)
+		mu.Lock()
+		hooks = append(hooks, notification)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message struct {
+			Text string `json:"text"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&message)
+		mu.Lock()
+		messages = append(messages, message.Text)
+		mu.Unlock()
+	}))
+	defer slack.Close()
+	mail := newFakeSMTP(t)
+
+	received := func() ([]notifier.Notification, []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]notifier.Notification(nil), hooks...), append([]string(nil), messages...)
+	}
+
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Notifier = config.NotifierConfig{
+			Enabled:  true,
+			Interval: 3600,
+			Channels: map[string]config.NotifierChannel{
+				"hook": {Type: "webhook", URL: webhook.URL, Headers: map[string]string{"Authorization": "Bearer hook-token"}},
+				"chat": {Type: "slack", URL: slack.URL},
+				"mail": {Type: "email", SMTPHost: "127.0.0.1", SMTPPort: mail.Port(), From: "cron@example.com", To: []string{"dba@example.com"}},
+				// Refused by the webhook, without its token
+				"broken": {Type: "webhook", URL: webhook.URL},
+			},
+			Routes: []config.NotifierRoute{
+				{Channels: []string{"hook"}},
+				{Labels: map[string]string{"team": "dba"}, Events: []string{"failure"}, Channels: []string{"chat", "mail", "hook", "broken"}},
+			},
+		}
+		cfg.Dashboard = config.DashboardConfig{
+			Enabled: true, Path: "/dashboard", Title: "Test Dashboard", RefreshInterval: 5, AuthRequired: true,
+			PageSize: 25, SSEHeartbeat: 30, KioskRotation: 15, KioskPageSize: 24,
+		}
+	})
+	defer server.Close()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	jobStore := server.Database.GetJobStore()
+	var backup, report model.Job
+	admin.POST("/api/job", map[string]interface{}{
+		"job_name": "backup", "host": "db1", "automatic_failure_threshold": 3600, "labels": map[string]string{"team": "dba"},
+	}).ExpectStatus(201).ExpectJSON(&backup)
+	admin.POST("/api/job", map[string]interface{}{"job_name": "report", "host": "app1", "automatic_failure_threshold": 3600}).
+		ExpectStatus(201).ExpectJSON(&report)
+	require.NoError(t, server.Notifier.Check(context.Background()))
+
+	submit := func(status string) {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": backup.ApiKey, "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": status}).
+			ExpectStatus(201)
+	}
+
+	t.Run("Failure", func(t *testing.T) {
+		// A result is checked right away; each channel is notified once
+		submit("failure")
+		require.Eventually(t, func() bool {
+			hooks, messages := received()
+			return len(hooks) == 1 && len(messages) == 1 && len(mail.Mails()) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		hooks, messages := received()
+		assert.Equal(t, notifier.EventFailure, hooks[0].Event)
+		assert.Equal(t, "backup", hooks[0].JobName)
+		assert.Equal(t, "failure", hooks[0].Reason)
+		assert.Equal(t, "success", hooks[0].Previous)
+		assert.Equal(t, "dba", hooks[0].Labels["team"])
+		assert.Contains(t, messages[0], "*Cron job backup on db1 is failing*")
+		assert.Contains(t, mail.Mails()[0], "Subject: Cron job backup on db1 is failing")
+		assert.Contains(t, mail.Mails()[0], "To: dba@example.com")
+
+		// A further failure changes nothing
+		submit("failure")
+		require.NoError(t, server.Notifier.Check(context.Background()))
+		hooks, _ = received()
+		assert.Len(t, hooks, 1)
+	})
+
+	t.Run("Recovery", func(t *testing.T) {
+		// The dba route only notifies failures
+		submit("success")
+		require.Eventually(t, func() bool {
+			hooks, _ := received()
+			return len(hooks) == 2
+		}, 5*time.Second, 10*time.Millisecond)
+
+		hooks, messages := received()
+		assert.Equal(t, notifier.EventRecovery, hooks[1].Event)
+		assert.Equal(t, "failure", hooks[1].Previous)
+		assert.Len(t, messages, 1)
+		assert.Len(t, mail.Mails(), 1)
+	})
+
+	t.Run("MissedDeadline", func(t *testing.T) {
+		require.NoError(t, jobStore.UpdateJobLastReported("report", "app1", time.Now().UTC().Add(-2*time.Hour)))
+		require.NoError(t, server.Notifier.Check(context.Background()))
+
+		hooks, messages := received()
+		require.Len(t, hooks, 3)
+		assert.Equal(t, notifier.EventMissedDeadline, hooks[2].Event)
+		assert.Equal(t, "report", hooks[2].JobName)
+		assert.Len(t, messages, 1)
+	})
+
+	t.Run("Maintenance", func(t *testing.T) {
+		// Jobs taken out of monitoring keep their state
+		admin.PUT(fmt.Sprintf("/api/job/%d", report.ID), map[string]interface{}{"status": "maintenance"}).ExpectStatus(200)
+		require.NoError(t, server.Notifier.Check(context.Background()))
+		admin.PUT(fmt.Sprintf("/api/job/%d", report.ID), map[string]interface{}{"status": "active"}).ExpectStatus(200)
+		require.NoError(t, server.Notifier.Check(context.Background()))
+
+		hooks, _ := received()
+		assert.Len(t, hooks, 3)
+	})
+
+	t.Run("HeaderInjection", func(t *testing.T) {
+		admin.POST("/api/job", map[string]interface{}{"job_name": "report\r\nBcc: victim@example.com", "host": "db1"}).
+			ExpectStatus(400).
+			ExpectContains("control characters")
+
+		// Jobs created before names were checked are still mailed safely
+		job := &model.Job{Name: "rapport\r\nBcc: victim@example.com", Host: "db1", AutomaticFailureThreshold: 3600,
+			Labels: map[string]string{"team": "dba"}, Status: "active", LastReportedAt: time.Now().UTC()}
+		require.NoError(t, jobStore.CreateJob(job))
+		require.NoError(t, server.Notifier.Check(context.Background()))
+		require.NoError(t, server.Database.GetJobResultStore().CreateJobResult(&model.JobResult{
+			JobName: job.Name, Host: job.Host, Status: "failure", Timestamp: time.Now().UTC(),
+		}))
+		require.NoError(t, server.Notifier.Check(context.Background()))
+
+		mails := mail.Mails()
+		require.Len(t, mails, 2)
+		headers, _, _ := strings.Cut(mails[1], "\r\n\r\n")
+		assert.Contains(t, headers, "Subject: Cron job rapport  Bcc: victim@example.com on db1 is failing")
+		assert.NotContains(t, headers, "\nBcc:")
+	})
+
+	t.Run("Deliveries", func(t *testing.T) {
+		// Every send is recorded, most recent first
+		var page model.NotificationPage
+		admin.GET("/api/notifications?job_name=backup&host=db1").ExpectStatus(200).ExpectJSON(&page)
+		require.Len(t, page.Deliveries, 5)
+		channels := map[string]string{}
+		for _, delivery := range page.Deliveries {
+			channels[delivery.Target] = delivery.Channel
+		}
+		assert.Equal(t, map[string]string{"hook": "webhook", "chat": "slack", "mail": "email", "broken": "webhook"}, channels)
+
+		recovery := page.Deliveries[0]
+		assert.Equal(t, model.DeliveryDelivered, recovery.Status)
+		assert.Equal(t, "Cron job backup on db1 recovered", recovery.Subject)
+		var failed *model.NotificationDelivery
+		for _, delivery := range page.Deliveries {
+			if delivery.Status == model.DeliveryFailed {
+				failed = delivery
+			}
+		}
+		require.NotNil(t, failed)
+		assert.Equal(t, "broken", failed.Target)
+		assert.Contains(t, failed.Error, "401")
+
+		// A redelivery sends the same notification again
+		var redelivered model.NotificationDelivery
+		admin.POST(fmt.Sprintf("/api/notifications/%d/redeliver", recovery.ID), nil).ExpectStatus(201).ExpectJSON(&redelivered)
+		require.NotNil(t, redelivered.RedeliveryOf)
+		assert.Equal(t, recovery.ID, *redelivered.RedeliveryOf)
+		hooks, _ := received()
+		assert.Equal(t, notifier.EventRecovery, hooks[len(hooks)-1].Event)
+		assert.Equal(t, "backup", hooks[len(hooks)-1].JobName)
+
+		var retried model.NotificationDelivery
+		admin.POST(fmt.Sprintf("/api/notifications/%d/redeliver", failed.ID), nil).ExpectStatus(502).ExpectJSON(&retried)
+		assert.Equal(t, model.DeliveryFailed, retried.Status)
+
+		body := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, `cronmetrics_notification_deliveries_total{channel="slack",status="delivered"} 2`)
+		assert.Contains(t, body, `cronmetrics_notification_deliveries_total{channel="webhook",status="failed"} 3`)
+
+		dashboard := testutil.NewHTTPClient(t, server.URL()).WithCookies().WithHeaders(server.DashboardHeaders())
+		dashboard.GET("/dashboard/admin/notifications").
+			ExpectStatus(200).
+			ExpectContains(fmt.Sprintf("/admin/notifications/%d/redeliver", recovery.ID))
+		mails := len(mail.Mails())
+		for _, delivery := range page.Deliveries {
+			if delivery.Target == "mail" {
+				dashboard.PostForm(fmt.Sprintf("/dashboard/admin/notifications/%d/redeliver", delivery.ID), nil).
+					ExpectStatus(200).
+					ExpectContains("Notification redelivered")
+			}
+		}
+		assert.Len(t, mail.Mails(), mails+1)
+	})
+}
+
+func TestNotifierDashboardChannels(t *testing.T) {
+	var mu sync.Mutex
+	var hooks []notifier.Notification
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "hook-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var notification notifier.Notification
+		_ = json.NewDecoder(r.Body).Decode(&notification)
+		mu.Lock()
+		hooks = append(hooks, notification)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+
+	received := func() []notifier.Notification {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]notifier.Notification(nil), hooks...)
+	}
+
+	// The route names a channel that only the dashboard defines
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Dashboard = config.DashboardConfig{
+			Enabled: true, Path: "/dashboard", Title: "Test Dashboard", RefreshInterval: 5, AuthRequired: true,
+			PageSize: 25, SSEHeartbeat: 30, KioskRotation: 15, KioskPageSize: 24,
+		}
+		cfg.Notifier = config.NotifierConfig{
+			Enabled:  true,
+			Interval: 3600,
+			Routes:   []config.NotifierRoute{{Channels: []string{"ops"}}},
+		}
+	})
+	defer server.Close()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	dashboard := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders())
+	var backup model.Job
+	admin.POST("/api/job", map[string]interface{}{"job_name": "backup", "host": "db1", "automatic_failure_threshold": 3600}).
+		ExpectStatus(201).ExpectJSON(&backup)
+
+	submit := func(status string) {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": backup.ApiKey, "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": status}).
+			ExpectStatus(201)
+	}
+	form := func(headers string) url.Values {
+		return url.Values{"name": {"ops"}, "type": {"webhook"}, "url": {webhook.URL}, "headers": {headers}}
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		body := dashboard.PostForm("/dashboard/admin/channels", form("X-Token: hook-secret")).
+			ExpectStatus(200).
+			BodyString()
+		assert.Contains(t, body, webhook.URL)
+		assert.NotContains(t, body, "hook-secret")
+
+		require.NoError(t, server.Notifier.Check(context.Background()))
+		submit("failure")
+		require.Eventually(t, func() bool { return len(received()) == 1 }, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, notifier.EventFailure, received()[0].Event)
+	})
+
+	t.Run("InvalidIsRefused", func(t *testing.T) {
+		dashboard.PostForm("/dashboard/admin/channels", url.Values{"name": {"chat"}, "type": {"slack"}, "url": {"ftp://example.com"}}).
+			ExpectStatus(200)
+		body := dashboard.GET("/dashboard/admin/channels").ExpectStatus(200).BodyString()
+		assert.NotContains(t, body, "ftp://example.com")
+	})
+
+	t.Run("UpdateKeepsSecrets", func(t *testing.T) {
+		body := dashboard.GET("/dashboard/admin/channels/1").
+			ExpectStatus(200).
+			ExpectContains("Leave blank to keep X-Token").
+			BodyString()
+		assert.NotContains(t, body, "hook-secret")
+
+		// Blank headers keep the token
+		dashboard.PostForm("/dashboard/admin/channels/1", form("")).ExpectStatus(200)
+		require.NoError(t, server.Notifier.Check(context.Background()))
+		submit("success")
+		require.Eventually(t, func() bool { return len(received()) == 2 }, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, notifier.EventRecovery, received()[1].Event)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		dashboard.PostForm("/dashboard/admin/channels/1/delete", nil).ExpectStatus(200)
+		require.NoError(t, server.Notifier.Check(context.Background()))
+		submit("failure")
+		require.NoError(t, server.Notifier.Check(context.Background()))
+		assert.Len(t, received(), 2)
+
+		dashboard.PostForm("/dashboard/admin/channels/1/delete", nil).ExpectStatus(404)
+		dashboard.GET("/dashboard/admin/audit").
+			ExpectStatus(200).
+			ExpectContains("notifier_channel.create").
+			ExpectContains("notifier_channel.update").
+			ExpectContains("notifier_channel.delete")
+	})
+}
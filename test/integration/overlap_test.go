@@ -0,0 +1,153 @@
+package integration
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/metrics"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlappingRuns(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	submit := func(status, runID string, at time.Time) {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{
+				"job_name": "backup", "host": "db1", "status": status, "run_id": runID,
+				"timestamp": at.UTC().Format(time.RFC3339),
+			}).
+			ExpectStatus(201)
+	}
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	check := func() metrics.JobCheck {
+		var check metrics.JobCheck
+		admin.POST("/api/job/1/check", nil).ExpectStatus(200).ExpectJSON(&check)
+		return check
+	}
+	store := server.Database.GetJobResultStore()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// Runs that start after the previous one finished do not overlap
+	submit("started", "run-1", now.Add(-30*time.Minute))
+	submit("success", "run-1", now.Add(-25*time.Minute))
+	submit("started", "run-2", now.Add(-20*time.Minute))
+	assert.Equal(t, "success", check().Reason)
+
+	// Nor do the starts of the open run
+	submit("started", "run-2", now.Add(-19*time.Minute))
+	assert.Equal(t, "success", check().Reason)
+
+	// run-3 starts while run-2 is still open
+	submit("started", "run-3", now.Add(-10*time.Minute))
+	status := check()
+	assert.Equal(t, "overlapping", status.Reason)
+	assert.Equal(t, float64(-5), status.Value)
+
+	overlaps, err := store.ListOverlaps("backup", "db1", 10)
+	require.NoError(t, err)
+	require.Len(t, overlaps, 1)
+	assert.Equal(t, "run-3", overlaps[0].RunID)
+	assert.Equal(t, "run-2", overlaps[0].PreviousRunID)
+	assert.True(t, overlaps[0].PreviousStartedAt.Equal(now.Add(-19*time.Minute)))
+
+	body := admin.GET("/metrics").ExpectStatus(200).BodyString()
+	assert.Contains(t, body, "# TYPE cronjob_overlapping_runs_total counter")
+	assert.Contains(t, body, `cronjob_overlapping_runs_total{job_name="backup",host="db1",env="prod",type="backup"} 1`)
+	assert.Contains(t, body, `cronjob_overlapping_runs_total{job_name="log-rotation",host="web1",env="prod",type="maintenance"} 0`)
+	assert.Contains(t, body, `cronjob_status{job_name="backup",host="db1",env="prod",type="backup"} -5`)
+	assert.Contains(t, body, "-5=overlapping")
+
+	t.Run("Problems", func(t *testing.T) {
+		var response struct {
+			Problems []*metrics.Problem `json:"problems"`
+		}
+		admin.GET("/api/problems").ExpectStatus(200).ExpectJSON(&response)
+		var overlapping *metrics.Problem
+		for _, problem := range response.Problems {
+			if problem.JobName == "backup" {
+				overlapping = problem
+			}
+		}
+		require.NotNil(t, overlapping)
+		assert.Equal(t, metrics.ProblemOverlapping, overlapping.Kind)
+		assert.Equal(t, 4, overlapping.Priority)
+		assert.True(t, overlapping.Since.Equal(now.Add(-10*time.Minute)))
+	})
+
+	t.Run("Dashboard", func(t *testing.T) {
+		testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders()).WithCookies().
+			GET("/dashboard/jobs/1").
+			ExpectStatus(200).
+			ExpectContains(`id="overlaps"`).
+			ExpectContains("<code>run-3</code>")
+	})
+
+	t.Run("Finish", func(t *testing.T) {
+		// The previous run's result leaves the overlapping run open
+		submit("success", "run-2", now.Add(-5*time.Minute))
+		assert.Equal(t, "overlapping", check().Reason)
+
+		// Until it reports its own result
+		submit("failure", "run-3", now.Add(-time.Minute))
+		assert.Equal(t, "failure", check().Reason)
+		start, err := store.OpenStart("backup", "db1")
+		require.NoError(t, err)
+		assert.Nil(t, start)
+	})
+
+	t.Run("WithoutRunIDs", func(t *testing.T) {
+		submit("started", "", now)
+		submit("started", "", now.Add(time.Second))
+		assert.Equal(t, "overlapping", check().Reason)
+
+		counts, err := store.OverlapCounts()
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), counts[model.JobRef{Name: "backup", Host: "db1"}])
+
+		submit("success", "", now.Add(2*time.Second))
+		assert.Equal(t, "success", check().Reason)
+	})
+}
+
+func TestOverlappingRunsConcurrent(t *testing.T) {
+	testDB := testutil.NewTestDatabase(t)
+	defer testDB.Close()
+	store := testDB.GetJobResultStore()
+	startedAt := time.Now().UTC().Truncate(time.Second)
+
+	// Each of the runs starting at once overlaps the one recorded before it
+	const runs = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, runs)
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := store.RecordStart("backup", "db1", fmt.Sprintf("run-%d", i), startedAt)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	overlaps, err := store.ListOverlaps("backup", "db1", runs)
+	require.NoError(t, err)
+	assert.Len(t, overlaps, runs-1)
+	previous := map[string]bool{}
+	for _, overlap := range overlaps {
+		assert.False(t, previous[overlap.PreviousRunID], "%s overlapped twice", overlap.PreviousRunID)
+		previous[overlap.PreviousRunID] = true
+	}
+}
@@ -0,0 +1,273 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobsCursorPagination(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+
+	client := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+
+	for i := 0; i < 5; i++ {
+		env := "staging"
+		if i%2 == 0 {
+			env = "prod"
+		}
+		client.POST("/api/job", map[string]interface{}{
+			"job_name": fmt.Sprintf("job-%d", i), "host": "h1",
+			"labels": map[string]string{"env": env},
+		}).ExpectStatus(201)
+	}
+
+	// Without cursor or limit the plain list is returned
+	var all []model.Job
+	client.GET("/api/job").ExpectStatus(200).ExpectJSON(&all)
+	assert.Len(t, all, 5)
+
+	var names []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5, "pagination did not terminate")
+
+		var page model.JobPage
+		client.GET("/api/job?limit=2&cursor=" + cursor).ExpectStatus(200).ExpectJSON(&page)
+		assert.LessOrEqual(t, len(page.Jobs), 2)
+		for _, job := range page.Jobs {
+			names = append(names, job.Name)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"job-0", "job-1", "job-2", "job-3", "job-4"}, names)
+
+	t.Run("LabelFilter", func(t *testing.T) {
+		var page model.JobPage
+		client.GET("/api/job?limit=2&label.env=prod").ExpectStatus(200).ExpectJSON(&page)
+		assert.Len(t, page.Jobs, 2)
+		assert.NotEmpty(t, page.NextCursor)
+
+		var rest model.JobPage
+		client.GET("/api/job?limit=2&label.env=prod&cursor=" + page.NextCursor).ExpectStatus(200).ExpectJSON(&rest)
+		require.Len(t, rest.Jobs, 1)
+		assert.Equal(t, "job-4", rest.Jobs[0].Name)
+		assert.Empty(t, rest.NextCursor)
+	})
+
+	t.Run("InvalidParameters", func(t *testing.T) {
+		client.GET("/api/job?cursor=not-a-cursor").ExpectStatus(400)
+		client.GET("/api/job?limit=0").ExpectStatus(400)
+		client.GET("/api/job?limit=5000").ExpectStatus(400)
+	})
+}
+
+func TestJobsSearch(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	client := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	search := func(query string) model.JobSearchResult {
+		var result model.JobSearchResult
+		client.GET("/api/job?" + query).ExpectStatus(200).ExpectJSON(&result)
+		return result
+	}
+
+	result := search("page_size=2&sort_by=name")
+	assert.Equal(t, 3, result.TotalCount)
+	assert.Equal(t, 2, result.TotalPages)
+	assert.True(t, result.HasNext)
+	require.Len(t, result.Jobs, 2)
+	assert.Equal(t, "backup", result.Jobs[0].Name)
+	assert.Equal(t, "log-rotation", result.Jobs[1].Name)
+
+	result = search("page_size=2&sort_by=name&page=2")
+	require.Len(t, result.Jobs, 1)
+	assert.Equal(t, "maintenance-job", result.Jobs[0].Name)
+	assert.True(t, result.HasPrevious)
+	assert.False(t, result.HasNext)
+
+	t.Run("Filters", func(t *testing.T) {
+		result := search("q=rotation")
+		require.Len(t, result.Jobs, 1)
+		assert.Equal(t, "log-rotation", result.Jobs[0].Name)
+		assert.Equal(t, 25, result.PageSize, "the default page size applies")
+
+		result = search("host=db")
+		require.Len(t, result.Jobs, 1)
+		assert.Equal(t, "backup", result.Jobs[0].Name)
+
+		result = search("status=maintenance")
+		require.Len(t, result.Jobs, 1)
+		assert.Equal(t, "maintenance-job", result.Jobs[0].Name)
+
+		assert.Equal(t, 3, search("status=active&status=maintenance").TotalCount)
+		assert.Equal(t, 1, search("name=backup&label.env=prod").TotalCount)
+		assert.Equal(t, 0, search("name=backup&label.env=staging").TotalCount)
+
+		// Only backup has reported
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "success"}).
+			ExpectStatus(201)
+		result = search("after=1h")
+		require.Len(t, result.Jobs, 1)
+		assert.Equal(t, "backup", result.Jobs[0].Name)
+		assert.Equal(t, 2, search("before=1h").TotalCount)
+		assert.Equal(t, 1, search("after="+time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)).TotalCount)
+
+		result = search("name=nothing")
+		assert.NotNil(t, result.Jobs)
+		assert.Empty(t, result.Jobs)
+	})
+
+	t.Run("WithoutSearchParameters", func(t *testing.T) {
+		var jobs []model.Job
+		client.GET("/api/job?label.env=prod").ExpectStatus(200).ExpectJSON(&jobs)
+		assert.Len(t, jobs, 2)
+	})
+
+	t.Run("InvalidParameters", func(t *testing.T) {
+		client.GET("/api/job?status=running").ExpectStatus(400).ExpectContains("status must be one of")
+		client.GET("/api/job?page=0").ExpectStatus(400).ExpectContains("page must be a positive integer")
+		client.GET("/api/job?page_size=5000").ExpectStatus(400).ExpectContains("page_size must be between 1 and 1000")
+		client.GET("/api/job?before=yesterday").ExpectStatus(400).ExpectContains("before must be an RFC 3339 timestamp")
+		client.GET("/api/job?sort_by=api_key").ExpectStatus(400).ExpectContains("sort_by must be one of")
+		client.GET("/api/job?sort_by=name&sort_dir=up").ExpectStatus(400).ExpectContains("sort_dir must be")
+		client.GET("/api/job?name=backup&limit=10").ExpectStatus(400).ExpectContains("cursor and limit cannot be combined")
+	})
+}
+
+func TestJobResultsCursorPagination(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	reporter := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{
+			"X-API-Key":    "cm_test_backup_key",
+			"Content-Type": "application/json",
+		})
+
+	var jobs []model.Job
+	admin.GET("/api/job?label.type=backup").ExpectStatus(200).ExpectJSON(&jobs)
+	require.Len(t, jobs, 1)
+	jobID := jobs[0].ID
+
+	for i := 0; i < 3; i++ {
+		reporter.POST("/api/job-result", map[string]interface{}{
+			"job_name": "backup", "host": "db1", "status": "success", "duration": i,
+		}).ExpectStatus(201)
+	}
+
+	var first model.JobResultPage
+	admin.GET(fmt.Sprintf("/api/job/%d/results?limit=2", jobID)).ExpectStatus(200).ExpectJSON(&first)
+	require.Len(t, first.Results, 2)
+	assert.Equal(t, 2, first.Results[0].Duration, "newest result comes first")
+	assert.NotEmpty(t, first.NextCursor)
+
+	var second model.JobResultPage
+	admin.GET(fmt.Sprintf("/api/job/%d/results?limit=2&cursor=%s", jobID, first.NextCursor)).
+		ExpectStatus(200).ExpectJSON(&second)
+	require.Len(t, second.Results, 1)
+	assert.Equal(t, 0, second.Results[0].Duration)
+	assert.Empty(t, second.NextCursor)
+
+	t.Run("UnknownJob", func(t *testing.T) {
+		admin.GET("/api/job/9999/results").ExpectStatus(404)
+	})
+
+	t.Run("RequiresAdmin", func(t *testing.T) {
+		reporter.GET(fmt.Sprintf("/api/job/%d/results", jobID)).ExpectStatus(401)
+	})
+}
+
+func TestJobResultsListing(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+	backup := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json"})
+	rotation := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{"X-API-Key": "cm_test_logrotation_key", "Content-Type": "application/json"})
+
+	backup.POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "success"}).ExpectStatus(201)
+	backup.POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "failure"}).ExpectStatus(201)
+	rotation.POST("/api/job-result", map[string]interface{}{"job_name": "log-rotation", "host": "web1", "status": "success"}).ExpectStatus(201)
+
+	// The first result ran three days ago
+	server.Database.Exec(`UPDATE job_results SET timestamp = ? WHERE id = 1`, time.Now().UTC().Add(-72*time.Hour))
+
+	list := func(query string) model.JobResultPage {
+		var page model.JobResultPage
+		admin.GET("/api/job-result?" + query).ExpectStatus(200).ExpectJSON(&page)
+		return page
+	}
+
+	t.Run("AllJobs", func(t *testing.T) {
+		first := list("limit=2")
+		require.Len(t, first.Results, 2)
+		assert.Equal(t, "log-rotation", first.Results[0].JobName, "newest result comes first")
+		assert.Equal(t, "failure", first.Results[1].Status)
+		require.NotEmpty(t, first.NextCursor)
+
+		rest := list("limit=2&cursor=" + first.NextCursor)
+		require.Len(t, rest.Results, 1)
+		assert.Equal(t, "success", rest.Results[0].Status)
+		assert.Empty(t, rest.NextCursor)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		assert.Len(t, list("job_name=backup&host=db1").Results, 2)
+		assert.Len(t, list("host=web1").Results, 1)
+
+		failed := list("status=failure").Results
+		require.Len(t, failed, 1)
+		assert.Equal(t, "backup", failed[0].JobName)
+
+		assert.Len(t, list("since=24h").Results, 2)
+		assert.Len(t, list("until=2d").Results, 1)
+		since := time.Now().UTC().Add(-96 * time.Hour).Format(time.RFC3339)
+		until := time.Now().UTC().Add(-48 * time.Hour).Format(time.RFC3339)
+		assert.Len(t, list("since="+since+"&until="+until).Results, 1)
+	})
+
+	t.Run("JobFilters", func(t *testing.T) {
+		var page model.JobResultPage
+		admin.GET("/api/job/1/results?since=1d&status=failure").ExpectStatus(200).ExpectJSON(&page)
+		require.Len(t, page.Results, 1)
+		assert.Equal(t, "failure", page.Results[0].Status)
+
+		admin.GET("/api/job/1/results?until=1d").ExpectStatus(200).ExpectJSON(&page)
+		assert.Len(t, page.Results, 1)
+	})
+
+	t.Run("InvalidFilters", func(t *testing.T) {
+		admin.GET("/api/job-result?status=started").ExpectStatus(400)
+		admin.GET("/api/job-result?since=yesterday").ExpectStatus(400)
+		admin.GET("/api/job-result?since=1h&until=2h").ExpectStatus(400)
+		admin.GET("/api/job/1/results?until=soon").ExpectStatus(400)
+	})
+
+	t.Run("RequiresAdmin", func(t *testing.T) {
+		backup.GET("/api/job-result").ExpectStatus(401)
+	})
+}
@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPing(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	client := testutil.NewHTTPClient(t, server.URL())
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+
+	client.GET("/api/ping?key=cm_test_backup_key").
+		ExpectStatus(200).
+		ExpectContains(`"job":"backup@db1"`)
+	client.GET("/api/ping?key=cm_test_backup_key&status=failure&duration=12&output=disk+full").ExpectStatus(200)
+	testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"}).
+		POST("/api/ping", nil).
+		ExpectStatus(200)
+	testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"}).
+		POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "success"}).
+		ExpectStatus(201)
+
+	var page model.JobResultPage
+	admin.GET("/api/job/1/results").ExpectStatus(200).ExpectJSON(&page)
+	require.Len(t, page.Results, 4)
+	assert.Empty(t, page.Results[0].Source, "submitted as JSON")
+	for _, result := range page.Results[1:] {
+		assert.Equal(t, "ping", result.Source)
+	}
+	failure := page.Results[2]
+	assert.Equal(t, "failure", failure.Status)
+	assert.Equal(t, 12, failure.Duration)
+	assert.Equal(t, "disk full", failure.Output)
+	assert.Equal(t, "success", page.Results[3].Status)
+
+	t.Run("Refused", func(t *testing.T) {
+		client.GET("/api/ping").ExpectStatus(401)
+		client.GET("/api/ping?key=cm_wrong_key_123").ExpectStatus(401)
+		client.GET("/api/ping?key=cm_test_backup_key&status=done").
+			ExpectStatus(400).
+			ExpectContains("status must be")
+		client.GET("/api/ping?key=cm_test_backup_key&state=failure").
+			ExpectStatus(400).
+			ExpectContains(`unknown field \"state\"`)
+		client.GET("/api/ping?key=cm_test_backup_key&job_name=log-rotation&host=web1").ExpectStatus(403)
+		client.DELETE("/api/ping?key=cm_test_backup_key").ExpectStatus(405)
+
+		var rejections model.RejectionPage
+		admin.GET("/api/result-rejections").ExpectStatus(200).ExpectJSON(&rejections)
+		assert.Len(t, rejections.Rejections, 5)
+	})
+}
@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/api"
+	"github.com/jaepetto/cron-exporter/pkg/metrics"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblems(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	jobStore := server.Database.GetJobStore()
+	results := server.Database.GetJobResultStore()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	create := func(name, host, status string, lastReport time.Time) {
+		admin.POST("/api/job", map[string]interface{}{
+			"job_name": name, "host": host, "automatic_failure_threshold": 3600, "status": status,
+		}).ExpectStatus(201)
+		require.NoError(t, jobStore.UpdateJobLastReported(name, host, lastReport))
+	}
+	record := func(name, host string, statuses ...string) {
+		for i, status := range statuses {
+			require.NoError(t, results.CreateJobResult(&model.JobResult{
+				JobName: name, Host: host, Status: status,
+				Timestamp: now.Add(time.Duration(i-len(statuses)) * time.Minute),
+			}))
+		}
+	}
+
+	// db1 keeps reporting: a failing job, one missing its deadline, a
+	// flapping one and a healthy one
+	create("backup", "db1", "active", now)
+	record("backup", "db1", "success", "failure", "failure")
+	create("report", "db1", "active", now.Add(-3*time.Hour))
+	create("sync", "db1", "active", now)
+	record("sync", "db1", "success", "failure", "success", "failure", "success")
+	create("healthy", "db1", "active", now)
+	record("healthy", "db1", "success", "success")
+
+	// Every job of db2 missed its deadline
+	create("a", "db2", "active", now.Add(-5*time.Hour))
+	create("b", "db2", "active", now.Add(-2*time.Hour))
+
+	// Jobs taken out of monitoring are left out
+	create("old", "db3", "maintenance", now.Add(-48*time.Hour))
+
+	var response api.ProblemsResponse
+	admin.GET("/api/problems").ExpectStatus(200).ExpectJSON(&response)
+	problems := response.Problems
+	require.Len(t, problems, 4)
+
+	kinds := make([]string, len(problems))
+	for i, problem := range problems {
+		kinds[i] = problem.Kind
+	}
+	assert.Equal(t, []string{metrics.ProblemStaleHost, metrics.ProblemFailure, metrics.ProblemMissedDeadline, metrics.ProblemFlapping}, kinds)
+
+	stale := problems[0]
+	assert.Equal(t, "db2", stale.Host)
+	assert.Equal(t, []string{"a", "b"}, stale.Jobs)
+	assert.Zero(t, stale.JobID)
+	assert.True(t, stale.Since.Equal(now.Add(-4*time.Hour)), "since the first missed deadline, got %s", stale.Since)
+	assert.Equal(t, 1, stale.Priority)
+
+	failure := problems[1]
+	assert.Equal(t, "backup", failure.JobName)
+	assert.Equal(t, "failure", failure.Reason)
+	assert.True(t, failure.Since.Equal(now.Add(-2*time.Minute)), "since the first failure, got %s", failure.Since)
+	assert.InDelta(t, 120, failure.Age, 5)
+
+	missed := problems[2]
+	assert.Equal(t, "report", missed.JobName)
+	assert.True(t, missed.Since.Equal(now.Add(-2*time.Hour)), "since the deadline, got %s", missed.Since)
+
+	assert.Equal(t, "sync", problems[3].JobName)
+	assert.Equal(t, "success", problems[3].Reason)
+
+	t.Run("Dashboard", func(t *testing.T) {
+		dashboard := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders()).WithCookies()
+		dashboard.GET("/dashboard/jobs").ExpectStatus(200).ExpectContains(`/dashboard/problems"`)
+		body := dashboard.GET("/dashboard/problems").
+			ExpectStatus(200).
+			ExpectContains(`<tr class="problem-stale_host">`).
+			ExpectContains("a, b").
+			ExpectContains(`<tr class="problem-flapping">`).
+			BodyString()
+		assert.NotContains(t, body, "healthy")
+	})
+
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		admin.POST("/api/problems", nil).ExpectStatus(405)
+	})
+}
@@ -0,0 +1,190 @@
+package integration
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultRateLimit(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.RateLimit.MinInterval = 60
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	job := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"})
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	submit := func(status string) *testutil.HTTPResponse {
+		return job.POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": status})
+	}
+
+	submit("success").ExpectStatus(201)
+	response := submit("failure").
+		ExpectStatus(429).
+		ExpectContains("retry in")
+	retryAfter, err := strconv.Atoi(response.Header.Get("Retry-After"))
+	require.NoError(t, err)
+	assert.InDelta(t, 60, retryAfter, 2)
+
+	// Starts are not results, and ping submissions are limited alike
+	submit("started").ExpectStatus(201)
+	testutil.NewHTTPClient(t, server.URL()).GET("/api/ping?key=cm_test_backup_key").ExpectStatus(429)
+
+	var page model.JobResultPage
+	admin.GET("/api/job/1/results").ExpectStatus(200).ExpectJSON(&page)
+	assert.Len(t, page.Results, 1)
+
+	var rejections model.RejectionPage
+	admin.GET("/api/result-rejections").ExpectStatus(200).ExpectJSON(&rejections)
+	require.Len(t, rejections.Rejections, 2)
+	assert.Equal(t, model.RejectionRateLimited, rejections.Rejections[0].Reason)
+
+	metrics := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+	assert.Contains(t, metrics, `cronjob_rate_limited_results_total{job_name="backup",host="db1",action="rejected"} 2`)
+	assert.Contains(t, metrics, `cronmetrics_rejected_results_total{reason="rate_limited"} 2`)
+	assert.NotContains(t, metrics, `job_name="log-rotation",host="web1",action=`)
+
+	// Names and hosts are escaped
+	var quoted model.Job
+	admin.POST("/api/job", map[string]interface{}{"job_name": `nightly "full"`, "host": `db\2`}).
+		ExpectStatus(201).
+		ExpectJSON(&quoted)
+	quotedJob := testutil.NewHTTPClient(t, server.URL()).WithHeaders(map[string]string{"X-API-Key": quoted.ApiKey})
+	for _, status := range []int{201, 429} {
+		quotedJob.POST("/api/job-result", map[string]interface{}{"job_name": quoted.Name, "host": quoted.Host, "status": "success"}).
+			ExpectStatus(status)
+	}
+	metrics = testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+	assert.Contains(t, metrics, `cronjob_rate_limited_results_total{job_name="nightly \"full\"",host="db\\2",action="rejected"} 1`)
+}
+
+func TestResultRateLimitCoalesce(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.RateLimit.MinInterval = 60
+		cfg.RateLimit.Action = "coalesce"
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	job := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"})
+	submit := func(status string) *testutil.HTTPResponse {
+		return job.POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": status})
+	}
+
+	submit("success").ExpectStatus(201)
+	submit("success").ExpectStatus(202).ExpectContains(`"status":"coalesced"`)
+	// A change of status is recorded, and starts the interval again
+	submit("failure").ExpectStatus(201)
+	submit("failure").ExpectStatus(202)
+	submit("failure").ExpectStatus(202)
+
+	var page model.JobResultPage
+	testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders()).
+		GET("/api/job/1/results").ExpectStatus(200).ExpectJSON(&page)
+	require.Len(t, page.Results, 2)
+	assert.Equal(t, "failure", page.Results[0].Status)
+
+	metrics := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+	assert.Contains(t, metrics, `cronjob_rate_limited_results_total{job_name="backup",host="db1",action="coalesced"} 3`)
+	assert.Contains(t, metrics, `cronmetrics_rejected_results_total{reason="rate_limited"} 0`)
+}
+
+func TestResultRateLimitConcurrent(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.RateLimit.MinInterval = 60
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	// A job in a loop submitting many results at once gets one through
+	const submissions = 20
+	var wg sync.WaitGroup
+	statuses := make(chan int, submissions)
+	for i := 0; i < submissions; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, err := http.NewRequest(http.MethodPost, server.URL()+"/api/job-result",
+				bytes.NewReader([]byte(`{"job_name": "backup", "host": "db1", "status": "success"}`)))
+			if err != nil {
+				return
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-API-Key", "cm_test_backup_key")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+			statuses <- resp.StatusCode
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	counts := map[int]int{}
+	for status := range statuses {
+		counts[status]++
+	}
+	assert.Equal(t, map[int]int{http.StatusCreated: 1, http.StatusTooManyRequests: submissions - 1}, counts)
+}
+
+func TestResultRateLimitStoreFailure(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.RateLimit.MinInterval = 60
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	_, err := server.Database.DB.GetDB().Exec(`
+		CREATE TRIGGER refuse_failures BEFORE INSERT ON job_results WHEN NEW.status = 'failure'
+		BEGIN SELECT RAISE(ABORT, 'refused'); END`)
+	require.NoError(t, err)
+
+	job := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"})
+	submit := func(status string) *testutil.HTTPResponse {
+		return job.POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": status})
+	}
+
+	// A result that could not be stored does not hold the interval
+	submit("failure").ExpectStatus(500)
+	submit("success").ExpectStatus(201)
+	submit("success").ExpectStatus(429)
+}
+
+func TestResultRateLimitPerJob(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	var backup model.Job
+	admin.GET("/api/job/1").ExpectStatus(200).ExpectJSON(&backup)
+	admin.PUT(fmt.Sprintf("/api/job/%d", backup.ID), map[string]interface{}{"min_interval": 60}).ExpectStatus(200)
+	admin.PUT(fmt.Sprintf("/api/job/%d", backup.ID), map[string]interface{}{"min_interval": -1}).ExpectStatus(400)
+
+	submit := func(key, name, host string) *testutil.HTTPResponse {
+		return testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": key}).
+			POST("/api/job-result", map[string]interface{}{"job_name": name, "host": host, "status": "success"})
+	}
+
+	// The job's own interval applies without a server-wide limit
+	submit("cm_test_backup_key", "backup", "db1").ExpectStatus(201)
+	submit("cm_test_backup_key", "backup", "db1").ExpectStatus(429)
+	submit("cm_test_logrotation_key", "log-rotation", "web1").ExpectStatus(201)
+	submit("cm_test_logrotation_key", "log-rotation", "web1").ExpectStatus(201)
+}
@@ -0,0 +1,316 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/api"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertJob(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+
+	client := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+
+	jobRequest := map[string]interface{}{
+		"job_name":                    "upsert-job",
+		"host":                        "web1",
+		"automatic_failure_threshold": 1800,
+		"labels":                      map[string]string{"env": "prod"},
+	}
+
+	var created model.Job
+	client.PUT("/api/job", jobRequest).
+		ExpectStatus(201).
+		ExpectHeader("X-Reconcile-Action", "created").
+		ExpectJSON(&created)
+
+	assert.Greater(t, created.ID, 0)
+	assert.NotEmpty(t, created.ApiKey)
+
+	// Same payload again is a no-op
+	var unchanged model.Job
+	client.PUT("/api/job", jobRequest).
+		ExpectStatus(200).
+		ExpectHeader("X-Reconcile-Action", "unchanged").
+		ExpectJSON(&unchanged)
+
+	assert.Equal(t, created.ID, unchanged.ID)
+	assert.Equal(t, created.ApiKey, unchanged.ApiKey)
+
+	// Changing a field updates the same job
+	jobRequest["automatic_failure_threshold"] = 7200
+	var updated model.Job
+	client.PUT("/api/job", jobRequest).
+		ExpectStatus(200).
+		ExpectHeader("X-Reconcile-Action", "updated").
+		ExpectJSON(&updated)
+
+	assert.Equal(t, created.ID, updated.ID)
+	assert.Equal(t, 7200, updated.AutomaticFailureThreshold)
+
+	t.Run("MissingIdentity", func(t *testing.T) {
+		client.PUT("/api/job", map[string]interface{}{"job_name": "no-host"}).
+			ExpectStatus(400)
+	})
+}
+
+func TestUpsertJobClearsFields(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+
+	client := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+
+	full := func(name string) map[string]interface{} {
+		return map[string]interface{}{
+			"job_name": name, "host": "web1", "type": model.JobTypeCounter,
+			"description": "Nightly export", "schedule": "0 3 * * *", "shard_policy": model.ShardPolicyAll,
+			"min_interval": 60, "throughput_floor": 100,
+		}
+	}
+	bare := func(name string) map[string]interface{} {
+		return map[string]interface{}{"job_name": name, "host": "web1", "type": model.JobTypeCounter}
+	}
+	assertCleared := func(t *testing.T, job model.Job) {
+		assert.Empty(t, job.Description)
+		assert.Empty(t, job.Schedule)
+		assert.Empty(t, job.ShardPolicy)
+		assert.Zero(t, job.MinInterval)
+		assert.Zero(t, job.ThroughputFloor)
+	}
+
+	t.Run("Upsert", func(t *testing.T) {
+		var job model.Job
+		client.PUT("/api/job", full("export")).ExpectStatus(201).ExpectJSON(&job)
+		assert.Equal(t, "0 3 * * *", job.Schedule)
+
+		// A spec dropping the fields clears them
+		var updated model.Job
+		client.PUT("/api/job", bare("export")).
+			ExpectStatus(200).
+			ExpectHeader("X-Reconcile-Action", "updated").
+			ExpectJSON(&updated)
+		assertCleared(t, updated)
+		client.PUT("/api/job", bare("export")).ExpectHeader("X-Reconcile-Action", "unchanged")
+	})
+
+	t.Run("Reconcile", func(t *testing.T) {
+		var resp api.ReconcileResponse
+		client.POST("/api/job/reconcile", map[string]interface{}{"jobs": []map[string]interface{}{full("import")}}).
+			ExpectStatus(200).ExpectJSON(&resp)
+		assert.Equal(t, 1, resp.Created)
+
+		resp = api.ReconcileResponse{}
+		client.POST("/api/job/reconcile", map[string]interface{}{"jobs": []map[string]interface{}{bare("import")}}).
+			ExpectStatus(200).ExpectJSON(&resp)
+		assert.Equal(t, 1, resp.Updated)
+		require.Len(t, resp.Results, 1)
+		assertCleared(t, *resp.Results[0].Job)
+
+		stored, err := server.Database.GetJobStore().GetJob("import", "web1")
+		require.NoError(t, err)
+		assertCleared(t, *stored)
+	})
+}
+
+func TestReconcileJobs(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+
+	client := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+
+	// Existing inventory: one job that stays, one managed job to prune, one unmanaged job
+	client.POST("/api/job", map[string]interface{}{
+		"job_name": "keep", "host": "h1", "labels": map[string]string{"managed_by": "terraform"},
+	}).ExpectStatus(201)
+	client.POST("/api/job", map[string]interface{}{
+		"job_name": "stale", "host": "h1", "labels": map[string]string{"managed_by": "terraform"},
+	}).ExpectStatus(201)
+	client.POST("/api/job", map[string]interface{}{
+		"job_name": "manual", "host": "h2",
+	}).ExpectStatus(201)
+
+	request := map[string]interface{}{
+		"prune":    true,
+		"selector": map[string]string{"managed_by": "terraform"},
+		"jobs": []map[string]interface{}{
+			{"job_name": "keep", "host": "h1", "labels": map[string]string{"managed_by": "terraform"}},
+			{"job_name": "new", "host": "h3", "labels": map[string]string{"managed_by": "terraform"}},
+		},
+	}
+
+	var resp api.ReconcileResponse
+	client.POST("/api/job/reconcile", request).
+		ExpectStatus(200).
+		ExpectJSON(&resp)
+
+	assert.Equal(t, 1, resp.Created)
+	assert.Equal(t, 0, resp.Updated)
+	assert.Equal(t, 1, resp.Unchanged)
+	assert.Equal(t, 1, resp.Deleted)
+
+	var jobs []model.Job
+	client.GET("/api/job").ExpectStatus(200).ExpectJSON(&jobs)
+
+	names := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		names = append(names, job.Name)
+	}
+	assert.ElementsMatch(t, []string{"keep", "new", "manual"}, names)
+
+	t.Run("DuplicateEntries", func(t *testing.T) {
+		client.POST("/api/job/reconcile", map[string]interface{}{
+			"jobs": []map[string]interface{}{
+				{"job_name": "dup", "host": "h1"},
+				{"job_name": "dup", "host": "h1"},
+			},
+		}).ExpectStatus(400)
+	})
+
+	t.Run("FailureAppliesNothing", func(t *testing.T) {
+		// The job "broken" cannot be stored
+		_, err := server.Database.DB.GetDB().Exec(`CREATE TRIGGER refuse_broken BEFORE INSERT ON jobs
+			WHEN NEW.name = 'broken' BEGIN SELECT RAISE(ABORT, 'refused'); END`)
+		require.NoError(t, err)
+
+		client.POST("/api/job/reconcile", map[string]interface{}{
+			"prune":    true,
+			"selector": map[string]string{"managed_by": "terraform"},
+			"jobs": []map[string]interface{}{
+				{"job_name": "keep", "host": "h1", "labels": map[string]string{"managed_by": "terraform", "team": "ops"}},
+				{"job_name": "broken", "host": "h1", "labels": map[string]string{"managed_by": "terraform"}},
+			},
+		}).ExpectStatus(500)
+
+		// Neither the update nor the prune of "new" went through
+		var jobs []model.Job
+		client.GET("/api/job").ExpectStatus(200).ExpectJSON(&jobs)
+		names := make([]string, 0, len(jobs))
+		for _, job := range jobs {
+			names = append(names, job.Name)
+			if job.Name == "keep" {
+				assert.NotContains(t, job.Labels, "team")
+			}
+		}
+		assert.ElementsMatch(t, []string{"keep", "new", "manual"}, names)
+	})
+
+	t.Run("RequiresAdmin", func(t *testing.T) {
+		testutil.NewHTTPClient(t, server.URL()).
+			POST("/api/job/reconcile", request).
+			ExpectStatus(401)
+	})
+}
+
+func TestJobDryRun(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+
+	client := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders())
+
+	t.Run("CreateDoesNotPersist", func(t *testing.T) {
+		var resp api.DryRunResponse
+		client.POST("/api/job?dry_run=true", map[string]interface{}{
+			"job_name": "dry-job", "host": "h1",
+		}).ExpectStatus(200).ExpectJSON(&resp)
+
+		assert.True(t, resp.DryRun)
+		assert.True(t, resp.Changed)
+		assert.Equal(t, "created", resp.Action)
+		assert.Contains(t, resp.Diff, "job_name")
+		assert.Equal(t, 0, server.Database.CountJobs())
+
+		// The generated key is never stored, nor shown
+		assert.Nil(t, resp.Diff["api_key"].Old)
+		assert.Equal(t, "REDACTED", resp.Diff["api_key"].New)
+		assert.Equal(t, "REDACTED", resp.Job.ApiKey)
+	})
+
+	var job model.Job
+	client.POST("/api/job", map[string]interface{}{
+		"job_name": "real-job", "host": "h1", "automatic_failure_threshold": 600,
+	}).ExpectStatus(201).ExpectJSON(&job)
+
+	t.Run("CreateConflict", func(t *testing.T) {
+		client.POST("/api/job?dry_run=true", map[string]interface{}{
+			"job_name": "real-job", "host": "h1",
+		}).ExpectStatus(409)
+	})
+
+	t.Run("UpdateReportsDiff", func(t *testing.T) {
+		var resp api.DryRunResponse
+		client.PUT(fmt.Sprintf("/api/job/%d?dry_run=true", job.ID), map[string]interface{}{
+			"automatic_failure_threshold": 900,
+		}).ExpectStatus(200).ExpectJSON(&resp)
+
+		assert.Equal(t, "updated", resp.Action)
+		assert.Len(t, resp.Diff, 1)
+		assert.EqualValues(t, 600, resp.Diff["automatic_failure_threshold"].Old)
+		assert.EqualValues(t, 900, resp.Diff["automatic_failure_threshold"].New)
+
+		var stored model.Job
+		client.GET(fmt.Sprintf("/api/job/%d", job.ID)).ExpectStatus(200).ExpectJSON(&stored)
+		assert.Equal(t, 600, stored.AutomaticFailureThreshold)
+	})
+
+	t.Run("UpsertRedactsKeys", func(t *testing.T) {
+		response := client.PUT("/api/job?dry_run=true", map[string]interface{}{
+			"job_name": "real-job", "host": "h1", "api_key": "cm_new_secret_key",
+		}).ExpectStatus(200)
+		body := response.BodyString()
+		var resp api.DryRunResponse
+		response.ExpectJSON(&resp)
+
+		assert.Equal(t, "updated", resp.Action)
+		assert.Equal(t, api.FieldChange{Old: "REDACTED", New: "REDACTED"}, resp.Diff["api_key"])
+		assert.NotContains(t, body, "cm_new_secret_key")
+		assert.NotContains(t, body, job.ApiKey)
+		assert.Contains(t, body, `"dry_run":true`)
+	})
+
+	t.Run("UpdateUnchanged", func(t *testing.T) {
+		var resp api.DryRunResponse
+		client.PUT(fmt.Sprintf("/api/job/%d?dry_run=true", job.ID), map[string]interface{}{
+			"automatic_failure_threshold": 600,
+		}).ExpectStatus(200).ExpectJSON(&resp)
+
+		assert.Equal(t, "unchanged", resp.Action)
+		assert.False(t, resp.Changed)
+		assert.Empty(t, resp.Diff)
+	})
+
+	t.Run("DeleteDoesNotPersist", func(t *testing.T) {
+		var resp api.DryRunResponse
+		client.DELETE(fmt.Sprintf("/api/job/%d?dry_run=true", job.ID)).
+			ExpectStatus(200).ExpectJSON(&resp)
+
+		assert.Equal(t, "deleted", resp.Action)
+		assert.Equal(t, 1, server.Database.CountJobs())
+
+		client.DELETE("/api/job/9999?dry_run=true").ExpectStatus(404)
+	})
+
+	t.Run("ReconcileDoesNotPersist", func(t *testing.T) {
+		var resp api.ReconcileResponse
+		client.POST("/api/job/reconcile?dry_run=true", map[string]interface{}{
+			"prune": true,
+			"jobs": []map[string]interface{}{
+				{"job_name": "planned", "host": "h9"},
+			},
+		}).ExpectStatus(200).ExpectJSON(&resp)
+
+		assert.True(t, resp.DryRun)
+		assert.Equal(t, 1, resp.Created)
+		assert.Equal(t, 1, resp.Deleted)
+		assert.Equal(t, 1, server.Database.CountJobs())
+	})
+}
@@ -0,0 +1,174 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoveryMetrics(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Metrics.HealthGroupLabel = "env"
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	submit := func(name, host, key, status string, ago time.Duration) {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": key, "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{
+				"job_name": name, "host": host, "status": status,
+				"timestamp": now.Add(-ago).Format(time.RFC3339),
+			}).
+			ExpectStatus(201)
+	}
+	backup := func(status string, ago time.Duration) {
+		submit("backup", "db1", "cm_test_backup_key", status, ago)
+	}
+
+	// An incident lasts from its first failure to the next success
+	backup("success", 60*time.Minute)
+	backup("failure", 50*time.Minute)
+	backup("failure", 45*time.Minute)
+	backup("success", 30*time.Minute)
+	backup("failure", 20*time.Minute)
+	backup("success", 10*time.Minute)
+	submit("log-rotation", "web1", "cm_test_logrotation_key", "failure", 40*time.Minute)
+	submit("log-rotation", "web1", "cm_test_logrotation_key", "success", 35*time.Minute)
+	// An open incident has not recovered yet
+	submit("maintenance-job", "app1", "cm_test_maintenance_key", "failure", 5*time.Minute)
+
+	recoveries, err := server.Database.GetJobResultStore().RecoveriesSince(now.Add(-24 * time.Hour))
+	require.NoError(t, err)
+	incidents := recoveries[model.JobRef{Name: "backup", Host: "db1"}]
+	require.Len(t, incidents, 2)
+	assert.Equal(t, 20*time.Minute, incidents[0].Duration())
+	assert.Equal(t, 10*time.Minute, incidents[1].Duration())
+	assert.Equal(t, 15*time.Minute, model.MeanRecovery(incidents))
+	assert.Empty(t, recoveries[model.JobRef{Name: "maintenance-job", Host: "app1"}])
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	body := admin.GET("/metrics").ExpectStatus(200).BodyString()
+	assert.Contains(t, body, "# TYPE cronjob_last_recovery_duration_seconds gauge")
+	assert.Contains(t, body, `cronjob_last_recovery_duration_seconds{job_name="backup",host="db1",env="prod",type="backup"} 600`)
+	assert.Contains(t, body, `cronjob_mttr_seconds{job_name="backup",host="db1",env="prod",type="backup"} 900`)
+	assert.Contains(t, body, `cronjob_mttr_seconds{job_name="log-rotation",host="web1",env="prod",type="maintenance"} 300`)
+	assert.NotContains(t, body, `cronjob_mttr_seconds{job_name="maintenance-job"`)
+	assert.Contains(t, body, `cronjob_group_mttr_seconds{group="prod"} 700`, "the mean of the group's incidents")
+	assert.NotContains(t, body, `cronjob_group_mttr_seconds{group="staging"}`)
+
+	t.Run("Window", func(t *testing.T) {
+		// Incidents open at the start of the window count from their first
+		// failure in it
+		recoveries, err := server.Database.GetJobResultStore().RecoveriesSince(now.Add(-47 * time.Minute))
+		require.NoError(t, err)
+		incidents := recoveries[model.JobRef{Name: "backup", Host: "db1"}]
+		require.Len(t, incidents, 2)
+		assert.Equal(t, 15*time.Minute, incidents[0].Duration())
+	})
+
+	t.Run("Shards", func(t *testing.T) {
+		var job model.Job
+		admin.POST("/api/job", map[string]interface{}{
+			"job_name": "reindex", "host": "batch1", "automatic_failure_threshold": 3600, "shard_policy": model.ShardPolicyAll,
+		}).ExpectStatus(201).ExpectJSON(&job)
+		shard := func(runID string, index int, status string, ago time.Duration) {
+			testutil.NewHTTPClient(t, server.URL()).
+				WithHeaders(map[string]string{"X-API-Key": job.ApiKey, "Content-Type": "application/json"}).
+				POST("/api/job-result", map[string]interface{}{
+					"job_name": job.Name, "host": job.Host, "status": status, "run_id": runID, "shard": index, "shards": 2,
+					"timestamp": now.Add(-ago).Format(time.RFC3339),
+				}).
+				ExpectStatus(201)
+		}
+
+		// A failed shard fails the run until its retry succeeds
+		shard("run-1", 0, "success", 55*time.Minute)
+		shard("run-1", 1, "failure", 50*time.Minute)
+		shard("run-1", 1, "success", 40*time.Minute)
+		// Both shards of run-2 failing are one failed run
+		shard("run-2", 0, "failure", 30*time.Minute)
+		shard("run-2", 1, "failure", 29*time.Minute)
+		// run-3 is undecided until its last shard succeeds
+		shard("run-3", 0, "success", 20*time.Minute)
+		shard("run-3", 1, "success", 15*time.Minute)
+
+		recoveries, err := server.Database.GetJobResultStore().RecoveriesSince(now.Add(-24 * time.Hour))
+		require.NoError(t, err)
+		incidents := recoveries[model.JobRef{Name: "reindex", Host: "batch1"}]
+		require.Len(t, incidents, 2)
+		assert.Equal(t, 10*time.Minute, incidents[0].Duration())
+		assert.Equal(t, 15*time.Minute, incidents[1].Duration())
+	})
+
+	t.Run("SLA", func(t *testing.T) {
+		var response graphQLResponse
+		admin.POST("/api/graphql", map[string]interface{}{
+			"query": `{ job(name: "backup", host: "db1") { sla(windowDays: 7) { total recoveries mttr } } maintenance: job(name: "maintenance-job", host: "app1") { sla { recoveries mttr } } }`,
+		}).ExpectStatus(200).ExpectJSON(&response)
+		require.Empty(t, response.Errors)
+
+		var data struct {
+			Job struct {
+				SLA struct {
+					Total      int      `json:"total"`
+					Recoveries int      `json:"recoveries"`
+					MTTR       *float64 `json:"mttr"`
+				} `json:"sla"`
+			} `json:"job"`
+			Maintenance struct {
+				SLA struct {
+					Recoveries int      `json:"recoveries"`
+					MTTR       *float64 `json:"mttr"`
+				} `json:"sla"`
+			} `json:"maintenance"`
+		}
+		require.NoError(t, json.Unmarshal(response.Data, &data))
+		assert.Equal(t, 6, data.Job.SLA.Total)
+		assert.Equal(t, 2, data.Job.SLA.Recoveries)
+		require.NotNil(t, data.Job.SLA.MTTR)
+		assert.InDelta(t, 900, *data.Job.SLA.MTTR, 0.001)
+		assert.Zero(t, data.Maintenance.SLA.Recoveries)
+		assert.Nil(t, data.Maintenance.SLA.MTTR)
+	})
+}
+
+func TestRecoveryMetricsEscaping(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Metrics.HealthGroupLabel = "env"
+	})
+	defer server.Close()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	var job model.Job
+	admin.POST("/api/job", map[string]interface{}{
+		"job_name": "backup", "host": "db1", "automatic_failure_threshold": 3600,
+		"labels": map[string]string{"env": `pr"od\n`},
+	}).ExpectStatus(201).ExpectJSON(&job)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, status := range []string{"failure", "success"} {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": job.ApiKey, "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{
+				"job_name": "backup", "host": "db1", "status": status,
+				"timestamp": now.Add(time.Duration(i-2) * time.Minute).Format(time.RFC3339),
+			}).
+			ExpectStatus(201)
+	}
+
+	// Label values are escaped, in the job's series and in group ones
+	body := admin.GET("/metrics").ExpectStatus(200).BodyString()
+	group := `pr\"od\\n`
+	assert.Contains(t, body, fmt.Sprintf(`cronjob_mttr_seconds{job_name="backup",host="db1",env="%s"} 60`, group))
+	assert.Contains(t, body, fmt.Sprintf(`cronjob_group_mttr_seconds{group="%s"} 60`, group))
+	assert.Contains(t, body, fmt.Sprintf(`cronjob_group_health_score{group="%s"}`, group))
+}
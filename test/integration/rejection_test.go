@@ -0,0 +1,145 @@
+package integration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultRejections(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	submit := func(apiKey string, result map[string]interface{}) string {
+		headers := map[string]string{"Content-Type": "application/json"}
+		if apiKey != "" {
+			headers["X-API-Key"] = apiKey
+		}
+		resp := testutil.NewHTTPClient(t, server.URL()).WithHeaders(headers).POST("/api/job-result", result)
+		resp.Close()
+
+		payload, err := json.Marshal(result)
+		require.NoError(t, err)
+		sum := sha256.Sum256(payload)
+		return hex.EncodeToString(sum[:])
+	}
+
+	backup := map[string]interface{}{"job_name": "backup", "host": "db1", "status": "success"}
+	fingerprints := map[string]string{
+		model.RejectionMissingKey:  submit("", backup),
+		model.RejectionInvalidKey:  submit("cm_wrong_key_123", backup),
+		model.RejectionJobMismatch: submit("cm_test_backup_key", map[string]interface{}{"job_name": "log-rotation", "host": "web1", "status": "success"}),
+	}
+	submit("cm_test_backup_key", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "maybe"})
+	submit("cm_test_backup_key", backup) // Accepted
+
+	req, err := http.NewRequest(http.MethodPost, server.URL()+"/api/job-result", strings.NewReader(`{"job_name": "backup"`))
+	require.NoError(t, err)
+	req.Header.Set("X-API-Key", "cm_test_backup_key")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+
+	t.Run("API", func(t *testing.T) {
+		var page model.RejectionPage
+		admin.GET("/api/result-rejections").ExpectStatus(200).ExpectJSON(&page)
+		require.Len(t, page.Rejections, 5)
+		assert.Empty(t, page.NextCursor)
+
+		invalidJSON := page.Rejections[0]
+		assert.Equal(t, model.RejectionInvalidPayload, invalidJSON.Reason)
+		assert.Contains(t, invalidJSON.Message, "invalid JSON")
+		sum := sha256.Sum256([]byte(`{"job_name": "backup"`))
+		assert.Equal(t, hex.EncodeToString(sum[:]), invalidJSON.Fingerprint)
+		assert.Equal(t, "127.0.0.1", invalidJSON.Source)
+
+		invalidStatus := page.Rejections[1]
+		assert.Equal(t, model.RejectionInvalidPayload, invalidStatus.Reason)
+		assert.Equal(t, "status must be 'success', 'failure' or 'started'", invalidStatus.Message)
+		assert.Equal(t, "backup", invalidStatus.JobName)
+
+		mismatch := page.Rejections[2]
+		assert.Equal(t, model.RejectionJobMismatch, mismatch.Reason)
+		assert.Equal(t, "log-rotation", mismatch.JobName)
+		assert.Equal(t, "web1", mismatch.Host)
+		assert.Equal(t, fingerprints[model.RejectionJobMismatch], mismatch.Fingerprint)
+		assert.Empty(t, mismatch.KeyPrefix, "valid keys are not recorded")
+
+		invalidKey := page.Rejections[3]
+		assert.Equal(t, model.RejectionInvalidKey, invalidKey.Reason)
+		assert.Equal(t, "cm_wron", invalidKey.KeyPrefix)
+		assert.Equal(t, "backup", invalidKey.JobName, "the payload is read for unauthenticated requests")
+		assert.Equal(t, fingerprints[model.RejectionInvalidKey], invalidKey.Fingerprint)
+
+		missingKey := page.Rejections[4]
+		assert.Equal(t, model.RejectionMissingKey, missingKey.Reason)
+		assert.Equal(t, fingerprints[model.RejectionMissingKey], missingKey.Fingerprint)
+		assert.WithinDuration(t, time.Now(), missingKey.CreatedAt, time.Minute)
+
+		// Pagination
+		var first, second model.RejectionPage
+		admin.GET("/api/result-rejections?limit=3").ExpectStatus(200).ExpectJSON(&first)
+		require.Len(t, first.Rejections, 3)
+		require.NotEmpty(t, first.NextCursor)
+		admin.GET("/api/result-rejections?limit=3&cursor=" + first.NextCursor).ExpectStatus(200).ExpectJSON(&second)
+		require.Len(t, second.Rejections, 2)
+		assert.Equal(t, missingKey.ID, second.Rejections[1].ID)
+
+		admin.GET("/api/result-rejections?cursor=bogus").ExpectStatus(400)
+		testutil.NewHTTPClient(t, server.URL()).GET("/api/result-rejections").ExpectStatus(401)
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		body := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, "# TYPE cronmetrics_rejected_results_total counter")
+		assert.Contains(t, body, `cronmetrics_rejected_results_total{reason="invalid_key"} 1`)
+		assert.Contains(t, body, `cronmetrics_rejected_results_total{reason="invalid_payload"} 2`)
+		assert.Contains(t, body, `cronmetrics_rejected_results_total{reason="job_mismatch"} 1`)
+		assert.Contains(t, body, `cronmetrics_rejected_results_total{reason="missing_key"} 1`)
+
+		sharded := testutil.NewHTTPClient(t, server.URL()).GET("/metrics?shard=2/2").ExpectStatus(200).BodyString()
+		assert.NotContains(t, sharded, "cronmetrics_rejected_results_total", "exported by the first shard only")
+	})
+
+	t.Run("Dashboard", func(t *testing.T) {
+		body := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders()).
+			GET("/dashboard/admin/rejections").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, "Rejected Results")
+		assert.Contains(t, body, "job_mismatch")
+		assert.Contains(t, body, "log-rotation@web1")
+		assert.Contains(t, body, "cm_wron")
+		assert.Contains(t, body, fingerprints[model.RejectionInvalidKey][:12])
+	})
+}
+
+func TestIngestedResultRejections(t *testing.T) {
+	recorder, db := newIngestRecorder(t)
+
+	result := &model.JobResult{JobName: "backup", Host: "db1", Status: "success"}
+	require.Error(t, recorder.RecordJobResult("cm_bad_key", result))
+	require.Error(t, recorder.RecordJobResult(ingestAPIKey, &model.JobResult{JobName: "backup", Host: "db2", Status: "success"}))
+	require.NoError(t, recorder.RecordJobResult(ingestAPIKey, result))
+
+	page, err := model.NewRejectionStore(db.DB.GetDB()).ListRejectionsPage("", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Rejections, 2)
+	assert.Equal(t, model.RejectionJobMismatch, page.Rejections[0].Reason)
+	assert.Equal(t, "db2", page.Rejections[0].Host)
+	assert.Equal(t, model.RejectionInvalidKey, page.Rejections[1].Reason)
+	assert.Equal(t, "ingest", page.Rejections[1].Source)
+	assert.Equal(t, "cm_bad_", page.Rejections[1].KeyPrefix)
+	assert.Len(t, page.Rejections[1].Fingerprint, 64)
+}
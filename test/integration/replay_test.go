@@ -0,0 +1,150 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/api"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayProtection(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Security.ReplayProtection = config.ReplayProtectionConfig{Enabled: true, MaxSkew: 60}
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	submit := func(apiKey string, result map[string]interface{}) *testutil.HTTPResponse {
+		return testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": apiKey, "Content-Type": "application/json"}).
+			POST("/api/job-result", result)
+	}
+	result := func(name, host string, timestamp time.Time, nonce string) map[string]interface{} {
+		return map[string]interface{}{"job_name": name, "host": host, "status": "success", "timestamp": timestamp, "nonce": nonce}
+	}
+	now := time.Now().UTC()
+
+	t.Run("RequiresTimestampAndNonce", func(t *testing.T) {
+		submit("cm_test_backup_key", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "success", "nonce": "n-1"}).
+			ExpectStatus(400).
+			ExpectContains("timestamp and nonce are required")
+		submit("cm_test_backup_key", result("backup", "db1", now, "")).
+			ExpectStatus(400).
+			ExpectContains("timestamp and nonce are required")
+	})
+
+	t.Run("Skew", func(t *testing.T) {
+		submit("cm_test_backup_key", result("backup", "db1", now.Add(-2*time.Minute), "n-old")).
+			ExpectStatus(400).
+			ExpectContains("timestamp must be within 1m0s of the server time")
+		submit("cm_test_backup_key", result("backup", "db1", now.Add(2*time.Minute), "n-future")).
+			ExpectStatus(400)
+		submit("cm_test_backup_key", result("backup", "db1", now.Add(-30*time.Second), "n-old")).
+			ExpectStatus(201)
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		captured := result("backup", "db1", now, "n-2")
+		submit("cm_test_backup_key", captured).ExpectStatus(201)
+		submit("cm_test_backup_key", captured).
+			ExpectStatus(409).
+			ExpectContains("nonce has already been used")
+
+		// Nonces are per job
+		submit("cm_test_logrotation_key", result("log-rotation", "web1", now, "n-2")).ExpectStatus(201)
+	})
+
+	t.Run("Rejections", func(t *testing.T) {
+		var page model.RejectionPage
+		testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders()).
+			GET("/api/result-rejections").ExpectStatus(200).ExpectJSON(&page)
+		reasons := map[string]int{}
+		for _, rejection := range page.Rejections {
+			reasons[rejection.Reason]++
+		}
+		assert.Equal(t, map[string]int{model.RejectionInvalidPayload: 2, model.RejectionStaleTimestamp: 2, model.RejectionReplay: 1}, reasons)
+
+		body := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, `cronmetrics_rejected_results_total{reason="replay"} 1`)
+		assert.Contains(t, body, `cronmetrics_rejected_results_total{reason="stale_timestamp"} 2`)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		server := testutil.NewTestServer(t)
+		defer server.Close()
+		server.SeedTestData()
+
+		client := testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json"})
+		old := result("backup", "db1", now.Add(-time.Hour), "n-1")
+		client.POST("/api/job-result", old).ExpectStatus(201)
+		client.POST("/api/job-result", old).ExpectStatus(201)
+	})
+}
+
+func TestReplayProtectionIngestion(t *testing.T) {
+	db := testutil.NewInMemoryTestDatabase(t)
+	jobStore := db.GetJobStore()
+	require.NoError(t, jobStore.CreateJob(&model.Job{Name: "backup", Host: "db1", ApiKey: ingestAPIKey, AutomaticFailureThreshold: 3600, Status: "active"}))
+
+	cfg := &config.Config{Security: config.SecurityConfig{ReplayProtection: config.ReplayProtectionConfig{Enabled: true, MaxSkew: 60}}}
+	server := api.NewServer(cfg, jobStore, db.GetJobResultStore(), nil)
+
+	queued := &model.JobResult{JobName: "backup", Host: "db1", Status: "success", Timestamp: time.Now().UTC(), Nonce: "n-1"}
+	require.NoError(t, server.RecordJobResult(ingestAPIKey, queued))
+	err := server.RecordJobResult(ingestAPIKey, queued)
+	var resultErr *api.ResultError
+	require.ErrorAs(t, err, &resultErr)
+	assert.Equal(t, model.RejectionReplay, resultErr.Reason)
+	assert.False(t, resultErr.Temporary(), "replays are not redelivered")
+
+	// Results read from the system log carry no nonce and host timestamps
+	observed := &model.JobResult{JobName: "backup", Host: "db1", Status: "success", Timestamp: time.Now().Add(-time.Hour)}
+	require.NoError(t, server.ObservedResultRecorder().RecordJobResult(ingestAPIKey, observed))
+	assert.Equal(t, 2, db.CountJobResults())
+}
+
+func TestNonceExpiry(t *testing.T) {
+	db := testutil.NewInMemoryTestDatabase(t)
+	nonces := model.NewNonceStore(db.DB.GetDB())
+
+	fresh, err := nonces.UseNonce("backup", "db1", "n-1", time.Now().Add(-time.Second))
+	require.NoError(t, err)
+	assert.True(t, fresh)
+
+	// The first nonce expired, so it was pruned before being used again
+	fresh, err = nonces.UseNonce("backup", "db1", "n-1", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, fresh)
+
+	fresh, err = nonces.UseNonce("backup", "db1", "n-1", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, fresh)
+
+	require.NoError(t, nonces.ReleaseNonce("backup", "db1", "n-1"))
+	fresh, err = nonces.UseNonce("backup", "db1", "n-1", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, fresh)
+}
+
+func TestReplayProtectionConfig(t *testing.T) {
+	buildBinary(t)
+
+	cliTest := testutil.NewCLITest(t)
+	cliTest.CreateDefaultTestConfig()
+
+	cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render",
+		"--set", "security.replay_protection.enabled=true").
+		ExpectSuccess().
+		ExpectStdoutContains("max_skew: 300")
+
+	cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render",
+		"--set", "security.replay_protection.enabled=true", "--set", "security.replay_protection.max_skew=0").
+		ExpectFailure().
+		ExpectStderrContains("security.replay_protection.max_skew must be positive")
+}
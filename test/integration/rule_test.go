@@ -0,0 +1,134 @@
+package integration
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusRules(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	job := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"})
+	submit := func(result map[string]interface{}) {
+		result["job_name"], result["host"] = "backup", "db1"
+		job.POST("/api/job-result", result).ExpectStatus(201)
+	}
+
+	var rowsRule model.StatusRule
+	admin.POST("/api/job/1/rules", map[string]interface{}{
+		"pattern": `^0 rows copied`, "status": "failure", "description": "empty exports are failures", "author": "alice",
+	}).ExpectStatus(201).ExpectJSON(&rowsRule)
+	assert.Equal(t, "alice", rowsRule.Author)
+	var exitRule model.StatusRule
+	admin.POST("/api/job/1/rules", map[string]interface{}{"exit_code": 3, "status": "success"}).
+		ExpectStatus(201).ExpectJSON(&exitRule)
+
+	var rules []model.StatusRule
+	admin.GET("/api/job/1/rules").ExpectStatus(200).ExpectJSON(&rules)
+	require.Len(t, rules, 2)
+	assert.Equal(t, rowsRule.ID, rules[0].ID, "in the order they apply")
+
+	submit(map[string]interface{}{"status": "success", "output": "0 rows copied"})
+	submit(map[string]interface{}{"status": "failure", "output": "partial", "exit_code": 3})
+	submit(map[string]interface{}{"status": "success", "output": "10 rows copied", "exit_code": 0})
+	// Clients cannot pretend a rule applied
+	submit(map[string]interface{}{"status": "failure", "reported_status": "success", "status_rule_id": 42})
+
+	var page model.JobResultPage
+	admin.GET("/api/job/1/results").ExpectStatus(200).ExpectJSON(&page)
+	require.Len(t, page.Results, 4)
+	forged, unmatched, exited, rows := page.Results[0], page.Results[1], page.Results[2], page.Results[3]
+
+	assert.Equal(t, "failure", rows.Status)
+	assert.Equal(t, "success", rows.ReportedStatus)
+	assert.Equal(t, rowsRule.ID, rows.StatusRuleID)
+
+	assert.Equal(t, "success", exited.Status)
+	assert.Equal(t, "failure", exited.ReportedStatus)
+	assert.Equal(t, exitRule.ID, exited.StatusRuleID)
+	require.NotNil(t, exited.ExitCode)
+	assert.Equal(t, 3, *exited.ExitCode)
+
+	assert.Equal(t, "success", unmatched.Status)
+	assert.Empty(t, unmatched.ReportedStatus)
+	require.NotNil(t, unmatched.ExitCode)
+	assert.Zero(t, *unmatched.ExitCode)
+
+	assert.Equal(t, "failure", forged.Status)
+	assert.Empty(t, forged.ReportedStatus)
+	assert.Zero(t, forged.StatusRuleID)
+
+	t.Run("Ping", func(t *testing.T) {
+		testutil.NewHTTPClient(t, server.URL()).
+			GET("/api/ping?key=cm_test_backup_key&exit_code=3&status=failure").
+			ExpectStatus(200)
+
+		var page model.JobResultPage
+		admin.GET("/api/job/1/results?limit=1").ExpectStatus(200).ExpectJSON(&page)
+		require.Len(t, page.Results, 1)
+		assert.Equal(t, "success", page.Results[0].Status)
+		assert.Equal(t, exitRule.ID, page.Results[0].StatusRuleID)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		admin.POST("/api/job/1/rules", map[string]interface{}{"status": "failure"}).
+			ExpectStatus(400).
+			ExpectContains("a pattern or an exit code is required")
+		admin.POST("/api/job/1/rules", map[string]interface{}{"pattern": "(", "status": "failure"}).
+			ExpectStatus(400).
+			ExpectContains("invalid pattern")
+		admin.POST("/api/job/1/rules", map[string]interface{}{"pattern": "x", "status": "lost"}).
+			ExpectStatus(400).
+			ExpectContains("status must be")
+		admin.POST("/api/job/999/rules", map[string]interface{}{"pattern": "x", "status": "failure"}).ExpectStatus(404)
+		admin.PUT("/api/job/1/rules", map[string]interface{}{"pattern": "x", "status": "failure"}).ExpectStatus(405)
+		testutil.NewHTTPClient(t, server.URL()).GET("/api/job/1/rules").ExpectStatus(401)
+	})
+
+	t.Run("Dashboard", func(t *testing.T) {
+		dashboard := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders()).WithCookies()
+		dashboard.PostForm("/dashboard/jobs/1/rules", url.Values{"pattern": {"WARN"}, "status": {"failure"}}).
+			ExpectStatus(200).
+			ExpectContains("Status rule added")
+		dashboard.PostForm("/dashboard/jobs/1/rules", url.Values{"status": {"failure"}}).
+			ExpectStatus(200).
+			ExpectContains("a pattern or an exit code is required")
+
+		body := dashboard.GET("/dashboard/jobs/1").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, "Status Rules")
+		assert.Contains(t, body, "<code>^0 rows copied</code>")
+		assert.Contains(t, body, fmt.Sprintf("Reported as success, overridden by status rule %d", rowsRule.ID))
+
+		admin.GET("/api/job/1/rules").ExpectStatus(200).ExpectJSON(&rules)
+		require.Len(t, rules, 3)
+		dashboard.PostForm(fmt.Sprintf("/dashboard/jobs/1/rules/%d/delete", rules[2].ID), url.Values{}).
+			ExpectStatus(200).
+			ExpectContains("Status rule deleted")
+
+		dashboard.GET("/dashboard/admin/audit").
+			ExpectStatus(200).
+			ExpectContains("job.rule.create").
+			ExpectContains("job.rule.delete")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		admin.DELETE(fmt.Sprintf("/api/job/1/rules/%d", rowsRule.ID)).ExpectStatus(204)
+		admin.DELETE(fmt.Sprintf("/api/job/1/rules/%d", rowsRule.ID)).ExpectStatus(404)
+		admin.DELETE("/api/job/1/rules/abc").ExpectStatus(400)
+
+		submit(map[string]interface{}{"status": "success", "output": "0 rows copied"})
+		var page model.JobResultPage
+		admin.GET("/api/job/1/results?limit=1").ExpectStatus(200).ExpectJSON(&page)
+		assert.Equal(t, "success", page.Results[0].Status)
+	})
+}
@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/ingest"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunGrouping(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	submit := func(status, runID string, at time.Time, duration int) *testutil.HTTPResponse {
+		return testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{
+				"job_name": "backup", "host": "db1", "status": status, "run_id": runID,
+				"timestamp": at.UTC().Format(time.RFC3339), "duration": duration,
+			})
+	}
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// A run starts, fails after a minute and succeeds on its retry
+	submit("started", "nightly-1", now.Add(-10*time.Minute), 0).ExpectStatus(201)
+	submit("failure", "nightly-1", now.Add(-9*time.Minute), 60).ExpectStatus(201)
+	submit("success", "nightly-1", now.Add(-2*time.Minute), 30).ExpectStatus(201)
+
+	var page model.JobResultPage
+	admin.GET("/api/job/1/results?run_id=nightly-1").ExpectStatus(200).ExpectJSON(&page)
+	require.Len(t, page.Results, 2, "starts are not results")
+	assert.Equal(t, "success", page.Results[0].Status)
+	assert.Equal(t, "nightly-1", page.Results[0].RunID)
+	assert.Equal(t, 480, page.Results[0].Duration, "the retry's duration covers the whole run")
+	assert.Equal(t, 60, page.Results[1].Duration)
+
+	body := admin.GET("/metrics").ExpectStatus(200).BodyString()
+	assert.Contains(t, body, "# TYPE cronjob_last_duration_seconds gauge")
+	assert.Contains(t, body, `cronjob_last_duration_seconds{job_name="backup",host="db1",env="prod",type="backup"} 480`)
+	assert.Contains(t, body, `cronjob_duration_seconds_sum{job_name="backup",host="db1",env="prod",type="backup"} 540`)
+
+	t.Run("OtherRuns", func(t *testing.T) {
+		// Results of other runs, or of none, keep their own duration
+		submit("success", "nightly-2", now.Add(-time.Minute), 45).ExpectStatus(201)
+		submit("success", "", now, 20).ExpectStatus(201)
+
+		results, err := server.Database.GetJobResultStore().GetJobResults("backup", "db1", 2)
+		require.NoError(t, err)
+		assert.Equal(t, 20, results[0].Duration)
+		assert.Empty(t, results[0].RunID)
+		assert.Equal(t, 45, results[1].Duration)
+	})
+
+	t.Run("LostRun", func(t *testing.T) {
+		// A lost run keeps the ID of its start
+		submit("started", "nightly-3", now, 0).ExpectStatus(201)
+		detector := ingest.NewLostRunDetector(&server.Config.LostRuns, server.Database.GetJobResultStore())
+		lost, err := detector.Check(context.Background(), now.Add(48*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, lost, 1)
+		assert.Equal(t, "nightly-3", lost[0].RunID)
+	})
+
+	t.Run("Dashboard", func(t *testing.T) {
+		testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders()).WithCookies().
+			GET("/dashboard/jobs/1").
+			ExpectStatus(200).
+			ExpectContains(`title="Run nightly-1"`)
+	})
+
+	t.Run("TooLong", func(t *testing.T) {
+		submit("success", strings.Repeat("r", 129), now, 0).
+			ExpectStatus(400).
+			ExpectContains("run_id must be at most 128 characters")
+	})
+}
@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/metrics"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobSchedules(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	jobStore := server.Database.GetJobStore()
+	now := time.Now().UTC().Truncate(time.Minute)
+
+	create := func(name, schedule string, lastReport time.Time) *model.Job {
+		var job model.Job
+		admin.POST("/api/job", map[string]interface{}{
+			"job_name": name, "host": "db1", "automatic_failure_threshold": 3600, "schedule": schedule,
+		}).ExpectStatus(201).ExpectJSON(&job)
+		require.NoError(t, jobStore.UpdateJobLastReported(name, "db1", lastReport))
+		return &job
+	}
+	check := func(job *model.Job) string {
+		var check metrics.JobCheck
+		admin.POST(fmt.Sprintf("/api/job/%d/check", job.ID), nil).ExpectStatus(200).ExpectJSON(&check)
+		return check.Reason
+	}
+
+	// A weekly job last run four days ago is not expected again for two days
+	nextRun := now.AddDate(0, 0, 2)
+	weekly := create("weekly", fmt.Sprintf("%d %d * * %d", nextRun.Minute(), nextRun.Hour(), nextRun.Weekday()), now.AddDate(0, 0, -4))
+	assert.NotEmpty(t, weekly.Schedule)
+	assert.Equal(t, "success", check(weekly))
+
+	// A daily job whose run two hours ago never reported missed it
+	missedRun := now.Add(-2 * time.Hour)
+	daily := create("daily", fmt.Sprintf("%d %d * * *", missedRun.Minute(), missedRun.Hour()), now.Add(-3*time.Hour))
+	assert.Equal(t, "missed_deadline", check(daily))
+
+	// Without a schedule, the threshold counts from the last report
+	unscheduled := create("unscheduled", "", now.AddDate(0, 0, -4))
+	assert.Equal(t, "missed_deadline", check(unscheduled))
+
+	body := admin.GET("/metrics").ExpectStatus(200).BodyString()
+	assert.Contains(t, body, "# TYPE cronjob_deadline_timestamp gauge")
+	assert.Contains(t, body, fmt.Sprintf(`cronjob_deadline_timestamp{job_name="weekly",host="db1"} %d`, nextRun.Add(time.Hour).Unix()))
+	assert.Contains(t, body, fmt.Sprintf(`cronjob_deadline_timestamp{job_name="daily",host="db1"} %d`, missedRun.Add(time.Hour).Unix()))
+	assert.Contains(t, body, `cronjob_status{job_name="weekly",host="db1"} 1`)
+	assert.Contains(t, body, `cronjob_status{job_name="daily",host="db1"} -2`)
+	assert.Contains(t, body, `cronjob_schedule_interval_seconds{job_name="weekly",host="db1"} 604800`)
+	assert.Contains(t, body, `cronjob_schedule_interval_seconds{job_name="daily",host="db1"} 86400`)
+	assert.NotContains(t, body, `cronjob_schedule_interval_seconds{job_name="unscheduled"`)
+
+	t.Run("Update", func(t *testing.T) {
+		var updated model.Job
+		admin.PUT(fmt.Sprintf("/api/job/%d", unscheduled.ID), map[string]interface{}{"schedule": "@monthly"}).
+			ExpectStatus(200).ExpectJSON(&updated)
+		assert.Equal(t, "@monthly", updated.Schedule)
+
+		versions := admin.GET(fmt.Sprintf("/api/job/%d/versions", unscheduled.ID)).ExpectStatus(200).BodyString()
+		assert.Contains(t, versions, `"field":"schedule"`)
+
+		// An omitted schedule is kept, an empty or null one clears it
+		admin.PUT(fmt.Sprintf("/api/job/%d", unscheduled.ID), map[string]interface{}{"description": "monthly report"}).
+			ExpectStatus(200)
+		job, err := jobStore.GetJobByID(unscheduled.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "@monthly", job.Schedule)
+
+		var cleared model.Job
+		admin.PUT(fmt.Sprintf("/api/job/%d", unscheduled.ID), map[string]interface{}{"schedule": ""}).
+			ExpectStatus(200).ExpectJSON(&cleared)
+		assert.Empty(t, cleared.Schedule)
+		job, err = jobStore.GetJobByID(unscheduled.ID)
+		require.NoError(t, err)
+		assert.Empty(t, job.Schedule)
+
+		admin.PUT(fmt.Sprintf("/api/job/%d", unscheduled.ID), map[string]interface{}{"schedule": "@daily"}).ExpectStatus(200)
+		admin.PUT(fmt.Sprintf("/api/job/%d", unscheduled.ID), map[string]interface{}{"schedule": nil}).ExpectStatus(200)
+		job, err = jobStore.GetJobByID(unscheduled.ID)
+		require.NoError(t, err)
+		assert.Empty(t, job.Schedule)
+
+		admin.PUT(fmt.Sprintf("/api/job/%d", unscheduled.ID), map[string]interface{}{"schedule": 5}).ExpectStatus(400)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		admin.POST("/api/job", map[string]interface{}{"job_name": "x", "host": "y", "schedule": "0 3 * *"}).
+			ExpectStatus(400).
+			ExpectContains("must be a crontab macro or have 5 fields")
+		admin.PUT(fmt.Sprintf("/api/job/%d", weekly.ID), map[string]interface{}{"schedule": "0 0 30 2 *"}).
+			ExpectStatus(400).
+			ExpectContains("never runs")
+	})
+
+	t.Run("Dashboard", func(t *testing.T) {
+		dashboard := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders()).WithCookies()
+		dashboard.GET(fmt.Sprintf("/dashboard/jobs/%d", daily.ID)).
+			ExpectStatus(200).
+			ExpectContains(daily.Schedule).
+			ExpectContains("Deadline:")
+
+		dashboard.PostForm(fmt.Sprintf("/dashboard/jobs/%d", weekly.ID), url.Values{
+			"name": {"weekly"}, "host": {"db1"}, "schedule": {"every monday"},
+		}).ExpectStatus(400).ExpectContains("Schedule must be a crontab schedule")
+
+		dashboard.PostForm(fmt.Sprintf("/dashboard/jobs/%d", weekly.ID), url.Values{
+			"name": {"weekly"}, "host": {"db1"}, "schedule": {"@weekly"},
+		})
+		job, err := jobStore.GetJobByID(weekly.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "@weekly", job.Schedule)
+	})
+}
@@ -0,0 +1,118 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeScript writes a Starlark script to a temporary file and returns its
+// path
+func writeScript(t *testing.T, name, src string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(src), 0o644))
+	return path
+}
+
+func TestResultScripts(t *testing.T) {
+	warnings := writeScript(t, "warnings.star", `
+def process(result):
+    if result["status"] == "success" and "WARN" in result["output"]:
+        result["status"] = "failure"
+    result["labels"]["env"] = result["job_labels"]["env"]
+`)
+	others := writeScript(t, "others.star", `
+def process(result):
+    result["status"] = "failure"
+`)
+
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Scripts.Assignments = []config.ScriptAssignment{
+			{Path: warnings, Labels: map[string]string{"env": "prod"}},
+			{Path: others, JobName: "log-rotation"},
+		}
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	job := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"})
+	job.POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "success", "output": "WARN: 3 tables skipped"}).
+		ExpectStatus(201)
+	job.POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "success", "output": "done"}).
+		ExpectStatus(201)
+
+	var page model.JobResultPage
+	testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders()).
+		GET("/api/job/1/results").ExpectStatus(200).ExpectJSON(&page)
+	require.Len(t, page.Results, 2)
+	assert.Equal(t, "success", page.Results[0].Status)
+	assert.Equal(t, "failure", page.Results[1].Status, "reclassified by the script")
+	assert.Equal(t, "WARN: 3 tables skipped", page.Results[1].Output)
+	assert.Equal(t, map[string]string{"env": "prod"}, page.Results[1].Labels)
+}
+
+func TestResultScriptLimits(t *testing.T) {
+	scripts := map[string]string{
+		"steps": `
+def process(result):
+    for i in range(10000000):
+        result["status"] = "failure"
+`,
+		"memory": `
+def process(result):
+    for i in range(1000):
+        result["output"] = "x" * (1 << 20)
+    result["status"] = "failure"
+`,
+		"invalid": `
+def process(result):
+    result["status"] = "maybe"
+`,
+	}
+
+	for name, src := range scripts {
+		t.Run(name, func(t *testing.T) {
+			path := writeScript(t, name+".star", src)
+			server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+				cfg.Scripts.Assignments = []config.ScriptAssignment{{Path: path}}
+			})
+			defer server.Close()
+			server.SeedTestData()
+
+			submission := testutil.NewHTTPClient(t, server.URL()).
+				WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"}).
+				POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "success", "output": "done"})
+
+			var page model.JobResultPage
+			results := func() []*model.JobResult {
+				testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders()).
+					GET("/api/job/1/results").ExpectStatus(200).ExpectJSON(&page)
+				return page.Results
+			}
+
+			if name == "invalid" {
+				// A failing script leaves the result as it was reported
+				submission.ExpectStatus(201)
+				require.Len(t, results(), 1)
+				assert.Equal(t, "success", page.Results[0].Status)
+				assert.Equal(t, "done", page.Results[0].Output)
+				return
+			}
+
+			// A script stopped at a limit refuses the result, to be
+			// submitted again
+			submission.ExpectStatus(503).ExpectContains("limits")
+			assert.Empty(t, results())
+			testutil.NewHTTPClient(t, server.URL()).GET("/metrics").
+				ExpectStatus(200).
+				ExpectContains(`cronmetrics_rejected_results_total{reason="script_limit"} 1`)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/metrics"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedRuns(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	now := time.Now().UTC().Truncate(time.Second)
+
+	create := func(name, policy string) *model.Job {
+		var job model.Job
+		admin.POST("/api/job", map[string]interface{}{
+			"job_name": name, "host": "batch1", "automatic_failure_threshold": 3600, "shard_policy": policy,
+		}).ExpectStatus(201).ExpectJSON(&job)
+		return &job
+	}
+	submit := func(job *model.Job, result map[string]interface{}) *testutil.HTTPResponse {
+		result["job_name"], result["host"] = job.Name, job.Host
+		result["timestamp"] = now.Format(time.RFC3339)
+		return testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": job.ApiKey, "Content-Type": "application/json"}).
+			POST("/api/job-result", result)
+	}
+	shard := func(job *model.Job, runID string, index, shards int, status string) {
+		submit(job, map[string]interface{}{
+			"status": status, "run_id": runID, "shard": index, "shards": shards, "duration": 10 + index,
+		}).ExpectStatus(201)
+	}
+	check := func(job *model.Job) string {
+		var check metrics.JobCheck
+		admin.POST(fmt.Sprintf("/api/job/%d/check", job.ID), nil).ExpectStatus(200).ExpectJSON(&check)
+		return check.Reason
+	}
+
+	all := create("reindex", model.ShardPolicyAll)
+	assert.Equal(t, model.ShardPolicyAll, all.ShardPolicy)
+
+	// The latest result of a shard counts, so a retried shard recovers
+	shard(all, "run-1", 0, 3, "success")
+	shard(all, "run-1", 1, 3, "failure")
+	assert.Equal(t, "failure", check(all))
+	shard(all, "run-1", 1, 3, "success")
+	shard(all, "run-1", 2, 3, "success")
+	assert.Equal(t, "success", check(all))
+
+	// A run whose shards are still reporting keeps the previous verdict,
+	// until a failed shard decides it
+	shard(all, "run-2", 0, 3, "success")
+	assert.Equal(t, "success", check(all))
+	shard(all, "run-2", 2, 3, "failure")
+	assert.Equal(t, "failure", check(all))
+
+	runs, err := server.Database.GetJobResultStore().GetShardRuns(all.Name, all.Host, 5)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "run-2", runs[0].RunID)
+	assert.Equal(t, []int{1}, runs[0].Missing())
+	require.Len(t, runs[1].Results, 3)
+	assert.Equal(t, 11, runs[1].Results[1].Duration, "a shard keeps its own duration")
+
+	t.Run("Any", func(t *testing.T) {
+		anyJob := create("crawl", model.ShardPolicyAny)
+		shard(anyJob, "run-1", 0, 2, "failure")
+		assert.Equal(t, "success", check(anyJob), "undecided while a shard may still succeed")
+		shard(anyJob, "run-1", 1, 2, "failure")
+		assert.Equal(t, "failure", check(anyJob))
+
+		shard(anyJob, "run-2", 1, 2, "success")
+		assert.Equal(t, "success", check(anyJob))
+
+		body := admin.GET("/metrics").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, `cronjob_status{job_name="crawl",host="batch1"} 1`)
+		assert.Contains(t, body, `cronjob_status{job_name="reindex",host="batch1"} 0`)
+	})
+
+	t.Run("Dashboard", func(t *testing.T) {
+		body := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders()).WithCookies().
+			GET(fmt.Sprintf("/dashboard/jobs/%d", all.ID)).
+			ExpectStatus(200).
+			ExpectContains(`id="shards"`).
+			ExpectContains("Run <code>run-2</code>").
+			ExpectContains("2 of 3 shards reported").
+			BodyString()
+		assert.Contains(t, body, `class="shard-missing"`)
+		assert.Contains(t, body, "all shards must succeed")
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		submit(all, map[string]interface{}{"status": "success", "shard": 0}).
+			ExpectStatus(400).
+			ExpectContains("shard requires run_id")
+		submit(all, map[string]interface{}{"status": "success", "run_id": "run-3", "shard": 3, "shards": 3}).
+			ExpectStatus(400).
+			ExpectContains("shard must be between 0 and shards - 1")
+		submit(all, map[string]interface{}{"status": "success", "run_id": "run-3", "shards": 3}).
+			ExpectStatus(400).
+			ExpectContains("shards requires shard")
+		admin.POST("/api/job", map[string]interface{}{"job_name": "x", "host": "y", "shard_policy": "most"}).
+			ExpectStatus(400).
+			ExpectContains("shard_policy must be 'all' or 'any'")
+	})
+}
@@ -0,0 +1,87 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignedJobResults(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	post := func(headers map[string]string, body []byte) int {
+		req, err := http.NewRequest(http.MethodPost, server.URL()+"/api/job-result", bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	payload := func(name, host string) []byte {
+		body, err := json.Marshal(map[string]interface{}{"job_name": name, "host": host, "status": "success"})
+		require.NoError(t, err)
+		return body
+	}
+	signed := func(apiKey string, body []byte) map[string]string {
+		return map[string]string{util.SignatureHeader: util.SignPayload(apiKey, body)}
+	}
+
+	t.Run("Accepted", func(t *testing.T) {
+		body := payload("backup", "db1")
+		assert.Equal(t, http.StatusCreated, post(signed("cm_test_backup_key", body), body))
+
+		results, err := server.Database.GetJobResultStore().GetJobResults("backup", "db1", 10)
+		require.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		body := payload("backup", "db1")
+		assert.Equal(t, http.StatusUnauthorized, post(signed("cm_test_logrotation_key", body), body), "another job's key")
+		assert.Equal(t, http.StatusUnauthorized, post(signed("cm_test_backup_key", body), payload("backup", "db2")), "modified body")
+		unknown := payload("restore", "db1")
+		assert.Equal(t, http.StatusUnauthorized, post(signed("cm_test_backup_key", unknown), unknown), "unknown job")
+		assert.Equal(t, http.StatusUnauthorized, post(map[string]string{util.SignatureHeader: "sha256=deadbeef"}, body), "malformed signature")
+
+		var page model.RejectionPage
+		testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders()).
+			GET("/api/result-rejections").ExpectStatus(200).ExpectJSON(&page)
+		require.Len(t, page.Rejections, 4)
+		for _, rejection := range page.Rejections {
+			assert.Equal(t, model.RejectionBadSignature, rejection.Reason)
+			assert.Equal(t, "invalid signature", rejection.Message)
+			assert.Empty(t, rejection.KeyPrefix)
+		}
+		assert.Equal(t, "restore", page.Rejections[1].JobName, "the payload is still audited")
+
+		metrics := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+		assert.Contains(t, metrics, `cronmetrics_rejected_results_total{reason="bad_signature"} 4`)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		// Signed bodies are capped before they are authenticated, like
+		// those sent with a key
+		body := append(payload("backup", "db1"), bytes.Repeat([]byte(" "), 2<<20)...)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, post(signed("cm_test_backup_key", body), body))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, post(map[string]string{"X-API-Key": "cm_test_backup_key"}, body))
+	})
+
+	t.Run("KeyTakesPrecedence", func(t *testing.T) {
+		body := payload("backup", "db1")
+		headers := map[string]string{"X-API-Key": "cm_test_backup_key", util.SignatureHeader: "sha256=deadbeef"}
+		assert.Equal(t, http.StatusCreated, post(headers, body), "the signature is ignored when the key is sent")
+	})
+}
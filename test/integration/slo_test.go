@@ -0,0 +1,146 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/slo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSLOErrorBudget(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Dashboard = config.DashboardConfig{
+			Enabled:         true,
+			Path:            "/dashboard",
+			Title:           "Test Dashboard",
+			RefreshInterval: 5,
+			AuthRequired:    true,
+			PageSize:        25,
+			SSEHeartbeat:    30,
+		}
+		cfg.SLO = config.SLOConfig{
+			BurnWindow:      3600,
+			BurnRateWarning: 1.5,
+			Interval:        60,
+			Objectives: []config.SLOObjective{
+				{Name: "prod", Labels: map[string]string{"env": "prod"}, Target: 0.5, WindowDays: 30},
+				{Name: "rotation", JobName: "log-rotation", Host: "web1", Target: 0.9, WindowDays: 7},
+			},
+		}
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	submit := func(status string) {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": status}).
+			ExpectStatus(201)
+	}
+	metrics := func() string {
+		return testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+	}
+	dashboard := func() string {
+		return testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders()).
+			GET("/dashboard/jobs").ExpectStatus(200).BodyString()
+	}
+
+	var events []model.ChangeEvent
+	tracker := slo.NewTracker(&server.Config.SLO, server.Database.GetJobStore(), server.Database.GetJobResultStore())
+	check := func() {
+		// A done context makes Run check the objectives once
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		tracker.Run(ctx, func(event model.ChangeEvent) { events = append(events, event) })
+	}
+
+	t.Run("WithinBudget", func(t *testing.T) {
+		submit("success")
+		submit("success")
+		submit("success")
+		submit("failure")
+
+		body := metrics()
+		assert.Contains(t, body, "# TYPE cronjob_slo_error_budget_remaining gauge")
+		assert.Contains(t, body, `cronjob_slo_error_budget_remaining{slo="prod"} 0.5`)
+		assert.Contains(t, body, `cronjob_slo_burn_rate{slo="prod"} 0.5`)
+		assert.Contains(t, body, `cronjob_slo_error_budget_remaining{slo="rotation"} 1`, "no results spend no budget")
+		assert.Contains(t, body, `cronjob_slo_burn_rate{slo="rotation"} 0`)
+
+		assert.NotContains(t, dashboard(), "slo-warnings")
+		check()
+		assert.Empty(t, events)
+	})
+
+	t.Run("Burning", func(t *testing.T) {
+		submit("failure")
+		submit("failure")
+		submit("failure")
+		submit("failure")
+
+		// 5 of 8 results failed against an allowed half
+		body := metrics()
+		assert.Contains(t, body, `cronjob_slo_error_budget_remaining{slo="prod"} -0.25`)
+		assert.Contains(t, body, `cronjob_slo_burn_rate{slo="prod"} 1.25`)
+
+		page := dashboard()
+		assert.Contains(t, page, "slo-warnings")
+		assert.Contains(t, page, "<strong>prod</strong> is burning its error budget")
+		assert.NotContains(t, page, "<strong>rotation</strong>")
+
+		check()
+		require.Len(t, events, 1)
+		assert.Equal(t, model.EventSLOBurning, events[0].Type)
+		require.NotNil(t, events[0].SLO)
+		assert.Equal(t, "prod", events[0].SLO.Name)
+		assert.Equal(t, 8, events[0].SLO.Total)
+		assert.Equal(t, 5, events[0].SLO.Failed)
+
+		check()
+		assert.Len(t, events, 1, "only changes are reported")
+	})
+
+	t.Run("MTTR", func(t *testing.T) {
+		tracker := slo.NewTracker(&server.Config.SLO, server.Database.GetJobStore(), server.Database.GetJobResultStore())
+		statuses, err := tracker.Evaluate(context.Background(), time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, statuses[0].Recoveries)
+		assert.Nil(t, statuses[0].MTTR, "the failures are still open")
+
+		submit("success")
+		tracker = slo.NewTracker(&server.Config.SLO, server.Database.GetJobStore(), server.Database.GetJobResultStore())
+		statuses, err = tracker.Evaluate(context.Background(), time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, statuses[0].Recoveries)
+		require.NotNil(t, statuses[0].MTTR)
+		assert.Nil(t, statuses[1].MTTR)
+	})
+}
+
+func TestSLOConfig(t *testing.T) {
+	buildBinary(t)
+
+	cliTest := testutil.NewCLITest(t)
+	cliTest.CreateDefaultTestConfig()
+
+	cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render",
+		"--set", `slo.objectives=[{name: prod, labels: {env: prod}, target: 0.99, window_days: 30}]`).
+		ExpectSuccess().
+		ExpectStdoutContains("burn_rate_warning: 14.4")
+
+	cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render",
+		"--set", `slo.objectives=[{name: prod, target: 99, window_days: 30}]`).
+		ExpectFailure().
+		ExpectStderrContains("target must be between 0 and 1")
+
+	cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render",
+		"--set", `slo.objectives=[{name: "prod env", target: 0.99, window_days: 30}]`).
+		ExpectFailure().
+		ExpectStderrContains("must be non-empty and contain only letters")
+}
@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobSnooze(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	submit := func(status string) {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": status}).
+			ExpectStatus(201)
+	}
+	metrics := func() string {
+		return testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+	}
+	const backupStatus = `cronjob_status{job_name="backup",host="db1",env="prod",type="backup"} `
+
+	submit("failure")
+	assert.Contains(t, metrics(), backupStatus+"0")
+
+	t.Run("Snooze", func(t *testing.T) {
+		var job model.Job
+		admin.POST("/api/job/1/snooze", map[string]string{"for": "2h"}).ExpectStatus(200).ExpectJSON(&job)
+		require.NotNil(t, job.SnoozedUntil)
+		assert.WithinDuration(t, time.Now().Add(2*time.Hour), *job.SnoozedUntil, time.Minute)
+
+		body := metrics()
+		assert.Contains(t, body, backupStatus+"-3")
+		assert.Contains(t, body, `cronjob_snoozed_until_timestamp{job_name="backup",host="db1"} `)
+
+		// Results are still recorded; successes are reported as usual
+		submit("success")
+		assert.Contains(t, metrics(), backupStatus+"1")
+		submit("failure")
+		assert.Contains(t, metrics(), backupStatus+"-3")
+
+		results, err := server.Database.GetJobResultStore().GetJobResults("backup", "db1", 10)
+		require.NoError(t, err)
+		assert.Len(t, results, 3)
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		var job model.Job
+		admin.DELETE("/api/job/1/snooze").ExpectStatus(200).ExpectJSON(&job)
+		assert.Nil(t, job.SnoozedUntil)
+
+		body := metrics()
+		assert.Contains(t, body, backupStatus+"0")
+		assert.NotContains(t, body, `cronjob_snoozed_until_timestamp{job_name="backup"`)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		past := time.Now().Add(-time.Minute)
+		_, err := server.Database.GetJobStore().SnoozeJobByID(1, &past)
+		require.NoError(t, err)
+		assert.Contains(t, metrics(), backupStatus+"0")
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		admin.POST("/api/job/1/snooze", map[string]string{"for": "soon"}).ExpectStatus(400)
+		admin.POST("/api/job/1/snooze", map[string]string{"for": "-1h"}).ExpectStatus(400)
+		admin.POST("/api/job/1/snooze", map[string]string{"for": "721h"}).
+			ExpectStatus(400).
+			ExpectContains("for must be a duration between 1s and 720h0m0s")
+		admin.POST("/api/job/999/snooze", map[string]string{"for": "1h"}).ExpectStatus(404)
+		admin.GET("/api/job/1/snooze").ExpectStatus(405)
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"}).
+			POST("/api/job/1/snooze", map[string]string{"for": "1h"}).
+			ExpectStatus(401)
+	})
+
+	t.Run("Escaping", func(t *testing.T) {
+		var job model.Job
+		admin.POST("/api/job", map[string]interface{}{
+			"job_name": `nightly "full"`, "host": `db\2`, "automatic_failure_threshold": 3600,
+		}).ExpectStatus(201).ExpectJSON(&job)
+		admin.POST(fmt.Sprintf("/api/job/%d/snooze", job.ID), map[string]string{"for": "1h"}).ExpectStatus(200)
+
+		assert.Contains(t, metrics(), `cronjob_snoozed_until_timestamp{job_name="nightly \"full\"",host="db\\2"} `)
+	})
+}
+
+func TestJobSnoozeCLI(t *testing.T) {
+	buildBinary(t)
+
+	cliTest := testutil.NewCLITest(t)
+	cliTest.CreateDefaultTestConfig()
+
+	cliTest.RunCommand("--config", cliTest.ConfigFile, "job", "add", "--name", "backup", "--host", "db1").ExpectSuccess()
+
+	cliTest.RunCommand("--config", cliTest.ConfigFile, "job", "snooze", "1", "--for", "90m").
+		ExpectSuccess().
+		ExpectStdoutContains("Job ID 1 ('backup@db1') snoozed until")
+	cliTest.RunCommand("--config", cliTest.ConfigFile, "job", "show", "1").
+		ExpectSuccess().
+		ExpectStdoutContains("Snoozed Until:")
+
+	cliTest.RunCommand("--config", cliTest.ConfigFile, "job", "snooze", "1", "--clear").
+		ExpectSuccess().
+		ExpectStdoutContains("is no longer snoozed")
+	cliTest.RunCommand("--config", cliTest.ConfigFile, "job", "snooze", "1").ExpectFailure()
+}
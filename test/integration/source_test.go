@@ -0,0 +1,135 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultSource(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	// The agent's clock runs a day behind; the source fields it sends are
+	// ignored
+	skewed := time.Now().UTC().Add(-24 * time.Hour).Truncate(time.Second)
+	before := time.Now().UTC().Add(-time.Second)
+	testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{
+			"X-API-Key":        "cm_test_backup_key",
+			"Content-Type":     "application/json",
+			"User-Agent":       "cronmetrics/v1.2.3",
+			"X-Client-Version": "v1.2.3",
+		}).
+		POST("/api/job-result", map[string]interface{}{
+			"job_name": "backup", "host": "db1", "status": "success",
+			"timestamp":   skewed.Format(time.RFC3339),
+			"source_ip":   "10.0.0.1",
+			"received_at": skewed.Format(time.RFC3339),
+		}).
+		ExpectStatus(201)
+
+	var page model.JobResultPage
+	testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders()).
+		GET("/api/job/1/results").ExpectStatus(200).ExpectJSON(&page)
+	require.Len(t, page.Results, 1)
+
+	result := page.Results[0]
+	assert.Equal(t, "127.0.0.1", result.SourceIP)
+	assert.Equal(t, "cronmetrics/v1.2.3", result.UserAgent)
+	assert.Equal(t, "v1.2.3", result.ClientVersion)
+	assert.True(t, result.Timestamp.Equal(skewed))
+	require.NotNil(t, result.ReceivedAt)
+	assert.False(t, result.ReceivedAt.Before(before), "received_at is the server's clock")
+
+	t.Run("Dashboard", func(t *testing.T) {
+		body := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders()).
+			GET("/dashboard/jobs/1").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, "127.0.0.1")
+		assert.Contains(t, body, "cronmetrics/v1.2.3")
+		assert.Contains(t, body, "clock skewed")
+	})
+}
+
+func TestClockSkew(t *testing.T) {
+	submit := func(t *testing.T, server *testutil.TestServer, key, name, host string, timestamp time.Time) {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": key, "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{
+				"job_name": name, "host": host, "status": "success",
+				"timestamp": timestamp.UTC().Format(time.RFC3339),
+			}).
+			ExpectStatus(201)
+	}
+	latest := func(t *testing.T, server *testutil.TestServer, name, host string) *model.JobResult {
+		results, err := server.Database.GetJobResultStore().GetJobResults(name, host, 1)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		return results[0]
+	}
+
+	t.Run("Flagged", func(t *testing.T) {
+		server := testutil.NewTestServer(t)
+		defer server.Close()
+		server.SeedTestData()
+
+		// db1 runs ten minutes ahead, web1 is in sync
+		ahead := time.Now().Add(10 * time.Minute)
+		submit(t, server, "cm_test_backup_key", "backup", "db1", ahead)
+		submit(t, server, "cm_test_logrotation_key", "log-rotation", "web1", time.Now())
+
+		result := latest(t, server, "backup", "db1")
+		assert.True(t, result.ClockSkewed)
+		require.NotNil(t, result.ClockSkew)
+		assert.InDelta(t, 600, *result.ClockSkew, 2)
+		assert.True(t, result.Timestamp.Equal(ahead.Truncate(time.Second)), "timestamps are kept without correction")
+
+		result = latest(t, server, "log-rotation", "web1")
+		assert.False(t, result.ClockSkewed)
+		require.NotNil(t, result.ClockSkew)
+		assert.InDelta(t, 0, *result.ClockSkew, 2)
+
+		metrics := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+		assert.Contains(t, metrics, "# TYPE cronjob_host_clock_skew_seconds gauge")
+		assert.Regexp(t, `cronjob_host_clock_skew_seconds\{host="db1"\} (59\d|600)`, metrics)
+		assert.Contains(t, metrics, `cronjob_host_clock_skew_seconds{host="web1"}`)
+		assert.NotContains(t, metrics, `cronjob_host_clock_skew_seconds{host="app1"}`, "app1 sent no result")
+
+		filtered := testutil.NewHTTPClient(t, server.URL()).GET("/metrics?host=web1").ExpectStatus(200).BodyString()
+		assert.NotContains(t, filtered, `cronjob_host_clock_skew_seconds{host="db1"}`)
+
+		// Hosts are escaped
+		var quoted model.Job
+		testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders()).
+			POST("/api/job", map[string]interface{}{"job_name": "backup", "host": `db"2`}).
+			ExpectStatus(201).
+			ExpectJSON(&quoted)
+		submit(t, server, quoted.ApiKey, "backup", `db"2`, time.Now())
+		metrics = testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+		assert.Contains(t, metrics, `cronjob_host_clock_skew_seconds{host="db\"2"}`)
+	})
+
+	t.Run("Corrected", func(t *testing.T) {
+		server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+			cfg.ClockSkew.Correct = true
+		})
+		defer server.Close()
+		server.SeedTestData()
+
+		before := time.Now().UTC().Add(-time.Second)
+		submit(t, server, "cm_test_backup_key", "backup", "db1", time.Now().Add(-2*time.Hour))
+
+		result := latest(t, server, "backup", "db1")
+		assert.True(t, result.ClockSkewed)
+		require.NotNil(t, result.ReceivedAt)
+		assert.True(t, result.Timestamp.Equal(*result.ReceivedAt), "recorded at the time it was received")
+		assert.False(t, result.Timestamp.Before(before))
+		assert.InDelta(t, -7200, *result.ClockSkew, 2)
+	})
+}
@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/api"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrictJSON(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+
+	t.Run("UnknownFields", func(t *testing.T) {
+		admin.POST("/api/job", map[string]interface{}{
+			"job_name":          "typo",
+			"host":              "web1",
+			"treshold":          600,
+			"automatic_failure": 3600,
+		}).
+			ExpectStatus(400).
+			ExpectContains(`unknown fields \"automatic_failure\", \"treshold\"`)
+
+		var found api.JobExistsResponse
+		admin.GET("/api/job/exists?name=typo&host=web1").ExpectStatus(200).ExpectJSON(&found)
+		assert.False(t, found.Exists, "a refused job is not created")
+	})
+
+	t.Run("NestedFields", func(t *testing.T) {
+		admin.POST("/api/job/reconcile", map[string]interface{}{
+			"jobs": []map[string]interface{}{
+				{"job_name": "ok", "host": "h1"},
+				{"job_name": "typo", "host": "h1", "lables": map[string]string{"env": "prod"}},
+			},
+		}).
+			ExpectStatus(400).
+			ExpectContains(`unknown field \"jobs[1].lables\"`)
+	})
+
+	t.Run("KnownFields", func(t *testing.T) {
+		// Keys match case-insensitively, as encoding/json decodes them, and
+		// maps take any key
+		admin.POST("/api/job", map[string]interface{}{
+			"Job_Name": "strict",
+			"host":     "web1",
+			"labels":   map[string]string{"anything": "goes"},
+		}).ExpectStatus(201)
+	})
+
+	t.Run("JobResult", func(t *testing.T) {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"}).
+			POST("/api/job-result", map[string]interface{}{
+				"job_name": "backup",
+				"host":     "db1",
+				"status":   "success",
+				"message":  "not a result field",
+			}).
+			ExpectStatus(400).
+			ExpectContains(`unknown field \"message\"`)
+
+		var page model.RejectionPage
+		admin.GET("/api/result-rejections").ExpectStatus(200).ExpectJSON(&page)
+		if assert.Len(t, page.Rejections, 1) {
+			assert.Equal(t, model.RejectionInvalidPayload, page.Rejections[0].Reason)
+		}
+	})
+}
+
+func TestStrictJSONDisabled(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Server.StrictJSON = false
+	})
+	defer server.Close()
+
+	var job model.Job
+	testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(server.AdminHeaders()).
+		POST("/api/job", map[string]interface{}{
+			"job_name": "lenient",
+			"host":     "web1",
+			"treshold": 600,
+		}).
+		ExpectStatus(201).
+		ExpectJSON(&job)
+
+	assert.Equal(t, "lenient", job.Name)
+}
@@ -0,0 +1,108 @@
+package integration
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultContentType(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	job := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"})
+	result := map[string]interface{}{"job_name": "backup", "host": "db1", "status": "success"}
+
+	t.Run("JSON", func(t *testing.T) {
+		job.POST("/api/job-result", result).ExpectStatus(201)
+
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json; charset=utf-8"}).
+			POST("/api/job-result", result).
+			ExpectStatus(201)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "text/plain"}).
+			POST("/api/job-result", result).
+			ExpectStatus(415).
+			ExpectHeader("Accept-Post", "application/json").
+			ExpectContains(`unsupported content type \"text/plain\"`)
+
+		// Form submissions are off by default
+		job.PostForm("/api/job-result", url.Values{"job_name": {"backup"}, "host": {"db1"}, "status": {"success"}}).
+			ExpectStatus(415)
+
+		var page model.RejectionPage
+		testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders()).
+			GET("/api/result-rejections").ExpectStatus(200).ExpectJSON(&page)
+		require.Len(t, page.Rejections, 2)
+		assert.Equal(t, model.RejectionInvalidPayload, page.Rejections[0].Reason)
+	})
+}
+
+func TestFormResults(t *testing.T) {
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Server.FormResults = true
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	job := testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"})
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	timestamp := time.Now().Add(-time.Minute).Truncate(time.Second).UTC()
+
+	job.PostForm("/api/job-result", url.Values{
+		"job_name":  {"backup"},
+		"host":      {"db1"},
+		"status":    {"success"},
+		"duration":  {"42"},
+		"output":    {"done"},
+		"label.env": {"prod"},
+		"timestamp": {fmt.Sprint(timestamp.Unix())},
+	}).ExpectStatus(201)
+
+	// Query parameters with an empty body, as `wget --post-data ''` sends
+	job.POST("/api/job-result?job_name=backup&host=db1&status=failure", nil).ExpectStatus(201)
+
+	var page model.JobResultPage
+	admin.GET("/api/job/1/results").ExpectStatus(200).ExpectJSON(&page)
+	require.Len(t, page.Results, 2)
+	assert.Equal(t, "failure", page.Results[0].Status)
+	form := page.Results[1]
+	assert.Equal(t, "success", form.Status)
+	assert.Equal(t, 42, form.Duration)
+	assert.Equal(t, "done", form.Output)
+	assert.Equal(t, "prod", form.Labels["env"])
+	assert.True(t, timestamp.Equal(form.Timestamp), "got %s", form.Timestamp)
+
+	t.Run("Invalid", func(t *testing.T) {
+		job.PostForm("/api/job-result", url.Values{"job_name": {"backup"}, "host": {"db1"}, "status": {"success"}, "api_key": {"x"}}).
+			ExpectStatus(400).
+			ExpectContains(`unknown field \"api_key\"`)
+		job.PostForm("/api/job-result", url.Values{"job_name": {"backup"}, "host": {"db1"}, "status": {"success"}, "duration": {"2m"}}).
+			ExpectStatus(400).
+			ExpectContains("duration must be a number of seconds")
+		job.POST("/api/job-result?job_name=backup&host=db1&status=success&timestamp=yesterday", nil).
+			ExpectStatus(400).
+			ExpectContains("timestamp must be RFC 3339 or Unix seconds")
+
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "text/csv"}).
+			POST("/api/job-result", "backup,db1,success").
+			ExpectStatus(415).
+			ExpectHeader("Accept-Post", "application/json, application/x-www-form-urlencoded").
+			ExpectContains("send application/json or application/x-www-form-urlencoded")
+	})
+}
@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/ingest"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appendLines(t *testing.T, path string, lines ...string) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	defer file.Close()
+
+	for _, line := range lines {
+		_, err := file.WriteString(line + "\n")
+		require.NoError(t, err)
+	}
+}
+
+func TestSyslogIngestion(t *testing.T) {
+	recorder, db := newIngestRecorder(t)
+	jobStore := db.GetJobStore()
+	require.NoError(t, jobStore.CreateJob(&model.Job{Name: "logrotate", Host: "db1", ApiKey: "cm_logrotate_key", AutomaticFailureThreshold: 3600, Status: "active"}))
+	require.NoError(t, jobStore.CreateJob(&model.Job{Name: "reports", Host: "db1", ApiKey: "cm_reports_key", AutomaticFailureThreshold: 3600, Status: "active",
+		Labels: map[string]string{"cron_command": "php artisan schedule:run"}}))
+
+	path := filepath.Join(t.TempDir(), "syslog")
+	// Existing history is not replayed
+	require.NoError(t, os.WriteFile(path, []byte("Oct 16 09:00:01 db1 CRON[100]: (root) CMD (/usr/local/bin/backup.sh)\n"+
+		"Oct 16 09:00:09 db1 CRON[100]: (root) END (/usr/local/bin/backup.sh)\n"), 0o644))
+
+	tailer, err := ingest.NewSyslogTailer(&config.SyslogConfig{Source: path, MaxRuntime: 2}, jobStore, recorder)
+	require.NoError(t, err)
+	defer tailer.Close()
+
+	results := func(name, host string) []*model.JobResult {
+		results, err := db.GetJobResultStore().GetJobResults(name, host, 10)
+		require.NoError(t, err)
+		return results
+	}
+
+	t.Run("successful run matched by program name", func(t *testing.T) {
+		appendLines(t, path,
+			"2025-10-16T10:00:01.000000+00:00 db1.example.com CRON[200]: (root) CMD (/usr/local/bin/backup.sh --full >/dev/null 2>&1)",
+			"2025-10-16T10:00:01.000000+00:00 db1.example.com systemd[1]: Started session.",
+			"2025-10-16T10:00:31.000000+00:00 db1.example.com CRON[200]: (root) END (/usr/local/bin/backup.sh --full >/dev/null 2>&1)")
+
+		require.Eventually(t, func() bool { return len(results("backup", "db1")) == 1 }, 5*time.Second, 20*time.Millisecond)
+		result := results("backup", "db1")[0]
+		assert.Equal(t, "success", result.Status)
+		assert.Equal(t, 30, result.Duration)
+		assert.Contains(t, result.Output, "/usr/local/bin/backup.sh --full")
+	})
+
+	t.Run("failed run matched by label", func(t *testing.T) {
+		appendLines(t, path,
+			"Oct 16 10:05:01 db1 CROND[300]: (www-data) CMD (cd /srv/app && php artisan schedule:run)",
+			"Oct 16 10:05:03 db1 CROND[300]: (CRON) error (grandchild #301 failed with exit status 2)",
+			"Oct 16 10:05:03 db1 CROND[300]: (www-data) CMDEND (cd /srv/app && php artisan schedule:run)")
+
+		require.Eventually(t, func() bool { return len(results("reports", "db1")) == 1 }, 5*time.Second, 20*time.Millisecond)
+		result := results("reports", "db1")[0]
+		assert.Equal(t, "failure", result.Status)
+		assert.Contains(t, result.Output, "exit status 2")
+	})
+
+	t.Run("run without end entry is recorded after max runtime", func(t *testing.T) {
+		appendLines(t, path, "2025-10-16T10:10:01+00:00 db1 CRON[400]: (root) CMD (test -x /usr/sbin/logrotate && /usr/sbin/logrotate /etc/logrotate.conf)")
+
+		require.Eventually(t, func() bool { return len(results("logrotate", "db1")) == 1 }, 5*time.Second, 20*time.Millisecond)
+		assert.Equal(t, "success", results("logrotate", "db1")[0].Status)
+	})
+
+	t.Run("unmatched and other hosts are ignored", func(t *testing.T) {
+		appendLines(t, path,
+			"Oct 16 10:15:01 db3 CRON[500]: (root) CMD (/usr/local/bin/backup.sh)",
+			"Oct 16 10:15:02 db3 CRON[500]: (root) END (/usr/local/bin/backup.sh)",
+			"Oct 16 10:15:01 db1 CRON[501]: (root) CMD (/usr/bin/unknown)",
+			"Oct 16 10:15:02 db1 CRON[501]: (root) END (/usr/bin/unknown)",
+			// Ambiguous between backup and logrotate
+			"Oct 16 10:15:01 db1 CRON[502]: (root) CMD (/usr/local/bin/backup.sh && /usr/sbin/logrotate)",
+			"Oct 16 10:15:02 db1 CRON[502]: (root) END (/usr/local/bin/backup.sh && /usr/sbin/logrotate)",
+			// Only jobs of the logging host are candidates
+			"Oct 16 10:15:03 db2 CRON[503]: (root) CMD (/opt/backup)",
+			"Oct 16 10:15:04 db2 CRON[503]: (root) END (/opt/backup)")
+
+		require.Eventually(t, func() bool { return len(results("backup", "db2")) == 1 }, 5*time.Second, 20*time.Millisecond)
+		assert.Equal(t, 4, db.CountJobResults())
+	})
+
+	t.Run("rotated file is followed", func(t *testing.T) {
+		require.NoError(t, os.Rename(path, path+".1"))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+		time.Sleep(1500 * time.Millisecond)
+
+		appendLines(t, path,
+			"Oct 16 10:20:01 db1 CRON[600]: (root) CMD (/usr/local/bin/backup.sh)",
+			"Oct 16 10:20:02 db1 CRON[600]: (root) END (/usr/local/bin/backup.sh)")
+
+		require.Eventually(t, func() bool { return len(results("backup", "db1")) == 2 }, 5*time.Second, 20*time.Millisecond)
+	})
+}
+
+func TestSyslogIngestionConfig(t *testing.T) {
+	recorder, db := newIngestRecorder(t)
+
+	_, err := ingest.NewSyslogTailer(&config.SyslogConfig{Source: filepath.Join(t.TempDir(), "missing"), MaxRuntime: 60}, db.GetJobStore(), recorder)
+	assert.Error(t, err)
+}
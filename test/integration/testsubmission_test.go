@@ -0,0 +1,164 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/api"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobTestSubmission(t *testing.T) {
+	var mu sync.Mutex
+	issues := map[string][]string{} // Titles by repository
+	tracker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var issue struct {
+			Title string `json:"title"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&issue)
+
+		mu.Lock()
+		issues[r.URL.Path] = append(issues[r.URL.Path], issue.Title)
+		number := len(issues[r.URL.Path])
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"number": number, "html_url": "https://github.com/issues/" + strconv.Itoa(number)})
+	}))
+	defer tracker.Close()
+	titles := func(path string) []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), issues[path]...)
+	}
+
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Tickets = config.TicketsConfig{
+			Enabled:          true,
+			FailureThreshold: 1,
+			TeamLabel:        "team",
+			Teams: map[string]config.TicketTracker{
+				"default": {Provider: "github", URL: tracker.URL, Repository: "org/infra", Token: "gh-token"},
+				"qa":      {Provider: "github", URL: tracker.URL, Repository: "org/qa", Token: "gh-token"},
+			},
+			TestTeam: "qa",
+		}
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	submit := func(result map[string]interface{}) {
+		result["job_name"], result["host"] = "backup", "db1"
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"}).
+			POST("/api/job-result", result).
+			ExpectStatus(201)
+	}
+	results := func() []*model.JobResult {
+		var page model.JobResultPage
+		admin.GET("/api/job/1/results").ExpectStatus(200).ExpectJSON(&page)
+		return page.Results
+	}
+	submit(map[string]interface{}{"status": "success"})
+	job, err := server.Database.GetJobStore().GetJob("backup", "db1")
+	require.NoError(t, err)
+
+	var report api.TestSubmissionResponse
+	admin.POST("/api/job/1/test", nil).ExpectStatus(200).ExpectJSON(&report)
+	assert.True(t, report.Passed)
+	assert.False(t, report.Kept)
+
+	stages := make(map[string]string)
+	for _, stage := range report.Stages {
+		stages[stage.Name] = stage.Status
+	}
+	assert.Equal(t, map[string]string{
+		"auth": "passed", "storage": "passed", "metrics": "passed", "notification": "passed", "cleanup": "passed",
+	}, stages)
+
+	require.NotNil(t, report.Result)
+	assert.True(t, report.Result.Test)
+	assert.Equal(t, "success", report.Result.Status)
+	require.NotNil(t, report.Metrics)
+	assert.Equal(t, "success", report.Metrics.Reason, "evaluated with the test result")
+	require.NotNil(t, report.Notification)
+	assert.Equal(t, "qa", report.Notification.Team)
+	assert.Equal(t, []string{"[test] backup on db1"}, titles("/repos/org/qa/issues"))
+
+	// The job's history, last report and acknowledgements are untouched
+	assert.Len(t, results(), 1)
+	after, err := server.Database.GetJobStore().GetJob("backup", "db1")
+	require.NoError(t, err)
+	assert.True(t, job.LastReportedAt.Equal(after.LastReportedAt))
+	var acks []model.Acknowledgement
+	admin.GET("/api/job/1/acknowledgements").ExpectStatus(200).ExpectJSON(&acks)
+	assert.Empty(t, acks)
+
+	t.Run("Keep", func(t *testing.T) {
+		admin.POST("/api/job/1/test", map[string]interface{}{"status": "failure", "output": "drill", "keep": true}).
+			ExpectStatus(200).
+			ExpectJSON(&report)
+		assert.True(t, report.Passed)
+		assert.True(t, report.Kept)
+		assert.Equal(t, "failure", report.Metrics.Reason)
+
+		kept := results()
+		require.Len(t, kept, 2)
+		assert.True(t, kept[0].Test)
+		assert.Equal(t, "drill", kept[0].Output)
+
+		// The failed test opens no issue; the real failure after it does
+		submit(map[string]interface{}{"status": "failure"})
+		require.Eventually(t, func() bool { return len(titles("/repos/org/infra/issues")) > 0 }, 5*time.Second, 10*time.Millisecond)
+		assert.Len(t, titles("/repos/org/infra/issues"), 1)
+	})
+
+	t.Run("Forged", func(t *testing.T) {
+		// Clients cannot flag their results as tests
+		submit(map[string]interface{}{"status": "success", "test": true})
+		assert.False(t, results()[0].Test)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		admin.POST("/api/job/1/test", map[string]interface{}{"status": "started"}).
+			ExpectStatus(400).
+			ExpectContains("status must be")
+		admin.POST("/api/job/999/test", nil).ExpectStatus(404)
+		admin.GET("/api/job/1/test").ExpectStatus(405)
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key"}).
+			POST("/api/job/1/test", nil).
+			ExpectStatus(401)
+	})
+}
+
+func TestJobTestSubmissionWithoutTickets(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	var report api.TestSubmissionResponse
+	testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders()).
+		POST("/api/job/2/test", map[string]interface{}{"keep": true}).
+		ExpectStatus(200).
+		ExpectJSON(&report)
+	assert.True(t, report.Passed)
+	require.Len(t, report.Stages, 4)
+	assert.Equal(t, api.TestStage{Name: "notification", Status: "skipped", Detail: "tickets are disabled"}, report.Stages[3])
+	assert.Nil(t, report.Notification)
+
+	testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders()).WithCookies().
+		GET("/dashboard/jobs/2").
+		ExpectStatus(200).
+		ExpectContains("Synthetic result of a test submission")
+}
@@ -0,0 +1,300 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/config"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcknowledgementTickets(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+
+	t.Run("API", func(t *testing.T) {
+		var ack model.Acknowledgement
+		admin.POST("/api/job/1/acknowledgements", map[string]interface{}{
+			"author": "alice", "ticket_id": "OPS-123", "ticket_url": "https://jira.example.com/browse/OPS-123",
+		}).ExpectStatus(201).ExpectJSON(&ack)
+		assert.Equal(t, "OPS-123", ack.TicketID)
+		assert.Equal(t, "alice", ack.Author)
+
+		var acks []model.Acknowledgement
+		admin.GET("/api/job/1/acknowledgements").ExpectStatus(200).ExpectJSON(&acks)
+		require.Len(t, acks, 1)
+		assert.Equal(t, "https://jira.example.com/browse/OPS-123", acks[0].TicketURL)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		admin.POST("/api/job/1/acknowledgements", map[string]interface{}{"ticket_url": "javascript:alert(1)"}).
+			ExpectStatus(400).
+			ExpectContains("ticket_url must be an http or https URL")
+		admin.POST("/api/job/999/acknowledgements", map[string]interface{}{}).ExpectStatus(404)
+		admin.DELETE("/api/job/1/acknowledgements").ExpectStatus(405)
+		testutil.NewHTTPClient(t, server.URL()).GET("/api/job/1/acknowledgements").ExpectStatus(401)
+	})
+
+	t.Run("Dashboard", func(t *testing.T) {
+		dashboard := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders()).WithCookies()
+		dashboard.PostForm("/dashboard/jobs/2/acknowledge", url.Values{
+			"ticket_id": {"org/infra#7"}, "ticket_url": {"https://github.com/org/infra/issues/7"},
+		}).ExpectStatus(200).ExpectContains("Job acknowledged")
+
+		body := dashboard.GET("/dashboard/jobs/2").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, `href="https://github.com/org/infra/issues/7"`)
+		assert.Contains(t, body, "org/infra#7")
+	})
+}
+
+func TestTicketCreation(t *testing.T) {
+	var mu sync.Mutex
+	var issues []map[string]interface{}
+	tracker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/org/infra/issues" || r.Header.Get("Authorization") != "Bearer gh-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var issue map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&issue)
+
+		mu.Lock()
+		issues = append(issues, issue)
+		number := len(issues)
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"number": number, "html_url": "https://github.com/org/infra/issues/" + strconv.Itoa(number),
+		})
+	}))
+	defer tracker.Close()
+
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Tickets = config.TicketsConfig{
+			Enabled:          true,
+			FailureThreshold: 2,
+			TeamLabel:        "team",
+			Teams: map[string]config.TicketTracker{
+				"default": {Provider: "github", URL: tracker.URL, Repository: "org/infra", Labels: []string{"cron"}, Token: "gh-token"},
+			},
+		}
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	submit := func(status string) {
+		testutil.NewHTTPClient(t, server.URL()).
+			WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json"}).
+			POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": status, "output": "disk full"}).
+			ExpectStatus(201)
+	}
+	ticketAcks := func() []model.Acknowledgement {
+		var acks []model.Acknowledgement
+		admin.GET("/api/job/1/acknowledgements").ExpectStatus(200).ExpectJSON(&acks)
+		return acks
+	}
+
+	// Issues start with the first lines of the job's runbook
+	var runbook []string
+	for step := 1; step <= 20; step++ {
+		runbook = append(runbook, fmt.Sprintf("%d. step %d", step, step))
+	}
+	admin.PUT("/api/job/1", map[string]interface{}{"description": strings.Join(runbook, "\n")}).ExpectStatus(200)
+
+	// One failure is below the threshold, the second opens an issue
+	submit("failure")
+	submit("failure")
+	require.Eventually(t, func() bool { return len(ticketAcks()) == 1 }, 5*time.Second, 50*time.Millisecond)
+
+	acks := ticketAcks()
+	assert.Equal(t, "tickets", acks[0].Author)
+	assert.Equal(t, "org/infra#1", acks[0].TicketID)
+	assert.Equal(t, "https://github.com/org/infra/issues/1", acks[0].TicketURL)
+
+	mu.Lock()
+	require.Len(t, issues, 1)
+	assert.Equal(t, "Cron job backup on db1 is failing", issues[0]["title"])
+	assert.Contains(t, issues[0]["body"], "disk full")
+	assert.Contains(t, issues[0]["body"], "Runbook:\n\n1. step 1\n")
+	assert.Contains(t, issues[0]["body"], "15. step 15\n...\n")
+	assert.NotContains(t, issues[0]["body"], "step 16")
+	assert.Equal(t, []interface{}{"cron"}, issues[0]["labels"])
+	mu.Unlock()
+
+	// Further failures of the same streak do not open more issues
+	submit("failure")
+
+	// A new streak opens a new issue, unless someone acknowledged it first
+	submit("success")
+	submit("failure")
+	admin.POST("/api/job/1/acknowledgements", map[string]interface{}{"author": "alice"}).ExpectStatus(201)
+	submit("failure")
+
+	submit("success")
+	submit("failure")
+	submit("failure")
+	require.Eventually(t, func() bool { return len(ticketAcks()) == 3 }, 5*time.Second, 50*time.Millisecond)
+
+	mu.Lock()
+	assert.Len(t, issues, 2)
+	mu.Unlock()
+}
+
+func TestNotificationDeliveries(t *testing.T) {
+	var mu sync.Mutex
+	failing := true
+	tracker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("tracker under maintenance"))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"number": 3, "html_url": "https://github.com/org/infra/issues/3"})
+	}))
+	defer tracker.Close()
+
+	server := testutil.NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Tickets = config.TicketsConfig{
+			Enabled:          true,
+			FailureThreshold: 1,
+			TeamLabel:        "team",
+			Teams: map[string]config.TicketTracker{
+				"default": {Provider: "github", URL: tracker.URL, Repository: "org/infra", Token: "gh-token"},
+			},
+		}
+		cfg.Dashboard = config.DashboardConfig{Enabled: true, Path: "/dashboard", Title: "Test Dashboard", RefreshInterval: 5, AuthRequired: true, PageSize: 25, SSEHeartbeat: 30}
+	})
+	defer server.Close()
+	server.SeedTestData()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	listDeliveries := func(query string) []*model.NotificationDelivery {
+		var page model.NotificationPage
+		admin.GET("/api/notifications" + query).ExpectStatus(200).ExpectJSON(&page)
+		return page.Deliveries
+	}
+
+	testutil.NewHTTPClient(t, server.URL()).
+		WithHeaders(map[string]string{"X-API-Key": "cm_test_backup_key", "Content-Type": "application/json"}).
+		POST("/api/job-result", map[string]interface{}{"job_name": "backup", "host": "db1", "status": "failure"}).
+		ExpectStatus(201)
+	require.Eventually(t, func() bool { return len(listDeliveries("")) == 1 }, 5*time.Second, 50*time.Millisecond)
+
+	failed := listDeliveries("")[0]
+
+	t.Run("FailedDelivery", func(t *testing.T) {
+		assert.Equal(t, model.DeliveryFailed, failed.Status)
+		assert.Equal(t, "backup", failed.JobName)
+		assert.Equal(t, "db1", failed.Host)
+		assert.Equal(t, "github", failed.Channel)
+		assert.Equal(t, "default", failed.Team)
+		assert.Equal(t, "org/infra", failed.Target)
+		assert.Contains(t, failed.Error, "tracker under maintenance")
+		assert.Equal(t, "Cron job backup on db1 is failing", failed.Subject)
+		assert.Empty(t, failed.Reference)
+
+		// No ticket, so the failure is not acknowledged
+		var acks []model.Acknowledgement
+		admin.GET("/api/job/1/acknowledgements").ExpectStatus(200).ExpectJSON(&acks)
+		assert.Empty(t, acks)
+	})
+
+	t.Run("Redeliver", func(t *testing.T) {
+		var retried model.NotificationDelivery
+		admin.POST(fmt.Sprintf("/api/notifications/%d/redeliver", failed.ID), nil).ExpectStatus(502).ExpectJSON(&retried)
+		assert.Equal(t, model.DeliveryFailed, retried.Status)
+
+		mu.Lock()
+		failing = false
+		mu.Unlock()
+
+		var delivered model.NotificationDelivery
+		admin.POST(fmt.Sprintf("/api/notifications/%d/redeliver", failed.ID), nil).ExpectStatus(201).ExpectJSON(&delivered)
+		assert.Equal(t, model.DeliveryDelivered, delivered.Status)
+		assert.Equal(t, "org/infra#3", delivered.Reference)
+		assert.Equal(t, "https://github.com/org/infra/issues/3", delivered.URL)
+		require.NotNil(t, delivered.RedeliveryOf)
+		assert.Equal(t, failed.ID, *delivered.RedeliveryOf)
+		assert.Equal(t, failed.Body, delivered.Body)
+
+		var acks []model.Acknowledgement
+		admin.GET("/api/job/1/acknowledgements").ExpectStatus(200).ExpectJSON(&acks)
+		require.Len(t, acks, 1)
+		assert.Equal(t, "org/infra#3", acks[0].TicketID)
+
+		admin.POST("/api/notifications/999/redeliver", nil).ExpectStatus(404)
+		admin.GET(fmt.Sprintf("/api/notifications/%d/redeliver", failed.ID)).ExpectStatus(405)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		assert.Len(t, listDeliveries(""), 3)
+		assert.Len(t, listDeliveries("?status=failed"), 2)
+		assert.Len(t, listDeliveries("?status=delivered&job_name=backup&host=db1"), 1)
+		assert.Empty(t, listDeliveries("?host=web1"))
+
+		var page model.NotificationPage
+		admin.GET("/api/notifications?limit=2").ExpectStatus(200).ExpectJSON(&page)
+		require.Len(t, page.Deliveries, 2)
+		require.NotEmpty(t, page.NextCursor)
+		assert.Len(t, listDeliveries("?cursor="+page.NextCursor), 1)
+
+		admin.GET("/api/notifications?status=pending").ExpectStatus(400)
+		admin.GET("/api/notifications?cursor=bogus").ExpectStatus(400)
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		body := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, `cronmetrics_notification_deliveries_total{channel="github",status="delivered"} 1`)
+		assert.Contains(t, body, `cronmetrics_notification_deliveries_total{channel="github",status="failed"} 2`)
+		assert.Contains(t, body, `cronmetrics_notification_delivery_seconds_total{channel="github"}`)
+	})
+
+	t.Run("Dashboard", func(t *testing.T) {
+		dashboard := testutil.NewHTTPClient(t, server.URL()).WithCookies().WithHeaders(server.DashboardHeaders())
+		dashboard.GET("/dashboard/admin/notifications").
+			ExpectStatus(200).
+			ExpectContains("Recent Notification Deliveries").
+			ExpectContains("tracker under maintenance").
+			ExpectContains("org/infra#3").
+			ExpectContains(fmt.Sprintf("/admin/notifications/%d/redeliver", failed.ID))
+
+		dashboard.PostForm(fmt.Sprintf("/dashboard/admin/notifications/%d/redeliver", failed.ID), nil).
+			ExpectStatus(200).
+			ExpectContains("Notification redelivered as org/infra#3")
+		dashboard.PostForm("/dashboard/admin/notifications/999/redeliver", nil).ExpectStatus(404)
+
+		dashboard.GET("/dashboard/admin/audit").ExpectStatus(200).ExpectContains("notification.redeliver")
+		assert.Len(t, listDeliveries("?status=delivered"), 2)
+	})
+
+	t.Run("MetricsEscaping", func(t *testing.T) {
+		store := model.NewNotificationStore(server.Database.GetJobStore().DB())
+		require.NoError(t, store.RecordDelivery(&model.NotificationDelivery{
+			JobName: "backup", Host: "db1", Channel: `git"hub`, Target: "org/infra",
+			Status: model.DeliveryDelivered, LatencyMs: 500,
+		}))
+
+		body := testutil.NewHTTPClient(t, server.URL()).GET("/metrics").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, `cronmetrics_notification_deliveries_total{channel="git\"hub",status="delivered"} 1`)
+		assert.Contains(t, body, `cronmetrics_notification_delivery_seconds_total{channel="git\"hub"} 0.5`)
+	})
+}
@@ -0,0 +1,152 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/jaepetto/cron-exporter/pkg/unixsocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnixSocketListener(t *testing.T) {
+	testServer := testutil.NewTestServer(t)
+	require.NoError(t, testServer.Database.GetJobStore().CreateJob(&model.Job{Name: "backup", Host: "db1", ApiKey: ingestAPIKey, AutomaticFailureThreshold: 3600, Status: "active"}))
+	path := filepath.Join(t.TempDir(), "cronmetrics.sock")
+
+	listener, err := unixsocket.Listen(path, 0o660)
+	require.NoError(t, err)
+	server := &http.Server{Handler: testServer.Server.Config.Handler}
+	go func() { _ = server.Serve(listener) }()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSocket)
+	assert.Equal(t, os.FileMode(0o660), info.Mode().Perm())
+
+	client, baseURL := unixsocket.Client(unixsocket.Prefix+path, &http.Client{Timeout: 5 * time.Second})
+	assert.Equal(t, unixsocket.BaseURL, baseURL)
+
+	resp, err := client.Get(baseURL + "/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := json.Marshal(map[string]interface{}{"job_name": "backup", "host": "db1", "status": "success"})
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, baseURL+"/api/job-result", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", ingestAPIKey)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, 1, testServer.Database.CountJobResults())
+
+	t.Run("in use", func(t *testing.T) {
+		_, err := unixsocket.Listen(path, 0o660)
+		assert.ErrorContains(t, err, "in use")
+	})
+
+	require.NoError(t, server.Close())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "socket is removed on close")
+
+	t.Run("stale socket", func(t *testing.T) {
+		stale, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		require.NoError(t, stale.Close())
+
+		listener, err := unixsocket.Listen(path, 0o600)
+		require.NoError(t, err)
+		defer listener.Close()
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+
+	t.Run("not a socket", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(file, []byte("keep me"), 0o644))
+
+		_, err := unixsocket.Listen(file, 0o660)
+		assert.ErrorContains(t, err, "not a socket")
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, "keep me", string(data))
+	})
+
+	t.Run("http URL", func(t *testing.T) {
+		httpClient := &http.Client{}
+		client, baseURL := unixsocket.Client("https://cronmetrics.example.com/", httpClient)
+		assert.Same(t, httpClient, client)
+		assert.Equal(t, "https://cronmetrics.example.com", baseURL)
+	})
+}
+
+func TestServeOnUnixSocket(t *testing.T) {
+	buildBinary(t)
+
+	cliTest := testutil.NewCLITest(t)
+	path := filepath.Join(cliTest.TempDir, "cronmetrics.sock")
+	cliTest.CreateTestConfig(fmt.Sprintf(`
+server:
+  listen: "unix:%s"
+  socket_mode: "0600"
+database:
+  path: "%s"
+security:
+  require_https: false
+  admin_api_keys:
+    - "admin-api-key"
+`, path, cliTest.DBFile))
+
+	server := cliTest.RunBackground("serve")
+	defer server.Stop()
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 10*time.Second, 50*time.Millisecond, "server did not create the socket")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	client, baseURL := unixsocket.Client(unixsocket.Prefix+path, &http.Client{Timeout: 5 * time.Second})
+	resp, err := client.Get(baseURL + "/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, server.Process.Signal(syscall.SIGTERM))
+	server.Wait().ExpectSuccess()
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "socket is removed on shutdown")
+
+	t.Run("invalid config", func(t *testing.T) {
+		cliTest.CreateDefaultTestConfig()
+
+		cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render", "--set", "server.listen=/run/cronmetrics.sock").
+			ExpectFailure().
+			ExpectStderrContains("invalid server listen address")
+
+		cliTest.RunCommand("--config", cliTest.ConfigFile, "config", "render",
+			"--set", "server.listen=unix:"+path, "--set", "server.socket_mode=rw").
+			ExpectFailure().
+			ExpectStderrContains("invalid socket mode")
+	})
+}
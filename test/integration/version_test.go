@@ -0,0 +1,120 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jaepetto/cron-exporter/internal/testutil"
+	"github.com/jaepetto/cron-exporter/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobVersions(t *testing.T) {
+	server := testutil.NewTestServerWithDashboard(t)
+	defer server.Close()
+	server.SeedTestData()
+
+	admin := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.AdminHeaders())
+	versions := func() []*model.JobVersion {
+		var versions []*model.JobVersion
+		admin.GET("/api/job/1/versions").ExpectStatus(200).ExpectJSON(&versions)
+		return versions
+	}
+
+	// A new job has its current version only
+	initial := versions()
+	require.Len(t, initial, 1)
+	assert.Equal(t, 1, initial[0].Version)
+	assert.Nil(t, initial[0].ReplacedAt)
+	assert.Empty(t, initial[0].Changes)
+
+	admin.PUT("/api/job/1", map[string]interface{}{
+		"automatic_failure_threshold": 7200,
+		"labels":                      map[string]string{"env": "prod", "team": "dba"},
+	}).ExpectStatus(200)
+	// Updates that leave the configuration as it is keep no version
+	admin.PUT("/api/job/1", map[string]interface{}{"automatic_failure_threshold": 7200}).ExpectStatus(200)
+	admin.PUT("/api/job/1", map[string]interface{}{"status": "maintenance"}).ExpectStatus(200)
+
+	history := versions()
+	require.Len(t, history, 3)
+	assert.Equal(t, []int{3, 2, 1}, []int{history[0].Version, history[1].Version, history[2].Version})
+	assert.Nil(t, history[0].ReplacedAt, "newest first")
+	assert.Equal(t, []model.FieldChange{{Field: "status", Old: "active", New: "maintenance"}}, history[0].Changes)
+	assert.Equal(t, []model.FieldChange{
+		{Field: "automatic_failure_threshold", Old: "3600", New: "7200"},
+		{Field: "labels", Old: "env=prod, type=backup", New: "env=prod, team=dba"},
+	}, history[1].Changes)
+	require.NotNil(t, history[2].ReplacedAt)
+	assert.Equal(t, 3600, history[2].Config.AutomaticFailureThreshold)
+
+	var version model.JobVersion
+	admin.GET("/api/job/1/versions/2").ExpectStatus(200).ExpectJSON(&version)
+	assert.Equal(t, "dba", version.Config.Labels["team"])
+
+	// Rolling back restores the version as a new one
+	var job model.Job
+	admin.POST("/api/job/1/versions/1/rollback", nil).ExpectStatus(200).ExpectJSON(&job)
+	assert.Equal(t, 3600, job.AutomaticFailureThreshold)
+	assert.Equal(t, "active", job.Status)
+	assert.Equal(t, map[string]string{"env": "prod", "type": "backup"}, job.Labels)
+	assert.Equal(t, "cm_test_backup_key", job.ApiKey, "the API key is not part of the configuration")
+
+	history = versions()
+	require.Len(t, history, 4)
+	assert.ElementsMatch(t, []model.FieldChange{
+		{Field: "automatic_failure_threshold", Old: "7200", New: "3600"},
+		{Field: "labels", Old: "env=prod, team=dba", New: "env=prod, type=backup"},
+		{Field: "status", Old: "maintenance", New: "active"},
+	}, history[0].Changes)
+
+	t.Run("CLI", func(t *testing.T) {
+		// Updates from the CLI keep versions too
+		require.NoError(t, server.Database.GetJobStore().UpdateJob(&model.Job{
+			Name: "backup", Host: "db1", ApiKey: job.ApiKey, AutomaticFailureThreshold: 60,
+			Labels: job.Labels, Status: "active", Type: model.JobTypeStandard, LastReportedAt: job.LastReportedAt,
+		}))
+		assert.Len(t, versions(), 5)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		admin.GET("/api/job/1/versions/42").ExpectStatus(404)
+		admin.GET("/api/job/1/versions/abc").ExpectStatus(400)
+		admin.POST("/api/job/1/versions/42/rollback", nil).ExpectStatus(404)
+		admin.GET("/api/job/1/versions/1/rollback").ExpectStatus(405)
+		admin.POST("/api/job/1/versions", nil).ExpectStatus(405)
+		admin.GET("/api/job/999/versions").ExpectStatus(404)
+		testutil.NewHTTPClient(t, server.URL()).GET("/api/job/1/versions").ExpectStatus(401)
+
+		// Another job took the name and host the version had
+		admin.PUT("/api/job/1", map[string]interface{}{"job_name": "backup-v2"}).ExpectStatus(200)
+		var other model.Job
+		admin.POST("/api/job", map[string]interface{}{"job_name": "backup", "host": "db1"}).ExpectStatus(201).ExpectJSON(&other)
+		admin.POST("/api/job/1/versions/1/rollback", nil).ExpectStatus(409)
+		admin.DELETE(fmt.Sprintf("/api/job/%d", other.ID)).ExpectStatus(204)
+	})
+
+	t.Run("Dashboard", func(t *testing.T) {
+		dashboard := testutil.NewHTTPClient(t, server.URL()).WithHeaders(server.DashboardHeaders()).WithCookies()
+		dashboard.GET("/dashboard/jobs/1").ExpectStatus(200).ExpectContains("/jobs/1/history")
+
+		body := dashboard.GET("/dashboard/jobs/1/history").ExpectStatus(200).BodyString()
+		assert.Contains(t, body, "Version 1")
+		assert.Contains(t, body, "<code>automatic_failure_threshold</code>")
+		assert.Contains(t, body, "Roll Back to Version 3")
+
+		dashboard.PostForm("/dashboard/jobs/1/history/3/rollback", nil).
+			ExpectStatus(200).
+			ExpectContains("rolled back to version 3")
+		restored, err := server.Database.GetJobStore().GetJobByID(1)
+		require.NoError(t, err)
+		assert.Equal(t, "maintenance", restored.Status)
+		assert.Equal(t, "backup", restored.Name)
+
+		dashboard.PostForm("/dashboard/jobs/1/history/42/rollback", nil).
+			ExpectStatus(200).
+			ExpectContains("Version 42 not found")
+		dashboard.GET("/dashboard/admin/audit").ExpectStatus(200).ExpectContains("job.rollback")
+	})
+}